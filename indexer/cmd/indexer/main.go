@@ -37,6 +37,9 @@ func main() {
 		"by account on L1 and L2"
 
 	app.Action = indexer.Main(GitVersion)
+	app.Commands = []cli.Command{
+		indexer.MigrateCommand,
+	}
 	err := app.Run(os.Args)
 	if err != nil {
 		log.Crit("Application failed", "message", err)
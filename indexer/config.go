@@ -37,22 +37,26 @@ type Config struct {
 	// and creating a new batch.
 	PollInterval time.Duration
 
-	// Hostname of the database connection.
+	/* Optional Params */
+
+	// Hostname of the Postgres database connection.
 	DBHost string
 
-	// Port of the database connection.
+	// Port of the Postgres database connection.
 	DBPort uint64
 
-	// Username of the database connection.
+	// Username of the Postgres database connection.
 	DBUser string
 
-	// Password of the database connection.
+	// Password of the Postgres database connection.
 	DBPassword string
 
-	// Database name of the database connection.
+	// Database name of the Postgres database connection.
 	DBName string
 
-	/* Optional Params */
+	// SQLitePath is the path of an embedded SQLite database to use instead
+	// of Postgres, if not empty.
+	SQLitePath string
 
 	// LogLevel is the lowest log level that will be output.
 	LogLevel string
@@ -102,6 +106,30 @@ type Config struct {
 	BedrockL1StandardBridgeAddress common.Address
 
 	BedrockOptimismPortalAddress common.Address
+
+	// EventConfigPath is the path of the config of the contract events to
+	// index. No contract events are indexed if it is empty.
+	EventConfigPath string
+
+	// WebhookConfigPath is the path of the config of the webhooks receiving
+	// bridge events. No webhooks are called if it is empty.
+	WebhookConfigPath string
+
+	// TokenListPath is the path of a token list whose tokens and token pairs
+	// are imported at startup. Nothing is imported if it is empty.
+	TokenListPath string
+
+	// Backfill enables indexing the blocks far behind the head in parallel
+	// chunks.
+	Backfill bool
+
+	// BackfillChunkSize is the number of blocks fetched at once when
+	// backfilling.
+	BackfillChunkSize uint64
+
+	// BackfillParallelism is the number of chunks fetched concurrently when
+	// backfilling.
+	BackfillParallelism uint64
 }
 
 // NewConfig parses the Config from the provided flags or environment variables.
@@ -113,12 +141,13 @@ func NewConfig(ctx *cli.Context) (Config, error) {
 		L1EthRpc:                ctx.GlobalString(flags.L1EthRPCFlag.Name),
 		L2EthRpc:                ctx.GlobalString(flags.L2EthRPCFlag.Name),
 		L1AddressManagerAddress: ctx.GlobalString(flags.L1AddressManagerAddressFlag.Name),
-		DBHost:                  ctx.GlobalString(flags.DBHostFlag.Name),
-		DBPort:                  ctx.GlobalUint64(flags.DBPortFlag.Name),
-		DBUser:                  ctx.GlobalString(flags.DBUserFlag.Name),
-		DBPassword:              ctx.GlobalString(flags.DBPasswordFlag.Name),
-		DBName:                  ctx.GlobalString(flags.DBNameFlag.Name),
 		/* Optional Flags */
+		DBHost:                         ctx.GlobalString(flags.DBHostFlag.Name),
+		DBPort:                         ctx.GlobalUint64(flags.DBPortFlag.Name),
+		DBUser:                         ctx.GlobalString(flags.DBUserFlag.Name),
+		DBPassword:                     ctx.GlobalString(flags.DBPasswordFlag.Name),
+		DBName:                         ctx.GlobalString(flags.DBNameFlag.Name),
+		SQLitePath:                     ctx.GlobalString(flags.SQLitePathFlag.Name),
 		Bedrock:                        ctx.GlobalBool(flags.BedrockFlag.Name),
 		BedrockL1StandardBridgeAddress: common.HexToAddress(ctx.GlobalString(flags.BedrockL1StandardBridgeAddress.Name)),
 		BedrockOptimismPortalAddress:   common.HexToAddress(ctx.GlobalString(flags.BedrockOptimismPortalAddress.Name)),
@@ -134,6 +163,12 @@ func NewConfig(ctx *cli.Context) (Config, error) {
 		RESTPort:                       ctx.GlobalUint64(flags.RESTPortFlag.Name),
 		MetricsHostname:                ctx.GlobalString(flags.MetricsHostnameFlag.Name),
 		MetricsPort:                    ctx.GlobalUint64(flags.MetricsPortFlag.Name),
+		EventConfigPath:                ctx.GlobalString(flags.EventConfigFlag.Name),
+		WebhookConfigPath:              ctx.GlobalString(flags.WebhookConfigFlag.Name),
+		TokenListPath:                  ctx.GlobalString(flags.TokenListFlag.Name),
+		Backfill:                       ctx.GlobalBool(flags.BackfillFlag.Name),
+		BackfillChunkSize:              ctx.GlobalUint64(flags.BackfillChunkSizeFlag.Name),
+		BackfillParallelism:            ctx.GlobalUint64(flags.BackfillParallelismFlag.Name),
 	}
 
 	err := ValidateConfig(&cfg)
@@ -161,5 +196,13 @@ func ValidateConfig(cfg *Config) error {
 		return errors.New("must specify l1 standard bridge and optimism portal addresses in bedrock mode")
 	}
 
+	if cfg.SQLitePath == "" && (cfg.DBHost == "" || cfg.DBPort == 0 || cfg.DBName == "") {
+		return errors.New("must specify the postgres database host, port and name, or a sqlite path")
+	}
+
+	if cfg.Backfill && (cfg.BackfillChunkSize == 0 || cfg.BackfillParallelism == 0) {
+		return errors.New("backfill chunk size and parallelism must be greater than zero")
+	}
+
 	return nil
 }
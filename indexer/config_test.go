@@ -1,6 +1,7 @@
 package indexer_test
 
 import (
+	"errors"
 	"fmt"
 	"testing"
 
@@ -20,6 +21,25 @@ var validateConfigTests = []struct {
 		},
 		expErr: fmt.Errorf("unknown level: unknown"),
 	},
+	{
+		name:   "missing database",
+		cfg:    indexer.Config{},
+		expErr: errors.New("must specify the postgres database host, port and name, or a sqlite path"),
+	},
+	{
+		name: "postgres database",
+		cfg: indexer.Config{
+			DBHost: "localhost",
+			DBPort: 5432,
+			DBName: "indexer",
+		},
+	},
+	{
+		name: "sqlite database",
+		cfg: indexer.Config{
+			SQLitePath: "indexer.db",
+		},
+	},
 }
 
 // TestValidateConfig asserts the behavior of ValidateConfig by testing expected
@@ -0,0 +1,203 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// BackfillCheckpoint is the progress of the backfill of a chain.
+type BackfillCheckpoint struct {
+	// Target is the last block to backfill.
+	Target uint64
+	// Committed is the last block stored by the backfill.
+	Committed BlockLocator
+}
+
+// BackfillChunk is the indexed data of a range of blocks, fetched by a
+// backfill and staged until the blocks before it are stored.
+type BackfillChunk struct {
+	Start uint64
+	End   uint64
+	// ParentHash is the parent hash of the first block, and Hash the hash of
+	// the last block, which link the chunk to its neighbours.
+	ParentHash common.Hash
+	Hash       common.Hash
+	// Data is the encoded indexed data of the blocks.
+	Data []byte
+}
+
+// GetBackfillCheckpoint returns the progress of the backfill of chain, or nil
+// if there is no backfill in progress.
+func (d *Database) GetBackfillCheckpoint(chain string) (*BackfillCheckpoint, error) {
+	const selectBackfillStatement = `
+	SELECT target, committed_number, committed_hash FROM backfills WHERE chain = $1
+	`
+
+	var checkpoint BackfillCheckpoint
+	var hash string
+	err := d.db.QueryRow(selectBackfillStatement, chain).Scan(&checkpoint.Target, &checkpoint.Committed.Number, &hash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	checkpoint.Committed.Hash = common.HexToHash(hash)
+	return &checkpoint, nil
+}
+
+// SetBackfillCheckpoint records the progress of the backfill of chain.
+func (d *Database) SetBackfillCheckpoint(chain string, checkpoint BackfillCheckpoint) error {
+	return txn(d.db, func(tx *sql.Tx) error {
+		return setBackfillCheckpoint(tx, chain, checkpoint)
+	})
+}
+
+func setBackfillCheckpoint(tx *sql.Tx, chain string, checkpoint BackfillCheckpoint) error {
+	const upsertBackfillStatement = `
+	INSERT INTO backfills
+		(chain, target, committed_number, committed_hash)
+	VALUES
+		($1, $2, $3, $4)
+	ON CONFLICT (chain) DO UPDATE SET
+		(target, committed_number, committed_hash) = (EXCLUDED.target, EXCLUDED.committed_number, EXCLUDED.committed_hash)
+	`
+
+	_, err := tx.Exec(
+		upsertBackfillStatement,
+		chain,
+		checkpoint.Target,
+		checkpoint.Committed.Number,
+		checkpoint.Committed.Hash.String(),
+	)
+	return err
+}
+
+// DeleteBackfill deletes the checkpoint and the staged chunks of the backfill
+// of chain.
+func (d *Database) DeleteBackfill(chain string) error {
+	const deleteChunksStatement = `
+	DELETE FROM backfill_chunks WHERE chain = $1
+	`
+	const deleteBackfillStatement = `
+	DELETE FROM backfills WHERE chain = $1
+	`
+
+	return txn(d.db, func(tx *sql.Tx) error {
+		if _, err := tx.Exec(deleteChunksStatement, chain); err != nil {
+			return err
+		}
+		_, err := tx.Exec(deleteBackfillStatement, chain)
+		return err
+	})
+}
+
+// AddBackfillChunk stages a chunk of the backfill of chain, replacing any
+// chunk staged with the same start.
+func (d *Database) AddBackfillChunk(chain string, chunk *BackfillChunk) error {
+	const upsertChunkStatement = `
+	INSERT INTO backfill_chunks
+		(chain, start_number, end_number, parent_hash, hash, data)
+	VALUES
+		($1, $2, $3, $4, $5, $6)
+	ON CONFLICT (chain, start_number) DO UPDATE SET
+		(end_number, parent_hash, hash, data) = (EXCLUDED.end_number, EXCLUDED.parent_hash, EXCLUDED.hash, EXCLUDED.data)
+	`
+
+	return txn(d.db, func(tx *sql.Tx) error {
+		_, err := tx.Exec(
+			upsertChunkStatement,
+			chain,
+			chunk.Start,
+			chunk.End,
+			chunk.ParentHash.String(),
+			chunk.Hash.String(),
+			chunk.Data,
+		)
+		return err
+	})
+}
+
+// GetBackfillChunk returns the staged chunk of the backfill of chain starting
+// at start, or nil if there is none.
+func (d *Database) GetBackfillChunk(chain string, start uint64) (*BackfillChunk, error) {
+	const selectChunkStatement = `
+	SELECT start_number, end_number, parent_hash, hash, data FROM backfill_chunks
+	WHERE chain = $1 AND start_number = $2
+	`
+
+	var chunk BackfillChunk
+	var parentHash, hash string
+	err := d.db.QueryRow(selectChunkStatement, chain, start).Scan(
+		&chunk.Start, &chunk.End, &parentHash, &hash, &chunk.Data,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	chunk.ParentHash = common.HexToHash(parentHash)
+	chunk.Hash = common.HexToHash(hash)
+	return &chunk, nil
+}
+
+// GetBackfillChunkRanges returns the end of the staged chunks of the backfill
+// of chain by their start.
+func (d *Database) GetBackfillChunkRanges(chain string) (map[uint64]uint64, error) {
+	const selectChunkRangesStatement = `
+	SELECT start_number, end_number FROM backfill_chunks WHERE chain = $1
+	`
+
+	ranges := make(map[uint64]uint64)
+	err := txn(d.db, func(tx *sql.Tx) error {
+		rows, err := tx.Query(selectChunkRangesStatement, chain)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var start, end uint64
+			if err := rows.Scan(&start, &end); err != nil {
+				return err
+			}
+			ranges[start] = end
+		}
+
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ranges, nil
+}
+
+// CommitBackfillChunk records that the blocks of the staged chunk starting at
+// start were stored, and unstages it.
+func (d *Database) CommitBackfillChunk(chain string, start uint64, checkpoint BackfillCheckpoint) error {
+	const deleteChunkStatement = `
+	DELETE FROM backfill_chunks WHERE chain = $1 AND start_number = $2
+	`
+
+	return txn(d.db, func(tx *sql.Tx) error {
+		if err := setBackfillCheckpoint(tx, chain, checkpoint); err != nil {
+			return err
+		}
+		_, err := tx.Exec(deleteChunkStatement, chain, start)
+		return err
+	})
+}
+
+// DeleteBackfillChunks unstages the chunks of the backfill of chain, keeping
+// its checkpoint.
+func (d *Database) DeleteBackfillChunks(chain string) error {
+	const deleteChunksStatement = `
+	DELETE FROM backfill_chunks WHERE chain = $1
+	`
+
+	_, err := d.db.Exec(deleteChunksStatement, chain)
+	return err
+}
@@ -0,0 +1,183 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const (
+	ChainL1 = "l1"
+	ChainL2 = "l2"
+)
+
+// ContractEvent is a decoded event of a contract configured for indexing.
+type ContractEvent struct {
+	ContractName    string
+	ContractAddress common.Address
+	EventName       string
+	EventSignature  string
+	// Args are the decoded parameters by name, as JSON values.
+	Args     map[string]interface{}
+	TxHash   common.Hash
+	LogIndex uint
+}
+
+// ContractEventJSON contains ContractEvent data suitable for JSON serialization.
+type ContractEventJSON struct {
+	GUID            string                 `json:"guid"`
+	Chain           string                 `json:"chain"`
+	ContractName    string                 `json:"contractName"`
+	ContractAddress string                 `json:"contractAddress"`
+	EventName       string                 `json:"eventName"`
+	EventSignature  string                 `json:"eventSignature"`
+	Args            map[string]interface{} `json:"args"`
+	BlockHash       string                 `json:"blockHash"`
+	BlockNumber     uint64                 `json:"blockNumber"`
+	BlockTimestamp  uint64                 `json:"blockTimestamp"`
+	TxHash          string                 `json:"transactionHash"`
+	LogIndex        uint64                 `json:"logIndex"`
+}
+
+// ContractEventFilter selects contract events. Empty fields match all events.
+type ContractEventFilter struct {
+	Chain    string
+	Contract *common.Address
+	Event    string
+	// Args are matched against the decoded parameters, with the values
+	// formatted as in ContractEventJSON.
+	Args map[string]string
+}
+
+type PaginatedContractEvents struct {
+	Param  *PaginationParam    `json:"pagination"`
+	Events []ContractEventJSON `json:"items"`
+}
+
+func insertContractEvents(tx *sql.Tx, chain string, blockHash common.Hash, number, timestamp uint64, events []ContractEvent) error {
+	const insertContractEventStatement = `
+	INSERT INTO contract_events
+		(guid, chain, contract_name, contract_address, event_name, event_signature, args, block_hash, block_number, block_timestamp, tx_hash, log_index)
+	VALUES
+		($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	`
+
+	for _, event := range events {
+		args, err := json.Marshal(event.Args)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(
+			insertContractEventStatement,
+			NewGUID(),
+			chain,
+			event.ContractName,
+			event.ContractAddress.String(),
+			event.EventName,
+			event.EventSignature,
+			string(args),
+			blockHash.String(),
+			number,
+			timestamp,
+			event.TxHash.String(),
+			event.LogIndex,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// conditions returns the conditions selecting the events of the filter on a
+// database of the given dialect.
+func (f ContractEventFilter) conditions(d dialect) *conditions {
+	var c conditions
+	if f.Chain != "" {
+		c.add("chain = $%d", f.Chain)
+	}
+	if f.Contract != nil {
+		c.add("contract_address = $%d", f.Contract.String())
+	}
+	if f.Event != "" {
+		c.add("event_name = $%d", f.Event)
+	}
+	if len(f.Args) > 0 && d == sqlite {
+		names := make([]string, 0, len(f.Args))
+		for name := range f.Args {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			c.add("json_extract(args, $%d) = $%d", fmt.Sprintf("$.%q", name), f.Args[name])
+		}
+	} else if len(f.Args) > 0 {
+		// Filtering by containment uses the GIN index of the args.
+		contained, _ := json.Marshal(f.Args)
+		c.add("args @> $%d::jsonb", string(contained))
+	}
+	return &c
+}
+
+// GetContractEvents returns the contract events selected by filter, ordered
+// by block and log index and paginated by the given params.
+func (d *Database) GetContractEvents(filter ContractEventFilter, page PaginationParam) (*PaginatedContractEvents, error) {
+	c := filter.conditions(d.dialect)
+	where, args := c.where(), c.args
+	selectContractEventsStatement := fmt.Sprintf(`
+	SELECT
+		guid, chain, contract_name, contract_address, event_name, event_signature, args,
+		block_hash, block_number, block_timestamp, tx_hash, log_index
+	FROM contract_events
+	%s
+	ORDER BY chain, block_number, log_index LIMIT $%d OFFSET $%d;
+	`, where, len(args)+1, len(args)+2)
+	selectContractEventCountStatement := fmt.Sprintf(`
+	SELECT count(*) FROM contract_events %s;
+	`, where)
+
+	events := []ContractEventJSON{}
+	var count uint64
+	err := txn(d.db, func(tx *sql.Tx) error {
+		rows, err := tx.Query(selectContractEventsStatement, append(args, page.Limit, page.Offset)...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var event ContractEventJSON
+			var eventArgs []byte
+			if err := rows.Scan(
+				&event.GUID, &event.Chain, &event.ContractName, &event.ContractAddress,
+				&event.EventName, &event.EventSignature, &eventArgs,
+				&event.BlockHash, &event.BlockNumber, &event.BlockTimestamp,
+				&event.TxHash, &event.LogIndex,
+			); err != nil {
+				return err
+			}
+			if err := json.Unmarshal(eventArgs, &event.Args); err != nil {
+				return err
+			}
+			events = append(events, event)
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		return tx.QueryRow(selectContractEventCountStatement, args...).Scan(&count)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	page.Total = count
+
+	return &PaginatedContractEvents{
+		Param:  &page,
+		Events: events,
+	}, nil
+}
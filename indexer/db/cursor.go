@@ -0,0 +1,138 @@
+package db
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Cursor locates a row in results ordered by block number, and then by an
+// index within the block such as the log index of deposits.
+type Cursor struct {
+	BlockNumber uint64
+	Index       uint64
+}
+
+// String encodes the cursor as an opaque string.
+func (c Cursor) String() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%d:%d", c.BlockNumber, c.Index)))
+}
+
+// ParseCursor decodes a cursor encoded by Cursor.String.
+func ParseCursor(in string) (Cursor, error) {
+	var c Cursor
+	data, err := base64.RawURLEncoding.DecodeString(in)
+	if err != nil {
+		return c, fmt.Errorf("invalid cursor %q", in)
+	}
+	if _, err := fmt.Sscanf(string(data), "%d:%d", &c.BlockNumber, &c.Index); err != nil {
+		return c, fmt.Errorf("invalid cursor %q", in)
+	}
+	return c, nil
+}
+
+// CursorParam holds the pagination fields of queries paging through results
+// with cursors.
+type CursorParam struct {
+	// First is the maximum number of results.
+	First uint64
+	// After is the cursor of the last result of the previous page, if any.
+	After *Cursor
+}
+
+// TransferFilter selects deposits or withdrawals. Nil fields match all
+// transfers.
+type TransferFilter struct {
+	// Address matches the sender or the recipient.
+	Address *common.Address
+	// Token matches the L1 or the L2 token.
+	Token *common.Address
+	// Since and Until bound the block timestamp, inclusive and exclusive.
+	Since *uint64
+	Until *uint64
+	// BlockHash matches the block of the transfer.
+	BlockHash *common.Hash
+	// Status only applies to withdrawals.
+	Status WithdrawalStatus
+	// MessageHash only applies to deposits.
+	MessageHash *common.Hash
+}
+
+// BlockFilter selects blocks, or the state batches of blocks. Nil fields
+// match all blocks.
+type BlockFilter struct {
+	Hash *common.Hash
+	// Since and Until bound the block timestamp, inclusive and exclusive.
+	Since *uint64
+	Until *uint64
+}
+
+// conditions builds the WHERE clause of a query, numbering its arguments.
+type conditions struct {
+	clauses []string
+	args    []interface{}
+}
+
+// add adds a condition, formatted with the numbers of args. The numbers can
+// be referenced explicitly to use an argument twice, e.g. "a = $%[1]d OR b =
+// $%[1]d".
+func (c *conditions) add(condition string, args ...interface{}) {
+	numbers := make([]interface{}, len(args))
+	for i, arg := range args {
+		c.args = append(c.args, arg)
+		numbers[i] = len(c.args)
+	}
+	c.clauses = append(c.clauses, fmt.Sprintf(condition, numbers...))
+}
+
+// arg adds an argument that isn't part of a condition, such as a limit, and
+// returns its number.
+func (c *conditions) arg(arg interface{}) int {
+	c.args = append(c.args, arg)
+	return len(c.args)
+}
+
+func (c *conditions) where() string {
+	if len(c.clauses) == 0 {
+		return ""
+	}
+	return "WHERE " + strings.Join(c.clauses, " AND ")
+}
+
+// addTransfer adds the conditions of filter on the transfers of table, in
+// the blocks of blocksTable.
+func (c *conditions) addTransfer(filter TransferFilter, table, blocksTable string) {
+	if filter.Address != nil {
+		c.add(fmt.Sprintf("(%[1]s.from_address = $%%[1]d OR %[1]s.to_address = $%%[1]d)", table), filter.Address.String())
+	}
+	if filter.Token != nil {
+		c.add(fmt.Sprintf("(%[1]s.l1_token = $%%[1]d OR %[1]s.l2_token = $%%[1]d)", table), filter.Token.String())
+	}
+	if filter.BlockHash != nil {
+		c.add(table+".block_hash = $%d", filter.BlockHash.String())
+	}
+	c.addBlock(BlockFilter{Since: filter.Since, Until: filter.Until}, blocksTable)
+}
+
+// addBlock adds the conditions of filter on the blocks of table.
+func (c *conditions) addBlock(filter BlockFilter, table string) {
+	if filter.Hash != nil {
+		c.add(table+".hash = $%d", filter.Hash.String())
+	}
+	if filter.Since != nil {
+		c.add(table+".timestamp >= $%d", *filter.Since)
+	}
+	if filter.Until != nil {
+		c.add(table+".timestamp < $%d", *filter.Until)
+	}
+}
+
+// addCursor adds the condition selecting the rows after the cursor of page,
+// given the columns of the block number and of the index.
+func (c *conditions) addCursor(page CursorParam, numberColumn, indexColumn string) {
+	if page.After != nil {
+		c.add(fmt.Sprintf("(%s, %s) > ($%%d, $%%d)", numberColumn, indexColumn), page.After.BlockNumber, page.After.Index)
+	}
+}
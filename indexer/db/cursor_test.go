@@ -0,0 +1,38 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCursor(t *testing.T) {
+	cursor := Cursor{BlockNumber: 12345, Index: 6}
+	parsed, err := ParseCursor(cursor.String())
+	require.NoError(t, err)
+	require.Equal(t, cursor, parsed)
+
+	for _, in := range []string{"", "!", Cursor{}.String()[1:], "MTI"} {
+		_, err := ParseCursor(in)
+		require.Error(t, err, in)
+	}
+}
+
+func TestConditions(t *testing.T) {
+	var c conditions
+	require.Equal(t, "", c.where())
+
+	address := common.HexToAddress("0x4200000000000000000000000000000000000010")
+	since := uint64(100)
+	c.addTransfer(TransferFilter{Address: &address, Since: &since}, "deposits", "l1_blocks")
+	c.addCursor(CursorParam{After: &Cursor{BlockNumber: 7, Index: 2}}, "l1_blocks.number", "deposits.log_index")
+	require.Equal(t, 5, c.arg(uint64(10)))
+
+	require.Equal(t,
+		"WHERE (deposits.from_address = $1 OR deposits.to_address = $1) AND l1_blocks.timestamp >= $2 "+
+			"AND (l1_blocks.number, deposits.log_index) > ($3, $4)",
+		c.where(),
+	)
+	require.Equal(t, []interface{}{address.String(), since, uint64(7), uint64(2), uint64(10)}, c.args)
+}
@@ -5,44 +5,80 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 
-	// NOTE: Only postgresql backend is supported at the moment.
 	_ "github.com/lib/pq"
 )
 
-// Database contains the database instance and the connection string.
+// dialect is the SQL dialect of a database backend.
+type dialect int
+
+const (
+	postgres dialect = iota
+	sqlite
+)
+
+// Database contains the database instance and the connection string. It
+// stores the indexed data on Postgres, or on an embedded SQLite database.
 type Database struct {
-	db     *sql.DB
-	config string
+	db      *sql.DB
+	config  string
+	dialect dialect
 }
 
-// NewDatabase returns the database for the given connection string.
+// NewDatabase returns the Postgres database for the given connection string,
+// after applying the pending migrations. It fails if the database was
+// migrated by a newer indexer.
 func NewDatabase(config string) (*Database, error) {
-	db, err := sql.Open("postgres", config)
+	d, err := OpenDatabase(config)
 	if err != nil {
 		return nil, err
 	}
+	if err := d.migrateToLatest(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
 
-	err = db.Ping()
+// OpenDatabase returns the Postgres database for the given connection string
+// without migrating it.
+func OpenDatabase(config string) (*Database, error) {
+	db, err := sql.Open("postgres", config)
 	if err != nil {
 		return nil, err
 	}
 
-	for _, migration := range schema {
-		_, err = db.Exec(migration)
-		if err != nil {
-			return nil, err
-		}
+	err = db.Ping()
+	if err != nil {
+		db.Close()
+		return nil, err
 	}
 
 	return &Database{
-		db:     db,
-		config: config,
+		db:      db,
+		config:  config,
+		dialect: postgres,
 	}, nil
 }
 
+// migrateToLatest applies the pending migrations, and closes the database if
+// it fails.
+func (d *Database) migrateToLatest() error {
+	if err := d.CheckSchemaVersion(); err != nil {
+		d.Close()
+		return err
+	}
+
+	if _, err := d.MigrateUp(LatestSchemaVersion()); err != nil {
+		d.Close()
+		return err
+	}
+
+	return nil
+}
+
 // Close closes the database.
 // NOTE: "It is rarely necessary to close a DB."
 // See: https://pkg.go.dev/database/sql#Open
@@ -181,9 +217,10 @@ func (d *Database) AddL2Token(address string, token *Token) error {
 }
 
 // AddIndexedL1Block inserts the indexed block i.e. the L1 block containing all
-// scanned Deposits into the known deposits database.
+// scanned Deposits into the known deposits database, and runs the hooks in
+// the same transaction.
 // NOTE: the block hash MUST be unique
-func (d *Database) AddIndexedL1Block(block *IndexedL1Block) error {
+func (d *Database) AddIndexedL1Block(block *IndexedL1Block, hooks ...BlockHook) error {
 	const insertBlockStatement = `
 	INSERT INTO l1_blocks
 		(hash, parent_hash, number, timestamp)
@@ -193,19 +230,32 @@ func (d *Database) AddIndexedL1Block(block *IndexedL1Block) error {
 
 	const insertDepositStatement = `
 	INSERT INTO deposits
-		(guid, from_address, to_address, l1_token, l2_token, amount, tx_hash, log_index, block_hash, data)
+		(guid, from_address, to_address, l1_token, l2_token, amount, tx_hash, log_index, block_hash, data, msg_hash)
 	VALUES
-		($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 	`
 
 	const updateProvenWithdrawalStatement = `
-	UPDATE withdrawals SET (br_withdrawal_proven_tx_hash, br_withdrawal_proven_log_index) = ($1, $2)
-	WHERE br_withdrawal_hash = $3
+	UPDATE withdrawals SET (br_withdrawal_proven_tx_hash, br_withdrawal_proven_log_index, br_withdrawal_proven_block_hash, br_withdrawal_finalizable_at) = ($1, $2, $3, $4)
+	WHERE br_withdrawal_hash = $5
 	`
 
 	const updateFinalizedWithdrawalStatement = `
-	UPDATE withdrawals SET (br_withdrawal_finalized_tx_hash, br_withdrawal_finalized_log_index, br_withdrawal_finalized_success) = ($1, $2, $3)
-	WHERE br_withdrawal_hash = $4
+	UPDATE withdrawals SET (br_withdrawal_finalized_tx_hash, br_withdrawal_finalized_log_index, br_withdrawal_finalized_success, br_withdrawal_finalized_block_hash) = ($1, $2, $3, $4)
+	WHERE br_withdrawal_hash = $5
+	`
+
+	const insertL2OutputStatement = `
+	INSERT INTO l2_outputs
+		(guid, l2_output_index, output_root, l2_block_number, l1_timestamp, block_hash, tx_hash, log_index)
+	VALUES
+		($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+
+	// Outputs proposed later in the same block aren't deleted.
+	const deleteL2OutputsStatement = `
+	UPDATE l2_outputs SET deleted_block_hash = $1
+	WHERE l2_output_index >= $2 AND deleted_block_hash IS NULL AND (block_hash != $1 OR log_index < $3)
 	`
 
 	return txn(d.db, func(tx *sql.Tx) error {
@@ -234,11 +284,20 @@ func (d *Database) AddIndexedL1Block(block *IndexedL1Block) error {
 					deposit.LogIndex,
 					block.Hash.String(),
 					deposit.Data,
+					nullableHash(deposit.MessageHash),
 				)
 				if err != nil {
 					return err
 				}
 			}
+
+			pairs := make([]TokenPair, len(block.Deposits))
+			for i, deposit := range block.Deposits {
+				pairs[i] = TokenPair{L1Token: deposit.L1Token, L2Token: deposit.L2Token}
+			}
+			if err := insertTokenPairs(tx, pairs, TokenPairSourceBridge, nil); err != nil {
+				return err
+			}
 		}
 
 		if len(block.ProvenWithdrawals) > 0 {
@@ -247,6 +306,8 @@ func (d *Database) AddIndexedL1Block(block *IndexedL1Block) error {
 					updateProvenWithdrawalStatement,
 					wd.TxHash.String(),
 					wd.LogIndex,
+					block.Hash.String(),
+					wd.FinalizableAt,
 					wd.WithdrawalHash.String(),
 				)
 				if err != nil {
@@ -262,6 +323,7 @@ func (d *Database) AddIndexedL1Block(block *IndexedL1Block) error {
 					wd.TxHash.String(),
 					wd.LogIndex,
 					wd.Success,
+					block.Hash.String(),
 					wd.WithdrawalHash.String(),
 				)
 				if err != nil {
@@ -270,14 +332,55 @@ func (d *Database) AddIndexedL1Block(block *IndexedL1Block) error {
 			}
 		}
 
-		return nil
+		for _, output := range block.L2Outputs {
+			_, err = tx.Exec(
+				insertL2OutputStatement,
+				NewGUID(),
+				output.Index,
+				output.OutputRoot.String(),
+				output.L2BlockNumber,
+				output.L1Timestamp,
+				block.Hash.String(),
+				output.TxHash.String(),
+				output.LogIndex,
+			)
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, deleted := range block.DeletedL2Outputs {
+			_, err = tx.Exec(
+				deleteL2OutputsStatement,
+				block.Hash.String(),
+				deleted.FromIndex,
+				deleted.LogIndex,
+			)
+			if err != nil {
+				return err
+			}
+		}
+
+		if err := insertSentMessages(tx, ChainL1, block.Hash, block.Number, block.Timestamp, block.SentMessages); err != nil {
+			return err
+		}
+		if err := insertRelayedMessages(tx, ChainL1, block.Hash, block.RelayedMessages); err != nil {
+			return err
+		}
+
+		if err := insertContractEvents(tx, ChainL1, block.Hash, block.Number, block.Timestamp, block.ContractEvents); err != nil {
+			return err
+		}
+
+		return runBlockHooks(tx, hooks)
 	})
 }
 
 // AddIndexedL2Block inserts the indexed block i.e. the L2 block containing all
-// scanned Withdrawals into the known withdrawals database.
+// scanned Withdrawals into the known withdrawals database, and runs the hooks
+// in the same transaction.
 // NOTE: the block hash MUST be unique
-func (d *Database) AddIndexedL2Block(block *IndexedL2Block) error {
+func (d *Database) AddIndexedL2Block(block *IndexedL2Block, hooks ...BlockHook) error {
 	const insertBlockStatement = `
 	INSERT INTO l2_blocks
 		(hash, parent_hash, number, timestamp)
@@ -291,6 +394,7 @@ func (d *Database) AddIndexedL2Block(block *IndexedL2Block) error {
 	VALUES
 		($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 	`
+
 	return txn(d.db, func(tx *sql.Tx) error {
 		_, err := tx.Exec(
 			insertBlockStatement,
@@ -303,10 +407,6 @@ func (d *Database) AddIndexedL2Block(block *IndexedL2Block) error {
 			return err
 		}
 
-		if len(block.Withdrawals) == 0 {
-			return nil
-		}
-
 		for _, withdrawal := range block.Withdrawals {
 			_, err = tx.Exec(
 				insertWithdrawalStatement,
@@ -327,7 +427,32 @@ func (d *Database) AddIndexedL2Block(block *IndexedL2Block) error {
 			}
 		}
 
-		return nil
+		// Pairs created in the block are registered first, so that
+		// withdrawals of the new tokens don't register them as seen only in
+		// bridge events.
+		if err := insertTokenPairs(tx, block.TokenPairs, TokenPairSourceFactory, &block.Hash); err != nil {
+			return err
+		}
+		pairs := make([]TokenPair, len(block.Withdrawals))
+		for i, withdrawal := range block.Withdrawals {
+			pairs[i] = TokenPair{L1Token: withdrawal.L1Token, L2Token: withdrawal.L2Token}
+		}
+		if err := insertTokenPairs(tx, pairs, TokenPairSourceBridge, nil); err != nil {
+			return err
+		}
+
+		if err := insertSentMessages(tx, ChainL2, block.Hash, block.Number, block.Timestamp, block.SentMessages); err != nil {
+			return err
+		}
+		if err := insertRelayedMessages(tx, ChainL2, block.Hash, block.RelayedMessages); err != nil {
+			return err
+		}
+
+		if err := insertContractEvents(tx, ChainL2, block.Hash, block.Number, block.Timestamp, block.ContractEvents); err != nil {
+			return err
+		}
+
+		return runBlockHooks(tx, hooks)
 	})
 }
 
@@ -336,7 +461,7 @@ func (d *Database) AddIndexedL2Block(block *IndexedL2Block) error {
 func (d *Database) AddStateBatch(batches []StateBatch) error {
 	const insertStateBatchStatement = `
 	INSERT INTO state_batches
-		(index, root, size, prev_total, extra_data, block_hash)
+		("index", root, size, prev_total, extra_data, block_hash)
 	VALUES
 		($1, $2, $3, $4, $5, $6)
 	`
@@ -370,10 +495,14 @@ func (d *Database) GetDepositsByAddress(address common.Address, page PaginationP
 		deposits.amount, deposits.tx_hash, deposits.data,
 		deposits.l1_token, deposits.l2_token,
 		l1_tokens.name, l1_tokens.symbol, l1_tokens.decimals,
-		l1_blocks.number, l1_blocks.timestamp
+		l1_blocks.number, l1_blocks.timestamp,
+		deposits.msg_hash, relayed_messages.tx_hash, token_pairs.source
 	FROM deposits
 		INNER JOIN l1_blocks ON deposits.block_hash=l1_blocks.hash
 		INNER JOIN l1_tokens ON deposits.l1_token=l1_tokens.address
+		LEFT JOIN relayed_messages ON deposits.msg_hash=relayed_messages.msg_hash
+			AND relayed_messages.chain = 'l2' AND NOT relayed_messages.failed
+		LEFT JOIN token_pairs ON deposits.l1_token=token_pairs.l1_token AND deposits.l2_token=token_pairs.l2_token
 	WHERE deposits.from_address = $1 ORDER BY l1_blocks.timestamp LIMIT $2 OFFSET $3;
 	`
 	var deposits []DepositJSON
@@ -388,16 +517,19 @@ func (d *Database) GetDepositsByAddress(address common.Address, page PaginationP
 		for rows.Next() {
 			var deposit DepositJSON
 			var l1Token Token
+			var pairSource nullableTokenPairSource
 			if err := rows.Scan(
 				&deposit.GUID, &deposit.FromAddress, &deposit.ToAddress,
 				&deposit.Amount, &deposit.TxHash, &deposit.Data,
 				&l1Token.Address, &deposit.L2Token,
 				&l1Token.Name, &l1Token.Symbol, &l1Token.Decimals,
 				&deposit.BlockNumber, &deposit.BlockTimestamp,
+				&deposit.MessageHash, &deposit.RelayedTxHash, &pairSource,
 			); err != nil {
 				return err
 			}
 			deposit.L1Token = &l1Token
+			deposit.Canonical = pairSource.canonical()
 			deposits = append(deposits, deposit)
 		}
 
@@ -442,7 +574,7 @@ func (d *Database) GetDepositsByAddress(address common.Address, page PaginationP
 func (d *Database) GetWithdrawalBatch(hash common.Hash) (*StateBatchJSON, error) {
 	const selectWithdrawalBatchStatement = `
 	SELECT
-		state_batches.index, state_batches.root, state_batches.size, state_batches.prev_total, state_batches.extra_data, state_batches.block_hash,
+		state_batches."index", state_batches.root, state_batches.size, state_batches.prev_total, state_batches.extra_data, state_batches.block_hash,
 		l1_blocks.number, l1_blocks.timestamp
 	FROM state_batches
 	INNER JOIN l1_blocks ON state_batches.block_hash = l1_blocks.hash
@@ -495,10 +627,9 @@ func (d *Database) GetWithdrawalBatch(hash common.Hash) (*StateBatchJSON, error)
 	return batch, nil
 }
 
-// GetWithdrawalsByAddress returns the list of Withdrawals indexed for the given
-// address paginated by the given params.
-func (d *Database) GetWithdrawalsByAddress(address common.Address, page PaginationParam, state FinalizationState) (*PaginatedWithdrawals, error) {
-	selectWithdrawalsStatement := fmt.Sprintf(`
+// selectWithdrawals selects the columns scanned by scanWithdrawal, with the
+// output covering the withdrawal joined as l2_outputs.
+const selectWithdrawals = `
 	SELECT
 	    withdrawals.guid, withdrawals.from_address, withdrawals.to_address,
 		withdrawals.amount, withdrawals.tx_hash, withdrawals.data,
@@ -507,12 +638,109 @@ func (d *Database) GetWithdrawalsByAddress(address common.Address, page Paginati
 		l2_blocks.number, l2_blocks.timestamp, withdrawals.br_withdrawal_hash,
 		withdrawals.br_withdrawal_proven_tx_hash, withdrawals.br_withdrawal_proven_log_index,
 		withdrawals.br_withdrawal_finalized_tx_hash, withdrawals.br_withdrawal_finalized_log_index,
-		withdrawals.br_withdrawal_finalized_success
+		withdrawals.br_withdrawal_finalized_success, withdrawals.br_withdrawal_finalizable_at,
+		l2_outputs.l2_output_index, l2_outputs.output_root, l2_outputs.l2_block_number,
+		l2_outputs.l1_timestamp, l2_outputs.tx_hash,
+		withdrawals.log_index, withdrawals.br_withdrawal_proven_block_hash,
+		withdrawals.br_withdrawal_finalized_block_hash, token_pairs.source
 	FROM withdrawals
 		INNER JOIN l2_blocks ON withdrawals.block_hash=l2_blocks.hash
 		INNER JOIN l2_tokens ON withdrawals.l2_token=l2_tokens.address
-	WHERE withdrawals.from_address = $1 %s ORDER BY l2_blocks.timestamp LIMIT $2 OFFSET $3;
-	`, state.SQL())
+		LEFT JOIN token_pairs ON withdrawals.l1_token=token_pairs.l1_token AND withdrawals.l2_token=token_pairs.l2_token
+		LEFT JOIN l2_outputs ON withdrawals.br_withdrawal_hash IS NOT NULL AND l2_outputs.guid = (
+			SELECT outputs.guid FROM l2_outputs outputs
+			WHERE outputs.l2_block_number >= l2_blocks.number AND outputs.deleted_block_hash IS NULL
+			ORDER BY outputs.l2_output_index LIMIT 1
+		)
+`
+
+// scanWithdrawal scans a withdrawal selected by selectWithdrawals, and sets
+// its status at the unix time now.
+func scanWithdrawal(rows *sql.Rows, now uint64) (WithdrawalJSON, error) {
+	var withdrawal WithdrawalJSON
+	var l2Token Token
+	var wdHash sql.NullString
+	var proveTxHash sql.NullString
+	var proveLogIndex sql.NullInt32
+	var finTxHash sql.NullString
+	var finLogIndex sql.NullInt32
+	var finSuccess sql.NullBool
+	var finalizableAt sql.NullInt64
+	var outputIndex sql.NullInt64
+	var outputRoot sql.NullString
+	var outputBlockNumber sql.NullInt64
+	var outputTimestamp sql.NullInt64
+	var outputTxHash sql.NullString
+	var provenBlockHash sql.NullString
+	var finalizedBlockHash sql.NullString
+	var pairSource nullableTokenPairSource
+	if err := rows.Scan(
+		&withdrawal.GUID, &withdrawal.FromAddress, &withdrawal.ToAddress,
+		&withdrawal.Amount, &withdrawal.TxHash, &withdrawal.Data,
+		&withdrawal.L1Token, &l2Token.Address,
+		&l2Token.Name, &l2Token.Symbol, &l2Token.Decimals,
+		&withdrawal.BlockNumber, &withdrawal.BlockTimestamp,
+		&wdHash, &proveTxHash, &proveLogIndex,
+		&finTxHash, &finLogIndex, &finSuccess, &finalizableAt,
+		&outputIndex, &outputRoot, &outputBlockNumber,
+		&outputTimestamp, &outputTxHash,
+		&withdrawal.LogIndex, &provenBlockHash, &finalizedBlockHash, &pairSource,
+	); err != nil {
+		return withdrawal, err
+	}
+	withdrawal.L2Token = &l2Token
+	withdrawal.Canonical = pairSource.canonical()
+	if wdHash.Valid {
+		withdrawal.BedrockWithdrawalHash = &wdHash.String
+	}
+	if proveTxHash.Valid {
+		withdrawal.BedrockProvenTxHash = &proveTxHash.String
+	}
+	if provenBlockHash.Valid {
+		withdrawal.BedrockProvenBlockHash = &provenBlockHash.String
+	}
+	if proveLogIndex.Valid {
+		idx := int(proveLogIndex.Int32)
+		withdrawal.BedrockProvenLogIndex = &idx
+	}
+	if finTxHash.Valid {
+		withdrawal.BedrockFinalizedTxHash = &finTxHash.String
+	}
+	if finalizedBlockHash.Valid {
+		withdrawal.BedrockFinalizedBlockHash = &finalizedBlockHash.String
+	}
+	if finLogIndex.Valid {
+		idx := int(finLogIndex.Int32)
+		withdrawal.BedrockFinalizedLogIndex = &idx
+	}
+	if finSuccess.Valid {
+		withdrawal.BedrockFinalizedSuccess = &finSuccess.Bool
+	}
+	if finalizableAt.Valid {
+		ts := uint64(finalizableAt.Int64)
+		withdrawal.BedrockFinalizableAt = &ts
+	}
+	if outputIndex.Valid {
+		withdrawal.BedrockL2Output = &L2OutputJSON{
+			Index:         uint64(outputIndex.Int64),
+			OutputRoot:    outputRoot.String,
+			L2BlockNumber: uint64(outputBlockNumber.Int64),
+			L1Timestamp:   uint64(outputTimestamp.Int64),
+			TxHash:        outputTxHash.String,
+		}
+		withdrawal.BedrockProvableAt = &withdrawal.BedrockL2Output.L1Timestamp
+	}
+	withdrawal.updateStatus(now)
+	return withdrawal, nil
+}
+
+// GetWithdrawalsByAddress returns the list of Withdrawals indexed for the given
+// address paginated by the given params.
+func (d *Database) GetWithdrawalsByAddress(address common.Address, page PaginationParam, state FinalizationState, status WithdrawalStatus) (*PaginatedWithdrawals, error) {
+	now := uint64(time.Now().Unix())
+	selectWithdrawalsStatement := fmt.Sprintf(`%s
+	WHERE withdrawals.from_address = $1 %s %s ORDER BY l2_blocks.timestamp LIMIT $2 OFFSET $3;
+	`, selectWithdrawals, state.SQL(), status.SQL(now))
 	var withdrawals []WithdrawalJSON
 
 	err := txn(d.db, func(tx *sql.Tx) error {
@@ -523,46 +751,10 @@ func (d *Database) GetWithdrawalsByAddress(address common.Address, page Paginati
 		defer rows.Close()
 
 		for rows.Next() {
-			var withdrawal WithdrawalJSON
-			var l2Token Token
-			var wdHash sql.NullString
-			var proveTxHash sql.NullString
-			var proveLogIndex sql.NullInt32
-			var finTxHash sql.NullString
-			var finLogIndex sql.NullInt32
-			var finSuccess sql.NullBool
-			if err := rows.Scan(
-				&withdrawal.GUID, &withdrawal.FromAddress, &withdrawal.ToAddress,
-				&withdrawal.Amount, &withdrawal.TxHash, &withdrawal.Data,
-				&withdrawal.L1Token, &l2Token.Address,
-				&l2Token.Name, &l2Token.Symbol, &l2Token.Decimals,
-				&withdrawal.BlockNumber, &withdrawal.BlockTimestamp,
-				&wdHash, &proveTxHash, &proveLogIndex,
-				&finTxHash, &finLogIndex, &finSuccess,
-			); err != nil {
+			withdrawal, err := scanWithdrawal(rows, now)
+			if err != nil {
 				return err
 			}
-			withdrawal.L2Token = &l2Token
-			if wdHash.Valid {
-				withdrawal.BedrockWithdrawalHash = &wdHash.String
-			}
-			if proveTxHash.Valid {
-				withdrawal.BedrockProvenTxHash = &proveTxHash.String
-			}
-			if proveLogIndex.Valid {
-				idx := int(proveLogIndex.Int32)
-				withdrawal.BedrockProvenLogIndex = &idx
-			}
-			if finTxHash.Valid {
-				withdrawal.BedrockFinalizedTxHash = &finTxHash.String
-			}
-			if finLogIndex.Valid {
-				idx := int(finLogIndex.Int32)
-				withdrawal.BedrockFinalizedLogIndex = &idx
-			}
-			if finSuccess.Valid {
-				withdrawal.BedrockFinalizedSuccess = &finSuccess.Bool
-			}
 			withdrawals = append(withdrawals, withdrawal)
 		}
 
@@ -608,6 +800,49 @@ func (d *Database) GetWithdrawalsByAddress(address common.Address, page Paginati
 	}, nil
 }
 
+// GetWithdrawalByHash returns the bedrock withdrawal with the given withdrawal
+// hash, or nil if it isn't indexed.
+func (d *Database) GetWithdrawalByHash(hash common.Hash) (*WithdrawalJSON, error) {
+	var withdrawal *WithdrawalJSON
+	err := txn(d.db, func(tx *sql.Tx) error {
+		var err error
+		withdrawal, err = getWithdrawalByHash(tx, hash)
+		return err
+	})
+	return withdrawal, err
+}
+
+func getWithdrawalByHash(tx *sql.Tx, hash common.Hash) (*WithdrawalJSON, error) {
+	now := uint64(time.Now().Unix())
+	selectWithdrawalStatement := selectWithdrawals + `
+	WHERE withdrawals.br_withdrawal_hash = $1;
+	`
+
+	var withdrawal *WithdrawalJSON
+	err := func() error {
+		rows, err := tx.Query(selectWithdrawalStatement, hash.String())
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		if rows.Next() {
+			wd, err := scanWithdrawal(rows, now)
+			if err != nil {
+				return err
+			}
+			withdrawal = &wd
+		}
+
+		return rows.Err()
+	}()
+	if err != nil {
+		return nil, err
+	}
+
+	return withdrawal, nil
+}
+
 // GetHighestL1Block returns the highest known L1 block.
 func (d *Database) GetHighestL1Block() (*BlockLocator, error) {
 	const selectHighestBlockStatement = `
@@ -17,6 +17,9 @@ type Deposit struct {
 	Amount      *big.Int
 	Data        []byte
 	LogIndex    uint
+	// MessageHash is the versioned hash of the cross domain message relaying
+	// a bedrock deposit on L2.
+	MessageHash *common.Hash
 }
 
 // String returns the tx hash for the deposit.
@@ -37,4 +40,11 @@ type DepositJSON struct {
 	BlockNumber    uint64 `json:"blockNumber"`
 	BlockTimestamp string `json:"blockTimestamp"`
 	TxHash         string `json:"transactionHash"`
+	// MessageHash is nil for legacy deposits, and RelayedTxHash until the
+	// message is relayed on L2.
+	MessageHash   *string `json:"messageHash"`
+	RelayedTxHash *string `json:"relayedTransactionHash"`
+	// Canonical is false if the token pair is only registered from bridge
+	// events, i.e. if the L2 token isn't known to represent the L1 token.
+	Canonical bool `json:"canonical"`
 }
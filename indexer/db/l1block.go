@@ -13,6 +13,13 @@ type IndexedL1Block struct {
 	Deposits             []Deposit
 	ProvenWithdrawals    []ProvenWithdrawal
 	FinalizedWithdrawals []FinalizedWithdrawal
+	L2Outputs            []L2Output
+	DeletedL2Outputs     []DeletedL2Outputs
+	// SentMessages are the messages sent to L2, and RelayedMessages the
+	// messages from L2 relayed in the block.
+	SentMessages    []SentMessage
+	RelayedMessages []RelayedMessage
+	ContractEvents  []ContractEvent
 }
 
 // String returns the block hash for the indexed l1 block.
@@ -27,9 +34,25 @@ type IndexedL2Block struct {
 	Number      uint64
 	Timestamp   uint64
 	Withdrawals []Withdrawal
+	// SentMessages are the messages sent to L1, and RelayedMessages the
+	// messages from L1 relayed in the block.
+	SentMessages    []SentMessage
+	RelayedMessages []RelayedMessage
+	// TokenPairs are the pairs created by the OptimismMintableERC20Factory.
+	TokenPairs     []TokenPair
+	ContractEvents []ContractEvent
 }
 
 // String returns the block hash for the indexed l2 block.
 func (b IndexedL2Block) String() string {
 	return b.Hash.String()
 }
+
+// BlockJSON contains the header of an indexed L1 or L2 block suitable for
+// JSON serialization.
+type BlockJSON struct {
+	Hash       string `json:"hash"`
+	ParentHash string `json:"parentHash"`
+	Number     uint64 `json:"number"`
+	Timestamp  uint64 `json:"timestamp"`
+}
@@ -0,0 +1,33 @@
+package db
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// L2Output is an output proposed to the L2OutputOracle. Withdrawals can be
+// proven against the first output at or after their L2 block.
+type L2Output struct {
+	Index         uint64
+	OutputRoot    common.Hash
+	L2BlockNumber uint64
+	L1Timestamp   uint64
+	TxHash        common.Hash
+	LogIndex      uint
+}
+
+// DeletedL2Outputs records that the outputs from index FromIndex on were
+// deleted from the L2OutputOracle.
+type DeletedL2Outputs struct {
+	FromIndex uint64
+	TxHash    common.Hash
+	LogIndex  uint
+}
+
+// L2OutputJSON contains L2Output data suitable for JSON serialization.
+type L2OutputJSON struct {
+	Index         uint64 `json:"index"`
+	OutputRoot    string `json:"outputRoot"`
+	L2BlockNumber uint64 `json:"l2BlockNumber"`
+	L1Timestamp   uint64 `json:"l1Timestamp"`
+	TxHash        string `json:"transactionHash"`
+}
@@ -0,0 +1,264 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// SentMessage is a cross domain message sent by the L1CrossDomainMessenger or
+// the L2CrossDomainMessenger.
+type SentMessage struct {
+	// MessageHash is the versioned hash of the message, which identifies it
+	// in the relay events.
+	MessageHash common.Hash
+	Nonce       *big.Int
+	Sender      common.Address
+	Target      common.Address
+	Value       *big.Int
+	GasLimit    *big.Int
+	Data        []byte
+	TxHash      common.Hash
+	LogIndex    uint
+}
+
+// MessageStatus is the relay status of a sent message.
+type MessageStatus string
+
+const (
+	// MessageStatusAny matches messages of any status in filters.
+	MessageStatusAny MessageStatus = ""
+	// MessageStatusPending is the status of the messages not relayed yet.
+	MessageStatusPending MessageStatus = "pending"
+	// MessageStatusFailed is the status of the messages whose relays all
+	// failed. They can be replayed.
+	MessageStatusFailed MessageStatus = "failed"
+	// MessageStatusRelayed is the status of the messages relayed
+	// successfully.
+	MessageStatusRelayed MessageStatus = "relayed"
+	// MessageStatusUnrelayed matches the pending and failed messages in
+	// filters.
+	MessageStatusUnrelayed MessageStatus = "unrelayed"
+)
+
+func ParseMessageStatus(in string) (MessageStatus, error) {
+	status := MessageStatus(in)
+	switch status {
+	case MessageStatusAny, MessageStatusPending, MessageStatusFailed, MessageStatusRelayed, MessageStatusUnrelayed:
+		return status, nil
+	}
+	return MessageStatusAny, fmt.Errorf("unknown message status %q", in)
+}
+
+// selectRelays selects the relays of the sent message on the other chain.
+const selectRelays = `
+	SELECT 1 FROM relayed_messages
+	WHERE relayed_messages.msg_hash = sent_messages.msg_hash AND relayed_messages.chain != sent_messages.chain`
+
+// SQL returns the condition selecting the sent messages of the status.
+func (s MessageStatus) SQL() string {
+	relayed := "EXISTS (" + selectRelays + " AND NOT relayed_messages.failed)"
+	switch s {
+	case MessageStatusPending:
+		return "NOT EXISTS (" + selectRelays + ")"
+	case MessageStatusFailed:
+		return "NOT " + relayed + " AND EXISTS (" + selectRelays + " AND relayed_messages.failed)"
+	case MessageStatusRelayed:
+		return relayed
+	case MessageStatusUnrelayed:
+		return "NOT " + relayed
+	}
+	return ""
+}
+
+// MessageJSON contains SentMessage data suitable for JSON serialization, with
+// the relays of the message.
+type MessageJSON struct {
+	GUID string `json:"guid"`
+	// Chain is the chain the message is sent from, l1 or l2.
+	Chain          string        `json:"chain"`
+	MessageHash    string        `json:"messageHash"`
+	Nonce          string        `json:"nonce"`
+	Sender         string        `json:"sender"`
+	Target         string        `json:"target"`
+	Value          string        `json:"value"`
+	GasLimit       string        `json:"gasLimit"`
+	Data           []byte        `json:"data"`
+	BlockHash      string        `json:"blockHash"`
+	BlockNumber    uint64        `json:"blockNumber"`
+	BlockTimestamp uint64        `json:"blockTimestamp"`
+	TxHash         string        `json:"transactionHash"`
+	LogIndex       uint64        `json:"logIndex"`
+	Status         MessageStatus `json:"status"`
+	// RelayedTxHash is the transaction relaying the message successfully.
+	RelayedTxHash *string `json:"relayedTransactionHash"`
+	FailedRelays  uint64  `json:"failedRelays"`
+}
+
+// MessageFilter selects sent messages. Empty fields match all messages.
+type MessageFilter struct {
+	// Chain is the chain the messages are sent from.
+	Chain  string
+	Sender *common.Address
+	Target *common.Address
+	Status MessageStatus
+}
+
+type PaginatedMessages struct {
+	Param    *PaginationParam `json:"pagination"`
+	Messages []MessageJSON    `json:"items"`
+}
+
+func insertSentMessages(tx *sql.Tx, chain string, blockHash common.Hash, number, timestamp uint64, msgs []SentMessage) error {
+	const insertSentMessageStatement = `
+	INSERT INTO sent_messages
+		(guid, chain, msg_hash, nonce, sender, target, value, gas_limit, data, block_hash, block_number, block_timestamp, tx_hash, log_index)
+	VALUES
+		($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+	`
+
+	for _, msg := range msgs {
+		_, err := tx.Exec(
+			insertSentMessageStatement,
+			NewGUID(),
+			chain,
+			msg.MessageHash.String(),
+			msg.Nonce.String(),
+			msg.Sender.String(),
+			msg.Target.String(),
+			msg.Value.String(),
+			msg.GasLimit.String(),
+			msg.Data,
+			blockHash.String(),
+			number,
+			timestamp,
+			msg.TxHash.String(),
+			msg.LogIndex,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func insertRelayedMessages(tx *sql.Tx, chain string, blockHash common.Hash, msgs []RelayedMessage) error {
+	const insertRelayedMessageStatement = `
+	INSERT INTO relayed_messages
+		(guid, chain, msg_hash, tx_hash, log_index, block_hash, failed)
+	VALUES
+		($1, $2, $3, $4, $5, $6, $7)
+	`
+
+	for _, msg := range msgs {
+		_, err := tx.Exec(
+			insertRelayedMessageStatement,
+			NewGUID(),
+			chain,
+			msg.MessageHash.String(),
+			msg.TxHash.String(),
+			msg.LogIndex,
+			blockHash.String(),
+			msg.Failed,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetMessages returns the sent messages selected by filter with their relays,
+// ordered by chain, block and log index and paginated by the given params.
+func (d *Database) GetMessages(filter MessageFilter, page PaginationParam) (*PaginatedMessages, error) {
+	var c conditions
+	if filter.Chain != "" {
+		c.add("sent_messages.chain = $%d", filter.Chain)
+	}
+	if filter.Sender != nil {
+		c.add("sent_messages.sender = $%d", filter.Sender.String())
+	}
+	if filter.Target != nil {
+		c.add("sent_messages.target = $%d", filter.Target.String())
+	}
+	if filter.Status != MessageStatusAny {
+		c.add(filter.Status.SQL())
+	}
+	where, args := c.where(), c.args
+
+	selectMessagesStatement := fmt.Sprintf(`
+	SELECT
+		sent_messages.guid, sent_messages.chain, sent_messages.msg_hash, sent_messages.nonce,
+		sent_messages.sender, sent_messages.target, sent_messages.value, sent_messages.gas_limit,
+		sent_messages.data, sent_messages.block_hash, sent_messages.block_number,
+		sent_messages.block_timestamp, sent_messages.tx_hash, sent_messages.log_index,
+		(
+			SELECT relayed_messages.tx_hash FROM relayed_messages
+			WHERE relayed_messages.msg_hash = sent_messages.msg_hash AND relayed_messages.chain != sent_messages.chain
+				AND NOT relayed_messages.failed
+			LIMIT 1
+		),
+		(
+			SELECT count(*) FROM relayed_messages
+			WHERE relayed_messages.msg_hash = sent_messages.msg_hash AND relayed_messages.chain != sent_messages.chain
+				AND relayed_messages.failed
+		)
+	FROM sent_messages
+	%s
+	ORDER BY sent_messages.chain, sent_messages.block_number, sent_messages.log_index LIMIT $%d OFFSET $%d;
+	`, where, len(args)+1, len(args)+2)
+	selectMessageCountStatement := fmt.Sprintf(`
+	SELECT count(*) FROM sent_messages %s;
+	`, where)
+
+	msgs := []MessageJSON{}
+	var count uint64
+	err := txn(d.db, func(tx *sql.Tx) error {
+		rows, err := tx.Query(selectMessagesStatement, append(args, page.Limit, page.Offset)...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var msg MessageJSON
+			var relayedTxHash sql.NullString
+			if err := rows.Scan(
+				&msg.GUID, &msg.Chain, &msg.MessageHash, &msg.Nonce,
+				&msg.Sender, &msg.Target, &msg.Value, &msg.GasLimit,
+				&msg.Data, &msg.BlockHash, &msg.BlockNumber,
+				&msg.BlockTimestamp, &msg.TxHash, &msg.LogIndex,
+				&relayedTxHash, &msg.FailedRelays,
+			); err != nil {
+				return err
+			}
+			switch {
+			case relayedTxHash.Valid:
+				msg.RelayedTxHash = &relayedTxHash.String
+				msg.Status = MessageStatusRelayed
+			case msg.FailedRelays > 0:
+				msg.Status = MessageStatusFailed
+			default:
+				msg.Status = MessageStatusPending
+			}
+			msgs = append(msgs, msg)
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		return tx.QueryRow(selectMessageCountStatement, args...).Scan(&count)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	page.Total = count
+
+	return &PaginatedMessages{
+		Param:    &page,
+		Messages: msgs,
+	}, nil
+}
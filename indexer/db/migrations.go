@@ -0,0 +1,421 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrUnknownSchemaVersion is returned when the database was migrated by a
+// newer indexer, whose migrations this indexer doesn't know.
+var ErrUnknownSchemaVersion = errors.New("database schema is newer than this indexer")
+
+// Migration is a numbered change to the schema. Up applies the change and
+// Down reverts it.
+type Migration struct {
+	Version     uint64
+	Description string
+	Up          string
+	Down        string
+	// SQLiteUp and SQLiteDown replace Up and Down on SQLite, when the
+	// Postgres statements aren't supported by SQLite.
+	SQLiteUp   string
+	SQLiteDown string
+}
+
+// up returns the statements applying m on a database of the given dialect.
+func (m Migration) up(d dialect) string {
+	if d == sqlite && m.SQLiteUp != "" {
+		return m.SQLiteUp
+	}
+	return m.Up
+}
+
+// down returns the statements reverting m on a database of the given
+// dialect.
+func (m Migration) down(d dialect) string {
+	if d == sqlite && m.SQLiteDown != "" {
+		return m.SQLiteDown
+	}
+	return m.Down
+}
+
+// migrations is the history of the schema. Released migrations must not be
+// changed, schema changes are made by appending new migrations.
+// NOTE: the first migrations create the tables with IF NOT EXISTS, since they
+// were created without versioning by earlier indexers.
+var migrations = []Migration{
+	{
+		Version:     1,
+		Description: "create blocks, tokens, state batches, deposits and withdrawals",
+		Up: strings.Join([]string{
+			createL1BlocksTable,
+			createL2BlocksTable,
+			createL1TokensTable,
+			createL2TokensTable,
+			createStateBatchesTable,
+			insertETHL1Token,
+			insertETHL2Token,
+			createDepositsTable,
+			createWithdrawalsTable,
+			createL1L2NumberIndex,
+		}, ";\n"),
+		Down: dropInitialTables,
+		SQLiteUp: strings.Join([]string{
+			createL1BlocksTable,
+			createL2BlocksTable,
+			createL1TokensTable,
+			createL2TokensTable,
+			sqliteCreateStateBatchesTable,
+			insertETHL1Token,
+			insertETHL2Token,
+			createDepositsTable,
+			sqliteCreateWithdrawalsTable,
+			createL1L2NumberIndex,
+		}, ";\n"),
+	},
+	{
+		Version:     2,
+		Description: "create airdrops",
+		Up:          createAirdropsTable,
+		Down:        dropAirdropsTable,
+		SQLiteUp:    sqliteCreateAirdropsTable,
+	},
+	{
+		Version:     3,
+		Description: "add bedrock withdrawal columns",
+		Up:          updateWithdrawalsTable,
+		Down:        revertWithdrawalsTable,
+		SQLiteUp:    sqliteUpdateWithdrawalsTable,
+		SQLiteDown:  sqliteRevertWithdrawalsTable,
+	},
+	{
+		Version:     4,
+		Description: "record the L1 blocks of proven and finalized withdrawals",
+		Up:          addWithdrawalBlockHashColumns,
+		Down:        dropWithdrawalBlockHashColumns,
+		SQLiteUp:    sqliteAddWithdrawalBlockHashColumns,
+		SQLiteDown:  sqliteDropWithdrawalBlockHashColumns,
+	},
+	{
+		Version:     5,
+		Description: "create l2 outputs and record when withdrawals can be finalized",
+		Up:          createL2OutputsTable,
+		Down:        dropL2OutputsTable,
+		SQLiteUp:    sqliteCreateL2OutputsTable,
+		SQLiteDown:  sqliteDropL2OutputsTable,
+	},
+	{
+		Version:     6,
+		Description: "create contract events",
+		Up:          createContractEventsTable,
+		Down:        dropContractEventsTable,
+		SQLiteUp:    sqliteCreateContractEventsTable,
+	},
+	{
+		Version:     7,
+		Description: "index deposits and withdrawals by address, token and time",
+		Up:          createTransferFilterIndexes,
+		Down:        dropTransferFilterIndexes,
+	},
+	{
+		Version:     8,
+		Description: "create backfill checkpoints and staged chunks",
+		Up:          createBackfillTables,
+		Down:        dropBackfillTables,
+	},
+	{
+		Version:     9,
+		Description: "record the messages of deposits and create relayed messages",
+		Up:          createRelayedMessagesTable,
+		Down:        dropRelayedMessagesTable,
+		SQLiteUp:    sqliteCreateRelayedMessagesTable,
+		SQLiteDown:  sqliteDropRelayedMessagesTable,
+	},
+	{
+		Version:     10,
+		Description: "create webhook deliveries",
+		Up:          createWebhookDeliveriesTable,
+		Down:        dropWebhookDeliveriesTable,
+	},
+	{
+		Version:     11,
+		Description: "create token pairs",
+		Up:          createTokenPairsTable,
+		Down:        dropTokenPairsTable,
+	},
+	{
+		Version:     12,
+		Description: "create sent messages and record relays on l1 and failed relays",
+		Up:          createSentMessagesTable,
+		Down:        dropSentMessagesTable,
+		SQLiteUp:    sqliteCreateSentMessagesTable,
+		SQLiteDown:  sqliteDropSentMessagesTable,
+	},
+	{
+		Version:     13,
+		Description: "index webhook deliveries by webhook",
+		Up:          createWebhookDeliveriesWebhookIndex,
+		Down:        dropWebhookDeliveriesWebhookIndex,
+	},
+}
+
+// LatestSchemaVersion returns the version of the last known migration.
+func LatestSchemaVersion() uint64 {
+	return migrations[len(migrations)-1].Version
+}
+
+// MigrationStatus describes a migration that is known to the indexer or
+// applied to the database.
+type MigrationStatus struct {
+	Version     uint64
+	Description string
+	// AppliedAt is nil if the migration is pending.
+	AppliedAt *time.Time
+}
+
+func validateMigrations(ms []Migration) error {
+	for i, m := range ms {
+		if m.Version != uint64(i+1) {
+			return fmt.Errorf("migration %d has version %d", i+1, m.Version)
+		}
+		if m.Description == "" || m.Up == "" || m.Down == "" {
+			return fmt.Errorf("migration %d must have a description, up and down", m.Version)
+		}
+	}
+	return nil
+}
+
+// planUp returns the migrations to apply to go from version current to
+// version target.
+func planUp(ms []Migration, current, target uint64) ([]Migration, error) {
+	latest := uint64(len(ms))
+	if current > latest {
+		return nil, ErrUnknownSchemaVersion
+	}
+	if target > latest {
+		return nil, fmt.Errorf("unknown target version %d, the latest version is %d", target, latest)
+	}
+	if target < current {
+		return nil, fmt.Errorf("target version %d is older than the current version %d", target, current)
+	}
+	return ms[current:target], nil
+}
+
+// planDown returns the migrations to revert, in order, to go from version
+// current to version target.
+func planDown(ms []Migration, current, target uint64) ([]Migration, error) {
+	if current > uint64(len(ms)) {
+		return nil, ErrUnknownSchemaVersion
+	}
+	if target > current {
+		return nil, fmt.Errorf("target version %d is newer than the current version %d", target, current)
+	}
+	var plan []Migration
+	for v := current; v > target; v-- {
+		plan = append(plan, ms[v-1])
+	}
+	return plan, nil
+}
+
+func (d *Database) createMigrationsTable() error {
+	statement := createSchemaMigrationsTable
+	if d.dialect == sqlite {
+		statement = sqliteCreateSchemaMigrationsTable
+	}
+	_, err := d.db.Exec(statement)
+	return err
+}
+
+// lockMigrations prevents concurrent indexers from migrating the database
+// until tx ends. SQLite transactions are already serialized.
+func (d *Database) lockMigrations(tx *sql.Tx) error {
+	if d.dialect == sqlite {
+		return nil
+	}
+	_, err := tx.Exec("LOCK TABLE schema_migrations IN EXCLUSIVE MODE")
+	return err
+}
+
+// schemaVersion returns the version of the last applied migration, or 0 if
+// none was applied.
+func schemaVersion(q interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}) (uint64, error) {
+	const selectSchemaVersionStatement = `
+	SELECT COALESCE(MAX(version), 0) FROM schema_migrations;
+	`
+
+	var version uint64
+	err := q.QueryRow(selectSchemaVersionStatement).Scan(&version)
+	return version, err
+}
+
+// SchemaVersion returns the version of the last migration applied to the
+// database.
+func (d *Database) SchemaVersion() (uint64, error) {
+	if err := d.createMigrationsTable(); err != nil {
+		return 0, err
+	}
+	return schemaVersion(d.db)
+}
+
+// CheckSchemaVersion returns ErrUnknownSchemaVersion if the database has
+// migrations applied that this indexer doesn't know.
+func (d *Database) CheckSchemaVersion() error {
+	version, err := d.SchemaVersion()
+	if err != nil {
+		return err
+	}
+	if version > LatestSchemaVersion() {
+		return fmt.Errorf("%w: version %d is applied, the latest known version is %d",
+			ErrUnknownSchemaVersion, version, LatestSchemaVersion())
+	}
+	return nil
+}
+
+// MigrateUp applies the pending migrations up to version target, and returns
+// the migrations it applied.
+func (d *Database) MigrateUp(target uint64) ([]Migration, error) {
+	current, err := d.SchemaVersion()
+	if err != nil {
+		return nil, err
+	}
+	plan, err := planUp(migrations, current, target)
+	if err != nil {
+		return nil, err
+	}
+
+	const insertMigrationStatement = `
+	INSERT INTO schema_migrations (version, description) VALUES ($1, $2);
+	`
+
+	var applied []Migration
+	for _, m := range plan {
+		m := m
+		var skipped bool
+		err := txn(d.db, func(tx *sql.Tx) error {
+			// Concurrent indexers must not apply the same migration.
+			if err := d.lockMigrations(tx); err != nil {
+				return err
+			}
+			version, err := schemaVersion(tx)
+			if err != nil {
+				return err
+			}
+			if version >= m.Version {
+				skipped = true
+				return nil
+			}
+			if _, err := tx.Exec(m.up(d.dialect)); err != nil {
+				return err
+			}
+			_, err = tx.Exec(insertMigrationStatement, m.Version, m.Description)
+			return err
+		})
+		if err != nil {
+			return applied, fmt.Errorf("error applying migration %d: %w", m.Version, err)
+		}
+		if !skipped {
+			applied = append(applied, m)
+		}
+	}
+	return applied, nil
+}
+
+// MigrateDown reverts the migrations newer than version target, and returns
+// the migrations it reverted.
+func (d *Database) MigrateDown(target uint64) ([]Migration, error) {
+	current, err := d.SchemaVersion()
+	if err != nil {
+		return nil, err
+	}
+	plan, err := planDown(migrations, current, target)
+	if err != nil {
+		return nil, err
+	}
+
+	const deleteMigrationStatement = `
+	DELETE FROM schema_migrations WHERE version = $1;
+	`
+
+	var reverted []Migration
+	for _, m := range plan {
+		m := m
+		err := txn(d.db, func(tx *sql.Tx) error {
+			if err := d.lockMigrations(tx); err != nil {
+				return err
+			}
+			version, err := schemaVersion(tx)
+			if err != nil {
+				return err
+			}
+			if version != m.Version {
+				return fmt.Errorf("schema version changed to %d while migrating", version)
+			}
+			if _, err := tx.Exec(m.down(d.dialect)); err != nil {
+				return err
+			}
+			_, err = tx.Exec(deleteMigrationStatement, m.Version)
+			return err
+		})
+		if err != nil {
+			return reverted, fmt.Errorf("error reverting migration %d: %w", m.Version, err)
+		}
+		reverted = append(reverted, m)
+	}
+	return reverted, nil
+}
+
+// MigrationStatus lists the known and the applied migrations by version.
+func (d *Database) MigrationStatus() ([]MigrationStatus, error) {
+	if err := d.createMigrationsTable(); err != nil {
+		return nil, err
+	}
+
+	const selectMigrationsStatement = `
+	SELECT version, description, applied_at FROM schema_migrations ORDER BY version;
+	`
+
+	rows, err := d.db.Query(selectMigrationsStatement)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[uint64]MigrationStatus)
+	var latest uint64
+	for rows.Next() {
+		var status MigrationStatus
+		var appliedAt time.Time
+		if err := rows.Scan(&status.Version, &status.Description, &appliedAt); err != nil {
+			return nil, err
+		}
+		status.AppliedAt = &appliedAt
+		applied[status.Version] = status
+		if status.Version > latest {
+			latest = status.Version
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if known := LatestSchemaVersion(); known > latest {
+		latest = known
+	}
+	var statuses []MigrationStatus
+	for v := uint64(1); v <= latest; v++ {
+		if status, ok := applied[v]; ok {
+			statuses = append(statuses, status)
+		} else if v <= LatestSchemaVersion() {
+			statuses = append(statuses, MigrationStatus{
+				Version:     v,
+				Description: migrations[v-1].Description,
+			})
+		}
+	}
+	return statuses, nil
+}
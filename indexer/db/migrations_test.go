@@ -0,0 +1,56 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrations(t *testing.T) {
+	require.NoError(t, validateMigrations(migrations))
+	require.Equal(t, uint64(len(migrations)), LatestSchemaVersion())
+}
+
+func TestValidateMigrations(t *testing.T) {
+	require.Error(t, validateMigrations([]Migration{
+		{Version: 2, Description: "b", Up: "up", Down: "down"},
+	}))
+	require.Error(t, validateMigrations([]Migration{
+		{Version: 1, Description: "a", Up: "up"},
+	}))
+}
+
+func TestPlanMigrations(t *testing.T) {
+	ms := []Migration{
+		{Version: 1, Description: "a", Up: "up 1", Down: "down 1"},
+		{Version: 2, Description: "b", Up: "up 2", Down: "down 2"},
+		{Version: 3, Description: "c", Up: "up 3", Down: "down 3"},
+	}
+
+	plan, err := planUp(ms, 0, 3)
+	require.NoError(t, err)
+	require.Equal(t, ms, plan)
+	plan, err = planUp(ms, 1, 2)
+	require.NoError(t, err)
+	require.Equal(t, ms[1:2], plan)
+	plan, err = planUp(ms, 3, 3)
+	require.NoError(t, err)
+	require.Empty(t, plan)
+	_, err = planUp(ms, 2, 1)
+	require.Error(t, err)
+	_, err = planUp(ms, 0, 4)
+	require.Error(t, err)
+	_, err = planUp(ms, 4, 4)
+	require.ErrorIs(t, err, ErrUnknownSchemaVersion)
+
+	plan, err = planDown(ms, 3, 1)
+	require.NoError(t, err)
+	require.Equal(t, []Migration{ms[2], ms[1]}, plan)
+	plan, err = planDown(ms, 1, 0)
+	require.NoError(t, err)
+	require.Equal(t, []Migration{ms[0]}, plan)
+	_, err = planDown(ms, 1, 2)
+	require.Error(t, err)
+	_, err = planDown(ms, 4, 3)
+	require.ErrorIs(t, err, ErrUnknownSchemaVersion)
+}
@@ -0,0 +1,377 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// GetDeposits returns the deposits selected by filter, ordered by block and
+// log index, and whether there are more deposits after them.
+func (d *Database) GetDeposits(filter TransferFilter, page CursorParam) ([]DepositJSON, bool, error) {
+	var deposits []DepositJSON
+	var hasNextPage bool
+	err := txn(d.db, func(tx *sql.Tx) error {
+		var err error
+		deposits, hasNextPage, err = getDeposits(tx, filter, page)
+		return err
+	})
+	return deposits, hasNextPage, err
+}
+
+func getDeposits(tx *sql.Tx, filter TransferFilter, page CursorParam) ([]DepositJSON, bool, error) {
+	var c conditions
+	c.addTransfer(filter, "deposits", "l1_blocks")
+	if filter.MessageHash != nil {
+		c.add("deposits.msg_hash = $%d", filter.MessageHash.String())
+	}
+	c.addCursor(page, "l1_blocks.number", "deposits.log_index")
+	limit := c.arg(page.First + 1)
+
+	selectDepositsStatement := fmt.Sprintf(`
+	SELECT
+		deposits.guid, deposits.from_address, deposits.to_address,
+		deposits.amount, deposits.tx_hash, deposits.data,
+		deposits.l1_token, deposits.l2_token,
+		l1_tokens.name, l1_tokens.symbol, l1_tokens.decimals,
+		deposits.log_index, l1_blocks.number, l1_blocks.timestamp,
+		deposits.msg_hash, relayed_messages.tx_hash, token_pairs.source
+	FROM deposits
+		INNER JOIN l1_blocks ON deposits.block_hash=l1_blocks.hash
+		INNER JOIN l1_tokens ON deposits.l1_token=l1_tokens.address
+		LEFT JOIN relayed_messages ON deposits.msg_hash=relayed_messages.msg_hash
+			AND relayed_messages.chain = 'l2' AND NOT relayed_messages.failed
+		LEFT JOIN token_pairs ON deposits.l1_token=token_pairs.l1_token AND deposits.l2_token=token_pairs.l2_token
+	%s ORDER BY l1_blocks.number, deposits.log_index LIMIT $%d;
+	`, c.where(), limit)
+
+	var deposits []DepositJSON
+	err := func() error {
+		rows, err := tx.Query(selectDepositsStatement, c.args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var deposit DepositJSON
+			var l1Token Token
+			var pairSource nullableTokenPairSource
+			if err := rows.Scan(
+				&deposit.GUID, &deposit.FromAddress, &deposit.ToAddress,
+				&deposit.Amount, &deposit.TxHash, &deposit.Data,
+				&l1Token.Address, &deposit.L2Token,
+				&l1Token.Name, &l1Token.Symbol, &l1Token.Decimals,
+				&deposit.LogIndex, &deposit.BlockNumber, &deposit.BlockTimestamp,
+				&deposit.MessageHash, &deposit.RelayedTxHash, &pairSource,
+			); err != nil {
+				return err
+			}
+			deposit.L1Token = &l1Token
+			deposit.Canonical = pairSource.canonical()
+			deposits = append(deposits, deposit)
+		}
+
+		return rows.Err()
+	}()
+	if err != nil {
+		return nil, false, err
+	}
+
+	hasNextPage := uint64(len(deposits)) > page.First
+	if hasNextPage {
+		deposits = deposits[:page.First]
+	}
+	return deposits, hasNextPage, nil
+}
+
+// GetWithdrawals returns the withdrawals selected by filter, ordered by block
+// and log index, and whether there are more withdrawals after them.
+func (d *Database) GetWithdrawals(filter TransferFilter, page CursorParam) ([]WithdrawalJSON, bool, error) {
+	var withdrawals []WithdrawalJSON
+	var hasNextPage bool
+	err := txn(d.db, func(tx *sql.Tx) error {
+		var err error
+		withdrawals, hasNextPage, err = getWithdrawals(tx, filter, page)
+		return err
+	})
+	return withdrawals, hasNextPage, err
+}
+
+func getWithdrawals(tx *sql.Tx, filter TransferFilter, page CursorParam) ([]WithdrawalJSON, bool, error) {
+	now := uint64(time.Now().Unix())
+	var c conditions
+	c.addTransfer(filter, "withdrawals", "l2_blocks")
+	if status := filter.Status.SQL(now); status != "" {
+		c.add(strings.TrimPrefix(status, "AND "))
+	}
+	c.addCursor(page, "l2_blocks.number", "withdrawals.log_index")
+	limit := c.arg(page.First + 1)
+
+	selectWithdrawalsStatement := fmt.Sprintf(`%s
+	%s ORDER BY l2_blocks.number, withdrawals.log_index LIMIT $%d;
+	`, selectWithdrawals, c.where(), limit)
+
+	var withdrawals []WithdrawalJSON
+	err := func() error {
+		rows, err := tx.Query(selectWithdrawalsStatement, c.args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			withdrawal, err := scanWithdrawal(rows, now)
+			if err != nil {
+				return err
+			}
+			withdrawals = append(withdrawals, withdrawal)
+		}
+
+		return rows.Err()
+	}()
+	if err != nil {
+		return nil, false, err
+	}
+
+	hasNextPage := uint64(len(withdrawals)) > page.First
+	if hasNextPage {
+		withdrawals = withdrawals[:page.First]
+	}
+	return withdrawals, hasNextPage, nil
+}
+
+// GetL1Tokens returns the known L1 tokens ordered by address, starting after
+// the address after if it isn't empty, and whether there are more tokens.
+func (d *Database) GetL1Tokens(first uint64, after string) ([]Token, bool, error) {
+	return d.getTokens("l1_tokens", first, after)
+}
+
+// GetL2Tokens returns the known L2 tokens ordered by address, starting after
+// the address after if it isn't empty, and whether there are more tokens.
+func (d *Database) GetL2Tokens(first uint64, after string) ([]Token, bool, error) {
+	return d.getTokens("l2_tokens", first, after)
+}
+
+func (d *Database) getTokens(table string, first uint64, after string) ([]Token, bool, error) {
+	selectTokensStatement := fmt.Sprintf(`
+	SELECT address, name, symbol, decimals FROM %s
+	WHERE address > $1 ORDER BY address LIMIT $2;
+	`, table)
+
+	var tokens []Token
+	err := txn(d.db, func(tx *sql.Tx) error {
+		rows, err := tx.Query(selectTokensStatement, after, first+1)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var token Token
+			if err := rows.Scan(&token.Address, &token.Name, &token.Symbol, &token.Decimals); err != nil {
+				return err
+			}
+			tokens = append(tokens, token)
+		}
+
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	hasNextPage := uint64(len(tokens)) > first
+	if hasNextPage {
+		tokens = tokens[:first]
+	}
+	return tokens, hasNextPage, nil
+}
+
+// GetL1Blocks returns the indexed L1 blocks selected by filter ordered by
+// number, and whether there are more blocks after them. Only the L1 blocks
+// containing indexed data are stored.
+func (d *Database) GetL1Blocks(filter BlockFilter, page CursorParam) ([]BlockJSON, bool, error) {
+	return d.getBlocks("l1_blocks", filter, page)
+}
+
+// GetL2Blocks returns the indexed L2 blocks selected by filter ordered by
+// number, and whether there are more blocks after them. Only the L2 blocks
+// containing indexed data are stored.
+func (d *Database) GetL2Blocks(filter BlockFilter, page CursorParam) ([]BlockJSON, bool, error) {
+	return d.getBlocks("l2_blocks", filter, page)
+}
+
+func (d *Database) getBlocks(table string, filter BlockFilter, page CursorParam) ([]BlockJSON, bool, error) {
+	var c conditions
+	c.addBlock(filter, table)
+	c.addCursor(page, table+".number", "0")
+	limit := c.arg(page.First + 1)
+
+	selectBlocksStatement := fmt.Sprintf(`
+	SELECT hash, parent_hash, number, timestamp FROM %s
+	%s ORDER BY number LIMIT $%d;
+	`, table, c.where(), limit)
+
+	var blocks []BlockJSON
+	err := txn(d.db, func(tx *sql.Tx) error {
+		rows, err := tx.Query(selectBlocksStatement, c.args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var block BlockJSON
+			if err := rows.Scan(&block.Hash, &block.ParentHash, &block.Number, &block.Timestamp); err != nil {
+				return err
+			}
+			blocks = append(blocks, block)
+		}
+
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	hasNextPage := uint64(len(blocks)) > page.First
+	if hasNextPage {
+		blocks = blocks[:page.First]
+	}
+	return blocks, hasNextPage, nil
+}
+
+// GetL1BlockByNumber returns the indexed L1 block with the given number, or
+// nil if it isn't indexed.
+func (d *Database) GetL1BlockByNumber(number uint64) (*BlockJSON, error) {
+	return d.getBlock("l1_blocks", "number", number)
+}
+
+// GetL1BlockByHash returns the indexed L1 block with the given hash, or nil
+// if it isn't indexed.
+func (d *Database) GetL1BlockByHash(hash common.Hash) (*BlockJSON, error) {
+	return d.getBlock("l1_blocks", "hash", hash.String())
+}
+
+// GetL2BlockByNumber returns the indexed L2 block with the given number, or
+// nil if it isn't indexed.
+func (d *Database) GetL2BlockByNumber(number uint64) (*BlockJSON, error) {
+	return d.getBlock("l2_blocks", "number", number)
+}
+
+// GetL2BlockByHash returns the indexed L2 block with the given hash, or nil
+// if it isn't indexed.
+func (d *Database) GetL2BlockByHash(hash common.Hash) (*BlockJSON, error) {
+	return d.getBlock("l2_blocks", "hash", hash.String())
+}
+
+func (d *Database) getBlock(table, column string, value interface{}) (*BlockJSON, error) {
+	selectBlockStatement := fmt.Sprintf(`
+	SELECT hash, parent_hash, number, timestamp FROM %s WHERE %s = $1;
+	`, table, column)
+
+	var block BlockJSON
+	err := d.db.QueryRow(selectBlockStatement, value).Scan(&block.Hash, &block.ParentHash, &block.Number, &block.Timestamp)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &block, nil
+}
+
+const selectStateBatches = `
+	SELECT
+		state_batches."index", state_batches.root, state_batches.size, state_batches.prev_total, state_batches.extra_data, state_batches.block_hash,
+		l1_blocks.number, l1_blocks.timestamp
+	FROM state_batches
+	INNER JOIN l1_blocks ON state_batches.block_hash = l1_blocks.hash
+`
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanStateBatch(row scanner) (StateBatchJSON, error) {
+	var batch StateBatchJSON
+	err := row.Scan(
+		&batch.Index, &batch.Root, &batch.Size, &batch.PrevTotal, &batch.ExtraData, &batch.BlockHash,
+		&batch.BlockNumber, &batch.BlockTimestamp,
+	)
+	return batch, err
+}
+
+// GetStateBatches returns the state batches appended in the L1 blocks
+// selected by filter, ordered by block and index, and whether there are more
+// state batches after them.
+func (d *Database) GetStateBatches(filter BlockFilter, page CursorParam) ([]StateBatchJSON, bool, error) {
+	var c conditions
+	c.addBlock(filter, "l1_blocks")
+	c.addCursor(page, "l1_blocks.number", `state_batches."index"`)
+	limit := c.arg(page.First + 1)
+
+	selectStateBatchesStatement := fmt.Sprintf(`%s
+	%s ORDER BY l1_blocks.number, state_batches."index" LIMIT $%d;
+	`, selectStateBatches, c.where(), limit)
+
+	var batches []StateBatchJSON
+	err := txn(d.db, func(tx *sql.Tx) error {
+		rows, err := tx.Query(selectStateBatchesStatement, c.args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			batch, err := scanStateBatch(rows)
+			if err != nil {
+				return err
+			}
+			batches = append(batches, batch)
+		}
+
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	hasNextPage := uint64(len(batches)) > page.First
+	if hasNextPage {
+		batches = batches[:page.First]
+	}
+	return batches, hasNextPage, nil
+}
+
+// GetStateBatchByIndex returns the state batch with the given index, or nil
+// if it isn't indexed.
+func (d *Database) GetStateBatchByIndex(index uint64) (*StateBatchJSON, error) {
+	return d.getStateBatch(selectStateBatches+`
+	WHERE state_batches."index" = $1;
+	`, index)
+}
+
+// GetStateBatchByL2Block returns the first state batch containing the L2
+// block with the given number, or nil if there is none yet.
+func (d *Database) GetStateBatchByL2Block(number uint64) (*StateBatchJSON, error) {
+	return d.getStateBatch(selectStateBatches+`
+	WHERE state_batches.size + state_batches.prev_total >= $1 ORDER BY state_batches."index" LIMIT 1;
+	`, number)
+}
+
+func (d *Database) getStateBatch(query string, arg interface{}) (*StateBatchJSON, error) {
+	batch, err := scanStateBatch(d.db.QueryRow(query, arg))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
@@ -0,0 +1,15 @@
+package db
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// RelayedMessage is a cross domain message relayed by the
+// L1CrossDomainMessenger or the L2CrossDomainMessenger, such as the message of
+// a bedrock deposit. Failed relays can be replayed until a relay succeeds.
+type RelayedMessage struct {
+	MessageHash common.Hash
+	TxHash      common.Hash
+	LogIndex    uint
+	Failed      bool
+}
@@ -0,0 +1,175 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// GetPreviousL1Block returns the highest known L1 block below number, or nil
+// if there is none.
+func (d *Database) GetPreviousL1Block(number uint64) (*BlockLocator, error) {
+	const selectPreviousBlockStatement = `
+	SELECT number, hash FROM l1_blocks WHERE number < $1 ORDER BY number DESC LIMIT 1
+	`
+
+	return d.getPreviousBlock(selectPreviousBlockStatement, number)
+}
+
+// GetPreviousL2Block returns the highest known L2 block below number, or nil
+// if there is none.
+func (d *Database) GetPreviousL2Block(number uint64) (*BlockLocator, error) {
+	const selectPreviousBlockStatement = `
+	SELECT number, hash FROM l2_blocks WHERE number < $1 ORDER BY number DESC LIMIT 1
+	`
+
+	return d.getPreviousBlock(selectPreviousBlockStatement, number)
+}
+
+func (d *Database) getPreviousBlock(query string, number uint64) (*BlockLocator, error) {
+	var hash string
+	var block BlockLocator
+	err := d.db.QueryRow(query, number).Scan(&block.Number, &hash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	block.Hash = common.HexToHash(hash)
+	return &block, nil
+}
+
+// RollbackL1Blocks deletes the L1 blocks above number, which were orphaned by
+// a reorg, along with their deposits, state batches, L2 outputs, sent and
+// relayed messages and contract events. The withdrawals that were proven or finalized in those blocks are
+// marked as not proven or not finalized again, and the L2 outputs deleted in
+// those blocks are restored. It returns the number of deleted blocks.
+func (d *Database) RollbackL1Blocks(number uint64) (int64, error) {
+	const resetProvenWithdrawalsStatement = `
+	UPDATE withdrawals SET (br_withdrawal_proven_tx_hash, br_withdrawal_proven_log_index, br_withdrawal_proven_block_hash, br_withdrawal_finalizable_at) = (NULL, NULL, NULL, NULL)
+	WHERE br_withdrawal_proven_block_hash IN (SELECT hash FROM l1_blocks WHERE number > $1)
+	`
+
+	const resetFinalizedWithdrawalsStatement = `
+	UPDATE withdrawals SET (br_withdrawal_finalized_tx_hash, br_withdrawal_finalized_log_index, br_withdrawal_finalized_success, br_withdrawal_finalized_block_hash) = (NULL, NULL, NULL, NULL)
+	WHERE br_withdrawal_finalized_block_hash IN (SELECT hash FROM l1_blocks WHERE number > $1)
+	`
+
+	const resetWithdrawalStateBatchesStatement = `
+	UPDATE withdrawals SET state_batch = NULL
+	WHERE state_batch IN (
+		SELECT "index" FROM state_batches
+		WHERE block_hash IN (SELECT hash FROM l1_blocks WHERE number > $1)
+	)
+	`
+
+	const deleteStateBatchesStatement = `
+	DELETE FROM state_batches WHERE block_hash IN (SELECT hash FROM l1_blocks WHERE number > $1)
+	`
+
+	const restoreL2OutputsStatement = `
+	UPDATE l2_outputs SET deleted_block_hash = NULL
+	WHERE deleted_block_hash IN (SELECT hash FROM l1_blocks WHERE number > $1)
+	`
+
+	const deleteL2OutputsStatement = `
+	DELETE FROM l2_outputs WHERE block_hash IN (SELECT hash FROM l1_blocks WHERE number > $1)
+	`
+
+	const deleteSentMessagesStatement = `
+	DELETE FROM sent_messages WHERE chain = 'l1' AND block_hash IN (SELECT hash FROM l1_blocks WHERE number > $1)
+	`
+
+	const deleteRelayedMessagesStatement = `
+	DELETE FROM relayed_messages WHERE chain = 'l1' AND block_hash IN (SELECT hash FROM l1_blocks WHERE number > $1)
+	`
+
+	const deleteContractEventsStatement = `
+	DELETE FROM contract_events WHERE chain = 'l1' AND block_hash IN (SELECT hash FROM l1_blocks WHERE number > $1)
+	`
+
+	const deleteDepositsStatement = `
+	DELETE FROM deposits WHERE block_hash IN (SELECT hash FROM l1_blocks WHERE number > $1)
+	`
+
+	const deleteBlocksStatement = `
+	DELETE FROM l1_blocks WHERE number > $1
+	`
+
+	return d.rollbackBlocks(number, []string{
+		resetProvenWithdrawalsStatement,
+		resetFinalizedWithdrawalsStatement,
+		resetWithdrawalStateBatchesStatement,
+		deleteStateBatchesStatement,
+		restoreL2OutputsStatement,
+		deleteL2OutputsStatement,
+		deleteSentMessagesStatement,
+		deleteRelayedMessagesStatement,
+		deleteContractEventsStatement,
+		deleteDepositsStatement,
+		deleteBlocksStatement,
+	})
+}
+
+// RollbackL2Blocks deletes the L2 blocks above number, which were orphaned by
+// a reorg, along with their withdrawals, sent and relayed messages, created
+// token pairs and contract events. It returns the number of deleted blocks.
+func (d *Database) RollbackL2Blocks(number uint64) (int64, error) {
+	const deleteWithdrawalsStatement = `
+	DELETE FROM withdrawals WHERE block_hash IN (SELECT hash FROM l2_blocks WHERE number > $1)
+	`
+
+	const deleteRelayedMessagesStatement = `
+	DELETE FROM relayed_messages WHERE chain = 'l2' AND block_hash IN (SELECT hash FROM l2_blocks WHERE number > $1)
+	`
+
+	const deleteSentMessagesStatement = `
+	DELETE FROM sent_messages WHERE chain = 'l2' AND block_hash IN (SELECT hash FROM l2_blocks WHERE number > $1)
+	`
+
+	const deleteTokenPairsStatement = `
+	DELETE FROM token_pairs WHERE block_hash IN (SELECT hash FROM l2_blocks WHERE number > $1)
+	`
+
+	const deleteContractEventsStatement = `
+	DELETE FROM contract_events WHERE chain = 'l2' AND block_hash IN (SELECT hash FROM l2_blocks WHERE number > $1)
+	`
+
+	const deleteBlocksStatement = `
+	DELETE FROM l2_blocks WHERE number > $1
+	`
+
+	return d.rollbackBlocks(number, []string{
+		deleteWithdrawalsStatement,
+		deleteRelayedMessagesStatement,
+		deleteSentMessagesStatement,
+		deleteTokenPairsStatement,
+		deleteContractEventsStatement,
+		deleteBlocksStatement,
+	})
+}
+
+// rollbackBlocks runs the statements in one transaction, and returns the
+// number of rows affected by the last one.
+func (d *Database) rollbackBlocks(number uint64, statements []string) (int64, error) {
+	var deleted int64
+	err := txn(d.db, func(tx *sql.Tx) error {
+		for _, statement := range statements {
+			res, err := tx.Exec(statement, number)
+			if err != nil {
+				return err
+			}
+			deleted, err = res.RowsAffected()
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return deleted, nil
+}
@@ -132,17 +132,271 @@ ALTER TABLE withdrawals ADD COLUMN IF NOT EXISTS br_withdrawal_finalized_success
 CREATE INDEX IF NOT EXISTS withdrawals_br_withdrawal_hash ON withdrawals(br_withdrawal_hash);
 `
 
-var schema = []string{
-	createL1BlocksTable,
-	createL2BlocksTable,
-	createL1TokensTable,
-	createL2TokensTable,
-	createStateBatchesTable,
-	insertETHL1Token,
-	insertETHL2Token,
-	createDepositsTable,
-	createWithdrawalsTable,
-	createL1L2NumberIndex,
-	createAirdropsTable,
-	updateWithdrawalsTable,
-}
+const dropInitialTables = `
+DROP TABLE IF EXISTS withdrawals;
+DROP TABLE IF EXISTS deposits;
+DROP TABLE IF EXISTS state_batches;
+DROP TABLE IF EXISTS l2_tokens;
+DROP TABLE IF EXISTS l1_tokens;
+DROP TABLE IF EXISTS l2_blocks;
+DROP TABLE IF EXISTS l1_blocks;
+`
+
+const dropAirdropsTable = `
+DROP TABLE IF EXISTS airdrops;
+`
+
+const revertWithdrawalsTable = `
+DROP INDEX IF EXISTS withdrawals_br_withdrawal_hash;
+ALTER TABLE withdrawals DROP COLUMN IF EXISTS br_withdrawal_hash;
+ALTER TABLE withdrawals DROP COLUMN IF EXISTS br_withdrawal_proven_tx_hash;
+ALTER TABLE withdrawals DROP COLUMN IF EXISTS br_withdrawal_proven_log_index;
+ALTER TABLE withdrawals DROP COLUMN IF EXISTS br_withdrawal_finalized_tx_hash;
+ALTER TABLE withdrawals DROP COLUMN IF EXISTS br_withdrawal_finalized_log_index;
+ALTER TABLE withdrawals DROP COLUMN IF EXISTS br_withdrawal_finalized_success;
+`
+
+const createSchemaMigrationsTable = `
+CREATE TABLE IF NOT EXISTS schema_migrations (
+	version INTEGER NOT NULL PRIMARY KEY,
+	description VARCHAR NOT NULL,
+	applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+)
+`
+
+const addWithdrawalBlockHashColumns = `
+ALTER TABLE withdrawals ADD COLUMN IF NOT EXISTS br_withdrawal_proven_block_hash VARCHAR NULL;
+ALTER TABLE withdrawals ADD COLUMN IF NOT EXISTS br_withdrawal_finalized_block_hash VARCHAR NULL;
+CREATE INDEX IF NOT EXISTS withdrawals_br_withdrawal_proven_block_hash ON withdrawals(br_withdrawal_proven_block_hash);
+CREATE INDEX IF NOT EXISTS withdrawals_br_withdrawal_finalized_block_hash ON withdrawals(br_withdrawal_finalized_block_hash);
+CREATE INDEX IF NOT EXISTS deposits_block_hash ON deposits(block_hash);
+CREATE INDEX IF NOT EXISTS withdrawals_block_hash ON withdrawals(block_hash);
+`
+
+const dropWithdrawalBlockHashColumns = `
+DROP INDEX IF EXISTS withdrawals_block_hash;
+DROP INDEX IF EXISTS deposits_block_hash;
+DROP INDEX IF EXISTS withdrawals_br_withdrawal_finalized_block_hash;
+DROP INDEX IF EXISTS withdrawals_br_withdrawal_proven_block_hash;
+ALTER TABLE withdrawals DROP COLUMN IF EXISTS br_withdrawal_proven_block_hash;
+ALTER TABLE withdrawals DROP COLUMN IF EXISTS br_withdrawal_finalized_block_hash;
+`
+
+const createL2OutputsTable = `
+CREATE TABLE IF NOT EXISTS l2_outputs (
+	guid VARCHAR PRIMARY KEY NOT NULL,
+	l2_output_index INTEGER NOT NULL,
+	output_root VARCHAR NOT NULL,
+	l2_block_number INTEGER NOT NULL,
+	l1_timestamp INTEGER NOT NULL,
+	block_hash VARCHAR NOT NULL REFERENCES l1_blocks(hash),
+	tx_hash VARCHAR NOT NULL,
+	log_index INTEGER NOT NULL,
+	deleted_block_hash VARCHAR NULL REFERENCES l1_blocks(hash)
+);
+CREATE INDEX IF NOT EXISTS l2_outputs_l2_block_number ON l2_outputs(l2_block_number);
+CREATE INDEX IF NOT EXISTS l2_outputs_block_hash ON l2_outputs(block_hash);
+CREATE INDEX IF NOT EXISTS l2_outputs_deleted_block_hash ON l2_outputs(deleted_block_hash);
+ALTER TABLE withdrawals ADD COLUMN IF NOT EXISTS br_withdrawal_finalizable_at INTEGER NULL;
+`
+
+const dropL2OutputsTable = `
+ALTER TABLE withdrawals DROP COLUMN IF EXISTS br_withdrawal_finalizable_at;
+DROP TABLE IF EXISTS l2_outputs;
+`
+
+const createContractEventsTable = `
+CREATE TABLE IF NOT EXISTS contract_events (
+	guid VARCHAR PRIMARY KEY NOT NULL,
+	chain VARCHAR NOT NULL,
+	contract_name VARCHAR NOT NULL,
+	contract_address VARCHAR NOT NULL,
+	event_name VARCHAR NOT NULL,
+	event_signature VARCHAR NOT NULL,
+	args JSONB NOT NULL,
+	block_hash VARCHAR NOT NULL,
+	block_number INTEGER NOT NULL,
+	block_timestamp INTEGER NOT NULL,
+	tx_hash VARCHAR NOT NULL,
+	log_index INTEGER NOT NULL,
+	UNIQUE (chain, block_hash, log_index)
+);
+CREATE INDEX IF NOT EXISTS contract_events_contract_event ON contract_events(contract_address, event_name);
+CREATE INDEX IF NOT EXISTS contract_events_chain_block_number ON contract_events(chain, block_number);
+CREATE INDEX IF NOT EXISTS contract_events_args ON contract_events USING GIN (args jsonb_path_ops);
+`
+
+const dropContractEventsTable = `
+DROP TABLE IF EXISTS contract_events;
+`
+
+const createTransferFilterIndexes = `
+CREATE INDEX IF NOT EXISTS deposits_from_address ON deposits(from_address);
+CREATE INDEX IF NOT EXISTS deposits_to_address ON deposits(to_address);
+CREATE INDEX IF NOT EXISTS deposits_l1_token ON deposits(l1_token);
+CREATE INDEX IF NOT EXISTS deposits_l2_token ON deposits(l2_token);
+CREATE INDEX IF NOT EXISTS withdrawals_from_address ON withdrawals(from_address);
+CREATE INDEX IF NOT EXISTS withdrawals_to_address ON withdrawals(to_address);
+CREATE INDEX IF NOT EXISTS withdrawals_l1_token ON withdrawals(l1_token);
+CREATE INDEX IF NOT EXISTS withdrawals_l2_token ON withdrawals(l2_token);
+CREATE INDEX IF NOT EXISTS l1_blocks_timestamp ON l1_blocks(timestamp);
+CREATE INDEX IF NOT EXISTS l2_blocks_timestamp ON l2_blocks(timestamp);
+`
+
+const dropTransferFilterIndexes = `
+DROP INDEX IF EXISTS l2_blocks_timestamp;
+DROP INDEX IF EXISTS l1_blocks_timestamp;
+DROP INDEX IF EXISTS withdrawals_l2_token;
+DROP INDEX IF EXISTS withdrawals_l1_token;
+DROP INDEX IF EXISTS withdrawals_to_address;
+DROP INDEX IF EXISTS withdrawals_from_address;
+DROP INDEX IF EXISTS deposits_l2_token;
+DROP INDEX IF EXISTS deposits_l1_token;
+DROP INDEX IF EXISTS deposits_to_address;
+DROP INDEX IF EXISTS deposits_from_address;
+`
+
+const createBackfillTables = `
+CREATE TABLE IF NOT EXISTS backfills (
+	chain VARCHAR NOT NULL PRIMARY KEY,
+	target INTEGER NOT NULL,
+	committed_number INTEGER NOT NULL,
+	committed_hash VARCHAR NOT NULL
+);
+CREATE TABLE IF NOT EXISTS backfill_chunks (
+	chain VARCHAR NOT NULL,
+	start_number INTEGER NOT NULL,
+	end_number INTEGER NOT NULL,
+	parent_hash VARCHAR NOT NULL,
+	hash VARCHAR NOT NULL,
+	data BYTEA NOT NULL,
+	PRIMARY KEY (chain, start_number)
+);
+`
+
+const dropBackfillTables = `
+DROP TABLE IF EXISTS backfill_chunks;
+DROP TABLE IF EXISTS backfills;
+`
+
+const createRelayedMessagesTable = `
+ALTER TABLE deposits ADD COLUMN IF NOT EXISTS msg_hash VARCHAR NULL;
+CREATE INDEX IF NOT EXISTS deposits_msg_hash ON deposits(msg_hash);
+CREATE TABLE IF NOT EXISTS relayed_messages (
+	guid VARCHAR PRIMARY KEY NOT NULL,
+	msg_hash VARCHAR NOT NULL,
+	tx_hash VARCHAR NOT NULL,
+	log_index INTEGER NOT NULL,
+	block_hash VARCHAR NOT NULL REFERENCES l2_blocks(hash)
+);
+CREATE INDEX IF NOT EXISTS relayed_messages_msg_hash ON relayed_messages(msg_hash);
+CREATE INDEX IF NOT EXISTS relayed_messages_block_hash ON relayed_messages(block_hash);
+`
+
+const dropRelayedMessagesTable = `
+DROP TABLE IF EXISTS relayed_messages;
+DROP INDEX IF EXISTS deposits_msg_hash;
+ALTER TABLE deposits DROP COLUMN IF EXISTS msg_hash;
+`
+
+const createWebhookDeliveriesTable = `
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+	guid VARCHAR PRIMARY KEY NOT NULL,
+	webhook VARCHAR NOT NULL,
+	event_id VARCHAR NOT NULL,
+	event_type VARCHAR NOT NULL,
+	payload VARCHAR NOT NULL,
+	status VARCHAR NOT NULL,
+	attempts INTEGER NOT NULL DEFAULT 0,
+	response_status INTEGER NULL,
+	last_error VARCHAR NULL,
+	next_attempt_at INTEGER NOT NULL,
+	created_at INTEGER NOT NULL,
+	delivered_at INTEGER NULL,
+	UNIQUE (webhook, event_id)
+);
+CREATE INDEX IF NOT EXISTS webhook_deliveries_status_next_attempt_at ON webhook_deliveries(status, next_attempt_at);
+`
+
+const dropWebhookDeliveriesTable = `
+DROP TABLE IF EXISTS webhook_deliveries;
+`
+
+// The ETH pairs are canonical, including the pair of earlier transactions
+// which used 0x0000000000000000000000000000000000000000 as address of ETH on
+// L2. Pairs seen in earlier deposits and withdrawals are registered as seen in
+// bridge events.
+const createTokenPairsTable = `
+CREATE TABLE IF NOT EXISTS token_pairs (
+	l1_token VARCHAR NOT NULL,
+	l2_token VARCHAR NOT NULL,
+	source VARCHAR NOT NULL,
+	block_hash VARCHAR NULL,
+	PRIMARY KEY (l1_token, l2_token)
+);
+CREATE INDEX IF NOT EXISTS token_pairs_l2_token ON token_pairs(l2_token);
+CREATE INDEX IF NOT EXISTS token_pairs_block_hash ON token_pairs(block_hash);
+INSERT INTO token_pairs
+	(l1_token, l2_token, source)
+VALUES
+	('0x0000000000000000000000000000000000000000', '0xDeadDeAddeAddEAddeadDEaDDEAdDeaDDeAD0000', 'native'),
+	('0x0000000000000000000000000000000000000000', '0x0000000000000000000000000000000000000000', 'native')
+ON CONFLICT (l1_token, l2_token) DO NOTHING;
+INSERT INTO token_pairs
+	(l1_token, l2_token, source)
+SELECT DISTINCT l1_token, l2_token, 'bridge' FROM deposits WHERE true
+ON CONFLICT (l1_token, l2_token) DO NOTHING;
+INSERT INTO token_pairs
+	(l1_token, l2_token, source)
+SELECT DISTINCT l1_token, l2_token, 'bridge' FROM withdrawals WHERE true
+ON CONFLICT (l1_token, l2_token) DO NOTHING;
+`
+
+const dropTokenPairsTable = `
+DROP TABLE IF EXISTS token_pairs;
+`
+
+// Messages sent on L2 are relayed on L1, so the blocks of the relayed messages
+// are no longer only L2 blocks.
+const createSentMessagesTable = `
+CREATE TABLE IF NOT EXISTS sent_messages (
+	guid VARCHAR PRIMARY KEY NOT NULL,
+	chain VARCHAR NOT NULL,
+	msg_hash VARCHAR NOT NULL,
+	nonce VARCHAR NOT NULL,
+	sender VARCHAR NOT NULL,
+	target VARCHAR NOT NULL,
+	value VARCHAR NOT NULL,
+	gas_limit VARCHAR NOT NULL,
+	data BYTEA NOT NULL,
+	block_hash VARCHAR NOT NULL,
+	block_number INTEGER NOT NULL,
+	block_timestamp INTEGER NOT NULL,
+	tx_hash VARCHAR NOT NULL,
+	log_index INTEGER NOT NULL,
+	UNIQUE (chain, block_hash, log_index)
+);
+CREATE INDEX IF NOT EXISTS sent_messages_msg_hash ON sent_messages(msg_hash);
+CREATE INDEX IF NOT EXISTS sent_messages_sender ON sent_messages(sender);
+CREATE INDEX IF NOT EXISTS sent_messages_target ON sent_messages(target);
+CREATE INDEX IF NOT EXISTS sent_messages_chain_block_number ON sent_messages(chain, block_number);
+ALTER TABLE relayed_messages ADD COLUMN IF NOT EXISTS chain VARCHAR NOT NULL DEFAULT 'l2';
+ALTER TABLE relayed_messages ADD COLUMN IF NOT EXISTS failed BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE relayed_messages DROP CONSTRAINT IF EXISTS relayed_messages_block_hash_fkey;
+`
+
+const dropSentMessagesTable = `
+DELETE FROM relayed_messages WHERE chain != 'l2' OR failed;
+ALTER TABLE relayed_messages DROP COLUMN IF EXISTS failed;
+ALTER TABLE relayed_messages DROP COLUMN IF EXISTS chain;
+ALTER TABLE relayed_messages ADD CONSTRAINT relayed_messages_block_hash_fkey FOREIGN KEY (block_hash) REFERENCES l2_blocks(hash);
+DROP TABLE IF EXISTS sent_messages;
+`
+
+const createWebhookDeliveriesWebhookIndex = `
+CREATE INDEX IF NOT EXISTS webhook_deliveries_webhook_status_next_attempt_at ON webhook_deliveries(webhook, status, next_attempt_at);
+`
+
+const dropWebhookDeliveriesWebhookIndex = `
+DROP INDEX IF EXISTS webhook_deliveries_webhook_status_next_attempt_at;
+`
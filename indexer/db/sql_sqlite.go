@@ -0,0 +1,218 @@
+package db
+
+// The statements below replace the statements of the migrations that SQLite
+// doesn't support: index as an unquoted column name, regular expressions,
+// IF [NOT] EXISTS on columns, JSONB and GIN indexes.
+
+const sqliteCreateStateBatchesTable = `
+CREATE TABLE IF NOT EXISTS state_batches (
+	"index" INTEGER NOT NULL PRIMARY KEY,
+	root VARCHAR NOT NULL,
+	size INTEGER NOT NULL,
+	prev_total INTEGER NOT NULL,
+	extra_data BLOB NOT NULL,
+	block_hash VARCHAR NOT NULL REFERENCES l1_blocks(hash)
+);
+CREATE INDEX IF NOT EXISTS state_batches_block_hash ON state_batches(block_hash);
+CREATE INDEX IF NOT EXISTS state_batches_size ON state_batches(size);
+CREATE INDEX IF NOT EXISTS state_batches_prev_total ON state_batches(prev_total);
+`
+
+const sqliteCreateWithdrawalsTable = `
+CREATE TABLE IF NOT EXISTS withdrawals (
+	guid VARCHAR PRIMARY KEY NOT NULL,
+	from_address VARCHAR NOT NULL,
+	to_address VARCHAR NOT NULL,
+	l1_token VARCHAR NOT NULL,
+	l2_token VARCHAR NOT NULL REFERENCES l2_tokens(address),
+	amount VARCHAR NOT NULL,
+	data BLOB NOT NULL,
+	log_index INTEGER NOT NULL,
+	block_hash VARCHAR NOT NULL REFERENCES l2_blocks(hash),
+	tx_hash VARCHAR NOT NULL,
+	state_batch INTEGER REFERENCES state_batches("index")
+)
+`
+
+const sqliteCreateAirdropsTable = `
+CREATE TABLE IF NOT EXISTS airdrops (
+	address VARCHAR(42) PRIMARY KEY,
+	voter_amount VARCHAR NOT NULL DEFAULT '0' CHECK(voter_amount <> '' AND voter_amount NOT GLOB '*[^0-9]*'),
+	multisig_signer_amount VARCHAR NOT NULL DEFAULT '0' CHECK(multisig_signer_amount <> '' AND multisig_signer_amount NOT GLOB '*[^0-9]*'),
+	gitcoin_amount VARCHAR NOT NULL DEFAULT '0' CHECK(gitcoin_amount <> '' AND gitcoin_amount NOT GLOB '*[^0-9]*'),
+	active_bridged_amount VARCHAR NOT NULL DEFAULT '0' CHECK(active_bridged_amount <> '' AND active_bridged_amount NOT GLOB '*[^0-9]*'),
+	op_user_amount VARCHAR NOT NULL DEFAULT '0' CHECK(op_user_amount <> '' AND op_user_amount NOT GLOB '*[^0-9]*'),
+	op_repeat_user_amount VARCHAR NOT NULL DEFAULT '0' CHECK(op_repeat_user_amount <> '' AND op_repeat_user_amount NOT GLOB '*[^0-9]*'),
+	op_og_amount VARCHAR NOT NULL DEFAULT '0' CHECK(op_og_amount <> '' AND op_og_amount NOT GLOB '*[^0-9]*'),
+	bonus_amount VARCHAR NOT NULL DEFAULT '0' CHECK(bonus_amount <> '' AND bonus_amount NOT GLOB '*[^0-9]*'),
+	total_amount VARCHAR NOT NULL CHECK(total_amount <> '' AND total_amount NOT GLOB '*[^0-9]*')
+)
+`
+
+const sqliteUpdateWithdrawalsTable = `
+ALTER TABLE withdrawals ADD COLUMN br_withdrawal_hash VARCHAR NULL;
+ALTER TABLE withdrawals ADD COLUMN br_withdrawal_proven_tx_hash VARCHAR NULL;
+ALTER TABLE withdrawals ADD COLUMN br_withdrawal_proven_log_index INTEGER NULL;
+ALTER TABLE withdrawals ADD COLUMN br_withdrawal_finalized_tx_hash VARCHAR NULL;
+ALTER TABLE withdrawals ADD COLUMN br_withdrawal_finalized_log_index INTEGER NULL;
+ALTER TABLE withdrawals ADD COLUMN br_withdrawal_finalized_success BOOLEAN NULL;
+CREATE INDEX IF NOT EXISTS withdrawals_br_withdrawal_hash ON withdrawals(br_withdrawal_hash);
+`
+
+const sqliteRevertWithdrawalsTable = `
+DROP INDEX IF EXISTS withdrawals_br_withdrawal_hash;
+ALTER TABLE withdrawals DROP COLUMN br_withdrawal_hash;
+ALTER TABLE withdrawals DROP COLUMN br_withdrawal_proven_tx_hash;
+ALTER TABLE withdrawals DROP COLUMN br_withdrawal_proven_log_index;
+ALTER TABLE withdrawals DROP COLUMN br_withdrawal_finalized_tx_hash;
+ALTER TABLE withdrawals DROP COLUMN br_withdrawal_finalized_log_index;
+ALTER TABLE withdrawals DROP COLUMN br_withdrawal_finalized_success;
+`
+
+const sqliteCreateSchemaMigrationsTable = `
+CREATE TABLE IF NOT EXISTS schema_migrations (
+	version INTEGER NOT NULL PRIMARY KEY,
+	description VARCHAR NOT NULL,
+	applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+)
+`
+
+const sqliteAddWithdrawalBlockHashColumns = `
+ALTER TABLE withdrawals ADD COLUMN br_withdrawal_proven_block_hash VARCHAR NULL;
+ALTER TABLE withdrawals ADD COLUMN br_withdrawal_finalized_block_hash VARCHAR NULL;
+CREATE INDEX IF NOT EXISTS withdrawals_br_withdrawal_proven_block_hash ON withdrawals(br_withdrawal_proven_block_hash);
+CREATE INDEX IF NOT EXISTS withdrawals_br_withdrawal_finalized_block_hash ON withdrawals(br_withdrawal_finalized_block_hash);
+CREATE INDEX IF NOT EXISTS deposits_block_hash ON deposits(block_hash);
+CREATE INDEX IF NOT EXISTS withdrawals_block_hash ON withdrawals(block_hash);
+`
+
+const sqliteDropWithdrawalBlockHashColumns = `
+DROP INDEX IF EXISTS withdrawals_block_hash;
+DROP INDEX IF EXISTS deposits_block_hash;
+DROP INDEX IF EXISTS withdrawals_br_withdrawal_finalized_block_hash;
+DROP INDEX IF EXISTS withdrawals_br_withdrawal_proven_block_hash;
+ALTER TABLE withdrawals DROP COLUMN br_withdrawal_proven_block_hash;
+ALTER TABLE withdrawals DROP COLUMN br_withdrawal_finalized_block_hash;
+`
+
+const sqliteCreateL2OutputsTable = `
+CREATE TABLE IF NOT EXISTS l2_outputs (
+	guid VARCHAR PRIMARY KEY NOT NULL,
+	l2_output_index INTEGER NOT NULL,
+	output_root VARCHAR NOT NULL,
+	l2_block_number INTEGER NOT NULL,
+	l1_timestamp INTEGER NOT NULL,
+	block_hash VARCHAR NOT NULL REFERENCES l1_blocks(hash),
+	tx_hash VARCHAR NOT NULL,
+	log_index INTEGER NOT NULL,
+	deleted_block_hash VARCHAR NULL REFERENCES l1_blocks(hash)
+);
+CREATE INDEX IF NOT EXISTS l2_outputs_l2_block_number ON l2_outputs(l2_block_number);
+CREATE INDEX IF NOT EXISTS l2_outputs_block_hash ON l2_outputs(block_hash);
+CREATE INDEX IF NOT EXISTS l2_outputs_deleted_block_hash ON l2_outputs(deleted_block_hash);
+ALTER TABLE withdrawals ADD COLUMN br_withdrawal_finalizable_at INTEGER NULL;
+`
+
+const sqliteDropL2OutputsTable = `
+ALTER TABLE withdrawals DROP COLUMN br_withdrawal_finalizable_at;
+DROP TABLE IF EXISTS l2_outputs;
+`
+
+// The args are stored as JSON text, and matched with json_extract.
+const sqliteCreateContractEventsTable = `
+CREATE TABLE IF NOT EXISTS contract_events (
+	guid VARCHAR PRIMARY KEY NOT NULL,
+	chain VARCHAR NOT NULL,
+	contract_name VARCHAR NOT NULL,
+	contract_address VARCHAR NOT NULL,
+	event_name VARCHAR NOT NULL,
+	event_signature VARCHAR NOT NULL,
+	args TEXT NOT NULL,
+	block_hash VARCHAR NOT NULL,
+	block_number INTEGER NOT NULL,
+	block_timestamp INTEGER NOT NULL,
+	tx_hash VARCHAR NOT NULL,
+	log_index INTEGER NOT NULL,
+	UNIQUE (chain, block_hash, log_index)
+);
+CREATE INDEX IF NOT EXISTS contract_events_contract_event ON contract_events(contract_address, event_name);
+CREATE INDEX IF NOT EXISTS contract_events_chain_block_number ON contract_events(chain, block_number);
+`
+
+const sqliteCreateRelayedMessagesTable = `
+ALTER TABLE deposits ADD COLUMN msg_hash VARCHAR NULL;
+CREATE INDEX IF NOT EXISTS deposits_msg_hash ON deposits(msg_hash);
+CREATE TABLE IF NOT EXISTS relayed_messages (
+	guid VARCHAR PRIMARY KEY NOT NULL,
+	msg_hash VARCHAR NOT NULL,
+	tx_hash VARCHAR NOT NULL,
+	log_index INTEGER NOT NULL,
+	block_hash VARCHAR NOT NULL REFERENCES l2_blocks(hash)
+);
+CREATE INDEX IF NOT EXISTS relayed_messages_msg_hash ON relayed_messages(msg_hash);
+CREATE INDEX IF NOT EXISTS relayed_messages_block_hash ON relayed_messages(block_hash);
+`
+
+const sqliteDropRelayedMessagesTable = `
+DROP TABLE IF EXISTS relayed_messages;
+DROP INDEX IF EXISTS deposits_msg_hash;
+ALTER TABLE deposits DROP COLUMN msg_hash;
+`
+
+// SQLite can't drop the foreign key of the relayed messages, so the table is
+// rebuilt.
+const sqliteCreateSentMessagesTable = `
+CREATE TABLE IF NOT EXISTS sent_messages (
+	guid VARCHAR PRIMARY KEY NOT NULL,
+	chain VARCHAR NOT NULL,
+	msg_hash VARCHAR NOT NULL,
+	nonce VARCHAR NOT NULL,
+	sender VARCHAR NOT NULL,
+	target VARCHAR NOT NULL,
+	value VARCHAR NOT NULL,
+	gas_limit VARCHAR NOT NULL,
+	data BLOB NOT NULL,
+	block_hash VARCHAR NOT NULL,
+	block_number INTEGER NOT NULL,
+	block_timestamp INTEGER NOT NULL,
+	tx_hash VARCHAR NOT NULL,
+	log_index INTEGER NOT NULL,
+	UNIQUE (chain, block_hash, log_index)
+);
+CREATE INDEX IF NOT EXISTS sent_messages_msg_hash ON sent_messages(msg_hash);
+CREATE INDEX IF NOT EXISTS sent_messages_sender ON sent_messages(sender);
+CREATE INDEX IF NOT EXISTS sent_messages_target ON sent_messages(target);
+CREATE INDEX IF NOT EXISTS sent_messages_chain_block_number ON sent_messages(chain, block_number);
+CREATE TABLE relayed_messages_new (
+	guid VARCHAR PRIMARY KEY NOT NULL,
+	msg_hash VARCHAR NOT NULL,
+	tx_hash VARCHAR NOT NULL,
+	log_index INTEGER NOT NULL,
+	block_hash VARCHAR NOT NULL,
+	chain VARCHAR NOT NULL DEFAULT 'l2',
+	failed BOOLEAN NOT NULL DEFAULT false
+);
+INSERT INTO relayed_messages_new (guid, msg_hash, tx_hash, log_index, block_hash)
+SELECT guid, msg_hash, tx_hash, log_index, block_hash FROM relayed_messages;
+DROP TABLE relayed_messages;
+ALTER TABLE relayed_messages_new RENAME TO relayed_messages;
+CREATE INDEX IF NOT EXISTS relayed_messages_msg_hash ON relayed_messages(msg_hash);
+CREATE INDEX IF NOT EXISTS relayed_messages_block_hash ON relayed_messages(block_hash);
+`
+
+const sqliteDropSentMessagesTable = `
+CREATE TABLE relayed_messages_old (
+	guid VARCHAR PRIMARY KEY NOT NULL,
+	msg_hash VARCHAR NOT NULL,
+	tx_hash VARCHAR NOT NULL,
+	log_index INTEGER NOT NULL,
+	block_hash VARCHAR NOT NULL REFERENCES l2_blocks(hash)
+);
+INSERT INTO relayed_messages_old (guid, msg_hash, tx_hash, log_index, block_hash)
+SELECT guid, msg_hash, tx_hash, log_index, block_hash FROM relayed_messages WHERE chain = 'l2' AND NOT failed;
+DROP TABLE relayed_messages;
+ALTER TABLE relayed_messages_old RENAME TO relayed_messages;
+CREATE INDEX IF NOT EXISTS relayed_messages_msg_hash ON relayed_messages(msg_hash);
+CREATE INDEX IF NOT EXISTS relayed_messages_block_hash ON relayed_messages(block_hash);
+DROP TABLE IF EXISTS sent_messages;
+`
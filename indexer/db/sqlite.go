@@ -0,0 +1,116 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"strings"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// sqliteDriverName is the name of the SQLite driver accepting the Postgres
+// style placeholders of the queries.
+const sqliteDriverName = "indexer_sqlite3"
+
+func init() {
+	sql.Register(sqliteDriverName, &sqliteDriver{&sqlite3.SQLiteDriver{}})
+}
+
+// NewSQLiteDatabase returns the embedded SQLite database stored at path,
+// after applying the pending migrations. The database is created if it
+// doesn't exist. It fails if the database was migrated by a newer indexer.
+func NewSQLiteDatabase(path string) (*Database, error) {
+	d, err := OpenSQLiteDatabase(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := d.migrateToLatest(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// OpenSQLiteDatabase returns the embedded SQLite database stored at path
+// without migrating it.
+func OpenSQLiteDatabase(path string) (*Database, error) {
+	dsn := fmt.Sprintf("file:%s?_foreign_keys=on&_busy_timeout=5000&_journal_mode=WAL", path)
+	db, err := sql.Open(sqliteDriverName, dsn)
+	if err != nil {
+		return nil, err
+	}
+	// SQLite allows a single writer, so the connection is shared to
+	// serialize the transactions instead of failing them when the database
+	// is locked.
+	db.SetMaxOpenConns(1)
+
+	err = db.Ping()
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &Database{
+		db:      db,
+		config:  path,
+		dialect: sqlite,
+	}, nil
+}
+
+// rebind replaces the $N placeholders of query, outside of string literals
+// and quoted identifiers, with the ?N placeholders of SQLite. SQLite binds $N
+// by order of appearance rather than by number.
+func rebind(query string) string {
+	var b strings.Builder
+	b.Grow(len(query))
+	var quote byte
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '$' && i+1 < len(query) && query[i+1] >= '0' && query[i+1] <= '9':
+			c = '?'
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// sqliteDriver wraps the SQLite driver to rebind the queries.
+type sqliteDriver struct {
+	driver *sqlite3.SQLiteDriver
+}
+
+func (d *sqliteDriver) Open(dsn string) (driver.Conn, error) {
+	conn, err := d.driver.Open(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &sqliteConn{conn.(*sqlite3.SQLiteConn)}, nil
+}
+
+type sqliteConn struct {
+	*sqlite3.SQLiteConn
+}
+
+func (c *sqliteConn) Prepare(query string) (driver.Stmt, error) {
+	return c.SQLiteConn.Prepare(rebind(query))
+}
+
+func (c *sqliteConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	return c.SQLiteConn.PrepareContext(ctx, rebind(query))
+}
+
+func (c *sqliteConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	return c.SQLiteConn.ExecContext(ctx, rebind(query), args)
+}
+
+func (c *sqliteConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	return c.SQLiteConn.QueryContext(ctx, rebind(query), args)
+}
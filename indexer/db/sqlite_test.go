@@ -0,0 +1,446 @@
+package db
+
+import (
+	"math/big"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSQLiteDatabase(t *testing.T) *Database {
+	d, err := NewSQLiteDatabase(filepath.Join(t.TempDir(), "indexer.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { d.Close() })
+	return d
+}
+
+func TestRebind(t *testing.T) {
+	require.Equal(t,
+		`SELECT * FROM t WHERE a = ?1 AND b = ?2 OR c = ?10`,
+		rebind(`SELECT * FROM t WHERE a = $1 AND b = $2 OR c = $10`),
+	)
+	require.Equal(t,
+		`SELECT '$1', "$2", $ FROM t WHERE a = ?3`,
+		rebind(`SELECT '$1', "$2", $ FROM t WHERE a = $3`),
+	)
+}
+
+func TestSQLiteMigrations(t *testing.T) {
+	d := newTestSQLiteDatabase(t)
+
+	version, err := d.SchemaVersion()
+	require.NoError(t, err)
+	require.Equal(t, LatestSchemaVersion(), version)
+
+	reverted, err := d.MigrateDown(0)
+	require.NoError(t, err)
+	require.Len(t, reverted, len(migrations))
+
+	applied, err := d.MigrateUp(LatestSchemaVersion())
+	require.NoError(t, err)
+	require.Len(t, applied, len(migrations))
+	require.NoError(t, d.CheckSchemaVersion())
+}
+
+func TestSQLiteStore(t *testing.T) {
+	d := newTestSQLiteDatabase(t)
+
+	sender := common.HexToAddress("0x01")
+	l1Token := common.HexToAddress("0x02")
+	msgHash := common.HexToHash("0x17")
+	l1Block := &IndexedL1Block{
+		Hash:      common.HexToHash("0x11"),
+		Number:    1,
+		Timestamp: 100,
+		Deposits: []Deposit{{
+			GUID:        "deposit",
+			TxHash:      common.HexToHash("0x12"),
+			L1Token:     ETHL1Address,
+			L2Token:     common.HexToAddress(ETHL2Token.Address),
+			FromAddress: sender,
+			ToAddress:   sender,
+			Amount:      big.NewInt(10),
+			Data:        []byte{},
+			MessageHash: &msgHash,
+		}},
+		ContractEvents: []ContractEvent{{
+			ContractName:    "Token",
+			ContractAddress: l1Token,
+			EventName:       "Transfer",
+			EventSignature:  "Transfer(address,address,uint256)",
+			Args:            map[string]interface{}{"from": sender.String(), "value": "10"},
+			TxHash:          common.HexToHash("0x13"),
+		}},
+		L2Outputs: []L2Output{{
+			Index:         0,
+			OutputRoot:    common.HexToHash("0x15"),
+			L2BlockNumber: 1,
+			L1Timestamp:   100,
+			TxHash:        common.HexToHash("0x16"),
+		}},
+	}
+	require.NoError(t, d.AddIndexedL1Block(l1Block))
+	require.NoError(t, d.AddStateBatch([]StateBatch{{
+		Index:     big.NewInt(0),
+		Root:      common.HexToHash("0x14"),
+		Size:      big.NewInt(5),
+		PrevTotal: big.NewInt(0),
+		ExtraData: []byte{},
+		BlockHash: l1Block.Hash,
+	}}))
+
+	highest, err := d.GetHighestL1Block()
+	require.NoError(t, err)
+	require.Equal(t, &BlockLocator{Number: 1, Hash: l1Block.Hash}, highest)
+
+	deposits, err := d.GetDepositsByAddress(sender, PaginationParam{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, deposits.Deposits, 1)
+	require.Equal(t, "10", deposits.Deposits[0].Amount)
+	require.True(t, deposits.Deposits[0].Canonical)
+
+	batch, err := d.GetStateBatchByIndex(0)
+	require.NoError(t, err)
+	require.NotNil(t, batch)
+	require.Equal(t, uint64(5), batch.Size)
+
+	events, err := d.GetContractEvents(ContractEventFilter{
+		Chain: ChainL1,
+		Args:  map[string]string{"from": sender.String()},
+	}, PaginationParam{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, events.Events, 1)
+	require.Equal(t, "10", events.Events[0].Args["value"])
+
+	events, err = d.GetContractEvents(ContractEventFilter{
+		Chain: ChainL1,
+		Args:  map[string]string{"from": l1Token.String()},
+	}, PaginationParam{Limit: 10})
+	require.NoError(t, err)
+	require.Empty(t, events.Events)
+
+	withdrawalHash := common.HexToHash("0x23")
+	l2Block := &IndexedL2Block{
+		Hash:      common.HexToHash("0x21"),
+		Number:    1,
+		Timestamp: 100,
+		Withdrawals: []Withdrawal{{
+			GUID:        "withdrawal",
+			TxHash:      common.HexToHash("0x22"),
+			L1Token:     ETHL1Address,
+			L2Token:     common.HexToAddress(ETHL2Token.Address),
+			FromAddress: sender,
+			ToAddress:   sender,
+			Amount:      big.NewInt(20),
+			Data:        []byte{},
+			BedrockHash: &withdrawalHash,
+		}},
+		RelayedMessages: []RelayedMessage{{
+			MessageHash: msgHash,
+			TxHash:      common.HexToHash("0x24"),
+		}},
+	}
+	require.NoError(t, d.AddIndexedL2Block(l2Block))
+
+	relayed, _, err := d.GetDeposits(TransferFilter{MessageHash: &msgHash}, CursorParam{First: 10})
+	require.NoError(t, err)
+	require.Len(t, relayed, 1)
+	require.Equal(t, msgHash.String(), *relayed[0].MessageHash)
+	require.Equal(t, common.HexToHash("0x24").String(), *relayed[0].RelayedTxHash)
+
+	withdrawals, err := d.GetWithdrawalsByAddress(sender, PaginationParam{Limit: 10}, FinalizationStateAny, WithdrawalStatusAny)
+	require.NoError(t, err)
+	require.Len(t, withdrawals.Withdrawals, 1)
+	require.Equal(t, "20", withdrawals.Withdrawals[0].Amount)
+	require.NotNil(t, withdrawals.Withdrawals[0].BedrockL2Output)
+	require.Equal(t, WithdrawalStatusReadyToProve, *withdrawals.Withdrawals[0].Status)
+
+	_, err = d.RollbackL2Blocks(0)
+	require.NoError(t, err)
+	deposits, err = d.GetDepositsByAddress(sender, PaginationParam{Limit: 10})
+	require.NoError(t, err)
+	require.Nil(t, deposits.Deposits[0].RelayedTxHash)
+
+	deleted, err := d.RollbackL1Blocks(0)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), deleted)
+	highest, err = d.GetHighestL1Block()
+	require.NoError(t, err)
+	require.Nil(t, highest)
+	batch, err = d.GetStateBatchByIndex(0)
+	require.NoError(t, err)
+	require.Nil(t, batch)
+}
+
+func TestSQLiteTokenPairs(t *testing.T) {
+	d := newTestSQLiteDatabase(t)
+
+	sender := common.HexToAddress("0x01")
+	l1Token := common.HexToAddress("0x02")
+	l2Token := common.HexToAddress("0x03")
+	fakeToken := common.HexToAddress("0x04")
+	listedToken := common.HexToAddress("0x05")
+	require.NoError(t, d.AddL1Token(l1Token.String(), &Token{Address: l1Token.String(), Name: "Token", Symbol: "TKN", Decimals: 18}))
+
+	// Deposits register their pairs as bridge pairs.
+	require.NoError(t, d.AddIndexedL1Block(&IndexedL1Block{
+		Hash:      common.HexToHash("0x11"),
+		Number:    1,
+		Timestamp: 100,
+		Deposits: []Deposit{{
+			GUID:        "deposit",
+			TxHash:      common.HexToHash("0x12"),
+			L1Token:     l1Token,
+			L2Token:     fakeToken,
+			FromAddress: sender,
+			ToAddress:   sender,
+			Amount:      big.NewInt(10),
+			Data:        []byte{},
+		}, {
+			GUID:        "deposit-2",
+			TxHash:      common.HexToHash("0x12"),
+			LogIndex:    1,
+			L1Token:     l1Token,
+			L2Token:     l2Token,
+			FromAddress: sender,
+			ToAddress:   sender,
+			Amount:      big.NewInt(10),
+			Data:        []byte{},
+		}},
+	}))
+
+	canonical := true
+	pairs, err := d.GetTokenPairs(TokenPairFilter{Canonical: &canonical}, PaginationParam{Limit: 10})
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), pairs.Param.Total)
+	for _, pair := range pairs.Pairs {
+		require.Equal(t, TokenPairSourceNative, pair.Source)
+	}
+
+	// The factory takes over the bridge pair it creates.
+	require.NoError(t, d.AddIndexedL2Block(&IndexedL2Block{
+		Hash:       common.HexToHash("0x21"),
+		Number:     1,
+		Timestamp:  100,
+		TokenPairs: []TokenPair{{L1Token: l1Token, L2Token: l2Token}},
+	}))
+	require.NoError(t, d.ImportTokens(nil, []Token{{Address: listedToken.String(), Name: "Listed", Symbol: "LST", Decimals: 6}},
+		[]TokenPair{{L1Token: l1Token, L2Token: listedToken}}))
+
+	pairs, err = d.GetTokenPairs(TokenPairFilter{Token: &l1Token}, PaginationParam{Limit: 10})
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), pairs.Param.Total)
+	sources := make(map[string]TokenPairSource)
+	for _, pair := range pairs.Pairs {
+		require.Equal(t, "TKN", pair.L1Token.Symbol)
+		require.Equal(t, pair.Source.Canonical(), pair.Canonical)
+		sources[pair.L2Token.Address] = pair.Source
+	}
+	require.Equal(t, map[string]TokenPairSource{
+		fakeToken.String():   TokenPairSourceBridge,
+		l2Token.String():     TokenPairSourceFactory,
+		listedToken.String(): TokenPairSourceTokenList,
+	}, sources)
+
+	canonical = false
+	pairs, err = d.GetTokenPairs(TokenPairFilter{Canonical: &canonical}, PaginationParam{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, pairs.Pairs, 1)
+	require.Equal(t, fakeToken.String(), pairs.Pairs[0].L2Token.Address)
+	require.Empty(t, pairs.Pairs[0].L2Token.Symbol)
+
+	deposits, err := d.GetDepositsByAddress(sender, PaginationParam{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, deposits.Deposits, 2)
+	for _, deposit := range deposits.Deposits {
+		require.Equal(t, deposit.L2Token == l2Token.String(), deposit.Canonical)
+	}
+
+	// Rolling back the block of the factory pair deletes it.
+	_, err = d.RollbackL2Blocks(0)
+	require.NoError(t, err)
+	pairs, err = d.GetTokenPairs(TokenPairFilter{Token: &l2Token}, PaginationParam{Limit: 10})
+	require.NoError(t, err)
+	require.Empty(t, pairs.Pairs)
+}
+
+func TestSQLiteMessages(t *testing.T) {
+	d := newTestSQLiteDatabase(t)
+
+	sender := common.HexToAddress("0x01")
+	target := common.HexToAddress("0x02")
+	newMessage := func(hash string, logIndex uint) SentMessage {
+		return SentMessage{
+			MessageHash: common.HexToHash(hash),
+			Nonce:       big.NewInt(int64(logIndex)),
+			Sender:      sender,
+			Target:      target,
+			Value:       big.NewInt(0),
+			GasLimit:    big.NewInt(100000),
+			Data:        []byte{},
+			TxHash:      common.HexToHash("0x12"),
+			LogIndex:    logIndex,
+		}
+	}
+
+	// The first message sent from L1 fails to be relayed and is replayed,
+	// the second one is pending. The message sent from L2 is relayed on L1.
+	require.NoError(t, d.AddIndexedL1Block(&IndexedL1Block{
+		Hash:         common.HexToHash("0x11"),
+		Number:       1,
+		Timestamp:    100,
+		SentMessages: []SentMessage{newMessage("0xa1", 0), newMessage("0xa2", 1)},
+		RelayedMessages: []RelayedMessage{{
+			MessageHash: common.HexToHash("0xb1"),
+			TxHash:      common.HexToHash("0x13"),
+		}},
+	}))
+	require.NoError(t, d.AddIndexedL2Block(&IndexedL2Block{
+		Hash:         common.HexToHash("0x21"),
+		Number:       1,
+		Timestamp:    100,
+		SentMessages: []SentMessage{newMessage("0xb1", 0)},
+		RelayedMessages: []RelayedMessage{{
+			MessageHash: common.HexToHash("0xa1"),
+			TxHash:      common.HexToHash("0x22"),
+			Failed:      true,
+		}},
+	}))
+
+	failed, err := d.GetMessages(MessageFilter{Chain: ChainL1, Status: MessageStatusFailed}, PaginationParam{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, failed.Messages, 1)
+	require.Equal(t, common.HexToHash("0xa1").String(), failed.Messages[0].MessageHash)
+	require.Equal(t, MessageStatusFailed, failed.Messages[0].Status)
+	require.Equal(t, uint64(1), failed.Messages[0].FailedRelays)
+
+	unrelayed, err := d.GetMessages(MessageFilter{Sender: &sender, Target: &target, Status: MessageStatusUnrelayed}, PaginationParam{Limit: 10})
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), unrelayed.Param.Total)
+
+	relayed, err := d.GetMessages(MessageFilter{Status: MessageStatusRelayed}, PaginationParam{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, relayed.Messages, 1)
+	require.Equal(t, ChainL2, relayed.Messages[0].Chain)
+	require.Equal(t, common.HexToHash("0x13").String(), *relayed.Messages[0].RelayedTxHash)
+
+	require.NoError(t, d.AddIndexedL2Block(&IndexedL2Block{
+		Hash:       common.HexToHash("0x23"),
+		ParentHash: common.HexToHash("0x21"),
+		Number:     2,
+		Timestamp:  200,
+		RelayedMessages: []RelayedMessage{{
+			MessageHash: common.HexToHash("0xa1"),
+			TxHash:      common.HexToHash("0x24"),
+		}},
+	}))
+	msgs, err := d.GetMessages(MessageFilter{Chain: ChainL1}, PaginationParam{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, msgs.Messages, 2)
+	require.Equal(t, MessageStatusRelayed, msgs.Messages[0].Status)
+	require.Equal(t, common.HexToHash("0x24").String(), *msgs.Messages[0].RelayedTxHash)
+	require.Equal(t, MessageStatusPending, msgs.Messages[1].Status)
+
+	// Rolling back the blocks deletes their messages and relays.
+	_, err = d.RollbackL2Blocks(0)
+	require.NoError(t, err)
+	msgs, err = d.GetMessages(MessageFilter{}, PaginationParam{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, msgs.Messages, 2)
+	for _, msg := range msgs.Messages {
+		require.Equal(t, MessageStatusPending, msg.Status)
+	}
+
+	_, err = d.RollbackL1Blocks(0)
+	require.NoError(t, err)
+	msgs, err = d.GetMessages(MessageFilter{}, PaginationParam{Limit: 10})
+	require.NoError(t, err)
+	require.Empty(t, msgs.Messages)
+}
+
+func TestSQLiteBackfill(t *testing.T) {
+	d := newTestSQLiteDatabase(t)
+
+	checkpoint := BackfillCheckpoint{
+		Target:    100,
+		Committed: BlockLocator{Number: 10, Hash: common.HexToHash("0x01")},
+	}
+	require.NoError(t, d.SetBackfillCheckpoint(ChainL1, checkpoint))
+	got, err := d.GetBackfillCheckpoint(ChainL1)
+	require.NoError(t, err)
+	require.Equal(t, &checkpoint, got)
+
+	chunk := &BackfillChunk{Start: 11, End: 20, ParentHash: common.HexToHash("0x01"), Hash: common.HexToHash("0x02"), Data: []byte{1}}
+	require.NoError(t, d.AddBackfillChunk(ChainL1, chunk))
+	chunk.Data = []byte{2}
+	require.NoError(t, d.AddBackfillChunk(ChainL1, chunk))
+	gotChunk, err := d.GetBackfillChunk(ChainL1, 11)
+	require.NoError(t, err)
+	require.Equal(t, chunk, gotChunk)
+
+	checkpoint.Committed = BlockLocator{Number: 20, Hash: chunk.Hash}
+	require.NoError(t, d.CommitBackfillChunk(ChainL1, 11, checkpoint))
+	ranges, err := d.GetBackfillChunkRanges(ChainL1)
+	require.NoError(t, err)
+	require.Empty(t, ranges)
+
+	require.NoError(t, d.DeleteBackfill(ChainL1))
+	got, err = d.GetBackfillCheckpoint(ChainL1)
+	require.NoError(t, err)
+	require.Nil(t, got)
+}
+
+func TestSQLiteWebhookDeliveries(t *testing.T) {
+	d := newTestSQLiteDatabase(t)
+
+	delivery := WebhookDelivery{
+		Webhook:       "app",
+		EventID:       "deposit_initiated:0x01:0",
+		EventType:     "deposit_initiated",
+		Payload:       []byte(`{"id":"deposit_initiated:0x01:0"}`),
+		NextAttemptAt: 100,
+		CreatedAt:     100,
+	}
+	other := delivery
+	other.Webhook = "other"
+	// The duplicate delivery to app is ignored.
+	require.NoError(t, d.AddWebhookDeliveries([]WebhookDelivery{delivery, delivery, other}))
+
+	due, err := d.GetDueWebhookDeliveries("app", 99, 10)
+	require.NoError(t, err)
+	require.Empty(t, due)
+	due, err = d.GetDueWebhookDeliveries("app", 100, 10)
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+	require.Equal(t, WebhookDeliveryPending, due[0].Status)
+	require.Equal(t, delivery.Payload, due[0].Payload)
+	dueOther, err := d.GetDueWebhookDeliveries("other", 100, 10)
+	require.NoError(t, err)
+	require.Len(t, dueOther, 1)
+	due = append(due, dueOther...)
+
+	status := 500
+	msg := "unexpected status 500"
+	due[0].Attempts = 1
+	due[0].ResponseStatus = &status
+	due[0].LastError = &msg
+	due[0].NextAttemptAt = 110
+	require.NoError(t, d.UpdateWebhookDelivery(&due[0]))
+	delivered := uint64(100)
+	due[1].Status = WebhookDeliveryDelivered
+	due[1].Attempts = 1
+	due[1].DeliveredAt = &delivered
+	require.NoError(t, d.UpdateWebhookDelivery(&due[1]))
+
+	retried, err := d.GetDueWebhookDeliveries("app", 110, 10)
+	require.NoError(t, err)
+	require.Equal(t, []WebhookDelivery{due[0]}, retried)
+
+	// The pending deliveries to unknown webhooks are failed.
+	require.NoError(t, d.FailUnknownWebhookDeliveries([]string{"other"}, "webhook not configured"))
+	retried, err = d.GetDueWebhookDeliveries("app", 110, 10)
+	require.NoError(t, err)
+	require.Empty(t, retried)
+}
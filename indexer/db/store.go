@@ -0,0 +1,36 @@
+package db
+
+import "github.com/ethereum/go-ethereum/common"
+
+// Store is the storage the L1 and L2 services index blocks into. It is
+// implemented on Postgres and on an embedded SQLite database by Database.
+type Store interface {
+	GetHighestL1Block() (*BlockLocator, error)
+	GetPreviousL1Block(number uint64) (*BlockLocator, error)
+	AddIndexedL1Block(block *IndexedL1Block, hooks ...BlockHook) error
+	AddStateBatch(batches []StateBatch) error
+	RollbackL1Blocks(number uint64) (int64, error)
+	GetL1TokenByAddress(address string) (*Token, error)
+	AddL1Token(address string, token *Token) error
+	GetDepositsByAddress(address common.Address, page PaginationParam) (*PaginatedDeposits, error)
+
+	GetHighestL2Block() (*BlockLocator, error)
+	GetPreviousL2Block(number uint64) (*BlockLocator, error)
+	AddIndexedL2Block(block *IndexedL2Block, hooks ...BlockHook) error
+	RollbackL2Blocks(number uint64) (int64, error)
+	GetL2TokenByAddress(address string) (*Token, error)
+	AddL2Token(address string, token *Token) error
+	GetWithdrawalsByAddress(address common.Address, page PaginationParam, state FinalizationState, status WithdrawalStatus) (*PaginatedWithdrawals, error)
+	GetWithdrawalBatch(hash common.Hash) (*StateBatchJSON, error)
+
+	GetBackfillCheckpoint(chain string) (*BackfillCheckpoint, error)
+	SetBackfillCheckpoint(chain string, checkpoint BackfillCheckpoint) error
+	DeleteBackfill(chain string) error
+	AddBackfillChunk(chain string, chunk *BackfillChunk) error
+	GetBackfillChunk(chain string, start uint64) (*BackfillChunk, error)
+	GetBackfillChunkRanges(chain string) (map[uint64]uint64, error)
+	CommitBackfillChunk(chain string, start uint64, checkpoint BackfillCheckpoint) error
+	DeleteBackfillChunks(chain string) error
+}
+
+var _ Store = (*Database)(nil)
@@ -0,0 +1,215 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// TokenPairSource is how a token pair was registered.
+type TokenPairSource string
+
+const (
+	// TokenPairSourceNative is the source of the ETH pairs.
+	TokenPairSourceNative TokenPairSource = "native"
+	// TokenPairSourceFactory is the source of the pairs created by the L2
+	// OptimismMintableERC20Factory.
+	TokenPairSourceFactory TokenPairSource = "factory"
+	// TokenPairSourceTokenList is the source of the pairs imported from a
+	// curated token list.
+	TokenPairSourceTokenList TokenPairSource = "token_list"
+	// TokenPairSourceBridge is the source of the pairs only seen in deposits
+	// or withdrawals. They are not canonical.
+	TokenPairSourceBridge TokenPairSource = "bridge"
+)
+
+// Canonical returns whether pairs of the source are canonical.
+func (s TokenPairSource) Canonical() bool {
+	return s != TokenPairSourceBridge
+}
+
+// TokenPair is an L1 token and the L2 token it is bridged to.
+type TokenPair struct {
+	L1Token common.Address
+	L2Token common.Address
+}
+
+// TokenPairJSON contains TokenPair data suitable for JSON serialization. The
+// tokens only have an address if their metadata is unknown.
+type TokenPairJSON struct {
+	L1Token   *Token          `json:"l1Token"`
+	L2Token   *Token          `json:"l2Token"`
+	Source    TokenPairSource `json:"source"`
+	Canonical bool            `json:"canonical"`
+}
+
+// TokenPairFilter selects token pairs. Nil fields match all pairs.
+type TokenPairFilter struct {
+	// Token matches the L1 or the L2 token.
+	Token     *common.Address
+	Canonical *bool
+}
+
+type PaginatedTokenPairs struct {
+	Param *PaginationParam `json:"pagination"`
+	Pairs []TokenPairJSON  `json:"items"`
+}
+
+// insertTokenPairs registers the pairs from source. Canonical sources take
+// over pairs only seen in bridge events, other pairs are left unchanged.
+// blockHash is the L2 block of the pairs created by the factory.
+func insertTokenPairs(tx *sql.Tx, pairs []TokenPair, source TokenPairSource, blockHash *common.Hash) error {
+	const insertTokenPairStatement = `
+	INSERT INTO token_pairs
+		(l1_token, l2_token, source, block_hash)
+	VALUES
+		($1, $2, $3, $4)
+	ON CONFLICT (l1_token, l2_token) DO UPDATE SET (source, block_hash) = (excluded.source, excluded.block_hash)
+	WHERE token_pairs.source = 'bridge' AND excluded.source != 'bridge'
+	`
+
+	for _, pair := range pairs {
+		_, err := tx.Exec(
+			insertTokenPairStatement,
+			pair.L1Token.String(),
+			pair.L2Token.String(),
+			source,
+			nullableHash(blockHash),
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ImportTokens registers tokens and pairs from a curated token list. The
+// metadata of the tokens replaces the metadata fetched from their contracts.
+func (d *Database) ImportTokens(l1Tokens, l2Tokens []Token, pairs []TokenPair) error {
+	const upsertTokenStatement = `
+	INSERT INTO %s
+		(address, name, symbol, decimals)
+	VALUES
+		($1, $2, $3, $4)
+	ON CONFLICT (address) DO UPDATE SET (name, symbol, decimals) = (excluded.name, excluded.symbol, excluded.decimals)
+	`
+
+	return txn(d.db, func(tx *sql.Tx) error {
+		for table, tokens := range map[string][]Token{"l1_tokens": l1Tokens, "l2_tokens": l2Tokens} {
+			for _, token := range tokens {
+				_, err := tx.Exec(
+					fmt.Sprintf(upsertTokenStatement, table),
+					token.Address,
+					token.Name,
+					token.Symbol,
+					token.Decimals,
+				)
+				if err != nil {
+					return err
+				}
+			}
+		}
+		return insertTokenPairs(tx, pairs, TokenPairSourceTokenList, nil)
+	})
+}
+
+// GetTokenPairs returns the token pairs selected by filter, with the metadata
+// of their tokens, ordered by L1 and L2 token and paginated by the given
+// params.
+func (d *Database) GetTokenPairs(filter TokenPairFilter, page PaginationParam) (*PaginatedTokenPairs, error) {
+	var c conditions
+	if filter.Token != nil {
+		c.add("(token_pairs.l1_token = $%[1]d OR token_pairs.l2_token = $%[1]d)", filter.Token.String())
+	}
+	if filter.Canonical != nil && *filter.Canonical {
+		c.add("token_pairs.source != $%d", TokenPairSourceBridge)
+	} else if filter.Canonical != nil {
+		c.add("token_pairs.source = $%d", TokenPairSourceBridge)
+	}
+	where, args := c.where(), c.args
+
+	selectTokenPairsStatement := fmt.Sprintf(`
+	SELECT
+		token_pairs.l1_token, l1_tokens.name, l1_tokens.symbol, l1_tokens.decimals,
+		token_pairs.l2_token, l2_tokens.name, l2_tokens.symbol, l2_tokens.decimals,
+		token_pairs.source
+	FROM token_pairs
+		LEFT JOIN l1_tokens ON token_pairs.l1_token=l1_tokens.address
+		LEFT JOIN l2_tokens ON token_pairs.l2_token=l2_tokens.address
+	%s
+	ORDER BY token_pairs.l1_token, token_pairs.l2_token LIMIT $%d OFFSET $%d;
+	`, where, len(args)+1, len(args)+2)
+	selectTokenPairCountStatement := fmt.Sprintf(`
+	SELECT count(*) FROM token_pairs %s;
+	`, where)
+
+	pairs := []TokenPairJSON{}
+	var count uint64
+	err := txn(d.db, func(tx *sql.Tx) error {
+		rows, err := tx.Query(selectTokenPairsStatement, append(args, page.Limit, page.Offset)...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var pair TokenPairJSON
+			var l1Token, l2Token nullableToken
+			if err := rows.Scan(
+				&l1Token.address, &l1Token.name, &l1Token.symbol, &l1Token.decimals,
+				&l2Token.address, &l2Token.name, &l2Token.symbol, &l2Token.decimals,
+				&pair.Source,
+			); err != nil {
+				return err
+			}
+			pair.L1Token = l1Token.token()
+			pair.L2Token = l2Token.token()
+			pair.Canonical = pair.Source.Canonical()
+			pairs = append(pairs, pair)
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		return tx.QueryRow(selectTokenPairCountStatement, args...).Scan(&count)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	page.Total = count
+
+	return &PaginatedTokenPairs{
+		Param: &page,
+		Pairs: pairs,
+	}, nil
+}
+
+// nullableTokenPairSource scans the source of a token pair which may not be
+// registered.
+type nullableTokenPairSource struct {
+	sql.NullString
+}
+
+// canonical returns whether the pair is registered from a canonical source.
+func (s nullableTokenPairSource) canonical() bool {
+	return s.Valid && TokenPairSource(s.String).Canonical()
+}
+
+// nullableToken scans a token whose metadata may be unknown.
+type nullableToken struct {
+	address  string
+	name     sql.NullString
+	symbol   sql.NullString
+	decimals sql.NullInt64
+}
+
+func (t *nullableToken) token() *Token {
+	return &Token{
+		Address:  t.address,
+		Name:     t.name.String,
+		Symbol:   t.symbol.String,
+		Decimals: uint8(t.decimals.Int64),
+	}
+}
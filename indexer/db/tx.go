@@ -0,0 +1,48 @@
+package db
+
+import (
+	"database/sql"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// BlockHook is called in the transaction storing an indexed block, once the
+// rows of the block are written. The block isn't stored if it fails.
+type BlockHook func(tx *Tx) error
+
+// Tx is the transaction storing an indexed block. Hooks read the rows of the
+// block and write their own rows through it, so that they are committed
+// along with the block or not at all.
+type Tx struct {
+	tx *sql.Tx
+}
+
+func runBlockHooks(tx *sql.Tx, hooks []BlockHook) error {
+	for _, hook := range hooks {
+		if err := hook(&Tx{tx: tx}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetDeposits is Database.GetDeposits within the transaction.
+func (t *Tx) GetDeposits(filter TransferFilter, page CursorParam) ([]DepositJSON, bool, error) {
+	return getDeposits(t.tx, filter, page)
+}
+
+// GetWithdrawals is Database.GetWithdrawals within the transaction.
+func (t *Tx) GetWithdrawals(filter TransferFilter, page CursorParam) ([]WithdrawalJSON, bool, error) {
+	return getWithdrawals(t.tx, filter, page)
+}
+
+// GetWithdrawalByHash is Database.GetWithdrawalByHash within the transaction.
+func (t *Tx) GetWithdrawalByHash(hash common.Hash) (*WithdrawalJSON, error) {
+	return getWithdrawalByHash(t.tx, hash)
+}
+
+// AddWebhookDeliveries is Database.AddWebhookDeliveries within the
+// transaction.
+func (t *Tx) AddWebhookDeliveries(deliveries []WebhookDelivery) error {
+	return addWebhookDeliveries(t.tx, deliveries)
+}
@@ -0,0 +1,181 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// WebhookDeliveryStatus is the status of the delivery of an event to a
+// webhook.
+type WebhookDeliveryStatus string
+
+const (
+	// WebhookDeliveryPending is the status of deliveries waiting for their
+	// next attempt.
+	WebhookDeliveryPending WebhookDeliveryStatus = "pending"
+	// WebhookDeliveryDelivered is the status of deliveries acknowledged by
+	// the webhook.
+	WebhookDeliveryDelivered WebhookDeliveryStatus = "delivered"
+	// WebhookDeliveryFailed is the status of deliveries that failed on their
+	// last attempt.
+	WebhookDeliveryFailed WebhookDeliveryStatus = "failed"
+)
+
+// WebhookDelivery is the delivery of an event to a webhook, logged with the
+// outcome of its last attempt. Times are unix timestamps.
+type WebhookDelivery struct {
+	GUID      string
+	Webhook   string
+	EventID   string
+	EventType string
+	Payload   []byte
+	Status    WebhookDeliveryStatus
+	Attempts  uint64
+	// ResponseStatus and LastError describe the last failed attempt.
+	ResponseStatus *int
+	LastError      *string
+	NextAttemptAt  uint64
+	CreatedAt      uint64
+	DeliveredAt    *uint64
+}
+
+// AddWebhookDeliveries logs pending deliveries. A delivery of an event that
+// was already logged for the same webhook is ignored.
+func (d *Database) AddWebhookDeliveries(deliveries []WebhookDelivery) error {
+	return txn(d.db, func(tx *sql.Tx) error {
+		return addWebhookDeliveries(tx, deliveries)
+	})
+}
+
+func addWebhookDeliveries(tx *sql.Tx, deliveries []WebhookDelivery) error {
+	const insertDeliveryStatement = `
+	INSERT INTO webhook_deliveries
+		(guid, webhook, event_id, event_type, payload, status, attempts, next_attempt_at, created_at)
+	VALUES
+		($1, $2, $3, $4, $5, $6, 0, $7, $8)
+	ON CONFLICT (webhook, event_id) DO NOTHING
+	`
+
+	for _, delivery := range deliveries {
+		_, err := tx.Exec(
+			insertDeliveryStatement,
+			NewGUID(),
+			delivery.Webhook,
+			delivery.EventID,
+			delivery.EventType,
+			string(delivery.Payload),
+			WebhookDeliveryPending,
+			delivery.NextAttemptAt,
+			delivery.CreatedAt,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetDueWebhookDeliveries returns at most limit pending deliveries to the
+// webhook whose next attempt is at or before now, the oldest first.
+func (d *Database) GetDueWebhookDeliveries(webhook string, now, limit uint64) ([]WebhookDelivery, error) {
+	const selectDeliveriesStatement = `
+	SELECT
+		guid, webhook, event_id, event_type, payload, status, attempts,
+		response_status, last_error, next_attempt_at, created_at, delivered_at
+	FROM webhook_deliveries
+	WHERE webhook = $1 AND status = $2 AND next_attempt_at <= $3
+	ORDER BY next_attempt_at, created_at LIMIT $4
+	`
+
+	var deliveries []WebhookDelivery
+	err := txn(d.db, func(tx *sql.Tx) error {
+		rows, err := tx.Query(selectDeliveriesStatement, webhook, WebhookDeliveryPending, now, limit)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var delivery WebhookDelivery
+			var payload string
+			var responseStatus sql.NullInt64
+			var lastError sql.NullString
+			var deliveredAt sql.NullInt64
+			if err := rows.Scan(
+				&delivery.GUID, &delivery.Webhook, &delivery.EventID, &delivery.EventType,
+				&payload, &delivery.Status, &delivery.Attempts,
+				&responseStatus, &lastError, &delivery.NextAttemptAt, &delivery.CreatedAt, &deliveredAt,
+			); err != nil {
+				return err
+			}
+			delivery.Payload = []byte(payload)
+			if responseStatus.Valid {
+				status := int(responseStatus.Int64)
+				delivery.ResponseStatus = &status
+			}
+			if lastError.Valid {
+				delivery.LastError = &lastError.String
+			}
+			if deliveredAt.Valid {
+				at := uint64(deliveredAt.Int64)
+				delivery.DeliveredAt = &at
+			}
+			deliveries = append(deliveries, delivery)
+		}
+
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// FailUnknownWebhookDeliveries marks the pending deliveries to webhooks other
+// than the given ones failed with lastError.
+func (d *Database) FailUnknownWebhookDeliveries(webhooks []string, lastError string) error {
+	const updateDeliveriesStatement = `
+	UPDATE webhook_deliveries SET (status, last_error) = ($1, $2)
+	WHERE status = $3
+	`
+
+	args := []interface{}{WebhookDeliveryFailed, lastError, WebhookDeliveryPending}
+	statement := updateDeliveriesStatement
+	if len(webhooks) > 0 {
+		placeholders := make([]string, len(webhooks))
+		for i, webhook := range webhooks {
+			args = append(args, webhook)
+			placeholders[i] = fmt.Sprintf("$%d", len(args))
+		}
+		statement += fmt.Sprintf("AND webhook NOT IN (%s)", strings.Join(placeholders, ", "))
+	}
+
+	return txn(d.db, func(tx *sql.Tx) error {
+		_, err := tx.Exec(statement, args...)
+		return err
+	})
+}
+
+// UpdateWebhookDelivery logs the outcome of an attempt of the delivery.
+func (d *Database) UpdateWebhookDelivery(delivery *WebhookDelivery) error {
+	const updateDeliveryStatement = `
+	UPDATE webhook_deliveries SET
+		(status, attempts, response_status, last_error, next_attempt_at, delivered_at) = ($1, $2, $3, $4, $5, $6)
+	WHERE guid = $7
+	`
+
+	return txn(d.db, func(tx *sql.Tx) error {
+		_, err := tx.Exec(
+			updateDeliveryStatement,
+			delivery.Status,
+			delivery.Attempts,
+			delivery.ResponseStatus,
+			delivery.LastError,
+			delivery.NextAttemptAt,
+			delivery.DeliveredAt,
+			delivery.GUID,
+		)
+		return err
+	})
+}
@@ -1,6 +1,7 @@
 package db
 
 import (
+	"fmt"
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -27,24 +28,61 @@ func (w Withdrawal) String() string {
 
 // WithdrawalJSON contains Withdrawal data suitable for JSON serialization.
 type WithdrawalJSON struct {
-	GUID                     string          `json:"guid"`
-	FromAddress              string          `json:"from"`
-	ToAddress                string          `json:"to"`
-	L1Token                  string          `json:"l1Token"`
-	L2Token                  *Token          `json:"l2Token"`
-	Amount                   string          `json:"amount"`
-	Data                     []byte          `json:"data"`
-	LogIndex                 uint64          `json:"logIndex"`
-	BlockNumber              uint64          `json:"blockNumber"`
-	BlockTimestamp           string          `json:"blockTimestamp"`
-	TxHash                   string          `json:"transactionHash"`
-	Batch                    *StateBatchJSON `json:"batch"`
-	BedrockWithdrawalHash    *string         `json:"bedrockWithdrawalHash"`
-	BedrockProvenTxHash      *string         `json:"bedrockProvenTxHash"`
-	BedrockProvenLogIndex    *int            `json:"bedrockProvenLogIndex"`
-	BedrockFinalizedTxHash   *string         `json:"bedrockFinalizedTxHash"`
-	BedrockFinalizedLogIndex *int            `json:"bedrockFinalizedLogIndex"`
-	BedrockFinalizedSuccess  *bool           `json:"bedrockFinalizedSuccess"`
+	GUID                      string          `json:"guid"`
+	FromAddress               string          `json:"from"`
+	ToAddress                 string          `json:"to"`
+	L1Token                   string          `json:"l1Token"`
+	L2Token                   *Token          `json:"l2Token"`
+	Amount                    string          `json:"amount"`
+	Data                      []byte          `json:"data"`
+	LogIndex                  uint64          `json:"logIndex"`
+	BlockNumber               uint64          `json:"blockNumber"`
+	BlockTimestamp            string          `json:"blockTimestamp"`
+	TxHash                    string          `json:"transactionHash"`
+	Batch                     *StateBatchJSON `json:"batch"`
+	BedrockWithdrawalHash     *string         `json:"bedrockWithdrawalHash"`
+	BedrockProvenTxHash       *string         `json:"bedrockProvenTxHash"`
+	BedrockProvenLogIndex     *int            `json:"bedrockProvenLogIndex"`
+	BedrockProvenBlockHash    *string         `json:"bedrockProvenBlockHash"`
+	BedrockFinalizedTxHash    *string         `json:"bedrockFinalizedTxHash"`
+	BedrockFinalizedLogIndex  *int            `json:"bedrockFinalizedLogIndex"`
+	BedrockFinalizedBlockHash *string         `json:"bedrockFinalizedBlockHash"`
+	BedrockFinalizedSuccess   *bool           `json:"bedrockFinalizedSuccess"`
+	BedrockL2Output           *L2OutputJSON   `json:"bedrockL2Output"`
+	BedrockProvableAt         *uint64         `json:"bedrockProvableAt"`
+	BedrockFinalizableAt      *uint64         `json:"bedrockFinalizableAt"`
+	// Status is nil for legacy withdrawals.
+	Status *WithdrawalStatus `json:"status"`
+	// Canonical is false if the token pair is only registered from bridge
+	// events, i.e. if the L2 token isn't known to represent the L1 token.
+	Canonical bool `json:"canonical"`
+}
+
+// updateStatus sets the status of a bedrock withdrawal at the unix time now.
+func (w *WithdrawalJSON) updateStatus(now uint64) {
+	if w.BedrockWithdrawalHash == nil {
+		w.Status = nil
+		return
+	}
+
+	var status WithdrawalStatus
+	switch {
+	case w.BedrockFinalizedTxHash != nil && w.BedrockFinalizedSuccess != nil && !*w.BedrockFinalizedSuccess:
+		status = WithdrawalStatusFailed
+	case w.BedrockFinalizedTxHash != nil:
+		status = WithdrawalStatusFinalized
+	case w.BedrockProvenTxHash != nil && w.BedrockFinalizableAt == nil:
+		status = WithdrawalStatusProven
+	case w.BedrockProvenTxHash != nil && *w.BedrockFinalizableAt < now:
+		status = WithdrawalStatusReadyToFinalize
+	case w.BedrockProvenTxHash != nil:
+		status = WithdrawalStatusInChallengePeriod
+	case w.BedrockL2Output != nil:
+		status = WithdrawalStatusReadyToProve
+	default:
+		status = WithdrawalStatusInitiated
+	}
+	w.Status = &status
 }
 
 type FinalizationState int
@@ -77,12 +115,87 @@ func (f FinalizationState) SQL() string {
 	return ""
 }
 
+// WithdrawalStatus is the step of its lifecycle a bedrock withdrawal is at.
+type WithdrawalStatus string
+
+const (
+	WithdrawalStatusAny WithdrawalStatus = ""
+	// WithdrawalStatusInitiated is the status of withdrawals that no output
+	// covers yet.
+	WithdrawalStatusInitiated WithdrawalStatus = "initiated"
+	// WithdrawalStatusReadyToProve is the status of withdrawals that can be
+	// proven against an output.
+	WithdrawalStatusReadyToProve WithdrawalStatus = "ready_to_prove"
+	// WithdrawalStatusProven is the status of proven withdrawals whose
+	// challenge period isn't known, since they were indexed by an older
+	// indexer.
+	WithdrawalStatusProven WithdrawalStatus = "proven"
+	// WithdrawalStatusInChallengePeriod is the status of proven withdrawals
+	// that can be finalized once the challenge period is over.
+	WithdrawalStatusInChallengePeriod WithdrawalStatus = "in_challenge_period"
+	// WithdrawalStatusReadyToFinalize is the status of proven withdrawals
+	// whose challenge period is over.
+	WithdrawalStatusReadyToFinalize WithdrawalStatus = "ready_to_finalize"
+	// WithdrawalStatusFinalized is the status of successfully finalized
+	// withdrawals.
+	WithdrawalStatusFinalized WithdrawalStatus = "finalized"
+	// WithdrawalStatusFailed is the status of withdrawals whose finalization
+	// failed. They can't be finalized again.
+	WithdrawalStatusFailed WithdrawalStatus = "failed"
+)
+
+func ParseWithdrawalStatus(in string) (WithdrawalStatus, error) {
+	status := WithdrawalStatus(in)
+	switch status {
+	case WithdrawalStatusAny, WithdrawalStatusInitiated, WithdrawalStatusReadyToProve,
+		WithdrawalStatusProven, WithdrawalStatusInChallengePeriod, WithdrawalStatusReadyToFinalize,
+		WithdrawalStatusFinalized, WithdrawalStatusFailed:
+		return status, nil
+	}
+	return WithdrawalStatusAny, fmt.Errorf("unknown withdrawal status %q", in)
+}
+
+// SQL returns the condition selecting the withdrawals with the status at the
+// unix time now. It expects the covering output to be joined as l2_outputs.
+func (s WithdrawalStatus) SQL(now uint64) string {
+	const (
+		bedrock     = "AND withdrawals.br_withdrawal_hash IS NOT NULL "
+		unfinalized = bedrock + "AND withdrawals.br_withdrawal_finalized_tx_hash IS NULL "
+		unproven    = unfinalized + "AND withdrawals.br_withdrawal_proven_tx_hash IS NULL "
+		proven      = unfinalized + "AND withdrawals.br_withdrawal_proven_tx_hash IS NOT NULL "
+	)
+
+	switch s {
+	case WithdrawalStatusInitiated:
+		return unproven + "AND l2_outputs.l2_output_index IS NULL"
+	case WithdrawalStatusReadyToProve:
+		return unproven + "AND l2_outputs.l2_output_index IS NOT NULL"
+	case WithdrawalStatusProven:
+		return proven + "AND withdrawals.br_withdrawal_finalizable_at IS NULL"
+	case WithdrawalStatusInChallengePeriod:
+		return proven + fmt.Sprintf("AND withdrawals.br_withdrawal_finalizable_at >= %d", now)
+	case WithdrawalStatusReadyToFinalize:
+		return proven + fmt.Sprintf("AND withdrawals.br_withdrawal_finalizable_at < %d", now)
+	case WithdrawalStatusFinalized:
+		return bedrock + "AND withdrawals.br_withdrawal_finalized_success IS NOT FALSE " +
+			"AND withdrawals.br_withdrawal_finalized_tx_hash IS NOT NULL"
+	case WithdrawalStatusFailed:
+		return bedrock + "AND withdrawals.br_withdrawal_finalized_success IS FALSE " +
+			"AND withdrawals.br_withdrawal_finalized_tx_hash IS NOT NULL"
+	}
+
+	return ""
+}
+
 type ProvenWithdrawal struct {
 	From           common.Address
 	To             common.Address
 	WithdrawalHash common.Hash
 	TxHash         common.Hash
 	LogIndex       uint
+	// FinalizableAt is the unix time at which the challenge period of the
+	// proof ends.
+	FinalizableAt uint64
 }
 
 type FinalizedWithdrawal struct {
@@ -0,0 +1,111 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithdrawalStatus(t *testing.T) {
+	hash := "0x01"
+	txHash := "0x02"
+	success := true
+	failure := false
+	finalizableAt := uint64(100)
+	output := &L2OutputJSON{Index: 1, L2BlockNumber: 10, L1Timestamp: 50}
+
+	tests := []struct {
+		name       string
+		withdrawal WithdrawalJSON
+		status     WithdrawalStatus
+	}{
+		{
+			name:       "initiated",
+			withdrawal: WithdrawalJSON{BedrockWithdrawalHash: &hash},
+			status:     WithdrawalStatusInitiated,
+		},
+		{
+			name: "ready to prove",
+			withdrawal: WithdrawalJSON{
+				BedrockWithdrawalHash: &hash,
+				BedrockL2Output:       output,
+			},
+			status: WithdrawalStatusReadyToProve,
+		},
+		{
+			name: "proven by an older indexer",
+			withdrawal: WithdrawalJSON{
+				BedrockWithdrawalHash: &hash,
+				BedrockL2Output:       output,
+				BedrockProvenTxHash:   &txHash,
+			},
+			status: WithdrawalStatusProven,
+		},
+		{
+			name: "in challenge period",
+			withdrawal: WithdrawalJSON{
+				BedrockWithdrawalHash: &hash,
+				BedrockL2Output:       output,
+				BedrockProvenTxHash:   &txHash,
+				BedrockFinalizableAt:  &finalizableAt,
+			},
+			status: WithdrawalStatusInChallengePeriod,
+		},
+		{
+			name: "finalized",
+			withdrawal: WithdrawalJSON{
+				BedrockWithdrawalHash:   &hash,
+				BedrockProvenTxHash:     &txHash,
+				BedrockFinalizedTxHash:  &txHash,
+				BedrockFinalizedSuccess: &success,
+			},
+			status: WithdrawalStatusFinalized,
+		},
+		{
+			name: "failed",
+			withdrawal: WithdrawalJSON{
+				BedrockWithdrawalHash:   &hash,
+				BedrockProvenTxHash:     &txHash,
+				BedrockFinalizedTxHash:  &txHash,
+				BedrockFinalizedSuccess: &failure,
+			},
+			status: WithdrawalStatusFailed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.withdrawal.updateStatus(finalizableAt)
+			require.NotNil(t, tt.withdrawal.Status)
+			require.Equal(t, tt.status, *tt.withdrawal.Status)
+		})
+	}
+
+	// The challenge period ends after the finalizable time.
+	withdrawal := WithdrawalJSON{
+		BedrockWithdrawalHash: &hash,
+		BedrockProvenTxHash:   &txHash,
+		BedrockFinalizableAt:  &finalizableAt,
+	}
+	withdrawal.updateStatus(finalizableAt + 1)
+	require.Equal(t, WithdrawalStatusReadyToFinalize, *withdrawal.Status)
+
+	// Legacy withdrawals have no status.
+	withdrawal = WithdrawalJSON{}
+	withdrawal.updateStatus(finalizableAt)
+	require.Nil(t, withdrawal.Status)
+}
+
+func TestParseWithdrawalStatus(t *testing.T) {
+	status, err := ParseWithdrawalStatus("")
+	require.NoError(t, err)
+	require.Equal(t, WithdrawalStatusAny, status)
+	require.Empty(t, status.SQL(0))
+
+	status, err = ParseWithdrawalStatus("in_challenge_period")
+	require.NoError(t, err)
+	require.Equal(t, WithdrawalStatusInChallengePeriod, status)
+	require.Contains(t, status.SQL(100), "br_withdrawal_finalizable_at >= 100")
+
+	_, err = ParseWithdrawalStatus("pending")
+	require.Error(t, err)
+}
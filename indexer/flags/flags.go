@@ -46,35 +46,38 @@ var (
 		Required: true,
 		EnvVar:   prefixEnvVar("L1_ADDRESS_MANAGER_ADDRESS"),
 	}
+
+	/* Database Flags */
+
 	DBHostFlag = cli.StringFlag{
-		Name:     "db-host",
-		Usage:    "Hostname of the database connection",
-		Required: true,
-		EnvVar:   prefixEnvVar("DB_HOST"),
+		Name:   "db-host",
+		Usage:  "Hostname of the Postgres database connection",
+		EnvVar: prefixEnvVar("DB_HOST"),
 	}
 	DBPortFlag = cli.Uint64Flag{
-		Name:     "db-port",
-		Usage:    "Port of the database connection",
-		Required: true,
-		EnvVar:   prefixEnvVar("DB_PORT"),
+		Name:   "db-port",
+		Usage:  "Port of the Postgres database connection",
+		EnvVar: prefixEnvVar("DB_PORT"),
 	}
 	DBUserFlag = cli.StringFlag{
-		Name:     "db-user",
-		Usage:    "Username of the database connection",
-		Required: true,
-		EnvVar:   prefixEnvVar("DB_USER"),
+		Name:   "db-user",
+		Usage:  "Username of the Postgres database connection",
+		EnvVar: prefixEnvVar("DB_USER"),
 	}
 	DBPasswordFlag = cli.StringFlag{
-		Name:     "db-password",
-		Usage:    "Password of the database connection",
-		Required: true,
-		EnvVar:   prefixEnvVar("DB_PASSWORD"),
+		Name:   "db-password",
+		Usage:  "Password of the Postgres database connection",
+		EnvVar: prefixEnvVar("DB_PASSWORD"),
 	}
 	DBNameFlag = cli.StringFlag{
-		Name:     "db-name",
-		Usage:    "Database name of the database connection",
-		Required: true,
-		EnvVar:   prefixEnvVar("DB_NAME"),
+		Name:   "db-name",
+		Usage:  "Database name of the Postgres database connection",
+		EnvVar: prefixEnvVar("DB_NAME"),
+	}
+	SQLitePathFlag = cli.StringFlag{
+		Name:   "sqlite-path",
+		Usage:  "Path of an embedded SQLite database to use instead of Postgres",
+		EnvVar: prefixEnvVar("SQLITE_PATH"),
 	}
 
 	/* Bedrock Flags */
@@ -184,6 +187,38 @@ var (
 		Value:  7300,
 		EnvVar: prefixEnvVar("METRICS_PORT"),
 	}
+	EventConfigFlag = cli.StringFlag{
+		Name:   "event-config",
+		Usage:  "Path to a JSON file listing the contracts and events to index",
+		EnvVar: prefixEnvVar("EVENT_CONFIG"),
+	}
+	WebhookConfigFlag = cli.StringFlag{
+		Name:   "webhook-config",
+		Usage:  "Path to a JSON file listing the webhooks receiving bridge events",
+		EnvVar: prefixEnvVar("WEBHOOK_CONFIG"),
+	}
+	TokenListFlag = cli.StringFlag{
+		Name:   "token-list",
+		Usage:  "Path to a token list JSON file whose tokens and L1/L2 token pairs are imported at startup",
+		EnvVar: prefixEnvVar("TOKEN_LIST"),
+	}
+	BackfillFlag = cli.BoolFlag{
+		Name:   "backfill",
+		Usage:  "Whether to index blocks far behind the head in parallel chunks",
+		EnvVar: prefixEnvVar("BACKFILL"),
+	}
+	BackfillChunkSizeFlag = cli.Uint64Flag{
+		Name:   "backfill-chunk-size",
+		Usage:  "The number of blocks fetched at once when backfilling",
+		Value:  2000,
+		EnvVar: prefixEnvVar("BACKFILL_CHUNK_SIZE"),
+	}
+	BackfillParallelismFlag = cli.Uint64Flag{
+		Name:   "backfill-parallelism",
+		Usage:  "The number of chunks fetched concurrently when backfilling",
+		Value:  8,
+		EnvVar: prefixEnvVar("BACKFILL_PARALLELISM"),
+	}
 )
 
 var requiredFlags = []cli.Flag{
@@ -192,14 +227,15 @@ var requiredFlags = []cli.Flag{
 	L1EthRPCFlag,
 	L2EthRPCFlag,
 	L1AddressManagerAddressFlag,
+}
+
+var optionalFlags = []cli.Flag{
 	DBHostFlag,
 	DBPortFlag,
 	DBUserFlag,
 	DBPasswordFlag,
 	DBNameFlag,
-}
-
-var optionalFlags = []cli.Flag{
+	SQLitePathFlag,
 	BedrockFlag,
 	BedrockL1StandardBridgeAddress,
 	BedrockOptimismPortalAddress,
@@ -218,6 +254,12 @@ var optionalFlags = []cli.Flag{
 	MetricsServerEnableFlag,
 	MetricsHostnameFlag,
 	MetricsPortFlag,
+	EventConfigFlag,
+	WebhookConfigFlag,
+	TokenListFlag,
+	BackfillFlag,
+	BackfillChunkSizeFlag,
+	BackfillParallelismFlag,
 }
 
 // Flags contains the list of configuration options available to the binary.
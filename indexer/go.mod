@@ -9,13 +9,17 @@ replace (
 
 require (
 	github.com/ethereum-optimism/optimism/op-bindings v0.10.14
+	github.com/ethereum-optimism/optimism/op-chain-ops v0.10.14
 	github.com/ethereum-optimism/optimism/op-e2e v0.10.14
 	github.com/ethereum-optimism/optimism/op-node v0.10.14
 	github.com/ethereum-optimism/optimism/op-service v0.10.14
 	github.com/ethereum/go-ethereum v1.10.26
 	github.com/google/uuid v1.3.0
 	github.com/gorilla/mux v1.8.0
+	github.com/gorilla/websocket v1.5.0
+	github.com/graph-gophers/graphql-go v1.3.0
 	github.com/lib/pq v1.10.4
+	github.com/mattn/go-sqlite3 v1.14.16
 	github.com/prometheus/client_golang v1.13.0
 	github.com/rs/cors v1.8.2
 	github.com/stretchr/testify v1.8.1
@@ -46,7 +50,6 @@ require (
 	github.com/elastic/gosigar v0.14.2 // indirect
 	github.com/ethereum-optimism/go-ethereum-hdwallet v0.1.3 // indirect
 	github.com/ethereum-optimism/optimism/op-batcher v0.10.14 // indirect
-	github.com/ethereum-optimism/optimism/op-chain-ops v0.10.14 // indirect
 	github.com/ethereum-optimism/optimism/op-proposer v0.10.14 // indirect
 	github.com/ethereum-optimism/optimism/op-signer v0.1.1 // indirect
 	github.com/fjl/memsize v0.0.1 // indirect
@@ -61,8 +64,6 @@ require (
 	github.com/golang/protobuf v1.5.2 // indirect
 	github.com/golang/snappy v0.0.4 // indirect
 	github.com/google/gopacket v1.1.19 // indirect
-	github.com/gorilla/websocket v1.5.0 // indirect
-	github.com/graph-gophers/graphql-go v1.3.0 // indirect
 	github.com/hashicorp/errwrap v1.1.0 // indirect
 	github.com/hashicorp/go-bexpr v0.1.11 // indirect
 	github.com/hashicorp/go-multierror v1.1.1 // indirect
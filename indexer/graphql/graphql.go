@@ -0,0 +1,135 @@
+// Package graphql serves the indexed deposits, withdrawals, tokens, blocks and
+// state batches over GraphQL.
+package graphql
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum-optimism/optimism/indexer/db"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/relay"
+)
+
+const (
+	// maxPageSize is the maximum number of nodes of a connection.
+	maxPageSize = 100
+	// maxDepth is the maximum depth of queries, which bounds the number of
+	// nested connections.
+	maxDepth = 10
+)
+
+var logger = log.New("service", "graphql")
+
+var (
+	// errDatabase is returned instead of database errors, which are logged.
+	errDatabase  = errors.New("database error")
+	errBlockArgs = errors.New("either number or hash must be set")
+)
+
+// NewHandler returns the handler of GraphQL queries over db.
+func NewHandler(database *db.Database) (http.Handler, error) {
+	s, err := graphql.ParseSchema(schema, &Resolver{db: database}, graphql.MaxDepth(maxDepth))
+	if err != nil {
+		return nil, err
+	}
+	return &relay.Handler{Schema: s}, nil
+}
+
+// Long is a 64 bit unsigned integer.
+type Long uint64
+
+// ImplementsGraphQLType returns true if Long implements the provided GraphQL
+// type.
+func (Long) ImplementsGraphQLType(name string) bool {
+	return name == "Long"
+}
+
+// UnmarshalGraphQL unmarshals the provided GraphQL query data.
+func (l *Long) UnmarshalGraphQL(input interface{}) error {
+	switch input := input.(type) {
+	case string:
+		var value uint64
+		var err error
+		if strings.HasPrefix(input, "0x") {
+			value, err = strconv.ParseUint(input[2:], 16, 64)
+		} else {
+			value, err = strconv.ParseUint(input, 10, 64)
+		}
+		if err != nil {
+			return fmt.Errorf("invalid Long %q", input)
+		}
+		*l = Long(value)
+	case int32:
+		if input < 0 {
+			return fmt.Errorf("invalid Long %d", input)
+		}
+		*l = Long(input)
+	case int64:
+		if input < 0 {
+			return fmt.Errorf("invalid Long %d", input)
+		}
+		*l = Long(input)
+	case float64:
+		if input < 0 || input != float64(uint64(input)) {
+			return fmt.Errorf("invalid Long %v", input)
+		}
+		*l = Long(input)
+	default:
+		return fmt.Errorf("unexpected type %T for Long", input)
+	}
+	return nil
+}
+
+func (l *Long) uint64() *uint64 {
+	if l == nil {
+		return nil
+	}
+	value := uint64(*l)
+	return &value
+}
+
+// cursorParam returns the pagination params of a connection.
+func cursorParam(first int32, after *string) (db.CursorParam, error) {
+	if first <= 0 || first > maxPageSize {
+		return db.CursorParam{}, fmt.Errorf("first must be between 1 and %d", maxPageSize)
+	}
+	page := db.CursorParam{First: uint64(first)}
+	if after != nil {
+		cursor, err := db.ParseCursor(*after)
+		if err != nil {
+			return page, err
+		}
+		page.After = &cursor
+	}
+	return page, nil
+}
+
+func parseAddress(in *string) (*common.Address, error) {
+	if in == nil {
+		return nil, nil
+	}
+	if !common.IsHexAddress(*in) {
+		return nil, fmt.Errorf("invalid address %q", *in)
+	}
+	address := common.HexToAddress(*in)
+	return &address, nil
+}
+
+func parseHash(in string) (common.Hash, error) {
+	if len(strings.TrimPrefix(in, "0x")) != 2*common.HashLength {
+		return common.Hash{}, fmt.Errorf("invalid hash %q", in)
+	}
+	return common.HexToHash(in), nil
+}
+
+// dbError logs a database error and returns errDatabase.
+func dbError(err error) error {
+	logger.Error("database error", "err", err)
+	return errDatabase
+}
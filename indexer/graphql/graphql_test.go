@@ -0,0 +1,83 @@
+package graphql
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ethereum-optimism/optimism/indexer/db"
+	"github.com/graph-gophers/graphql-go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchema(t *testing.T) {
+	// Parsing the schema checks that the resolvers implement it.
+	_, err := NewHandler(nil)
+	require.NoError(t, err)
+}
+
+func TestLong(t *testing.T) {
+	tests := []struct {
+		input interface{}
+		value Long
+		err   bool
+	}{
+		{input: "12", value: 12},
+		{input: "0x1f", value: 31},
+		{input: "18446744073709551615", value: 18446744073709551615},
+		{input: int32(7), value: 7},
+		{input: float64(1700000000), value: 1700000000},
+		{input: "abc", err: true},
+		{input: "-1", err: true},
+		{input: int32(-1), err: true},
+		{input: 1.5, err: true},
+		{input: true, err: true},
+	}
+	for _, tt := range tests {
+		var l Long
+		err := l.UnmarshalGraphQL(tt.input)
+		if tt.err {
+			require.Error(t, err, tt.input)
+			continue
+		}
+		require.NoError(t, err, tt.input)
+		require.Equal(t, tt.value, l)
+	}
+}
+
+func TestCursorParam(t *testing.T) {
+	cursor := db.Cursor{BlockNumber: 100, Index: 3}.String()
+	page, err := cursorParam(10, &cursor)
+	require.NoError(t, err)
+	require.Equal(t, uint64(10), page.First)
+	require.Equal(t, &db.Cursor{BlockNumber: 100, Index: 3}, page.After)
+
+	_, err = cursorParam(0, nil)
+	require.Error(t, err)
+	_, err = cursorParam(maxPageSize+1, nil)
+	require.Error(t, err)
+	invalid := "not a cursor"
+	_, err = cursorParam(10, &invalid)
+	require.Error(t, err)
+}
+
+func TestArgumentErrors(t *testing.T) {
+	s := graphql.MustParseSchema(schema, &Resolver{})
+	tests := []struct {
+		query string
+		err   string
+	}{
+		{`{ deposits(first: 0) { nodes { guid } } }`, "first must be between 1 and 100"},
+		{`{ deposits(after: "x") { nodes { guid } } }`, `invalid cursor "x"`},
+		{`{ deposits(filter: {address: "0x1"}) { nodes { guid } } }`, `invalid address "0x1"`},
+		{`{ withdrawals(filter: {token: "0x1"}) { nodes { guid } } }`, `invalid address "0x1"`},
+		{`{ withdrawal(hash: "0x1") { guid } }`, `invalid hash "0x1"`},
+		{`{ l1Tokens(first: 101) { nodes { name } } }`, "first must be between 1 and 100"},
+		{`{ l1Block { hash } }`, errBlockArgs.Error()},
+		{`{ l2Block(number: 1, hash: "0x1") { hash } }`, errBlockArgs.Error()},
+	}
+	for _, tt := range tests {
+		res := s.Exec(context.Background(), tt.query, "", nil)
+		require.Len(t, res.Errors, 1, tt.query)
+		require.Equal(t, tt.err, res.Errors[0].Message, tt.query)
+	}
+}
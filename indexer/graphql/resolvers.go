@@ -0,0 +1,637 @@
+package graphql
+
+import (
+	"strings"
+
+	"github.com/ethereum-optimism/optimism/indexer/db"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/graph-gophers/graphql-go"
+)
+
+// Resolver resolves the root queries.
+type Resolver struct {
+	db *db.Database
+}
+
+type connectionArgs struct {
+	First int32
+	After *string
+}
+
+type TransferFilter struct {
+	Address *string
+	Token   *string
+	Since   *Long
+	Until   *Long
+}
+
+func (f *TransferFilter) filter() (db.TransferFilter, error) {
+	var filter db.TransferFilter
+	if f == nil {
+		return filter, nil
+	}
+	var err error
+	if filter.Address, err = parseAddress(f.Address); err != nil {
+		return filter, err
+	}
+	if filter.Token, err = parseAddress(f.Token); err != nil {
+		return filter, err
+	}
+	filter.Since = f.Since.uint64()
+	filter.Until = f.Until.uint64()
+	return filter, nil
+}
+
+type WithdrawalFilter struct {
+	TransferFilter
+	Status *string
+}
+
+func (f *WithdrawalFilter) filter() (db.TransferFilter, error) {
+	if f == nil {
+		return db.TransferFilter{}, nil
+	}
+	filter, err := f.TransferFilter.filter()
+	if err != nil {
+		return filter, err
+	}
+	if f.Status != nil {
+		filter.Status, err = db.ParseWithdrawalStatus(strings.ToLower(*f.Status))
+	}
+	return filter, err
+}
+
+type BlockFilter struct {
+	Since *Long
+	Until *Long
+}
+
+func (f *BlockFilter) filter() db.BlockFilter {
+	if f == nil {
+		return db.BlockFilter{}
+	}
+	return db.BlockFilter{
+		Since: f.Since.uint64(),
+		Until: f.Until.uint64(),
+	}
+}
+
+func (r *Resolver) Deposits(args struct {
+	Filter *TransferFilter
+	connectionArgs
+}) (*DepositConnection, error) {
+	filter, err := args.Filter.filter()
+	if err != nil {
+		return nil, err
+	}
+	return r.deposits(filter, args.connectionArgs)
+}
+
+func (r *Resolver) deposits(filter db.TransferFilter, args connectionArgs) (*DepositConnection, error) {
+	page, err := cursorParam(args.First, args.After)
+	if err != nil {
+		return nil, err
+	}
+	deposits, hasNextPage, err := r.db.GetDeposits(filter, page)
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	conn := &DepositConnection{
+		nodes:    make([]*Deposit, len(deposits)),
+		pageInfo: &PageInfo{hasNextPage: hasNextPage},
+	}
+	for i, deposit := range deposits {
+		conn.nodes[i] = &Deposit{r: r, d: deposit}
+	}
+	if len(deposits) > 0 {
+		cursor := conn.nodes[len(deposits)-1].Cursor()
+		conn.pageInfo.endCursor = &cursor
+	}
+	return conn, nil
+}
+
+func (r *Resolver) Withdrawals(args struct {
+	Filter *WithdrawalFilter
+	connectionArgs
+}) (*WithdrawalConnection, error) {
+	filter, err := args.Filter.filter()
+	if err != nil {
+		return nil, err
+	}
+	return r.withdrawals(filter, args.connectionArgs)
+}
+
+func (r *Resolver) withdrawals(filter db.TransferFilter, args connectionArgs) (*WithdrawalConnection, error) {
+	page, err := cursorParam(args.First, args.After)
+	if err != nil {
+		return nil, err
+	}
+	withdrawals, hasNextPage, err := r.db.GetWithdrawals(filter, page)
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	conn := &WithdrawalConnection{
+		nodes:    make([]*Withdrawal, len(withdrawals)),
+		pageInfo: &PageInfo{hasNextPage: hasNextPage},
+	}
+	for i, withdrawal := range withdrawals {
+		conn.nodes[i] = &Withdrawal{r: r, w: withdrawal}
+	}
+	if len(withdrawals) > 0 {
+		cursor := conn.nodes[len(withdrawals)-1].Cursor()
+		conn.pageInfo.endCursor = &cursor
+	}
+	return conn, nil
+}
+
+func (r *Resolver) Withdrawal(args struct{ Hash string }) (*Withdrawal, error) {
+	hash, err := parseHash(args.Hash)
+	if err != nil {
+		return nil, err
+	}
+	withdrawal, err := r.db.GetWithdrawalByHash(hash)
+	if err != nil {
+		return nil, dbError(err)
+	}
+	if withdrawal == nil {
+		return nil, nil
+	}
+	return &Withdrawal{r: r, w: *withdrawal}, nil
+}
+
+func (r *Resolver) L1Token(args struct{ Address string }) (*Token, error) {
+	return r.token(args.Address, r.db.GetL1TokenByAddress)
+}
+
+func (r *Resolver) L2Token(args struct{ Address string }) (*Token, error) {
+	return r.token(args.Address, r.db.GetL2TokenByAddress)
+}
+
+func (r *Resolver) token(in string, get func(string) (*db.Token, error)) (*Token, error) {
+	address, err := parseAddress(&in)
+	if err != nil {
+		return nil, err
+	}
+	token, err := get(address.String())
+	if err != nil {
+		return nil, dbError(err)
+	}
+	if token == nil {
+		return nil, nil
+	}
+	// The address isn't selected by the token getters.
+	token.Address = address.String()
+	return &Token{*token}, nil
+}
+
+func (r *Resolver) L1Tokens(args connectionArgs) (*TokenConnection, error) {
+	return r.tokens(args, r.db.GetL1Tokens)
+}
+
+func (r *Resolver) L2Tokens(args connectionArgs) (*TokenConnection, error) {
+	return r.tokens(args, r.db.GetL2Tokens)
+}
+
+func (r *Resolver) tokens(args connectionArgs, get func(uint64, string) ([]db.Token, bool, error)) (*TokenConnection, error) {
+	if _, err := cursorParam(args.First, nil); err != nil {
+		return nil, err
+	}
+	// Tokens are ordered by address, which is their cursor.
+	var after string
+	if args.After != nil {
+		after = *args.After
+	}
+	tokens, hasNextPage, err := get(uint64(args.First), after)
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	conn := &TokenConnection{
+		nodes:    make([]*Token, len(tokens)),
+		pageInfo: &PageInfo{hasNextPage: hasNextPage},
+	}
+	for i, token := range tokens {
+		conn.nodes[i] = &Token{token}
+	}
+	if len(tokens) > 0 {
+		conn.pageInfo.endCursor = &tokens[len(tokens)-1].Address
+	}
+	return conn, nil
+}
+
+type blockArgs struct {
+	Number *Long
+	Hash   *string
+}
+
+func (r *Resolver) L1Block(args blockArgs) (*L1Block, error) {
+	block, err := r.block(args, r.db.GetL1BlockByNumber, r.db.GetL1BlockByHash)
+	if block == nil || err != nil {
+		return nil, err
+	}
+	return &L1Block{r: r, b: *block}, nil
+}
+
+func (r *Resolver) L2Block(args blockArgs) (*L2Block, error) {
+	block, err := r.block(args, r.db.GetL2BlockByNumber, r.db.GetL2BlockByHash)
+	if block == nil || err != nil {
+		return nil, err
+	}
+	return &L2Block{r: r, b: *block}, nil
+}
+
+func (r *Resolver) block(
+	args blockArgs,
+	byNumber func(uint64) (*db.BlockJSON, error),
+	byHash func(common.Hash) (*db.BlockJSON, error),
+) (*db.BlockJSON, error) {
+	var block *db.BlockJSON
+	var err error
+	switch {
+	case args.Number != nil && args.Hash != nil:
+		return nil, errBlockArgs
+	case args.Number != nil:
+		block, err = byNumber(uint64(*args.Number))
+	case args.Hash != nil:
+		var hash common.Hash
+		if hash, err = parseHash(*args.Hash); err != nil {
+			return nil, err
+		}
+		block, err = byHash(hash)
+	default:
+		return nil, errBlockArgs
+	}
+	if err != nil {
+		return nil, dbError(err)
+	}
+	return block, nil
+}
+
+func (r *Resolver) L1Blocks(args struct {
+	Filter *BlockFilter
+	connectionArgs
+}) (*L1BlockConnection, error) {
+	blocks, pageInfo, err := r.blocks(args.Filter.filter(), args.connectionArgs, r.db.GetL1Blocks)
+	if err != nil {
+		return nil, err
+	}
+	conn := &L1BlockConnection{
+		nodes:    make([]*L1Block, len(blocks)),
+		pageInfo: pageInfo,
+	}
+	for i, block := range blocks {
+		conn.nodes[i] = &L1Block{r: r, b: block}
+	}
+	return conn, nil
+}
+
+func (r *Resolver) L2Blocks(args struct {
+	Filter *BlockFilter
+	connectionArgs
+}) (*L2BlockConnection, error) {
+	blocks, pageInfo, err := r.blocks(args.Filter.filter(), args.connectionArgs, r.db.GetL2Blocks)
+	if err != nil {
+		return nil, err
+	}
+	conn := &L2BlockConnection{
+		nodes:    make([]*L2Block, len(blocks)),
+		pageInfo: pageInfo,
+	}
+	for i, block := range blocks {
+		conn.nodes[i] = &L2Block{r: r, b: block}
+	}
+	return conn, nil
+}
+
+func (r *Resolver) blocks(
+	filter db.BlockFilter,
+	args connectionArgs,
+	get func(db.BlockFilter, db.CursorParam) ([]db.BlockJSON, bool, error),
+) ([]db.BlockJSON, *PageInfo, error) {
+	page, err := cursorParam(args.First, args.After)
+	if err != nil {
+		return nil, nil, err
+	}
+	blocks, hasNextPage, err := get(filter, page)
+	if err != nil {
+		return nil, nil, dbError(err)
+	}
+
+	pageInfo := &PageInfo{hasNextPage: hasNextPage}
+	if len(blocks) > 0 {
+		cursor := db.Cursor{BlockNumber: blocks[len(blocks)-1].Number}.String()
+		pageInfo.endCursor = &cursor
+	}
+	return blocks, pageInfo, nil
+}
+
+func (r *Resolver) StateBatch(args struct{ Index Long }) (*StateBatch, error) {
+	batch, err := r.db.GetStateBatchByIndex(uint64(args.Index))
+	if err != nil {
+		return nil, dbError(err)
+	}
+	if batch == nil {
+		return nil, nil
+	}
+	return &StateBatch{r: r, b: *batch}, nil
+}
+
+func (r *Resolver) StateBatches(args struct {
+	Filter *BlockFilter
+	connectionArgs
+}) (*StateBatchConnection, error) {
+	return r.stateBatches(args.Filter.filter(), args.connectionArgs)
+}
+
+func (r *Resolver) stateBatches(filter db.BlockFilter, args connectionArgs) (*StateBatchConnection, error) {
+	page, err := cursorParam(args.First, args.After)
+	if err != nil {
+		return nil, err
+	}
+	batches, hasNextPage, err := r.db.GetStateBatches(filter, page)
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	conn := &StateBatchConnection{
+		nodes:    make([]*StateBatch, len(batches)),
+		pageInfo: &PageInfo{hasNextPage: hasNextPage},
+	}
+	for i, batch := range batches {
+		conn.nodes[i] = &StateBatch{r: r, b: batch}
+	}
+	if len(batches) > 0 {
+		last := batches[len(batches)-1]
+		cursor := db.Cursor{BlockNumber: last.BlockNumber, Index: last.Index}.String()
+		conn.pageInfo.endCursor = &cursor
+	}
+	return conn, nil
+}
+
+type PageInfo struct {
+	endCursor   *string
+	hasNextPage bool
+}
+
+func (p *PageInfo) EndCursor() *string { return p.endCursor }
+func (p *PageInfo) HasNextPage() bool  { return p.hasNextPage }
+
+type DepositConnection struct {
+	nodes    []*Deposit
+	pageInfo *PageInfo
+}
+
+func (c *DepositConnection) Nodes() []*Deposit   { return c.nodes }
+func (c *DepositConnection) PageInfo() *PageInfo { return c.pageInfo }
+
+type WithdrawalConnection struct {
+	nodes    []*Withdrawal
+	pageInfo *PageInfo
+}
+
+func (c *WithdrawalConnection) Nodes() []*Withdrawal { return c.nodes }
+func (c *WithdrawalConnection) PageInfo() *PageInfo  { return c.pageInfo }
+
+type TokenConnection struct {
+	nodes    []*Token
+	pageInfo *PageInfo
+}
+
+func (c *TokenConnection) Nodes() []*Token     { return c.nodes }
+func (c *TokenConnection) PageInfo() *PageInfo { return c.pageInfo }
+
+type L1BlockConnection struct {
+	nodes    []*L1Block
+	pageInfo *PageInfo
+}
+
+func (c *L1BlockConnection) Nodes() []*L1Block   { return c.nodes }
+func (c *L1BlockConnection) PageInfo() *PageInfo { return c.pageInfo }
+
+type L2BlockConnection struct {
+	nodes    []*L2Block
+	pageInfo *PageInfo
+}
+
+func (c *L2BlockConnection) Nodes() []*L2Block   { return c.nodes }
+func (c *L2BlockConnection) PageInfo() *PageInfo { return c.pageInfo }
+
+type StateBatchConnection struct {
+	nodes    []*StateBatch
+	pageInfo *PageInfo
+}
+
+func (c *StateBatchConnection) Nodes() []*StateBatch { return c.nodes }
+func (c *StateBatchConnection) PageInfo() *PageInfo  { return c.pageInfo }
+
+type Token struct {
+	t db.Token
+}
+
+func (t *Token) Address() string { return t.t.Address }
+func (t *Token) Name() string    { return t.t.Name }
+func (t *Token) Symbol() string  { return t.t.Symbol }
+func (t *Token) Decimals() int32 { return int32(t.t.Decimals) }
+
+// Deposit resolves a deposit, and the tokens and block it references.
+type Deposit struct {
+	r *Resolver
+	d db.DepositJSON
+}
+
+func (d *Deposit) GUID() graphql.ID        { return graphql.ID(d.d.GUID) }
+func (d *Deposit) From() string            { return d.d.FromAddress }
+func (d *Deposit) To() string              { return d.d.ToAddress }
+func (d *Deposit) L1Token() *Token         { return &Token{*d.d.L1Token} }
+func (d *Deposit) Amount() string          { return d.d.Amount }
+func (d *Deposit) Data() string            { return hexutil.Encode(d.d.Data) }
+func (d *Deposit) LogIndex() int32         { return int32(d.d.LogIndex) }
+func (d *Deposit) TransactionHash() string { return d.d.TxHash }
+
+func (d *Deposit) L2Token() (*Token, error) {
+	return d.r.L2Token(struct{ Address string }{d.d.L2Token})
+}
+
+func (d *Deposit) Block() (*L1Block, error) {
+	return d.r.L1Block(blockArgs{Number: (*Long)(&d.d.BlockNumber)})
+}
+
+func (d *Deposit) Cursor() string {
+	return db.Cursor{BlockNumber: d.d.BlockNumber, Index: d.d.LogIndex}.String()
+}
+
+// Withdrawal resolves a withdrawal, and the tokens, block, state batch and
+// lifecycle events it references.
+type Withdrawal struct {
+	r *Resolver
+	w db.WithdrawalJSON
+}
+
+func (w *Withdrawal) GUID() graphql.ID        { return graphql.ID(w.w.GUID) }
+func (w *Withdrawal) From() string            { return w.w.FromAddress }
+func (w *Withdrawal) To() string              { return w.w.ToAddress }
+func (w *Withdrawal) L2Token() *Token         { return &Token{*w.w.L2Token} }
+func (w *Withdrawal) Amount() string          { return w.w.Amount }
+func (w *Withdrawal) Data() string            { return hexutil.Encode(w.w.Data) }
+func (w *Withdrawal) LogIndex() int32         { return int32(w.w.LogIndex) }
+func (w *Withdrawal) TransactionHash() string { return w.w.TxHash }
+
+func (w *Withdrawal) L1Token() (*Token, error) {
+	return w.r.L1Token(struct{ Address string }{w.w.L1Token})
+}
+
+func (w *Withdrawal) Block() (*L2Block, error) {
+	return w.r.L2Block(blockArgs{Number: (*Long)(&w.w.BlockNumber)})
+}
+
+func (w *Withdrawal) StateBatch() (*StateBatch, error) {
+	// Bedrock withdrawals are proven against outputs instead.
+	if w.w.BedrockWithdrawalHash != nil {
+		return nil, nil
+	}
+	batch, err := w.r.db.GetStateBatchByL2Block(w.w.BlockNumber)
+	if err != nil {
+		return nil, dbError(err)
+	}
+	if batch == nil {
+		return nil, nil
+	}
+	return &StateBatch{r: w.r, b: *batch}, nil
+}
+
+func (w *Withdrawal) Lifecycle() *WithdrawalLifecycle {
+	if w.w.Status == nil {
+		return nil
+	}
+	return &WithdrawalLifecycle{r: w.r, w: &w.w}
+}
+
+func (w *Withdrawal) Cursor() string {
+	return db.Cursor{BlockNumber: w.w.BlockNumber, Index: w.w.LogIndex}.String()
+}
+
+// WithdrawalLifecycle resolves the status of a bedrock withdrawal and the L1
+// events of its proof and finalization.
+type WithdrawalLifecycle struct {
+	r *Resolver
+	w *db.WithdrawalJSON
+}
+
+func (l *WithdrawalLifecycle) Status() string         { return strings.ToUpper(string(*l.w.Status)) }
+func (l *WithdrawalLifecycle) WithdrawalHash() string { return *l.w.BedrockWithdrawalHash }
+func (l *WithdrawalLifecycle) ProvableAt() *Long      { return (*Long)(l.w.BedrockProvableAt) }
+func (l *WithdrawalLifecycle) FinalizableAt() *Long   { return (*Long)(l.w.BedrockFinalizableAt) }
+func (l *WithdrawalLifecycle) FinalizedSuccess() *bool {
+	return l.w.BedrockFinalizedSuccess
+}
+
+func (l *WithdrawalLifecycle) L2Output() *L2Output {
+	if l.w.BedrockL2Output == nil {
+		return nil
+	}
+	return &L2Output{*l.w.BedrockL2Output}
+}
+
+func (l *WithdrawalLifecycle) Proven() *L1Event {
+	if l.w.BedrockProvenTxHash == nil {
+		return nil
+	}
+	return &L1Event{
+		r:         l.r,
+		txHash:    *l.w.BedrockProvenTxHash,
+		logIndex:  *l.w.BedrockProvenLogIndex,
+		blockHash: l.w.BedrockProvenBlockHash,
+	}
+}
+
+func (l *WithdrawalLifecycle) Finalized() *L1Event {
+	if l.w.BedrockFinalizedTxHash == nil {
+		return nil
+	}
+	return &L1Event{
+		r:         l.r,
+		txHash:    *l.w.BedrockFinalizedTxHash,
+		logIndex:  *l.w.BedrockFinalizedLogIndex,
+		blockHash: l.w.BedrockFinalizedBlockHash,
+	}
+}
+
+type L2Output struct {
+	o db.L2OutputJSON
+}
+
+func (o *L2Output) Index() Long             { return Long(o.o.Index) }
+func (o *L2Output) OutputRoot() string      { return o.o.OutputRoot }
+func (o *L2Output) L2BlockNumber() Long     { return Long(o.o.L2BlockNumber) }
+func (o *L2Output) L1Timestamp() Long       { return Long(o.o.L1Timestamp) }
+func (o *L2Output) TransactionHash() string { return o.o.TxHash }
+
+type L1Event struct {
+	r         *Resolver
+	txHash    string
+	logIndex  int
+	blockHash *string
+}
+
+func (e *L1Event) TransactionHash() string { return e.txHash }
+func (e *L1Event) LogIndex() int32         { return int32(e.logIndex) }
+
+func (e *L1Event) Block() (*L1Block, error) {
+	if e.blockHash == nil {
+		return nil, nil
+	}
+	return e.r.L1Block(blockArgs{Hash: e.blockHash})
+}
+
+type L1Block struct {
+	r *Resolver
+	b db.BlockJSON
+}
+
+func (b *L1Block) Hash() string       { return b.b.Hash }
+func (b *L1Block) ParentHash() string { return b.b.ParentHash }
+func (b *L1Block) Number() Long       { return Long(b.b.Number) }
+func (b *L1Block) Timestamp() Long    { return Long(b.b.Timestamp) }
+
+func (b *L1Block) Deposits(args connectionArgs) (*DepositConnection, error) {
+	hash := common.HexToHash(b.b.Hash)
+	return b.r.deposits(db.TransferFilter{BlockHash: &hash}, args)
+}
+
+func (b *L1Block) StateBatches(args connectionArgs) (*StateBatchConnection, error) {
+	hash := common.HexToHash(b.b.Hash)
+	return b.r.stateBatches(db.BlockFilter{Hash: &hash}, args)
+}
+
+type L2Block struct {
+	r *Resolver
+	b db.BlockJSON
+}
+
+func (b *L2Block) Hash() string       { return b.b.Hash }
+func (b *L2Block) ParentHash() string { return b.b.ParentHash }
+func (b *L2Block) Number() Long       { return Long(b.b.Number) }
+func (b *L2Block) Timestamp() Long    { return Long(b.b.Timestamp) }
+
+func (b *L2Block) Withdrawals(args connectionArgs) (*WithdrawalConnection, error) {
+	hash := common.HexToHash(b.b.Hash)
+	return b.r.withdrawals(db.TransferFilter{BlockHash: &hash}, args)
+}
+
+type StateBatch struct {
+	r *Resolver
+	b db.StateBatchJSON
+}
+
+func (b *StateBatch) Index() Long       { return Long(b.b.Index) }
+func (b *StateBatch) Root() string      { return b.b.Root }
+func (b *StateBatch) Size() Long        { return Long(b.b.Size) }
+func (b *StateBatch) PrevTotal() Long   { return Long(b.b.PrevTotal) }
+func (b *StateBatch) ExtraData() string { return hexutil.Encode(b.b.ExtraData) }
+
+func (b *StateBatch) Block() (*L1Block, error) {
+	return b.r.L1Block(blockArgs{Hash: &b.b.BlockHash})
+}
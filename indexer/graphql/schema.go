@@ -0,0 +1,207 @@
+package graphql
+
+const schema string = `
+    # Long is a 64 bit unsigned integer. Inputs can be decimal or 0x prefixed
+    # hexadecimal strings.
+    scalar Long
+
+    schema {
+        query: Query
+    }
+
+    type Query {
+        # Deposits returns the deposits selected by filter, ordered by L1 block
+        # and log index.
+        deposits(filter: TransferFilter, first: Int = 10, after: String): DepositConnection!
+        # Withdrawals returns the withdrawals selected by filter, ordered by L2
+        # block and log index.
+        withdrawals(filter: WithdrawalFilter, first: Int = 10, after: String): WithdrawalConnection!
+        # Withdrawal returns the bedrock withdrawal with the given withdrawal
+        # hash.
+        withdrawal(hash: String!): Withdrawal
+        l1Token(address: String!): Token
+        l2Token(address: String!): Token
+        l1Tokens(first: Int = 10, after: String): TokenConnection!
+        l2Tokens(first: Int = 10, after: String): TokenConnection!
+        # L1Block returns the indexed L1 block with the given number or hash.
+        # Only the blocks containing indexed data are stored.
+        l1Block(number: Long, hash: String): L1Block
+        # L2Block returns the indexed L2 block with the given number or hash.
+        # Only the blocks containing indexed data are stored.
+        l2Block(number: Long, hash: String): L2Block
+        l1Blocks(filter: BlockFilter, first: Int = 10, after: String): L1BlockConnection!
+        l2Blocks(filter: BlockFilter, first: Int = 10, after: String): L2BlockConnection!
+        stateBatch(index: Long!): StateBatch
+        stateBatches(filter: BlockFilter, first: Int = 10, after: String): StateBatchConnection!
+    }
+
+    # TransferFilter selects deposits or withdrawals. Omitted fields match all
+    # transfers.
+    input TransferFilter {
+        # Address matches the sender or the recipient.
+        address: String
+        # Token matches the L1 or the L2 token.
+        token: String
+        # Since and until bound the block timestamp, inclusive and exclusive.
+        since: Long
+        until: Long
+    }
+
+    input WithdrawalFilter {
+        address: String
+        token: String
+        since: Long
+        until: Long
+        # Status only matches bedrock withdrawals.
+        status: WithdrawalStatus
+    }
+
+    input BlockFilter {
+        # Since and until bound the block timestamp, inclusive and exclusive.
+        since: Long
+        until: Long
+    }
+
+    type PageInfo {
+        # EndCursor is the cursor of the last node, to pass as after to get the
+        # next page.
+        endCursor: String
+        hasNextPage: Boolean!
+    }
+
+    type DepositConnection {
+        nodes: [Deposit!]!
+        pageInfo: PageInfo!
+    }
+
+    type WithdrawalConnection {
+        nodes: [Withdrawal!]!
+        pageInfo: PageInfo!
+    }
+
+    type TokenConnection {
+        nodes: [Token!]!
+        pageInfo: PageInfo!
+    }
+
+    type L1BlockConnection {
+        nodes: [L1Block!]!
+        pageInfo: PageInfo!
+    }
+
+    type L2BlockConnection {
+        nodes: [L2Block!]!
+        pageInfo: PageInfo!
+    }
+
+    type StateBatchConnection {
+        nodes: [StateBatch!]!
+        pageInfo: PageInfo!
+    }
+
+    type Token {
+        address: String!
+        name: String!
+        symbol: String!
+        decimals: Int!
+    }
+
+    type Deposit {
+        guid: ID!
+        from: String!
+        to: String!
+        l1Token: Token!
+        # L2Token is null until a withdrawal of the token is indexed.
+        l2Token: Token
+        amount: String!
+        data: String!
+        logIndex: Int!
+        transactionHash: String!
+        block: L1Block!
+        cursor: String!
+    }
+
+    type Withdrawal {
+        guid: ID!
+        from: String!
+        to: String!
+        # L1Token is null until a deposit of the token is indexed.
+        l1Token: Token
+        l2Token: Token!
+        amount: String!
+        data: String!
+        logIndex: Int!
+        transactionHash: String!
+        block: L2Block!
+        # StateBatch is the state batch containing a legacy withdrawal, once it
+        # is appended.
+        stateBatch: StateBatch
+        # Lifecycle is null for legacy withdrawals.
+        lifecycle: WithdrawalLifecycle
+        cursor: String!
+    }
+
+    enum WithdrawalStatus {
+        INITIATED
+        READY_TO_PROVE
+        PROVEN
+        IN_CHALLENGE_PERIOD
+        READY_TO_FINALIZE
+        FINALIZED
+        FAILED
+    }
+
+    type WithdrawalLifecycle {
+        status: WithdrawalStatus!
+        withdrawalHash: String!
+        # L2Output is the first output the withdrawal can be proven against.
+        l2Output: L2Output
+        provableAt: Long
+        proven: L1Event
+        finalizableAt: Long
+        finalized: L1Event
+        finalizedSuccess: Boolean
+    }
+
+    type L2Output {
+        index: Long!
+        outputRoot: String!
+        l2BlockNumber: Long!
+        l1Timestamp: Long!
+        transactionHash: String!
+    }
+
+    # L1Event is an event of a withdrawal emitted on L1.
+    type L1Event {
+        transactionHash: String!
+        logIndex: Int!
+        # Block is null for events indexed before blocks were recorded.
+        block: L1Block
+    }
+
+    type L1Block {
+        hash: String!
+        parentHash: String!
+        number: Long!
+        timestamp: Long!
+        deposits(first: Int = 10, after: String): DepositConnection!
+        stateBatches(first: Int = 10, after: String): StateBatchConnection!
+    }
+
+    type L2Block {
+        hash: String!
+        parentHash: String!
+        number: Long!
+        timestamp: Long!
+        withdrawals(first: Int = 10, after: String): WithdrawalConnection!
+    }
+
+    type StateBatch {
+        index: Long!
+        root: String!
+        size: Long!
+        prevTotal: Long!
+        extraData: String!
+        block: L1Block!
+    }
+`
@@ -10,6 +10,7 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/ethereum-optimism/optimism/indexer/graphql"
 	"github.com/ethereum-optimism/optimism/indexer/services"
 	"github.com/ethereum/go-ethereum/common"
 
@@ -18,8 +19,12 @@ import (
 	"github.com/rs/cors"
 
 	database "github.com/ethereum-optimism/optimism/indexer/db"
+	"github.com/ethereum-optimism/optimism/indexer/services/backfill"
+	"github.com/ethereum-optimism/optimism/indexer/services/events"
 	"github.com/ethereum-optimism/optimism/indexer/services/l1"
 	"github.com/ethereum-optimism/optimism/indexer/services/l2"
+	"github.com/ethereum-optimism/optimism/indexer/services/notify"
+	"github.com/ethereum-optimism/optimism/indexer/services/tokenlist"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/rpc"
@@ -78,6 +83,13 @@ type Indexer struct {
 	l1IndexingService *l1.Service
 	l2IndexingService *l2.Service
 	airdropService    *services.Airdrop
+	// withdrawals is nil on legacy networks.
+	withdrawals    *services.Withdrawals
+	contractEvents *services.ContractEvents
+	tokens         *services.Tokens
+	messages       *services.Messages
+	notifier       *notify.Notifier
+	graphql        http.Handler
 
 	router  *mux.Router
 	metrics *metrics.Metrics
@@ -129,15 +141,7 @@ func NewIndexer(cfg Config) (*Indexer, error) {
 		log.Info("metrics server enabled", "host", cfg.MetricsHostname, "port", cfg.MetricsPort)
 	}
 
-	dsn := fmt.Sprintf("host=%s port=%d dbname=%s sslmode=disable",
-		cfg.DBHost, cfg.DBPort, cfg.DBName)
-	if cfg.DBUser != "" {
-		dsn += fmt.Sprintf(" user=%s", cfg.DBUser)
-	}
-	if cfg.DBPassword != "" {
-		dsn += fmt.Sprintf(" password=%s", cfg.DBPassword)
-	}
-	db, err := database.NewDatabase(dsn)
+	db, err := newDatabase(cfg)
 	if err != nil {
 		return nil, err
 	}
@@ -156,6 +160,59 @@ func NewIndexer(cfg Config) (*Indexer, error) {
 		return nil, err
 	}
 
+	var withdrawals *services.Withdrawals
+	if cfg.Bedrock {
+		withdrawals = services.NewWithdrawals(db, l2Client, l2RPC, addrManager)
+	}
+
+	var l1Events, l2Events *events.Indexer
+	if cfg.EventConfigPath != "" {
+		eventCfg, err := events.LoadConfig(cfg.EventConfigPath)
+		if err != nil {
+			return nil, err
+		}
+		if contracts := eventCfg.ContractsOn(database.ChainL1); len(contracts) > 0 {
+			l1Events, err = events.NewIndexer(l1Client, contracts)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if contracts := eventCfg.ContractsOn(database.ChainL2); len(contracts) > 0 {
+			l2Events, err = events.NewIndexer(l2Client, contracts)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if cfg.TokenListPath != "" {
+		if err := importTokenList(ctx, db, l2Client, cfg.TokenListPath, cfg.ChainID); err != nil {
+			return nil, err
+		}
+	}
+
+	var webhookCfg *notify.Config
+	if cfg.WebhookConfigPath != "" {
+		webhookCfg, err = notify.LoadConfig(cfg.WebhookConfigPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+	notifier := notify.New(ctx, db, webhookCfg, m)
+
+	var backfillCfg *backfill.Config
+	if cfg.Backfill {
+		backfillCfg = &backfill.Config{
+			ChunkSize:   cfg.BackfillChunkSize,
+			Parallelism: int(cfg.BackfillParallelism),
+		}
+	}
+
+	graphqlHandler, err := graphql.NewHandler(db)
+	if err != nil {
+		return nil, err
+	}
+
 	l1IndexingService, err := l1.NewService(l1.ServiceConfig{
 		Context:            ctx,
 		Metrics:            m,
@@ -168,6 +225,9 @@ func NewIndexer(cfg Config) (*Indexer, error) {
 		MaxHeaderBatchSize: cfg.MaxHeaderBatchSize,
 		StartBlockNumber:   cfg.L1StartBlockNumber,
 		Bedrock:            cfg.Bedrock,
+		ContractEvents:     l1Events,
+		Backfill:           backfillCfg,
+		Notifier:           notifier,
 	})
 	if err != nil {
 		return nil, err
@@ -183,6 +243,9 @@ func NewIndexer(cfg Config) (*Indexer, error) {
 		MaxHeaderBatchSize: cfg.MaxHeaderBatchSize,
 		StartBlockNumber:   uint64(0),
 		Bedrock:            cfg.Bedrock,
+		ContractEvents:     l2Events,
+		Backfill:           backfillCfg,
+		Notifier:           notifier,
 	})
 	if err != nil {
 		return nil, err
@@ -196,12 +259,49 @@ func NewIndexer(cfg Config) (*Indexer, error) {
 		l1IndexingService: l1IndexingService,
 		l2IndexingService: l2IndexingService,
 		airdropService:    services.NewAirdrop(db, m),
+		withdrawals:       withdrawals,
+		contractEvents:    services.NewContractEvents(db),
+		tokens:            services.NewTokens(db),
+		messages:          services.NewMessages(db),
+		notifier:          notifier,
+		graphql:           graphqlHandler,
 		router:            mux.NewRouter(),
 		metrics:           m,
 		db:                db,
 	}, nil
 }
 
+// newDatabase returns the database of cfg, SQLite if a path is configured and
+// Postgres otherwise, after applying the pending migrations.
+func newDatabase(cfg Config) (*database.Database, error) {
+	if cfg.SQLitePath != "" {
+		return database.NewSQLiteDatabase(cfg.SQLitePath)
+	}
+	return database.NewDatabase(dbConnectionString(cfg))
+}
+
+// openDatabase returns the database of cfg without migrating it.
+func openDatabase(cfg Config) (*database.Database, error) {
+	if cfg.SQLitePath != "" {
+		return database.OpenSQLiteDatabase(cfg.SQLitePath)
+	}
+	return database.OpenDatabase(dbConnectionString(cfg))
+}
+
+// dbConnectionString returns the connection string of the Postgres database
+// of cfg.
+func dbConnectionString(cfg Config) string {
+	dsn := fmt.Sprintf("host=%s port=%d dbname=%s sslmode=disable",
+		cfg.DBHost, cfg.DBPort, cfg.DBName)
+	if cfg.DBUser != "" {
+		dsn += fmt.Sprintf(" user=%s", cfg.DBUser)
+	}
+	if cfg.DBPassword != "" {
+		dsn += fmt.Sprintf(" password=%s", cfg.DBPassword)
+	}
+	return dsn
+}
+
 // Serve spins up a REST API server at the given hostname and port.
 func (b *Indexer) Serve() error {
 	c := cors.New(cors.Options{
@@ -213,6 +313,14 @@ func (b *Indexer) Serve() error {
 	b.router.HandleFunc("/v1/deposits/0x{address:[a-fA-F0-9]{40}}", b.l1IndexingService.GetDeposits).Methods("GET")
 	b.router.HandleFunc("/v1/withdrawal/0x{hash:[a-fA-F0-9]{64}}", b.l2IndexingService.GetWithdrawalBatch).Methods("GET")
 	b.router.HandleFunc("/v1/withdrawals/0x{address:[a-fA-F0-9]{40}}", b.l2IndexingService.GetWithdrawals).Methods("GET")
+	if b.withdrawals != nil {
+		b.router.HandleFunc("/v1/withdrawal-status/0x{hash:[a-fA-F0-9]{64}}", b.withdrawals.GetWithdrawalStatus).Methods("GET")
+	}
+	b.router.HandleFunc("/v1/events", b.contractEvents.GetContractEvents).Methods("GET")
+	b.router.HandleFunc("/v1/tokens", b.tokens.GetTokenPairs).Methods("GET")
+	b.router.HandleFunc("/v1/messages", b.messages.GetMessages).Methods("GET")
+	b.router.Handle("/v1/graphql", b.graphql).Methods("POST")
+	b.router.HandleFunc("/v1/notifications", b.notifier.ServeWebsocket).Methods("GET")
 	b.router.HandleFunc("/v1/airdrops/0x{address:[a-fA-F0-9]{40}}", b.airdropService.GetAirdrop)
 	b.router.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(200)
@@ -264,6 +372,7 @@ func (b *Indexer) Start() error {
 		if err != nil {
 			return err
 		}
+		b.notifier.Start()
 	}
 
 	return b.Serve()
@@ -271,6 +380,10 @@ func (b *Indexer) Start() error {
 
 // Stop stops the indexing service on L1 and L2 chains.
 func (b *Indexer) Stop() {
+	// Subscribers are disconnected first, as hijacked connections aren't
+	// closed by the server shutdown.
+	b.notifier.Stop()
+
 	b.db.Close()
 
 	if b.server != nil {
@@ -285,6 +398,33 @@ func (b *Indexer) Stop() {
 	}
 }
 
+// importTokenList imports the tokens and token pairs of the token list at
+// path. The L2 chain ID, used to find the L2 tokens of the list, is read from
+// the L2 provider.
+func importTokenList(ctx context.Context, db *database.Database, l2Client *ethclient.Client, path string, l1ChainID uint64) error {
+	list, err := tokenlist.Load(path)
+	if err != nil {
+		return err
+	}
+
+	l2ChainID, err := l2Client.ChainID(ctx)
+	if err != nil {
+		return err
+	}
+
+	l1Tokens, l2Tokens, pairs, err := list.Pairs(l1ChainID, l2ChainID.Uint64())
+	if err != nil {
+		return err
+	}
+	if err := db.ImportTokens(l1Tokens, l2Tokens, pairs); err != nil {
+		return err
+	}
+
+	log.Info("imported token list", "name", list.Name, "l1_tokens", len(l1Tokens),
+		"l2_tokens", len(l2Tokens), "pairs", len(pairs))
+	return nil
+}
+
 // dialL1EthClientWithTimeout attempts to dial the L1 provider using the
 // provided URL. If the dial doesn't complete within defaultDialTimeout seconds,
 // this method will return an error.
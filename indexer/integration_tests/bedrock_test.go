@@ -8,6 +8,7 @@ import (
 	"math/big"
 	"net/http"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -34,8 +35,6 @@ import (
 )
 
 func TestBedrockIndexer(t *testing.T) {
-	dbParams := createTestDB(t)
-
 	cfg := op_e2e.DefaultSystemConfig(t)
 	cfg.DeployConfig.FinalizationPeriodSeconds = 2
 	sys, err := cfg.Start()
@@ -65,11 +64,6 @@ func TestBedrockIndexer(t *testing.T) {
 		L1EthRpc:                       sys.Nodes["l1"].HTTPEndpoint(),
 		L2EthRpc:                       sys.Nodes["sequencer"].HTTPEndpoint(),
 		PollInterval:                   time.Second,
-		DBHost:                         dbParams.Host,
-		DBPort:                         dbParams.Port,
-		DBUser:                         dbParams.User,
-		DBPassword:                     dbParams.Password,
-		DBName:                         dbParams.Name,
 		LogLevel:                       "info",
 		LogTerminal:                    true,
 		L1StartBlockNumber:             0,
@@ -83,6 +77,7 @@ func TestBedrockIndexer(t *testing.T) {
 		BedrockL1StandardBridgeAddress: cfg.DeployConfig.L1StandardBridgeProxy,
 		BedrockOptimismPortalAddress:   cfg.DeployConfig.OptimismPortalProxy,
 	}
+	configureTestDB(t, &idxrCfg)
 	idxr, err := indexer.NewIndexer(idxrCfg)
 	require.NoError(t, err)
 
@@ -298,6 +293,22 @@ func TestBedrockIndexer(t *testing.T) {
 	})
 }
 
+// configureTestDB points cfg to a new embedded SQLite database, or to a new
+// Postgres database when INDEXER_TEST_POSTGRES is set.
+func configureTestDB(t *testing.T, cfg *indexer.Config) {
+	if os.Getenv("INDEXER_TEST_POSTGRES") == "" {
+		cfg.SQLitePath = filepath.Join(t.TempDir(), "indexer.db")
+		return
+	}
+
+	dbParams := createTestDB(t)
+	cfg.DBHost = dbParams.Host
+	cfg.DBPort = dbParams.Port
+	cfg.DBUser = dbParams.User
+	cfg.DBPassword = dbParams.Password
+	cfg.DBName = dbParams.Name
+}
+
 type testDBParams struct {
 	Host     string
 	Port     uint64
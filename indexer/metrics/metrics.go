@@ -31,6 +31,10 @@ type Metrics struct {
 
 	UpdateDuration *prometheus.SummaryVec
 
+	ReorgsCount *prometheus.CounterVec
+
+	ReorgedBlocksCount *prometheus.CounterVec
+
 	CachedTokensCount *prometheus.CounterVec
 
 	HTTPRequestsCount prometheus.Counter
@@ -39,6 +43,8 @@ type Metrics struct {
 
 	HTTPRequestDurationSecs prometheus.Summary
 
+	WebhookDeliveriesCount *prometheus.CounterVec
+
 	tokenAddrs map[string]string
 }
 
@@ -109,6 +115,22 @@ func NewMetrics(monitoredTokens map[string]string) *Metrics {
 			"chain",
 		}),
 
+		ReorgsCount: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name:      "reorgs_count",
+			Help:      "The number of reorgs handled for each chain.",
+			Namespace: metricsNamespace,
+		}, []string{
+			"chain",
+		}),
+
+		ReorgedBlocksCount: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name:      "reorged_blocks_count",
+			Help:      "The number of indexed blocks removed by reorgs for each chain.",
+			Namespace: metricsNamespace,
+		}, []string{
+			"chain",
+		}),
+
 		CachedTokensCount: promauto.NewCounterVec(prometheus.CounterOpts{
 			Name:      "cached_tokens_count",
 			Help:      "How many tokens are in the cache",
@@ -138,6 +160,14 @@ func NewMetrics(monitoredTokens map[string]string) *Metrics {
 			Objectives: map[float64]float64{0.5: 0.05, 0.9: 0.01, 0.95: 0.005, 0.99: 0.001},
 		}),
 
+		WebhookDeliveriesCount: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name:      "webhook_deliveries_count",
+			Help:      "The number of webhook delivery attempts by outcome.",
+			Namespace: metricsNamespace,
+		}, []string{
+			"outcome",
+		}),
+
 		tokenAddrs: mts,
 	}
 }
@@ -172,6 +202,16 @@ func (m *Metrics) RecordStateBatches(count int) {
 	m.StateBatchesCount.Add(float64(count))
 }
 
+func (m *Metrics) RecordL1Reorg(removedBlocks int64) {
+	m.ReorgsCount.WithLabelValues("l1").Inc()
+	m.ReorgedBlocksCount.WithLabelValues("l1").Add(float64(removedBlocks))
+}
+
+func (m *Metrics) RecordL2Reorg(removedBlocks int64) {
+	m.ReorgsCount.WithLabelValues("l2").Inc()
+	m.ReorgedBlocksCount.WithLabelValues("l2").Add(float64(removedBlocks))
+}
+
 func (m *Metrics) SetL1CatchingUp(state bool) {
 	var catchingUp float64
 	if state {
@@ -213,6 +253,12 @@ func (m *Metrics) RecordHTTPResponse(code int, dur time.Duration) {
 	m.HTTPRequestDurationSecs.Observe(float64(dur) / float64(time.Second))
 }
 
+// RecordWebhookDelivery records a webhook delivery attempt, by whether it was
+// delivered, retried or failed.
+func (m *Metrics) RecordWebhookDelivery(outcome string) {
+	m.WebhookDeliveriesCount.WithLabelValues(outcome).Inc()
+}
+
 func (m *Metrics) Serve(hostname string, port uint64) (*http.Server, error) {
 	mux := http.NewServeMux()
 	mux.Handle("/metrics", promhttp.Handler())
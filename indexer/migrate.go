@@ -0,0 +1,125 @@
+package indexer
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/urfave/cli"
+
+	database "github.com/ethereum-optimism/optimism/indexer/db"
+)
+
+var migrateToFlag = cli.Uint64Flag{
+	Name:  "to",
+	Usage: "The schema version to migrate to",
+}
+
+// MigrateCommand manages the migrations of the database schema. It uses the
+// database flags of the indexer.
+var MigrateCommand = cli.Command{
+	Name:  "migrate",
+	Usage: "Manages the migrations of the database schema",
+	Subcommands: []cli.Command{
+		{
+			Name:   "up",
+			Usage:  "Applies the pending migrations, up to the latest version by default",
+			Flags:  []cli.Flag{migrateToFlag},
+			Action: migrateUp,
+		},
+		{
+			Name:   "down",
+			Usage:  "Reverts migrations, only the last one by default",
+			Flags:  []cli.Flag{migrateToFlag},
+			Action: migrateDown,
+		},
+		{
+			Name:   "status",
+			Usage:  "Lists the applied and pending migrations",
+			Action: migrateStatus,
+		},
+	},
+}
+
+func openMigrationDatabase(ctx *cli.Context) (*database.Database, error) {
+	cfg, err := NewConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return openDatabase(cfg)
+}
+
+func migrateUp(ctx *cli.Context) error {
+	db, err := openMigrationDatabase(ctx)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	target := database.LatestSchemaVersion()
+	if ctx.IsSet(migrateToFlag.Name) {
+		target = ctx.Uint64(migrateToFlag.Name)
+	}
+	applied, err := db.MigrateUp(target)
+	for _, m := range applied {
+		log.Info("applied migration", "version", m.Version, "description", m.Description)
+	}
+	if err != nil {
+		return err
+	}
+	if len(applied) == 0 {
+		log.Info("no pending migrations", "version", target)
+	}
+	return nil
+}
+
+func migrateDown(ctx *cli.Context) error {
+	db, err := openMigrationDatabase(ctx)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	current, err := db.SchemaVersion()
+	if err != nil {
+		return err
+	}
+	if current == 0 {
+		log.Info("no migrations to revert")
+		return nil
+	}
+	target := current - 1
+	if ctx.IsSet(migrateToFlag.Name) {
+		target = ctx.Uint64(migrateToFlag.Name)
+	}
+	reverted, err := db.MigrateDown(target)
+	for _, m := range reverted {
+		log.Info("reverted migration", "version", m.Version, "description", m.Description)
+	}
+	return err
+}
+
+func migrateStatus(ctx *cli.Context) error {
+	db, err := openMigrationDatabase(ctx)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	statuses, err := db.MigrationStatus()
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tAPPLIED AT\tDESCRIPTION")
+	for _, status := range statuses {
+		appliedAt := "pending"
+		if status.AppliedAt != nil {
+			appliedAt = status.AppliedAt.UTC().Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\n", status.Version, appliedAt, status.Description)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,260 @@
+// Package backfill indexes a range of blocks far behind the head by fetching
+// chunks of blocks concurrently and storing them in order.
+package backfill
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/ethereum-optimism/optimism/indexer/db"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+var logger = log.New("service", "backfill")
+
+// errDisconnected is returned when a staged chunk doesn't connect to the last
+// stored block, because the chain reorged since it was fetched.
+var errDisconnected = errors.New("chunk does not connect to the last stored block")
+
+// Config configures a Backfiller.
+type Config struct {
+	// ChunkSize is the number of blocks fetched at once.
+	ChunkSize uint64
+	// Parallelism is the number of chunks fetched concurrently.
+	Parallelism int
+}
+
+// Chain fetches and stores the indexed data of the blocks of a chain.
+type Chain interface {
+	// FetchChunk fetches the indexed data of the blocks from start to end
+	// included. It may be called concurrently.
+	FetchChunk(ctx context.Context, start, end uint64) (*db.BackfillChunk, error)
+	// CommitChunk stores the blocks of a chunk fetched by FetchChunk, which
+	// follows the last stored block.
+	CommitChunk(chunk *db.BackfillChunk) error
+	// Rollback deletes the stored blocks after the given number.
+	Rollback(number uint64) error
+}
+
+// Store stages the chunks and records the progress of backfills. It is
+// implemented by *db.Database.
+type Store interface {
+	GetBackfillCheckpoint(chain string) (*db.BackfillCheckpoint, error)
+	SetBackfillCheckpoint(chain string, checkpoint db.BackfillCheckpoint) error
+	DeleteBackfill(chain string) error
+	AddBackfillChunk(chain string, chunk *db.BackfillChunk) error
+	GetBackfillChunk(chain string, start uint64) (*db.BackfillChunk, error)
+	GetBackfillChunkRanges(chain string) (map[uint64]uint64, error)
+	CommitBackfillChunk(chain string, start uint64, checkpoint db.BackfillCheckpoint) error
+	DeleteBackfillChunks(chain string) error
+}
+
+// Backfiller fetches chunks of blocks concurrently and stages them out of
+// order, then stores them in order, recording a checkpoint after each chunk
+// so that an interrupted backfill resumes where it stopped.
+type Backfiller struct {
+	name  string
+	cfg   Config
+	store Store
+	chain Chain
+}
+
+// New returns a Backfiller of the chain with the given name, l1 or l2, which
+// keys its checkpoint and staged chunks in store.
+func New(name string, store Store, chain Chain, cfg Config) (*Backfiller, error) {
+	if cfg.ChunkSize == 0 {
+		return nil, errors.New("ChunkSize must be greater than zero")
+	}
+	if cfg.Parallelism <= 0 {
+		return nil, errors.New("Parallelism must be greater than zero")
+	}
+
+	return &Backfiller{
+		name:  name,
+		cfg:   cfg,
+		store: store,
+		chain: chain,
+	}, nil
+}
+
+type chunkRange struct {
+	start uint64
+	end   uint64
+}
+
+// chunks splits the blocks from start to end included into chunks of at
+// most size blocks.
+func chunks(start, end, size uint64) []chunkRange {
+	var out []chunkRange
+	for ; start <= end; start += size {
+		chunkEnd := start + size - 1
+		if chunkEnd > end {
+			chunkEnd = end
+		}
+		out = append(out, chunkRange{start, chunkEnd})
+	}
+	return out
+}
+
+// Run stores the blocks after last, the last stored block, up to target
+// included. If a backfill was interrupted, it resumes from its checkpoint
+// instead. A zero hash in last skips checking that the first chunk connects
+// to it.
+func (b *Backfiller) Run(ctx context.Context, last db.BlockLocator, target uint64) (err error) {
+	checkpoint, err := b.store.GetBackfillCheckpoint(b.name)
+	if err != nil {
+		return fmt.Errorf("error fetching checkpoint: %w", err)
+	}
+	if checkpoint != nil {
+		// Blocks stored after the checkpoint belong to a chunk whose commit
+		// was interrupted, and are stored again with the chunk.
+		if err := b.chain.Rollback(checkpoint.Committed.Number); err != nil {
+			return fmt.Errorf("error rolling back to checkpoint: %w", err)
+		}
+		last = checkpoint.Committed
+		logger.Info("resuming backfill", "chain", b.name, "committed", last.Number, "target", target)
+	}
+	if last.Number >= target {
+		return b.store.DeleteBackfill(b.name)
+	}
+	if err := b.store.SetBackfillCheckpoint(b.name, db.BackfillCheckpoint{Target: target, Committed: last}); err != nil {
+		return fmt.Errorf("error setting checkpoint: %w", err)
+	}
+
+	staged, err := b.store.GetBackfillChunkRanges(b.name)
+	if err != nil {
+		return fmt.Errorf("error fetching staged chunks: %w", err)
+	}
+
+	ranges := chunks(last.Number+1, target, b.cfg.ChunkSize)
+	logger.Info("starting backfill", "chain", b.name, "start", last.Number+1, "target", target,
+		"chunks", len(ranges), "staged", len(staged))
+
+	ctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	defer func() {
+		cancel()
+		wg.Wait()
+		// The staged chunks are dropped once no more chunks are being
+		// fetched, and fetched again on the next run.
+		if errors.Is(err, errDisconnected) {
+			if deleteErr := b.store.DeleteBackfillChunks(b.name); deleteErr != nil {
+				logger.Error("error deleting staged chunks", "chain", b.name, "err", deleteErr)
+			}
+		}
+	}()
+
+	// window bounds the number of chunks fetched ahead of the next chunk to
+	// commit, and so the number of staged chunks.
+	window := make(chan struct{}, 2*b.cfg.Parallelism)
+	jobs := make(chan chunkRange)
+	fetched := make(chan uint64, cap(window))
+	errCh := make(chan error, b.cfg.Parallelism)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(jobs)
+		for _, r := range ranges {
+			select {
+			case window <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			if end, ok := staged[r.start]; ok && end == r.end {
+				fetched <- r.start
+				continue
+			}
+			select {
+			case jobs <- r:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	for i := 0; i < b.cfg.Parallelism; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for r := range jobs {
+				if err := b.fetch(ctx, r); err != nil {
+					errCh <- err
+					return
+				}
+				fetched <- r.start
+			}
+		}()
+	}
+
+	ready := make(map[uint64]bool)
+	for _, r := range ranges {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		for !ready[r.start] {
+			select {
+			case start := <-fetched:
+				ready[start] = true
+			case err := <-errCh:
+				return err
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		delete(ready, r.start)
+
+		if last, err = b.commit(r, last, target); err != nil {
+			return err
+		}
+		<-window
+	}
+
+	logger.Info("finished backfill", "chain", b.name, "target", target)
+	return b.store.DeleteBackfill(b.name)
+}
+
+// fetch fetches and stages the chunk of blocks r.
+func (b *Backfiller) fetch(ctx context.Context, r chunkRange) error {
+	chunk, err := b.chain.FetchChunk(ctx, r.start, r.end)
+	if err != nil {
+		return fmt.Errorf("error fetching chunk %d-%d: %w", r.start, r.end, err)
+	}
+	if err := b.store.AddBackfillChunk(b.name, chunk); err != nil {
+		return fmt.Errorf("error staging chunk %d-%d: %w", r.start, r.end, err)
+	}
+	logger.Debug("staged chunk", "chain", b.name, "start", r.start, "end", r.end)
+	return nil
+}
+
+// commit stores the staged chunk of blocks r, which follows last, and returns
+// its last block.
+func (b *Backfiller) commit(r chunkRange, last db.BlockLocator, target uint64) (db.BlockLocator, error) {
+	chunk, err := b.store.GetBackfillChunk(b.name, r.start)
+	if err != nil {
+		return last, fmt.Errorf("error fetching staged chunk %d-%d: %w", r.start, r.end, err)
+	}
+	if chunk == nil {
+		return last, fmt.Errorf("chunk %d-%d is not staged", r.start, r.end)
+	}
+
+	if last.Hash != (common.Hash{}) && chunk.ParentHash != last.Hash {
+		return last, fmt.Errorf("%w: chunk %d-%d, block %d %s",
+			errDisconnected, r.start, r.end, last.Number, last.Hash)
+	}
+
+	if err := b.chain.CommitChunk(chunk); err != nil {
+		return last, fmt.Errorf("error storing chunk %d-%d: %w", r.start, r.end, err)
+	}
+
+	last = db.BlockLocator{Number: chunk.End, Hash: chunk.Hash}
+	if err := b.store.CommitBackfillChunk(b.name, r.start, db.BackfillCheckpoint{Target: target, Committed: last}); err != nil {
+		return last, fmt.Errorf("error committing chunk %d-%d: %w", r.start, r.end, err)
+	}
+
+	logger.Info("committed chunk", "chain", b.name, "start", r.start, "end", r.end, "target", target)
+	return last, nil
+}
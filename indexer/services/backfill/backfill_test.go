@@ -0,0 +1,227 @@
+package backfill
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+
+	"github.com/ethereum-optimism/optimism/indexer/db"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+type memStore struct {
+	mu         sync.Mutex
+	checkpoint *db.BackfillCheckpoint
+	chunks     map[uint64]*db.BackfillChunk
+}
+
+func newMemStore() *memStore {
+	return &memStore{chunks: make(map[uint64]*db.BackfillChunk)}
+}
+
+func (m *memStore) GetBackfillCheckpoint(string) (*db.BackfillCheckpoint, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.checkpoint, nil
+}
+
+func (m *memStore) SetBackfillCheckpoint(_ string, checkpoint db.BackfillCheckpoint) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.checkpoint = &checkpoint
+	return nil
+}
+
+func (m *memStore) DeleteBackfill(string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.checkpoint = nil
+	m.chunks = make(map[uint64]*db.BackfillChunk)
+	return nil
+}
+
+func (m *memStore) AddBackfillChunk(_ string, chunk *db.BackfillChunk) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.chunks[chunk.Start] = chunk
+	return nil
+}
+
+func (m *memStore) GetBackfillChunk(_ string, start uint64) (*db.BackfillChunk, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.chunks[start], nil
+}
+
+func (m *memStore) GetBackfillChunkRanges(string) (map[uint64]uint64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ranges := make(map[uint64]uint64)
+	for start, chunk := range m.chunks {
+		ranges[start] = chunk.End
+	}
+	return ranges, nil
+}
+
+func (m *memStore) CommitBackfillChunk(_ string, start uint64, checkpoint db.BackfillCheckpoint) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.checkpoint = &checkpoint
+	delete(m.chunks, start)
+	return nil
+}
+
+func (m *memStore) DeleteBackfillChunks(string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.chunks = make(map[uint64]*db.BackfillChunk)
+	return nil
+}
+
+func blockHash(number uint64) common.Hash {
+	return common.BigToHash(new(big.Int).SetUint64(number + 1))
+}
+
+// fakeChain stores block numbers, and fails to fetch the chunk starting at
+// failAt.
+type fakeChain struct {
+	mu      sync.Mutex
+	stored  []uint64
+	fetches map[uint64]int
+	failAt  uint64
+}
+
+func newFakeChain() *fakeChain {
+	return &fakeChain{fetches: make(map[uint64]int)}
+}
+
+func (c *fakeChain) FetchChunk(_ context.Context, start, end uint64) (*db.BackfillChunk, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.fetches[start]++
+	if c.failAt != 0 && start == c.failAt {
+		return nil, errors.New("fetch failed")
+	}
+	return &db.BackfillChunk{
+		Start:      start,
+		End:        end,
+		ParentHash: blockHash(start - 1),
+		Hash:       blockHash(end),
+	}, nil
+}
+
+func (c *fakeChain) CommitChunk(chunk *db.BackfillChunk) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for n := chunk.Start; n <= chunk.End; n++ {
+		c.stored = append(c.stored, n)
+	}
+	return nil
+}
+
+func (c *fakeChain) Rollback(number uint64) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, n := range c.stored {
+		if n > number {
+			c.stored = c.stored[:i]
+			break
+		}
+	}
+	return nil
+}
+
+func blockRange(start, end uint64) []uint64 {
+	var out []uint64
+	for n := start; n <= end; n++ {
+		out = append(out, n)
+	}
+	return out
+}
+
+func TestChunks(t *testing.T) {
+	require.Equal(t, []chunkRange{{1, 10}, {11, 20}, {21, 25}}, chunks(1, 25, 10))
+	require.Equal(t, []chunkRange{{5, 5}}, chunks(5, 5, 10))
+	require.Nil(t, chunks(6, 5, 10))
+}
+
+func TestNew(t *testing.T) {
+	_, err := New("l1", newMemStore(), newFakeChain(), Config{Parallelism: 1})
+	require.Error(t, err)
+	_, err = New("l1", newMemStore(), newFakeChain(), Config{ChunkSize: 10})
+	require.Error(t, err)
+}
+
+func TestRun(t *testing.T) {
+	store := newMemStore()
+	chain := newFakeChain()
+	b, err := New("l1", store, chain, Config{ChunkSize: 10, Parallelism: 3})
+	require.NoError(t, err)
+
+	last := db.BlockLocator{Number: 4, Hash: blockHash(4)}
+	require.NoError(t, b.Run(context.Background(), last, 99))
+	require.Equal(t, blockRange(5, 99), chain.stored)
+	require.Nil(t, store.checkpoint)
+	require.Empty(t, store.chunks)
+}
+
+func TestRunResume(t *testing.T) {
+	store := newMemStore()
+	chain := newFakeChain()
+	chain.failAt = 31
+	b, err := New("l1", store, chain, Config{ChunkSize: 10, Parallelism: 2})
+	require.NoError(t, err)
+
+	last := db.BlockLocator{Number: 0, Hash: blockHash(0)}
+	require.Error(t, b.Run(context.Background(), last, 100))
+	committed := store.checkpoint.Committed.Number
+	require.LessOrEqual(t, committed, uint64(30))
+	require.Equal(t, blockRange(1, committed), chain.stored)
+
+	// Simulate blocks stored by a chunk whose commit was interrupted.
+	chain.stored = append(chain.stored, committed+1, committed+2)
+	staged := len(store.chunks)
+	chain.failAt = 0
+
+	// The target of the resumed run may be further ahead.
+	require.NoError(t, b.Run(context.Background(), db.BlockLocator{Number: committed + 2}, 105))
+	require.Equal(t, blockRange(1, 105), chain.stored)
+	require.Nil(t, store.checkpoint)
+
+	var refetched int
+	for start, n := range chain.fetches {
+		if start > 31 && n > 1 {
+			refetched++
+		}
+	}
+	require.Zero(t, refetched, "%d staged chunks were fetched again", staged)
+}
+
+func TestRunReorg(t *testing.T) {
+	store := newMemStore()
+	chain := newFakeChain()
+	b, err := New("l1", store, chain, Config{ChunkSize: 10, Parallelism: 2})
+	require.NoError(t, err)
+
+	store.chunks[11] = &db.BackfillChunk{Start: 11, End: 20, ParentHash: common.HexToHash("0x01"), Hash: blockHash(20)}
+	last := db.BlockLocator{Number: 10, Hash: blockHash(10)}
+	require.Error(t, b.Run(context.Background(), last, 30))
+	require.Empty(t, store.chunks)
+	require.Equal(t, uint64(10), store.checkpoint.Committed.Number)
+	require.Empty(t, chain.stored)
+
+	require.NoError(t, b.Run(context.Background(), last, 30))
+	require.Equal(t, blockRange(11, 30), chain.stored)
+}
+
+func TestRunCanceled(t *testing.T) {
+	b, err := New("l1", newMemStore(), newFakeChain(), Config{ChunkSize: 10, Parallelism: 2})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.ErrorIs(t, b.Run(ctx, db.BlockLocator{}, 100), context.Canceled)
+}
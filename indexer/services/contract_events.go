@@ -0,0 +1,122 @@
+package services
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum-optimism/optimism/indexer/db"
+	"github.com/ethereum-optimism/optimism/indexer/server"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+var contractEventsLogger = log.New("service", "contract-events")
+
+// argsQueryPrefix prefixes the query parameters filtering events by their
+// decoded parameters, e.g. args.from=0x...
+const argsQueryPrefix = "args."
+
+// ContractEvents serves the events of the contracts configured for indexing.
+type ContractEvents struct {
+	db *db.Database
+}
+
+func NewContractEvents(db *db.Database) *ContractEvents {
+	return &ContractEvents{
+		db: db,
+	}
+}
+
+func (c *ContractEvents) GetContractEvents(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	limitStr := query.Get("limit")
+	limit, err := strconv.ParseUint(limitStr, 10, 64)
+	if err != nil && limitStr != "" {
+		server.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if limit == 0 {
+		limit = 10
+	}
+
+	offsetStr := query.Get("offset")
+	offset, err := strconv.ParseUint(offsetStr, 10, 64)
+	if err != nil && offsetStr != "" {
+		server.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	filter, err := parseContractEventFilter(query)
+	if err != nil {
+		server.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	page := db.PaginationParam{
+		Limit:  limit,
+		Offset: offset,
+	}
+
+	events, err := c.db.GetContractEvents(filter, page)
+	if err != nil {
+		contractEventsLogger.Error("db error getting contract events", "err", err)
+		server.RespondWithError(w, http.StatusInternalServerError, "database error")
+		return
+	}
+
+	server.RespondWithJSON(w, http.StatusOK, events)
+}
+
+// parseContractEventFilter parses the chain, contract, event and args.<name>
+// query parameters. Argument values are normalized to the format of the
+// stored arguments.
+func parseContractEventFilter(query url.Values) (db.ContractEventFilter, error) {
+	filter := db.ContractEventFilter{
+		Chain: query.Get("chain"),
+		Event: query.Get("event"),
+	}
+
+	if filter.Chain != "" && filter.Chain != db.ChainL1 && filter.Chain != db.ChainL2 {
+		return filter, errors.New("chain must be l1 or l2")
+	}
+
+	if contract := query.Get("contract"); contract != "" {
+		if !common.IsHexAddress(contract) {
+			return filter, errors.New("invalid contract address")
+		}
+		address := common.HexToAddress(contract)
+		filter.Contract = &address
+	}
+
+	for key, values := range query {
+		if !strings.HasPrefix(key, argsQueryPrefix) || len(values) == 0 {
+			continue
+		}
+		name := strings.TrimPrefix(key, argsQueryPrefix)
+		if name == "" {
+			return filter, errors.New("argument name must not be empty")
+		}
+		if filter.Args == nil {
+			filter.Args = make(map[string]string)
+		}
+		filter.Args[name] = normalizeArg(values[0])
+	}
+
+	return filter, nil
+}
+
+// normalizeArg formats addresses as checksummed hex strings and other hex
+// values as lower case hex strings, as they are stored.
+func normalizeArg(value string) string {
+	if !strings.HasPrefix(value, "0x") && !strings.HasPrefix(value, "0X") {
+		return value
+	}
+	if common.IsHexAddress(value) {
+		return common.HexToAddress(value).Hex()
+	}
+	return "0x" + strings.ToLower(value[2:])
+}
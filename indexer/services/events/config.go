@@ -0,0 +1,85 @@
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/ethereum-optimism/optimism/indexer/db"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Config lists the contracts whose events are indexed.
+type Config struct {
+	Contracts []ContractConfig `json:"contracts"`
+}
+
+// ContractConfig is a contract whose events are indexed.
+type ContractConfig struct {
+	// Name identifies the contract in the API.
+	Name string `json:"name"`
+	// Chain is the chain of the contract, either l1 or l2.
+	Chain   string         `json:"chain"`
+	Address common.Address `json:"address"`
+	// Events are the signatures of the indexed events, e.g.
+	// "Transfer(address indexed from, address indexed to, uint256 value)".
+	Events []string `json:"events"`
+}
+
+// LoadConfig reads the JSON config at path.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("error parsing event config: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// Validate checks that the contracts are well-formed and that their event
+// signatures parse.
+func (c *Config) Validate() error {
+	names := make(map[string]bool)
+	for _, contract := range c.Contracts {
+		if contract.Name == "" {
+			return errors.New("contracts must have a name")
+		}
+		if names[contract.Name] {
+			return fmt.Errorf("duplicate contract name %s", contract.Name)
+		}
+		names[contract.Name] = true
+		if contract.Chain != db.ChainL1 && contract.Chain != db.ChainL2 {
+			return fmt.Errorf("contract %s: chain must be %s or %s", contract.Name, db.ChainL1, db.ChainL2)
+		}
+		if contract.Address == (common.Address{}) {
+			return fmt.Errorf("contract %s: must have an address", contract.Name)
+		}
+		if len(contract.Events) == 0 {
+			return fmt.Errorf("contract %s: must have events", contract.Name)
+		}
+		for _, sig := range contract.Events {
+			if _, err := ParseEventSignature(sig); err != nil {
+				return fmt.Errorf("contract %s: %w", contract.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// ContractsOn returns the contracts on chain.
+func (c *Config) ContractsOn(chain string) []ContractConfig {
+	var contracts []ContractConfig
+	for _, contract := range c.Contracts {
+		if contract.Chain == chain {
+			contracts = append(contracts, contract)
+		}
+	}
+	return contracts
+}
@@ -0,0 +1,263 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum-optimism/optimism/indexer/db"
+	"github.com/ethereum-optimism/optimism/op-service/backoff"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+var logger = log.New("service", "events")
+
+// ContractEventsMap is a collection of contract events keyed on block hashes.
+type ContractEventsMap map[common.Hash][]db.ContractEvent
+
+// ParseEventSignature parses an event signature such as
+// "Transfer(address indexed from, address indexed to, uint256 value)". The
+// event keyword and the parameter names are optional, tuples aren't
+// supported.
+func ParseEventSignature(sig string) (abi.Event, error) {
+	sig = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(sig), "event "))
+	open := strings.Index(sig, "(")
+	if open <= 0 || !strings.HasSuffix(sig, ")") {
+		return abi.Event{}, fmt.Errorf("invalid event signature %q", sig)
+	}
+	name := strings.TrimSpace(sig[:open])
+	params := sig[open+1 : len(sig)-1]
+	if strings.ContainsAny(name, " \t") || strings.ContainsAny(params, "()") {
+		return abi.Event{}, fmt.Errorf("invalid event signature %q", sig)
+	}
+
+	var inputs abi.Arguments
+	var indexed int
+	names := make(map[string]bool)
+	if strings.TrimSpace(params) != "" {
+		for i, param := range strings.Split(params, ",") {
+			fields := strings.Fields(param)
+			if len(fields) == 0 || len(fields) > 3 {
+				return abi.Event{}, fmt.Errorf("invalid parameter %q in event signature %q", param, sig)
+			}
+			typ, err := abi.NewType(fields[0], "", nil)
+			if err != nil {
+				return abi.Event{}, fmt.Errorf("invalid parameter %q in event signature %q: %w", param, sig, err)
+			}
+			arg := abi.Argument{Type: typ}
+			fields = fields[1:]
+			if len(fields) > 0 && fields[0] == "indexed" {
+				arg.Indexed = true
+				indexed++
+				fields = fields[1:]
+			}
+			switch len(fields) {
+			case 0:
+				arg.Name = fmt.Sprintf("arg%d", i)
+			case 1:
+				arg.Name = fields[0]
+			default:
+				return abi.Event{}, fmt.Errorf("invalid parameter %q in event signature %q", param, sig)
+			}
+			if names[arg.Name] {
+				return abi.Event{}, fmt.Errorf("duplicate parameter %s in event signature %q", arg.Name, sig)
+			}
+			names[arg.Name] = true
+			inputs = append(inputs, arg)
+		}
+	}
+	if indexed > 3 {
+		return abi.Event{}, fmt.Errorf("event signature %q has more than 3 indexed parameters", sig)
+	}
+
+	return abi.NewEvent(name, name, false, inputs), nil
+}
+
+type contract struct {
+	name    string
+	address common.Address
+	events  map[common.Hash]abi.Event
+}
+
+// Indexer fetches and decodes the events of the contracts of a chain.
+type Indexer struct {
+	client    ethereum.LogFilterer
+	contracts map[common.Address]*contract
+	addresses []common.Address
+	topics    []common.Hash
+}
+
+// NewIndexer returns an indexer of the events of contracts, which must be on
+// the chain of client.
+func NewIndexer(client ethereum.LogFilterer, contracts []ContractConfig) (*Indexer, error) {
+	i := &Indexer{
+		client:    client,
+		contracts: make(map[common.Address]*contract),
+	}
+	topics := make(map[common.Hash]bool)
+	for _, cfg := range contracts {
+		if _, ok := i.contracts[cfg.Address]; ok {
+			return nil, fmt.Errorf("contract %s is configured twice", cfg.Address)
+		}
+		c := &contract{
+			name:    cfg.Name,
+			address: cfg.Address,
+			events:  make(map[common.Hash]abi.Event),
+		}
+		for _, sig := range cfg.Events {
+			event, err := ParseEventSignature(sig)
+			if err != nil {
+				return nil, err
+			}
+			c.events[event.ID] = event
+			if !topics[event.ID] {
+				topics[event.ID] = true
+				i.topics = append(i.topics, event.ID)
+			}
+		}
+		i.contracts[cfg.Address] = c
+		i.addresses = append(i.addresses, cfg.Address)
+	}
+	return i, nil
+}
+
+// GetEventsByBlockRange returns the decoded events of the contracts between
+// blocks start and end included.
+func (i *Indexer) GetEventsByBlockRange(ctx context.Context, start, end uint64) (ContractEventsMap, error) {
+	query := ethereum.FilterQuery{
+		FromBlock: new(big.Int).SetUint64(start),
+		ToBlock:   new(big.Int).SetUint64(end),
+		Addresses: i.addresses,
+		Topics:    [][]common.Hash{i.topics},
+	}
+
+	var logs []types.Log
+	err := backoff.Do(3, backoff.Exponential(), func() error {
+		var err error
+		logs, err = i.client.FilterLogs(ctx, query)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	eventsByBlockHash := make(ContractEventsMap)
+	for _, l := range logs {
+		event, ok, err := i.decode(l)
+		if err != nil {
+			// Logs that don't match the configured signature, e.g. because
+			// of their indexed parameters, are skipped.
+			logger.Warn("unable to decode event", "address", l.Address, "tx_hash", l.TxHash, "log_index", l.Index, "err", err)
+			continue
+		}
+		if ok {
+			eventsByBlockHash[l.BlockHash] = append(eventsByBlockHash[l.BlockHash], event)
+		}
+	}
+	return eventsByBlockHash, nil
+}
+
+// decode decodes l, and returns false if it isn't one of the configured
+// events.
+func (i *Indexer) decode(l types.Log) (db.ContractEvent, bool, error) {
+	c := i.contracts[l.Address]
+	if c == nil || len(l.Topics) == 0 || l.Removed {
+		return db.ContractEvent{}, false, nil
+	}
+	event, ok := c.events[l.Topics[0]]
+	if !ok {
+		return db.ContractEvent{}, false, nil
+	}
+
+	args, err := decodeArgs(event, l)
+	if err != nil {
+		return db.ContractEvent{}, false, err
+	}
+	return db.ContractEvent{
+		ContractName:    c.name,
+		ContractAddress: c.address,
+		EventName:       event.Name,
+		EventSignature:  event.Sig,
+		Args:            args,
+		TxHash:          l.TxHash,
+		LogIndex:        l.Index,
+	}, true, nil
+}
+
+// decodeArgs decodes the parameters of the event in l into JSON values.
+func decodeArgs(event abi.Event, l types.Log) (map[string]interface{}, error) {
+	var indexed abi.Arguments
+	for _, arg := range event.Inputs {
+		if arg.Indexed {
+			indexed = append(indexed, arg)
+		}
+	}
+	if len(l.Topics)-1 != len(indexed) {
+		return nil, fmt.Errorf("expected %d indexed parameters, got %d", len(indexed), len(l.Topics)-1)
+	}
+
+	values := make(map[string]interface{})
+	if err := event.Inputs.NonIndexed().UnpackIntoMap(values, l.Data); err != nil {
+		return nil, err
+	}
+	if err := abi.ParseTopicsIntoMap(values, indexed, l.Topics[1:]); err != nil {
+		return nil, err
+	}
+
+	args := make(map[string]interface{}, len(values))
+	for name, value := range values {
+		args[name] = jsonValue(reflect.ValueOf(value))
+	}
+	return args, nil
+}
+
+// jsonValue converts a decoded ABI value to a JSON value. Scalars are
+// converted to strings so that they can be matched by the API: integers to
+// decimal strings, addresses to checksummed hex strings and bytes to hex
+// strings. Arrays are converted to lists.
+func jsonValue(v reflect.Value) interface{} {
+	switch value := v.Interface().(type) {
+	case *big.Int:
+		return value.String()
+	case common.Address:
+		return value.Hex()
+	case common.Hash:
+		return value.Hex()
+	case []byte:
+		return hexutil.Encode(value)
+	case bool:
+		return strconv.FormatBool(value)
+	case string:
+		return value
+	}
+
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return big.NewInt(v.Int()).String()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return new(big.Int).SetUint64(v.Uint()).String()
+	case reflect.Array:
+		// Fixed size bytes.
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			b := make([]byte, v.Len())
+			reflect.Copy(reflect.ValueOf(b), v)
+			return hexutil.Encode(b)
+		}
+		fallthrough
+	case reflect.Slice:
+		values := make([]interface{}, v.Len())
+		for i := range values {
+			values[i] = jsonValue(v.Index(i))
+		}
+		return values
+	}
+	return fmt.Sprint(v.Interface())
+}
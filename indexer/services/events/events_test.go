@@ -0,0 +1,150 @@
+package events
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseEventSignature(t *testing.T) {
+	event, err := ParseEventSignature("event Transfer(address indexed from, address indexed to, uint256)")
+	require.NoError(t, err)
+	require.Equal(t, "Transfer", event.Name)
+	require.Equal(t, "Transfer(address,address,uint256)", event.Sig)
+	require.Equal(t, common.HexToHash("0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"), event.ID)
+	require.Len(t, event.Inputs, 3)
+	require.True(t, event.Inputs[0].Indexed)
+	require.Equal(t, "from", event.Inputs[0].Name)
+	require.False(t, event.Inputs[2].Indexed)
+	require.Equal(t, "arg2", event.Inputs[2].Name)
+
+	event, err = ParseEventSignature("Ping()")
+	require.NoError(t, err)
+	require.Equal(t, "Ping()", event.Sig)
+
+	for _, sig := range []string{
+		"",
+		"Transfer",
+		"(address)",
+		"Transfer(address",
+		"Transfer(foo)",
+		"Transfer(address a, address a)",
+		"Transfer(address indexed a b)",
+		"Transfer((address,uint256) t)",
+		"Transfer(uint8 indexed, uint8 indexed, uint8 indexed, uint8 indexed)",
+	} {
+		_, err := ParseEventSignature(sig)
+		require.Error(t, err, sig)
+	}
+}
+
+type logFilterer struct {
+	logs []types.Log
+}
+
+func (f *logFilterer) FilterLogs(context.Context, ethereum.FilterQuery) ([]types.Log, error) {
+	return f.logs, nil
+}
+
+func (f *logFilterer) SubscribeFilterLogs(context.Context, ethereum.FilterQuery, chan<- types.Log) (ethereum.Subscription, error) {
+	panic("not implemented")
+}
+
+func TestGetEventsByBlockRange(t *testing.T) {
+	token := common.HexToAddress("0x4200000000000000000000000000000000000042")
+	from := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	to := common.HexToAddress("0x00000000000000000000000000000000000000bb")
+	blockHash := common.Hash{0x01}
+	transfer := common.HexToHash("0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef")
+
+	filterer := &logFilterer{
+		logs: []types.Log{
+			{
+				Address:   token,
+				Topics:    []common.Hash{transfer, common.BytesToHash(from.Bytes()), common.BytesToHash(to.Bytes())},
+				Data:      common.BigToHash(big.NewInt(1000)).Bytes(),
+				BlockHash: blockHash,
+				TxHash:    common.Hash{0x02},
+				Index:     3,
+			},
+			// An ERC721 transfer, whose token ID is indexed, is skipped.
+			{
+				Address:   token,
+				Topics:    []common.Hash{transfer, common.BytesToHash(from.Bytes()), common.BytesToHash(to.Bytes()), {0x01}},
+				BlockHash: blockHash,
+				Index:     4,
+			},
+			// Events of other contracts are skipped.
+			{
+				Address:   common.Address{0x01},
+				Topics:    []common.Hash{transfer, common.BytesToHash(from.Bytes()), common.BytesToHash(to.Bytes())},
+				Data:      common.BigToHash(big.NewInt(1000)).Bytes(),
+				BlockHash: blockHash,
+				Index:     5,
+			},
+		},
+	}
+
+	indexer, err := NewIndexer(filterer, []ContractConfig{{
+		Name:    "OP",
+		Chain:   "l2",
+		Address: token,
+		Events:  []string{"Transfer(address indexed from, address indexed to, uint256 value)"},
+	}})
+	require.NoError(t, err)
+
+	events, err := indexer.GetEventsByBlockRange(context.Background(), 1, 10)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.Len(t, events[blockHash], 1)
+
+	event := events[blockHash][0]
+	require.Equal(t, "OP", event.ContractName)
+	require.Equal(t, token, event.ContractAddress)
+	require.Equal(t, "Transfer", event.EventName)
+	require.Equal(t, "Transfer(address,address,uint256)", event.EventSignature)
+	require.Equal(t, common.Hash{0x02}, event.TxHash)
+	require.Equal(t, uint(3), event.LogIndex)
+	require.Equal(t, map[string]interface{}{
+		"from":  from.Hex(),
+		"to":    to.Hex(),
+		"value": "1000",
+	}, event.Args)
+}
+
+func TestConfigValidate(t *testing.T) {
+	valid := ContractConfig{
+		Name:    "OP",
+		Chain:   "l2",
+		Address: common.Address{0x01},
+		Events:  []string{"Transfer(address indexed from, address indexed to, uint256 value)"},
+	}
+	require.NoError(t, (&Config{Contracts: []ContractConfig{valid}}).Validate())
+
+	tests := []struct {
+		name   string
+		modify func(*ContractConfig)
+	}{
+		{"no name", func(c *ContractConfig) { c.Name = "" }},
+		{"invalid chain", func(c *ContractConfig) { c.Chain = "l3" }},
+		{"no address", func(c *ContractConfig) { c.Address = common.Address{} }},
+		{"no events", func(c *ContractConfig) { c.Events = nil }},
+		{"invalid event", func(c *ContractConfig) { c.Events = []string{"Transfer("} }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			contract := valid
+			tt.modify(&contract)
+			require.Error(t, (&Config{Contracts: []ContractConfig{contract}}).Validate())
+		})
+	}
+
+	duplicate := valid
+	duplicate.Address = common.Address{0x02}
+	require.Error(t, (&Config{Contracts: []ContractConfig{valid, duplicate}}).Validate())
+}
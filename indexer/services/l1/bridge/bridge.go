@@ -29,6 +29,14 @@ type ProvenWithdrawalsMap map[common.Hash][]db.ProvenWithdrawal
 // objects keyed on block hashes.
 type FinalizedWithdrawalsMap map[common.Hash][]db.FinalizedWithdrawal
 
+// L2OutputsMap is a collection of proposed L2 outputs keyed
+// on block hashes.
+type L2OutputsMap map[common.Hash][]db.L2Output
+
+// DeletedL2OutputsMap is a collection of L2 output deletions keyed
+// on block hashes.
+type DeletedL2OutputsMap map[common.Hash][]db.DeletedL2Outputs
+
 type Bridge interface {
 	Address() common.Address
 	GetDepositsByBlockRange(context.Context, uint64, uint64) (DepositsMap, error)
@@ -0,0 +1,192 @@
+package bridge
+
+import (
+	"context"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum-optimism/optimism/indexer/db"
+	"github.com/ethereum-optimism/optimism/indexer/services"
+	"github.com/ethereum-optimism/optimism/indexer/services/util"
+	"github.com/ethereum-optimism/optimism/op-bindings/bindings"
+	"github.com/ethereum-optimism/optimism/op-service/backoff"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// SentMessagesMap is a collection of sent messages keyed on block hashes.
+type SentMessagesMap map[common.Hash][]db.SentMessage
+
+// RelayedMessagesMap is a collection of relayed messages keyed on block
+// hashes.
+type RelayedMessagesMap map[common.Hash][]db.RelayedMessage
+
+// Messenger scans the L1CrossDomainMessenger for the messages sent to L2,
+// including the messages relaying the deposits, and for the relays of the
+// messages sent from L2.
+type Messenger struct {
+	address  common.Address
+	contract *bindings.L1CrossDomainMessenger
+}
+
+func NewMessenger(addrs services.AddressManager) *Messenger {
+	address, contract := addrs.L1CrossDomainMessenger()
+
+	return &Messenger{
+		address:  address,
+		contract: contract,
+	}
+}
+
+func (m *Messenger) Address() common.Address {
+	return m.address
+}
+
+func (m *Messenger) GetSentMessagesByBlockRange(ctx context.Context, start, end uint64) (SentMessagesMap, error) {
+	opts := &bind.FilterOpts{
+		Context: ctx,
+		Start:   start,
+		End:     &end,
+	}
+
+	// The value of a message is emitted by the SentMessageExtension1 event
+	// following its SentMessage event.
+	type logLocator struct {
+		txHash   common.Hash
+		logIndex uint
+	}
+	var extIter *bindings.L1CrossDomainMessengerSentMessageExtension1Iterator
+	err := backoff.Do(3, backoff.Exponential(), func() error {
+		var err error
+		extIter, err = m.contract.FilterSentMessageExtension1(opts, nil)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer extIter.Close()
+	values := make(map[logLocator]*big.Int)
+	for extIter.Next() {
+		values[logLocator{extIter.Event.Raw.TxHash, extIter.Event.Raw.Index}] = extIter.Event.Value
+	}
+	if err := extIter.Error(); err != nil {
+		return nil, err
+	}
+
+	var iter *bindings.L1CrossDomainMessengerSentMessageIterator
+	err = backoff.Do(3, backoff.Exponential(), func() error {
+		var err error
+		iter, err = m.contract.FilterSentMessage(opts, nil)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+
+	msgsByBlockHash := make(SentMessagesMap)
+	for iter.Next() {
+		ev := iter.Event
+		value := values[logLocator{ev.Raw.TxHash, ev.Raw.Index + 1}]
+		if value == nil && util.IsLegacyMessage(ev.MessageNonce) {
+			value = new(big.Int)
+		} else if value == nil {
+			logger.Warn("missing SentMessageExtension1 event, ignoring message", "tx_hash", ev.Raw.TxHash, "log_index", ev.Raw.Index)
+			continue
+		}
+
+		msg, err := util.NewSentMessage(ev.MessageNonce, ev.Sender, ev.Target, value, ev.GasLimit, ev.Message, ev.Raw)
+		if err != nil {
+			return nil, err
+		}
+
+		msgsByBlockHash[ev.Raw.BlockHash] = append(msgsByBlockHash[ev.Raw.BlockHash], msg)
+	}
+
+	return msgsByBlockHash, iter.Error()
+}
+
+// GetRelayedMessagesByBlockRange returns the successful and the failed relays
+// of the messages sent from L2.
+func (m *Messenger) GetRelayedMessagesByBlockRange(ctx context.Context, start, end uint64) (RelayedMessagesMap, error) {
+	msgsByBlockHash := make(RelayedMessagesMap)
+	opts := &bind.FilterOpts{
+		Context: ctx,
+		Start:   start,
+		End:     &end,
+	}
+
+	var iter *bindings.L1CrossDomainMessengerRelayedMessageIterator
+	err := backoff.Do(3, backoff.Exponential(), func() error {
+		var err error
+		iter, err = m.contract.FilterRelayedMessage(opts, nil)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+	for iter.Next() {
+		msgsByBlockHash[iter.Event.Raw.BlockHash] = append(
+			msgsByBlockHash[iter.Event.Raw.BlockHash], db.RelayedMessage{
+				MessageHash: iter.Event.MsgHash,
+				TxHash:      iter.Event.Raw.TxHash,
+				LogIndex:    iter.Event.Raw.Index,
+			},
+		)
+	}
+	if err := iter.Error(); err != nil {
+		return nil, err
+	}
+
+	var failedIter *bindings.L1CrossDomainMessengerFailedRelayedMessageIterator
+	err = backoff.Do(3, backoff.Exponential(), func() error {
+		var err error
+		failedIter, err = m.contract.FilterFailedRelayedMessage(opts, nil)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer failedIter.Close()
+	for failedIter.Next() {
+		msgsByBlockHash[failedIter.Event.Raw.BlockHash] = append(
+			msgsByBlockHash[failedIter.Event.Raw.BlockHash], db.RelayedMessage{
+				MessageHash: failedIter.Event.MsgHash,
+				TxHash:      failedIter.Event.Raw.TxHash,
+				LogIndex:    failedIter.Event.Raw.Index,
+				Failed:      true,
+			},
+		)
+	}
+
+	return msgsByBlockHash, failedIter.Error()
+}
+
+// SetDepositMessageHashes sets the message hash of the deposits of a block to
+// the hash of the first message sent after them in their transaction, which
+// is the message relaying them on L2.
+func SetDepositMessageHashes(deposits []db.Deposit, msgs []db.SentMessage) {
+	// The deposits of a block are collected from several bridges, so they
+	// are matched in log order.
+	order := make([]int, len(deposits))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool {
+		return deposits[order[i]].LogIndex < deposits[order[j]].LogIndex
+	})
+
+	used := make(map[int]bool)
+	for _, i := range order {
+		for j, msg := range msgs {
+			if used[j] || msg.TxHash != deposits[i].TxHash || msg.LogIndex <= deposits[i].LogIndex {
+				continue
+			}
+			used[j] = true
+			hash := msg.MessageHash
+			deposits[i].MessageHash = &hash
+			break
+		}
+	}
+}
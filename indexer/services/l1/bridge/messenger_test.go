@@ -0,0 +1,34 @@
+package bridge
+
+import (
+	"testing"
+
+	"github.com/ethereum-optimism/optimism/indexer/db"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetDepositMessageHashes(t *testing.T) {
+	tx1 := common.HexToHash("0x01")
+	tx2 := common.HexToHash("0x02")
+	// The deposits are out of log order, as when collected from several
+	// bridges.
+	deposits := []db.Deposit{
+		{TxHash: tx1, LogIndex: 4},
+		{TxHash: tx1, LogIndex: 0},
+		{TxHash: tx2, LogIndex: 10},
+		{TxHash: tx2, LogIndex: 20},
+	}
+	msgs := []db.SentMessage{
+		{TxHash: tx1, LogIndex: 2, MessageHash: common.HexToHash("0xa1")},
+		{TxHash: tx1, LogIndex: 6, MessageHash: common.HexToHash("0xa2")},
+		{TxHash: tx2, LogIndex: 12, MessageHash: common.HexToHash("0xb1")},
+	}
+
+	SetDepositMessageHashes(deposits, msgs)
+	require.Equal(t, common.HexToHash("0xa2"), *deposits[0].MessageHash)
+	require.Equal(t, common.HexToHash("0xa1"), *deposits[1].MessageHash)
+	require.Equal(t, common.HexToHash("0xb1"), *deposits[2].MessageHash)
+	// No message follows the last deposit of tx2.
+	require.Nil(t, deposits[3].MessageHash)
+}
@@ -0,0 +1,97 @@
+package bridge
+
+import (
+	"context"
+
+	"github.com/ethereum-optimism/optimism/indexer/db"
+	"github.com/ethereum-optimism/optimism/indexer/services"
+	"github.com/ethereum-optimism/optimism/op-bindings/bindings"
+	"github.com/ethereum-optimism/optimism/op-service/backoff"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+type OutputOracle struct {
+	address  common.Address
+	contract *bindings.L2OutputOracle
+}
+
+func NewOutputOracle(addrs services.AddressManager) *OutputOracle {
+	address, contract := addrs.L2OutputOracle()
+
+	return &OutputOracle{
+		address:  address,
+		contract: contract,
+	}
+}
+
+func (o *OutputOracle) Address() common.Address {
+	return o.address
+}
+
+func (o *OutputOracle) GetOutputsByBlockRange(ctx context.Context, start, end uint64) (L2OutputsMap, error) {
+	outputsByBlockHash := make(L2OutputsMap)
+	opts := &bind.FilterOpts{
+		Context: ctx,
+		Start:   start,
+		End:     &end,
+	}
+
+	var iter *bindings.L2OutputOracleOutputProposedIterator
+	err := backoff.Do(3, backoff.Exponential(), func() error {
+		var err error
+		iter, err = o.contract.FilterOutputProposed(opts, nil, nil, nil)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	defer iter.Close()
+	for iter.Next() {
+		outputsByBlockHash[iter.Event.Raw.BlockHash] = append(
+			outputsByBlockHash[iter.Event.Raw.BlockHash], db.L2Output{
+				Index:         iter.Event.L2OutputIndex.Uint64(),
+				OutputRoot:    iter.Event.OutputRoot,
+				L2BlockNumber: iter.Event.L2BlockNumber.Uint64(),
+				L1Timestamp:   iter.Event.L1Timestamp.Uint64(),
+				TxHash:        iter.Event.Raw.TxHash,
+				LogIndex:      iter.Event.Raw.Index,
+			},
+		)
+	}
+
+	return outputsByBlockHash, iter.Error()
+}
+
+func (o *OutputOracle) GetDeletedOutputsByBlockRange(ctx context.Context, start, end uint64) (DeletedL2OutputsMap, error) {
+	deletedByBlockHash := make(DeletedL2OutputsMap)
+	opts := &bind.FilterOpts{
+		Context: ctx,
+		Start:   start,
+		End:     &end,
+	}
+
+	var iter *bindings.L2OutputOracleOutputsDeletedIterator
+	err := backoff.Do(3, backoff.Exponential(), func() error {
+		var err error
+		iter, err = o.contract.FilterOutputsDeleted(opts, nil, nil)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	defer iter.Close()
+	for iter.Next() {
+		deletedByBlockHash[iter.Event.Raw.BlockHash] = append(
+			deletedByBlockHash[iter.Event.Raw.BlockHash], db.DeletedL2Outputs{
+				FromIndex: iter.Event.NewNextOutputIndex.Uint64(),
+				TxHash:    iter.Event.Raw.TxHash,
+				LogIndex:  iter.Event.Raw.Index,
+			},
+		)
+	}
+
+	return deletedByBlockHash, iter.Error()
+}
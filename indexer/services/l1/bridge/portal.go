@@ -29,6 +29,16 @@ func (p *Portal) Address() common.Address {
 	return p.address
 }
 
+// FinalizationPeriod returns the challenge period of proven withdrawals in
+// seconds.
+func (p *Portal) FinalizationPeriod(ctx context.Context) (uint64, error) {
+	period, err := p.contract.FINALIZATIONPERIODSECONDS(&bind.CallOpts{Context: ctx})
+	if err != nil {
+		return 0, err
+	}
+	return period.Uint64(), nil
+}
+
 func (p *Portal) GetProvenWithdrawalsByBlockRange(ctx context.Context, start, end uint64) (ProvenWithdrawalsMap, error) {
 	wdsByBlockHash := make(ProvenWithdrawalsMap)
 	opts := &bind.FilterOpts{
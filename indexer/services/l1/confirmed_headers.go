@@ -192,28 +192,9 @@ func (f *ConfirmedHeaderSelector) NewHead(
 			"startHeight", startHeight, "endHeight", endHeight)
 	}
 
-	headers := make([]*NewHeader, 0)
-	height := startHeight
-	left := nHeaders - len(headers)
-	for left > 0 {
-		count := DefaultMaxBatchSize
-		if count > left {
-			count = left
-		}
-
-		logger.Info("Loading block batch",
-			"height", height, "count", count)
-
-		ctxt, cancel := context.WithTimeout(ctx, DefaultConnectionTimeout)
-		fetched, err := HeadersByRange(ctxt, client, height, count)
-		cancel()
-		if err != nil {
-			return nil, err
-		}
-
-		headers = append(headers, fetched...)
-		left = nHeaders - len(headers)
-		height += uint64(count)
+	headers, err := fetchHeaders(ctx, client, startHeight, nHeaders)
+	if err != nil {
+		return nil, err
 	}
 
 	logger.Debug("Verifying block range ",
@@ -246,6 +227,35 @@ func (f *ConfirmedHeaderSelector) NewHead(
 	return headers, nil
 }
 
+// fetchHeaders fetches count headers from startHeight in batches of
+// DefaultMaxBatchSize.
+func fetchHeaders(ctx context.Context, client *rpc.Client, startHeight uint64, count int) ([]*NewHeader, error) {
+	headers := make([]*NewHeader, 0, count)
+	height := startHeight
+	left := count
+	for left > 0 {
+		batchSize := DefaultMaxBatchSize
+		if batchSize > left {
+			batchSize = left
+		}
+
+		logger.Info("Loading block batch",
+			"height", height, "count", batchSize)
+
+		ctxt, cancel := context.WithTimeout(ctx, DefaultConnectionTimeout)
+		fetched, err := HeadersByRange(ctxt, client, height, batchSize)
+		cancel()
+		if err != nil {
+			return nil, err
+		}
+
+		headers = append(headers, fetched...)
+		left = count - len(headers)
+		height += uint64(batchSize)
+	}
+	return headers, nil
+}
+
 func NewConfirmedHeaderSelector(cfg HeaderSelectorConfig) (*ConfirmedHeaderSelector,
 	error) {
 	if cfg.ConfDepth == 0 {
@@ -15,10 +15,14 @@ import (
 	"github.com/ethereum-optimism/optimism/indexer/metrics"
 	"github.com/ethereum-optimism/optimism/indexer/services"
 	"github.com/ethereum-optimism/optimism/indexer/services/query"
+	"github.com/ethereum-optimism/optimism/indexer/services/util"
 	"github.com/prometheus/client_golang/prometheus"
 
 	"github.com/ethereum-optimism/optimism/indexer/server"
+	"github.com/ethereum-optimism/optimism/indexer/services/backfill"
+	"github.com/ethereum-optimism/optimism/indexer/services/events"
 	"github.com/ethereum-optimism/optimism/indexer/services/l1/bridge"
+	"github.com/ethereum-optimism/optimism/indexer/services/notify"
 
 	_ "github.com/lib/pq"
 
@@ -57,8 +61,17 @@ type ServiceConfig struct {
 	ConfDepth          uint64
 	MaxHeaderBatchSize uint64
 	StartBlockNumber   uint64
-	DB                 *db.Database
+	DB                 db.Store
 	Bedrock            bool
+	// ContractEvents indexes the events of the configured L1 contracts, if
+	// not nil.
+	ContractEvents *events.Indexer
+	// Backfill indexes the blocks far behind the head in parallel chunks
+	// when catching up, if not nil.
+	Backfill *backfill.Config
+	// Notifier publishes the bridge events of the new blocks, if not nil.
+	// Backfilled blocks are not published.
+	Notifier *notify.Notifier
 }
 
 type Service struct {
@@ -66,13 +79,19 @@ type Service struct {
 	ctx    context.Context
 	cancel func()
 
-	bridges        map[string]bridge.Bridge
-	portal         *bridge.Portal
-	batchScanner   *scc.StateCommitmentChainFilterer
-	latestHeader   uint64
-	headerSelector *ConfirmedHeaderSelector
-	l1Client       *ethclient.Client
-
+	bridges      map[string]bridge.Bridge
+	portal       *bridge.Portal
+	outputOracle *bridge.OutputOracle
+	messenger    *bridge.Messenger
+	// finalizationPeriod is the challenge period of proven withdrawals
+	// in seconds.
+	finalizationPeriod uint64
+	batchScanner       *scc.StateCommitmentChainFilterer
+	latestHeader       uint64
+	headerSelector     *ConfirmedHeaderSelector
+	l1Client           *ethclient.Client
+
+	backfill   *backfill.Backfiller
 	metrics    *metrics.Metrics
 	tokenCache map[common.Address]*db.Token
 	isBedrock  bool
@@ -108,9 +127,19 @@ func NewService(cfg ServiceConfig) (*Service, error) {
 	}
 
 	var portal *bridge.Portal
+	var outputOracle *bridge.OutputOracle
+	var messenger *bridge.Messenger
+	var finalizationPeriod uint64
 	var batchScanner *scc.StateCommitmentChainFilterer
 	if cfg.Bedrock {
 		portal = bridge.NewPortal(cfg.AddressManager)
+		outputOracle = bridge.NewOutputOracle(cfg.AddressManager)
+		messenger = bridge.NewMessenger(cfg.AddressManager)
+		finalizationPeriod, err = portal.FinalizationPeriod(ctx)
+		if err != nil {
+			cancel()
+			return nil, fmt.Errorf("error fetching finalization period: %w", err)
+		}
 	} else {
 		batchScanner, err = bridge.StateCommitmentChainScanner(cfg.L1Client, cfg.AddressManager)
 		if err != nil {
@@ -132,20 +161,30 @@ func NewService(cfg ServiceConfig) (*Service, error) {
 	}
 
 	service := &Service{
-		cfg:            cfg,
-		ctx:            ctx,
-		cancel:         cancel,
-		portal:         portal,
-		bridges:        bridges,
-		batchScanner:   batchScanner,
-		headerSelector: confirmedHeaderSelector,
-		metrics:        cfg.Metrics,
+		cfg:                cfg,
+		ctx:                ctx,
+		cancel:             cancel,
+		portal:             portal,
+		outputOracle:       outputOracle,
+		messenger:          messenger,
+		finalizationPeriod: finalizationPeriod,
+		bridges:            bridges,
+		batchScanner:       batchScanner,
+		headerSelector:     confirmedHeaderSelector,
+		metrics:            cfg.Metrics,
 		tokenCache: map[common.Address]*db.Token{
 			ZeroAddress: db.ETHL1Token,
 		},
 		isBedrock: cfg.Bedrock,
 		l1Client:  cfg.L1Client,
 	}
+	if cfg.Backfill != nil {
+		service.backfill, err = backfill.New("l1", cfg.DB, backfillChain{service}, *cfg.Backfill)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+	}
 	service.wg.Add(1)
 	return service, nil
 }
@@ -203,23 +242,63 @@ func (s *Service) loop() {
 	}
 }
 
-func (s *Service) Update(newHeader *types.Header) error {
-	var lowest db.BlockLocator
+// handleReorg deletes the indexed blocks that are no longer canonical, down to
+// the common ancestor of head and the canonical chain. The next update then
+// indexes the new branch.
+func (s *Service) handleReorg(head db.BlockLocator) error {
+	ancestor, err := util.FindCommonAncestor(&head, s.cfg.DB.GetPreviousL1Block, func(number uint64) (common.Hash, error) {
+		headers, err := HeadersByRange(s.ctx, s.cfg.RawL1Client, number, 1)
+		if err != nil {
+			return common.Hash{}, err
+		}
+		return headers[0].Hash, nil
+	})
+	if err != nil {
+		return fmt.Errorf("error finding common ancestor: %w", err)
+	}
+
+	// Without a common ancestor the index is rebuilt from the start block.
+	var ancestorNumber uint64
+	if ancestor != nil {
+		ancestorNumber = ancestor.Number
+	}
+	removed, err := s.cfg.DB.RollbackL1Blocks(ancestorNumber)
+	if err != nil {
+		return fmt.Errorf("error rolling back blocks: %w", err)
+	}
+	s.metrics.RecordL1Reorg(removed)
+	logger.Warn("rolled back reorged blocks",
+		"head", head.Number, "hash", head.Hash,
+		"common_ancestor", ancestorNumber, "removed_blocks", removed)
+	return nil
+}
+
+// lastBlock returns the highest indexed block, or the start block if no
+// block is indexed yet.
+func (s *Service) lastBlock() (db.BlockLocator, error) {
 	highestConfirmed, err := s.cfg.DB.GetHighestL1Block()
 	if err != nil {
-		return err
+		return db.BlockLocator{}, err
 	}
-	if highestConfirmed == nil {
-		startHeader, err := s.l1Client.HeaderByNumber(s.ctx, new(big.Int).SetUint64(s.cfg.StartBlockNumber))
-		if err != nil {
-			return fmt.Errorf("error fetching header by number: %w", err)
-		}
-		highestConfirmed = &db.BlockLocator{
-			Number: s.cfg.StartBlockNumber,
-			Hash:   startHeader.Hash(),
-		}
+	if highestConfirmed != nil {
+		return *highestConfirmed, nil
+	}
+
+	startHeader, err := s.l1Client.HeaderByNumber(s.ctx, new(big.Int).SetUint64(s.cfg.StartBlockNumber))
+	if err != nil {
+		return db.BlockLocator{}, fmt.Errorf("error fetching header by number: %w", err)
+	}
+	return db.BlockLocator{
+		Number: s.cfg.StartBlockNumber,
+		Hash:   startHeader.Hash(),
+	}, nil
+}
+
+func (s *Service) Update(newHeader *types.Header) error {
+	lowest, err := s.lastBlock()
+	if err != nil {
+		return err
 	}
-	lowest = *highestConfirmed
 
 	headers, err := s.headerSelector.NewHead(s.ctx, lowest.Number, newHeader, s.cfg.RawL1Client)
 	if err != nil {
@@ -233,19 +312,18 @@ func (s *Service) Update(newHeader *types.Header) error {
 		logger.Error("Block number does not immediately follow ",
 			"block", headers[0].Number.Uint64(), "hash", headers[0].Hash,
 			"lowest_block", lowest.Number, "hash", lowest.Hash)
-		return nil
+		return fmt.Errorf("block %d does not immediately follow block %d", headers[0].Number.Uint64(), lowest.Number)
 	}
 
 	if lowest.Number > 0 && lowest.Hash != headers[0].ParentHash {
-		logger.Error("Parent hash does not connect to ",
+		logger.Warn("Parent hash does not connect, handling reorg",
 			"block", headers[0].Number.Uint64(), "hash", headers[0].Hash,
 			"lowest_block", lowest.Number, "hash", lowest.Hash)
-		return nil
+		return s.handleReorg(lowest)
 	}
 
 	startHeight := headers[0].Number.Uint64()
 	endHeight := headers[len(headers)-1].Number.Uint64()
-	depositsByBlockHash := make(map[common.Hash][]db.Deposit)
 
 	start := prometheus.NewTimer(s.metrics.UpdateDuration.WithLabelValues("l1"))
 	defer func() {
@@ -253,14 +331,51 @@ func (s *Service) Update(newHeader *types.Header) error {
 		logger.Info("updated index", "start_height", startHeight, "end_height", endHeight, "duration", dur)
 	}()
 
+	blocks, err := s.indexRange(s.ctx, headers)
+	if err != nil {
+		return err
+	}
+	if err := s.storeBlocks(blocks); err != nil {
+		return err
+	}
+
+	newHeaderNumber := newHeader.Number.Uint64()
+	s.metrics.SetL1SyncHeight(endHeight)
+	s.metrics.SetL1SyncPercent(endHeight, newHeaderNumber)
+	latestHeaderNumber := headers[len(headers)-1].Number.Uint64()
+	if latestHeaderNumber+s.cfg.ConfDepth-1 == newHeaderNumber {
+		return errNoNewBlocks
+	}
+	return nil
+}
+
+// indexedBlock is an indexed L1 block with the state batches appended in it.
+type indexedBlock struct {
+	Block        *db.IndexedL1Block
+	StateBatches []db.StateBatch
+}
+
+// indexRange fetches the indexed data of headers, which must be contiguous.
+// It returns the blocks containing indexed data, and always the last block.
+// It doesn't write to the database, so ranges can be indexed concurrently.
+func (s *Service) indexRange(ctx context.Context, headers []*NewHeader) ([]*indexedBlock, error) {
+	startHeight := headers[0].Number.Uint64()
+	endHeight := headers[len(headers)-1].Number.Uint64()
+	depositsByBlockHash := make(map[common.Hash][]db.Deposit)
+
 	bridgeDepositsCh := make(chan bridge.DepositsMap, len(s.bridges))
 	provenWithdrawalsCh := make(chan bridge.ProvenWithdrawalsMap, 1)
 	finalizedWithdrawalsCh := make(chan bridge.FinalizedWithdrawalsMap, 1)
-	errCh := make(chan error, len(s.bridges)+1)
+	outputsCh := make(chan bridge.L2OutputsMap, 1)
+	deletedOutputsCh := make(chan bridge.DeletedL2OutputsMap, 1)
+	sentMessagesCh := make(chan bridge.SentMessagesMap, 1)
+	relayedMessagesCh := make(chan bridge.RelayedMessagesMap, 1)
+	contractEventsCh := make(chan events.ContractEventsMap, 1)
+	errCh := make(chan error, len(s.bridges)+7)
 
 	for _, bridgeImpl := range s.bridges {
 		go func(b bridge.Bridge) {
-			deposits, err := b.GetDepositsByBlockRange(s.ctx, startHeight, endHeight)
+			deposits, err := b.GetDepositsByBlockRange(ctx, startHeight, endHeight)
 			if err != nil {
 				errCh <- err
 				return
@@ -271,7 +386,7 @@ func (s *Service) Update(newHeader *types.Header) error {
 
 	if s.isBedrock {
 		go func() {
-			provenWithdrawals, err := s.portal.GetProvenWithdrawalsByBlockRange(s.ctx, startHeight, endHeight)
+			provenWithdrawals, err := s.portal.GetProvenWithdrawalsByBlockRange(ctx, startHeight, endHeight)
 			if err != nil {
 				errCh <- err
 				return
@@ -279,16 +394,65 @@ func (s *Service) Update(newHeader *types.Header) error {
 			provenWithdrawalsCh <- provenWithdrawals
 		}()
 		go func() {
-			finalizedWithdrawals, err := s.portal.GetFinalizedWithdrawalsByBlockRange(s.ctx, startHeight, endHeight)
+			finalizedWithdrawals, err := s.portal.GetFinalizedWithdrawalsByBlockRange(ctx, startHeight, endHeight)
 			if err != nil {
 				errCh <- err
 				return
 			}
 			finalizedWithdrawalsCh <- finalizedWithdrawals
 		}()
+		go func() {
+			outputs, err := s.outputOracle.GetOutputsByBlockRange(ctx, startHeight, endHeight)
+			if err != nil {
+				errCh <- err
+				return
+			}
+			outputsCh <- outputs
+		}()
+		go func() {
+			deletedOutputs, err := s.outputOracle.GetDeletedOutputsByBlockRange(ctx, startHeight, endHeight)
+			if err != nil {
+				errCh <- err
+				return
+			}
+			deletedOutputsCh <- deletedOutputs
+		}()
+		go func() {
+			sentMessages, err := s.messenger.GetSentMessagesByBlockRange(ctx, startHeight, endHeight)
+			if err != nil {
+				errCh <- err
+				return
+			}
+			sentMessagesCh <- sentMessages
+		}()
+		go func() {
+			relayedMessages, err := s.messenger.GetRelayedMessagesByBlockRange(ctx, startHeight, endHeight)
+			if err != nil {
+				errCh <- err
+				return
+			}
+			relayedMessagesCh <- relayedMessages
+		}()
 	} else {
 		provenWithdrawalsCh <- make(bridge.ProvenWithdrawalsMap)
 		finalizedWithdrawalsCh <- make(bridge.FinalizedWithdrawalsMap)
+		outputsCh <- make(bridge.L2OutputsMap)
+		deletedOutputsCh <- make(bridge.DeletedL2OutputsMap)
+		sentMessagesCh <- make(bridge.SentMessagesMap)
+		relayedMessagesCh <- make(bridge.RelayedMessagesMap)
+	}
+
+	if s.cfg.ContractEvents != nil {
+		go func() {
+			contractEvents, err := s.cfg.ContractEvents.GetEventsByBlockRange(ctx, startHeight, endHeight)
+			if err != nil {
+				errCh <- err
+				return
+			}
+			contractEventsCh <- contractEvents
+		}()
+	} else {
+		contractEventsCh <- make(events.ContractEventsMap)
 	}
 
 	var receives int
@@ -296,16 +460,10 @@ func (s *Service) Update(newHeader *types.Header) error {
 		select {
 		case bridgeDeposits := <-bridgeDepositsCh:
 			for blockHash, deposits := range bridgeDeposits {
-				for _, deposit := range deposits {
-					if err := s.cacheToken(deposit); err != nil {
-						logger.Warn("error caching token", "err", err)
-					}
-				}
-
 				depositsByBlockHash[blockHash] = append(depositsByBlockHash[blockHash], deposits...)
 			}
 		case err := <-errCh:
-			return err
+			return nil, err
 		}
 
 		receives++
@@ -314,18 +472,38 @@ func (s *Service) Update(newHeader *types.Header) error {
 		}
 	}
 
-	provenWithdrawalsByBlockHash := <-provenWithdrawalsCh
-	finalizedWithdrawalsByBlockHash := <-finalizedWithdrawalsCh
+	var provenWithdrawalsByBlockHash bridge.ProvenWithdrawalsMap
+	var finalizedWithdrawalsByBlockHash bridge.FinalizedWithdrawalsMap
+	var outputsByBlockHash bridge.L2OutputsMap
+	var deletedOutputsByBlockHash bridge.DeletedL2OutputsMap
+	var sentMessagesByBlockHash bridge.SentMessagesMap
+	var relayedMessagesByBlockHash bridge.RelayedMessagesMap
+	var contractEventsByBlockHash events.ContractEventsMap
+	for receives := 0; receives < 7; receives++ {
+		select {
+		case provenWithdrawalsByBlockHash = <-provenWithdrawalsCh:
+		case finalizedWithdrawalsByBlockHash = <-finalizedWithdrawalsCh:
+		case outputsByBlockHash = <-outputsCh:
+		case deletedOutputsByBlockHash = <-deletedOutputsCh:
+		case sentMessagesByBlockHash = <-sentMessagesCh:
+		case relayedMessagesByBlockHash = <-relayedMessagesCh:
+		case contractEventsByBlockHash = <-contractEventsCh:
+		case err := <-errCh:
+			return nil, err
+		}
+	}
 
 	var stateBatches map[common.Hash][]db.StateBatch
 	if !s.isBedrock {
-		stateBatches, err = QueryStateBatches(s.batchScanner, startHeight, endHeight, s.ctx)
+		var err error
+		stateBatches, err = QueryStateBatches(s.batchScanner, startHeight, endHeight, ctx)
 		if err != nil {
 			logger.Error("Error querying state batches", "err", err)
-			return err
+			return nil, err
 		}
 	}
 
+	var blocks []*indexedBlock
 	for i, header := range headers {
 		blockHash := header.Hash
 		number := header.Number.Uint64()
@@ -333,48 +511,96 @@ func (s *Service) Update(newHeader *types.Header) error {
 		batches := stateBatches[blockHash]
 		provenWds := provenWithdrawalsByBlockHash[blockHash]
 		finalizedWds := finalizedWithdrawalsByBlockHash[blockHash]
+		outputs := outputsByBlockHash[blockHash]
+		deletedOutputs := deletedOutputsByBlockHash[blockHash]
+		sentMessages := sentMessagesByBlockHash[blockHash]
+		relayedMessages := relayedMessagesByBlockHash[blockHash]
+		contractEvents := contractEventsByBlockHash[blockHash]
 
 		// Always record block data in the last block
 		// in the list of headers
-		if len(deposits) == 0 && len(batches) == 0 && len(provenWds) == 0 && len(finalizedWds) == 0 && i != len(headers)-1 {
+		if len(deposits) == 0 && len(batches) == 0 && len(provenWds) == 0 && len(finalizedWds) == 0 &&
+			len(outputs) == 0 && len(deletedOutputs) == 0 && len(sentMessages) == 0 &&
+			len(relayedMessages) == 0 && len(contractEvents) == 0 && i != len(headers)-1 {
 			continue
 		}
 
-		block := &db.IndexedL1Block{
-			Hash:                 blockHash,
-			ParentHash:           header.ParentHash,
-			Number:               number,
-			Timestamp:            header.Time,
-			Deposits:             deposits,
-			ProvenWithdrawals:    provenWds,
-			FinalizedWithdrawals: finalizedWds,
+		bridge.SetDepositMessageHashes(deposits, sentMessages)
+		for j := range provenWds {
+			provenWds[j].FinalizableAt = header.Time + s.finalizationPeriod
 		}
 
-		err := s.cfg.DB.AddIndexedL1Block(block)
+		blocks = append(blocks, &indexedBlock{
+			Block: &db.IndexedL1Block{
+				Hash:                 blockHash,
+				ParentHash:           header.ParentHash,
+				Number:               number,
+				Timestamp:            header.Time,
+				Deposits:             deposits,
+				ProvenWithdrawals:    provenWds,
+				FinalizedWithdrawals: finalizedWds,
+				L2Outputs:            outputs,
+				DeletedL2Outputs:     deletedOutputs,
+				SentMessages:         sentMessages,
+				RelayedMessages:      relayedMessages,
+				ContractEvents:       contractEvents,
+			},
+			StateBatches: batches,
+		})
+	}
+	return blocks, nil
+}
+
+// storeBlocks stores blocks returned by indexRange in order, after caching
+// the tokens of their deposits.
+func (s *Service) storeBlocks(blocks []*indexedBlock) error {
+	for _, indexed := range blocks {
+		block := indexed.Block
+		for _, deposit := range block.Deposits {
+			if err := s.cacheToken(deposit); err != nil {
+				logger.Warn("error caching token", "err", err)
+			}
+		}
+
+		// The webhook deliveries of the events of the block are logged
+		// with it, and the events are published once it is stored.
+		var events []notify.Event
+		var hooks []db.BlockHook
+		if s.cfg.Notifier != nil {
+			hooks = append(hooks, func(tx *db.Tx) error {
+				var err error
+				events, err = s.cfg.Notifier.LogL1Block(tx, block)
+				return err
+			})
+		}
+		err := s.cfg.DB.AddIndexedL1Block(block, hooks...)
 		if err != nil {
 			logger.Error(
 				"Unable to import ",
-				"block", number,
-				"hash", blockHash, "err", err,
+				"block", block.Number,
+				"hash", block.Hash, "err", err,
 				"block", block,
 			)
 			return err
 		}
+		if s.cfg.Notifier != nil {
+			s.cfg.Notifier.Publish(events)
+		}
 
-		err = s.cfg.DB.AddStateBatch(batches)
+		err = s.cfg.DB.AddStateBatch(indexed.StateBatches)
 		if err != nil {
 			logger.Error(
 				"Unable to import state append batch",
-				"block", number,
-				"hash", blockHash, "err", err,
+				"block", block.Number,
+				"hash", block.Hash, "err", err,
 				"block", block,
 			)
 			return err
 		}
-		s.metrics.RecordStateBatches(len(batches))
+		s.metrics.RecordStateBatches(len(indexed.StateBatches))
 
 		logger.Debug("Imported ",
-			"block", number, "hash", blockHash, "deposits", len(block.Deposits))
+			"block", block.Number, "hash", block.Hash, "deposits", len(block.Deposits))
 		for _, deposit := range block.Deposits {
 			token := s.tokenCache[deposit.L1Token]
 			logger.Info(
@@ -386,14 +612,6 @@ func (s *Service) Update(newHeader *types.Header) error {
 			s.metrics.RecordDeposit(deposit.L1Token)
 		}
 	}
-
-	newHeaderNumber := newHeader.Number.Uint64()
-	s.metrics.SetL1SyncHeight(endHeight)
-	s.metrics.SetL1SyncPercent(endHeight, newHeaderNumber)
-	latestHeaderNumber := headers[len(headers)-1].Number.Uint64()
-	if latestHeaderNumber+s.cfg.ConfDepth-1 == newHeaderNumber {
-		return errNoNewBlocks
-	}
 	return nil
 }
 
@@ -474,6 +692,26 @@ func (s *Service) catchUp() error {
 	logger.Info("chain is far behind head, resyncing")
 	s.metrics.SetL1CatchingUp(true)
 
+	if s.backfill != nil {
+		last, err := s.lastBlock()
+		if err != nil {
+			return err
+		}
+		// Backfill up to the highest confirmed block, as selected by the
+		// header selector.
+		if err := s.backfill.Run(s.ctx, last, realHeadNum-s.cfg.ConfDepth+1); err != nil {
+			if s.ctx.Err() != nil {
+				return s.ctx.Err()
+			}
+			return fmt.Errorf("error backfilling: %w", err)
+		}
+		currHead, err = s.cfg.DB.GetHighestL1Block()
+		if err != nil {
+			return err
+		}
+		currHeadNum = currHead.Number
+	}
+
 	for realHeadNum-s.cfg.ConfDepth > currHeadNum+s.cfg.MaxHeaderBatchSize {
 		select {
 		case <-s.ctx.Done():
@@ -514,6 +752,8 @@ func (s *Service) cacheToken(deposit db.Deposit) error {
 	if err != nil {
 		logger.Error("Error querying ERC20 token details",
 			"l1_token", deposit.L1Token.String(), "err", err)
+	}
+	if token == nil {
 		token = &db.Token{
 			Address: deposit.L1Token.String(),
 		}
@@ -0,0 +1,67 @@
+package l2
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ethereum-optimism/optimism/indexer/db"
+)
+
+// backfillChain fetches and stores chunks of L2 blocks for a backfill.
+type backfillChain struct {
+	s *Service
+}
+
+// FetchChunk fetches the indexed data of the L2 blocks from start to end
+// included.
+func (c backfillChain) FetchChunk(ctx context.Context, start, end uint64) (*db.BackfillChunk, error) {
+	headers, err := fetchHeaders(ctx, c.s.cfg.L2RPC, start, int(end-start+1))
+	if err != nil {
+		return nil, err
+	}
+	for i, header := range headers {
+		if header == nil || header.Number == nil || header.Number.Uint64() != start+uint64(i) {
+			return nil, fmt.Errorf("missing block %d", start+uint64(i))
+		}
+		if i > 0 && headers[i-1].Hash() != header.ParentHash {
+			return nil, fmt.Errorf("block %d does not connect to block %d", start+uint64(i), start+uint64(i)-1)
+		}
+	}
+
+	blocks, err := c.s.indexRange(ctx, headers)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(blocks)
+	if err != nil {
+		return nil, err
+	}
+
+	return &db.BackfillChunk{
+		Start:      start,
+		End:        end,
+		ParentHash: headers[0].ParentHash,
+		Hash:       headers[len(headers)-1].Hash(),
+		Data:       data,
+	}, nil
+}
+
+// CommitChunk stores the L2 blocks of a chunk.
+func (c backfillChain) CommitChunk(chunk *db.BackfillChunk) error {
+	var blocks []*db.IndexedL2Block
+	if err := json.Unmarshal(chunk.Data, &blocks); err != nil {
+		return fmt.Errorf("error decoding chunk: %w", err)
+	}
+	if err := c.s.storeBlocks(blocks); err != nil {
+		return err
+	}
+	c.s.metrics.SetL2SyncHeight(chunk.End)
+	return nil
+}
+
+// Rollback deletes the stored L2 blocks after number.
+func (c backfillChain) Rollback(number uint64) error {
+	_, err := c.s.cfg.DB.RollbackL2Blocks(number)
+	return err
+}
@@ -0,0 +1,62 @@
+package bridge
+
+import (
+	"context"
+
+	"github.com/ethereum-optimism/optimism/indexer/db"
+	"github.com/ethereum-optimism/optimism/op-bindings/bindings"
+	"github.com/ethereum-optimism/optimism/op-bindings/predeploys"
+	"github.com/ethereum-optimism/optimism/op-service/backoff"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// TokenPairsMap is a collection of created token pairs keyed on block hashes.
+type TokenPairsMap map[common.Hash][]db.TokenPair
+
+// TokenFactory scans the OptimismMintableERC20Factory for the L2 tokens it
+// creates. The legacy L2StandardTokenFactory, deployed at the same address,
+// emits the same StandardL2TokenCreated event.
+type TokenFactory struct {
+	contract *bindings.OptimismMintableERC20Factory
+}
+
+func NewTokenFactory(client *ethclient.Client) (*TokenFactory, error) {
+	contract, err := bindings.NewOptimismMintableERC20Factory(predeploys.OptimismMintableERC20FactoryAddr, client)
+	if err != nil {
+		return nil, err
+	}
+	return &TokenFactory{contract: contract}, nil
+}
+
+func (f *TokenFactory) GetTokenPairsByBlockRange(ctx context.Context, start, end uint64) (TokenPairsMap, error) {
+	pairsByBlockHash := make(TokenPairsMap)
+	opts := &bind.FilterOpts{
+		Context: ctx,
+		Start:   start,
+		End:     &end,
+	}
+
+	var iter *bindings.OptimismMintableERC20FactoryStandardL2TokenCreatedIterator
+	err := backoff.Do(3, backoff.Exponential(), func() error {
+		var err error
+		iter, err = f.contract.FilterStandardL2TokenCreated(opts, nil, nil)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	defer iter.Close()
+	for iter.Next() {
+		pairsByBlockHash[iter.Event.Raw.BlockHash] = append(
+			pairsByBlockHash[iter.Event.Raw.BlockHash], db.TokenPair{
+				L1Token: iter.Event.RemoteToken,
+				L2Token: iter.Event.LocalToken,
+			},
+		)
+	}
+
+	return pairsByBlockHash, iter.Error()
+}
@@ -13,11 +13,15 @@ import (
 	"github.com/ethereum-optimism/optimism/indexer/metrics"
 	"github.com/ethereum-optimism/optimism/indexer/server"
 	"github.com/ethereum-optimism/optimism/indexer/services/query"
+	"github.com/ethereum-optimism/optimism/indexer/services/util"
 	"github.com/ethereum-optimism/optimism/op-bindings/predeploys"
 	"github.com/prometheus/client_golang/prometheus"
 
 	"github.com/ethereum-optimism/optimism/indexer/db"
+	"github.com/ethereum-optimism/optimism/indexer/services/backfill"
+	"github.com/ethereum-optimism/optimism/indexer/services/events"
 	"github.com/ethereum-optimism/optimism/indexer/services/l2/bridge"
+	"github.com/ethereum-optimism/optimism/indexer/services/notify"
 
 	"github.com/ethereum/go-ethereum/rpc"
 
@@ -50,8 +54,17 @@ type ServiceConfig struct {
 	ConfDepth          uint64
 	MaxHeaderBatchSize uint64
 	StartBlockNumber   uint64
-	DB                 *db.Database
+	DB                 db.Store
 	Bedrock            bool
+	// ContractEvents indexes the events of the configured L2 contracts, if
+	// not nil.
+	ContractEvents *events.Indexer
+	// Backfill indexes the blocks far behind the head in parallel chunks
+	// when catching up, if not nil.
+	Backfill *backfill.Config
+	// Notifier publishes the bridge events of the new blocks, if not nil.
+	// Backfilled blocks are not published.
+	Notifier *notify.Notifier
 }
 
 type Service struct {
@@ -60,9 +73,12 @@ type Service struct {
 	cancel func()
 
 	bridges        map[string]bridge.Bridge
+	messenger      *bridge.Messenger
+	tokenFactory   *bridge.TokenFactory
 	latestHeader   uint64
 	headerSelector *ConfirmedHeaderSelector
 
+	backfill   *backfill.Backfiller
 	metrics    *metrics.Metrics
 	tokenCache map[common.Address]*db.Token
 	wg         sync.WaitGroup
@@ -102,6 +118,21 @@ func NewService(cfg ServiceConfig) (*Service, error) {
 		return nil, err
 	}
 
+	var messenger *bridge.Messenger
+	if cfg.Bedrock {
+		messenger, err = bridge.NewMessenger(cfg.L2Client)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+	}
+
+	tokenFactory, err := bridge.NewTokenFactory(cfg.L2Client)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
 	logger.Info("Scanning bridges for withdrawals", "bridges", bridges)
 
 	confirmedHeaderSelector, err := NewConfirmedHeaderSelector(HeaderSelectorConfig{
@@ -119,12 +150,21 @@ func NewService(cfg ServiceConfig) (*Service, error) {
 		ctx:            ctx,
 		cancel:         cancel,
 		bridges:        bridges,
+		messenger:      messenger,
+		tokenFactory:   tokenFactory,
 		headerSelector: confirmedHeaderSelector,
 		metrics:        cfg.Metrics,
 		tokenCache: map[common.Address]*db.Token{
 			predeploys.LegacyERC20ETHAddr: db.ETHL1Token,
 		},
 	}
+	if cfg.Backfill != nil {
+		service.backfill, err = backfill.New("l2", cfg.DB, backfillChain{service}, *cfg.Backfill)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+	}
 	service.wg.Add(1)
 	return service, nil
 }
@@ -177,6 +217,37 @@ func (s *Service) loop() {
 	}
 }
 
+// handleReorg deletes the indexed blocks that are no longer canonical, down to
+// the common ancestor of head and the canonical chain. The next update then
+// indexes the new branch.
+func (s *Service) handleReorg(head db.BlockLocator) error {
+	ancestor, err := util.FindCommonAncestor(&head, s.cfg.DB.GetPreviousL2Block, func(number uint64) (common.Hash, error) {
+		headers, err := HeadersByRange(s.ctx, s.cfg.L2RPC, number, 1)
+		if err != nil {
+			return common.Hash{}, err
+		}
+		return headers[0].Hash(), nil
+	})
+	if err != nil {
+		return fmt.Errorf("error finding common ancestor: %w", err)
+	}
+
+	// Without a common ancestor the index is rebuilt from the start block.
+	var ancestorNumber uint64
+	if ancestor != nil {
+		ancestorNumber = ancestor.Number
+	}
+	removed, err := s.cfg.DB.RollbackL2Blocks(ancestorNumber)
+	if err != nil {
+		return fmt.Errorf("error rolling back blocks: %w", err)
+	}
+	s.metrics.RecordL2Reorg(removed)
+	logger.Warn("rolled back reorged blocks",
+		"head", head.Number, "hash", head.Hash,
+		"common_ancestor", ancestorNumber, "removed_blocks", removed)
+	return nil
+}
+
 func (s *Service) Update(newHeader *types.Header) error {
 	var lowest = db.BlockLocator{
 		Number: s.cfg.StartBlockNumber,
@@ -201,19 +272,19 @@ func (s *Service) Update(newHeader *types.Header) error {
 		logger.Error("Block number does not immediately follow ",
 			"block", headers[0].Number.Uint64(), "hash", headers[0].Hash(),
 			"lowest_block", lowest.Number, "hash", lowest.Hash)
-		return nil
+		return fmt.Errorf("block %d does not immediately follow block %d", headers[0].Number.Uint64(), lowest.Number)
 	}
 
-	if lowest.Number > 0 && lowest.Hash != headers[0].ParentHash {
-		logger.Error("Parent hash does not connect to ",
+	// The hash of the start block isn't known until a block is indexed.
+	if highestConfirmed != nil && lowest.Hash != headers[0].ParentHash {
+		logger.Warn("Parent hash does not connect, handling reorg",
 			"block", headers[0].Number.Uint64(), "hash", headers[0].Hash(),
 			"lowest_block", lowest.Number, "hash", lowest.Hash)
-		return nil
+		return s.handleReorg(lowest)
 	}
 
 	startHeight := headers[0].Number.Uint64()
 	endHeight := headers[len(headers)-1].Number.Uint64()
-	withdrawalsByBlockHash := make(map[common.Hash][]db.Withdrawal)
 
 	start := prometheus.NewTimer(s.metrics.UpdateDuration.WithLabelValues("l2"))
 	defer func() {
@@ -221,12 +292,42 @@ func (s *Service) Update(newHeader *types.Header) error {
 		logger.Info("updated index", "start_height", startHeight, "end_height", endHeight, "duration", dur)
 	}()
 
-	bridgeWdsCh := make(chan bridge.WithdrawalsMap)
-	errCh := make(chan error, len(s.bridges))
+	blocks, err := s.indexRange(s.ctx, headers)
+	if err != nil {
+		return err
+	}
+	if err := s.storeBlocks(blocks); err != nil {
+		return err
+	}
+
+	newHeaderNumber := newHeader.Number.Uint64()
+	s.metrics.SetL2SyncHeight(endHeight)
+	s.metrics.SetL2SyncPercent(endHeight, newHeaderNumber)
+	latestHeaderNumber := headers[len(headers)-1].Number.Uint64()
+	if latestHeaderNumber+s.cfg.ConfDepth-1 == newHeaderNumber {
+		return errNoNewBlocks
+	}
+	return nil
+}
+
+// indexRange fetches the indexed data of headers, which must be contiguous.
+// It returns the blocks containing indexed data, and always the last block.
+// It doesn't write to the database, so ranges can be indexed concurrently.
+func (s *Service) indexRange(ctx context.Context, headers []*types.Header) ([]*db.IndexedL2Block, error) {
+	startHeight := headers[0].Number.Uint64()
+	endHeight := headers[len(headers)-1].Number.Uint64()
+	withdrawalsByBlockHash := make(map[common.Hash][]db.Withdrawal)
+
+	bridgeWdsCh := make(chan bridge.WithdrawalsMap, len(s.bridges))
+	sentMessagesCh := make(chan bridge.SentMessagesMap, 1)
+	relayedMessagesCh := make(chan bridge.RelayedMessagesMap, 1)
+	tokenPairsCh := make(chan bridge.TokenPairsMap, 1)
+	contractEventsCh := make(chan events.ContractEventsMap, 1)
+	errCh := make(chan error, len(s.bridges)+4)
 
 	for _, bridgeImpl := range s.bridges {
 		go func(b bridge.Bridge) {
-			wds, err := b.GetWithdrawalsByBlockRange(s.ctx, startHeight, endHeight)
+			wds, err := b.GetWithdrawalsByBlockRange(ctx, startHeight, endHeight)
 			if err != nil {
 				errCh <- err
 				return
@@ -235,21 +336,59 @@ func (s *Service) Update(newHeader *types.Header) error {
 		}(bridgeImpl)
 	}
 
+	if s.messenger != nil {
+		go func() {
+			sentMessages, err := s.messenger.GetSentMessagesByBlockRange(ctx, startHeight, endHeight)
+			if err != nil {
+				errCh <- err
+				return
+			}
+			sentMessagesCh <- sentMessages
+		}()
+		go func() {
+			relayedMessages, err := s.messenger.GetRelayedMessagesByBlockRange(ctx, startHeight, endHeight)
+			if err != nil {
+				errCh <- err
+				return
+			}
+			relayedMessagesCh <- relayedMessages
+		}()
+	} else {
+		sentMessagesCh <- make(bridge.SentMessagesMap)
+		relayedMessagesCh <- make(bridge.RelayedMessagesMap)
+	}
+
+	go func() {
+		tokenPairs, err := s.tokenFactory.GetTokenPairsByBlockRange(ctx, startHeight, endHeight)
+		if err != nil {
+			errCh <- err
+			return
+		}
+		tokenPairsCh <- tokenPairs
+	}()
+
+	if s.cfg.ContractEvents != nil {
+		go func() {
+			contractEvents, err := s.cfg.ContractEvents.GetEventsByBlockRange(ctx, startHeight, endHeight)
+			if err != nil {
+				errCh <- err
+				return
+			}
+			contractEventsCh <- contractEvents
+		}()
+	} else {
+		contractEventsCh <- make(events.ContractEventsMap)
+	}
+
 	var receives int
 	for {
 		select {
 		case bridgeWds := <-bridgeWdsCh:
 			for blockHash, withdrawals := range bridgeWds {
-				for _, wd := range withdrawals {
-					if err := s.cacheToken(wd); err != nil {
-						logger.Warn("error caching token", "err", err)
-					}
-				}
-
 				withdrawalsByBlockHash[blockHash] = append(withdrawalsByBlockHash[blockHash], withdrawals...)
 			}
 		case err := <-errCh:
-			return err
+			return nil, err
 		}
 
 		receives++
@@ -258,37 +397,88 @@ func (s *Service) Update(newHeader *types.Header) error {
 		}
 	}
 
+	var sentMessagesByBlockHash bridge.SentMessagesMap
+	var relayedMessagesByBlockHash bridge.RelayedMessagesMap
+	var tokenPairsByBlockHash bridge.TokenPairsMap
+	var contractEventsByBlockHash events.ContractEventsMap
+	for receives := 0; receives < 4; receives++ {
+		select {
+		case sentMessagesByBlockHash = <-sentMessagesCh:
+		case relayedMessagesByBlockHash = <-relayedMessagesCh:
+		case tokenPairsByBlockHash = <-tokenPairsCh:
+		case contractEventsByBlockHash = <-contractEventsCh:
+		case err := <-errCh:
+			return nil, err
+		}
+	}
+
+	var blocks []*db.IndexedL2Block
 	for i, header := range headers {
 		blockHash := header.Hash()
-		number := header.Number.Uint64()
 		withdrawals := withdrawalsByBlockHash[blockHash]
+		sentMessages := sentMessagesByBlockHash[blockHash]
+		relayedMessages := relayedMessagesByBlockHash[blockHash]
+		tokenPairs := tokenPairsByBlockHash[blockHash]
+		contractEvents := contractEventsByBlockHash[blockHash]
 
-		if len(withdrawals) == 0 && i != len(headers)-1 {
+		if len(withdrawals) == 0 && len(sentMessages) == 0 && len(relayedMessages) == 0 &&
+			len(tokenPairs) == 0 && len(contractEvents) == 0 && i != len(headers)-1 {
 			continue
 		}
 
-		block := &db.IndexedL2Block{
-			Hash:        blockHash,
-			ParentHash:  header.ParentHash,
-			Number:      number,
-			Timestamp:   header.Time,
-			Withdrawals: withdrawals,
+		blocks = append(blocks, &db.IndexedL2Block{
+			Hash:            blockHash,
+			ParentHash:      header.ParentHash,
+			Number:          header.Number.Uint64(),
+			Timestamp:       header.Time,
+			Withdrawals:     withdrawals,
+			SentMessages:    sentMessages,
+			RelayedMessages: relayedMessages,
+			TokenPairs:      tokenPairs,
+			ContractEvents:  contractEvents,
+		})
+	}
+	return blocks, nil
+}
+
+// storeBlocks stores blocks returned by indexRange in order, after caching
+// the tokens of their withdrawals.
+func (s *Service) storeBlocks(blocks []*db.IndexedL2Block) error {
+	for _, block := range blocks {
+		for _, wd := range block.Withdrawals {
+			if err := s.cacheToken(wd); err != nil {
+				logger.Warn("error caching token", "err", err)
+			}
 		}
 
-		err := s.cfg.DB.AddIndexedL2Block(block)
+		// The webhook deliveries of the events of the block are logged
+		// with it, and the events are published once it is stored.
+		var events []notify.Event
+		var hooks []db.BlockHook
+		if s.cfg.Notifier != nil {
+			hooks = append(hooks, func(tx *db.Tx) error {
+				var err error
+				events, err = s.cfg.Notifier.LogL2Block(tx, block)
+				return err
+			})
+		}
+		err := s.cfg.DB.AddIndexedL2Block(block, hooks...)
 		if err != nil {
 			logger.Error(
 				"Unable to import ",
-				"block", number,
-				"hash", blockHash,
+				"block", block.Number,
+				"hash", block.Hash,
 				"err", err,
 				"block", block,
 			)
 			return err
 		}
+		if s.cfg.Notifier != nil {
+			s.cfg.Notifier.Publish(events)
+		}
 
 		logger.Debug("Imported ",
-			"block", number, "hash", blockHash, "withdrawals", len(block.Withdrawals))
+			"block", block.Number, "hash", block.Hash, "withdrawals", len(block.Withdrawals))
 		for _, withdrawal := range block.Withdrawals {
 			token := s.tokenCache[withdrawal.L2Token]
 			logger.Info(
@@ -300,14 +490,6 @@ func (s *Service) Update(newHeader *types.Header) error {
 			s.metrics.RecordWithdrawal(withdrawal.L2Token)
 		}
 	}
-
-	newHeaderNumber := newHeader.Number.Uint64()
-	s.metrics.SetL2SyncHeight(endHeight)
-	s.metrics.SetL2SyncPercent(endHeight, newHeaderNumber)
-	latestHeaderNumber := headers[len(headers)-1].Number.Uint64()
-	if latestHeaderNumber+s.cfg.ConfDepth-1 == newHeaderNumber {
-		return errNoNewBlocks
-	}
 	return nil
 }
 
@@ -369,13 +551,18 @@ func (s *Service) GetWithdrawals(w http.ResponseWriter, r *http.Request) {
 	}
 
 	finalizationState := db.ParseFinalizationState(r.URL.Query().Get("finalized"))
+	status, err := db.ParseWithdrawalStatus(r.URL.Query().Get("status"))
+	if err != nil {
+		server.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 
 	page := db.PaginationParam{
 		Limit:  limit,
 		Offset: offset,
 	}
 
-	withdrawals, err := s.cfg.DB.GetWithdrawalsByAddress(common.HexToAddress(vars["address"]), page, finalizationState)
+	withdrawals, err := s.cfg.DB.GetWithdrawalsByAddress(common.HexToAddress(vars["address"]), page, finalizationState, status)
 	if err != nil {
 		server.RespondWithError(w, http.StatusInternalServerError, err.Error())
 		return
@@ -407,6 +594,27 @@ func (s *Service) catchUp() error {
 	logger.Info("chain is far behind head, resyncing")
 	s.metrics.SetL2CatchingUp(true)
 
+	if s.backfill != nil {
+		// The hash of the start block isn't known until a block is indexed.
+		last := db.BlockLocator{Number: s.cfg.StartBlockNumber}
+		if currHead != nil {
+			last = *currHead
+		}
+		// Backfill up to the highest confirmed block, as selected by the
+		// header selector.
+		if err := s.backfill.Run(s.ctx, last, realHeadNum-s.cfg.ConfDepth+1); err != nil {
+			if s.ctx.Err() != nil {
+				return s.ctx.Err()
+			}
+			return fmt.Errorf("error backfilling: %w", err)
+		}
+		currHead, err = s.cfg.DB.GetHighestL2Block()
+		if err != nil {
+			return err
+		}
+		currHeadNum = currHead.Number
+	}
+
 	for realHeadNum-s.cfg.ConfDepth > currHeadNum+s.cfg.MaxHeaderBatchSize {
 		select {
 		case <-s.ctx.Done():
@@ -446,6 +654,8 @@ func (s *Service) cacheToken(withdrawal db.Withdrawal) error {
 	if err != nil {
 		logger.Error("Error querying ERC20 token details",
 			"l2_token", withdrawal.L2Token.String(), "err", err)
+	}
+	if token == nil {
 		token = &db.Token{
 			Address: withdrawal.L2Token.String(),
 		}
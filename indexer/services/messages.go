@@ -0,0 +1,106 @@
+package services
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/ethereum-optimism/optimism/indexer/db"
+	"github.com/ethereum-optimism/optimism/indexer/server"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+var messagesLogger = log.New("service", "messages")
+
+// Messages serves the cross domain messages sent in both directions with
+// their relay status, so that failed messages can be found and replayed.
+type Messages struct {
+	db *db.Database
+}
+
+func NewMessages(db *db.Database) *Messages {
+	return &Messages{
+		db: db,
+	}
+}
+
+func (m *Messages) GetMessages(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	limitStr := query.Get("limit")
+	limit, err := strconv.ParseUint(limitStr, 10, 64)
+	if err != nil && limitStr != "" {
+		server.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if limit == 0 {
+		limit = 10
+	}
+
+	offsetStr := query.Get("offset")
+	offset, err := strconv.ParseUint(offsetStr, 10, 64)
+	if err != nil && offsetStr != "" {
+		server.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	filter, err := parseMessageFilter(query)
+	if err != nil {
+		server.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	page := db.PaginationParam{
+		Limit:  limit,
+		Offset: offset,
+	}
+
+	msgs, err := m.db.GetMessages(filter, page)
+	if err != nil {
+		messagesLogger.Error("db error getting messages", "err", err)
+		server.RespondWithError(w, http.StatusInternalServerError, "database error")
+		return
+	}
+
+	server.RespondWithJSON(w, http.StatusOK, msgs)
+}
+
+// parseMessageFilter parses the chain, sender, target and status query
+// parameters. The chain is the chain the messages are sent from.
+func parseMessageFilter(query url.Values) (db.MessageFilter, error) {
+	filter := db.MessageFilter{
+		Chain: query.Get("chain"),
+	}
+
+	if filter.Chain != "" && filter.Chain != db.ChainL1 && filter.Chain != db.ChainL2 {
+		return filter, errors.New("chain must be l1 or l2")
+	}
+
+	for _, param := range []struct {
+		name string
+		dst  **common.Address
+	}{
+		{"sender", &filter.Sender},
+		{"target", &filter.Target},
+	} {
+		value := query.Get(param.name)
+		if value == "" {
+			continue
+		}
+		if !common.IsHexAddress(value) {
+			return filter, errors.New("invalid " + param.name + " address")
+		}
+		address := common.HexToAddress(value)
+		*param.dst = &address
+	}
+
+	status, err := db.ParseMessageStatus(query.Get("status"))
+	if err != nil {
+		return filter, err
+	}
+	filter.Status = status
+
+	return filter, nil
+}
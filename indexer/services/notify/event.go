@@ -0,0 +1,135 @@
+// Package notify publishes the bridge events of the indexed blocks to
+// websocket subscribers and webhooks.
+package notify
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ethereum-optimism/optimism/indexer/db"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// EventType is the type of a bridge event.
+type EventType string
+
+const (
+	// EventDepositInitiated is published when a deposit is indexed on L1.
+	EventDepositInitiated EventType = "deposit_initiated"
+	// EventDepositRelayed is published when the message of a bedrock
+	// deposit is relayed on L2.
+	EventDepositRelayed EventType = "deposit_relayed"
+	// EventWithdrawalInitiated is published when a withdrawal is indexed on
+	// L2.
+	EventWithdrawalInitiated EventType = "withdrawal_initiated"
+	// EventWithdrawalProven is published when a bedrock withdrawal is
+	// proven on L1.
+	EventWithdrawalProven EventType = "withdrawal_proven"
+	// EventWithdrawalFinalized is published when a bedrock withdrawal is
+	// finalized on L1.
+	EventWithdrawalFinalized EventType = "withdrawal_finalized"
+)
+
+// EventTypes lists the types of the published events.
+var EventTypes = []EventType{
+	EventDepositInitiated,
+	EventDepositRelayed,
+	EventWithdrawalInitiated,
+	EventWithdrawalProven,
+	EventWithdrawalFinalized,
+}
+
+// ParseEventType parses the name of an event type.
+func ParseEventType(in string) (EventType, error) {
+	for _, t := range EventTypes {
+		if string(t) == in {
+			return t, nil
+		}
+	}
+	return "", fmt.Errorf("unknown event type %q", in)
+}
+
+// Event is a bridge event. It holds the deposit or the withdrawal it relates
+// to, as served by the REST API.
+type Event struct {
+	// ID identifies the event. It is the same every time the event is
+	// published, so that receivers can ignore duplicates.
+	ID   string    `json:"id"`
+	Type EventType `json:"type"`
+	// Chain is the chain of the transaction emitting the event, l1 or l2.
+	Chain  string `json:"chain"`
+	TxHash string `json:"transactionHash"`
+
+	Deposit    *db.DepositJSON    `json:"deposit,omitempty"`
+	Withdrawal *db.WithdrawalJSON `json:"withdrawal,omitempty"`
+}
+
+// newDepositEvent returns an event of the deposit, identified by the deposit
+// transaction and log index.
+func newDepositEvent(t EventType, chain, txHash string, deposit db.DepositJSON) Event {
+	return Event{
+		ID:      fmt.Sprintf("%s:%s:%d", t, deposit.TxHash, deposit.LogIndex),
+		Type:    t,
+		Chain:   chain,
+		TxHash:  txHash,
+		Deposit: &deposit,
+	}
+}
+
+// newWithdrawalEvent returns an event of the withdrawal, identified by the
+// withdrawal transaction and log index.
+func newWithdrawalEvent(t EventType, chain, txHash string, withdrawal db.WithdrawalJSON) Event {
+	return Event{
+		ID:         fmt.Sprintf("%s:%s:%d", t, withdrawal.TxHash, withdrawal.LogIndex),
+		Type:       t,
+		Chain:      chain,
+		TxHash:     txHash,
+		Withdrawal: &withdrawal,
+	}
+}
+
+// addresses returns the sender and the recipient of the transfer of the
+// event.
+func (e *Event) addresses() (string, string) {
+	if e.Deposit != nil {
+		return e.Deposit.FromAddress, e.Deposit.ToAddress
+	}
+	if e.Withdrawal != nil {
+		return e.Withdrawal.FromAddress, e.Withdrawal.ToAddress
+	}
+	return "", ""
+}
+
+// Filter selects events. Empty fields match all events.
+type Filter struct {
+	// Addresses match the sender or the recipient of the transfer.
+	Addresses []common.Address
+	Types     []EventType
+}
+
+// Match returns whether the filter selects the event.
+func (f *Filter) Match(e *Event) bool {
+	if len(f.Types) > 0 {
+		var ok bool
+		for _, t := range f.Types {
+			if t == e.Type {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+
+	if len(f.Addresses) == 0 {
+		return true
+	}
+	from, to := e.addresses()
+	for _, address := range f.Addresses {
+		if strings.EqualFold(address.String(), from) || strings.EqualFold(address.String(), to) {
+			return true
+		}
+	}
+	return false
+}
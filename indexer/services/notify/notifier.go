@@ -0,0 +1,251 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ethereum-optimism/optimism/indexer/db"
+	"github.com/ethereum-optimism/optimism/indexer/metrics"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+var logger = log.New("service", "notify")
+
+// deliveryInterval is the interval of the polls of the due webhook
+// deliveries.
+const deliveryInterval = time.Second
+
+// Tx reads the indexed transfers and logs the webhook deliveries in the
+// transaction storing a block. It is implemented by *db.Tx.
+type Tx interface {
+	GetDeposits(filter db.TransferFilter, page db.CursorParam) ([]db.DepositJSON, bool, error)
+	GetWithdrawals(filter db.TransferFilter, page db.CursorParam) ([]db.WithdrawalJSON, bool, error)
+	GetWithdrawalByHash(hash common.Hash) (*db.WithdrawalJSON, error)
+	AddWebhookDeliveries(deliveries []db.WebhookDelivery) error
+}
+
+// Store reads and updates the logged webhook deliveries. It is implemented by
+// *db.Database.
+type Store interface {
+	GetDueWebhookDeliveries(webhook string, now, limit uint64) ([]db.WebhookDelivery, error)
+	FailUnknownWebhookDeliveries(webhooks []string, lastError string) error
+	UpdateWebhookDelivery(delivery *db.WebhookDelivery) error
+}
+
+// Notifier publishes the bridge events of the stored blocks to websocket
+// subscribers, and logs their deliveries to the configured webhooks, which it
+// attempts in the background until they succeed. Deliveries are logged in
+// the transaction storing their block, so that none is lost if the indexer
+// stops. Events are published at least once: an event can be published again
+// if its block is reorged out and indexed again, with the same ID.
+type Notifier struct {
+	ctx    context.Context
+	cancel func()
+	store  Store
+	hub    *hub
+	client *http.Client
+
+	// webhooks are the configured webhooks keyed on their name.
+	webhooks map[string]*WebhookConfig
+	metrics  *metrics.Metrics
+	wg       sync.WaitGroup
+}
+
+// New returns a Notifier of the events of the blocks stored in store. cfg
+// configures the webhooks and may be nil.
+func New(ctx context.Context, store Store, cfg *Config, m *metrics.Metrics) *Notifier {
+	ctx, cancel := context.WithCancel(ctx)
+	webhooks := make(map[string]*WebhookConfig)
+	if cfg != nil {
+		for i := range cfg.Webhooks {
+			webhooks[cfg.Webhooks[i].Name] = &cfg.Webhooks[i]
+		}
+	}
+
+	return &Notifier{
+		ctx:      ctx,
+		cancel:   cancel,
+		store:    store,
+		hub:      newHub(),
+		client:   &http.Client{Timeout: deliveryTimeout},
+		webhooks: webhooks,
+		metrics:  m,
+	}
+}
+
+// Start starts delivering the logged webhook deliveries.
+func (n *Notifier) Start() {
+	if len(n.webhooks) == 0 {
+		return
+	}
+	n.wg.Add(1)
+	go n.deliverLoop(deliveryInterval)
+}
+
+// Stop stops the deliveries and disconnects the subscribers.
+func (n *Notifier) Stop() {
+	n.cancel()
+	n.wg.Wait()
+}
+
+// active returns whether any subscriber or webhook receives events.
+func (n *Notifier) active() bool {
+	return len(n.webhooks) > 0 || n.hub.len() > 0
+}
+
+// LogL1Block returns the events of an L1 block stored in tx, and logs their
+// webhook deliveries in tx: the deposits it initiates, the withdrawals it
+// proves or finalizes, and the deposits it initiates which are already
+// relayed on L2. The events are sent to the websocket subscribers by Publish,
+// once tx is committed.
+func (n *Notifier) LogL1Block(tx Tx, block *db.IndexedL1Block) ([]Event, error) {
+	if !n.active() {
+		return nil, nil
+	}
+
+	var events []Event
+	if len(block.Deposits) > 0 {
+		deposits, _, err := tx.GetDeposits(
+			db.TransferFilter{BlockHash: &block.Hash},
+			db.CursorParam{First: uint64(len(block.Deposits))},
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error fetching deposits: %w", err)
+		}
+		for _, deposit := range deposits {
+			events = append(events, newDepositEvent(EventDepositInitiated, db.ChainL1, deposit.TxHash, deposit))
+			// The L2 block relaying the deposit can be indexed before the
+			// L1 block, which waits for more confirmations.
+			if deposit.RelayedTxHash != nil {
+				events = append(events, newDepositEvent(EventDepositRelayed, db.ChainL2, *deposit.RelayedTxHash, deposit))
+			}
+		}
+	}
+
+	for _, proven := range block.ProvenWithdrawals {
+		withdrawal, err := tx.GetWithdrawalByHash(proven.WithdrawalHash)
+		if err != nil {
+			return nil, fmt.Errorf("error fetching withdrawal %s: %w", proven.WithdrawalHash, err)
+		}
+		if withdrawal != nil {
+			events = append(events, newWithdrawalEvent(EventWithdrawalProven, db.ChainL1, proven.TxHash.String(), *withdrawal))
+		}
+	}
+	for _, finalized := range block.FinalizedWithdrawals {
+		withdrawal, err := tx.GetWithdrawalByHash(finalized.WithdrawalHash)
+		if err != nil {
+			return nil, fmt.Errorf("error fetching withdrawal %s: %w", finalized.WithdrawalHash, err)
+		}
+		if withdrawal != nil {
+			events = append(events, newWithdrawalEvent(EventWithdrawalFinalized, db.ChainL1, finalized.TxHash.String(), *withdrawal))
+		}
+	}
+
+	if err := n.logDeliveries(tx, events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// LogL2Block returns the events of an L2 block stored in tx, and logs their
+// webhook deliveries in tx: the withdrawals it initiates and the deposits it
+// relays. The events are sent to the websocket subscribers by Publish, once
+// tx is committed.
+func (n *Notifier) LogL2Block(tx Tx, block *db.IndexedL2Block) ([]Event, error) {
+	if !n.active() {
+		return nil, nil
+	}
+
+	var events []Event
+	if len(block.Withdrawals) > 0 {
+		withdrawals, _, err := tx.GetWithdrawals(
+			db.TransferFilter{BlockHash: &block.Hash},
+			db.CursorParam{First: uint64(len(block.Withdrawals))},
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error fetching withdrawals: %w", err)
+		}
+		for _, withdrawal := range withdrawals {
+			events = append(events, newWithdrawalEvent(EventWithdrawalInitiated, db.ChainL2, withdrawal.TxHash, withdrawal))
+		}
+	}
+
+	for _, msg := range block.RelayedMessages {
+		// Failed relays can be replayed, so only successful relays are
+		// published.
+		if msg.Failed {
+			continue
+		}
+		hash := msg.MessageHash
+		deposits, _, err := tx.GetDeposits(db.TransferFilter{MessageHash: &hash}, db.CursorParam{First: 1})
+		if err != nil {
+			return nil, fmt.Errorf("error fetching deposit of message %s: %w", hash, err)
+		}
+		// Messages which don't relay deposits, or whose L1 block isn't
+		// indexed yet, are skipped. The latter are published with the L1
+		// block.
+		if len(deposits) == 0 {
+			continue
+		}
+		events = append(events, newDepositEvent(EventDepositRelayed, db.ChainL2, msg.TxHash.String(), deposits[0]))
+	}
+
+	if err := n.logDeliveries(tx, events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// Publish sends the events of a stored block to the websocket subscribers.
+func (n *Notifier) Publish(events []Event) {
+	for _, event := range events {
+		n.hub.publish(event)
+	}
+}
+
+// logDeliveries logs the deliveries of the events to the webhooks in tx.
+func (n *Notifier) logDeliveries(tx Tx, events []Event) error {
+	if len(events) == 0 || len(n.webhooks) == 0 {
+		return nil
+	}
+
+	now := uint64(time.Now().Unix())
+	var deliveries []db.WebhookDelivery
+	for i := range events {
+		event := &events[i]
+		var payload []byte
+		for _, webhook := range n.webhooks {
+			filter := webhook.filter()
+			if !filter.Match(event) {
+				continue
+			}
+			if payload == nil {
+				var err error
+				if payload, err = json.Marshal(event); err != nil {
+					return fmt.Errorf("error encoding event %s: %w", event.ID, err)
+				}
+			}
+			deliveries = append(deliveries, db.WebhookDelivery{
+				Webhook:       webhook.Name,
+				EventID:       event.ID,
+				EventType:     string(event.Type),
+				Payload:       payload,
+				NextAttemptAt: now,
+				CreatedAt:     now,
+			})
+		}
+	}
+
+	if len(deliveries) == 0 {
+		return nil
+	}
+	if err := tx.AddWebhookDeliveries(deliveries); err != nil {
+		return fmt.Errorf("error logging webhook deliveries: %w", err)
+	}
+	return nil
+}
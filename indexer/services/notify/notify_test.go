@@ -0,0 +1,437 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum-optimism/optimism/indexer/db"
+	"github.com/ethereum-optimism/optimism/indexer/metrics"
+	"github.com/ethereum-optimism/optimism/indexer/server"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+// testMetrics is shared by the tests, as metrics are registered globally.
+var testMetrics = metrics.NewMetrics(nil)
+
+func TestFilter(t *testing.T) {
+	sender := common.HexToAddress("0x01")
+	deposit := newDepositEvent(EventDepositInitiated, db.ChainL1, "0x11", db.DepositJSON{
+		FromAddress: sender.String(),
+		ToAddress:   common.HexToAddress("0x02").String(),
+	})
+
+	require.True(t, (&Filter{}).Match(&deposit))
+	require.True(t, (&Filter{Addresses: []common.Address{sender}}).Match(&deposit))
+	require.True(t, (&Filter{Types: []EventType{EventWithdrawalProven, EventDepositInitiated}}).Match(&deposit))
+	require.False(t, (&Filter{Types: []EventType{EventDepositRelayed}}).Match(&deposit))
+	require.False(t, (&Filter{Addresses: []common.Address{common.HexToAddress("0x03")}}).Match(&deposit))
+	require.False(t, (&Filter{
+		Addresses: []common.Address{sender},
+		Types:     []EventType{EventWithdrawalInitiated},
+	}).Match(&deposit))
+}
+
+func TestConfigValidate(t *testing.T) {
+	valid := WebhookConfig{Name: "app", URL: "https://example.com/hook", Secret: "secret"}
+	require.NoError(t, (&Config{Webhooks: []WebhookConfig{valid}}).Validate())
+
+	for name, modify := range map[string]func(w *WebhookConfig){
+		"missing name":   func(w *WebhookConfig) { w.Name = "" },
+		"invalid url":    func(w *WebhookConfig) { w.URL = "example.com" },
+		"missing secret": func(w *WebhookConfig) { w.Secret = "" },
+		"unknown event":  func(w *WebhookConfig) { w.Events = []EventType{"deposit_finalized"} },
+	} {
+		webhook := valid
+		modify(&webhook)
+		require.Error(t, (&Config{Webhooks: []WebhookConfig{webhook}}).Validate(), name)
+	}
+	require.Error(t, (&Config{Webhooks: []WebhookConfig{valid, valid}}).Validate(), "duplicate name")
+}
+
+func TestRetryDelay(t *testing.T) {
+	require.Equal(t, 10*time.Second, retryDelay(1))
+	require.Equal(t, 20*time.Second, retryDelay(2))
+	require.Equal(t, 80*time.Second, retryDelay(4))
+	require.Equal(t, time.Hour, retryDelay(20))
+}
+
+// webhookServer records the requests to a webhook, responding with status.
+type webhookServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	status   int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func newWebhookServer(t *testing.T, status int) *webhookServer {
+	s := &webhookServer{status: status}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		s.mu.Lock()
+		s.requests = append(s.requests, r)
+		s.bodies = append(s.bodies, body)
+		s.mu.Unlock()
+		w.WriteHeader(s.status)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func newTestStore(t *testing.T) *db.Database {
+	d, err := db.NewSQLiteDatabase(filepath.Join(t.TempDir(), "indexer.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { d.Close() })
+	return d
+}
+
+// storeL1Block stores the block, logging and publishing its events like the
+// L1 service.
+func storeL1Block(t *testing.T, store *db.Database, n *Notifier, block *db.IndexedL1Block) {
+	var events []Event
+	require.NoError(t, store.AddIndexedL1Block(block, func(tx *db.Tx) error {
+		var err error
+		events, err = n.LogL1Block(tx, block)
+		return err
+	}))
+	n.Publish(events)
+}
+
+func storeL2Block(t *testing.T, store *db.Database, n *Notifier, block *db.IndexedL2Block) {
+	var events []Event
+	require.NoError(t, store.AddIndexedL2Block(block, func(tx *db.Tx) error {
+		var err error
+		events, err = n.LogL2Block(tx, block)
+		return err
+	}))
+	n.Publish(events)
+}
+
+// dueDeliveries returns the deliveries to the webhooks due at now.
+func dueDeliveries(t *testing.T, store *db.Database, now time.Time, webhooks ...string) []db.WebhookDelivery {
+	var due []db.WebhookDelivery
+	for _, webhook := range webhooks {
+		deliveries, err := store.GetDueWebhookDeliveries(webhook, uint64(now.Unix()), 10)
+		require.NoError(t, err)
+		due = append(due, deliveries...)
+	}
+	return due
+}
+
+func receive(t *testing.T, sub *subscription) Event {
+	select {
+	case e := <-sub.events:
+		return e
+	case <-time.After(time.Second):
+		t.Fatal("no event received")
+		return Event{}
+	}
+}
+
+func TestNotifier(t *testing.T) {
+	store := newTestStore(t)
+	hook := newWebhookServer(t, http.StatusOK)
+	failing := newWebhookServer(t, http.StatusInternalServerError)
+	sender := common.HexToAddress("0x01")
+	n := New(context.Background(), store, &Config{Webhooks: []WebhookConfig{
+		{Name: "deposits", URL: hook.URL, Secret: "secret", Events: []EventType{EventDepositInitiated, EventDepositRelayed}},
+		{Name: "failing", URL: failing.URL, Secret: "secret", Addresses: []common.Address{sender}, Events: []EventType{EventWithdrawalInitiated}},
+		{Name: "other", URL: hook.URL, Secret: "secret", Addresses: []common.Address{common.HexToAddress("0x02")}},
+	}}, testMetrics)
+	defer n.Stop()
+	sub := n.hub.subscribe(Filter{})
+
+	msgHash := common.HexToHash("0x99")
+	l1Block := &db.IndexedL1Block{
+		Hash:      common.HexToHash("0x11"),
+		Number:    1,
+		Timestamp: 100,
+		Deposits: []db.Deposit{{
+			GUID:        "deposit",
+			TxHash:      common.HexToHash("0x12"),
+			L1Token:     db.ETHL1Address,
+			L2Token:     common.HexToAddress(db.ETHL2Token.Address),
+			FromAddress: sender,
+			ToAddress:   sender,
+			Amount:      big.NewInt(10),
+			Data:        []byte{},
+			LogIndex:    3,
+			MessageHash: &msgHash,
+		}},
+	}
+	storeL1Block(t, store, n, l1Block)
+
+	e := receive(t, sub)
+	require.Equal(t, EventDepositInitiated, e.Type)
+	require.Equal(t, "deposit_initiated:"+l1Block.Deposits[0].TxHash.String()+":3", e.ID)
+	require.Equal(t, db.ChainL1, e.Chain)
+	require.Equal(t, "10", e.Deposit.Amount)
+	require.Nil(t, e.Deposit.RelayedTxHash)
+
+	relayTx := common.HexToHash("0x22")
+	l2Block := &db.IndexedL2Block{
+		Hash:      common.HexToHash("0x21"),
+		Number:    1,
+		Timestamp: 110,
+		Withdrawals: []db.Withdrawal{{
+			GUID:        "withdrawal",
+			TxHash:      common.HexToHash("0x23"),
+			L1Token:     db.ETHL1Address,
+			L2Token:     common.HexToAddress(db.ETHL2Token.Address),
+			FromAddress: sender,
+			ToAddress:   sender,
+			Amount:      big.NewInt(20),
+			Data:        []byte{},
+		}},
+		RelayedMessages: []db.RelayedMessage{
+			{MessageHash: msgHash, TxHash: relayTx, LogIndex: 1},
+			// A message not relaying an indexed deposit is skipped.
+			{MessageHash: common.HexToHash("0x98"), TxHash: relayTx, LogIndex: 2},
+		},
+	}
+	storeL2Block(t, store, n, l2Block)
+
+	e = receive(t, sub)
+	require.Equal(t, EventWithdrawalInitiated, e.Type)
+	require.Equal(t, "20", e.Withdrawal.Amount)
+	e = receive(t, sub)
+	require.Equal(t, EventDepositRelayed, e.Type)
+	require.Equal(t, db.ChainL2, e.Chain)
+	require.Equal(t, relayTx.String(), e.TxHash)
+	require.Equal(t, relayTx.String(), *e.Deposit.RelayedTxHash)
+	require.Empty(t, sub.events)
+
+	deliveries := dueDeliveries(t, store, time.Now(), "deposits", "failing", "other")
+	require.Len(t, deliveries, 3)
+
+	// The deliveries of a block that fails to be stored aren't logged.
+	failedBlock := &db.IndexedL2Block{
+		Hash:        common.HexToHash("0x31"),
+		ParentHash:  l2Block.Hash,
+		Number:      2,
+		Timestamp:   120,
+		Withdrawals: []db.Withdrawal{l2Block.Withdrawals[0]},
+	}
+	failedBlock.Withdrawals[0].TxHash = common.HexToHash("0x32")
+	err := store.AddIndexedL2Block(failedBlock, func(tx *db.Tx) error {
+		events, err := n.LogL2Block(tx, failedBlock)
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		return errors.New("failed")
+	})
+	require.Error(t, err)
+	deliveries = dueDeliveries(t, store, time.Now(), "deposits", "failing", "other")
+	require.Len(t, deliveries, 3)
+
+	require.NoError(t, n.deliverDue())
+	hook.mu.Lock()
+	defer hook.mu.Unlock()
+	require.Len(t, hook.requests, 2)
+	for i, req := range hook.requests {
+		timestamp, err := strconv.ParseInt(req.Header.Get(HeaderTimestamp), 10, 64)
+		require.NoError(t, err)
+		require.Equal(t, Sign("secret", timestamp, hook.bodies[i]), req.Header.Get(HeaderSignature))
+		var e Event
+		require.NoError(t, json.Unmarshal(hook.bodies[i], &e))
+		require.Equal(t, req.Header.Get(HeaderDelivery), e.ID)
+		require.Equal(t, req.Header.Get(HeaderEvent), string(e.Type))
+		require.True(t, strings.HasPrefix(e.ID, string(e.Type)))
+	}
+	failing.mu.Lock()
+	defer failing.mu.Unlock()
+	require.Len(t, failing.requests, 1)
+	require.Equal(t, string(EventWithdrawalInitiated), failing.requests[0].Header.Get(HeaderEvent))
+
+	// The failed delivery is retried later.
+	require.Empty(t, dueDeliveries(t, store, time.Now(), "deposits", "failing", "other"))
+	deliveries = dueDeliveries(t, store, time.Now().Add(time.Minute), "deposits", "failing", "other")
+	require.Len(t, deliveries, 1)
+	require.Equal(t, "failing", deliveries[0].Webhook)
+	require.Equal(t, uint64(1), deliveries[0].Attempts)
+	require.Equal(t, http.StatusInternalServerError, *deliveries[0].ResponseStatus)
+	require.NotNil(t, deliveries[0].LastError)
+}
+
+func TestDeliverDue(t *testing.T) {
+	store := newTestStore(t)
+	hook := newWebhookServer(t, http.StatusOK)
+	failing := newWebhookServer(t, http.StatusInternalServerError)
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer slow.Close()
+	n := New(context.Background(), store, &Config{Webhooks: []WebhookConfig{
+		{Name: "hook", URL: hook.URL, Secret: "secret"},
+		{Name: "failing", URL: failing.URL, Secret: "secret"},
+		{Name: "slow", URL: slow.URL, Secret: "secret"},
+	}}, testMetrics)
+	defer n.Stop()
+
+	now := uint64(time.Now().Unix())
+	var deliveries []db.WebhookDelivery
+	for _, webhook := range []string{"slow", "failing", "hook"} {
+		for i := 0; i < 2; i++ {
+			deliveries = append(deliveries, db.WebhookDelivery{
+				Webhook:       webhook,
+				EventID:       strconv.Itoa(i),
+				EventType:     string(EventDepositInitiated),
+				Payload:       []byte("{}"),
+				NextAttemptAt: now,
+				CreatedAt:     now,
+			})
+		}
+	}
+	require.NoError(t, store.AddWebhookDeliveries(deliveries))
+
+	errC := make(chan error, 1)
+	go func() {
+		errC <- n.deliverDue()
+	}()
+
+	// The deliveries to the other webhooks don't wait for the slow one.
+	require.Eventually(t, func() bool {
+		hook.mu.Lock()
+		defer hook.mu.Unlock()
+		return len(hook.requests) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	close(release)
+	require.NoError(t, <-errC)
+
+	// The deliveries to a webhook after a failed one wait for the next poll.
+	failing.mu.Lock()
+	require.Len(t, failing.requests, 1)
+	failing.mu.Unlock()
+	due := dueDeliveries(t, store, time.Now(), "hook", "failing", "slow")
+	require.Len(t, due, 1)
+	require.Equal(t, "failing", due[0].Webhook)
+	require.Zero(t, due[0].Attempts)
+}
+
+func TestDeliverDueBacklog(t *testing.T) {
+	store := newTestStore(t)
+	hook := newWebhookServer(t, http.StatusOK)
+	dead := newWebhookServer(t, http.StatusInternalServerError)
+	n := New(context.Background(), store, &Config{Webhooks: []WebhookConfig{
+		{Name: "hook", URL: hook.URL, Secret: "secret"},
+		{Name: "dead", URL: dead.URL, Secret: "secret"},
+	}}, testMetrics)
+	defer n.Stop()
+
+	// The backlog of the dead webhook is older than the delivery to the
+	// healthy one and bigger than a batch.
+	now := uint64(time.Now().Unix())
+	var deliveries []db.WebhookDelivery
+	for i := 0; i < deliveryBatchSize+10; i++ {
+		deliveries = append(deliveries, db.WebhookDelivery{
+			Webhook:       "dead",
+			EventID:       strconv.Itoa(i),
+			EventType:     string(EventDepositInitiated),
+			Payload:       []byte("{}"),
+			NextAttemptAt: now - 100,
+			CreatedAt:     now - 100,
+		})
+	}
+	removed := db.WebhookDelivery{
+		Webhook:       "removed",
+		EventID:       "0",
+		EventType:     string(EventDepositInitiated),
+		Payload:       []byte("{}"),
+		NextAttemptAt: now - 100,
+		CreatedAt:     now - 100,
+	}
+	healthy := removed
+	healthy.Webhook = "hook"
+	healthy.NextAttemptAt = now
+	healthy.CreatedAt = now
+	deliveries = append(deliveries, removed, healthy)
+	require.NoError(t, store.AddWebhookDeliveries(deliveries))
+
+	require.NoError(t, n.deliverDue())
+	hook.mu.Lock()
+	require.Len(t, hook.requests, 1)
+	hook.mu.Unlock()
+	dead.mu.Lock()
+	require.Len(t, dead.requests, 1)
+	dead.mu.Unlock()
+
+	// The deliveries to the removed webhook are failed.
+	require.Empty(t, dueDeliveries(t, store, time.Now(), "removed", "hook"))
+}
+
+func TestNotifierInactive(t *testing.T) {
+	// Without subscribers or webhooks, the store isn't queried.
+	n := New(context.Background(), nil, nil, testMetrics)
+	defer n.Stop()
+	events, err := n.LogL1Block(nil, &db.IndexedL1Block{Deposits: []db.Deposit{{}}})
+	require.NoError(t, err)
+	require.Empty(t, events)
+	events, err = n.LogL2Block(nil, &db.IndexedL2Block{Withdrawals: []db.Withdrawal{{}}})
+	require.NoError(t, err)
+	require.Empty(t, events)
+}
+
+func TestHubDropsSlowSubscribers(t *testing.T) {
+	h := newHub()
+	slow := h.subscribe(Filter{})
+	other := h.subscribe(Filter{Types: []EventType{EventDepositRelayed}})
+	for i := 0; i <= subscriptionBuffer; i++ {
+		h.publish(Event{Type: EventDepositInitiated})
+	}
+	require.True(t, h.isDropped(slow))
+	require.False(t, h.isDropped(other))
+	require.Equal(t, 1, h.len())
+
+	// Unsubscribing a dropped subscriber doesn't close its queue twice.
+	h.unsubscribe(slow)
+	h.unsubscribe(other)
+	require.Equal(t, 0, h.len())
+}
+
+func TestServeWebsocket(t *testing.T) {
+	n := New(context.Background(), nil, nil, testMetrics)
+	defer n.Stop()
+	handler := server.LoggingMiddleware(testMetrics, log.New())(http.HandlerFunc(n.ServeWebsocket))
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+	url := "ws" + strings.TrimPrefix(srv.URL, "http")
+
+	resp, err := http.Get(srv.URL + "?address=0xinvalid")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	sender := common.HexToAddress("0x01")
+	conn, _, err := websocket.DefaultDialer.Dial(url+"?type=deposit_initiated,deposit_relayed&address="+sender.String(), nil)
+	require.NoError(t, err)
+	defer conn.Close()
+	require.Eventually(t, func() bool { return n.hub.len() == 1 }, time.Second, 10*time.Millisecond)
+
+	deposit := db.DepositJSON{FromAddress: sender.String(), ToAddress: sender.String(), TxHash: "0x12"}
+	n.hub.publish(newWithdrawalEvent(EventWithdrawalInitiated, db.ChainL2, "0x11", db.WithdrawalJSON{FromAddress: sender.String()}))
+	n.hub.publish(newDepositEvent(EventDepositInitiated, db.ChainL1, "0x12", deposit))
+
+	var e Event
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	require.NoError(t, conn.ReadJSON(&e))
+	require.Equal(t, EventDepositInitiated, e.Type)
+	require.Equal(t, "0x12", e.Deposit.TxHash)
+
+	require.NoError(t, conn.Close())
+	require.Eventually(t, func() bool { return n.hub.len() == 0 }, time.Second, 10*time.Millisecond)
+}
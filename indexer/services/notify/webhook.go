@@ -0,0 +1,293 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ethereum-optimism/optimism/indexer/db"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const (
+	// deliveryBatchSize is the maximum number of deliveries attempted per
+	// poll.
+	deliveryBatchSize = 100
+	// deliveryTimeout bounds the time of a delivery attempt.
+	deliveryTimeout = 10 * time.Second
+	// minRetryDelay and maxRetryDelay bound the delay between attempts,
+	// which doubles after each failed attempt.
+	minRetryDelay = 10 * time.Second
+	maxRetryDelay = time.Hour
+	// defaultMaxAttempts is the number of attempts of a delivery before it
+	// is marked failed, unless configured.
+	defaultMaxAttempts = 10
+)
+
+// Headers of webhook requests.
+const (
+	HeaderEvent     = "X-Indexer-Event"
+	HeaderDelivery  = "X-Indexer-Delivery"
+	HeaderTimestamp = "X-Indexer-Timestamp"
+	HeaderSignature = "X-Indexer-Signature"
+)
+
+// Config lists the webhooks receiving events.
+type Config struct {
+	Webhooks []WebhookConfig `json:"webhooks"`
+}
+
+// WebhookConfig is a webhook receiving the events selected by its addresses
+// and events, or all events if both are empty.
+type WebhookConfig struct {
+	// Name identifies the webhook in the delivery log.
+	Name string `json:"name"`
+	URL  string `json:"url"`
+	// Secret signs the requests to the webhook.
+	Secret    string           `json:"secret"`
+	Addresses []common.Address `json:"addresses"`
+	Events    []EventType      `json:"events"`
+	// MaxAttempts is the number of attempts of a delivery before it is
+	// marked failed, 10 if zero.
+	MaxAttempts uint64 `json:"maxAttempts"`
+}
+
+// LoadConfig reads the JSON config at path.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("error parsing webhook config: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// Validate checks that the webhooks are well-formed.
+func (c *Config) Validate() error {
+	names := make(map[string]bool)
+	for _, webhook := range c.Webhooks {
+		if webhook.Name == "" {
+			return errors.New("webhooks must have a name")
+		}
+		if names[webhook.Name] {
+			return fmt.Errorf("duplicate webhook name %s", webhook.Name)
+		}
+		names[webhook.Name] = true
+		u, err := url.Parse(webhook.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("webhook %s: url must be an http or https url", webhook.Name)
+		}
+		if webhook.Secret == "" {
+			return fmt.Errorf("webhook %s: must have a secret", webhook.Name)
+		}
+		for _, t := range webhook.Events {
+			if _, err := ParseEventType(string(t)); err != nil {
+				return fmt.Errorf("webhook %s: %w", webhook.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (w *WebhookConfig) filter() Filter {
+	return Filter{Addresses: w.Addresses, Types: w.Events}
+}
+
+func (w *WebhookConfig) maxAttempts() uint64 {
+	if w.MaxAttempts == 0 {
+		return defaultMaxAttempts
+	}
+	return w.MaxAttempts
+}
+
+// Sign returns the signature of a webhook request, the hex encoded
+// HMAC-SHA256 of the timestamp and the payload joined by a dot, keyed with
+// the secret of the webhook. Receivers should recompute it and reject stale
+// timestamps.
+func Sign(secret string, timestamp int64, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return "v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// retryDelay returns the delay before the next attempt of a delivery which
+// failed attempts times.
+func retryDelay(attempts uint64) time.Duration {
+	delay := minRetryDelay
+	for i := uint64(1); i < attempts && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	return delay
+}
+
+// deliverLoop attempts the due deliveries every interval until the context
+// is canceled.
+func (n *Notifier) deliverLoop(interval time.Duration) {
+	defer n.wg.Done()
+
+	tick := time.NewTicker(interval)
+	defer tick.Stop()
+	for {
+		select {
+		case <-tick.C:
+			if err := n.deliverDue(); err != nil {
+				logger.Error("error delivering webhooks", "err", err)
+			}
+		case <-n.ctx.Done():
+			return
+		}
+	}
+}
+
+// deliverDue attempts the deliveries due now and logs their outcome. The
+// deliveries to each webhook are queried and attempted in order, concurrently
+// with those to the other webhooks. Once an attempt fails, the other
+// deliveries to the webhook are left for the next poll, so that an
+// unresponsive webhook doesn't hold up the others.
+func (n *Notifier) deliverDue() error {
+	names := make([]string, 0, len(n.webhooks))
+	for name := range n.webhooks {
+		names = append(names, name)
+	}
+	// The deliveries to webhooks removed from the config are never attempted.
+	if err := n.store.FailUnknownWebhookDeliveries(names, "webhook not configured"); err != nil {
+		return err
+	}
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	for _, webhook := range n.webhooks {
+		wg.Add(1)
+		go func(webhook *WebhookConfig) {
+			defer wg.Done()
+			if err := n.deliverWebhook(webhook); err != nil {
+				mu.Lock()
+				defer mu.Unlock()
+				if firstErr == nil {
+					firstErr = err
+				}
+			}
+		}(webhook)
+	}
+	wg.Wait()
+	return firstErr
+}
+
+// deliverWebhook attempts the deliveries to the webhook due now in order,
+// until an attempt fails.
+func (n *Notifier) deliverWebhook(webhook *WebhookConfig) error {
+	for {
+		deliveries, err := n.store.GetDueWebhookDeliveries(webhook.Name, uint64(time.Now().Unix()), deliveryBatchSize)
+		if err != nil {
+			return err
+		}
+		for i := range deliveries {
+			if n.ctx.Err() != nil {
+				return nil
+			}
+			ok, err := n.deliver(webhook, &deliveries[i])
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return nil
+			}
+		}
+		if len(deliveries) < deliveryBatchSize {
+			return nil
+		}
+	}
+}
+
+// deliver attempts the delivery to the webhook and logs its outcome. It
+// returns false if the attempt failed.
+func (n *Notifier) deliver(webhook *WebhookConfig, delivery *db.WebhookDelivery) (bool, error) {
+	delivery.Attempts++
+	now := time.Now()
+	status, err := n.post(webhook, delivery, now)
+	if err == nil {
+		delivered := uint64(now.Unix())
+		delivery.Status = db.WebhookDeliveryDelivered
+		delivery.DeliveredAt = &delivered
+		delivery.ResponseStatus = &status
+		delivery.LastError = nil
+		n.metrics.RecordWebhookDelivery(string(db.WebhookDeliveryDelivered))
+		return true, n.store.UpdateWebhookDelivery(delivery)
+	}
+
+	msg := err.Error()
+	delivery.LastError = &msg
+	delivery.ResponseStatus = nil
+	if status != 0 {
+		delivery.ResponseStatus = &status
+	}
+	if delivery.Attempts >= webhook.maxAttempts() {
+		delivery.Status = db.WebhookDeliveryFailed
+		n.metrics.RecordWebhookDelivery(string(db.WebhookDeliveryFailed))
+		logger.Warn("webhook delivery failed", "webhook", webhook.Name, "event", delivery.EventID,
+			"attempts", delivery.Attempts, "err", err)
+	} else {
+		delivery.NextAttemptAt = uint64(now.Add(retryDelay(delivery.Attempts)).Unix())
+		n.metrics.RecordWebhookDelivery("retried")
+		logger.Debug("retrying webhook delivery", "webhook", webhook.Name, "event", delivery.EventID,
+			"attempts", delivery.Attempts, "err", err)
+	}
+	return false, n.store.UpdateWebhookDelivery(delivery)
+}
+
+// post sends the signed payload of the delivery to the webhook and returns
+// the response status, or zero if there was no response. Any status other
+// than 2xx is an error.
+func (n *Notifier) post(webhook *WebhookConfig, delivery *db.WebhookDelivery, now time.Time) (int, error) {
+	ctx, cancel := context.WithTimeout(n.ctx, deliveryTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := now.Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderDelivery, delivery.EventID)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(webhook.Secret, timestamp, delivery.Payload))
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
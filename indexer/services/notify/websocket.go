@@ -0,0 +1,198 @@
+package notify
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum-optimism/optimism/indexer/server"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// subscriptionBuffer is the number of events queued for a subscriber
+	// before it is dropped as too slow.
+	subscriptionBuffer = 64
+	// writeTimeout bounds the time to write a message to a subscriber.
+	writeTimeout = 10 * time.Second
+	// pingInterval is the interval of the pings keeping connections alive.
+	pingInterval = 30 * time.Second
+	// pongTimeout bounds the time to receive a pong after a ping.
+	pongTimeout = pingInterval + writeTimeout
+)
+
+// subscription is a subscriber of the hub, receiving the events selected by
+// its filter.
+type subscription struct {
+	filter Filter
+	events chan Event
+	// dropped is set when the hub drops the subscription because its queue
+	// is full.
+	dropped bool
+}
+
+// hub publishes events to subscribers.
+type hub struct {
+	mu   sync.Mutex
+	subs map[*subscription]struct{}
+}
+
+func newHub() *hub {
+	return &hub{subs: make(map[*subscription]struct{})}
+}
+
+func (h *hub) subscribe(filter Filter) *subscription {
+	sub := &subscription{
+		filter: filter,
+		events: make(chan Event, subscriptionBuffer),
+	}
+	h.mu.Lock()
+	h.subs[sub] = struct{}{}
+	h.mu.Unlock()
+	return sub
+}
+
+// unsubscribe removes the subscription and closes its queue, unless it was
+// already dropped.
+func (h *hub) unsubscribe(sub *subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.subs[sub]; ok {
+		delete(h.subs, sub)
+		close(sub.events)
+	}
+}
+
+// isDropped returns whether the hub dropped the subscription.
+func (h *hub) isDropped(sub *subscription) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return sub.dropped
+}
+
+func (h *hub) len() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subs)
+}
+
+// publish queues the event for the subscribers it matches. Subscribers whose
+// queue is full are dropped rather than blocking the indexer.
+func (h *hub) publish(e Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for sub := range h.subs {
+		if !sub.filter.Match(&e) {
+			continue
+		}
+		select {
+		case sub.events <- e:
+		default:
+			logger.Warn("dropping slow subscriber")
+			sub.dropped = true
+			delete(h.subs, sub)
+			close(sub.events)
+		}
+	}
+}
+
+var upgrader = websocket.Upgrader{
+	// The REST API is served to any origin.
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// parseFilter parses the address and type query parameters of r. Both can be
+// repeated or comma separated.
+func parseFilter(r *http.Request) (Filter, error) {
+	var filter Filter
+	query := r.URL.Query()
+	for _, param := range query["address"] {
+		for _, address := range strings.Split(param, ",") {
+			if !common.IsHexAddress(address) {
+				return filter, fmt.Errorf("invalid address %q", address)
+			}
+			filter.Addresses = append(filter.Addresses, common.HexToAddress(address))
+		}
+	}
+	for _, param := range query["type"] {
+		for _, name := range strings.Split(param, ",") {
+			t, err := ParseEventType(name)
+			if err != nil {
+				return filter, err
+			}
+			filter.Types = append(filter.Types, t)
+		}
+	}
+	return filter, nil
+}
+
+// ServeWebsocket upgrades the request to a websocket and streams the events
+// selected by the address and type query parameters as JSON messages until
+// the client disconnects. Subscribers that don't keep up are disconnected
+// with a try again later close code.
+func (n *Notifier) ServeWebsocket(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseFilter(r)
+	if err != nil {
+		server.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// The upgrader already responded with an error.
+		logger.Debug("error upgrading websocket", "err", err)
+		return
+	}
+	defer conn.Close()
+
+	sub := n.hub.subscribe(filter)
+	defer n.hub.unsubscribe(sub)
+
+	// Clients don't send messages, but the connection must be read to
+	// process pongs and detect disconnections.
+	closed := make(chan struct{})
+	_ = conn.SetReadDeadline(time.Now().Add(pongTimeout))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(pongTimeout))
+	})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ping := time.NewTicker(pingInterval)
+	defer ping.Stop()
+	for {
+		select {
+		case e, ok := <-sub.events:
+			if !ok {
+				if n.hub.isDropped(sub) {
+					msg := websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "subscriber too slow")
+					_ = conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(writeTimeout))
+				}
+				return
+			}
+			_ = conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+			if err := conn.WriteJSON(e); err != nil {
+				return
+			}
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeTimeout)); err != nil {
+				return
+			}
+		case <-closed:
+			return
+		case <-n.ctx.Done():
+			msg := websocket.FormatCloseMessage(websocket.CloseGoingAway, "")
+			_ = conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(writeTimeout))
+			return
+		}
+	}
+}
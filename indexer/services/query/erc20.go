@@ -1,37 +1,81 @@
 package query
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
 	"github.com/ethereum-optimism/optimism/indexer/db"
 	"github.com/ethereum-optimism/optimism/op-bindings/bindings"
-	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/ethclient"
 )
 
+// NewERC20 queries the metadata of an ERC20 token. Each getter is queried on
+// its own: when some revert or return malformed data, the token holds the
+// fields which could be read and the error lists the others. Names and
+// symbols returned as bytes32, as by MKR, are decoded too.
 func NewERC20(address common.Address, client *ethclient.Client) (*db.Token, error) {
-	contract, err := bindings.NewERC20(address, client)
+	erc20ABI, err := bindings.ERC20MetaData.GetAbi()
 	if err != nil {
 		return nil, err
 	}
 
-	name, err := contract.Name(&bind.CallOpts{})
-	if err != nil {
-		return nil, err
+	token := &db.Token{Address: address.String()}
+	var errs []string
+
+	call := func(method string) ([]byte, error) {
+		input, err := erc20ABI.Pack(method)
+		if err != nil {
+			return nil, err
+		}
+		return client.CallContract(context.Background(), ethereum.CallMsg{To: &address, Data: input}, nil)
 	}
 
-	symbol, err := contract.Symbol(&bind.CallOpts{})
-	if err != nil {
-		return nil, err
+	for _, field := range []struct {
+		method string
+		dst    *string
+	}{
+		{"name", &token.Name},
+		{"symbol", &token.Symbol},
+	} {
+		out, err := call(field.method)
+		if err == nil {
+			*field.dst, err = decodeString(erc20ABI, field.method, out)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", field.method, err))
+		}
 	}
 
-	decimals, err := contract.Decimals(&bind.CallOpts{})
+	out, err := call("decimals")
+	if err == nil {
+		err = erc20ABI.UnpackIntoInterface(&token.Decimals, "decimals", out)
+	}
 	if err != nil {
-		return nil, err
+		errs = append(errs, fmt.Sprintf("decimals: %v", err))
+	}
+
+	if len(errs) > 0 {
+		return token, errors.New(strings.Join(errs, ", "))
 	}
+	return token, nil
+}
 
-	return &db.Token{
-		Name:     name,
-		Symbol:   symbol,
-		Decimals: decimals,
-	}, nil
+// decodeString decodes the string returned by method, or the bytes32 some
+// older tokens return instead.
+func decodeString(contractABI *abi.ABI, method string, out []byte) (string, error) {
+	if len(out) == 32 {
+		return string(bytes.TrimRight(out, "\x00")), nil
+	}
+
+	var s string
+	if err := contractABI.UnpackIntoInterface(&s, method, out); err != nil {
+		return "", err
+	}
+	return s, nil
 }
@@ -0,0 +1,29 @@
+package query
+
+import (
+	"testing"
+
+	"github.com/ethereum-optimism/optimism/op-bindings/bindings"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeString(t *testing.T) {
+	erc20ABI, err := bindings.ERC20MetaData.GetAbi()
+	require.NoError(t, err)
+
+	out, err := erc20ABI.Methods["symbol"].Outputs.Pack("TKN")
+	require.NoError(t, err)
+	symbol, err := decodeString(erc20ABI, "symbol", out)
+	require.NoError(t, err)
+	require.Equal(t, "TKN", symbol)
+
+	// MKR returns its symbol as bytes32.
+	out = common.RightPadBytes([]byte("MKR"), 32)
+	symbol, err = decodeString(erc20ABI, "symbol", out)
+	require.NoError(t, err)
+	require.Equal(t, "MKR", symbol)
+
+	_, err = decodeString(erc20ABI, "symbol", nil)
+	require.Error(t, err)
+}
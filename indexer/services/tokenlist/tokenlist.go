@@ -0,0 +1,119 @@
+// Package tokenlist reads curated token lists in the format of
+// https://tokenlists.org, as published by the Optimism token list.
+package tokenlist
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/ethereum-optimism/optimism/indexer/db"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// TokenList is a curated list of tokens.
+type TokenList struct {
+	Name   string  `json:"name"`
+	Tokens []Token `json:"tokens"`
+}
+
+// Token is a token of the list on a single chain.
+type Token struct {
+	ChainID    uint64         `json:"chainId"`
+	Address    common.Address `json:"address"`
+	Name       string         `json:"name"`
+	Symbol     string         `json:"symbol"`
+	Decimals   uint8          `json:"decimals"`
+	Extensions Extensions     `json:"extensions"`
+}
+
+// Extensions are the extensions of a token used by the indexer.
+type Extensions struct {
+	// BridgeInfo is the address of the token on other chains, keyed on
+	// their decimal chain ID.
+	BridgeInfo map[string]BridgeInfo `json:"bridgeInfo"`
+}
+
+// BridgeInfo is the address of a token on another chain.
+type BridgeInfo struct {
+	TokenAddress common.Address `json:"tokenAddress"`
+}
+
+// Load reads the token list at path.
+func Load(path string) (*TokenList, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var list TokenList
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("error parsing token list: %w", err)
+	}
+	if err := list.Validate(); err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+// Validate checks that the tokens are well-formed.
+func (l *TokenList) Validate() error {
+	for i, token := range l.Tokens {
+		if token.ChainID == 0 {
+			return fmt.Errorf("token %d: must have a chain id", i)
+		}
+		if token.Address == (common.Address{}) {
+			return fmt.Errorf("token %d: must have an address", i)
+		}
+		for chainID := range token.Extensions.BridgeInfo {
+			if _, err := strconv.ParseUint(chainID, 10, 64); err != nil {
+				return fmt.Errorf("token %d: invalid bridge chain id %q", i, chainID)
+			}
+		}
+	}
+	return nil
+}
+
+// Pairs returns the tokens of the list on the L1 and L2 chains, and the pairs
+// bridging them. A pair is listed by the bridgeInfo of either of its tokens.
+func (l *TokenList) Pairs(l1ChainID, l2ChainID uint64) ([]db.Token, []db.Token, []db.TokenPair, error) {
+	if l1ChainID == l2ChainID {
+		return nil, nil, nil, errors.New("l1 and l2 chain ids must differ")
+	}
+
+	var l1Tokens, l2Tokens []db.Token
+	var pairs []db.TokenPair
+	seen := make(map[db.TokenPair]bool)
+	addPair := func(pair db.TokenPair) {
+		if !seen[pair] {
+			seen[pair] = true
+			pairs = append(pairs, pair)
+		}
+	}
+
+	l1Key := strconv.FormatUint(l1ChainID, 10)
+	l2Key := strconv.FormatUint(l2ChainID, 10)
+	for _, token := range l.Tokens {
+		dbToken := db.Token{
+			Address:  token.Address.String(),
+			Name:     token.Name,
+			Symbol:   token.Symbol,
+			Decimals: token.Decimals,
+		}
+		switch token.ChainID {
+		case l1ChainID:
+			l1Tokens = append(l1Tokens, dbToken)
+			if info, ok := token.Extensions.BridgeInfo[l2Key]; ok {
+				addPair(db.TokenPair{L1Token: token.Address, L2Token: info.TokenAddress})
+			}
+		case l2ChainID:
+			l2Tokens = append(l2Tokens, dbToken)
+			if info, ok := token.Extensions.BridgeInfo[l1Key]; ok {
+				addPair(db.TokenPair{L1Token: info.TokenAddress, L2Token: token.Address})
+			}
+		}
+	}
+
+	return l1Tokens, l2Tokens, pairs, nil
+}
@@ -0,0 +1,78 @@
+package tokenlist
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum-optimism/optimism/indexer/db"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+const testTokenList = `{
+	"name": "Test List",
+	"tokens": [
+		{
+			"chainId": 1,
+			"address": "0x0000000000000000000000000000000000000001",
+			"name": "Token",
+			"symbol": "TKN",
+			"decimals": 18,
+			"extensions": {"bridgeInfo": {"10": {"tokenAddress": "0x0000000000000000000000000000000000000002"}}}
+		},
+		{
+			"chainId": 10,
+			"address": "0x0000000000000000000000000000000000000002",
+			"name": "Token",
+			"symbol": "TKN",
+			"decimals": 18,
+			"extensions": {"bridgeInfo": {"1": {"tokenAddress": "0x0000000000000000000000000000000000000001"}}}
+		},
+		{
+			"chainId": 5,
+			"address": "0x0000000000000000000000000000000000000003",
+			"name": "Other",
+			"symbol": "OTH",
+			"decimals": 6
+		}
+	]
+}`
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokenlist.json")
+	require.NoError(t, os.WriteFile(path, []byte(testTokenList), 0644))
+
+	list, err := Load(path)
+	require.NoError(t, err)
+	require.Equal(t, "Test List", list.Name)
+
+	l1Tokens, l2Tokens, pairs, err := list.Pairs(1, 10)
+	require.NoError(t, err)
+	require.Equal(t, []db.Token{{
+		Address:  common.HexToAddress("0x01").String(),
+		Name:     "Token",
+		Symbol:   "TKN",
+		Decimals: 18,
+	}}, l1Tokens)
+	require.Len(t, l2Tokens, 1)
+	// Both tokens list the pair.
+	require.Equal(t, []db.TokenPair{{
+		L1Token: common.HexToAddress("0x01"),
+		L2Token: common.HexToAddress("0x02"),
+	}}, pairs)
+
+	_, _, _, err = list.Pairs(1, 1)
+	require.Error(t, err)
+}
+
+func TestValidate(t *testing.T) {
+	list := TokenList{Tokens: []Token{{ChainID: 1}}}
+	require.Error(t, list.Validate())
+
+	list.Tokens[0].Address = common.HexToAddress("0x01")
+	require.NoError(t, list.Validate())
+
+	list.Tokens[0].Extensions.BridgeInfo = map[string]BridgeInfo{"optimism": {}}
+	require.Error(t, list.Validate())
+}
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/ethereum-optimism/optimism/indexer/db"
+	"github.com/ethereum-optimism/optimism/indexer/server"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+var tokensLogger = log.New("service", "tokens")
+
+// Tokens serves the registered L1/L2 token pairs with the metadata of their
+// tokens.
+type Tokens struct {
+	db *db.Database
+}
+
+func NewTokens(db *db.Database) *Tokens {
+	return &Tokens{
+		db: db,
+	}
+}
+
+func (t *Tokens) GetTokenPairs(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	limitStr := query.Get("limit")
+	limit, err := strconv.ParseUint(limitStr, 10, 64)
+	if err != nil && limitStr != "" {
+		server.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if limit == 0 {
+		limit = 10
+	}
+
+	offsetStr := query.Get("offset")
+	offset, err := strconv.ParseUint(offsetStr, 10, 64)
+	if err != nil && offsetStr != "" {
+		server.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	filter, err := parseTokenPairFilter(query)
+	if err != nil {
+		server.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	page := db.PaginationParam{
+		Limit:  limit,
+		Offset: offset,
+	}
+
+	pairs, err := t.db.GetTokenPairs(filter, page)
+	if err != nil {
+		tokensLogger.Error("db error getting token pairs", "err", err)
+		server.RespondWithError(w, http.StatusInternalServerError, "database error")
+		return
+	}
+
+	server.RespondWithJSON(w, http.StatusOK, pairs)
+}
+
+// parseTokenPairFilter parses the token and canonical query parameters.
+func parseTokenPairFilter(query url.Values) (db.TokenPairFilter, error) {
+	var filter db.TokenPairFilter
+	if token := query.Get("token"); token != "" {
+		if !common.IsHexAddress(token) {
+			return filter, errors.New("invalid token address")
+		}
+		address := common.HexToAddress(token)
+		filter.Token = &address
+	}
+	if canonicalStr := query.Get("canonical"); canonicalStr != "" {
+		canonical, err := strconv.ParseBool(canonicalStr)
+		if err != nil {
+			return filter, errors.New("canonical must be true or false")
+		}
+		filter.Canonical = &canonical
+	}
+	return filter, nil
+}
@@ -0,0 +1,45 @@
+package util
+
+import (
+	"math/big"
+
+	"github.com/ethereum-optimism/optimism/indexer/db"
+	"github.com/ethereum-optimism/optimism/op-chain-ops/crossdomain"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// NewSentMessage returns the message sent by a CrossDomainMessenger in log,
+// identified by its versioned hash: legacy messages, whose nonces have
+// version 0, are hashed without their value, which is always zero.
+func NewSentMessage(
+	nonce *big.Int,
+	sender, target common.Address,
+	value, gasLimit *big.Int,
+	data []byte,
+	log types.Log,
+) (db.SentMessage, error) {
+	hash, err := crossdomain.NewCrossDomainMessage(nonce, &sender, &target, value, gasLimit, data).Hash()
+	if err != nil {
+		return db.SentMessage{}, err
+	}
+
+	return db.SentMessage{
+		MessageHash: hash,
+		Nonce:       nonce,
+		Sender:      sender,
+		Target:      target,
+		Value:       value,
+		GasLimit:    gasLimit,
+		Data:        data,
+		TxHash:      log.TxHash,
+		LogIndex:    log.Index,
+	}, nil
+}
+
+// IsLegacyMessage returns whether the nonce of a message has version 0. The
+// value of these messages isn't emitted in a SentMessageExtension1 event.
+func IsLegacyMessage(nonce *big.Int) bool {
+	_, version := crossdomain.DecodeVersionedNonce(nonce)
+	return version.Sign() == 0
+}
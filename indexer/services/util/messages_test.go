@@ -0,0 +1,36 @@
+package util
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum-optimism/optimism/op-chain-ops/crossdomain"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSentMessage(t *testing.T) {
+	sender := common.HexToAddress("0x01")
+	target := common.HexToAddress("0x02")
+	data := []byte{0x03}
+	log := types.Log{TxHash: common.HexToHash("0x04"), Index: 5}
+
+	legacyNonce := big.NewInt(7)
+	require.True(t, IsLegacyMessage(legacyNonce))
+	msg, err := NewSentMessage(legacyNonce, sender, target, new(big.Int), big.NewInt(100), data, log)
+	require.NoError(t, err)
+	hash, err := crossdomain.HashCrossDomainMessageV0(&target, &sender, data, legacyNonce)
+	require.NoError(t, err)
+	require.Equal(t, hash, msg.MessageHash)
+	require.Equal(t, log.TxHash, msg.TxHash)
+	require.Equal(t, uint(5), msg.LogIndex)
+
+	nonce := crossdomain.EncodeVersionedNonce(big.NewInt(7), common.Big1)
+	require.False(t, IsLegacyMessage(nonce))
+	msg, err = NewSentMessage(nonce, sender, target, big.NewInt(10), big.NewInt(100), data, log)
+	require.NoError(t, err)
+	hash, err = crossdomain.HashCrossDomainMessageV1(nonce, &sender, &target, big.NewInt(10), big.NewInt(100), data)
+	require.NoError(t, err)
+	require.Equal(t, hash, msg.MessageHash)
+}
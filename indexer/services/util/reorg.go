@@ -0,0 +1,32 @@
+package util
+
+import (
+	"github.com/ethereum-optimism/optimism/indexer/db"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// FindCommonAncestor walks back the indexed blocks from head, and returns the
+// highest one that is still part of the canonical chain, or nil if none is.
+// previous returns the indexed block below a number, and canonicalHash the
+// hash of the canonical block at a number.
+func FindCommonAncestor(
+	head *db.BlockLocator,
+	previous func(number uint64) (*db.BlockLocator, error),
+	canonicalHash func(number uint64) (common.Hash, error),
+) (*db.BlockLocator, error) {
+	block := head
+	for block != nil {
+		hash, err := canonicalHash(block.Number)
+		if err != nil {
+			return nil, err
+		}
+		if hash == block.Hash {
+			return block, nil
+		}
+		block, err = previous(block.Number)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,59 @@
+package util
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ethereum-optimism/optimism/indexer/db"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindCommonAncestor(t *testing.T) {
+	indexed := []*db.BlockLocator{
+		{Number: 10, Hash: common.Hash{0x0a}},
+		{Number: 12, Hash: common.Hash{0x0c}},
+		{Number: 15, Hash: common.Hash{0x0f}},
+		{Number: 16, Hash: common.Hash{0x10}},
+	}
+	previous := func(number uint64) (*db.BlockLocator, error) {
+		for i := len(indexed) - 1; i >= 0; i-- {
+			if indexed[i].Number < number {
+				return indexed[i], nil
+			}
+		}
+		return nil, nil
+	}
+	// canonical returns the hashes of a chain that forked after block fork.
+	canonical := func(fork uint64) func(uint64) (common.Hash, error) {
+		return func(number uint64) (common.Hash, error) {
+			if number > fork {
+				return common.Hash{0xff, byte(number)}, nil
+			}
+			return common.Hash{byte(number)}, nil
+		}
+	}
+	head := indexed[len(indexed)-1]
+
+	ancestor, err := FindCommonAncestor(head, previous, canonical(100))
+	require.NoError(t, err)
+	require.Equal(t, head, ancestor)
+
+	ancestor, err = FindCommonAncestor(head, previous, canonical(14))
+	require.NoError(t, err)
+	require.Equal(t, indexed[1], ancestor)
+
+	ancestor, err = FindCommonAncestor(head, previous, canonical(15))
+	require.NoError(t, err)
+	require.Equal(t, indexed[2], ancestor)
+
+	ancestor, err = FindCommonAncestor(head, previous, canonical(5))
+	require.NoError(t, err)
+	require.Nil(t, ancestor)
+
+	errRPC := errors.New("rpc error")
+	_, err = FindCommonAncestor(head, previous, func(uint64) (common.Hash, error) {
+		return common.Hash{}, errRPC
+	})
+	require.ErrorIs(t, err, errRPC)
+}
@@ -0,0 +1,186 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"net/http"
+
+	"github.com/ethereum-optimism/optimism/indexer/db"
+	"github.com/ethereum-optimism/optimism/indexer/server"
+	"github.com/ethereum-optimism/optimism/op-bindings/bindings"
+	"github.com/ethereum-optimism/optimism/op-bindings/predeploys"
+	"github.com/ethereum-optimism/optimism/op-node/withdrawals"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/ethclient/gethclient"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/gorilla/mux"
+)
+
+var withdrawalsLogger = log.New("service", "withdrawals")
+
+// WithdrawalStatusJSON is the status of a bedrock withdrawal. Proof is only
+// set once the withdrawal can be proven.
+type WithdrawalStatusJSON struct {
+	Withdrawal *db.WithdrawalJSON   `json:"withdrawal"`
+	Proof      *WithdrawalProofJSON `json:"proof"`
+}
+
+// WithdrawalProofJSON contains the arguments of the
+// proveWithdrawalTransaction function of the OptimismPortal.
+type WithdrawalProofJSON struct {
+	WithdrawalTransaction WithdrawalTransactionJSON `json:"withdrawalTransaction"`
+	L2OutputIndex         string                    `json:"l2OutputIndex"`
+	OutputRootProof       OutputRootProofJSON       `json:"outputRootProof"`
+	WithdrawalProof       []hexutil.Bytes           `json:"withdrawalProof"`
+}
+
+type WithdrawalTransactionJSON struct {
+	Nonce    string         `json:"nonce"`
+	Sender   common.Address `json:"sender"`
+	Target   common.Address `json:"target"`
+	Value    string         `json:"value"`
+	GasLimit string         `json:"gasLimit"`
+	Data     hexutil.Bytes  `json:"data"`
+}
+
+type OutputRootProofJSON struct {
+	Version                  common.Hash `json:"version"`
+	StateRoot                common.Hash `json:"stateRoot"`
+	MessagePasserStorageRoot common.Hash `json:"messagePasserStorageRoot"`
+	LatestBlockhash          common.Hash `json:"latestBlockhash"`
+}
+
+// Withdrawals serves the status of bedrock withdrawals.
+type Withdrawals struct {
+	db       *db.Database
+	l2Client *ethclient.Client
+	l2Proofs *gethclient.Client
+	l2OO     *bindings.L2OutputOracleCaller
+}
+
+func NewWithdrawals(db *db.Database, l2Client *ethclient.Client, l2RPC *rpc.Client, addrs AddressManager) *Withdrawals {
+	_, l2OO := addrs.L2OutputOracle()
+
+	return &Withdrawals{
+		db:       db,
+		l2Client: l2Client,
+		l2Proofs: gethclient.New(l2RPC),
+		l2OO:     &l2OO.L2OutputOracleCaller,
+	}
+}
+
+func (s *Withdrawals) GetWithdrawalStatus(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	withdrawal, err := s.db.GetWithdrawalByHash(common.HexToHash(vars["hash"]))
+	if err != nil {
+		withdrawalsLogger.Error("db error getting withdrawal", "err", err)
+		server.RespondWithError(w, http.StatusInternalServerError, "database error")
+		return
+	}
+
+	if withdrawal == nil {
+		server.RespondWithError(w, http.StatusNotFound, "withdrawal not found")
+		return
+	}
+
+	status := &WithdrawalStatusJSON{
+		Withdrawal: withdrawal,
+	}
+	if *withdrawal.Status == db.WithdrawalStatusReadyToProve {
+		status.Proof, err = s.proof(r.Context(), withdrawal)
+		if err != nil {
+			withdrawalsLogger.Error("error generating withdrawal proof", "hash", vars["hash"], "err", err)
+			server.RespondWithError(w, http.StatusInternalServerError, "error generating withdrawal proof")
+			return
+		}
+	}
+
+	server.RespondWithJSON(w, http.StatusOK, status)
+}
+
+// proof returns the proof of the withdrawal against the output covering it.
+func (s *Withdrawals) proof(ctx context.Context, withdrawal *db.WithdrawalJSON) (*WithdrawalProofJSON, error) {
+	header, err := s.l2Client.HeaderByNumber(ctx, new(big.Int).SetUint64(withdrawal.BedrockL2Output.L2BlockNumber))
+	if err != nil {
+		return nil, fmt.Errorf("error fetching output block: %w", err)
+	}
+
+	// The indexer depends on a release of op-node without
+	// ProveWithdrawalParametersForEvent, and ProveWithdrawalParameters
+	// proves the first withdrawal of the transaction. It is given a receipt
+	// with only the event of the withdrawal instead.
+	receipts := &withdrawalReceiptClient{
+		client:         s.l2Client,
+		withdrawalHash: common.HexToHash(*withdrawal.BedrockWithdrawalHash),
+	}
+	params, err := withdrawals.ProveWithdrawalParameters(
+		ctx, s.l2Proofs, receipts, common.HexToHash(withdrawal.TxHash), header, s.l2OO,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	withdrawalProof := make([]hexutil.Bytes, len(params.WithdrawalProof))
+	for i, node := range params.WithdrawalProof {
+		withdrawalProof[i] = node
+	}
+
+	return &WithdrawalProofJSON{
+		WithdrawalTransaction: WithdrawalTransactionJSON{
+			Nonce:    params.Nonce.String(),
+			Sender:   params.Sender,
+			Target:   params.Target,
+			Value:    params.Value.String(),
+			GasLimit: params.GasLimit.String(),
+			Data:     params.Data,
+		},
+		L2OutputIndex: params.L2OutputIndex.String(),
+		OutputRootProof: OutputRootProofJSON{
+			Version:                  params.OutputRootProof.Version,
+			StateRoot:                params.OutputRootProof.StateRoot,
+			MessagePasserStorageRoot: params.OutputRootProof.MessagePasserStorageRoot,
+			LatestBlockhash:          params.OutputRootProof.LatestBlockhash,
+		},
+		WithdrawalProof: withdrawalProof,
+	}, nil
+}
+
+// withdrawalReceiptClient returns the receipts of withdrawal transactions
+// with the MessagePassed event of a single withdrawal, dropping those of the
+// other withdrawals of the transaction.
+type withdrawalReceiptClient struct {
+	client         withdrawals.ReceiptClient
+	withdrawalHash common.Hash
+}
+
+func (c *withdrawalReceiptClient) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	receipt, err := c.client.TransactionReceipt(ctx, txHash)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching withdrawal receipt: %w", err)
+	}
+	messagePasser, err := bindings.NewL2ToL1MessagePasserFilterer(predeploys.L2ToL1MessagePasserAddr, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, l := range receipt.Logs {
+		if l.Address != predeploys.L2ToL1MessagePasserAddr || len(l.Topics) == 0 || l.Topics[0] != withdrawals.MessagePassedTopic {
+			continue
+		}
+		ev, err := messagePasser.ParseMessagePassed(*l)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing MessagePassed event: %w", err)
+		}
+		if ev.WithdrawalHash == c.withdrawalHash {
+			filtered := *receipt
+			filtered.Logs = []*types.Log{l}
+			return &filtered, nil
+		}
+	}
+	return nil, fmt.Errorf("transaction %s doesn't initiate withdrawal %s", txHash, c.withdrawalHash)
+}
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum-optimism/optimism/op-bindings/bindings"
+	"github.com/ethereum-optimism/optimism/op-bindings/predeploys"
+	"github.com/ethereum-optimism/optimism/op-node/withdrawals"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/require"
+)
+
+type receiptClient map[common.Hash]*types.Receipt
+
+func (c receiptClient) TransactionReceipt(_ context.Context, txHash common.Hash) (*types.Receipt, error) {
+	return c[txHash], nil
+}
+
+func messagePassedLog(t *testing.T, nonce int64) (*types.Log, common.Hash) {
+	ev := &bindings.L2ToL1MessagePasserMessagePassed{
+		Nonce:    big.NewInt(nonce),
+		Sender:   common.HexToAddress("0x01"),
+		Target:   common.HexToAddress("0x02"),
+		Value:    big.NewInt(0),
+		GasLimit: big.NewInt(100_000),
+		Data:     []byte{},
+	}
+	hash, err := withdrawals.WithdrawalHash(ev)
+	require.NoError(t, err)
+
+	messagePasser, err := bindings.L2ToL1MessagePasserMetaData.GetAbi()
+	require.NoError(t, err)
+	data, err := messagePasser.Events["MessagePassed"].Inputs.NonIndexed().Pack(ev.Value, ev.GasLimit, ev.Data, hash)
+	require.NoError(t, err)
+	return &types.Log{
+		Address: predeploys.L2ToL1MessagePasserAddr,
+		Topics: []common.Hash{
+			withdrawals.MessagePassedTopic,
+			common.BigToHash(ev.Nonce),
+			common.BytesToHash(ev.Sender.Bytes()),
+			common.BytesToHash(ev.Target.Bytes()),
+		},
+		Data: data,
+	}, hash
+}
+
+func TestWithdrawalReceiptClient(t *testing.T) {
+	first, _ := messagePassedLog(t, 1)
+	second, secondHash := messagePassedLog(t, 2)
+	txHash := common.HexToHash("0x11")
+	client := receiptClient{txHash: {
+		TxHash: txHash,
+		Logs:   []*types.Log{{Address: common.HexToAddress("0x03")}, first, second},
+	}}
+
+	receipt, err := (&withdrawalReceiptClient{client: client, withdrawalHash: secondHash}).TransactionReceipt(context.Background(), txHash)
+	require.NoError(t, err)
+	ev, err := withdrawals.ParseMessagePassed(receipt)
+	require.NoError(t, err)
+	require.Equal(t, secondHash, common.Hash(ev.WithdrawalHash))
+	require.Equal(t, int64(2), ev.Nonce.Int64())
+	require.Len(t, client[txHash].Logs, 3)
+
+	_, err = (&withdrawalReceiptClient{client: client, withdrawalHash: common.HexToHash("0x99")}).TransactionReceipt(context.Background(), txHash)
+	require.Error(t, err)
+}
@@ -0,0 +1,510 @@
+package challenger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	_ "net/http/pprof"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/urfave/cli"
+
+	"github.com/ethereum-optimism/optimism/op-bindings/bindings"
+	"github.com/ethereum-optimism/optimism/op-challenger/metrics"
+	"github.com/ethereum-optimism/optimism/op-node/eth"
+	oplog "github.com/ethereum-optimism/optimism/op-service/log"
+	oppprof "github.com/ethereum-optimism/optimism/op-service/pprof"
+	oprpc "github.com/ethereum-optimism/optimism/op-service/rpc"
+	"github.com/ethereum-optimism/optimism/op-service/txmgr"
+)
+
+// maxL1BlockRange is the maximum number of L1 blocks queried for output proposals at once.
+const maxL1BlockRange = 1000
+
+// Main is the entrypoint into the Challenger. This method executes the
+// service and blocks until the service exits.
+func Main(version string, cliCtx *cli.Context) error {
+	cfg := NewConfig(cliCtx)
+	if err := cfg.Check(); err != nil {
+		return fmt.Errorf("invalid CLI flags: %w", err)
+	}
+
+	l := oplog.NewLogger(cfg.LogConfig)
+	m := metrics.NewMetrics("default")
+	l.Info("Initializing Challenger")
+
+	challengerConfig, err := NewChallengerConfigFromCLIConfig(cfg, l)
+	if err != nil {
+		l.Error("Unable to create the Challenger", "error", err)
+		return err
+	}
+
+	challenger, err := NewChallenger(*challengerConfig, l, m)
+	if err != nil {
+		l.Error("Unable to create the Challenger", "error", err)
+		return err
+	}
+
+	l.Info("Starting Challenger")
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := challenger.Start(); err != nil {
+		cancel()
+		l.Error("Unable to start Challenger", "error", err)
+		return err
+	}
+	defer challenger.Stop()
+
+	l.Info("Challenger started")
+	pprofConfig := cfg.PprofConfig
+	if pprofConfig.Enabled {
+		l.Info("starting pprof", "addr", pprofConfig.ListenAddr, "port", pprofConfig.ListenPort)
+		go func() {
+			if err := oppprof.ListenAndServe(ctx, pprofConfig.ListenAddr, pprofConfig.ListenPort); err != nil {
+				l.Error("error starting pprof", "err", err)
+			}
+		}()
+	}
+
+	metricsCfg := cfg.MetricsConfig
+	if metricsCfg.Enabled {
+		l.Info("starting metrics server", "addr", metricsCfg.ListenAddr, "port", metricsCfg.ListenPort)
+		go func() {
+			if err := m.Serve(ctx, metricsCfg.ListenAddr, metricsCfg.ListenPort); err != nil {
+				l.Error("error starting metrics server", err)
+			}
+		}()
+		if challengerConfig.TxManager != nil {
+			m.StartBalanceMetrics(ctx, l, challengerConfig.L1Client, challengerConfig.TxManager.From())
+		}
+	}
+
+	rpcCfg := cfg.RPCConfig
+	server := oprpc.NewServer(rpcCfg.ListenAddr, rpcCfg.ListenPort, version, oprpc.WithLogger(l))
+	if err := server.Start(); err != nil {
+		cancel()
+		return fmt.Errorf("error starting RPC server: %w", err)
+	}
+
+	m.RecordInfo(version)
+	m.RecordUp()
+
+	interruptChannel := make(chan os.Signal, 1)
+	signal.Notify(interruptChannel, []os.Signal{
+		os.Interrupt,
+		os.Kill,
+		syscall.SIGTERM,
+		syscall.SIGQUIT,
+	}...)
+	<-interruptChannel
+	cancel()
+
+	return nil
+}
+
+// Challenger follows the output proposals of the L2OutputOracle, checks every output root
+// against the trusted L2 state, and reports (and optionally deletes) invalid outputs.
+type Challenger struct {
+	txMgr txmgr.TxManager
+	wg    sync.WaitGroup
+	done  chan struct{}
+	log   log.Logger
+	metr  metrics.Metricer
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	l1Client *ethclient.Client
+
+	verifier *outputVerifier
+	notifier Notifier
+
+	l2ooContract     *bindings.L2OutputOracle
+	l2ooContractAddr common.Address
+	l2ooABI          *abi.ABI
+
+	pollInterval        time.Duration
+	networkTimeout      time.Duration
+	l1ConfirmationDepth uint64
+	// finalizationPeriod is the time after which proposed outputs are finalized and can no longer be deleted
+	finalizationPeriod time.Duration
+
+	// nextL1Block is the next L1 block to scan for output proposals
+	nextL1Block uint64
+	// pending holds the output proposals that have not been checked yet, in proposal order
+	pending []*bindings.L2OutputOracleOutputProposed
+	// reported holds the invalid outputs that have already been reported, by output index
+	reported map[uint64]eth.Bytes32
+}
+
+// NewChallengerFromCLIConfig creates a new Challenger given the CLI Config
+func NewChallengerFromCLIConfig(cfg CLIConfig, l log.Logger, m metrics.Metricer) (*Challenger, error) {
+	challengerConfig, err := NewChallengerConfigFromCLIConfig(cfg, l)
+	if err != nil {
+		return nil, err
+	}
+	return NewChallenger(*challengerConfig, l, m)
+}
+
+// NewChallengerConfigFromCLIConfig creates the challenger config from the CLI config.
+func NewChallengerConfigFromCLIConfig(cfg CLIConfig, l log.Logger) (*Config, error) {
+	l2ooAddress, err := parseAddress(cfg.L2OOAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	networkTimeout := cfg.TxMgrConfig.NetworkTimeout
+	var txManager txmgr.TxManager
+	if cfg.DeleteInvalidOutputs {
+		txManagerConfig, err := txmgr.NewConfig(cfg.TxMgrConfig, l)
+		if err != nil {
+			return nil, err
+		}
+		txManager = txmgr.NewSimpleTxManager("challenger", l, txManagerConfig)
+	}
+
+	var notifier Notifier = noopNotifier{}
+	if cfg.WebhookURL != "" {
+		notifier = NewWebhookNotifier(cfg.WebhookURL, 10*time.Second)
+	}
+
+	// Connect to L1 and L2 providers. Perform these last since they are the most expensive.
+	ctx := context.Background()
+	l1Client, err := dialEthClientWithTimeout(ctx, cfg.L1EthRpc)
+	if err != nil {
+		return nil, err
+	}
+
+	rollupClient, err := dialRollupClientWithTimeout(ctx, cfg.RollupRpc)
+	if err != nil {
+		return nil, err
+	}
+
+	l2Client, err := dialL2ClientWithTimeout(ctx, cfg.L2EthRpc)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Config{
+		L2OutputOracleAddr:  l2ooAddress,
+		PollInterval:        cfg.PollInterval,
+		NetworkTimeout:      networkTimeout,
+		L1StartBlock:        cfg.L1StartBlock,
+		L1ConfirmationDepth: cfg.L1ConfirmationDepth,
+		L1Client:            l1Client,
+		RollupClient:        rollupClient,
+		L2Client:            l2Client,
+		Notifier:            notifier,
+		TxManager:           txManager,
+	}, nil
+}
+
+// NewChallenger creates a new Challenger
+func NewChallenger(cfg Config, l log.Logger, m metrics.Metricer) (*Challenger, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	l2ooContract, err := bindings.NewL2OutputOracle(cfg.L2OutputOracleAddr, cfg.L1Client)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	cCtx, cCancel := context.WithTimeout(ctx, cfg.NetworkTimeout)
+	defer cCancel()
+	version, err := l2ooContract.Version(&bind.CallOpts{Context: cCtx})
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	log.Info("Connected to L2OutputOracle", "address", cfg.L2OutputOracleAddr, "version", version)
+
+	if cfg.TxManager != nil {
+		cCtx, cCancel := context.WithTimeout(ctx, cfg.NetworkTimeout)
+		defer cCancel()
+		challenger, err := l2ooContract.CHALLENGER(&bind.CallOpts{Context: cCtx})
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		if challenger != cfg.TxManager.From() {
+			cancel()
+			return nil, fmt.Errorf("transaction manager address %s is not the L2OutputOracle challenger %s", cfg.TxManager.From(), challenger)
+		}
+	}
+
+	cCtx, cCancel = context.WithTimeout(ctx, cfg.NetworkTimeout)
+	defer cCancel()
+	finalizationPeriod, err := l2ooContract.FINALIZATIONPERIODSECONDS(&bind.CallOpts{Context: cCtx})
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	parsed, err := bindings.L2OutputOracleMetaData.GetAbi()
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	notifier := cfg.Notifier
+	if notifier == nil {
+		notifier = noopNotifier{}
+	}
+
+	return &Challenger{
+		txMgr:  cfg.TxManager,
+		done:   make(chan struct{}),
+		log:    l,
+		ctx:    ctx,
+		cancel: cancel,
+		metr:   m,
+
+		l1Client: cfg.L1Client,
+
+		verifier: &outputVerifier{
+			rollupClient:   cfg.RollupClient,
+			l2Client:       cfg.L2Client,
+			networkTimeout: cfg.NetworkTimeout,
+		},
+		notifier: notifier,
+
+		l2ooContract:     l2ooContract,
+		l2ooContractAddr: cfg.L2OutputOracleAddr,
+		l2ooABI:          parsed,
+
+		pollInterval:        cfg.PollInterval,
+		networkTimeout:      cfg.NetworkTimeout,
+		l1ConfirmationDepth: cfg.L1ConfirmationDepth,
+		finalizationPeriod:  time.Duration(finalizationPeriod.Uint64()) * time.Second,
+
+		nextL1Block: cfg.L1StartBlock,
+		reported:    make(map[uint64]eth.Bytes32),
+	}, nil
+}
+
+func (c *Challenger) Start() error {
+	if c.nextL1Block == 0 {
+		cCtx, cancel := context.WithTimeout(c.ctx, c.networkTimeout)
+		defer cancel()
+		head, err := c.l1Client.BlockNumber(cCtx)
+		if err != nil {
+			return fmt.Errorf("failed to fetch L1 head: %w", err)
+		}
+		c.nextL1Block = head
+	}
+	c.log.Info("Following output proposals", "l1_start_block", c.nextL1Block)
+	c.wg.Add(1)
+	go c.loop()
+	return nil
+}
+
+func (c *Challenger) Stop() {
+	c.cancel()
+	close(c.done)
+	c.wg.Wait()
+}
+
+// fetchOutputProposals appends the output proposals of all confirmed L1 blocks that have not been scanned yet.
+func (c *Challenger) fetchOutputProposals(ctx context.Context) error {
+	cCtx, cancel := context.WithTimeout(ctx, c.networkTimeout)
+	defer cancel()
+	head, err := c.l1Client.BlockNumber(cCtx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch L1 head: %w", err)
+	}
+	if head < c.l1ConfirmationDepth {
+		return nil
+	}
+	target := head - c.l1ConfirmationDepth
+
+	for c.nextL1Block <= target {
+		end := c.nextL1Block + maxL1BlockRange - 1
+		if end > target {
+			end = target
+		}
+		cCtx, cancel := context.WithTimeout(ctx, c.networkTimeout)
+		it, err := c.l2ooContract.FilterOutputProposed(&bind.FilterOpts{Start: c.nextL1Block, End: &end, Context: cCtx}, nil, nil, nil)
+		if err != nil {
+			cancel()
+			return fmt.Errorf("failed to filter output proposals in L1 blocks %d-%d: %w", c.nextL1Block, end, err)
+		}
+		var proposals []*bindings.L2OutputOracleOutputProposed
+		for it.Next() {
+			proposals = append(proposals, it.Event)
+		}
+		err = it.Error()
+		it.Close()
+		cancel()
+		if err != nil {
+			return fmt.Errorf("failed to read output proposals in L1 blocks %d-%d: %w", c.nextL1Block, end, err)
+		}
+		for _, p := range proposals {
+			c.log.Info("Output proposed", "output_index", p.L2OutputIndex, "l2_block", p.L2BlockNumber,
+				"output_root", eth.Bytes32(p.OutputRoot), "l1_block", p.Raw.BlockNumber, "l1_tx", p.Raw.TxHash)
+		}
+		c.pending = append(c.pending, proposals...)
+		c.nextL1Block = end + 1
+		c.metr.RecordL1BlockProcessed(end)
+	}
+	return nil
+}
+
+// checkPendingOutputs checks the pending outputs in order, until an output cannot be checked yet.
+// Outputs that fail to be checked or deleted stay pending and are retried on the next poll, without
+// holding up the later outputs, until they are finalized.
+func (c *Challenger) checkPendingOutputs(ctx context.Context) {
+	remaining := c.pending[:0]
+	for i, proposal := range c.pending {
+		err := c.checkOutput(ctx, proposal)
+		if errors.Is(err, ErrNotReady) {
+			c.log.Debug("Waiting for trusted nodes to sync output block", "output_index", proposal.L2OutputIndex, "err", err)
+			remaining = append(remaining, c.pending[i:]...)
+			break
+		}
+		if err == nil {
+			continue
+		}
+		if c.finalized(proposal) {
+			c.log.Error("Failed to check output before its finalization, abandoning it", "output_index", proposal.L2OutputIndex,
+				"l2_block", proposal.L2BlockNumber, "output_root", eth.Bytes32(proposal.OutputRoot), "err", err)
+			c.metr.RecordOutputCheckFailed(true)
+			continue
+		}
+		c.log.Error("Failed to check output", "output_index", proposal.L2OutputIndex, "err", err)
+		c.metr.RecordOutputCheckFailed(false)
+		remaining = append(remaining, proposal)
+	}
+	c.pending = remaining
+}
+
+// finalized returns true if the proposed output can no longer be deleted.
+func (c *Challenger) finalized(proposal *bindings.L2OutputOracleOutputProposed) bool {
+	if c.finalizationPeriod == 0 || proposal.L1Timestamp == nil {
+		return false
+	}
+	proposedAt := time.Unix(int64(proposal.L1Timestamp.Uint64()), 0)
+	return time.Since(proposedAt) > c.finalizationPeriod
+}
+
+// checkOutput compares a proposed output root to the root computed from the trusted nodes.
+// Invalid outputs are reported once, and deleted if a transaction manager is configured.
+func (c *Challenger) checkOutput(ctx context.Context, proposal *bindings.L2OutputOracleOutputProposed) error {
+	outputIndex := proposal.L2OutputIndex.Uint64()
+	l2BlockNumber := proposal.L2BlockNumber.Uint64()
+	expected, err := c.verifier.ExpectedOutputRoot(ctx, l2BlockNumber)
+	if err != nil {
+		return err
+	}
+	outputRoot := eth.Bytes32(proposal.OutputRoot)
+	if expected == outputRoot {
+		c.log.Info("Output is valid", "output_index", outputIndex, "l2_block", l2BlockNumber, "output_root", outputRoot)
+		c.metr.RecordOutputChecked(outputIndex, true)
+		return nil
+	}
+
+	invalid := &InvalidOutput{
+		OutputIndex:   outputIndex,
+		L2BlockNumber: l2BlockNumber,
+		OutputRoot:    outputRoot,
+		ExpectedRoot:  expected,
+		L1BlockNumber: proposal.Raw.BlockNumber,
+		L1TxHash:      proposal.Raw.TxHash,
+	}
+	if reported, ok := c.reported[outputIndex]; !ok || reported != outputRoot {
+		c.log.Error("Invalid output proposed", "output_index", outputIndex, "l2_block", l2BlockNumber,
+			"output_root", outputRoot, "expected_output_root", expected, "l1_tx", proposal.Raw.TxHash)
+		c.metr.RecordOutputChecked(outputIndex, false)
+		if err := c.notifier.NotifyInvalidOutput(ctx, invalid); err != nil {
+			c.log.Error("Failed to notify invalid output", "output_index", outputIndex, "err", err)
+		}
+		c.reported[outputIndex] = outputRoot
+	}
+
+	if c.txMgr == nil {
+		return nil
+	}
+	return c.deleteOutput(ctx, invalid)
+}
+
+// DeleteL2OutputsTxData creates the transaction data for the deleteL2Outputs function
+func (c *Challenger) DeleteL2OutputsTxData(outputIndex uint64) ([]byte, error) {
+	return deleteL2OutputsTxData(c.l2ooABI, outputIndex)
+}
+
+// deleteL2OutputsTxData creates the transaction data for the deleteL2Outputs function
+func deleteL2OutputsTxData(abi *abi.ABI, outputIndex uint64) ([]byte, error) {
+	return abi.Pack("deleteL2Outputs", new(big.Int).SetUint64(outputIndex))
+}
+
+// deleteOutput deletes the invalid output, and all outputs after it, unless it was deleted already.
+func (c *Challenger) deleteOutput(ctx context.Context, invalid *InvalidOutput) error {
+	cCtx, cancel := context.WithTimeout(ctx, c.networkTimeout)
+	defer cancel()
+	latest, err := c.l2ooContract.LatestOutputIndex(&bind.CallOpts{Context: cCtx})
+	if err != nil {
+		return fmt.Errorf("failed to fetch latest output index: %w", err)
+	}
+	if latest.Uint64() < invalid.OutputIndex {
+		c.log.Info("Invalid output was already deleted", "output_index", invalid.OutputIndex)
+		return nil
+	}
+	cCtx, cancel = context.WithTimeout(ctx, c.networkTimeout)
+	defer cancel()
+	current, err := c.l2ooContract.GetL2Output(&bind.CallOpts{Context: cCtx}, new(big.Int).SetUint64(invalid.OutputIndex))
+	if err != nil {
+		return fmt.Errorf("failed to fetch output %d: %w", invalid.OutputIndex, err)
+	}
+	if eth.Bytes32(current.OutputRoot) != invalid.OutputRoot {
+		c.log.Info("Invalid output was already replaced", "output_index", invalid.OutputIndex, "output_root", eth.Bytes32(current.OutputRoot))
+		return nil
+	}
+
+	data, err := c.DeleteL2OutputsTxData(invalid.OutputIndex)
+	if err != nil {
+		return err
+	}
+	cCtx, cancel = context.WithTimeout(ctx, 10*time.Minute)
+	defer cancel()
+	receipt, err := c.txMgr.Send(cCtx, txmgr.TxCandidate{
+		TxData:   data,
+		To:       c.l2ooContractAddr,
+		GasLimit: 0,
+		From:     c.txMgr.From(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send deleteL2Outputs transaction: %w", err)
+	}
+	c.log.Warn("Deleted invalid output", "output_index", invalid.OutputIndex, "tx_hash", receipt.TxHash)
+	c.metr.RecordOutputDeleted(invalid.OutputIndex)
+	return nil
+}
+
+// loop is responsible for following the output proposals & checking them
+func (c *Challenger) loop() {
+	defer c.wg.Done()
+
+	ctx := c.ctx
+
+	ticker := time.NewTicker(c.pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := c.fetchOutputProposals(ctx); err != nil {
+				c.log.Error("Failed to fetch output proposals", "err", err)
+			}
+			c.checkPendingOutputs(ctx)
+		case <-c.done:
+			return
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package challenger
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ethereum-optimism/optimism/op-bindings/bindings"
+	"github.com/ethereum-optimism/optimism/op-bindings/predeploys"
+	"github.com/ethereum-optimism/optimism/op-challenger/metrics"
+	"github.com/ethereum-optimism/optimism/op-node/eth"
+	"github.com/ethereum-optimism/optimism/op-node/rollup"
+)
+
+// testL2 serves a single L2 block whose state contains the L2ToL1MessagePasser.
+type testL2 struct {
+	header *types.Header
+	proof  *eth.AccountResult
+	safe   uint64
+	// noOutput makes the rollup node return an empty response
+	noOutput bool
+}
+
+func newTestL2(t *testing.T, number uint64) *testL2 {
+	db := state.NewDatabase(rawdb.NewMemoryDatabase())
+	st, err := state.New(types.EmptyRootHash, db, nil)
+	require.NoError(t, err)
+	st.SetNonce(predeploys.L2ToL1MessagePasserAddr, 1)
+	st.SetState(predeploys.L2ToL1MessagePasserAddr, common.Hash{0x01}, common.Hash{0x02})
+	root, err := st.Commit(false)
+	require.NoError(t, err)
+
+	st, err = state.New(root, db, nil)
+	require.NoError(t, err)
+	accountProof, err := st.GetProof(predeploys.L2ToL1MessagePasserAddr)
+	require.NoError(t, err)
+	storageTrie, err := st.StorageTrie(predeploys.L2ToL1MessagePasserAddr)
+	require.NoError(t, err)
+	proof := &eth.AccountResult{
+		Address:     predeploys.L2ToL1MessagePasserAddr,
+		Balance:     (*hexutil.Big)(st.GetBalance(predeploys.L2ToL1MessagePasserAddr)),
+		CodeHash:    st.GetCodeHash(predeploys.L2ToL1MessagePasserAddr),
+		Nonce:       hexutil.Uint64(st.GetNonce(predeploys.L2ToL1MessagePasserAddr)),
+		StorageHash: storageTrie.Hash(),
+	}
+	for _, node := range accountProof {
+		proof.AccountProof = append(proof.AccountProof, node)
+	}
+	return &testL2{
+		header: &types.Header{Number: new(big.Int).SetUint64(number), Root: root, Difficulty: common.Big0},
+		proof:  proof,
+		safe:   number,
+	}
+}
+
+func (l *testL2) outputRoot(t *testing.T) eth.Bytes32 {
+	root, err := rollup.ComputeL2OutputRoot(&bindings.TypesOutputRootProof{
+		StateRoot:                l.header.Root,
+		MessagePasserStorageRoot: l.proof.StorageHash,
+		LatestBlockhash:          l.header.Hash(),
+	})
+	require.NoError(t, err)
+	return root
+}
+
+func (l *testL2) OutputAtBlock(_ context.Context, blockNum uint64) (*eth.OutputResponse, error) {
+	if blockNum != l.header.Number.Uint64() {
+		return nil, errors.New("unknown block")
+	}
+	if l.noOutput {
+		return nil, nil
+	}
+	return &eth.OutputResponse{BlockRef: eth.L2BlockRef{Hash: l.header.Hash(), Number: blockNum}}, nil
+}
+
+func (l *testL2) SyncStatus(_ context.Context) (*eth.SyncStatus, error) {
+	return &eth.SyncStatus{SafeL2: eth.L2BlockRef{Number: l.safe}}, nil
+}
+
+func (l *testL2) HeaderByNumber(_ context.Context, number *big.Int) (*types.Header, error) {
+	if number.Cmp(l.header.Number) != 0 {
+		return nil, errors.New("unknown block")
+	}
+	return l.header, nil
+}
+
+func (l *testL2) GetProof(_ context.Context, address common.Address, _ []common.Hash, blockTag string) (*eth.AccountResult, error) {
+	if address != predeploys.L2ToL1MessagePasserAddr || blockTag != l.header.Hash().String() {
+		return nil, errors.New("unknown account")
+	}
+	return l.proof, nil
+}
+
+type recordingNotifier struct {
+	outputs []*InvalidOutput
+}
+
+func (r *recordingNotifier) NotifyInvalidOutput(_ context.Context, output *InvalidOutput) error {
+	r.outputs = append(r.outputs, output)
+	return nil
+}
+
+func newTestChallenger(l2 *testL2, notifier Notifier) *Challenger {
+	return &Challenger{
+		log:  log.New(),
+		metr: metrics.NoopMetrics,
+		verifier: &outputVerifier{
+			rollupClient:   l2,
+			l2Client:       l2,
+			networkTimeout: time.Second,
+		},
+		notifier: notifier,
+		reported: make(map[uint64]eth.Bytes32),
+	}
+}
+
+func proposal(index uint64, l2Block uint64, root eth.Bytes32) *bindings.L2OutputOracleOutputProposed {
+	return &bindings.L2OutputOracleOutputProposed{
+		OutputRoot:    root,
+		L2OutputIndex: new(big.Int).SetUint64(index),
+		L2BlockNumber: new(big.Int).SetUint64(l2Block),
+		Raw:           types.Log{BlockNumber: 100, TxHash: common.Hash{0xcc}},
+	}
+}
+
+func TestExpectedOutputRoot(t *testing.T) {
+	l2 := newTestL2(t, 10)
+	v := &outputVerifier{rollupClient: l2, l2Client: l2, networkTimeout: time.Second}
+
+	root, err := v.ExpectedOutputRoot(context.Background(), 10)
+	require.NoError(t, err)
+	require.Equal(t, l2.outputRoot(t), root)
+
+	l2.safe = 9
+	_, err = v.ExpectedOutputRoot(context.Background(), 10)
+	require.ErrorIs(t, err, ErrNotReady)
+
+	l2.safe = 10
+	l2.proof.StorageHash = common.Hash{0xba, 0xd}
+	_, err = v.ExpectedOutputRoot(context.Background(), 10)
+	require.ErrorContains(t, err, "invalid message passer proof")
+
+	l2.noOutput = true
+	_, err = v.ExpectedOutputRoot(context.Background(), 10)
+	require.ErrorIs(t, err, errNoOutput)
+}
+
+func TestCheckPendingOutputs(t *testing.T) {
+	l2 := newTestL2(t, 10)
+	notifier := &recordingNotifier{}
+	c := newTestChallenger(l2, notifier)
+	valid := l2.outputRoot(t)
+	invalid := eth.Bytes32{0xde, 0xad}
+
+	c.pending = []*bindings.L2OutputOracleOutputProposed{
+		proposal(0, 10, valid),
+		proposal(1, 10, invalid),
+		proposal(2, 20, valid),
+	}
+	c.checkPendingOutputs(context.Background())
+
+	require.Len(t, c.pending, 1, "output of an unsafe L2 block must stay pending")
+	require.Equal(t, uint64(2), c.pending[0].L2OutputIndex.Uint64())
+	require.Equal(t, []*InvalidOutput{{
+		OutputIndex:   1,
+		L2BlockNumber: 10,
+		OutputRoot:    invalid,
+		ExpectedRoot:  valid,
+		L1BlockNumber: 100,
+		L1TxHash:      common.Hash{0xcc},
+	}}, notifier.outputs)
+
+	// an invalid output is only reported once
+	require.NoError(t, c.checkOutput(context.Background(), proposal(1, 10, invalid)))
+	require.Len(t, notifier.outputs, 1)
+}
+
+func TestCheckPendingOutputsFailure(t *testing.T) {
+	l2 := newTestL2(t, 10)
+	notifier := &recordingNotifier{}
+	c := newTestChallenger(l2, notifier)
+	c.finalizationPeriod = time.Hour
+	valid := l2.outputRoot(t)
+
+	// The trusted nodes don't serve the output of block 5.
+	failing := proposal(0, 5, valid)
+	failing.L1Timestamp = big.NewInt(time.Now().Unix())
+	finalized := proposal(2, 5, valid)
+	finalized.L1Timestamp = big.NewInt(time.Now().Add(-2 * time.Hour).Unix())
+	c.pending = []*bindings.L2OutputOracleOutputProposed{
+		failing,
+		proposal(1, 10, eth.Bytes32{0xde, 0xad}),
+		finalized,
+		proposal(3, 20, valid),
+	}
+	c.checkPendingOutputs(context.Background())
+
+	// The failed output is retried later, and doesn't hold up the next ones.
+	require.Len(t, notifier.outputs, 1)
+	require.Equal(t, uint64(1), notifier.outputs[0].OutputIndex)
+	require.Len(t, c.pending, 2, "finalized output must be abandoned")
+	require.Equal(t, uint64(0), c.pending[0].L2OutputIndex.Uint64())
+	require.Equal(t, uint64(3), c.pending[1].L2OutputIndex.Uint64())
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	received := make(chan InvalidOutput, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var output InvalidOutput
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&output))
+		received <- output
+	}))
+	defer srv.Close()
+
+	output := &InvalidOutput{OutputIndex: 3, L2BlockNumber: 10, OutputRoot: eth.Bytes32{1}, ExpectedRoot: eth.Bytes32{2}}
+	require.NoError(t, NewWebhookNotifier(srv.URL, time.Second).NotifyInvalidOutput(context.Background(), output))
+	require.Equal(t, *output, <-received)
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+	require.Error(t, NewWebhookNotifier(failing.URL, time.Second).NotifyInvalidOutput(context.Background(), output))
+}
@@ -0,0 +1,145 @@
+package challenger
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/urfave/cli"
+
+	"github.com/ethereum-optimism/optimism/op-challenger/flags"
+	"github.com/ethereum-optimism/optimism/op-node/eth"
+	"github.com/ethereum-optimism/optimism/op-node/sources"
+	oplog "github.com/ethereum-optimism/optimism/op-service/log"
+	opmetrics "github.com/ethereum-optimism/optimism/op-service/metrics"
+	oppprof "github.com/ethereum-optimism/optimism/op-service/pprof"
+	oprpc "github.com/ethereum-optimism/optimism/op-service/rpc"
+	"github.com/ethereum-optimism/optimism/op-service/txmgr"
+)
+
+// RollupClient is the subset of the trusted rollup node RPC used by the challenger.
+type RollupClient interface {
+	OutputAtBlock(ctx context.Context, blockNum uint64) (*eth.OutputResponse, error)
+	SyncStatus(ctx context.Context) (*eth.SyncStatus, error)
+}
+
+var _ RollupClient = (*sources.RollupClient)(nil)
+
+// L2Client is the subset of the trusted L2 execution client RPC used by the challenger.
+type L2Client interface {
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+	GetProof(ctx context.Context, address common.Address, storage []common.Hash, blockTag string) (*eth.AccountResult, error)
+}
+
+// Config contains the well typed fields that are used to initialize the challenger.
+// It is intended for programmatic use.
+type Config struct {
+	L2OutputOracleAddr  common.Address
+	PollInterval        time.Duration
+	NetworkTimeout      time.Duration
+	L1StartBlock        uint64
+	L1ConfirmationDepth uint64
+	L1Client            *ethclient.Client
+	RollupClient        RollupClient
+	L2Client            L2Client
+	Notifier            Notifier
+	// TxManager submits deleteL2Outputs transactions. Invalid outputs are only reported if it is nil.
+	TxManager txmgr.TxManager
+}
+
+// CLIConfig is a well typed config that is parsed from the CLI params.
+// This also contains config options for auxiliary services.
+// It is transformed into a `Config` before the challenger is started.
+type CLIConfig struct {
+	/* Required Params */
+
+	// L1EthRpc is the HTTP provider URL for L1.
+	L1EthRpc string
+
+	// RollupRpc is the HTTP provider URL for the trusted rollup node.
+	RollupRpc string
+
+	// L2EthRpc is the HTTP provider URL for the trusted L2 execution client.
+	L2EthRpc string
+
+	// L2OOAddress is the L2OutputOracle contract address.
+	L2OOAddress string
+
+	// PollInterval is the delay between querying L1 for new output proposals.
+	PollInterval time.Duration
+
+	/* Optional Params */
+
+	// L1StartBlock is the L1 block to start following output proposals from.
+	// 0 starts at the current L1 head.
+	L1StartBlock uint64
+
+	// L1ConfirmationDepth is the number of L1 blocks to wait before processing output proposals.
+	L1ConfirmationDepth uint64
+
+	// WebhookURL receives a JSON POST request for every invalid output.
+	WebhookURL string
+
+	// DeleteInvalidOutputs enables deleteL2Outputs transactions for invalid outputs.
+	DeleteInvalidOutputs bool
+
+	TxMgrConfig txmgr.CLIConfig
+
+	RPCConfig oprpc.CLIConfig
+
+	LogConfig oplog.CLIConfig
+
+	MetricsConfig opmetrics.CLIConfig
+
+	PprofConfig oppprof.CLIConfig
+}
+
+func (c CLIConfig) Check() error {
+	if c.PollInterval == 0 {
+		return errors.New("must provide a poll interval")
+	}
+	if err := c.RPCConfig.Check(); err != nil {
+		return err
+	}
+	if err := c.LogConfig.Check(); err != nil {
+		return err
+	}
+	if err := c.MetricsConfig.Check(); err != nil {
+		return err
+	}
+	if err := c.PprofConfig.Check(); err != nil {
+		return err
+	}
+	if c.DeleteInvalidOutputs {
+		if err := c.TxMgrConfig.Check(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NewConfig parses the Config from the provided flags or environment variables.
+func NewConfig(ctx *cli.Context) CLIConfig {
+	return CLIConfig{
+		// Required Flags
+		L1EthRpc:     ctx.GlobalString(flags.L1EthRpcFlag.Name),
+		RollupRpc:    ctx.GlobalString(flags.RollupRpcFlag.Name),
+		L2EthRpc:     ctx.GlobalString(flags.L2EthRpcFlag.Name),
+		L2OOAddress:  ctx.GlobalString(flags.L2OOAddressFlag.Name),
+		PollInterval: ctx.GlobalDuration(flags.PollIntervalFlag.Name),
+		// Optional Flags
+		L1StartBlock:         ctx.GlobalUint64(flags.L1StartBlockFlag.Name),
+		L1ConfirmationDepth:  ctx.GlobalUint64(flags.L1ConfirmationDepthFlag.Name),
+		WebhookURL:           ctx.GlobalString(flags.WebhookURLFlag.Name),
+		DeleteInvalidOutputs: ctx.GlobalBool(flags.DeleteInvalidOutputsFlag.Name),
+		TxMgrConfig:          txmgr.ReadCLIConfig(ctx),
+		RPCConfig:            oprpc.ReadCLIConfig(ctx),
+		LogConfig:            oplog.ReadCLIConfig(ctx),
+		MetricsConfig:        opmetrics.ReadCLIConfig(ctx),
+		PprofConfig:          oppprof.ReadCLIConfig(ctx),
+	}
+}
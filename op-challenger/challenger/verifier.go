@@ -0,0 +1,86 @@
+package challenger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/ethereum-optimism/optimism/op-bindings/bindings"
+	"github.com/ethereum-optimism/optimism/op-bindings/predeploys"
+	"github.com/ethereum-optimism/optimism/op-node/eth"
+	"github.com/ethereum-optimism/optimism/op-node/rollup"
+)
+
+// ErrNotReady is returned when the trusted nodes have not yet synced the L2 block of an output.
+var ErrNotReady = errors.New("L2 block not yet safe on trusted rollup node")
+
+var errNoOutput = errors.New("rollup node returned no output")
+
+var supportedL2OutputVersion = eth.Bytes32{}
+
+// outputVerifier recomputes output roots from the trusted rollup node and L2 execution client.
+type outputVerifier struct {
+	rollupClient   RollupClient
+	l2Client       L2Client
+	networkTimeout time.Duration
+}
+
+// ExpectedOutputRoot computes the output root of the canonical L2 block at the given height.
+// The block hash is taken from the trusted rollup node, the state root and withdrawal storage root
+// are proven against it by the trusted L2 execution client.
+func (v *outputVerifier) ExpectedOutputRoot(ctx context.Context, l2BlockNumber uint64) (eth.Bytes32, error) {
+	cCtx, cancel := context.WithTimeout(ctx, v.networkTimeout)
+	defer cancel()
+	status, err := v.rollupClient.SyncStatus(cCtx)
+	if err != nil {
+		return eth.Bytes32{}, fmt.Errorf("failed to fetch sync status: %w", err)
+	}
+	if l2BlockNumber > status.SafeL2.Number {
+		return eth.Bytes32{}, fmt.Errorf("%w: block %d, safe head %d", ErrNotReady, l2BlockNumber, status.SafeL2.Number)
+	}
+
+	cCtx, cancel = context.WithTimeout(ctx, v.networkTimeout)
+	defer cancel()
+	output, err := v.rollupClient.OutputAtBlock(cCtx, l2BlockNumber)
+	if err != nil {
+		return eth.Bytes32{}, fmt.Errorf("failed to fetch output at block %d: %w", l2BlockNumber, err)
+	}
+	if output == nil {
+		return eth.Bytes32{}, fmt.Errorf("failed to fetch output at block %d: %w", l2BlockNumber, errNoOutput)
+	}
+	if output.Version != supportedL2OutputVersion {
+		return eth.Bytes32{}, fmt.Errorf("unsupported l2 output version: %s", output.Version)
+	}
+
+	cCtx, cancel = context.WithTimeout(ctx, v.networkTimeout)
+	defer cancel()
+	header, err := v.l2Client.HeaderByNumber(cCtx, new(big.Int).SetUint64(l2BlockNumber))
+	if err != nil {
+		return eth.Bytes32{}, fmt.Errorf("failed to fetch L2 header %d: %w", l2BlockNumber, err)
+	}
+	if header.Hash() != output.BlockRef.Hash {
+		return eth.Bytes32{}, fmt.Errorf("trusted nodes disagree on L2 block %d: rollup node has %s, execution client has %s",
+			l2BlockNumber, output.BlockRef.Hash, header.Hash())
+	}
+
+	cCtx, cancel = context.WithTimeout(ctx, v.networkTimeout)
+	defer cancel()
+	proof, err := v.l2Client.GetProof(cCtx, predeploys.L2ToL1MessagePasserAddr, []common.Hash{}, header.Hash().String())
+	if err != nil {
+		return eth.Bytes32{}, fmt.Errorf("failed to fetch message passer proof at block %d: %w", l2BlockNumber, err)
+	}
+	if err := proof.Verify(header.Root); err != nil {
+		return eth.Bytes32{}, fmt.Errorf("invalid message passer proof at block %d: %w", l2BlockNumber, err)
+	}
+
+	return rollup.ComputeL2OutputRoot(&bindings.TypesOutputRootProof{
+		Version:                  supportedL2OutputVersion,
+		StateRoot:                header.Root,
+		MessagePasserStorageRoot: proof.StorageHash,
+		LatestBlockhash:          header.Hash(),
+	})
+}
@@ -0,0 +1,67 @@
+package challenger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/ethereum-optimism/optimism/op-node/eth"
+)
+
+// InvalidOutput describes an output proposal that does not match the trusted L2 state.
+type InvalidOutput struct {
+	OutputIndex   uint64      `json:"outputIndex"`
+	L2BlockNumber uint64      `json:"l2BlockNumber"`
+	OutputRoot    eth.Bytes32 `json:"outputRoot"`
+	ExpectedRoot  eth.Bytes32 `json:"expectedOutputRoot"`
+	L1BlockNumber uint64      `json:"l1BlockNumber"`
+	L1TxHash      common.Hash `json:"l1TxHash"`
+}
+
+// Notifier is alerted of every invalid output.
+type Notifier interface {
+	NotifyInvalidOutput(ctx context.Context, output *InvalidOutput) error
+}
+
+type noopNotifier struct{}
+
+func (noopNotifier) NotifyInvalidOutput(context.Context, *InvalidOutput) error { return nil }
+
+// WebhookNotifier posts invalid outputs as JSON to a webhook URL.
+type WebhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+func NewWebhookNotifier(url string, timeout time.Duration) *WebhookNotifier {
+	return &WebhookNotifier{
+		url:    url,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+func (w *WebhookNotifier) NotifyInvalidOutput(ctx context.Context, output *InvalidOutput) error {
+	body, err := json.Marshal(output)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	res, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", res.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package flags
+
+import (
+	"github.com/urfave/cli"
+
+	opservice "github.com/ethereum-optimism/optimism/op-service"
+	oplog "github.com/ethereum-optimism/optimism/op-service/log"
+	opmetrics "github.com/ethereum-optimism/optimism/op-service/metrics"
+	oppprof "github.com/ethereum-optimism/optimism/op-service/pprof"
+	oprpc "github.com/ethereum-optimism/optimism/op-service/rpc"
+	"github.com/ethereum-optimism/optimism/op-service/txmgr"
+)
+
+const envVarPrefix = "OP_CHALLENGER"
+
+var (
+	// Required Flags
+	L1EthRpcFlag = cli.StringFlag{
+		Name:     "l1-eth-rpc",
+		Usage:    "HTTP provider URL for L1",
+		Required: true,
+		EnvVar:   opservice.PrefixEnvVar(envVarPrefix, "L1_ETH_RPC"),
+	}
+	RollupRpcFlag = cli.StringFlag{
+		Name:     "rollup-rpc",
+		Usage:    "HTTP provider URL for the trusted rollup node",
+		Required: true,
+		EnvVar:   opservice.PrefixEnvVar(envVarPrefix, "ROLLUP_RPC"),
+	}
+	L2EthRpcFlag = cli.StringFlag{
+		Name:     "l2-eth-rpc",
+		Usage:    "HTTP provider URL for the trusted L2 execution client",
+		Required: true,
+		EnvVar:   opservice.PrefixEnvVar(envVarPrefix, "L2_ETH_RPC"),
+	}
+	L2OOAddressFlag = cli.StringFlag{
+		Name:     "l2oo-address",
+		Usage:    "Address of the L2OutputOracle contract",
+		Required: true,
+		EnvVar:   opservice.PrefixEnvVar(envVarPrefix, "L2OO_ADDRESS"),
+	}
+	PollIntervalFlag = cli.DurationFlag{
+		Name:     "poll-interval",
+		Usage:    "Delay between querying L1 for new output proposals",
+		Required: true,
+		EnvVar:   opservice.PrefixEnvVar(envVarPrefix, "POLL_INTERVAL"),
+	}
+	// Optional flags
+	L1StartBlockFlag = cli.Uint64Flag{
+		Name:   "l1-start-block",
+		Usage:  "L1 block to start following OutputProposed events from. 0 starts at the current L1 head",
+		EnvVar: opservice.PrefixEnvVar(envVarPrefix, "L1_START_BLOCK"),
+	}
+	L1ConfirmationDepthFlag = cli.Uint64Flag{
+		Name:   "l1-confirmation-depth",
+		Usage:  "Number of L1 blocks to wait before processing OutputProposed events",
+		Value:  3,
+		EnvVar: opservice.PrefixEnvVar(envVarPrefix, "L1_CONFIRMATION_DEPTH"),
+	}
+	WebhookURLFlag = cli.StringFlag{
+		Name:   "webhook-url",
+		Usage:  "URL that receives a JSON POST request for every invalid output",
+		EnvVar: opservice.PrefixEnvVar(envVarPrefix, "WEBHOOK_URL"),
+	}
+	DeleteInvalidOutputsFlag = cli.BoolFlag{
+		Name: "delete-invalid-outputs",
+		Usage: "Submit deleteL2Outputs transactions for invalid outputs. " +
+			"The transaction manager key must be the L2OutputOracle CHALLENGER",
+		EnvVar: opservice.PrefixEnvVar(envVarPrefix, "DELETE_INVALID_OUTPUTS"),
+	}
+)
+
+var requiredFlags = []cli.Flag{
+	L1EthRpcFlag,
+	RollupRpcFlag,
+	L2EthRpcFlag,
+	L2OOAddressFlag,
+	PollIntervalFlag,
+}
+
+var optionalFlags = []cli.Flag{
+	L1StartBlockFlag,
+	L1ConfirmationDepthFlag,
+	WebhookURLFlag,
+	DeleteInvalidOutputsFlag,
+}
+
+func init() {
+	requiredFlags = append(requiredFlags, oprpc.CLIFlags(envVarPrefix)...)
+
+	optionalFlags = append(optionalFlags, oplog.CLIFlags(envVarPrefix)...)
+	optionalFlags = append(optionalFlags, opmetrics.CLIFlags(envVarPrefix)...)
+	optionalFlags = append(optionalFlags, oppprof.CLIFlags(envVarPrefix)...)
+	optionalFlags = append(optionalFlags, txmgr.CLIFlags(envVarPrefix)...)
+
+	Flags = append(requiredFlags, optionalFlags...)
+}
+
+// Flags contains the list of configuration options available to the binary.
+var Flags []cli.Flag
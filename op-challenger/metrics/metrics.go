@@ -0,0 +1,174 @@
+package metrics
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/prometheus/client_golang/prometheus"
+
+	opmetrics "github.com/ethereum-optimism/optimism/op-service/metrics"
+)
+
+const Namespace = "op_challenger"
+
+type Metricer interface {
+	RecordInfo(version string)
+	RecordUp()
+
+	RecordL1BlockProcessed(number uint64)
+	RecordOutputChecked(outputIndex uint64, valid bool)
+	RecordOutputCheckFailed(abandoned bool)
+	RecordOutputDeleted(outputIndex uint64)
+}
+
+type Metrics struct {
+	ns       string
+	registry *prometheus.Registry
+	factory  opmetrics.Factory
+
+	Info prometheus.GaugeVec
+	Up   prometheus.Gauge
+
+	L1BlockProcessed   prometheus.Gauge
+	OutputsChecked     prometheus.CounterVec
+	LatestOutputIndex  prometheus.GaugeVec
+	InvalidOutputs     prometheus.Gauge
+	OutputCheckFailed  prometheus.CounterVec
+	OutputsDeleted     prometheus.Counter
+	LatestDeletedIndex prometheus.Gauge
+}
+
+var _ Metricer = (*Metrics)(nil)
+
+func NewMetrics(procName string) *Metrics {
+	if procName == "" {
+		procName = "default"
+	}
+	ns := Namespace + "_" + procName
+
+	registry := opmetrics.NewRegistry()
+	factory := opmetrics.With(registry)
+
+	return &Metrics{
+		ns:       ns,
+		registry: registry,
+		factory:  factory,
+
+		Info: *factory.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: ns,
+			Name:      "info",
+			Help:      "Pseudo-metric tracking version and config info",
+		}, []string{
+			"version",
+		}),
+		Up: factory.NewGauge(prometheus.GaugeOpts{
+			Namespace: ns,
+			Name:      "up",
+			Help:      "1 if the op-challenger has finished starting up",
+		}),
+		L1BlockProcessed: factory.NewGauge(prometheus.GaugeOpts{
+			Namespace: ns,
+			Name:      "l1_block_processed",
+			Help:      "Latest L1 block scanned for OutputProposed events",
+		}),
+		OutputsChecked: *factory.NewCounterVec(prometheus.CounterOpts{
+			Namespace: ns,
+			Name:      "outputs_checked_total",
+			Help:      "Number of proposed outputs checked against the trusted L2 state, by result",
+		}, []string{
+			"result",
+		}),
+		LatestOutputIndex: *factory.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: ns,
+			Name:      "latest_output_index",
+			Help:      "Index of the latest checked output, by result",
+		}, []string{
+			"result",
+		}),
+		InvalidOutputs: factory.NewGauge(prometheus.GaugeOpts{
+			Namespace: ns,
+			Name:      "invalid_outputs",
+			Help:      "Number of invalid outputs detected since startup. Any non-zero value must be alerted on",
+		}),
+		OutputCheckFailed: *factory.NewCounterVec(prometheus.CounterOpts{
+			Namespace: ns,
+			Name:      "output_check_failures_total",
+			Help:      "Number of failed attempts to check or delete a proposed output, by whether the output is retried or abandoned once finalized",
+		}, []string{
+			"result",
+		}),
+		OutputsDeleted: factory.NewCounter(prometheus.CounterOpts{
+			Namespace: ns,
+			Name:      "outputs_deleted_total",
+			Help:      "Number of deleteL2Outputs transactions submitted",
+		}),
+		LatestDeletedIndex: factory.NewGauge(prometheus.GaugeOpts{
+			Namespace: ns,
+			Name:      "latest_deleted_output_index",
+			Help:      "Output index of the latest deleteL2Outputs transaction",
+		}),
+	}
+}
+
+func (m *Metrics) Serve(ctx context.Context, host string, port int) error {
+	return opmetrics.ListenAndServe(ctx, m.registry, host, port)
+}
+
+func (m *Metrics) StartBalanceMetrics(ctx context.Context,
+	l log.Logger, client *ethclient.Client, account common.Address) {
+	opmetrics.LaunchBalanceMetrics(ctx, l, m.registry, m.ns, client, account)
+}
+
+// RecordInfo sets a pseudo-metric that contains versioning and
+// config info for the op-challenger.
+func (m *Metrics) RecordInfo(version string) {
+	m.Info.WithLabelValues(version).Set(1)
+}
+
+// RecordUp sets the up metric to 1.
+func (m *Metrics) RecordUp() {
+	m.Up.Set(1)
+}
+
+// RecordL1BlockProcessed records the latest L1 block scanned for output proposals
+func (m *Metrics) RecordL1BlockProcessed(number uint64) {
+	m.L1BlockProcessed.Set(float64(number))
+}
+
+const (
+	OutputValid   = "valid"
+	OutputInvalid = "invalid"
+)
+
+// RecordOutputChecked records the result of checking a proposed output
+func (m *Metrics) RecordOutputChecked(outputIndex uint64, valid bool) {
+	result := OutputValid
+	if !valid {
+		result = OutputInvalid
+		m.InvalidOutputs.Inc()
+	}
+	m.OutputsChecked.WithLabelValues(result).Inc()
+	m.LatestOutputIndex.WithLabelValues(result).Set(float64(outputIndex))
+}
+
+const (
+	OutputCheckRetried   = "retried"
+	OutputCheckAbandoned = "abandoned"
+)
+
+// RecordOutputCheckFailed records a failed attempt to check or delete a proposed output
+func (m *Metrics) RecordOutputCheckFailed(abandoned bool) {
+	result := OutputCheckRetried
+	if abandoned {
+		result = OutputCheckAbandoned
+	}
+	m.OutputCheckFailed.WithLabelValues(result).Inc()
+}
+
+// RecordOutputDeleted records a deleteL2Outputs transaction
+func (m *Metrics) RecordOutputDeleted(outputIndex uint64) {
+	m.OutputsDeleted.Inc()
+	m.LatestDeletedIndex.Set(float64(outputIndex))
+}
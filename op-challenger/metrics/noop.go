@@ -0,0 +1,13 @@
+package metrics
+
+type noopMetrics struct{}
+
+var NoopMetrics Metricer = new(noopMetrics)
+
+func (*noopMetrics) RecordInfo(version string) {}
+func (*noopMetrics) RecordUp()                 {}
+
+func (*noopMetrics) RecordL1BlockProcessed(number uint64)               {}
+func (*noopMetrics) RecordOutputChecked(outputIndex uint64, valid bool) {}
+func (*noopMetrics) RecordOutputCheckFailed(abandoned bool)             {}
+func (*noopMetrics) RecordOutputDeleted(outputIndex uint64)             {}
@@ -157,6 +157,12 @@ func ProveWithdrawalParameters(ctx context.Context, proofCl ProofClient, l2Recei
 	if err != nil {
 		return ProvenWithdrawalParameters{}, err
 	}
+	return ProveWithdrawalParametersForEvent(ctx, proofCl, ev, header, l2OutputOracleContract)
+}
+
+// ProveWithdrawalParametersForEvent generates the withdrawal parameters and proof of an already parsed MessagePassed event.
+// This supports transactions that initiate multiple withdrawals. The same requirements for the header apply as for ProveWithdrawalParameters.
+func ProveWithdrawalParametersForEvent(ctx context.Context, proofCl ProofClient, ev *bindings.L2ToL1MessagePasserMessagePassed, header *types.Header, l2OutputOracleContract *bindings.L2OutputOracleCaller) (ProvenWithdrawalParameters, error) {
 	// Generate then verify the withdrawal proof
 	withdrawalHash, err := WithdrawalHash(ev)
 	if !bytes.Equal(withdrawalHash[:], ev.WithdrawalHash[:]) {
@@ -172,7 +178,7 @@ func ProveWithdrawalParameters(ctx context.Context, proofCl ProofClient, l2Recei
 	}
 
 	// Fetch the L2OutputIndex from the L2 Output Oracle caller (on L1)
-	l2OutputIndex, err := l2OutputOracleContract.GetL2OutputIndexAfter(&bind.CallOpts{}, header.Number)
+	l2OutputIndex, err := l2OutputOracleContract.GetL2OutputIndexAfter(&bind.CallOpts{Context: ctx}, header.Number)
 	if err != nil {
 		return ProvenWithdrawalParameters{}, fmt.Errorf("failed to get l2OutputIndex: %w", err)
 	}
@@ -46,6 +46,19 @@ var (
 		Usage:  "Allow the proposer to submit proposals for L2 blocks derived from non-finalized L1 blocks.",
 		EnvVar: opservice.PrefixEnvVar(envVarPrefix, "ALLOW_NON_FINALIZED"),
 	}
+	VerifierRollupRpcsFlag = cli.StringSliceFlag{
+		Name: "verifier-rollup-rpcs",
+		Usage: "HTTP provider URLs of additional rollup nodes. Outputs are only proposed if " +
+			"every verifier rollup node returns the same output root as the rollup node",
+		EnvVar: opservice.PrefixEnvVar(envVarPrefix, "VERIFIER_ROLLUP_RPCS"),
+	}
+	StandbyDelayFlag = cli.DurationFlag{
+		Name: "standby-delay",
+		Usage: "Run as a standby proposer: only propose an output once it has been ready for " +
+			"this long, plus up to a quarter of random jitter, without being proposed by the active proposer. " +
+			"0 runs as the active proposer",
+		EnvVar: opservice.PrefixEnvVar(envVarPrefix, "STANDBY_DELAY"),
+	}
 	// Legacy Flags
 	L2OutputHDPathFlag = txmgr.L2OutputHDPathFlag
 )
@@ -59,6 +72,8 @@ var requiredFlags = []cli.Flag{
 
 var optionalFlags = []cli.Flag{
 	AllowNonFinalizedFlag,
+	VerifierRollupRpcsFlag,
+	StandbyDelayFlag,
 }
 
 func init() {
@@ -2,6 +2,7 @@ package metrics
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/ethereum-optimism/optimism/op-node/eth"
 
@@ -23,6 +24,9 @@ type Metricer interface {
 	opmetrics.RefMetricer
 
 	RecordL2BlocksProposed(l2ref eth.L2BlockRef)
+
+	RecordOutputMismatch(rollupNode string)
+	RecordActive(active bool)
 }
 
 type Metrics struct {
@@ -34,6 +38,9 @@ type Metrics struct {
 
 	Info prometheus.GaugeVec
 	Up   prometheus.Gauge
+
+	OutputMismatches prometheus.CounterVec
+	Active           prometheus.Gauge
 }
 
 var _ Metricer = (*Metrics)(nil)
@@ -66,6 +73,18 @@ func NewMetrics(procName string) *Metrics {
 			Name:      "up",
 			Help:      "1 if the op-proposer has finished starting up",
 		}),
+		OutputMismatches: *factory.NewCounterVec(prometheus.CounterOpts{
+			Namespace: ns,
+			Name:      "output_mismatches_total",
+			Help:      "Number of outputs that were not proposed because a rollup node returned a mismatching output",
+		}, []string{
+			"rollup_node",
+		}),
+		Active: factory.NewGauge(prometheus.GaugeOpts{
+			Namespace: ns,
+			Name:      "active",
+			Help:      "1 if this op-proposer is proposing outputs, 0 if it is waiting as a standby",
+		}),
 	}
 }
 
@@ -92,9 +111,30 @@ func (m *Metrics) RecordUp() {
 
 const (
 	BlockProposed = "proposed"
+
+	PrimaryRollupNode = "primary"
 )
 
+// VerifierRollupNode returns the rollup_node label of the i-th verifier rollup node.
+func VerifierRollupNode(i int) string {
+	return fmt.Sprintf("verifier_%d", i)
+}
+
 // RecordL2BlocksProposed should be called when new L2 block is proposed
 func (m *Metrics) RecordL2BlocksProposed(l2ref eth.L2BlockRef) {
 	m.RecordL2Ref(BlockProposed, l2ref)
 }
+
+// RecordOutputMismatch should be called when a rollup node returns a mismatching output
+func (m *Metrics) RecordOutputMismatch(rollupNode string) {
+	m.OutputMismatches.WithLabelValues(rollupNode).Inc()
+}
+
+// RecordActive records whether the proposer is proposing or waiting as a standby
+func (m *Metrics) RecordActive(active bool) {
+	if active {
+		m.Active.Set(1)
+	} else {
+		m.Active.Set(0)
+	}
+}
@@ -13,3 +13,6 @@ func (*noopMetrics) RecordInfo(version string) {}
 func (*noopMetrics) RecordUp()                 {}
 
 func (*noopMetrics) RecordL2BlocksProposed(l2ref eth.L2BlockRef) {}
+
+func (*noopMetrics) RecordOutputMismatch(rollupNode string) {}
+func (*noopMetrics) RecordActive(active bool)               {}
@@ -1,12 +1,15 @@
 package proposer
 
 import (
+	"context"
+	"errors"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/urfave/cli"
 
+	"github.com/ethereum-optimism/optimism/op-node/eth"
 	"github.com/ethereum-optimism/optimism/op-node/sources"
 	"github.com/ethereum-optimism/optimism/op-proposer/flags"
 
@@ -25,10 +28,24 @@ type Config struct {
 	NetworkTimeout     time.Duration
 	TxManager          txmgr.TxManager
 	L1Client           *ethclient.Client
-	RollupClient       *sources.RollupClient
+	RollupClient       RollupClient
 	AllowNonFinalized  bool
+
+	// VerifierRollupClients must all agree with RollupClient on an output before it is proposed.
+	VerifierRollupClients []RollupClient
+	// StandbyDelay is how long an output must be ready before a standby proposer proposes it,
+	// jittered by up to a quarter. A zero delay makes this proposer the active proposer.
+	StandbyDelay time.Duration
+}
+
+// RollupClient is the subset of the rollup node RPC used by the output submitter.
+type RollupClient interface {
+	OutputAtBlock(ctx context.Context, blockNum uint64) (*eth.OutputResponse, error)
+	SyncStatus(ctx context.Context) (*eth.SyncStatus, error)
 }
 
+var _ RollupClient = (*sources.RollupClient)(nil)
+
 // CLIConfig is a well typed config that is parsed from the CLI params.
 // This also contains config options for auxiliary services.
 // It is transformed into a `Config` before the L2 output submitter is started.
@@ -52,6 +69,14 @@ type CLIConfig struct {
 	// for L2 blocks derived from non-finalized L1 data.
 	AllowNonFinalized bool
 
+	// VerifierRollupRpcs are the HTTP provider URLs of the rollup nodes used to
+	// cross-check every output before it is proposed.
+	VerifierRollupRpcs []string
+
+	// StandbyDelay runs the proposer in standby mode when non-zero: outputs are only
+	// proposed once they have been ready for this long without being proposed by the active proposer.
+	StandbyDelay time.Duration
+
 	TxMgrConfig txmgr.CLIConfig
 
 	RPCConfig oprpc.CLIConfig
@@ -79,6 +104,14 @@ func (c CLIConfig) Check() error {
 	if err := c.TxMgrConfig.Check(); err != nil {
 		return err
 	}
+	if c.StandbyDelay < 0 {
+		return errors.New("standby delay must not be negative")
+	}
+	for _, url := range c.VerifierRollupRpcs {
+		if url == c.RollupRpc {
+			return errors.New("verifier rollup rpcs must be distinct from the rollup rpc")
+		}
+	}
 	return nil
 }
 
@@ -92,10 +125,12 @@ func NewConfig(ctx *cli.Context) CLIConfig {
 		PollInterval: ctx.GlobalDuration(flags.PollIntervalFlag.Name),
 		TxMgrConfig:  txmgr.ReadCLIConfig(ctx),
 		// Optional Flags
-		AllowNonFinalized: ctx.GlobalBool(flags.AllowNonFinalizedFlag.Name),
-		RPCConfig:         oprpc.ReadCLIConfig(ctx),
-		LogConfig:         oplog.ReadCLIConfig(ctx),
-		MetricsConfig:     opmetrics.ReadCLIConfig(ctx),
-		PprofConfig:       oppprof.ReadCLIConfig(ctx),
+		AllowNonFinalized:  ctx.GlobalBool(flags.AllowNonFinalizedFlag.Name),
+		VerifierRollupRpcs: ctx.GlobalStringSlice(flags.VerifierRollupRpcsFlag.Name),
+		StandbyDelay:       ctx.GlobalDuration(flags.StandbyDelayFlag.Name),
+		RPCConfig:          oprpc.ReadCLIConfig(ctx),
+		LogConfig:          oplog.ReadCLIConfig(ctx),
+		MetricsConfig:      opmetrics.ReadCLIConfig(ctx),
+		PprofConfig:        oppprof.ReadCLIConfig(ctx),
 	}
 }
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"math/rand"
 	_ "net/http/pprof"
 	"os"
 	"os/signal"
@@ -20,7 +21,6 @@ import (
 
 	"github.com/ethereum-optimism/optimism/op-bindings/bindings"
 	"github.com/ethereum-optimism/optimism/op-node/eth"
-	"github.com/ethereum-optimism/optimism/op-node/sources"
 	"github.com/ethereum-optimism/optimism/op-proposer/metrics"
 	oplog "github.com/ethereum-optimism/optimism/op-service/log"
 	oppprof "github.com/ethereum-optimism/optimism/op-service/pprof"
@@ -120,7 +120,9 @@ type L2OutputSubmitter struct {
 	cancel context.CancelFunc
 
 	// RollupClient is used to retrieve output roots from
-	rollupClient *sources.RollupClient
+	rollupClient RollupClient
+	// verifier cross-checks outputs with the verifier rollup nodes before they are proposed
+	verifier *outputVerifier
 
 	l2ooContract     *bindings.L2OutputOracleCaller
 	l2ooContractAddr common.Address
@@ -134,6 +136,15 @@ type L2OutputSubmitter struct {
 	// How frequently to poll L2 for new finalized outputs
 	pollInterval   time.Duration
 	networkTimeout time.Duration
+
+	// standbyDelay is how long a ready output is left to the active proposer before this
+	// proposer takes over. Zero means this is the active proposer.
+	standbyDelay time.Duration
+	// readyBlock is the block number of the next output, readySince is when it first became ready
+	// and readyDelay is the jittered standby delay drawn for it.
+	readyBlock uint64
+	readySince time.Time
+	readyDelay time.Duration
 }
 
 // NewL2OutputSubmitterFromCLIConfig creates a new L2 Output Submitter given the CLI Config
@@ -170,6 +181,15 @@ func NewL2OutputSubmitterConfigFromCLIConfig(cfg CLIConfig, l log.Logger) (*Conf
 		return nil, err
 	}
 
+	verifierClients := make([]RollupClient, 0, len(cfg.VerifierRollupRpcs))
+	for _, url := range cfg.VerifierRollupRpcs {
+		verifierClient, err := dialRollupClientWithTimeout(ctx, url)
+		if err != nil {
+			return nil, err
+		}
+		verifierClients = append(verifierClients, verifierClient)
+	}
+
 	return &Config{
 		L2OutputOracleAddr: l2ooAddress,
 		PollInterval:       cfg.PollInterval,
@@ -178,6 +198,9 @@ func NewL2OutputSubmitterConfigFromCLIConfig(cfg CLIConfig, l log.Logger) (*Conf
 		RollupClient:       rollupClient,
 		AllowNonFinalized:  cfg.AllowNonFinalized,
 		TxManager:          txManager,
+
+		VerifierRollupClients: verifierClients,
+		StandbyDelay:          cfg.StandbyDelay,
 	}, nil
 
 }
@@ -216,6 +239,7 @@ func NewL2OutputSubmitter(cfg Config, l log.Logger, m metrics.Metricer) (*L2Outp
 		metr:   m,
 
 		rollupClient: cfg.RollupClient,
+		verifier:     newOutputVerifier(l, m, cfg.VerifierRollupClients, cfg.NetworkTimeout),
 
 		l2ooContract:     l2ooContract,
 		l2ooContractAddr: cfg.L2OutputOracleAddr,
@@ -224,10 +248,12 @@ func NewL2OutputSubmitter(cfg Config, l log.Logger, m metrics.Metricer) (*L2Outp
 		allowNonFinalized: cfg.AllowNonFinalized,
 		pollInterval:      cfg.PollInterval,
 		networkTimeout:    cfg.NetworkTimeout,
+		standbyDelay:      cfg.StandbyDelay,
 	}, nil
 }
 
 func (l *L2OutputSubmitter) Start() error {
+	l.metr.RecordActive(l.standbyDelay == 0)
 	l.wg.Add(1)
 	go l.loop()
 	return nil
@@ -303,9 +329,53 @@ func (l *L2OutputSubmitter) fetchOuput(ctx context.Context, block *big.Int) (*et
 			"allow_non_finalized", l.allowNonFinalized)
 		return nil, false, nil
 	}
+	if err := l.verifier.Verify(ctx, output); err != nil {
+		return nil, false, err
+	}
 	return output, true, nil
 }
 
+// standbyTurn returns true if it is this proposer's turn to propose the ready output.
+// The active proposer always proposes. A standby proposer only takes over once the output
+// has been ready for the standby delay, i.e. the active proposer failed to propose it.
+// The delay is jittered by up to a quarter per output, so that standby proposers sharing
+// the same delay don't all take over at once.
+func (l *L2OutputSubmitter) standbyTurn(output *eth.OutputResponse, now time.Time) bool {
+	if l.standbyDelay == 0 {
+		return true
+	}
+	if l.readySince.IsZero() || l.readyBlock != output.BlockRef.Number {
+		l.readyBlock = output.BlockRef.Number
+		l.readySince = now
+		l.readyDelay = l.standbyDelay + time.Duration(rand.Int63n(int64(l.standbyDelay/4)+1))
+	}
+	if waited := now.Sub(l.readySince); waited < l.readyDelay {
+		l.log.Debug("standby proposer waiting for the active proposer",
+			"l2_proposal", output.BlockRef, "waited", waited, "standby_delay", l.readyDelay)
+		l.metr.RecordActive(false)
+		return false
+	}
+	l.log.Warn("active proposer did not propose output in time, standby proposer taking over",
+		"l2_proposal", output.BlockRef, "standby_delay", l.readyDelay)
+	l.metr.RecordActive(true)
+	return true
+}
+
+// alreadyProposed returns true if the output oracle has moved past the output, e.g. because
+// another proposer proposed it since it was fetched.
+func (l *L2OutputSubmitter) alreadyProposed(ctx context.Context, output *eth.OutputResponse) (bool, error) {
+	cCtx, cancel := context.WithTimeout(ctx, l.networkTimeout)
+	defer cancel()
+	nextCheckpointBlock, err := l.l2ooContract.NextBlockNumber(&bind.CallOpts{
+		From:    l.txMgr.From(),
+		Context: cCtx,
+	})
+	if err != nil {
+		return false, err
+	}
+	return nextCheckpointBlock.Uint64() > output.BlockRef.Number, nil
+}
+
 // ProposeL2OutputTxData creates the transaction data for the ProposeL2Output function
 func (l *L2OutputSubmitter) ProposeL2OutputTxData(output *eth.OutputResponse) ([]byte, error) {
 	return proposeL2OutputTxData(l.l2ooABI, output)
@@ -358,6 +428,20 @@ func (l *L2OutputSubmitter) loop() {
 			if !shouldPropose {
 				break
 			}
+			if !l.standbyTurn(output, time.Now()) {
+				break
+			}
+			// The active proposer may have proposed the output while it was verified or
+			// while this standby proposer waited.
+			proposed, err := l.alreadyProposed(ctx, output)
+			if err != nil {
+				l.log.Error("proposer unable to get next block number", "err", err)
+				break
+			}
+			if proposed {
+				l.log.Info("output already proposed", "l2_proposal", output.BlockRef)
+				break
+			}
 
 			cCtx, cancel := context.WithTimeout(ctx, 10*time.Minute)
 			if err := l.sendTransaction(cCtx, output); err != nil {
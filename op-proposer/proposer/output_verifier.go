@@ -0,0 +1,98 @@
+package proposer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/ethereum-optimism/optimism/op-bindings/bindings"
+	"github.com/ethereum-optimism/optimism/op-node/eth"
+	"github.com/ethereum-optimism/optimism/op-node/rollup"
+	"github.com/ethereum-optimism/optimism/op-proposer/metrics"
+)
+
+// ErrOutputMismatch is returned when the rollup nodes disagree on an output.
+var ErrOutputMismatch = errors.New("output mismatch")
+
+var errNoOutput = errors.New("rollup node returned no output")
+
+// outputVerifier cross-checks the output returned by the rollup node before it is proposed.
+// Every output root is recomputed from its components, and every verifier rollup node
+// must return the same output for the block.
+type outputVerifier struct {
+	log            log.Logger
+	metr           metrics.Metricer
+	verifiers      []RollupClient
+	networkTimeout time.Duration
+}
+
+func newOutputVerifier(l log.Logger, m metrics.Metricer, verifiers []RollupClient, networkTimeout time.Duration) *outputVerifier {
+	return &outputVerifier{
+		log:            l,
+		metr:           m,
+		verifiers:      verifiers,
+		networkTimeout: networkTimeout,
+	}
+}
+
+// Verify returns an error if the output cannot be verified, and wraps ErrOutputMismatch
+// if any rollup node returned a different or inconsistent output.
+func (v *outputVerifier) Verify(ctx context.Context, output *eth.OutputResponse) error {
+	if err := checkOutputRoot(output); err != nil {
+		v.metr.RecordOutputMismatch(metrics.PrimaryRollupNode)
+		v.log.Error("rollup node returned an inconsistent output root", "block", output.BlockRef, "err", err)
+		return err
+	}
+	for i, verifier := range v.verifiers {
+		name := metrics.VerifierRollupNode(i)
+		cCtx, cancel := context.WithTimeout(ctx, v.networkTimeout)
+		other, err := verifier.OutputAtBlock(cCtx, output.BlockRef.Number)
+		cancel()
+		if err == nil && other == nil {
+			err = errNoOutput
+		}
+		if err != nil {
+			v.log.Error("failed to fetch output from verifier rollup node", "verifier", name, "block", output.BlockRef.Number, "err", err)
+			return fmt.Errorf("failed to fetch output from %s: %w", name, err)
+		}
+		if err := checkOutputRoot(other); err != nil {
+			v.metr.RecordOutputMismatch(name)
+			v.log.Error("verifier rollup node returned an inconsistent output root", "verifier", name, "block", other.BlockRef, "err", err)
+			return err
+		}
+		if other.OutputRoot != output.OutputRoot || other.BlockRef.Hash != output.BlockRef.Hash {
+			v.metr.RecordOutputMismatch(name)
+			v.log.Error("refusing to propose: rollup nodes disagree on output",
+				"verifier", name,
+				"block", output.BlockRef.Number,
+				"output_root", output.OutputRoot, "verifier_output_root", other.OutputRoot,
+				"block_hash", output.BlockRef.Hash, "verifier_block_hash", other.BlockRef.Hash)
+			return fmt.Errorf("%w: %s returned output root %s for block %d, expected %s",
+				ErrOutputMismatch, name, other.OutputRoot, output.BlockRef.Number, output.OutputRoot)
+		}
+	}
+	return nil
+}
+
+// checkOutputRoot recomputes the output root from its components and compares it to the reported root.
+func checkOutputRoot(output *eth.OutputResponse) error {
+	if output == nil {
+		return errNoOutput
+	}
+	computed, err := rollup.ComputeL2OutputRoot(&bindings.TypesOutputRootProof{
+		Version:                  output.Version,
+		StateRoot:                output.StateRoot,
+		MessagePasserStorageRoot: output.WithdrawalStorageRoot,
+		LatestBlockhash:          output.BlockRef.Hash,
+	})
+	if err != nil {
+		return err
+	}
+	if computed != output.OutputRoot {
+		return fmt.Errorf("%w: reported output root %s does not match computed root %s", ErrOutputMismatch, output.OutputRoot, computed)
+	}
+	return nil
+}
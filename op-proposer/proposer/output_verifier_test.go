@@ -0,0 +1,104 @@
+package proposer
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ethereum-optimism/optimism/op-bindings/bindings"
+	"github.com/ethereum-optimism/optimism/op-node/eth"
+	"github.com/ethereum-optimism/optimism/op-node/rollup"
+	"github.com/ethereum-optimism/optimism/op-proposer/metrics"
+)
+
+type stubRollupClient struct {
+	outputs map[uint64]*eth.OutputResponse
+	err     error
+}
+
+func (s *stubRollupClient) OutputAtBlock(_ context.Context, blockNum uint64) (*eth.OutputResponse, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return s.outputs[blockNum], nil
+}
+
+func (s *stubRollupClient) SyncStatus(_ context.Context) (*eth.SyncStatus, error) {
+	return nil, errors.New("not implemented")
+}
+
+func makeOutput(t *testing.T, number uint64, stateRoot common.Hash) *eth.OutputResponse {
+	output := &eth.OutputResponse{
+		BlockRef:              eth.L2BlockRef{Hash: common.Hash{byte(number)}, Number: number},
+		WithdrawalStorageRoot: common.Hash{0xaa},
+		StateRoot:             stateRoot,
+	}
+	root, err := rollup.ComputeL2OutputRoot(&bindings.TypesOutputRootProof{
+		Version:                  output.Version,
+		StateRoot:                output.StateRoot,
+		MessagePasserStorageRoot: output.WithdrawalStorageRoot,
+		LatestBlockhash:          output.BlockRef.Hash,
+	})
+	require.NoError(t, err)
+	output.OutputRoot = root
+	return output
+}
+
+func TestOutputVerifier(t *testing.T) {
+	good := makeOutput(t, 10, common.Hash{0x01})
+	bad := makeOutput(t, 10, common.Hash{0x02})
+	inconsistent := makeOutput(t, 10, common.Hash{0x01})
+	inconsistent.StateRoot = common.Hash{0x03}
+
+	agreeing := &stubRollupClient{outputs: map[uint64]*eth.OutputResponse{10: good}}
+	disagreeing := &stubRollupClient{outputs: map[uint64]*eth.OutputResponse{10: bad}}
+	lying := &stubRollupClient{outputs: map[uint64]*eth.OutputResponse{10: inconsistent}}
+	offline := &stubRollupClient{err: errors.New("offline")}
+	empty := &stubRollupClient{}
+
+	newVerifier := func(verifiers ...RollupClient) *outputVerifier {
+		return newOutputVerifier(log.New(), metrics.NoopMetrics, verifiers, time.Second)
+	}
+	ctx := context.Background()
+
+	require.NoError(t, newVerifier().Verify(ctx, good))
+	require.NoError(t, newVerifier(agreeing, agreeing).Verify(ctx, good))
+	require.ErrorIs(t, newVerifier().Verify(ctx, inconsistent), ErrOutputMismatch)
+	require.ErrorIs(t, newVerifier(agreeing, disagreeing).Verify(ctx, good), ErrOutputMismatch)
+	require.ErrorIs(t, newVerifier(lying).Verify(ctx, good), ErrOutputMismatch)
+
+	err := newVerifier(offline).Verify(ctx, good)
+	require.Error(t, err)
+	require.NotErrorIs(t, err, ErrOutputMismatch)
+
+	// a rollup node returning no output without an error must not be trusted
+	require.ErrorIs(t, newVerifier(empty).Verify(ctx, good), errNoOutput)
+}
+
+func TestStandbyTurn(t *testing.T) {
+	newSubmitter := func(delay time.Duration) *L2OutputSubmitter {
+		return &L2OutputSubmitter{log: log.New(), metr: metrics.NoopMetrics, standbyDelay: delay}
+	}
+	now := time.Unix(1_000_000, 0)
+	output := makeOutput(t, 10, common.Hash{0x01})
+
+	active := newSubmitter(0)
+	require.True(t, active.standbyTurn(output, now))
+
+	standby := newSubmitter(time.Minute)
+	require.False(t, standby.standbyTurn(output, now))
+	require.False(t, standby.standbyTurn(output, now.Add(59*time.Second)))
+	require.GreaterOrEqual(t, standby.readyDelay, time.Minute)
+	require.LessOrEqual(t, standby.readyDelay, 75*time.Second, "jitter is at most a quarter of the delay")
+	require.True(t, standby.standbyTurn(output, now.Add(75*time.Second)), "standby takes over once the delay elapsed")
+
+	// the next output starts a new wait
+	next := makeOutput(t, 20, common.Hash{0x02})
+	require.False(t, standby.standbyTurn(next, now.Add(2*time.Minute)))
+	require.True(t, standby.standbyTurn(next, now.Add(2*time.Minute+75*time.Second)))
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/urfave/cli"
+
+	"github.com/ethereum-optimism/optimism/op-relayer/flags"
+	"github.com/ethereum-optimism/optimism/op-relayer/relayer"
+	oplog "github.com/ethereum-optimism/optimism/op-service/log"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+var (
+	Version   = "v0.1.0"
+	GitCommit = ""
+	GitDate   = ""
+)
+
+func main() {
+	oplog.SetupDefaults()
+
+	app := cli.NewApp()
+	app.Flags = flags.Flags
+	app.Version = fmt.Sprintf("%s-%s-%s", Version, GitCommit, GitDate)
+	app.Name = "op-relayer"
+	app.Usage = "Withdrawal Relayer"
+	app.Description = "Service for proving and finalizing L2 to L1 withdrawals on L1"
+
+	app.Action = curryMain(Version)
+	err := app.Run(os.Args)
+	if err != nil {
+		log.Crit("Application failed", "message", err)
+	}
+}
+
+// curryMain transforms the relayer.Main function into an app.Action
+// This is done to capture the Version of the relayer.
+func curryMain(version string) func(ctx *cli.Context) error {
+	return func(ctx *cli.Context) error {
+		return relayer.Main(version, ctx)
+	}
+}
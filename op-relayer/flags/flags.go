@@ -0,0 +1,98 @@
+package flags
+
+import (
+	"github.com/urfave/cli"
+
+	opservice "github.com/ethereum-optimism/optimism/op-service"
+	oplog "github.com/ethereum-optimism/optimism/op-service/log"
+	opmetrics "github.com/ethereum-optimism/optimism/op-service/metrics"
+	oppprof "github.com/ethereum-optimism/optimism/op-service/pprof"
+	oprpc "github.com/ethereum-optimism/optimism/op-service/rpc"
+	"github.com/ethereum-optimism/optimism/op-service/txmgr"
+)
+
+const envVarPrefix = "OP_RELAYER"
+
+var (
+	// Required Flags
+	L1EthRpcFlag = cli.StringFlag{
+		Name:     "l1-eth-rpc",
+		Usage:    "HTTP provider URL for L1",
+		Required: true,
+		EnvVar:   opservice.PrefixEnvVar(envVarPrefix, "L1_ETH_RPC"),
+	}
+	L2EthRpcFlag = cli.StringFlag{
+		Name:     "l2-eth-rpc",
+		Usage:    "HTTP provider URL for the L2 execution client. Must serve eth_getProof",
+		Required: true,
+		EnvVar:   opservice.PrefixEnvVar(envVarPrefix, "L2_ETH_RPC"),
+	}
+	OptimismPortalAddressFlag = cli.StringFlag{
+		Name:     "optimism-portal-address",
+		Usage:    "Address of the OptimismPortal contract",
+		Required: true,
+		EnvVar:   opservice.PrefixEnvVar(envVarPrefix, "OPTIMISM_PORTAL_ADDRESS"),
+	}
+	PollIntervalFlag = cli.DurationFlag{
+		Name:     "poll-interval",
+		Usage:    "Delay between querying L2 for new withdrawals and L1 for new outputs",
+		Required: true,
+		EnvVar:   opservice.PrefixEnvVar(envVarPrefix, "POLL_INTERVAL"),
+	}
+	StateFileFlag = cli.StringFlag{
+		Name:     "state-file",
+		Usage:    "File used to persist the scanned L2 blocks and the progress of pending withdrawals",
+		Required: true,
+		EnvVar:   opservice.PrefixEnvVar(envVarPrefix, "STATE_FILE"),
+	}
+	// Optional flags
+	AllowNonFinalizedFlag = cli.BoolFlag{
+		Name:   "allow-non-finalized",
+		Usage:  "Scan L2 blocks up to the safe head for withdrawals, instead of the finalized head",
+		EnvVar: opservice.PrefixEnvVar(envVarPrefix, "ALLOW_NON_FINALIZED"),
+	}
+	L2StartBlockFlag = cli.Uint64Flag{
+		Name:   "l2-start-block",
+		Usage:  "L2 block to start following MessagePassed events from, if the state file does not exist yet. 0 starts at the current L2 head",
+		EnvVar: opservice.PrefixEnvVar(envVarPrefix, "L2_START_BLOCK"),
+	}
+	SenderFilterFlag = cli.StringSliceFlag{
+		Name:   "sender",
+		Usage:  "Only relay withdrawals sent by these L2 addresses. Relays withdrawals of all senders if empty",
+		EnvVar: opservice.PrefixEnvVar(envVarPrefix, "SENDERS"),
+	}
+	TargetFilterFlag = cli.StringSliceFlag{
+		Name:   "target",
+		Usage:  "Only relay withdrawals to these L1 addresses. Relays withdrawals to all targets if empty",
+		EnvVar: opservice.PrefixEnvVar(envVarPrefix, "TARGETS"),
+	}
+)
+
+var requiredFlags = []cli.Flag{
+	L1EthRpcFlag,
+	L2EthRpcFlag,
+	OptimismPortalAddressFlag,
+	PollIntervalFlag,
+	StateFileFlag,
+}
+
+var optionalFlags = []cli.Flag{
+	AllowNonFinalizedFlag,
+	L2StartBlockFlag,
+	SenderFilterFlag,
+	TargetFilterFlag,
+}
+
+func init() {
+	requiredFlags = append(requiredFlags, oprpc.CLIFlags(envVarPrefix)...)
+
+	optionalFlags = append(optionalFlags, oplog.CLIFlags(envVarPrefix)...)
+	optionalFlags = append(optionalFlags, opmetrics.CLIFlags(envVarPrefix)...)
+	optionalFlags = append(optionalFlags, oppprof.CLIFlags(envVarPrefix)...)
+	optionalFlags = append(optionalFlags, txmgr.CLIFlags(envVarPrefix)...)
+
+	Flags = append(requiredFlags, optionalFlags...)
+}
+
+// Flags contains the list of configuration options available to the binary.
+var Flags []cli.Flag
@@ -0,0 +1,140 @@
+package metrics
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/prometheus/client_golang/prometheus"
+
+	opmetrics "github.com/ethereum-optimism/optimism/op-service/metrics"
+)
+
+const Namespace = "op_relayer"
+
+type Metricer interface {
+	RecordInfo(version string)
+	RecordUp()
+
+	RecordL2BlockProcessed(number uint64)
+	RecordWithdrawalInitiated()
+	RecordWithdrawalProven()
+	RecordWithdrawalFinalized()
+	RecordPendingWithdrawals(initiated int, proven int)
+}
+
+type Metrics struct {
+	ns       string
+	registry *prometheus.Registry
+	factory  opmetrics.Factory
+
+	Info prometheus.GaugeVec
+	Up   prometheus.Gauge
+
+	L2BlockProcessed   prometheus.Gauge
+	Withdrawals        prometheus.CounterVec
+	PendingWithdrawals prometheus.GaugeVec
+}
+
+var _ Metricer = (*Metrics)(nil)
+
+func NewMetrics(procName string) *Metrics {
+	if procName == "" {
+		procName = "default"
+	}
+	ns := Namespace + "_" + procName
+
+	registry := opmetrics.NewRegistry()
+	factory := opmetrics.With(registry)
+
+	return &Metrics{
+		ns:       ns,
+		registry: registry,
+		factory:  factory,
+
+		Info: *factory.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: ns,
+			Name:      "info",
+			Help:      "Pseudo-metric tracking version and config info",
+		}, []string{
+			"version",
+		}),
+		Up: factory.NewGauge(prometheus.GaugeOpts{
+			Namespace: ns,
+			Name:      "up",
+			Help:      "1 if the op-relayer has finished starting up",
+		}),
+		L2BlockProcessed: factory.NewGauge(prometheus.GaugeOpts{
+			Namespace: ns,
+			Name:      "l2_block_processed",
+			Help:      "Latest L2 block scanned for MessagePassed events",
+		}),
+		Withdrawals: *factory.NewCounterVec(prometheus.CounterOpts{
+			Namespace: ns,
+			Name:      "withdrawals_total",
+			Help:      "Number of withdrawals that reached a step of the withdrawal flow, by step",
+		}, []string{
+			"step",
+		}),
+		PendingWithdrawals: *factory.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: ns,
+			Name:      "pending_withdrawals",
+			Help:      "Number of withdrawals that are not finalized yet, by status",
+		}, []string{
+			"status",
+		}),
+	}
+}
+
+func (m *Metrics) Serve(ctx context.Context, host string, port int) error {
+	return opmetrics.ListenAndServe(ctx, m.registry, host, port)
+}
+
+func (m *Metrics) StartBalanceMetrics(ctx context.Context,
+	l log.Logger, client *ethclient.Client, account common.Address) {
+	opmetrics.LaunchBalanceMetrics(ctx, l, m.registry, m.ns, client, account)
+}
+
+// RecordInfo sets a pseudo-metric that contains versioning and
+// config info for the op-relayer.
+func (m *Metrics) RecordInfo(version string) {
+	m.Info.WithLabelValues(version).Set(1)
+}
+
+// RecordUp sets the up metric to 1.
+func (m *Metrics) RecordUp() {
+	m.Up.Set(1)
+}
+
+// RecordL2BlockProcessed records the latest L2 block scanned for withdrawals
+func (m *Metrics) RecordL2BlockProcessed(number uint64) {
+	m.L2BlockProcessed.Set(float64(number))
+}
+
+const (
+	StepInitiated = "initiated"
+	StepProven    = "proven"
+	StepFinalized = "finalized"
+)
+
+// RecordWithdrawalInitiated records a new withdrawal that matches the filters
+func (m *Metrics) RecordWithdrawalInitiated() {
+	m.Withdrawals.WithLabelValues(StepInitiated).Inc()
+}
+
+// RecordWithdrawalProven records a proven withdrawal
+func (m *Metrics) RecordWithdrawalProven() {
+	m.Withdrawals.WithLabelValues(StepProven).Inc()
+}
+
+// RecordWithdrawalFinalized records a finalized withdrawal
+func (m *Metrics) RecordWithdrawalFinalized() {
+	m.Withdrawals.WithLabelValues(StepFinalized).Inc()
+}
+
+// RecordPendingWithdrawals records the number of withdrawals waiting to be proven and finalized
+func (m *Metrics) RecordPendingWithdrawals(initiated int, proven int) {
+	m.PendingWithdrawals.WithLabelValues(StepInitiated).Set(float64(initiated))
+	m.PendingWithdrawals.WithLabelValues(StepProven).Set(float64(proven))
+}
@@ -0,0 +1,14 @@
+package metrics
+
+type noopMetrics struct{}
+
+var NoopMetrics Metricer = new(noopMetrics)
+
+func (*noopMetrics) RecordInfo(version string) {}
+func (*noopMetrics) RecordUp()                 {}
+
+func (*noopMetrics) RecordL2BlockProcessed(number uint64)               {}
+func (*noopMetrics) RecordWithdrawalInitiated()                         {}
+func (*noopMetrics) RecordWithdrawalProven()                            {}
+func (*noopMetrics) RecordWithdrawalFinalized()                         {}
+func (*noopMetrics) RecordPendingWithdrawals(initiated int, proven int) {}
@@ -0,0 +1,140 @@
+package relayer
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/urfave/cli"
+
+	"github.com/ethereum-optimism/optimism/op-node/withdrawals"
+	"github.com/ethereum-optimism/optimism/op-relayer/flags"
+	oplog "github.com/ethereum-optimism/optimism/op-service/log"
+	opmetrics "github.com/ethereum-optimism/optimism/op-service/metrics"
+	oppprof "github.com/ethereum-optimism/optimism/op-service/pprof"
+	oprpc "github.com/ethereum-optimism/optimism/op-service/rpc"
+	"github.com/ethereum-optimism/optimism/op-service/txmgr"
+)
+
+// Config contains the well typed fields that are used to initialize the relayer.
+// It is intended for programmatic use.
+type Config struct {
+	OptimismPortalAddr common.Address
+	PollInterval       time.Duration
+	NetworkTimeout     time.Duration
+	AllowNonFinalized  bool
+	Filter             Filter
+	Store              *Store
+	L1Client           *ethclient.Client
+	L2Client           *ethclient.Client
+	L2ProofClient      withdrawals.ProofClient
+	TxManager          txmgr.TxManager
+}
+
+// CLIConfig is a well typed config that is parsed from the CLI params.
+// This also contains config options for auxiliary services.
+// It is transformed into a `Config` before the relayer is started.
+type CLIConfig struct {
+	/* Required Params */
+
+	// L1EthRpc is the HTTP provider URL for L1.
+	L1EthRpc string
+
+	// L2EthRpc is the HTTP provider URL for the L2 execution client.
+	L2EthRpc string
+
+	// OptimismPortalAddress is the OptimismPortal contract address.
+	OptimismPortalAddress string
+
+	// PollInterval is the delay between querying L2 for new withdrawals and L1 for new outputs.
+	PollInterval time.Duration
+
+	// StateFile persists the relayer progress across restarts.
+	StateFile string
+
+	/* Optional Params */
+
+	// AllowNonFinalized scans L2 blocks up to the safe head instead of the finalized head.
+	AllowNonFinalized bool
+
+	// L2StartBlock is the L2 block to start following withdrawals from, if there is no state file yet.
+	// 0 starts at the current L2 head.
+	L2StartBlock uint64
+
+	// Senders restricts the relayed withdrawals to these L2 senders.
+	Senders []string
+
+	// Targets restricts the relayed withdrawals to these L1 targets.
+	Targets []string
+
+	TxMgrConfig txmgr.CLIConfig
+
+	RPCConfig oprpc.CLIConfig
+
+	LogConfig oplog.CLIConfig
+
+	MetricsConfig opmetrics.CLIConfig
+
+	PprofConfig oppprof.CLIConfig
+}
+
+func (c CLIConfig) Check() error {
+	if c.PollInterval == 0 {
+		return errors.New("must provide a poll interval")
+	}
+	if c.StateFile == "" {
+		return errors.New("must provide a state file")
+	}
+	if _, err := NewFilter(c.Senders, c.Targets); err != nil {
+		return err
+	}
+	if err := c.RPCConfig.Check(); err != nil {
+		return err
+	}
+	if err := c.LogConfig.Check(); err != nil {
+		return err
+	}
+	if err := c.MetricsConfig.Check(); err != nil {
+		return err
+	}
+	if err := c.PprofConfig.Check(); err != nil {
+		return err
+	}
+	if err := c.TxMgrConfig.Check(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// NewConfig parses the Config from the provided flags or environment variables.
+func NewConfig(ctx *cli.Context) CLIConfig {
+	return CLIConfig{
+		// Required Flags
+		L1EthRpc:              ctx.GlobalString(flags.L1EthRpcFlag.Name),
+		L2EthRpc:              ctx.GlobalString(flags.L2EthRpcFlag.Name),
+		OptimismPortalAddress: ctx.GlobalString(flags.OptimismPortalAddressFlag.Name),
+		PollInterval:          ctx.GlobalDuration(flags.PollIntervalFlag.Name),
+		StateFile:             ctx.GlobalString(flags.StateFileFlag.Name),
+		// Optional Flags
+		AllowNonFinalized: ctx.GlobalBool(flags.AllowNonFinalizedFlag.Name),
+		L2StartBlock:      ctx.GlobalUint64(flags.L2StartBlockFlag.Name),
+		Senders:           ctx.GlobalStringSlice(flags.SenderFilterFlag.Name),
+		Targets:           ctx.GlobalStringSlice(flags.TargetFilterFlag.Name),
+		TxMgrConfig:       txmgr.ReadCLIConfig(ctx),
+		RPCConfig:         oprpc.ReadCLIConfig(ctx),
+		LogConfig:         oplog.ReadCLIConfig(ctx),
+		MetricsConfig:     opmetrics.ReadCLIConfig(ctx),
+		PprofConfig:       oppprof.ReadCLIConfig(ctx),
+	}
+}
+
+// parseAddress parses an ETH address from a hex string. This method will fail if
+// the address is not a valid hexadecimal address.
+func parseAddress(address string) (common.Address, error) {
+	if common.IsHexAddress(address) {
+		return common.HexToAddress(address), nil
+	}
+	return common.Address{}, fmt.Errorf("invalid address: %v", address)
+}
@@ -0,0 +1,51 @@
+package relayer
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Filter restricts the relayed withdrawals by L2 sender and L1 target.
+// An empty list matches every address. A withdrawal must match both lists.
+type Filter struct {
+	Senders []common.Address
+	Targets []common.Address
+}
+
+// NewFilter parses the sender and target addresses of a Filter.
+func NewFilter(senders []string, targets []string) (Filter, error) {
+	var f Filter
+	for _, s := range senders {
+		addr, err := parseAddress(s)
+		if err != nil {
+			return Filter{}, fmt.Errorf("invalid sender filter: %w", err)
+		}
+		f.Senders = append(f.Senders, addr)
+	}
+	for _, t := range targets {
+		addr, err := parseAddress(t)
+		if err != nil {
+			return Filter{}, fmt.Errorf("invalid target filter: %w", err)
+		}
+		f.Targets = append(f.Targets, addr)
+	}
+	return f, nil
+}
+
+// Matches returns true if a withdrawal from sender to target passes the filter.
+func (f Filter) Matches(sender common.Address, target common.Address) bool {
+	return matchesAny(f.Senders, sender) && matchesAny(f.Targets, target)
+}
+
+func matchesAny(addrs []common.Address, addr common.Address) bool {
+	if len(addrs) == 0 {
+		return true
+	}
+	for _, a := range addrs {
+		if a == addr {
+			return true
+		}
+	}
+	return false
+}
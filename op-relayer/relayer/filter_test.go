@@ -0,0 +1,34 @@
+package relayer
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilter(t *testing.T) {
+	sender := common.HexToAddress("0x4200000000000000000000000000000000000007")
+	target := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	other := common.HexToAddress("0x2222222222222222222222222222222222222222")
+
+	f, err := NewFilter(nil, nil)
+	require.NoError(t, err)
+	require.True(t, f.Matches(sender, target))
+
+	f, err = NewFilter([]string{sender.Hex()}, nil)
+	require.NoError(t, err)
+	require.True(t, f.Matches(sender, target))
+	require.False(t, f.Matches(other, target))
+
+	f, err = NewFilter([]string{sender.Hex()}, []string{target.Hex(), other.Hex()})
+	require.NoError(t, err)
+	require.True(t, f.Matches(sender, target))
+	require.True(t, f.Matches(sender, other))
+	require.False(t, f.Matches(other, target))
+
+	_, err = NewFilter([]string{"0x1234"}, nil)
+	require.Error(t, err)
+	_, err = NewFilter(nil, []string{"not an address"})
+	require.Error(t, err)
+}
@@ -0,0 +1,614 @@
+package relayer
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	_ "net/http/pprof"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/ethclient/gethclient"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/urfave/cli"
+
+	"github.com/ethereum-optimism/optimism/op-bindings/bindings"
+	"github.com/ethereum-optimism/optimism/op-bindings/predeploys"
+	"github.com/ethereum-optimism/optimism/op-node/withdrawals"
+	"github.com/ethereum-optimism/optimism/op-relayer/metrics"
+	oplog "github.com/ethereum-optimism/optimism/op-service/log"
+	oppprof "github.com/ethereum-optimism/optimism/op-service/pprof"
+	oprpc "github.com/ethereum-optimism/optimism/op-service/rpc"
+	"github.com/ethereum-optimism/optimism/op-service/txmgr"
+)
+
+// maxL2BlockRange is the maximum number of L2 blocks queried for withdrawals at once.
+const maxL2BlockRange = 1000
+
+var defaultDialTimeout = 5 * time.Second
+
+// Main is the entrypoint into the Relayer. This method executes the
+// service and blocks until the service exits.
+func Main(version string, cliCtx *cli.Context) error {
+	cfg := NewConfig(cliCtx)
+	if err := cfg.Check(); err != nil {
+		return fmt.Errorf("invalid CLI flags: %w", err)
+	}
+
+	l := oplog.NewLogger(cfg.LogConfig)
+	m := metrics.NewMetrics("default")
+	l.Info("Initializing Relayer")
+
+	relayerConfig, err := NewRelayerConfigFromCLIConfig(cfg, l)
+	if err != nil {
+		l.Error("Unable to create the Relayer", "error", err)
+		return err
+	}
+
+	relayer, err := NewRelayer(*relayerConfig, l, m)
+	if err != nil {
+		l.Error("Unable to create the Relayer", "error", err)
+		return err
+	}
+
+	l.Info("Starting Relayer")
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := relayer.Start(); err != nil {
+		cancel()
+		l.Error("Unable to start Relayer", "error", err)
+		return err
+	}
+	defer relayer.Stop()
+
+	l.Info("Relayer started")
+	pprofConfig := cfg.PprofConfig
+	if pprofConfig.Enabled {
+		l.Info("starting pprof", "addr", pprofConfig.ListenAddr, "port", pprofConfig.ListenPort)
+		go func() {
+			if err := oppprof.ListenAndServe(ctx, pprofConfig.ListenAddr, pprofConfig.ListenPort); err != nil {
+				l.Error("error starting pprof", "err", err)
+			}
+		}()
+	}
+
+	metricsCfg := cfg.MetricsConfig
+	if metricsCfg.Enabled {
+		l.Info("starting metrics server", "addr", metricsCfg.ListenAddr, "port", metricsCfg.ListenPort)
+		go func() {
+			if err := m.Serve(ctx, metricsCfg.ListenAddr, metricsCfg.ListenPort); err != nil {
+				l.Error("error starting metrics server", err)
+			}
+		}()
+		m.StartBalanceMetrics(ctx, l, relayerConfig.L1Client, relayerConfig.TxManager.From())
+	}
+
+	rpcCfg := cfg.RPCConfig
+	server := oprpc.NewServer(rpcCfg.ListenAddr, rpcCfg.ListenPort, version, oprpc.WithLogger(l))
+	if err := server.Start(); err != nil {
+		cancel()
+		return fmt.Errorf("error starting RPC server: %w", err)
+	}
+
+	m.RecordInfo(version)
+	m.RecordUp()
+
+	interruptChannel := make(chan os.Signal, 1)
+	signal.Notify(interruptChannel, []os.Signal{
+		os.Interrupt,
+		os.Kill,
+		syscall.SIGTERM,
+		syscall.SIGQUIT,
+	}...)
+	<-interruptChannel
+	cancel()
+
+	return nil
+}
+
+// Relayer follows the withdrawals initiated on L2, proves each of them once an output covering
+// the withdrawal is posted to L1, and finalizes them once the finalization period has passed.
+type Relayer struct {
+	txMgr txmgr.TxManager
+	wg    sync.WaitGroup
+	done  chan struct{}
+	log   log.Logger
+	metr  metrics.Metricer
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	l1Client      *ethclient.Client
+	l2Client      *ethclient.Client
+	l2ProofClient withdrawals.ProofClient
+
+	messagePasser *bindings.L2ToL1MessagePasser
+
+	portalContract     *bindings.OptimismPortalCaller
+	portalContractAddr common.Address
+	portalABI          *abi.ABI
+	l2ooContract       *bindings.L2OutputOracleCaller
+
+	finalizationPeriod *big.Int
+
+	pollInterval   time.Duration
+	networkTimeout time.Duration
+
+	// allowNonFinalized scans L2 blocks up to the safe head instead of the finalized head.
+	// Withdrawals of unsafe blocks are never scanned, as they are likely to be reorged out.
+	allowNonFinalized bool
+
+	filter Filter
+	store  *Store
+}
+
+// NewRelayerFromCLIConfig creates a new Relayer given the CLI Config
+func NewRelayerFromCLIConfig(cfg CLIConfig, l log.Logger, m metrics.Metricer) (*Relayer, error) {
+	relayerConfig, err := NewRelayerConfigFromCLIConfig(cfg, l)
+	if err != nil {
+		return nil, err
+	}
+	return NewRelayer(*relayerConfig, l, m)
+}
+
+// NewRelayerConfigFromCLIConfig creates the relayer config from the CLI config.
+func NewRelayerConfigFromCLIConfig(cfg CLIConfig, l log.Logger) (*Config, error) {
+	portalAddress, err := parseAddress(cfg.OptimismPortalAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	filter, err := NewFilter(cfg.Senders, cfg.Targets)
+	if err != nil {
+		return nil, err
+	}
+
+	store, err := OpenStore(cfg.StateFile, cfg.L2StartBlock)
+	if err != nil {
+		return nil, err
+	}
+
+	txManagerConfig, err := txmgr.NewConfig(cfg.TxMgrConfig, l)
+	if err != nil {
+		return nil, err
+	}
+	txManager := txmgr.NewSimpleTxManager("relayer", l, txManagerConfig)
+
+	// Connect to L1 and L2 providers. Perform these last since they are the most expensive.
+	ctx := context.Background()
+	l1Client, err := dialEthClientWithTimeout(ctx, cfg.L1EthRpc)
+	if err != nil {
+		return nil, err
+	}
+
+	ctxt, cancel := context.WithTimeout(ctx, defaultDialTimeout)
+	defer cancel()
+	l2RPC, err := rpc.DialContext(ctxt, cfg.L2EthRpc)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Config{
+		OptimismPortalAddr: portalAddress,
+		PollInterval:       cfg.PollInterval,
+		NetworkTimeout:     cfg.TxMgrConfig.NetworkTimeout,
+		AllowNonFinalized:  cfg.AllowNonFinalized,
+		Filter:             filter,
+		Store:              store,
+		L1Client:           l1Client,
+		L2Client:           ethclient.NewClient(l2RPC),
+		L2ProofClient:      gethclient.New(l2RPC),
+		TxManager:          txManager,
+	}, nil
+}
+
+// NewRelayer creates a new Relayer
+func NewRelayer(cfg Config, l log.Logger, m metrics.Metricer) (*Relayer, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	portalContract, err := bindings.NewOptimismPortalCaller(cfg.OptimismPortalAddr, cfg.L1Client)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	cCtx, cCancel := context.WithTimeout(ctx, cfg.NetworkTimeout)
+	defer cCancel()
+	l2ooAddr, err := portalContract.L2ORACLE(&bind.CallOpts{Context: cCtx})
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	l2ooContract, err := bindings.NewL2OutputOracleCaller(l2ooAddr, cfg.L1Client)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	finalizationPeriod, err := l2ooContract.FINALIZATIONPERIODSECONDS(&bind.CallOpts{Context: cCtx})
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	log.Info("Connected to OptimismPortal", "address", cfg.OptimismPortalAddr, "l2oo", l2ooAddr, "finalization_period", finalizationPeriod)
+
+	messagePasser, err := bindings.NewL2ToL1MessagePasser(predeploys.L2ToL1MessagePasserAddr, cfg.L2Client)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	parsed, err := bindings.OptimismPortalMetaData.GetAbi()
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	return &Relayer{
+		txMgr:  cfg.TxManager,
+		done:   make(chan struct{}),
+		log:    l,
+		ctx:    ctx,
+		cancel: cancel,
+		metr:   m,
+
+		l1Client:      cfg.L1Client,
+		l2Client:      cfg.L2Client,
+		l2ProofClient: cfg.L2ProofClient,
+
+		messagePasser: messagePasser,
+
+		portalContract:     portalContract,
+		portalContractAddr: cfg.OptimismPortalAddr,
+		portalABI:          parsed,
+		l2ooContract:       l2ooContract,
+
+		finalizationPeriod: finalizationPeriod,
+
+		pollInterval:   cfg.PollInterval,
+		networkTimeout: cfg.NetworkTimeout,
+
+		allowNonFinalized: cfg.AllowNonFinalized,
+
+		filter: cfg.Filter,
+		store:  cfg.Store,
+	}, nil
+}
+
+func (r *Relayer) Start() error {
+	if !r.store.Persisted() && r.store.NextL2Block() == 0 {
+		head, err := r.l2Head(r.ctx)
+		if err != nil {
+			return err
+		}
+		if err := r.store.SetNextL2Block(head); err != nil {
+			return err
+		}
+	}
+	r.log.Info("Following withdrawals", "l2_start_block", r.store.NextL2Block(),
+		"pending_withdrawals", len(r.store.Withdrawals()), "senders", r.filter.Senders, "targets", r.filter.Targets)
+	r.wg.Add(1)
+	go r.loop()
+	return nil
+}
+
+func (r *Relayer) Stop() {
+	r.cancel()
+	close(r.done)
+	r.wg.Wait()
+}
+
+// l2Head returns the number of the last L2 block to scan, the finalized head or, if non-finalized
+// blocks are allowed, the safe head.
+func (r *Relayer) l2Head(ctx context.Context) (uint64, error) {
+	cCtx, cancel := context.WithTimeout(ctx, r.networkTimeout)
+	defer cancel()
+	header, err := r.l2Client.HeaderByNumber(cCtx, l2HeadTag(r.allowNonFinalized))
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch L2 head: %w", err)
+	}
+	return header.Number.Uint64(), nil
+}
+
+// l2HeadTag returns the block tag of the last L2 block to scan.
+func l2HeadTag(allowNonFinalized bool) *big.Int {
+	if allowNonFinalized {
+		return big.NewInt(int64(rpc.SafeBlockNumber))
+	}
+	return big.NewInt(int64(rpc.FinalizedBlockNumber))
+}
+
+// fetchWithdrawals adds the withdrawals of all L2 blocks that have not been scanned yet to the store.
+func (r *Relayer) fetchWithdrawals(ctx context.Context) error {
+	head, err := r.l2Head(ctx)
+	if err != nil {
+		return err
+	}
+
+	for start := r.store.NextL2Block(); start <= head; start = r.store.NextL2Block() {
+		end := start + maxL2BlockRange - 1
+		if end > head {
+			end = head
+		}
+		cCtx, cancel := context.WithTimeout(ctx, r.networkTimeout)
+		it, err := r.messagePasser.FilterMessagePassed(&bind.FilterOpts{Start: start, End: &end, Context: cCtx}, nil, r.filter.Senders, r.filter.Targets)
+		if err != nil {
+			cancel()
+			return fmt.Errorf("failed to filter withdrawals in L2 blocks %d-%d: %w", start, end, err)
+		}
+		var ws []*Withdrawal
+		for it.Next() {
+			if r.filter.Matches(it.Event.Sender, it.Event.Target) {
+				ws = append(ws, NewWithdrawal(it.Event))
+			}
+		}
+		err = it.Error()
+		it.Close()
+		cancel()
+		if err != nil {
+			return fmt.Errorf("failed to read withdrawals in L2 blocks %d-%d: %w", start, end, err)
+		}
+		for _, w := range ws {
+			r.log.Info("Withdrawal initiated", "withdrawal_hash", w.Hash, "sender", w.Sender, "target", w.Target,
+				"value", w.Value, "l2_block", w.L2BlockNumber, "l2_tx", w.L2TxHash)
+			r.metr.RecordWithdrawalInitiated()
+		}
+		if err := r.store.AddWithdrawals(ws, end); err != nil {
+			return fmt.Errorf("failed to store withdrawals: %w", err)
+		}
+		r.metr.RecordL2BlockProcessed(end)
+	}
+	return nil
+}
+
+// processWithdrawals proves and finalizes the pending withdrawals, in L2 block order.
+func (r *Relayer) processWithdrawals(ctx context.Context) {
+	cCtx, cancel := context.WithTimeout(ctx, r.networkTimeout)
+	defer cancel()
+	latestOutputBlock, err := r.l2ooContract.LatestBlockNumber(&bind.CallOpts{Context: cCtx})
+	if err != nil {
+		r.log.Error("Failed to fetch latest output block", "err", err)
+		return
+	}
+
+	for _, w := range r.store.Withdrawals() {
+		switch w.Status {
+		case StatusInitiated:
+			if w.L2BlockNumber > latestOutputBlock.Uint64() {
+				r.log.Debug("Waiting for output covering withdrawal", "withdrawal_hash", w.Hash, "l2_block", w.L2BlockNumber, "latest_output_block", latestOutputBlock)
+				continue
+			}
+			if err := r.proveWithdrawal(ctx, w); err != nil {
+				r.log.Error("Failed to prove withdrawal", "withdrawal_hash", w.Hash, "err", err)
+			}
+		case StatusProven:
+			if err := r.finalizeWithdrawal(ctx, w); err != nil {
+				r.log.Error("Failed to finalize withdrawal", "withdrawal_hash", w.Hash, "err", err)
+			}
+		}
+	}
+	r.recordPendingWithdrawals()
+}
+
+func (r *Relayer) recordPendingWithdrawals() {
+	var initiated, proven int
+	for _, w := range r.store.Withdrawals() {
+		switch w.Status {
+		case StatusInitiated:
+			initiated++
+		case StatusProven:
+			proven++
+		}
+	}
+	r.metr.RecordPendingWithdrawals(initiated, proven)
+}
+
+// ProveWithdrawalTxData creates the transaction data for the proveWithdrawalTransaction function
+func (r *Relayer) ProveWithdrawalTxData(w *Withdrawal, params withdrawals.ProvenWithdrawalParameters) ([]byte, error) {
+	return proveWithdrawalTxData(r.portalABI, w, params)
+}
+
+// proveWithdrawalTxData creates the transaction data for the proveWithdrawalTransaction function
+func proveWithdrawalTxData(abi *abi.ABI, w *Withdrawal, params withdrawals.ProvenWithdrawalParameters) ([]byte, error) {
+	return abi.Pack("proveWithdrawalTransaction", w.WithdrawalTransaction(), params.L2OutputIndex, params.OutputRootProof, params.WithdrawalProof)
+}
+
+// FinalizeWithdrawalTxData creates the transaction data for the finalizeWithdrawalTransaction function
+func (r *Relayer) FinalizeWithdrawalTxData(w *Withdrawal) ([]byte, error) {
+	return finalizeWithdrawalTxData(r.portalABI, w)
+}
+
+// finalizeWithdrawalTxData creates the transaction data for the finalizeWithdrawalTransaction function
+func finalizeWithdrawalTxData(abi *abi.ABI, w *Withdrawal) ([]byte, error) {
+	return abi.Pack("finalizeWithdrawalTransaction", w.WithdrawalTransaction())
+}
+
+// proveWithdrawal proves the withdrawal against the first output covering its L2 block,
+// unless it was proven by someone else already. Withdrawals that are not part of the L2 chain
+// of the output are dropped.
+func (r *Relayer) proveWithdrawal(ctx context.Context, w *Withdrawal) error {
+	cCtx, cancel := context.WithTimeout(ctx, r.networkTimeout)
+	defer cancel()
+	proven, err := r.portalContract.ProvenWithdrawals(&bind.CallOpts{Context: cCtx}, w.Hash)
+	if err != nil {
+		return fmt.Errorf("failed to fetch proven withdrawal: %w", err)
+	}
+	if proven.Timestamp.Sign() != 0 {
+		r.log.Info("Withdrawal was already proven", "withdrawal_hash", w.Hash, "output_index", proven.L2OutputIndex)
+		w.Status = StatusProven
+		return r.store.UpdateWithdrawal(w)
+	}
+
+	cCtx, cancel = context.WithTimeout(ctx, r.networkTimeout)
+	defer cancel()
+	outputIndex, err := r.l2ooContract.GetL2OutputIndexAfter(&bind.CallOpts{Context: cCtx}, new(big.Int).SetUint64(w.L2BlockNumber))
+	if err != nil {
+		return fmt.Errorf("failed to fetch output index: %w", err)
+	}
+	output, err := r.l2ooContract.GetL2Output(&bind.CallOpts{Context: cCtx}, outputIndex)
+	if err != nil {
+		return fmt.Errorf("failed to fetch output %d: %w", outputIndex, err)
+	}
+	header, err := r.l2Client.HeaderByNumber(cCtx, output.L2BlockNumber)
+	if err != nil {
+		return fmt.Errorf("failed to fetch L2 header %d: %w", output.L2BlockNumber, err)
+	}
+	// A withdrawal missing from the message passer at the output block was reorged out, and can
+	// never be proven.
+	sent, err := r.messagePasser.SentMessages(&bind.CallOpts{Context: cCtx, BlockNumber: output.L2BlockNumber}, w.Hash)
+	if err != nil {
+		return fmt.Errorf("failed to fetch sent message: %w", err)
+	}
+	if !sent {
+		r.log.Warn("Withdrawal is no longer canonical, dropping it", "withdrawal_hash", w.Hash,
+			"l2_block", w.L2BlockNumber, "l2_tx", w.L2TxHash, "output_block", output.L2BlockNumber)
+		return r.store.RemoveWithdrawal(w.Hash)
+	}
+	params, err := withdrawals.ProveWithdrawalParametersForEvent(cCtx, r.l2ProofClient, w.Event(), header, r.l2ooContract)
+	if err != nil {
+		return fmt.Errorf("failed to generate withdrawal proof: %w", err)
+	}
+
+	data, err := r.ProveWithdrawalTxData(w, params)
+	if err != nil {
+		return err
+	}
+	receipt, err := r.send(ctx, data)
+	if err != nil {
+		return fmt.Errorf("failed to send proveWithdrawalTransaction transaction: %w", err)
+	}
+	r.log.Info("Proved withdrawal", "withdrawal_hash", w.Hash, "output_index", params.L2OutputIndex, "tx_hash", receipt.TxHash)
+	r.metr.RecordWithdrawalProven()
+	w.Status = StatusProven
+	w.ProveTxHash = &receipt.TxHash
+	return r.store.UpdateWithdrawal(w)
+}
+
+// finalizeWithdrawal finalizes the proven withdrawal once the finalization period has passed,
+// unless it was finalized by someone else already. Withdrawals proven against an output that
+// was deleted since are proven again.
+func (r *Relayer) finalizeWithdrawal(ctx context.Context, w *Withdrawal) error {
+	cCtx, cancel := context.WithTimeout(ctx, r.networkTimeout)
+	defer cancel()
+	opts := &bind.CallOpts{Context: cCtx}
+	finalized, err := r.portalContract.FinalizedWithdrawals(opts, w.Hash)
+	if err != nil {
+		return fmt.Errorf("failed to fetch finalized withdrawal: %w", err)
+	}
+	if finalized {
+		r.log.Info("Withdrawal was already finalized", "withdrawal_hash", w.Hash)
+		return r.store.RemoveWithdrawal(w.Hash)
+	}
+
+	proven, err := r.portalContract.ProvenWithdrawals(opts, w.Hash)
+	if err != nil {
+		return fmt.Errorf("failed to fetch proven withdrawal: %w", err)
+	}
+	latestOutputIndex, err := r.l2ooContract.LatestOutputIndex(opts)
+	if err != nil {
+		return fmt.Errorf("failed to fetch latest output index: %w", err)
+	}
+	var output bindings.TypesOutputProposal
+	if proven.Timestamp.Sign() != 0 && proven.L2OutputIndex.Cmp(latestOutputIndex) <= 0 {
+		output, err = r.l2ooContract.GetL2Output(opts, proven.L2OutputIndex)
+		if err != nil {
+			return fmt.Errorf("failed to fetch output %d: %w", proven.L2OutputIndex, err)
+		}
+	}
+	if proven.Timestamp.Sign() == 0 || output.OutputRoot != proven.OutputRoot {
+		r.log.Warn("Proven output of withdrawal was deleted, proving again", "withdrawal_hash", w.Hash, "output_index", proven.L2OutputIndex)
+		w.Status = StatusInitiated
+		w.ProveTxHash = nil
+		return r.store.UpdateWithdrawal(w)
+	}
+
+	header, err := r.l1Client.HeaderByNumber(cCtx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to fetch L1 head: %w", err)
+	}
+	if !finalizationPeriodPassed(header.Time, r.finalizationPeriod, proven.Timestamp, output.Timestamp) {
+		r.log.Debug("Waiting for finalization period", "withdrawal_hash", w.Hash, "proven_timestamp", proven.Timestamp)
+		return nil
+	}
+
+	data, err := r.FinalizeWithdrawalTxData(w)
+	if err != nil {
+		return err
+	}
+	receipt, err := r.send(ctx, data)
+	if err != nil {
+		return fmt.Errorf("failed to send finalizeWithdrawalTransaction transaction: %w", err)
+	}
+	r.log.Info("Finalized withdrawal", "withdrawal_hash", w.Hash, "tx_hash", receipt.TxHash)
+	r.metr.RecordWithdrawalFinalized()
+	return r.store.RemoveWithdrawal(w.Hash)
+}
+
+// finalizationPeriodPassed returns true if a withdrawal, proven at provenTimestamp against an output
+// proposed at outputTimestamp, can be finalized in an L1 block with the given timestamp.
+// The OptimismPortal requires the finalization period to have passed since both timestamps.
+func finalizationPeriodPassed(l1Time uint64, finalizationPeriod *big.Int, provenTimestamp *big.Int, outputTimestamp *big.Int) bool {
+	since := provenTimestamp
+	if outputTimestamp.Cmp(since) > 0 {
+		since = outputTimestamp
+	}
+	deadline := new(big.Int).Add(since, finalizationPeriod)
+	return new(big.Int).SetUint64(l1Time).Cmp(deadline) > 0
+}
+
+// send submits a transaction to the OptimismPortal and waits for it to succeed.
+func (r *Relayer) send(ctx context.Context, data []byte) (*types.Receipt, error) {
+	cCtx, cancel := context.WithTimeout(ctx, 10*time.Minute)
+	defer cancel()
+	receipt, err := r.txMgr.Send(cCtx, txmgr.TxCandidate{
+		TxData:   data,
+		To:       r.portalContractAddr,
+		GasLimit: 0,
+		From:     r.txMgr.From(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return nil, fmt.Errorf("transaction %s reverted", receipt.TxHash)
+	}
+	return receipt, nil
+}
+
+// loop is responsible for following the withdrawals & relaying them
+func (r *Relayer) loop() {
+	defer r.wg.Done()
+
+	ctx := r.ctx
+
+	ticker := time.NewTicker(r.pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := r.fetchWithdrawals(ctx); err != nil {
+				r.log.Error("Failed to fetch withdrawals", "err", err)
+			}
+			r.processWithdrawals(ctx)
+		case <-r.done:
+			return
+		}
+	}
+}
+
+// dialEthClientWithTimeout attempts to dial the L1 provider using the provided
+// URL. If the dial doesn't complete within defaultDialTimeout seconds, this
+// method will return an error.
+func dialEthClientWithTimeout(ctx context.Context, url string) (*ethclient.Client, error) {
+	ctxt, cancel := context.WithTimeout(ctx, defaultDialTimeout)
+	defer cancel()
+
+	return ethclient.DialContext(ctxt, url)
+}
@@ -0,0 +1,59 @@
+package relayer
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ethereum-optimism/optimism/op-bindings/bindings"
+	"github.com/ethereum-optimism/optimism/op-node/withdrawals"
+)
+
+func TestFinalizationPeriodPassed(t *testing.T) {
+	period := big.NewInt(100)
+	// The period must have passed since the withdrawal was proven
+	require.False(t, finalizationPeriodPassed(1100, period, big.NewInt(1000), big.NewInt(900)))
+	require.True(t, finalizationPeriodPassed(1101, period, big.NewInt(1000), big.NewInt(900)))
+	// and since the output was proposed
+	require.False(t, finalizationPeriodPassed(1150, period, big.NewInt(1000), big.NewInt(1050)))
+	require.True(t, finalizationPeriodPassed(1151, period, big.NewInt(1000), big.NewInt(1050)))
+}
+
+func TestWithdrawalTxData(t *testing.T) {
+	portalABI, err := bindings.OptimismPortalMetaData.GetAbi()
+	require.NoError(t, err)
+	w := testWithdrawal(1, 10)
+
+	data, err := finalizeWithdrawalTxData(portalABI, w)
+	require.NoError(t, err)
+	method, err := portalABI.MethodById(data[:4])
+	require.NoError(t, err)
+	require.Equal(t, "finalizeWithdrawalTransaction", method.Name)
+
+	params := withdrawals.ProvenWithdrawalParameters{
+		L2OutputIndex: big.NewInt(3),
+		OutputRootProof: bindings.TypesOutputRootProof{
+			StateRoot:                common.Hash{0x01},
+			MessagePasserStorageRoot: common.Hash{0x02},
+			LatestBlockhash:          common.Hash{0x03},
+		},
+		WithdrawalProof: [][]byte{{0x04}, {0x05}},
+	}
+	data, err = proveWithdrawalTxData(portalABI, w, params)
+	require.NoError(t, err)
+	method, err = portalABI.MethodById(data[:4])
+	require.NoError(t, err)
+	require.Equal(t, "proveWithdrawalTransaction", method.Name)
+	args, err := method.Inputs.Unpack(data[4:])
+	require.NoError(t, err)
+	require.Equal(t, params.L2OutputIndex, args[1])
+	require.Equal(t, params.WithdrawalProof, args[3])
+}
+
+func TestL2HeadTag(t *testing.T) {
+	require.Equal(t, big.NewInt(int64(rpc.FinalizedBlockNumber)), l2HeadTag(false))
+	require.Equal(t, big.NewInt(int64(rpc.SafeBlockNumber)), l2HeadTag(true))
+}
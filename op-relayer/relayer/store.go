@@ -0,0 +1,210 @@
+package relayer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/ethereum-optimism/optimism/op-bindings/bindings"
+)
+
+type WithdrawalStatus string
+
+const (
+	// StatusInitiated withdrawals are waiting for an output covering their L2 block to be proven.
+	StatusInitiated WithdrawalStatus = "initiated"
+	// StatusProven withdrawals are waiting for the finalization period to pass to be finalized.
+	StatusProven WithdrawalStatus = "proven"
+)
+
+// Withdrawal is a withdrawal initiated on L2 that has not been finalized yet.
+type Withdrawal struct {
+	Hash          common.Hash      `json:"hash"`
+	Nonce         *hexutil.Big     `json:"nonce"`
+	Sender        common.Address   `json:"sender"`
+	Target        common.Address   `json:"target"`
+	Value         *hexutil.Big     `json:"value"`
+	GasLimit      *hexutil.Big     `json:"gasLimit"`
+	Data          hexutil.Bytes    `json:"data"`
+	L2BlockNumber uint64           `json:"l2BlockNumber"`
+	L2TxHash      common.Hash      `json:"l2TxHash"`
+	Status        WithdrawalStatus `json:"status"`
+	ProveTxHash   *common.Hash     `json:"proveTxHash,omitempty"`
+}
+
+// NewWithdrawal creates an initiated withdrawal from a MessagePassed event.
+func NewWithdrawal(ev *bindings.L2ToL1MessagePasserMessagePassed) *Withdrawal {
+	return &Withdrawal{
+		Hash:          ev.WithdrawalHash,
+		Nonce:         (*hexutil.Big)(ev.Nonce),
+		Sender:        ev.Sender,
+		Target:        ev.Target,
+		Value:         (*hexutil.Big)(ev.Value),
+		GasLimit:      (*hexutil.Big)(ev.GasLimit),
+		Data:          ev.Data,
+		L2BlockNumber: ev.Raw.BlockNumber,
+		L2TxHash:      ev.Raw.TxHash,
+		Status:        StatusInitiated,
+	}
+}
+
+// Event returns the MessagePassed event that initiated the withdrawal.
+func (w *Withdrawal) Event() *bindings.L2ToL1MessagePasserMessagePassed {
+	return &bindings.L2ToL1MessagePasserMessagePassed{
+		Nonce:          w.Nonce.ToInt(),
+		Sender:         w.Sender,
+		Target:         w.Target,
+		Value:          w.Value.ToInt(),
+		GasLimit:       w.GasLimit.ToInt(),
+		Data:           w.Data,
+		WithdrawalHash: w.Hash,
+		Raw:            types.Log{BlockNumber: w.L2BlockNumber, TxHash: w.L2TxHash},
+	}
+}
+
+// WithdrawalTransaction returns the withdrawal as passed to the OptimismPortal.
+func (w *Withdrawal) WithdrawalTransaction() bindings.TypesWithdrawalTransaction {
+	return bindings.TypesWithdrawalTransaction{
+		Nonce:    new(big.Int).Set(w.Nonce.ToInt()),
+		Sender:   w.Sender,
+		Target:   w.Target,
+		Value:    new(big.Int).Set(w.Value.ToInt()),
+		GasLimit: new(big.Int).Set(w.GasLimit.ToInt()),
+		Data:     w.Data,
+	}
+}
+
+type storeState struct {
+	NextL2Block uint64        `json:"nextL2Block"`
+	Withdrawals []*Withdrawal `json:"withdrawals"`
+}
+
+// Store tracks the next L2 block to scan and the withdrawals that are not finalized yet.
+// Every change is persisted to a JSON file, so the relayer resumes where it left off after a restart.
+// Finalized withdrawals are removed from the store.
+type Store struct {
+	path  string
+	state storeState
+	// persisted is false until the store has been written to its file for the first time
+	persisted bool
+}
+
+// OpenStore loads the store from the file at path. A new store, starting at l2StartBlock, is created
+// if the file does not exist yet.
+func OpenStore(path string, l2StartBlock uint64) (*Store, error) {
+	s := &Store{path: path}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		s.state.NextL2Block = l2StartBlock
+		return s, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read state file: %w", err)
+	}
+	if err := json.Unmarshal(data, &s.state); err != nil {
+		return nil, fmt.Errorf("failed to decode state file %s: %w", path, err)
+	}
+	s.persisted = true
+	return s, nil
+}
+
+// Persisted returns true if the store was loaded from, or has been written to, its file.
+func (s *Store) Persisted() bool {
+	return s.persisted
+}
+
+// NextL2Block returns the next L2 block to scan for withdrawals.
+func (s *Store) NextL2Block() uint64 {
+	return s.state.NextL2Block
+}
+
+// Withdrawals returns the withdrawals that are not finalized yet, ordered by L2 block.
+func (s *Store) Withdrawals() []*Withdrawal {
+	return append([]*Withdrawal(nil), s.state.Withdrawals...)
+}
+
+// SetNextL2Block sets the next L2 block to scan for withdrawals and persists the store.
+func (s *Store) SetNextL2Block(number uint64) error {
+	s.state.NextL2Block = number
+	return s.save()
+}
+
+// AddWithdrawals adds the withdrawals of the scanned L2 blocks, up to and including end,
+// and persists the store. Withdrawals that are tracked already are ignored.
+func (s *Store) AddWithdrawals(ws []*Withdrawal, end uint64) error {
+	for _, w := range ws {
+		if s.find(w.Hash) < 0 {
+			s.state.Withdrawals = append(s.state.Withdrawals, w)
+		}
+	}
+	sort.SliceStable(s.state.Withdrawals, func(i, j int) bool {
+		return s.state.Withdrawals[i].L2BlockNumber < s.state.Withdrawals[j].L2BlockNumber
+	})
+	s.state.NextL2Block = end + 1
+	return s.save()
+}
+
+// UpdateWithdrawal persists the changed status of a tracked withdrawal.
+func (s *Store) UpdateWithdrawal(w *Withdrawal) error {
+	i := s.find(w.Hash)
+	if i < 0 {
+		return fmt.Errorf("unknown withdrawal %s", w.Hash)
+	}
+	s.state.Withdrawals[i] = w
+	return s.save()
+}
+
+// RemoveWithdrawal stops tracking a finalized withdrawal and persists the store.
+func (s *Store) RemoveWithdrawal(hash common.Hash) error {
+	i := s.find(hash)
+	if i < 0 {
+		return nil
+	}
+	s.state.Withdrawals = append(s.state.Withdrawals[:i], s.state.Withdrawals[i+1:]...)
+	return s.save()
+}
+
+func (s *Store) find(hash common.Hash) int {
+	for i, w := range s.state.Withdrawals {
+		if w.Hash == hash {
+			return i
+		}
+	}
+	return -1
+}
+
+// save atomically replaces the state file, so a crash never leaves a partially written file behind.
+func (s *Store) save() error {
+	data, err := json.MarshalIndent(&s.state, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create state file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync state file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close state file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to replace state file: %w", err)
+	}
+	s.persisted = true
+	return nil
+}
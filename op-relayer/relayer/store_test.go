@@ -0,0 +1,78 @@
+package relayer
+
+import (
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/require"
+)
+
+func testWithdrawal(i byte, l2Block uint64) *Withdrawal {
+	return &Withdrawal{
+		Hash:          common.Hash{i},
+		Nonce:         (*hexutil.Big)(big.NewInt(int64(i))),
+		Sender:        common.Address{0xaa},
+		Target:        common.Address{0xbb},
+		Value:         (*hexutil.Big)(big.NewInt(1000)),
+		GasLimit:      (*hexutil.Big)(big.NewInt(100_000)),
+		Data:          []byte{i},
+		L2BlockNumber: l2Block,
+		L2TxHash:      common.Hash{0xee, i},
+		Status:        StatusInitiated,
+	}
+}
+
+func TestStoreNew(t *testing.T) {
+	s, err := OpenStore(filepath.Join(t.TempDir(), "state.json"), 42)
+	require.NoError(t, err)
+	require.False(t, s.Persisted())
+	require.Equal(t, uint64(42), s.NextL2Block())
+	require.Empty(t, s.Withdrawals())
+}
+
+func TestStorePersistsProgress(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	s, err := OpenStore(path, 0)
+	require.NoError(t, err)
+
+	w1, w2, w3 := testWithdrawal(1, 10), testWithdrawal(2, 5), testWithdrawal(3, 20)
+	require.NoError(t, s.AddWithdrawals([]*Withdrawal{w1, w2}, 15))
+	// Withdrawals that are tracked already are ignored
+	require.NoError(t, s.AddWithdrawals([]*Withdrawal{w3, testWithdrawal(1, 10)}, 30))
+	require.True(t, s.Persisted())
+	require.Equal(t, uint64(31), s.NextL2Block())
+	require.Equal(t, []*Withdrawal{w2, w1, w3}, s.Withdrawals())
+
+	proveTx := common.Hash{0xcc}
+	w1.Status = StatusProven
+	w1.ProveTxHash = &proveTx
+	require.NoError(t, s.UpdateWithdrawal(w1))
+	require.NoError(t, s.RemoveWithdrawal(w2.Hash))
+	require.Error(t, s.UpdateWithdrawal(w2))
+
+	// The store resumes where it left off, ignoring the start block
+	reopened, err := OpenStore(path, 100)
+	require.NoError(t, err)
+	require.True(t, reopened.Persisted())
+	require.Equal(t, uint64(31), reopened.NextL2Block())
+	require.Equal(t, []*Withdrawal{w1, w3}, reopened.Withdrawals())
+}
+
+func TestStoreInvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	s, err := OpenStore(path, 0)
+	require.NoError(t, err)
+	require.NoError(t, s.SetNextL2Block(7))
+	require.NoError(t, os.WriteFile(path, []byte("{"), 0600))
+	_, err = OpenStore(path, 0)
+	require.Error(t, err)
+}
+
+func TestWithdrawalEventRoundTrip(t *testing.T) {
+	w := testWithdrawal(1, 10)
+	require.Equal(t, w, NewWithdrawal(w.Event()))
+}
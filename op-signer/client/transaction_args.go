@@ -1,6 +1,8 @@
 package client
 
 import (
+	"bytes"
+	"errors"
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -82,3 +84,26 @@ func (args *TransactionArgs) ToTransaction() *types.Transaction {
 	}
 	return types.NewTx(data)
 }
+
+// Check validates that the arguments can be converted into a transaction with ToTransaction.
+func (args *TransactionArgs) Check() error {
+	if args.Nonce == nil {
+		return errors.New("missing nonce")
+	}
+	if args.Gas == nil {
+		return errors.New("missing gas limit")
+	}
+	if args.ChainID == nil {
+		return errors.New("missing chain id")
+	}
+	if args.MaxFeePerGas == nil || args.MaxPriorityFeePerGas == nil {
+		return errors.New("missing fee caps: only EIP-1559 transactions are supported")
+	}
+	if args.Value == nil {
+		return errors.New("missing value")
+	}
+	if args.Input != nil && args.Data != nil && !bytes.Equal(*args.Input, *args.Data) {
+		return errors.New("both data and input set and not equal")
+	}
+	return nil
+}
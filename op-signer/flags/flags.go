@@ -0,0 +1,84 @@
+package flags
+
+import (
+	"github.com/urfave/cli"
+
+	opservice "github.com/ethereum-optimism/optimism/op-service"
+	oplog "github.com/ethereum-optimism/optimism/op-service/log"
+	opmetrics "github.com/ethereum-optimism/optimism/op-service/metrics"
+	oppprof "github.com/ethereum-optimism/optimism/op-service/pprof"
+	oprpc "github.com/ethereum-optimism/optimism/op-service/rpc"
+	optls "github.com/ethereum-optimism/optimism/op-service/tls"
+)
+
+const envVarPrefix = "OP_SIGNER"
+
+const (
+	KeystoreBackend = "keystore"
+	PKCS11Backend   = "pkcs11"
+	MockBackend     = "mock"
+)
+
+var KeyBackends = []string{KeystoreBackend, PKCS11Backend, MockBackend}
+
+var (
+	// Required Flags
+	ServiceConfigFlag = cli.StringFlag{
+		Name:     "config",
+		Usage:    "Path to the signer service config file listing the authorized clients and their keys",
+		Required: true,
+		EnvVar:   opservice.PrefixEnvVar(envVarPrefix, "CONFIG"),
+	}
+	KeyBackendFlag = cli.StringFlag{
+		Name:   "key-backend",
+		Usage:  "Key backend used for signing. One of: keystore, pkcs11, mock",
+		Value:  KeystoreBackend,
+		EnvVar: opservice.PrefixEnvVar(envVarPrefix, "KEY_BACKEND"),
+	}
+	// Optional flags
+	KeystoreDirFlag = cli.StringFlag{
+		Name:   "keystore.dir",
+		Usage:  "Directory of the encrypted keystore, used by the keystore key backend",
+		EnvVar: opservice.PrefixEnvVar(envVarPrefix, "KEYSTORE_DIR"),
+	}
+	KeystorePasswordFileFlag = cli.StringFlag{
+		Name:   "keystore.password-file",
+		Usage:  "File containing the passphrase of the keystore accounts",
+		EnvVar: opservice.PrefixEnvVar(envVarPrefix, "KEYSTORE_PASSWORD_FILE"),
+	}
+	PKCS11TokenDirFlag = cli.StringFlag{
+		Name:   "pkcs11.software-token-dir",
+		Usage:  "Directory of <label>.key files loaded into the software PKCS#11 token. Development only.",
+		EnvVar: opservice.PrefixEnvVar(envVarPrefix, "PKCS11_SOFTWARE_TOKEN_DIR"),
+	}
+	AuditLogFileFlag = cli.StringFlag{
+		Name:   "audit-log.file",
+		Usage:  "File to append JSON audit records of signing requests to. Records are always logged.",
+		EnvVar: opservice.PrefixEnvVar(envVarPrefix, "AUDIT_LOG_FILE"),
+	}
+)
+
+var requiredFlags = []cli.Flag{
+	ServiceConfigFlag,
+}
+
+var optionalFlags = []cli.Flag{
+	KeyBackendFlag,
+	KeystoreDirFlag,
+	KeystorePasswordFileFlag,
+	PKCS11TokenDirFlag,
+	AuditLogFileFlag,
+}
+
+func init() {
+	optionalFlags = append(optionalFlags, oprpc.CLIFlags(envVarPrefix)...)
+	optionalFlags = append(optionalFlags, optls.CLIFlags(envVarPrefix)...)
+	optionalFlags = append(optionalFlags, oplog.CLIFlags(envVarPrefix)...)
+	optionalFlags = append(optionalFlags, opmetrics.CLIFlags(envVarPrefix)...)
+	optionalFlags = append(optionalFlags, oppprof.CLIFlags(envVarPrefix)...)
+
+	Flags = append(requiredFlags, optionalFlags...)
+}
+
+// Flags contains the list of configuration options available to the binary.
+var Flags []cli.Flag
@@ -0,0 +1,108 @@
+package metrics
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	opmetrics "github.com/ethereum-optimism/optimism/op-service/metrics"
+)
+
+const Namespace = "op_signer"
+
+type Metricer interface {
+	RecordInfo(version string)
+	RecordUp()
+
+	RecordSignTransactionRequest(client string, status string)
+	RecordPolicyViolation(key string, mode string)
+}
+
+type Metrics struct {
+	ns       string
+	registry *prometheus.Registry
+	factory  opmetrics.Factory
+
+	Info prometheus.GaugeVec
+	Up   prometheus.Gauge
+
+	SignTransactionRequests prometheus.CounterVec
+	PolicyViolations        prometheus.CounterVec
+}
+
+var _ Metricer = (*Metrics)(nil)
+
+func NewMetrics(procName string) *Metrics {
+	if procName == "" {
+		procName = "default"
+	}
+	ns := Namespace + "_" + procName
+
+	registry := opmetrics.NewRegistry()
+	factory := opmetrics.With(registry)
+
+	return &Metrics{
+		ns:       ns,
+		registry: registry,
+		factory:  factory,
+
+		Info: *factory.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: ns,
+			Name:      "info",
+			Help:      "Pseudo-metric tracking version and config info",
+		}, []string{
+			"version",
+		}),
+		Up: factory.NewGauge(prometheus.GaugeOpts{
+			Namespace: ns,
+			Name:      "up",
+			Help:      "1 if the op-signer has finished starting up",
+		}),
+		SignTransactionRequests: *factory.NewCounterVec(prometheus.CounterOpts{
+			Namespace: ns,
+			Name:      "sign_transaction_requests_total",
+			Help:      "Number of eth_signTransaction requests, by client and result status",
+		}, []string{
+			"client",
+			"status",
+		}),
+		PolicyViolations: *factory.NewCounterVec(prometheus.CounterOpts{
+			Namespace: ns,
+			Name:      "policy_violations_total",
+			Help:      "Number of signing requests that violated the policy of their key, by key and policy mode",
+		}, []string{
+			"key",
+			"mode",
+		}),
+	}
+}
+
+func (m *Metrics) Serve(ctx context.Context, host string, port int) error {
+	return opmetrics.ListenAndServe(ctx, m.registry, host, port)
+}
+
+// Registry returns the metrics registry, so it can be shared with the RPC server HTTP recorder.
+func (m *Metrics) Registry() *prometheus.Registry {
+	return m.registry
+}
+
+// RecordInfo sets a pseudo-metric that contains versioning and
+// config info for the op-signer.
+func (m *Metrics) RecordInfo(version string) {
+	m.Info.WithLabelValues(version).Set(1)
+}
+
+// RecordUp sets the up metric to 1.
+func (m *Metrics) RecordUp() {
+	m.Up.Set(1)
+}
+
+// RecordSignTransactionRequest counts an eth_signTransaction request and its outcome.
+func (m *Metrics) RecordSignTransactionRequest(client string, status string) {
+	m.SignTransactionRequests.WithLabelValues(client, status).Inc()
+}
+
+// RecordPolicyViolation counts a signing request that violated the policy of its key.
+func (m *Metrics) RecordPolicyViolation(key string, mode string) {
+	m.PolicyViolations.WithLabelValues(key, mode).Inc()
+}
@@ -0,0 +1,11 @@
+package metrics
+
+type noopMetrics struct{}
+
+var NoopMetrics Metricer = new(noopMetrics)
+
+func (*noopMetrics) RecordInfo(version string) {}
+func (*noopMetrics) RecordUp()                 {}
+
+func (*noopMetrics) RecordSignTransactionRequest(client string, status string) {}
+func (*noopMetrics) RecordPolicyViolation(key string, mode string)             {}
@@ -0,0 +1,220 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Mode controls what happens when a transaction violates a rule.
+type Mode string
+
+const (
+	// ModeEnforce rejects violating transactions. This is the default.
+	ModeEnforce Mode = "enforce"
+	// ModeLogOnly signs violating transactions, but logs and counts the violations.
+	// It is meant to roll out a new rule without risking an outage.
+	ModeLogOnly Mode = "log-only"
+	// ModeDryRun evaluates the rule but never signs. The verdict is returned to the client.
+	ModeDryRun Mode = "dry-run"
+)
+
+// Rule restricts the transactions that may be signed with a single key.
+// Unset fields do not restrict anything.
+type Rule struct {
+	// Key is the name of the key the rule applies to.
+	Key  string `yaml:"key"`
+	Mode Mode   `yaml:"mode"`
+
+	// ToAddresses is the allowlist of destinations.
+	ToAddresses []common.Address `yaml:"toAddresses"`
+	// AllowContractCreation permits transactions without a destination when ToAddresses is set.
+	AllowContractCreation bool `yaml:"allowContractCreation"`
+	// MaxValue is the maximum value in wei that may be transferred.
+	MaxValue *hexutil.Big `yaml:"maxValue"`
+	// MaxFeeCap is the maximum fee cap (maxFeePerGas) in wei.
+	MaxFeeCap *hexutil.Big `yaml:"maxFeeCap"`
+	// MethodSelectors is the allowlist of the first 4 bytes of the calldata.
+	MethodSelectors []hexutil.Bytes `yaml:"methodSelectors"`
+	// MaxTxPerHour limits the number of signatures in any sliding one hour window.
+	MaxTxPerHour uint64 `yaml:"maxTxPerHour"`
+}
+
+func (r *Rule) Check() error {
+	if r.Key == "" {
+		return errors.New("policy rule is missing a key")
+	}
+	switch r.Mode {
+	case "":
+		r.Mode = ModeEnforce
+	case ModeEnforce, ModeLogOnly, ModeDryRun:
+	default:
+		return fmt.Errorf("policy rule for %s has unknown mode %q", r.Key, r.Mode)
+	}
+	for _, sel := range r.MethodSelectors {
+		if len(sel) != 4 {
+			return fmt.Errorf("policy rule for %s has invalid method selector %s", r.Key, sel)
+		}
+	}
+	return nil
+}
+
+// Decision is the outcome of evaluating a transaction against the rule of its key.
+type Decision struct {
+	Mode Mode
+	// Reasons lists every violated rule. It is empty if the transaction complies.
+	Reasons []string
+}
+
+// Violated returns true if the transaction broke at least one rule.
+func (d *Decision) Violated() bool {
+	return len(d.Reasons) > 0
+}
+
+// Sign returns true if the transaction should be signed.
+func (d *Decision) Sign() bool {
+	switch d.Mode {
+	case ModeDryRun:
+		return false
+	case ModeLogOnly:
+		return true
+	default:
+		return !d.Violated()
+	}
+}
+
+func (d *Decision) String() string {
+	return strings.Join(d.Reasons, "; ")
+}
+
+// Engine evaluates signing requests against the configured per-key rules.
+// Keys without a rule are not restricted.
+type Engine struct {
+	rules map[string]*Rule
+	now   func() time.Time
+
+	mu     sync.Mutex
+	signed map[string][]time.Time
+}
+
+func NewEngine(rules []Rule) (*Engine, error) {
+	e := &Engine{
+		rules:  make(map[string]*Rule),
+		now:    time.Now,
+		signed: make(map[string][]time.Time),
+	}
+	for i := range rules {
+		rule := rules[i]
+		if err := rule.Check(); err != nil {
+			return nil, err
+		}
+		if _, ok := e.rules[KeyID(rule.Key)]; ok {
+			return nil, fmt.Errorf("duplicate policy rule for key %s", rule.Key)
+		}
+		e.rules[KeyID(rule.Key)] = &rule
+	}
+	return e, nil
+}
+
+// Evaluate checks the transaction against the rule of the key. The signature only counts
+// towards the hourly rate limit of the key once it is recorded with RecordSigned.
+func (e *Engine) Evaluate(key string, tx *types.Transaction) *Decision {
+	key = KeyID(key)
+	rule, ok := e.rules[key]
+	if !ok {
+		return &Decision{Mode: ModeEnforce}
+	}
+	d := &Decision{Mode: rule.Mode}
+
+	if len(rule.ToAddresses) > 0 {
+		if tx.To() == nil {
+			if !rule.AllowContractCreation {
+				d.Reasons = append(d.Reasons, "contract creation is not allowed")
+			}
+		} else if !containsAddress(rule.ToAddresses, *tx.To()) {
+			d.Reasons = append(d.Reasons, fmt.Sprintf("destination %s is not allowed", tx.To()))
+		}
+	}
+	if rule.MaxValue != nil && tx.Value().Cmp(rule.MaxValue.ToInt()) > 0 {
+		d.Reasons = append(d.Reasons, fmt.Sprintf("value %s exceeds maximum %s", tx.Value(), rule.MaxValue.ToInt()))
+	}
+	if rule.MaxFeeCap != nil && tx.GasFeeCap().Cmp(rule.MaxFeeCap.ToInt()) > 0 {
+		d.Reasons = append(d.Reasons, fmt.Sprintf("fee cap %s exceeds maximum %s", tx.GasFeeCap(), rule.MaxFeeCap.ToInt()))
+	}
+	if len(rule.MethodSelectors) > 0 {
+		data := tx.Data()
+		if len(data) < 4 {
+			d.Reasons = append(d.Reasons, "calldata without a method selector is not allowed")
+		} else if !containsSelector(rule.MethodSelectors, data[:4]) {
+			d.Reasons = append(d.Reasons, fmt.Sprintf("method selector %s is not allowed", hexutil.Bytes(data[:4])))
+		}
+	}
+
+	if rule.MaxTxPerHour > 0 {
+		e.mu.Lock()
+		recent := e.recent(key, e.now())
+		e.mu.Unlock()
+		if uint64(len(recent)) >= rule.MaxTxPerHour {
+			d.Reasons = append(d.Reasons, fmt.Sprintf("rate limit of %d transactions per hour exceeded", rule.MaxTxPerHour))
+		}
+	}
+	return d
+}
+
+// RecordSigned counts a transaction signed with the key towards its hourly rate limit.
+func (e *Engine) RecordSigned(key string) {
+	key = KeyID(key)
+	rule, ok := e.rules[key]
+	if !ok || rule.MaxTxPerHour == 0 {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.signed[key] = append(e.signed[key], e.now())
+}
+
+// recent drops the signatures older than one hour and returns the remaining ones.
+func (e *Engine) recent(key string, now time.Time) []time.Time {
+	times := e.signed[key]
+	cutoff := now.Add(-time.Hour)
+	i := 0
+	for i < len(times) && !times[i].After(cutoff) {
+		i++
+	}
+	times = times[i:]
+	e.signed[key] = times
+	return times
+}
+
+// KeyID identifies a key by its name. Names that are 0x prefixed addresses are identified
+// regardless of their case. Other names, such as PKCS#11 labels, are case sensitive.
+func KeyID(keyName string) string {
+	if strings.HasPrefix(keyName, "0x") && common.IsHexAddress(keyName) {
+		return common.HexToAddress(keyName).Hex()
+	}
+	return keyName
+}
+
+func containsAddress(list []common.Address, addr common.Address) bool {
+	for _, a := range list {
+		if a == addr {
+			return true
+		}
+	}
+	return false
+}
+
+func containsSelector(list []hexutil.Bytes, selector []byte) bool {
+	for _, s := range list {
+		if string(s) == string(selector) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,137 @@
+package policy
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/require"
+)
+
+var batchInbox = common.HexToAddress("0xff00000000000000000000000000000000000420")
+
+func testTx(to *common.Address, value int64, feeCap int64, data []byte) *types.Transaction {
+	return types.NewTx(&types.DynamicFeeTx{
+		ChainID:   big.NewInt(10),
+		GasTipCap: big.NewInt(1),
+		GasFeeCap: big.NewInt(feeCap),
+		Gas:       100_000,
+		To:        to,
+		Value:     big.NewInt(value),
+		Data:      data,
+	})
+}
+
+func TestEvaluate(t *testing.T) {
+	other := common.HexToAddress("0x1234")
+	e, err := NewEngine([]Rule{
+		{
+			Key:         "batcher",
+			ToAddresses: []common.Address{batchInbox},
+			MaxValue:    (*hexutil.Big)(big.NewInt(0)),
+			MaxFeeCap:   (*hexutil.Big)(big.NewInt(1000)),
+		},
+		{
+			Key:             "proposer",
+			MethodSelectors: []hexutil.Bytes{{0x9a, 0xaa, 0xb6, 0x48}},
+		},
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		key     string
+		tx      *types.Transaction
+		reasons int
+	}{
+		{"batcher to inbox", "batcher", testTx(&batchInbox, 0, 1000, []byte{0}), 0},
+		{"batcher to other", "batcher", testTx(&other, 0, 1000, nil), 1},
+		{"batcher contract creation", "batcher", testTx(nil, 0, 1000, nil), 1},
+		{"batcher value", "batcher", testTx(&batchInbox, 1, 1000, nil), 1},
+		{"batcher fee cap", "batcher", testTx(&batchInbox, 0, 1001, nil), 1},
+		{"batcher everything", "batcher", testTx(&other, 1, 1001, nil), 3},
+		{"proposer selector", "proposer", testTx(&other, 0, 1, []byte{0x9a, 0xaa, 0xb6, 0x48, 0}), 0},
+		{"proposer bad selector", "proposer", testTx(&other, 0, 1, []byte{1, 2, 3, 4}), 1},
+		{"proposer no selector", "proposer", testTx(&other, 0, 1, []byte{1}), 1},
+		{"unrestricted key", "other", testTx(nil, 100, 1_000_000, nil), 0},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			d := e.Evaluate(test.key, test.tx)
+			require.Len(t, d.Reasons, test.reasons, d.String())
+			require.Equal(t, test.reasons == 0, d.Sign())
+		})
+	}
+}
+
+func TestEvaluateModes(t *testing.T) {
+	e, err := NewEngine([]Rule{
+		{Key: "log-only", Mode: ModeLogOnly, ToAddresses: []common.Address{batchInbox}},
+		{Key: "dry-run", Mode: ModeDryRun, ToAddresses: []common.Address{batchInbox}},
+	})
+	require.NoError(t, err)
+
+	d := e.Evaluate("log-only", testTx(nil, 0, 1, nil))
+	require.True(t, d.Violated())
+	require.True(t, d.Sign())
+
+	d = e.Evaluate("dry-run", testTx(&batchInbox, 0, 1, nil))
+	require.False(t, d.Violated())
+	require.False(t, d.Sign())
+}
+
+func TestEvaluateRateLimit(t *testing.T) {
+	e, err := NewEngine([]Rule{{Key: "batcher", MaxTxPerHour: 2}})
+	require.NoError(t, err)
+	now := time.Unix(1_000_000, 0)
+	e.now = func() time.Time { return now }
+	tx := testTx(&batchInbox, 0, 1, nil)
+	sign := func() bool {
+		if !e.Evaluate("batcher", tx).Sign() {
+			return false
+		}
+		e.RecordSigned("batcher")
+		return true
+	}
+
+	require.True(t, sign())
+	now = now.Add(30 * time.Minute)
+	// allowed transactions that were not signed don't count
+	require.True(t, e.Evaluate("batcher", tx).Sign())
+	require.True(t, sign())
+	require.False(t, sign(), "third tx within the hour must be rejected")
+
+	// the first signature leaves the window
+	now = now.Add(31 * time.Minute)
+	require.True(t, sign())
+	require.False(t, sign())
+}
+
+func TestNewEngineInvalid(t *testing.T) {
+	_, err := NewEngine([]Rule{{Key: "a"}, {Key: "a"}})
+	require.Error(t, err)
+	_, err = NewEngine([]Rule{{Key: "a", Mode: "maybe"}})
+	require.Error(t, err)
+	_, err = NewEngine([]Rule{{}})
+	require.Error(t, err)
+}
+
+func TestKeyID(t *testing.T) {
+	checksummed := "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"
+	require.Equal(t, checksummed, KeyID("0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"))
+	require.Equal(t, checksummed, KeyID("0x5AAEB6053F3E94C9B9A09F33669435E7EF1BEAED"))
+	// labels that merely look like addresses are case sensitive
+	require.Equal(t, "5AAEB6053F3E94C9B9A09F33669435E7EF1BEAED", KeyID("5AAEB6053F3E94C9B9A09F33669435E7EF1BEAED"))
+	require.Equal(t, "batcher", KeyID("batcher"))
+
+	e, err := NewEngine([]Rule{{Key: "0x5AAEB6053F3E94C9B9A09F33669435E7EF1BEAED", MaxTxPerHour: 1}})
+	require.NoError(t, err)
+	tx := testTx(&batchInbox, 0, 1, nil)
+	require.True(t, e.Evaluate("0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", tx).Sign())
+	e.RecordSigned("0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed")
+	require.False(t, e.Evaluate(checksummed, tx).Sign())
+}
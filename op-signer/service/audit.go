@@ -0,0 +1,107 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// AuditRecord describes a single signing request and its outcome.
+type AuditRecord struct {
+	Time     time.Time       `json:"time"`
+	Client   string          `json:"client"`
+	Key      string          `json:"key,omitempty"`
+	ChainID  *hexutil.Big    `json:"chainId,omitempty"`
+	From     *common.Address `json:"from,omitempty"`
+	To       *common.Address `json:"to,omitempty"`
+	Nonce    *hexutil.Uint64 `json:"nonce,omitempty"`
+	Value    *hexutil.Big    `json:"value,omitempty"`
+	Selector hexutil.Bytes   `json:"selector,omitempty"`
+	TxHash   *common.Hash    `json:"txHash,omitempty"`
+	Status   string          `json:"status"`
+	Error    string          `json:"error,omitempty"`
+
+	PolicyMode       string   `json:"policyMode,omitempty"`
+	PolicyViolations []string `json:"policyViolations,omitempty"`
+}
+
+// AuditLog records every signing request. Records are always written to the logger,
+// and appended as JSON lines to the audit file if one is configured.
+type AuditLog struct {
+	log log.Logger
+
+	mu sync.Mutex
+	w  io.WriteCloser
+}
+
+// NewAuditLog creates an audit log. If path is empty, records are only logged.
+func NewAuditLog(logger log.Logger, path string) (*AuditLog, error) {
+	a := &AuditLog{log: logger.New("module", "audit")}
+	if path != "" {
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open audit log file: %w", err)
+		}
+		a.w = f
+	}
+	return a, nil
+}
+
+func (a *AuditLog) Record(r *AuditRecord) {
+	ctx := []any{"client", r.Client, "key", r.Key, "status", r.Status}
+	if r.From != nil {
+		ctx = append(ctx, "from", *r.From)
+	}
+	if r.To != nil {
+		ctx = append(ctx, "to", *r.To)
+	}
+	if r.Nonce != nil {
+		ctx = append(ctx, "nonce", uint64(*r.Nonce))
+	}
+	if r.Value != nil {
+		ctx = append(ctx, "value", r.Value.ToInt())
+	}
+	if len(r.Selector) > 0 {
+		ctx = append(ctx, "selector", r.Selector)
+	}
+	if r.TxHash != nil {
+		ctx = append(ctx, "tx_hash", *r.TxHash)
+	}
+	if len(r.PolicyViolations) > 0 {
+		ctx = append(ctx, "policy_mode", r.PolicyMode, "policy_violations", strings.Join(r.PolicyViolations, "; "))
+	}
+	if r.Error != "" {
+		ctx = append(ctx, "err", r.Error)
+	}
+	a.log.Info("sign request", ctx...)
+	if a.w == nil {
+		return
+	}
+	data, err := json.Marshal(r)
+	if err != nil {
+		a.log.Error("failed to encode audit record", "err", err)
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, err := a.w.Write(append(data, '\n')); err != nil {
+		a.log.Error("failed to write audit record", "err", err)
+	}
+}
+
+func (a *AuditLog) Close() error {
+	if a.w == nil {
+		return nil
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.w.Close()
+}
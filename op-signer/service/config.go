@@ -0,0 +1,173 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/urfave/cli"
+	"gopkg.in/yaml.v3"
+
+	oplog "github.com/ethereum-optimism/optimism/op-service/log"
+	opmetrics "github.com/ethereum-optimism/optimism/op-service/metrics"
+	oppprof "github.com/ethereum-optimism/optimism/op-service/pprof"
+	oprpc "github.com/ethereum-optimism/optimism/op-service/rpc"
+	optls "github.com/ethereum-optimism/optimism/op-service/tls"
+	"github.com/ethereum-optimism/optimism/op-signer/flags"
+	"github.com/ethereum-optimism/optimism/op-signer/policy"
+)
+
+// AuthConfig authorizes a single client to sign with a single key.
+type AuthConfig struct {
+	// ClientName is the DNS name (or common name) of the TLS client certificate.
+	ClientName string `yaml:"name"`
+	// KeyName identifies the key in the configured key backend.
+	KeyName string `yaml:"key"`
+}
+
+func (c AuthConfig) Check() error {
+	if c.ClientName == "" {
+		return errors.New("auth config is missing a client name")
+	}
+	if c.KeyName == "" {
+		return fmt.Errorf("auth config for %s is missing a key", c.ClientName)
+	}
+	return nil
+}
+
+// SignerServiceConfig is the file based configuration of the signer service.
+type SignerServiceConfig struct {
+	Auth []AuthConfig `yaml:"auth"`
+	// Policies restrict what may be signed with each key.
+	Policies []policy.Rule `yaml:"policies"`
+}
+
+// ReadConfig parses the YAML signer service config at path. Unknown fields are rejected, so
+// that a misspelled or removed restriction fails loudly instead of being ignored.
+func ReadConfig(path string) (SignerServiceConfig, error) {
+	config := SignerServiceConfig{}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return config, err
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&config); err != nil && !errors.Is(err, io.EOF) {
+		return config, fmt.Errorf("failed to parse signer config: %w", err)
+	}
+	seen := make(map[string]struct{})
+	keys := make(map[string]struct{})
+	for i := range config.Auth {
+		auth := &config.Auth[i]
+		if err := auth.Check(); err != nil {
+			return config, err
+		}
+		if _, ok := seen[auth.ClientName]; ok {
+			return config, fmt.Errorf("duplicate auth config for client %s", auth.ClientName)
+		}
+		seen[auth.ClientName] = struct{}{}
+		keys[policy.KeyID(auth.KeyName)] = struct{}{}
+	}
+	for i := range config.Policies {
+		rule := &config.Policies[i]
+		if err := rule.Check(); err != nil {
+			return config, err
+		}
+		// A policy that matches no key would silently leave the intended key unrestricted.
+		if _, ok := keys[policy.KeyID(rule.Key)]; !ok {
+			return config, fmt.Errorf("policy rule for %s does not match the key of any client", rule.Key)
+		}
+	}
+	return config, nil
+}
+
+// GetAuthConfigForClient returns the auth config of the named client, or an error if the client is unknown.
+func (s SignerServiceConfig) GetAuthConfigForClient(clientName string) (*AuthConfig, error) {
+	for _, auth := range s.Auth {
+		if auth.ClientName == clientName {
+			return &auth, nil
+		}
+	}
+	return nil, fmt.Errorf("client %q is not authorized to use any key", clientName)
+}
+
+// CLIConfig is a well typed config that is parsed from the CLI params.
+type CLIConfig struct {
+	// ServiceConfig is the path to the signer service config file.
+	ServiceConfig string
+
+	// KeyBackend selects the signature provider.
+	KeyBackend string
+
+	KeystoreDir          string
+	KeystorePasswordFile string
+
+	PKCS11SoftwareTokenDir string
+
+	// AuditLogFile optionally receives JSON audit records.
+	AuditLogFile string
+
+	RPCConfig oprpc.CLIConfig
+
+	TLSConfig optls.CLIConfig
+
+	LogConfig oplog.CLIConfig
+
+	MetricsConfig opmetrics.CLIConfig
+
+	PprofConfig oppprof.CLIConfig
+}
+
+func (c CLIConfig) Check() error {
+	if err := c.RPCConfig.Check(); err != nil {
+		return err
+	}
+	if err := c.TLSConfig.Check(); err != nil {
+		return err
+	}
+	if !c.TLSConfig.TLSEnabled() {
+		return errors.New("tls must be enabled: clients are authorized by their certificate")
+	}
+	if err := c.LogConfig.Check(); err != nil {
+		return err
+	}
+	if err := c.MetricsConfig.Check(); err != nil {
+		return err
+	}
+	if err := c.PprofConfig.Check(); err != nil {
+		return err
+	}
+	switch c.KeyBackend {
+	case flags.KeystoreBackend:
+		if c.KeystoreDir == "" || c.KeystorePasswordFile == "" {
+			return errors.New("keystore backend requires a keystore dir and password file")
+		}
+	case flags.PKCS11Backend:
+		if c.PKCS11SoftwareTokenDir == "" {
+			return errors.New("pkcs11 backend requires a token")
+		}
+	case flags.MockBackend:
+	default:
+		return fmt.Errorf("unknown key backend %q, expected one of %v", c.KeyBackend, flags.KeyBackends)
+	}
+	return nil
+}
+
+// NewConfig parses the Config from the provided flags or environment variables.
+func NewConfig(ctx *cli.Context) CLIConfig {
+	return CLIConfig{
+		ServiceConfig:          ctx.GlobalString(flags.ServiceConfigFlag.Name),
+		KeyBackend:             ctx.GlobalString(flags.KeyBackendFlag.Name),
+		KeystoreDir:            ctx.GlobalString(flags.KeystoreDirFlag.Name),
+		KeystorePasswordFile:   ctx.GlobalString(flags.KeystorePasswordFileFlag.Name),
+		PKCS11SoftwareTokenDir: ctx.GlobalString(flags.PKCS11TokenDirFlag.Name),
+		AuditLogFile:           ctx.GlobalString(flags.AuditLogFileFlag.Name),
+		RPCConfig:              oprpc.ReadCLIConfig(ctx),
+		TLSConfig:              optls.ReadCLIConfig(ctx),
+		LogConfig:              oplog.ReadCLIConfig(ctx),
+		MetricsConfig:          opmetrics.ReadCLIConfig(ctx),
+		PprofConfig:            oppprof.ReadCLIConfig(ctx),
+	}
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ethereum-optimism/optimism/op-signer/policy"
+)
+
+func writeConfig(t *testing.T, data string) string {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(data), 0o600))
+	return path
+}
+
+func TestReadConfig(t *testing.T) {
+	path := writeConfig(t, `
+auth:
+  - name: batcher.test
+    key: "0x0000000000000000000000000000000000000001"
+  - name: proposer.test
+    key: proposer
+policies:
+  - key: "0x0000000000000000000000000000000000000001"
+    toAddresses: ["0xff00000000000000000000000000000000000420"]
+    maxValue: "0x0"
+    maxTxPerHour: 120
+  - key: proposer
+    mode: log-only
+    methodSelectors: ["0x9aaab648"]
+`)
+	config, err := ReadConfig(path)
+	require.NoError(t, err)
+	require.Len(t, config.Auth, 2)
+
+	auth, err := config.GetAuthConfigForClient("batcher.test")
+	require.NoError(t, err)
+	require.Equal(t, "0x0000000000000000000000000000000000000001", auth.KeyName)
+
+	auth, err = config.GetAuthConfigForClient("proposer.test")
+	require.NoError(t, err)
+	require.Equal(t, "proposer", auth.KeyName)
+
+	require.Len(t, config.Policies, 2)
+	require.Equal(t, policy.ModeEnforce, config.Policies[0].Mode)
+	require.Equal(t, []common.Address{common.HexToAddress("0xff00000000000000000000000000000000000420")}, config.Policies[0].ToAddresses)
+	require.Equal(t, uint64(0), config.Policies[0].MaxValue.ToInt().Uint64())
+	require.Equal(t, uint64(120), config.Policies[0].MaxTxPerHour)
+	require.Equal(t, policy.ModeLogOnly, config.Policies[1].Mode)
+	require.Equal(t, []hexutil.Bytes{{0x9a, 0xaa, 0xb6, 0x48}}, config.Policies[1].MethodSelectors)
+
+	_, err = config.GetAuthConfigForClient("unknown.test")
+	require.Error(t, err)
+}
+
+func TestReadConfigInvalid(t *testing.T) {
+	for name, data := range map[string]string{
+		"missing key":           "auth:\n  - name: batcher.test\n",
+		"bad selector":          "policies:\n  - key: k\n    methodSelectors: [\"0x01\"]\n",
+		"bad mode":              "policies:\n  - key: k\n    mode: sometimes\n",
+		"duplicate client":      "auth:\n  - name: a\n    key: k\n  - name: a\n    key: k2\n",
+		"policy of unknown key": "auth:\n  - name: a\n    key: k\npolicies:\n  - key: k2\n",
+		"policy of other label": "auth:\n  - name: a\n    key: 5aaeb6053f3e94c9b9a09f33669435e7ef1beaed\npolicies:\n  - key: 5AAEB6053F3E94C9B9A09F33669435E7EF1BEAED\n",
+		"removed auth field":    "auth:\n  - name: a\n    key: k\n    toAddresses: [\"0xff00000000000000000000000000000000000420\"]\n",
+		"unknown policy field":  "auth:\n  - name: a\n    key: k\npolicies:\n  - key: k\n    maxValu: \"0x0\"\n",
+	} {
+		data := data
+		t.Run(name, func(t *testing.T) {
+			_, err := ReadConfig(writeConfig(t, data))
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestReadConfigMatchesAddresses(t *testing.T) {
+	path := writeConfig(t, `
+auth:
+  - name: batcher.test
+    key: "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"
+policies:
+  - key: "0x5AAEB6053F3E94C9B9A09F33669435E7EF1BEAED"
+    maxValue: "0x0"
+`)
+	config, err := ReadConfig(path)
+	require.NoError(t, err)
+	// The configured names are passed to the backend unchanged.
+	require.Equal(t, "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", config.Auth[0].KeyName)
+	require.Equal(t, "0x5AAEB6053F3E94C9B9A09F33669435E7EF1BEAED", config.Policies[0].Key)
+}
@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/urfave/cli"
+
+	oplog "github.com/ethereum-optimism/optimism/op-service/log"
+	opmetrics "github.com/ethereum-optimism/optimism/op-service/metrics"
+	oppprof "github.com/ethereum-optimism/optimism/op-service/pprof"
+	oprpc "github.com/ethereum-optimism/optimism/op-service/rpc"
+	optls "github.com/ethereum-optimism/optimism/op-service/tls"
+	"github.com/ethereum-optimism/optimism/op-service/tls/certman"
+	"github.com/ethereum-optimism/optimism/op-signer/flags"
+	"github.com/ethereum-optimism/optimism/op-signer/metrics"
+	"github.com/ethereum-optimism/optimism/op-signer/service/provider"
+)
+
+// Main is the entrypoint into the signer service. This method executes the
+// service and blocks until the service exits.
+func Main(version string, cliCtx *cli.Context) error {
+	cfg := NewConfig(cliCtx)
+	if err := cfg.Check(); err != nil {
+		return fmt.Errorf("invalid CLI flags: %w", err)
+	}
+
+	l := oplog.NewLogger(cfg.LogConfig)
+	m := metrics.NewMetrics("default")
+	l.Info("Initializing signer service", "key_backend", cfg.KeyBackend)
+
+	serviceConfig, err := ReadConfig(cfg.ServiceConfig)
+	if err != nil {
+		return fmt.Errorf("failed to read service config: %w", err)
+	}
+
+	signatureProvider, err := NewSignatureProviderFromCLIConfig(l, cfg, serviceConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create signature provider: %w", err)
+	}
+
+	audit, err := NewAuditLog(l, cfg.AuditLogFile)
+	if err != nil {
+		return err
+	}
+	defer audit.Close()
+
+	tlsConfig, cm, err := newServerTLSConfig(l, cfg.TLSConfig)
+	if err != nil {
+		return err
+	}
+	defer cm.Stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	pprofConfig := cfg.PprofConfig
+	if pprofConfig.Enabled {
+		l.Info("starting pprof", "addr", pprofConfig.ListenAddr, "port", pprofConfig.ListenPort)
+		go func() {
+			if err := oppprof.ListenAndServe(ctx, pprofConfig.ListenAddr, pprofConfig.ListenPort); err != nil {
+				l.Error("error starting pprof", "err", err)
+			}
+		}()
+	}
+
+	metricsCfg := cfg.MetricsConfig
+	if metricsCfg.Enabled {
+		l.Info("starting metrics server", "addr", metricsCfg.ListenAddr, "port", metricsCfg.ListenPort)
+		go func() {
+			if err := m.Serve(ctx, metricsCfg.ListenAddr, metricsCfg.ListenPort); err != nil {
+				l.Error("error starting metrics server", err)
+			}
+		}()
+	}
+
+	signerService, err := NewSignerService(l, serviceConfig, signatureProvider, audit, m)
+	if err != nil {
+		return fmt.Errorf("failed to create signer service: %w", err)
+	}
+
+	rpcCfg := cfg.RPCConfig
+	server := oprpc.NewServer(
+		rpcCfg.ListenAddr,
+		rpcCfg.ListenPort,
+		version,
+		oprpc.WithLogger(l),
+		oprpc.WithTLSConfig(tlsConfig),
+		oprpc.WithHTTPRecorder(opmetrics.NewPromHTTPRecorder(m.Registry(), metrics.Namespace)),
+		oprpc.WithAPIs([]rpc.API{
+			{
+				Namespace: "eth",
+				Service:   signerService,
+			},
+		}),
+	)
+	l.Info("Starting signer service", "endpoint", server.Endpoint())
+	if err := server.Start(); err != nil {
+		return fmt.Errorf("error starting RPC server: %w", err)
+	}
+	defer func() {
+		_ = server.Stop()
+	}()
+
+	m.RecordInfo(version)
+	m.RecordUp()
+
+	interruptChannel := make(chan os.Signal, 1)
+	signal.Notify(interruptChannel, []os.Signal{
+		os.Interrupt,
+		os.Kill,
+		syscall.SIGTERM,
+		syscall.SIGQUIT,
+	}...)
+	<-interruptChannel
+
+	return nil
+}
+
+// NewSignatureProviderFromCLIConfig creates the signature provider of the configured key backend.
+func NewSignatureProviderFromCLIConfig(l log.Logger, cfg CLIConfig, serviceConfig SignerServiceConfig) (provider.SignatureProvider, error) {
+	switch cfg.KeyBackend {
+	case flags.KeystoreBackend:
+		return provider.NewKeystoreSignatureProvider(l, cfg.KeystoreDir, cfg.KeystorePasswordFile)
+	case flags.PKCS11Backend:
+		token, err := provider.NewSoftwareTokenFromDir(cfg.PKCS11SoftwareTokenDir)
+		if err != nil {
+			return nil, err
+		}
+		l.Warn("using the software PKCS#11 token, keys are not protected by hardware")
+		return provider.NewPKCS11SignatureProvider(l, token), nil
+	case flags.MockBackend:
+		l.Warn("using the mock key backend, keys are ephemeral and must only be used for testing")
+		mock := provider.NewMockSignatureProvider()
+		for _, auth := range serviceConfig.Auth {
+			addr, err := mock.GenerateKey(auth.KeyName)
+			if err != nil {
+				return nil, err
+			}
+			l.Info("generated mock key", "key", auth.KeyName, "address", addr)
+		}
+		return mock, nil
+	default:
+		return nil, fmt.Errorf("unknown key backend %q", cfg.KeyBackend)
+	}
+}
+
+// newServerTLSConfig builds a mutual TLS config: client certificates must be signed by the configured CA.
+// The server certificate is reloaded by certman when it changes on disk.
+func newServerTLSConfig(l log.Logger, cfg optls.CLIConfig) (*oprpc.ServerTLSConfig, *certman.CertMan, error) {
+	caCert, err := os.ReadFile(cfg.TLSCaCert)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read tls.ca: %w", err)
+	}
+	caCertPool := x509.NewCertPool()
+	if !caCertPool.AppendCertsFromPEM(caCert) {
+		return nil, nil, errors.New("failed to parse tls.ca")
+	}
+
+	cm, err := certman.New(l, cfg.TLSCert, cfg.TLSKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read tls cert or key: %w", err)
+	}
+	if err := cm.Watch(); err != nil {
+		return nil, nil, fmt.Errorf("failed to start certman watcher: %w", err)
+	}
+
+	return &oprpc.ServerTLSConfig{
+		Config: &tls.Config{
+			MinVersion:     tls.VersionTLS13,
+			ClientAuth:     tls.RequireAndVerifyClientCert,
+			ClientCAs:      caCertPool,
+			GetCertificate: cm.GetCertificate,
+		},
+		CLIConfig: &cfg,
+	}, cm, nil
+}
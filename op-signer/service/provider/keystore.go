@@ -0,0 +1,68 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// KeystoreSignatureProvider signs with keys from a local encrypted keystore directory
+// in the standard geth (web3 secret storage) format. Keys are referenced by their hex address.
+type KeystoreSignatureProvider struct {
+	log log.Logger
+	ks  *keystore.KeyStore
+}
+
+var _ SignatureProvider = (*KeystoreSignatureProvider)(nil)
+
+// NewKeystoreSignatureProvider opens the keystore in dir and unlocks every account in it
+// with the passphrase read from passwordFile.
+func NewKeystoreSignatureProvider(logger log.Logger, dir string, passwordFile string) (*KeystoreSignatureProvider, error) {
+	password, err := os.ReadFile(passwordFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keystore password file: %w", err)
+	}
+	passphrase := strings.TrimRight(string(password), "\r\n")
+
+	ks := keystore.NewKeyStore(dir, keystore.StandardScryptN, keystore.StandardScryptP)
+	for _, account := range ks.Accounts() {
+		if err := ks.Unlock(account, passphrase); err != nil {
+			return nil, fmt.Errorf("failed to unlock keystore account %s: %w", account.Address, err)
+		}
+		logger.Info("unlocked keystore account", "address", account.Address)
+	}
+	return &KeystoreSignatureProvider{log: logger, ks: ks}, nil
+}
+
+func (k *KeystoreSignatureProvider) account(keyName string) (accounts.Account, error) {
+	if !common.IsHexAddress(keyName) {
+		return accounts.Account{}, fmt.Errorf("invalid keystore key name %q: must be a hex address", keyName)
+	}
+	account, err := k.ks.Find(accounts.Account{Address: common.HexToAddress(keyName)})
+	if err != nil {
+		return accounts.Account{}, fmt.Errorf("%w: %s", ErrKeyNotFound, keyName)
+	}
+	return account, nil
+}
+
+func (k *KeystoreSignatureProvider) SignDigest(_ context.Context, keyName string, digest []byte) ([]byte, error) {
+	account, err := k.account(keyName)
+	if err != nil {
+		return nil, err
+	}
+	return k.ks.SignHash(account, digest)
+}
+
+func (k *KeystoreSignatureProvider) Address(_ context.Context, keyName string) (common.Address, error) {
+	account, err := k.account(keyName)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return account.Address, nil
+}
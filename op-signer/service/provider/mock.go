@@ -0,0 +1,67 @@
+package provider
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// MockSignatureProvider holds plaintext private keys in memory.
+// It must only be used for testing.
+type MockSignatureProvider struct {
+	mu   sync.RWMutex
+	keys map[string]*ecdsa.PrivateKey
+}
+
+var _ SignatureProvider = (*MockSignatureProvider)(nil)
+
+func NewMockSignatureProvider() *MockSignatureProvider {
+	return &MockSignatureProvider{keys: make(map[string]*ecdsa.PrivateKey)}
+}
+
+// AddKey registers the private key under the given name.
+func (m *MockSignatureProvider) AddKey(keyName string, key *ecdsa.PrivateKey) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.keys[keyName] = key
+}
+
+// GenerateKey creates a new random key, registers it under the given name and returns its address.
+func (m *MockSignatureProvider) GenerateKey(keyName string) (common.Address, error) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		return common.Address{}, err
+	}
+	m.AddKey(keyName, key)
+	return crypto.PubkeyToAddress(key.PublicKey), nil
+}
+
+func (m *MockSignatureProvider) key(keyName string) (*ecdsa.PrivateKey, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	key, ok := m.keys[keyName]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrKeyNotFound, keyName)
+	}
+	return key, nil
+}
+
+func (m *MockSignatureProvider) SignDigest(_ context.Context, keyName string, digest []byte) ([]byte, error) {
+	key, err := m.key(keyName)
+	if err != nil {
+		return nil, err
+	}
+	return crypto.Sign(digest, key)
+}
+
+func (m *MockSignatureProvider) Address(_ context.Context, keyName string) (common.Address, error) {
+	key, err := m.key(keyName)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return crypto.PubkeyToAddress(key.PublicKey), nil
+}
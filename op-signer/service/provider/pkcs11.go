@@ -0,0 +1,201 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+var (
+	secp256k1N     = crypto.S256().Params().N
+	secp256k1HalfN = new(big.Int).Rsh(secp256k1N, 1)
+)
+
+// ObjectHandle identifies a key object within a PKCS#11 token.
+type ObjectHandle uint
+
+// PKCS11Token is the subset of a PKCS#11 session used by the signer.
+// Hardware modules do not return a recovery id and may return high-S signatures,
+// so the provider normalizes every signature to the format expected by Ethereum.
+type PKCS11Token interface {
+	// FindKey returns the handle of the secp256k1 private key object with the given label (CKA_LABEL).
+	FindKey(label string) (ObjectHandle, error)
+	// PublicKey returns the 65 byte uncompressed public key (CKA_EC_POINT) of the key object.
+	PublicKey(handle ObjectHandle) ([]byte, error)
+	// Sign performs a raw CKM_ECDSA signature of the digest and returns the 64 byte r || s.
+	Sign(handle ObjectHandle, digest []byte) ([]byte, error)
+}
+
+// PKCS11SignatureProvider signs with keys held by a PKCS#11 token. Keys are referenced by label.
+type PKCS11SignatureProvider struct {
+	log   log.Logger
+	token PKCS11Token
+
+	mu      sync.Mutex
+	pubKeys map[ObjectHandle][]byte
+}
+
+var _ SignatureProvider = (*PKCS11SignatureProvider)(nil)
+
+func NewPKCS11SignatureProvider(logger log.Logger, token PKCS11Token) *PKCS11SignatureProvider {
+	return &PKCS11SignatureProvider{
+		log:     logger,
+		token:   token,
+		pubKeys: make(map[ObjectHandle][]byte),
+	}
+}
+
+func (p *PKCS11SignatureProvider) publicKey(handle ObjectHandle) ([]byte, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if pub, ok := p.pubKeys[handle]; ok {
+		return pub, nil
+	}
+	pub, err := p.token.PublicKey(handle)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read public key: %w", err)
+	}
+	if _, err := crypto.UnmarshalPubkey(pub); err != nil {
+		return nil, fmt.Errorf("invalid secp256k1 public key: %w", err)
+	}
+	p.pubKeys[handle] = pub
+	return pub, nil
+}
+
+func (p *PKCS11SignatureProvider) SignDigest(_ context.Context, keyName string, digest []byte) ([]byte, error) {
+	handle, err := p.token.FindKey(keyName)
+	if err != nil {
+		return nil, err
+	}
+	pub, err := p.publicKey(handle)
+	if err != nil {
+		return nil, err
+	}
+	rs, err := p.token.Sign(handle, digest)
+	if err != nil {
+		return nil, fmt.Errorf("pkcs11 sign failed: %w", err)
+	}
+	return recoverableSignature(rs, digest, pub)
+}
+
+func (p *PKCS11SignatureProvider) Address(_ context.Context, keyName string) (common.Address, error) {
+	handle, err := p.token.FindKey(keyName)
+	if err != nil {
+		return common.Address{}, err
+	}
+	pub, err := p.publicKey(handle)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return common.BytesToAddress(crypto.Keccak256(pub[1:])[12:]), nil
+}
+
+// recoverableSignature converts a raw r || s signature into the [R || S || V] format.
+// S is normalized to the lower half of the curve order (EIP-2) and V is found by
+// recovering the public key for both candidate values.
+func recoverableSignature(rs []byte, digest []byte, pub []byte) ([]byte, error) {
+	if len(rs) != 64 {
+		return nil, fmt.Errorf("invalid raw signature length %d", len(rs))
+	}
+	s := new(big.Int).SetBytes(rs[32:])
+	if s.Cmp(secp256k1HalfN) > 0 {
+		s.Sub(secp256k1N, s)
+	}
+	sig := make([]byte, 65)
+	copy(sig[:32], rs[:32])
+	s.FillBytes(sig[32:64])
+	for v := byte(0); v < 2; v++ {
+		sig[64] = v
+		recovered, err := crypto.Ecrecover(digest, sig)
+		if err == nil && bytes.Equal(recovered, pub) {
+			return sig, nil
+		}
+	}
+	return nil, errors.New("failed to determine signature recovery id")
+}
+
+// SoftwareToken is an in-memory stand-in for a PKCS#11 token.
+// It is intended for development and testing only: keys are stored unencrypted.
+type SoftwareToken struct {
+	labels map[string]ObjectHandle
+	keys   []*ecdsa.PrivateKey
+}
+
+var _ PKCS11Token = (*SoftwareToken)(nil)
+
+func NewSoftwareToken() *SoftwareToken {
+	return &SoftwareToken{labels: make(map[string]ObjectHandle)}
+}
+
+// NewSoftwareTokenFromDir loads every "<label>.key" file in dir as a hex encoded private key.
+func NewSoftwareTokenFromDir(dir string) (*SoftwareToken, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read software token dir: %w", err)
+	}
+	token := NewSoftwareToken()
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".key" {
+			continue
+		}
+		key, err := crypto.LoadECDSA(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load key %s: %w", entry.Name(), err)
+		}
+		token.AddKey(strings.TrimSuffix(entry.Name(), ".key"), key)
+	}
+	return token, nil
+}
+
+// AddKey stores the private key under the given label.
+func (t *SoftwareToken) AddKey(label string, key *ecdsa.PrivateKey) {
+	t.labels[label] = ObjectHandle(len(t.keys))
+	t.keys = append(t.keys, key)
+}
+
+func (t *SoftwareToken) FindKey(label string) (ObjectHandle, error) {
+	handle, ok := t.labels[label]
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", ErrKeyNotFound, label)
+	}
+	return handle, nil
+}
+
+func (t *SoftwareToken) key(handle ObjectHandle) (*ecdsa.PrivateKey, error) {
+	if int(handle) >= len(t.keys) {
+		return nil, fmt.Errorf("invalid object handle %d", handle)
+	}
+	return t.keys[handle], nil
+}
+
+func (t *SoftwareToken) PublicKey(handle ObjectHandle) ([]byte, error) {
+	key, err := t.key(handle)
+	if err != nil {
+		return nil, err
+	}
+	return crypto.FromECDSAPub(&key.PublicKey), nil
+}
+
+func (t *SoftwareToken) Sign(handle ObjectHandle, digest []byte) ([]byte, error) {
+	key, err := t.key(handle)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := crypto.Sign(digest, key)
+	if err != nil {
+		return nil, err
+	}
+	// drop the recovery id, like a hardware module would
+	return sig[:64], nil
+}
@@ -0,0 +1,68 @@
+package provider
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/stretchr/testify/require"
+)
+
+// highSToken flips every signature to its high-S form, like some hardware modules do.
+type highSToken struct {
+	*SoftwareToken
+}
+
+func (t highSToken) Sign(handle ObjectHandle, digest []byte) ([]byte, error) {
+	rs, err := t.SoftwareToken.Sign(handle, digest)
+	if err != nil {
+		return nil, err
+	}
+	s := new(big.Int).SetBytes(rs[32:])
+	new(big.Int).Sub(secp256k1N, s).FillBytes(rs[32:])
+	return rs, nil
+}
+
+func TestPKCS11SignatureProvider(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+	digest := crypto.Keccak256([]byte("op-signer"))
+
+	for name, token := range map[string]PKCS11Token{
+		"software": NewSoftwareToken(),
+		"high-s":   highSToken{NewSoftwareToken()},
+	} {
+		token := token
+		t.Run(name, func(t *testing.T) {
+			switch tok := token.(type) {
+			case *SoftwareToken:
+				tok.AddKey("batcher", key)
+			case highSToken:
+				tok.AddKey("batcher", key)
+			}
+			p := NewPKCS11SignatureProvider(log.New(), token)
+
+			got, err := p.Address(context.Background(), "batcher")
+			require.NoError(t, err)
+			require.Equal(t, addr, got)
+
+			sig, err := p.SignDigest(context.Background(), "batcher", digest)
+			require.NoError(t, err)
+			require.Len(t, sig, 65)
+			require.True(t, new(big.Int).SetBytes(sig[32:64]).Cmp(secp256k1HalfN) <= 0, "S must be normalized")
+
+			pub, err := crypto.SigToPub(digest, sig)
+			require.NoError(t, err)
+			require.Equal(t, addr, crypto.PubkeyToAddress(*pub))
+		})
+	}
+}
+
+func TestPKCS11SignatureProviderUnknownKey(t *testing.T) {
+	p := NewPKCS11SignatureProvider(log.New(), NewSoftwareToken())
+	_, err := p.SignDigest(context.Background(), "missing", make([]byte, 32))
+	require.ErrorIs(t, err, ErrKeyNotFound)
+}
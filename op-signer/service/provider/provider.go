@@ -0,0 +1,22 @@
+package provider
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// ErrKeyNotFound is returned when a provider does not hold the requested key.
+var ErrKeyNotFound = errors.New("key not found")
+
+// SignatureProvider signs digests with keys held by a key backend.
+// Keys are referenced by name; the meaning of the name is backend specific
+// (an address for the keystore backend, a label for the PKCS#11 backend, ...).
+type SignatureProvider interface {
+	// SignDigest signs the 32 byte digest with the named key and returns
+	// a 65 byte signature in the [R || S || V] format where V is 0 or 1.
+	SignDigest(ctx context.Context, keyName string, digest []byte) ([]byte, error)
+	// Address returns the address of the named key.
+	Address(ctx context.Context, keyName string) (common.Address, error)
+}
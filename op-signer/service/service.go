@@ -0,0 +1,208 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+
+	optls "github.com/ethereum-optimism/optimism/op-service/tls"
+	"github.com/ethereum-optimism/optimism/op-signer/client"
+	"github.com/ethereum-optimism/optimism/op-signer/metrics"
+	"github.com/ethereum-optimism/optimism/op-signer/policy"
+	"github.com/ethereum-optimism/optimism/op-signer/service/provider"
+)
+
+const (
+	StatusSigned       = "signed"
+	StatusInvalid      = "invalid"
+	StatusUnauthorized = "unauthorized"
+	StatusRejected     = "rejected"
+	StatusDryRun       = "dry-run"
+	StatusFailed       = "failed"
+)
+
+// Error codes returned in the JSON-RPC error object.
+const (
+	InvalidParamsErrorCode   = -32602
+	UnauthorizedErrorCode    = -32001
+	PolicyViolationErrorCode = -32002
+	DryRunErrorCode          = -32003
+)
+
+// SignerError is returned to RPC clients, its code and data are included in the JSON-RPC error object.
+type SignerError struct {
+	code    int
+	message string
+	data    interface{}
+}
+
+func (e *SignerError) Error() string          { return e.message }
+func (e *SignerError) ErrorCode() int         { return e.code }
+func (e *SignerError) ErrorData() interface{} { return e.data }
+
+// PolicyErrorData is the JSON-RPC error data of policy violations and dry-run verdicts.
+type PolicyErrorData struct {
+	Reasons []string `json:"reasons"`
+}
+
+func invalidParamsError(format string, args ...any) *SignerError {
+	return &SignerError{code: InvalidParamsErrorCode, message: fmt.Sprintf(format, args...)}
+}
+
+func unauthorizedError(format string, args ...any) *SignerError {
+	return &SignerError{code: UnauthorizedErrorCode, message: fmt.Sprintf(format, args...)}
+}
+
+// SignerService implements the eth_signTransaction RPC. Each client is identified by its
+// TLS client certificate and may only sign with the key configured for it.
+type SignerService struct {
+	log      log.Logger
+	config   SignerServiceConfig
+	provider provider.SignatureProvider
+	policy   *policy.Engine
+	audit    *AuditLog
+	metr     metrics.Metricer
+}
+
+func NewSignerService(l log.Logger, config SignerServiceConfig, provider provider.SignatureProvider, audit *AuditLog, m metrics.Metricer) (*SignerService, error) {
+	engine, err := policy.NewEngine(config.Policies)
+	if err != nil {
+		return nil, err
+	}
+	return &SignerService{
+		log:      l,
+		config:   config,
+		provider: provider,
+		policy:   engine,
+		audit:    audit,
+		metr:     m,
+	}, nil
+}
+
+// clientNameFromContext returns the DNS name of the peer certificate, falling back to its common name.
+func clientNameFromContext(ctx context.Context) string {
+	cert := optls.PeerTLSInfoFromContext(ctx).LeafCertificate
+	if cert == nil {
+		return ""
+	}
+	if len(cert.DNSNames) > 0 {
+		return cert.DNSNames[0]
+	}
+	return cert.Subject.CommonName
+}
+
+// SignTransaction signs the transaction described by args with the key of the calling client
+// and returns the RLP encoded signed transaction.
+func (s *SignerService) SignTransaction(ctx context.Context, args client.TransactionArgs) (hexutil.Bytes, error) {
+	return s.signTransaction(ctx, clientNameFromContext(ctx), args)
+}
+
+func (s *SignerService) signTransaction(ctx context.Context, clientName string, args client.TransactionArgs) (hexutil.Bytes, error) {
+	record := &AuditRecord{
+		Time:    time.Now().UTC(),
+		Client:  clientName,
+		ChainID: args.ChainID,
+		From:    args.From,
+		To:      args.To,
+		Nonce:   args.Nonce,
+		Value:   args.Value,
+	}
+	result, err := s.sign(ctx, clientName, &args, record)
+	if err != nil {
+		record.Error = err.Error()
+		var signerErr *SignerError
+		record.Status = StatusFailed
+		if errors.As(err, &signerErr) {
+			switch signerErr.code {
+			case UnauthorizedErrorCode:
+				record.Status = StatusUnauthorized
+			case InvalidParamsErrorCode:
+				record.Status = StatusInvalid
+			case PolicyViolationErrorCode:
+				record.Status = StatusRejected
+			case DryRunErrorCode:
+				record.Status = StatusDryRun
+			}
+		}
+	} else {
+		record.Status = StatusSigned
+	}
+	s.audit.Record(record)
+	s.metr.RecordSignTransactionRequest(clientName, record.Status)
+	return result, err
+}
+
+func (s *SignerService) sign(ctx context.Context, clientName string, args *client.TransactionArgs, record *AuditRecord) (hexutil.Bytes, error) {
+	if clientName == "" {
+		return nil, unauthorizedError("client certificate required")
+	}
+	auth, err := s.config.GetAuthConfigForClient(clientName)
+	if err != nil {
+		return nil, unauthorizedError(err.Error())
+	}
+	record.Key = auth.KeyName
+
+	if err := args.Check(); err != nil {
+		return nil, invalidParamsError("invalid transaction args: %s", err)
+	}
+	tx := args.ToTransaction()
+	if len(tx.Data()) >= 4 {
+		record.Selector = tx.Data()[:4]
+	}
+
+	from, err := s.provider.Address(ctx, auth.KeyName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve signing key: %w", err)
+	}
+	if args.From != nil && *args.From != from {
+		return nil, unauthorizedError("client %s may not sign for %s", clientName, args.From)
+	}
+	if err := s.checkPolicy(auth.KeyName, tx, record); err != nil {
+		return nil, err
+	}
+
+	signer := types.LatestSignerForChainID((*big.Int)(args.ChainID))
+	digest := signer.Hash(tx)
+	signature, err := s.provider.SignDigest(ctx, auth.KeyName, digest.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign transaction: %w", err)
+	}
+	signed, err := tx.WithSignature(signer, signature)
+	if err != nil {
+		return nil, fmt.Errorf("invalid transaction signature: %w", err)
+	}
+	s.policy.RecordSigned(auth.KeyName)
+	txHash := signed.Hash()
+	record.TxHash = &txHash
+
+	return signed.MarshalBinary()
+}
+
+// checkPolicy evaluates the transaction against the policy of the key and returns
+// an error if it must not be signed.
+func (s *SignerService) checkPolicy(keyName string, tx *types.Transaction, record *AuditRecord) error {
+	decision := s.policy.Evaluate(keyName, tx)
+	record.PolicyMode = string(decision.Mode)
+	record.PolicyViolations = decision.Reasons
+	if decision.Violated() {
+		s.metr.RecordPolicyViolation(keyName, string(decision.Mode))
+	}
+	data := &PolicyErrorData{Reasons: decision.Reasons}
+	switch {
+	case decision.Mode == policy.ModeDryRun && decision.Violated():
+		return &SignerError{code: DryRunErrorCode, message: "dry-run: transaction would be rejected by policy: " + decision.String(), data: data}
+	case decision.Mode == policy.ModeDryRun:
+		return &SignerError{code: DryRunErrorCode, message: "dry-run: transaction would be signed", data: data}
+	case !decision.Sign():
+		return &SignerError{code: PolicyViolationErrorCode, message: "transaction rejected by policy: " + decision.String(), data: data}
+	case decision.Violated():
+		s.log.Warn("signing transaction in violation of log-only policy", "key", keyName, "reasons", decision.String())
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ethereum-optimism/optimism/op-signer/client"
+	"github.com/ethereum-optimism/optimism/op-signer/metrics"
+	"github.com/ethereum-optimism/optimism/op-signer/policy"
+	"github.com/ethereum-optimism/optimism/op-signer/service/provider"
+)
+
+var (
+	batchInbox = common.HexToAddress("0xff00000000000000000000000000000000000420")
+	l2oo       = common.HexToAddress("0x1234000000000000000000000000000000000000")
+	proposeSel = hexutil.Bytes{0x93, 0x91, 0x8d, 0x30}
+)
+
+func newTestService(t *testing.T) (*SignerService, map[string]common.Address) {
+	mock := provider.NewMockSignatureProvider()
+	addrs := make(map[string]common.Address)
+	for _, key := range []string{"batcher-key", "proposer-key"} {
+		addr, err := mock.GenerateKey(key)
+		require.NoError(t, err)
+		addrs[key] = addr
+	}
+	config := SignerServiceConfig{
+		Auth: []AuthConfig{
+			{ClientName: "batcher.test", KeyName: "batcher-key"},
+			{ClientName: "proposer.test", KeyName: "proposer-key"},
+			{ClientName: "dry-run.test", KeyName: "proposer-key"},
+		},
+		Policies: []policy.Rule{
+			{
+				Key:         "batcher-key",
+				ToAddresses: []common.Address{batchInbox},
+			},
+			{
+				Key:             "proposer-key",
+				ToAddresses:     []common.Address{l2oo},
+				MethodSelectors: []hexutil.Bytes{proposeSel},
+			},
+		},
+	}
+	l := log.New()
+	audit, err := NewAuditLog(l, "")
+	require.NoError(t, err)
+	s, err := NewSignerService(l, config, mock, audit, metrics.NoopMetrics)
+	require.NoError(t, err)
+	return s, addrs
+}
+
+func testArgs(from common.Address, to common.Address, data []byte) client.TransactionArgs {
+	tx := types.NewTx(&types.DynamicFeeTx{
+		ChainID:   big.NewInt(10),
+		Nonce:     3,
+		GasTipCap: big.NewInt(1),
+		GasFeeCap: big.NewInt(100),
+		Gas:       21000 + uint64(len(data))*16,
+		To:        &to,
+		Value:     big.NewInt(0),
+		Data:      data,
+	})
+	return *client.NewTransactionArgsFromTransaction(big.NewInt(10), from, tx)
+}
+
+func TestSignTransaction(t *testing.T) {
+	s, addrs := newTestService(t)
+
+	result, err := s.signTransaction(context.Background(), "batcher.test", testArgs(addrs["batcher-key"], batchInbox, []byte{0, 1, 2}))
+	require.NoError(t, err)
+
+	signed := new(types.Transaction)
+	require.NoError(t, signed.UnmarshalBinary(result))
+	sender, err := types.LatestSignerForChainID(big.NewInt(10)).Sender(signed)
+	require.NoError(t, err)
+	require.Equal(t, addrs["batcher-key"], sender)
+	require.Equal(t, batchInbox, *signed.To())
+	require.Equal(t, uint64(3), signed.Nonce())
+}
+
+func TestSignTransactionRejected(t *testing.T) {
+	s, addrs := newTestService(t)
+	proposeData := append(append([]byte{}, proposeSel...), make([]byte, 32)...)
+
+	tests := []struct {
+		name   string
+		client string
+		args   client.TransactionArgs
+		code   int
+	}{
+		{"no client certificate", "", testArgs(addrs["batcher-key"], batchInbox, nil), UnauthorizedErrorCode},
+		{"unknown client", "other.test", testArgs(addrs["batcher-key"], batchInbox, nil), UnauthorizedErrorCode},
+		{"other client key", "batcher.test", testArgs(addrs["proposer-key"], batchInbox, nil), UnauthorizedErrorCode},
+		{"destination not allowed", "batcher.test", testArgs(addrs["batcher-key"], l2oo, nil), PolicyViolationErrorCode},
+		{"selector not allowed", "proposer.test", testArgs(addrs["proposer-key"], l2oo, []byte{1, 2, 3, 4}), PolicyViolationErrorCode},
+		{"missing selector", "proposer.test", testArgs(addrs["proposer-key"], l2oo, nil), PolicyViolationErrorCode},
+		{"missing nonce", "proposer.test", func() client.TransactionArgs {
+			args := testArgs(addrs["proposer-key"], l2oo, proposeData)
+			args.Nonce = nil
+			return args
+		}(), InvalidParamsErrorCode},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			_, err := s.signTransaction(context.Background(), test.client, test.args)
+			var signerErr *SignerError
+			require.ErrorAs(t, err, &signerErr)
+			require.Equal(t, test.code, signerErr.ErrorCode())
+		})
+	}
+
+	_, err := s.signTransaction(context.Background(), "proposer.test", testArgs(addrs["proposer-key"], l2oo, proposeData))
+	require.NoError(t, err)
+}
+
+func TestSignTransactionPolicyReasons(t *testing.T) {
+	s, addrs := newTestService(t)
+
+	_, err := s.signTransaction(context.Background(), "batcher.test", testArgs(addrs["batcher-key"], l2oo, nil))
+	var signerErr *SignerError
+	require.ErrorAs(t, err, &signerErr)
+	require.Equal(t, PolicyViolationErrorCode, signerErr.ErrorCode())
+	require.Contains(t, signerErr.Error(), "destination "+l2oo.Hex()+" is not allowed")
+	require.Equal(t, &PolicyErrorData{Reasons: []string{"destination " + l2oo.Hex() + " is not allowed"}}, signerErr.ErrorData())
+}
+
+func TestSignTransactionDryRun(t *testing.T) {
+	s, addrs := newTestService(t)
+	s.config.Policies[1].Mode = policy.ModeDryRun
+	engine, err := policy.NewEngine(s.config.Policies)
+	require.NoError(t, err)
+	s.policy = engine
+	proposeData := append(append([]byte{}, proposeSel...), make([]byte, 32)...)
+
+	_, err = s.signTransaction(context.Background(), "dry-run.test", testArgs(addrs["proposer-key"], l2oo, proposeData))
+	var signerErr *SignerError
+	require.ErrorAs(t, err, &signerErr)
+	require.Equal(t, DryRunErrorCode, signerErr.ErrorCode())
+	require.Empty(t, signerErr.ErrorData().(*PolicyErrorData).Reasons)
+
+	_, err = s.signTransaction(context.Background(), "dry-run.test", testArgs(addrs["proposer-key"], batchInbox, proposeData))
+	require.ErrorAs(t, err, &signerErr)
+	require.Equal(t, DryRunErrorCode, signerErr.ErrorCode())
+	require.Len(t, signerErr.ErrorData().(*PolicyErrorData).Reasons, 1)
+}
+
+// failingProvider fails to sign while fail is set.
+type failingProvider struct {
+	provider.SignatureProvider
+	fail bool
+}
+
+func (p *failingProvider) SignDigest(ctx context.Context, keyName string, digest []byte) ([]byte, error) {
+	if p.fail {
+		return nil, errors.New("backend unavailable")
+	}
+	return p.SignatureProvider.SignDigest(ctx, keyName, digest)
+}
+
+func TestSignTransactionRateLimit(t *testing.T) {
+	s, addrs := newTestService(t)
+	s.config.Policies[0].MaxTxPerHour = 1
+	engine, err := policy.NewEngine(s.config.Policies)
+	require.NoError(t, err)
+	s.policy = engine
+	backend := &failingProvider{SignatureProvider: s.provider, fail: true}
+	s.provider = backend
+	args := testArgs(addrs["batcher-key"], batchInbox, nil)
+
+	// Failed signatures don't count towards the rate limit.
+	_, err = s.signTransaction(context.Background(), "batcher.test", args)
+	require.ErrorContains(t, err, "backend unavailable")
+	backend.fail = false
+	_, err = s.signTransaction(context.Background(), "batcher.test", args)
+	require.NoError(t, err)
+
+	_, err = s.signTransaction(context.Background(), "batcher.test", args)
+	var signerErr *SignerError
+	require.ErrorAs(t, err, &signerErr)
+	require.Equal(t, PolicyViolationErrorCode, signerErr.ErrorCode())
+}
@@ -0,0 +1,206 @@
+package proxyd
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// AccessLogRecord is the JSON line written to the access log for every HTTP request and
+// websocket message.
+type AccessLogRecord struct {
+	Time        time.Time          `json:"time"`
+	TraceID     string             `json:"trace_id"`
+	ReqID       string             `json:"req_id"`
+	Auth        string             `json:"auth"`
+	RemoteIP    string             `json:"remote_ip"`
+	UserAgent   string             `json:"user_agent,omitempty"`
+	Origin      string             `json:"origin,omitempty"`
+	Batch       bool               `json:"batch"`
+	Websocket   bool               `json:"websocket,omitempty"`
+	Status      int                `json:"status"`
+	RateLimited bool               `json:"rate_limited"`
+	DurationMS  float64            `json:"duration_ms"`
+	Steps       map[string]float64 `json:"steps"`
+	Calls       []*AccessLogCall   `json:"calls"`
+}
+
+// AccessLogCall describes how a call of a request, or of a batch request, was served.
+type AccessLogCall struct {
+	Index        int     `json:"index"`
+	Method       string  `json:"method"`
+	BackendGroup string  `json:"backend_group,omitempty"`
+	Backend      string  `json:"backend,omitempty"`
+	Retries      int     `json:"retries"`
+	CacheHit     bool    `json:"cache_hit"`
+	RateLimited  bool    `json:"rate_limited"`
+	ErrorCode    int     `json:"error_code,omitempty"`
+	DurationMS   float64 `json:"duration_ms"`
+}
+
+func newAccessLogRecord(id traceID, spans []*spanSnapshot) *AccessLogRecord {
+	record := &AccessLogRecord{
+		TraceID: hex.EncodeToString(id[:]),
+		Steps:   make(map[string]float64),
+		Calls:   make([]*AccessLogCall, 0),
+	}
+	if len(spans) == 0 {
+		return record
+	}
+	// The root span is always the first span of the trace.
+	root := spans[0]
+	record.Time = root.start
+	record.ReqID = stringAttr(root, AttrReqID)
+	record.Auth = stringAttr(root, AttrAuth)
+	record.RemoteIP = stringAttr(root, AttrRemoteIP)
+	record.UserAgent = stringAttr(root, AttrUserAgent)
+	record.Origin = stringAttr(root, AttrOrigin)
+	record.Batch = boolAttr(root, AttrBatch)
+	record.Websocket = boolAttr(root, AttrWebsocket)
+	record.Status = intAttr(root, AttrStatus)
+	record.RateLimited = boolAttr(root, AttrRateLimited)
+	record.DurationMS = durationMS(root.end.Sub(root.start))
+
+	for _, span := range spans[1:] {
+		if span.parentID != root.id {
+			continue
+		}
+		if span.name != SpanCall {
+			record.Steps[span.name] += durationMS(span.end.Sub(span.start))
+			continue
+		}
+		record.Calls = append(record.Calls, &AccessLogCall{
+			Index:        intAttr(span, AttrIndex),
+			Method:       stringAttr(span, AttrMethod),
+			BackendGroup: stringAttr(span, AttrBackendGroup),
+			Backend:      stringAttr(span, AttrBackend),
+			Retries:      intAttr(span, AttrRetries),
+			CacheHit:     boolAttr(span, AttrCacheHit),
+			RateLimited:  boolAttr(span, AttrRateLimited),
+			ErrorCode:    intAttr(span, AttrErrorCode),
+			DurationMS:   durationMS(span.end.Sub(span.start)),
+		})
+	}
+	sort.Slice(record.Calls, func(i, j int) bool {
+		return record.Calls[i].Index < record.Calls[j].Index
+	})
+	return record
+}
+
+func stringAttr(s *spanSnapshot, key string) string {
+	v, _ := s.attributes[key].(string)
+	return v
+}
+
+func boolAttr(s *spanSnapshot, key string) bool {
+	v, _ := s.attributes[key].(bool)
+	return v
+}
+
+func intAttr(s *spanSnapshot, key string) int {
+	v, _ := s.attributes[key].(int)
+	return v
+}
+
+func durationMS(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// RotatingFile is a file that is rotated once it grows over a maximum size. The rotated files
+// are renamed with the suffixes .1 to .maxBackups, .1 being the most recent.
+type RotatingFile struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+
+	mtx  sync.Mutex
+	file *os.File
+	size int64
+}
+
+// NewRotatingFile opens the file at path for appending. The file is never rotated if maxSizeMB
+// is 0.
+func NewRotatingFile(path string, maxSizeMB int, maxBackups int) (*RotatingFile, error) {
+	f := &RotatingFile{
+		path:       path,
+		maxSize:    int64(maxSizeMB) * 1024 * 1024,
+		maxBackups: maxBackups,
+	}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *RotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return wrapErr(err, "error opening file")
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return wrapErr(err, "error reading file info")
+	}
+	f.file = file
+	f.size = info.Size()
+	return nil
+}
+
+// Write appends p to the file, after rotating it if p doesn't fit. p is never split across files.
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	if f.file == nil {
+		return 0, os.ErrClosed
+	}
+	if f.maxSize > 0 && f.size > 0 && f.size+int64(len(p)) > f.maxSize {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+func (f *RotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return wrapErr(err, "error closing file")
+	}
+	f.file = nil
+	if f.maxBackups == 0 {
+		if err := os.Remove(f.path); err != nil && !os.IsNotExist(err) {
+			return wrapErr(err, "error removing file")
+		}
+		return f.open()
+	}
+	for i := f.maxBackups - 1; i > 0; i-- {
+		err := os.Rename(f.backupPath(i), f.backupPath(i+1))
+		if err != nil && !os.IsNotExist(err) {
+			return wrapErr(err, "error renaming backup")
+		}
+	}
+	if err := os.Rename(f.path, f.backupPath(1)); err != nil && !os.IsNotExist(err) {
+		return wrapErr(err, "error renaming file")
+	}
+	return f.open()
+}
+
+func (f *RotatingFile) backupPath(i int) string {
+	return fmt.Sprintf("%s.%d", f.path, i)
+}
+
+func (f *RotatingFile) Close() error {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
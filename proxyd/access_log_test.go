@@ -0,0 +1,49 @@
+package proxyd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "access.log")
+	f, err := NewRotatingFile(path, 1, 2)
+	require.NoError(t, err)
+	defer f.Close()
+
+	line := strings.Repeat("a", 400*1024) + "\n"
+	write := func(n int) {
+		for i := 0; i < n; i++ {
+			_, err := f.Write([]byte(line))
+			require.NoError(t, err)
+		}
+	}
+	sizeOf := func(path string) int64 {
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		return info.Size()
+	}
+
+	write(2)
+	require.NoFileExists(t, path+".1")
+	write(1)
+	require.Equal(t, int64(len(line)), sizeOf(path))
+	require.Equal(t, int64(2*len(line)), sizeOf(path+".1"))
+
+	// Only max_backups rotated files are kept.
+	write(6)
+	require.FileExists(t, path+".2")
+	require.NoFileExists(t, path+".3")
+	require.Equal(t, int64(len(line)), sizeOf(path))
+
+	// Writes after a restart append to the file.
+	require.NoError(t, f.Close())
+	f, err = NewRotatingFile(path, 1, 2)
+	require.NoError(t, err)
+	write(1)
+	require.Equal(t, int64(2*len(line)), sizeOf(path))
+}
@@ -0,0 +1,211 @@
+package proxyd
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/gorilla/mux"
+)
+
+// AdminServer serves the administrative HTTP API of proxyd. It is meant
+// to be reachable by operators only, and requires a bearer token.
+type AdminServer struct {
+	token    string
+	tenants  *TenantRegistry
+	reloader *ConfigReloader
+	srv      *http.Server
+	srvMu    sync.Mutex
+}
+
+func NewAdminServer(token string, tenants *TenantRegistry, reloader *ConfigReloader) *AdminServer {
+	return &AdminServer{
+		token:    token,
+		tenants:  tenants,
+		reloader: reloader,
+	}
+}
+
+func (a *AdminServer) Handler() http.Handler {
+	hdlr := mux.NewRouter()
+	hdlr.HandleFunc("/tenants/usage", a.HandleTenantsUsage).Methods("GET")
+	hdlr.HandleFunc("/tenants/reload", a.HandleTenantsReload).Methods("POST")
+	hdlr.HandleFunc("/tenants/{name}/usage", a.HandleTenantUsage).Methods("GET")
+	hdlr.HandleFunc("/config/reload", a.HandleConfigReload).Methods("POST")
+	hdlr.Use(a.authenticate)
+	return hdlr
+}
+
+func (a *AdminServer) ListenAndServe(host string, port int) error {
+	a.srvMu.Lock()
+	addr := fmt.Sprintf("%s:%d", host, port)
+	a.srv = &http.Server{
+		Handler: a.Handler(),
+		Addr:    addr,
+	}
+	log.Info("starting admin server", "addr", addr)
+	a.srvMu.Unlock()
+	return a.srv.ListenAndServe()
+}
+
+func (a *AdminServer) Shutdown() {
+	a.srvMu.Lock()
+	defer a.srvMu.Unlock()
+	if a.srv != nil {
+		_ = a.srv.Shutdown(context.Background())
+	}
+}
+
+func (a *AdminServer) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		expected := []byte("Bearer " + a.token)
+		if a.token == "" || subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) != 1 {
+			writeAdminError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+type tenantsUsageRes struct {
+	Month   string                  `json:"month"`
+	Tenants map[string]*TenantUsage `json:"tenants"`
+}
+
+type tenantUsageRes struct {
+	Month  string       `json:"month"`
+	Tenant string       `json:"tenant"`
+	Usage  *TenantUsage `json:"usage"`
+}
+
+type tenantsReloadRes struct {
+	Reloaded bool     `json:"reloaded"`
+	Tenants  []string `json:"tenants"`
+}
+
+func (a *AdminServer) HandleTenantsUsage(w http.ResponseWriter, r *http.Request) {
+	if a.tenants == nil {
+		writeAdminError(w, http.StatusNotFound, errors.New("tenants are not configured"))
+		return
+	}
+	month, err := usageMonthParam(r)
+	if err != nil {
+		writeAdminError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	res := &tenantsUsageRes{
+		Month:   month,
+		Tenants: make(map[string]*TenantUsage),
+	}
+	for _, name := range a.tenants.Names() {
+		usage, err := a.tenants.Usage(r.Context(), name, month)
+		if errors.Is(err, ErrUnknownTenant) {
+			// The tenant was removed by a concurrent reload.
+			continue
+		}
+		if err != nil {
+			log.Error("error getting tenant usage", "tenant", name, "err", err)
+			writeAdminError(w, http.StatusInternalServerError, err)
+			return
+		}
+		res.Tenants[name] = usage
+	}
+	writeAdminRes(w, res)
+}
+
+func (a *AdminServer) HandleTenantUsage(w http.ResponseWriter, r *http.Request) {
+	if a.tenants == nil {
+		writeAdminError(w, http.StatusNotFound, errors.New("tenants are not configured"))
+		return
+	}
+	month, err := usageMonthParam(r)
+	if err != nil {
+		writeAdminError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	name := mux.Vars(r)["name"]
+	usage, err := a.tenants.Usage(r.Context(), name, month)
+	if errors.Is(err, ErrUnknownTenant) {
+		writeAdminError(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		log.Error("error getting tenant usage", "tenant", name, "err", err)
+		writeAdminError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeAdminRes(w, &tenantUsageRes{
+		Month:  month,
+		Tenant: name,
+		Usage:  usage,
+	})
+}
+
+func (a *AdminServer) HandleTenantsReload(w http.ResponseWriter, r *http.Request) {
+	if a.tenants == nil {
+		writeAdminError(w, http.StatusNotFound, errors.New("tenants are not configured"))
+		return
+	}
+	reloaded, err := a.tenants.Reload()
+	if err != nil {
+		log.Error("error reloading tenants", "err", err)
+		writeAdminError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeAdminRes(w, &tenantsReloadRes{
+		Reloaded: reloaded,
+		Tenants:  a.tenants.Names(),
+	})
+}
+
+func (a *AdminServer) HandleConfigReload(w http.ResponseWriter, r *http.Request) {
+	if a.reloader == nil {
+		writeAdminError(w, http.StatusNotFound, ErrConfigReloadDisabled)
+		return
+	}
+	res, err := a.reloader.Reload()
+	if errors.Is(err, ErrConfigReloadDisabled) {
+		writeAdminError(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		writeAdminError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeAdminRes(w, res)
+}
+
+// usageMonthParam returns the month query parameter, which defaults to
+// the current month.
+func usageMonthParam(r *http.Request) (string, error) {
+	month := r.URL.Query().Get("month")
+	if month == "" {
+		return UsageMonth(time.Now()), nil
+	}
+	if err := ValidateUsageMonth(month); err != nil {
+		return "", err
+	}
+	return month, nil
+}
+
+func writeAdminRes(w http.ResponseWriter, res interface{}) {
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(res); err != nil {
+		log.Error("error writing admin response", "err", err)
+	}
+}
+
+func writeAdminError(w http.ResponseWriter, statusCode int, err error) {
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
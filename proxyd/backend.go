@@ -15,6 +15,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/ethereum/go-ethereum/log"
@@ -104,6 +105,9 @@ func ErrInvalidParams(msg string) *RPCErr {
 }
 
 type Backend struct {
+	// inFlight is the number of requests being forwarded to the backend.
+	// It is accessed atomically, and must stay the first field for alignment.
+	inFlight             int64
 	Name                 string
 	rpcURL               string
 	wsURL                string
@@ -119,6 +123,8 @@ type Backend struct {
 	outOfServiceInterval time.Duration
 	stripTrailingXFF     bool
 	proxydIP             string
+	weight               int
+	latency              latencyEWMA
 }
 
 type BackendOpt func(b *Backend)
@@ -187,6 +193,12 @@ func WithProxydIP(ip string) BackendOpt {
 	}
 }
 
+func WithWeight(weight int) BackendOpt {
+	return func(b *Backend) {
+		b.weight = weight
+	}
+}
+
 func NewBackend(
 	name string,
 	rpcURL string,
@@ -201,6 +213,7 @@ func NewBackend(
 		wsURL:           wsURL,
 		rateLimiter:     rateLimiter,
 		maxResponseSize: math.MaxInt64,
+		weight:          DefaultBackendWeight,
 		client: &LimitedHTTPClient{
 			Client:      http.Client{Timeout: 5 * time.Second},
 			sem:         rpcSemaphore,
@@ -247,7 +260,12 @@ func (b *Backend) Forward(ctx context.Context, reqs []*RPCReq, isBatch bool) ([]
 			),
 		)
 
-		res, err := b.doForward(ctx, reqs, isBatch)
+		attemptCtx, attempt := StartSpan(ctx, SpanBackendRequest)
+		attempt.SetAttribute(AttrBackend, b.Name)
+		attempt.SetAttribute(AttrAttempt, i)
+		res, err := b.doForward(attemptCtx, reqs, isBatch)
+		attempt.SetError(err)
+		attempt.End()
 		switch err {
 		case nil: // do nothing
 		// ErrBackendUnexpectedJSONRPC occurs because infura responds with a single JSON-RPC object
@@ -269,12 +287,12 @@ func (b *Backend) Forward(ctx context.Context, reqs []*RPCReq, isBatch bool) ([]
 				"req_id", GetReqID(ctx),
 				"err", err,
 			)
-			timer.ObserveDuration()
+			RecordBackendLatencyEWMA(b, b.latency.ObserveFailure(timer.ObserveDuration()))
 			RecordBatchRPCError(ctx, b.Name, reqs, err)
 			sleepContext(ctx, calcBackoff(i))
 			continue
 		}
-		timer.ObserveDuration()
+		RecordBackendLatencyEWMA(b, b.latency.Observe(timer.ObserveDuration()))
 
 		MaybeRecordErrorsInRPCRes(ctx, b.Name, reqs, res)
 		return res, err
@@ -284,7 +302,71 @@ func (b *Backend) Forward(ctx context.Context, reqs []*RPCReq, isBatch bool) ([]
 	return nil, wrapErr(lastError, "permanent error forwarding request")
 }
 
-func (b *Backend) ProxyWS(clientConn *websocket.Conn, methodWhitelist *StringSet) (*WSProxier, error) {
+// ForwardRPC makes a single RPC call to the backend, bypassing the retries and rate limits of Forward,
+// and unmarshals the result into res.
+func (b *Backend) ForwardRPC(ctx context.Context, res interface{}, id string, method string, params ...interface{}) error {
+	jsonParams, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+
+	rpcReq := &RPCReq{
+		JSONRPC: JSONRPCVersion,
+		Method:  method,
+		Params:  jsonParams,
+		ID:      []byte(id),
+	}
+
+	rpcRes, err := b.doForward(ctx, []*RPCReq{rpcReq}, false)
+	if err != nil {
+		return err
+	}
+	if rpcRes[0].IsError() {
+		return rpcRes[0].Error
+	}
+
+	raw, err := json.Marshal(rpcRes[0].Result)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, res)
+}
+
+// Drain waits until the requests being forwarded to the backend have
+// completed, or until ctx is done, then closes its idle connections. It is
+// used once a config reload removed the backend, so it gets no new requests.
+func (b *Backend) Drain(ctx context.Context) {
+	ticker := time.NewTicker(backendDrainPollInterval)
+	defer ticker.Stop()
+	for atomic.LoadInt64(&b.inFlight) > 0 {
+		select {
+		case <-ctx.Done():
+			log.Warn("timed out draining backend", "name", b.Name, "in_flight", atomic.LoadInt64(&b.inFlight))
+			b.client.CloseIdleConnections()
+			return
+		case <-ticker.C:
+		}
+	}
+	b.client.CloseIdleConnections()
+	log.Info("drained backend", "name", b.Name)
+}
+
+// Latency returns the average response time of the backend, or 0 if it hasn't served any request yet.
+func (b *Backend) Latency() time.Duration {
+	return b.latency.Value()
+}
+
+func (b *Backend) ProxyWS(clientConn *websocket.Conn, methodWhitelist *StringSet, filter WSRequestFilter) (*WSProxier, error) {
+	backendConn, err := b.dialWS()
+	if err != nil {
+		return nil, err
+	}
+	return NewWSProxier(b, clientConn, backendConn, methodWhitelist, filter), nil
+}
+
+// dialWS opens a websocket connection to the backend, which counts
+// towards its maximum number of connections until releaseWSConn is called.
+func (b *Backend) dialWS() (*websocket.Conn, error) {
 	if !b.Online() {
 		return nil, ErrBackendOffline
 	}
@@ -302,7 +384,14 @@ func (b *Backend) ProxyWS(clientConn *websocket.Conn, methodWhitelist *StringSet
 	}
 
 	activeBackendWsConnsGauge.WithLabelValues(b.Name).Inc()
-	return NewWSProxier(b, clientConn, backendConn, methodWhitelist), nil
+	return backendConn, nil
+}
+
+func (b *Backend) releaseWSConn() {
+	if err := b.rateLimiter.DecBackendWSConns(b.Name); err != nil {
+		log.Error("error decrementing backend ws conns", "name", b.Name, "err", err)
+	}
+	activeBackendWsConnsGauge.WithLabelValues(b.Name).Dec()
 }
 
 func (b *Backend) Online() bool {
@@ -366,6 +455,9 @@ func (b *Backend) setOffline() {
 }
 
 func (b *Backend) doForward(ctx context.Context, rpcReqs []*RPCReq, isBatch bool) ([]*RPCRes, error) {
+	atomic.AddInt64(&b.inFlight, 1)
+	defer atomic.AddInt64(&b.inFlight, -1)
+
 	isSingleElementBatch := len(rpcReqs) == 1
 
 	// Single element batches are unwrapped before being sent
@@ -396,6 +488,9 @@ func (b *Backend) doForward(ctx context.Context, rpcReqs []*RPCReq, isBatch bool
 
 	httpReq.Header.Set("content-type", "application/json")
 	httpReq.Header.Set("X-Forwarded-For", xForwardedFor)
+	if span := SpanFromContext(ctx); span != nil {
+		httpReq.Header.Set("traceparent", span.Traceparent())
+	}
 
 	httpRes, err := b.client.DoLimited(httpReq)
 	if err != nil {
@@ -484,8 +579,15 @@ func sortBatchRPCResponse(req []*RPCReq, res []*RPCRes) {
 }
 
 type BackendGroup struct {
-	Name     string
-	Backends []*Backend
+	Name          string
+	Backends      []*Backend
+	Consensus     *ConsensusPoller
+	Shadow        *ShadowComparer
+	Subscriptions *SubscriptionMultiplexer
+	Strategy      RoutingStrategy
+	StickyRouting bool
+
+	roundRobinCounter uint64
 }
 
 func (b *BackendGroup) Forward(ctx context.Context, rpcReqs []*RPCReq, isBatch bool) ([]*RPCRes, error) {
@@ -495,7 +597,49 @@ func (b *BackendGroup) Forward(ctx context.Context, rpcReqs []*RPCReq, isBatch b
 
 	rpcRequestsTotal.Inc()
 
-	for _, back := range b.Backends {
+	if b.Consensus != nil {
+		return b.forwardToConsensusGroup(ctx, rpcReqs, isBatch)
+	}
+	return b.forwardToBackends(ctx, b.Backends, rpcReqs, isBatch)
+}
+
+// forwardToConsensusGroup rewrites the block tags of the requests to the consensus blocks,
+// and forwards them to the backends of the consensus group. Requests that can't be rewritten
+// are answered with an error without being forwarded.
+func (b *BackendGroup) forwardToConsensusGroup(ctx context.Context, rpcReqs []*RPCReq, isBatch bool) ([]*RPCRes, error) {
+	backends := b.Consensus.GetConsensusGroup()
+	consensus := b.Consensus.GetConsensusBlocks()
+
+	responses := make([]*RPCRes, len(rpcReqs))
+	var forwardReqs []*RPCReq
+	var forwardIdx []int
+	for i, req := range rpcReqs {
+		rewritten, err := RewriteRequest(consensus, req)
+		if err != nil {
+			RecordRPCError(ctx, BackendProxyd, req.Method, err)
+			responses[i] = NewRPCErrorRes(req.ID, err)
+			continue
+		}
+		forwardReqs = append(forwardReqs, rewritten)
+		forwardIdx = append(forwardIdx, i)
+	}
+	if len(forwardReqs) == 0 {
+		return responses, nil
+	}
+
+	res, err := b.forwardToBackends(ctx, backends, forwardReqs, isBatch)
+	if err != nil {
+		return nil, err
+	}
+	for i := range res {
+		RewriteResponse(consensus, forwardReqs[i], res[i])
+		responses[forwardIdx[i]] = res[i]
+	}
+	return responses, nil
+}
+
+func (b *BackendGroup) forwardToBackends(ctx context.Context, backends []*Backend, rpcReqs []*RPCReq, isBatch bool) ([]*RPCRes, error) {
+	for _, back := range b.orderBackends(ctx, backends) {
 		res, err := back.Forward(ctx, rpcReqs, isBatch)
 		if errors.Is(err, ErrMethodNotWhitelisted) {
 			return nil, err
@@ -528,6 +672,9 @@ func (b *BackendGroup) Forward(ctx context.Context, rpcReqs []*RPCReq, isBatch b
 			)
 			continue
 		}
+		if b.Shadow != nil {
+			b.Shadow.Shadow(ctx, rpcReqs, res, isBatch)
+		}
 		return res, nil
 	}
 
@@ -535,9 +682,24 @@ func (b *BackendGroup) Forward(ctx context.Context, rpcReqs []*RPCReq, isBatch b
 	return nil, ErrNoBackends
 }
 
-func (b *BackendGroup) ProxyWS(ctx context.Context, clientConn *websocket.Conn, methodWhitelist *StringSet) (*WSProxier, error) {
-	for _, back := range b.Backends {
-		proxier, err := back.ProxyWS(clientConn, methodWhitelist)
+func (b *BackendGroup) ProxyWS(ctx context.Context, clientConn *websocket.Conn, methodWhitelist *StringSet, filter WSRequestFilter) (*WSProxier, error) {
+	if b.Subscriptions != nil {
+		// The backend connection is dialed when the client sends a request that
+		// can't be served by the multiplexed subscriptions.
+		return NewMultiplexedWSProxier(b, clientConn, methodWhitelist, filter), nil
+	}
+
+	back, backendConn, err := b.dialWS(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return NewWSProxier(back, clientConn, backendConn, methodWhitelist, filter), nil
+}
+
+// dialWS opens a websocket connection to the first available backend of the group.
+func (b *BackendGroup) dialWS(ctx context.Context) (*Backend, *websocket.Conn, error) {
+	for _, back := range b.orderBackends(ctx, b.Backends) {
+		backendConn, err := back.dialWS()
 		if errors.Is(err, ErrBackendOffline) {
 			log.Warn(
 				"skipping offline backend",
@@ -566,10 +728,10 @@ func (b *BackendGroup) ProxyWS(ctx context.Context, clientConn *websocket.Conn,
 			)
 			continue
 		}
-		return proxier, nil
+		return back, backendConn, nil
 	}
 
-	return nil, ErrNoBackends
+	return nil, nil, ErrNoBackends
 }
 
 func calcBackoff(i int) time.Duration {
@@ -578,27 +740,59 @@ func calcBackoff(i int) time.Duration {
 	return time.Duration(ms) * time.Millisecond
 }
 
+// WSRequestFilter is called with each request of a websocket client before
+// it is served. It returns the response to send to the client instead of
+// serving the request, or nil if the request may be served.
+type WSRequestFilter func(ctx context.Context, req *RPCReq) *RPCRes
+
 type WSProxier struct {
 	backend         *Backend
 	clientConn      *websocket.Conn
 	backendConn     *websocket.Conn
 	methodWhitelist *StringSet
+	filter          WSRequestFilter
+	tracer          *Tracer
 	clientConnMu    sync.Mutex
+
+	// Set if the subscriptions of the client are multiplexed, in which case
+	// the backend connection is dialed on the first request that isn't.
+	group         *BackendGroup
+	subscriptions *SubscriptionMultiplexer
+	subscriber    *wsSubscriber
+	backendMu     sync.Mutex
+	closed        bool
 }
 
-func NewWSProxier(backend *Backend, clientConn, backendConn *websocket.Conn, methodWhitelist *StringSet) *WSProxier {
+func NewWSProxier(backend *Backend, clientConn, backendConn *websocket.Conn, methodWhitelist *StringSet, filter WSRequestFilter) *WSProxier {
 	return &WSProxier{
 		backend:         backend,
 		clientConn:      clientConn,
 		backendConn:     backendConn,
 		methodWhitelist: methodWhitelist,
+		filter:          filter,
+	}
+}
+
+func NewMultiplexedWSProxier(group *BackendGroup, clientConn *websocket.Conn, methodWhitelist *StringSet, filter WSRequestFilter) *WSProxier {
+	return &WSProxier{
+		clientConn:      clientConn,
+		methodWhitelist: methodWhitelist,
+		filter:          filter,
+		group:           group,
+		subscriptions:   group.Subscriptions,
+		subscriber:      group.Subscriptions.NewSubscriber(),
 	}
 }
 
 func (w *WSProxier) Proxy(ctx context.Context) error {
-	errC := make(chan error, 2)
+	errC := make(chan error, 3)
 	go w.clientPump(ctx, errC)
-	go w.backendPump(ctx, errC)
+	if w.backendConn != nil {
+		go w.backendPump(ctx, errC)
+	}
+	if w.subscriber != nil {
+		go w.subscriptionPump(errC)
+	}
 	err := <-errC
 	w.close()
 	return err
@@ -610,17 +804,23 @@ func (w *WSProxier) clientPump(ctx context.Context, errC chan error) {
 		msgType, msg, err := w.clientConn.ReadMessage()
 		if err != nil {
 			errC <- err
+			if w.backendConn == nil {
+				return
+			}
 			if err := w.backendConn.WriteMessage(websocket.CloseMessage, formatWSError(err)); err != nil {
 				log.Error("error writing backendConn message", "err", err)
 			}
 			return
 		}
 
-		RecordWSMessage(ctx, w.backend.Name, SourceClient)
+		RecordWSMessage(ctx, w.backendName(), SourceClient)
 
 		// Route control messages to the backend. These don't
 		// count towards the total RPC requests count.
 		if msgType != websocket.TextMessage && msgType != websocket.BinaryMessage {
+			if w.backendConn == nil {
+				continue
+			}
 			err := w.backendConn.WriteMessage(msgType, msg)
 			if err != nil {
 				errC <- err
@@ -629,62 +829,167 @@ func (w *WSProxier) clientPump(ctx context.Context, errC chan error) {
 			continue
 		}
 
-		rpcRequestsTotal.Inc()
+		if err := w.handleClientMsg(ctx, msgType, msg, errC); err != nil {
+			errC <- err
+			return
+		}
+	}
+}
+
+// handleClientMsg answers a request of the client, or forwards it to the
+// backend. Each request is traced as a request with a single call, which
+// ends once the request is answered or forwarded.
+func (w *WSProxier) handleClientMsg(ctx context.Context, msgType int, msg []byte, errC chan error) error {
+	// The backend pump started by handleMultiplexedReq outlives the request,
+	// so it is given the context of the connection.
+	connCtx := ctx
+	ctx, root := w.tracer.StartWSRequest(ctx)
+	defer w.tracer.FinishRequest(root)
+	ctx, call := StartSpan(ctx, SpanCall)
+	defer call.End()
 
-		// Don't bother sending invalid requests to the backend,
-		// just handle them here.
-		req, err := w.prepareClientMsg(msg)
-		if err != nil {
-			var id json.RawMessage
-			method := MethodUnknown
-			if req != nil {
-				id = req.ID
-				method = req.Method
+	respond := func(res *RPCRes) error {
+		call.SetResponse(res)
+		return w.writeClientConn(msgType, mustMarshalJSON(res))
+	}
+
+	rpcRequestsTotal.Inc()
+
+	// Don't bother sending invalid requests to the backend,
+	// just handle them here.
+	req, err := w.prepareClientMsg(msg)
+	if err != nil {
+		var id json.RawMessage
+		method := MethodUnknown
+		if req != nil {
+			id = req.ID
+			method = req.Method
+			call.SetAttribute(AttrMethod, method)
+		}
+		log.Info(
+			"error preparing client message",
+			"auth", GetAuthCtx(ctx),
+			"req_id", GetReqID(ctx),
+			"err", err,
+		)
+		RecordRPCError(ctx, BackendProxyd, method, err)
+
+		// Send error response to client
+		return respond(NewRPCErrorRes(id, err))
+	}
+	call.SetAttribute(AttrMethod, req.Method)
+
+	// Send eth_accounts requests directly to the client
+	if req.Method == "eth_accounts" {
+		RecordRPCForward(ctx, BackendProxyd, "eth_accounts", RPCRequestSourceWS)
+		return respond(NewRPCRes(req.ID, emptyArrayResponse))
+	}
+
+	if w.filter != nil {
+		if res := w.filter(ctx, req); res != nil {
+			if res.IsError() && (res.Error.Code == ErrOverRateLimit.Code || res.Error.Code == ErrOverQuota.Code) {
+				call.SetAttribute(AttrRateLimited, true)
 			}
-			log.Info(
-				"error preparing client message",
-				"auth", GetAuthCtx(ctx),
-				"req_id", GetReqID(ctx),
-				"err", err,
-			)
-			msg = mustMarshalJSON(NewRPCErrorRes(id, err))
-			RecordRPCError(ctx, BackendProxyd, method, err)
+			return respond(res)
+		}
+	}
+
+	if w.subscriptions != nil {
+		if res := w.handleMultiplexedReq(connCtx, req, errC); res != nil {
+			return respond(res)
+		}
+	}
+
+	call.SetAttribute(AttrBackend, w.backend.Name)
+	RecordRPCForward(ctx, w.backend.Name, req.Method, RPCRequestSourceWS)
+	log.Info(
+		"forwarded WS message to backend",
+		"method", req.Method,
+		"auth", GetAuthCtx(ctx),
+		"req_id", GetReqID(ctx),
+	)
 
-			// Send error response to client
-			err = w.writeClientConn(msgType, msg)
+	return w.backendConn.WriteMessage(msgType, msg)
+}
+
+// handleMultiplexedReq serves the subscription requests that can be
+// multiplexed, and dials the backend for the others. It returns the
+// response to send to the client, or nil if the request must be forwarded
+// to the backend.
+func (w *WSProxier) handleMultiplexedReq(ctx context.Context, req *RPCReq, errC chan error) *RPCRes {
+	switch req.Method {
+	case "eth_subscribe":
+		if _, ok := subscriptionKey(req.Params); ok {
+			RecordRPCForward(ctx, BackendProxyd, req.Method, RPCRequestSourceWS)
+			id, err := w.subscriptions.Subscribe(w.subscriber, req.Params)
 			if err != nil {
-				errC <- err
-				return
+				log.Info(
+					"error subscribing",
+					"auth", GetAuthCtx(ctx),
+					"req_id", GetReqID(ctx),
+					"err", err,
+				)
+				RecordRPCError(ctx, BackendProxyd, req.Method, err)
+				return NewRPCErrorRes(req.ID, err)
+			}
+			return NewRPCRes(req.ID, id)
+		}
+	case "eth_unsubscribe":
+		var ids []string
+		if err := json.Unmarshal(req.Params, &ids); err == nil && len(ids) == 1 {
+			if w.subscriptions.Unsubscribe(w.subscriber, ids[0]) {
+				RecordRPCForward(ctx, BackendProxyd, req.Method, RPCRequestSourceWS)
+				return NewRPCRes(req.ID, true)
 			}
-			continue
 		}
+	}
 
-		// Send eth_accounts requests directly to the client
-		if req.Method == "eth_accounts" {
-			msg = mustMarshalJSON(NewRPCRes(req.ID, emptyArrayResponse))
-			RecordRPCForward(ctx, BackendProxyd, "eth_accounts", RPCRequestSourceWS)
-			err = w.writeClientConn(msgType, msg)
-			if err != nil {
-				errC <- err
-				return
+	if w.backendConn == nil {
+		if err := w.dialBackend(ctx, errC); err != nil {
+			log.Warn(
+				"error dialing ws backend",
+				"auth", GetAuthCtx(ctx),
+				"req_id", GetReqID(ctx),
+				"err", err,
+			)
+			if errors.Is(err, ErrNoBackends) {
+				RecordUnserviceableRequest(ctx, RPCRequestSourceWS)
 			}
-			continue
+			RecordRPCError(ctx, BackendProxyd, req.Method, err)
+			return NewRPCErrorRes(req.ID, err)
 		}
+	}
+	if w.backend.IsRateLimited() {
+		RecordRPCError(ctx, BackendProxyd, req.Method, ErrBackendOverCapacity)
+		return NewRPCErrorRes(req.ID, ErrBackendOverCapacity)
+	}
+	return nil
+}
 
-		RecordRPCForward(ctx, w.backend.Name, req.Method, RPCRequestSourceWS)
-		log.Info(
-			"forwarded WS message to backend",
-			"method", req.Method,
-			"auth", GetAuthCtx(ctx),
-			"req_id", GetReqID(ctx),
-		)
+func (w *WSProxier) dialBackend(ctx context.Context, errC chan error) error {
+	backend, backendConn, err := w.group.dialWS(ctx)
+	if err != nil {
+		return err
+	}
 
-		err = w.backendConn.WriteMessage(msgType, msg)
-		if err != nil {
-			errC <- err
-			return
-		}
+	w.backendMu.Lock()
+	defer w.backendMu.Unlock()
+	if w.closed {
+		backendConn.Close()
+		backend.releaseWSConn()
+		return ErrBackendOffline
 	}
+	w.backend = backend
+	w.backendConn = backendConn
+	go w.backendPump(ctx, errC)
+	return nil
+}
+
+func (w *WSProxier) backendName() string {
+	if w.backend == nil {
+		return BackendProxyd
+	}
+	return w.backend.Name
 }
 
 func (w *WSProxier) backendPump(ctx context.Context, errC chan error) {
@@ -747,13 +1052,42 @@ func (w *WSProxier) backendPump(ctx context.Context, errC chan error) {
 	}
 }
 
+// subscriptionPump writes the notifications of multiplexed subscriptions to the client.
+func (w *WSProxier) subscriptionPump(errC chan error) {
+	for {
+		select {
+		case msg := <-w.subscriber.notifications:
+			if err := w.writeClientConn(websocket.TextMessage, msg); err != nil {
+				errC <- err
+				return
+			}
+		case <-w.subscriber.overflow:
+			errC <- ErrWSSubscriberTooSlow
+			if err := w.writeClientConn(websocket.CloseMessage, formatWSError(ErrWSSubscriberTooSlow)); err != nil {
+				log.Error("error writing clientConn message", "err", err)
+			}
+			return
+		case <-w.subscriber.quit:
+			return
+		}
+	}
+}
+
 func (w *WSProxier) close() {
 	w.clientConn.Close()
-	w.backendConn.Close()
-	if err := w.backend.rateLimiter.DecBackendWSConns(w.backend.Name); err != nil {
-		log.Error("error decrementing backend ws conns", "name", w.backend.Name, "err", err)
+
+	w.backendMu.Lock()
+	w.closed = true
+	backend, backendConn := w.backend, w.backendConn
+	w.backendMu.Unlock()
+	if backendConn != nil {
+		backendConn.Close()
+		backend.releaseWSConn()
+	}
+
+	if w.subscriber != nil {
+		w.subscriptions.RemoveSubscriber(w.subscriber)
 	}
-	activeBackendWsConnsGauge.WithLabelValues(w.backend.Name).Dec()
 }
 
 func (w *WSProxier) prepareClientMsg(msg []byte) (*RPCReq, error) {
@@ -766,7 +1100,9 @@ func (w *WSProxier) prepareClientMsg(msg []byte) (*RPCReq, error) {
 		return req, ErrMethodNotWhitelisted
 	}
 
-	if w.backend.IsRateLimited() {
+	// Multiplexed proxiers check the rate limit of the backend only
+	// for the requests that are forwarded to it.
+	if w.subscriptions == nil && w.backend.IsRateLimited() {
 		return req, ErrBackendOverCapacity
 	}
 
@@ -0,0 +1,215 @@
+package proxyd
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// RoutingStrategy decides which backend of a group serves a request first.
+// The other backends of the group are tried in order if it fails.
+type RoutingStrategy string
+
+const (
+	// FallbackRoutingStrategy always prefers the backends in config order.
+	FallbackRoutingStrategy RoutingStrategy = "fallback"
+	// RoundRobinRoutingStrategy rotates through the backends.
+	RoundRobinRoutingStrategy RoutingStrategy = "round_robin"
+	// WeightedRoutingStrategy picks backends at random, proportionally to their weight.
+	WeightedRoutingStrategy RoutingStrategy = "weighted"
+	// LatencyRoutingStrategy prefers the backends with the lowest average response time.
+	LatencyRoutingStrategy RoutingStrategy = "latency"
+)
+
+const (
+	DefaultBackendWeight = 1
+
+	// latencyEWMADecay is the weight of a new response time in the average response time of a backend.
+	latencyEWMADecay = 0.2
+	// latencyFailurePenalty is the response time observed for a failed request, unless it took longer.
+	latencyFailurePenalty = 5 * time.Second
+	// latencyScoreHalfLife is the time after which the score of a backend that didn't serve any
+	// request is halved, so that slow or failing backends are eventually tried again and their
+	// average response time is refreshed.
+	latencyScoreHalfLife = 30 * time.Second
+)
+
+func ParseRoutingStrategy(strategy string) (RoutingStrategy, error) {
+	switch RoutingStrategy(strategy) {
+	case "":
+		return FallbackRoutingStrategy, nil
+	case FallbackRoutingStrategy, RoundRobinRoutingStrategy, WeightedRoutingStrategy, LatencyRoutingStrategy:
+		return RoutingStrategy(strategy), nil
+	default:
+		return "", fmt.Errorf("invalid routing strategy: %s", strategy)
+	}
+}
+
+// latencyEWMA is an exponentially weighted moving average of the response times of a backend.
+type latencyEWMA struct {
+	mtx      sync.Mutex
+	value    float64
+	observed time.Time
+}
+
+func (l *latencyEWMA) Observe(d time.Duration) float64 {
+	return l.observeAt(time.Now(), d)
+}
+
+// ObserveFailure observes a failed request that took d, as if it took at least latencyFailurePenalty.
+func (l *latencyEWMA) ObserveFailure(d time.Duration) float64 {
+	if d < latencyFailurePenalty {
+		d = latencyFailurePenalty
+	}
+	return l.observeAt(time.Now(), d)
+}
+
+func (l *latencyEWMA) observeAt(now time.Time, d time.Duration) float64 {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	if l.value == 0 {
+		l.value = d.Seconds()
+	} else {
+		l.value = latencyEWMADecay*d.Seconds() + (1-latencyEWMADecay)*l.value
+	}
+	l.observed = now
+	return l.value
+}
+
+func (l *latencyEWMA) Value() time.Duration {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	return time.Duration(l.value * float64(time.Second))
+}
+
+// Score returns the average response time, halved every latencyScoreHalfLife since the last
+// observation.
+func (l *latencyEWMA) Score(now time.Time) float64 {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	if l.value == 0 {
+		return 0
+	}
+	age := now.Sub(l.observed)
+	if age <= 0 {
+		return l.value
+	}
+	return l.value * math.Exp2(-age.Seconds()/latencyScoreHalfLife.Seconds())
+}
+
+// orderBackends returns the backends in the order they should be tried for a request.
+// With sticky routing, requests from the same client are routed to the same backend
+// as long as it is part of the group, regardless of the routing strategy.
+func (b *BackendGroup) orderBackends(ctx context.Context, backends []*Backend) []*Backend {
+	if len(backends) <= 1 {
+		return backends
+	}
+
+	if b.StickyRouting {
+		if clientIP := stickyClientIP(ctx); clientIP != "" {
+			return orderBackendsByClient(clientIP, backends)
+		}
+	}
+
+	switch b.Strategy {
+	case RoundRobinRoutingStrategy:
+		return orderBackendsRoundRobin(atomic.AddUint64(&b.roundRobinCounter, 1)-1, backends)
+	case WeightedRoutingStrategy:
+		return orderBackendsWeighted(rand.Float64, backends)
+	case LatencyRoutingStrategy:
+		return orderBackendsByLatency(time.Now(), backends)
+	default:
+		return backends
+	}
+}
+
+func orderBackendsRoundRobin(counter uint64, backends []*Backend) []*Backend {
+	start := int(counter % uint64(len(backends)))
+	ordered := make([]*Backend, 0, len(backends))
+	ordered = append(ordered, backends[start:]...)
+	return append(ordered, backends[:start]...)
+}
+
+// orderBackendsWeighted draws the backends at random without replacement, proportionally to their weight.
+func orderBackendsWeighted(random func() float64, backends []*Backend) []*Backend {
+	remaining := make([]*Backend, len(backends))
+	copy(remaining, backends)
+
+	ordered := make([]*Backend, 0, len(backends))
+	for len(remaining) > 0 {
+		var total int
+		for _, be := range remaining {
+			total += be.weight
+		}
+
+		i := 0
+		if total > 0 {
+			target := random() * float64(total)
+			for ; i < len(remaining)-1; i++ {
+				target -= float64(remaining[i].weight)
+				if target < 0 {
+					break
+				}
+			}
+		}
+		ordered = append(ordered, remaining[i])
+		remaining = append(remaining[:i], remaining[i+1:]...)
+	}
+	return ordered
+}
+
+// orderBackendsByLatency sorts the backends by average response time. Backends without
+// a measured response time come first, so every backend gets measured. Failed requests count
+// as slow responses, and the averages decay while a backend isn't used, so that it is tried
+// again once it may have recovered.
+func orderBackendsByLatency(now time.Time, backends []*Backend) []*Backend {
+	ordered := make([]*Backend, len(backends))
+	copy(ordered, backends)
+
+	scores := make(map[*Backend]float64, len(backends))
+	for _, be := range backends {
+		scores[be] = be.latency.Score(now)
+	}
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return scores[ordered[i]] < scores[ordered[j]]
+	})
+	return ordered
+}
+
+// orderBackendsByClient orders the backends by rendezvous hashing of the client IP, so a client
+// keeps its backend when other backends leave or join the group. Weights are taken into account.
+func orderBackendsByClient(clientIP string, backends []*Backend) []*Backend {
+	ordered := make([]*Backend, len(backends))
+	copy(ordered, backends)
+
+	scores := make(map[*Backend]float64, len(backends))
+	for _, be := range backends {
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(clientIP))
+		_, _ = h.Write([]byte(be.Name))
+		// Map the hash to (0, 1) and weigh it, see https://en.wikipedia.org/wiki/Rendezvous_hashing
+		u := (float64(h.Sum64()>>11) + 0.5) / float64(1<<53)
+		scores[be] = -float64(be.weight) / math.Log(u)
+	}
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return scores[ordered[i]] > scores[ordered[j]]
+	})
+	return ordered
+}
+
+// stickyClientIP returns the IP of the client that sent the request, which is the first
+// entry of the X-Forwarded-For header.
+func stickyClientIP(ctx context.Context) string {
+	xff := GetXForwardedFor(ctx)
+	if i := strings.Index(xff, ","); i != -1 {
+		xff = xff[:i]
+	}
+	return strings.TrimSpace(xff)
+}
@@ -0,0 +1,158 @@
+package proxyd
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestBackends(weights ...int) []*Backend {
+	backends := make([]*Backend, len(weights))
+	for i, weight := range weights {
+		backends[i] = NewBackend(fmt.Sprintf("%c", 'a'+i), "", "", nil, nil, WithWeight(weight), WithStrippedTrailingXFF())
+	}
+	return backends
+}
+
+func backendNames(backends []*Backend) string {
+	var names string
+	for _, be := range backends {
+		names += be.Name
+	}
+	return names
+}
+
+func TestParseRoutingStrategy(t *testing.T) {
+	strategy, err := ParseRoutingStrategy("")
+	require.NoError(t, err)
+	require.Equal(t, FallbackRoutingStrategy, strategy)
+
+	strategy, err = ParseRoutingStrategy("latency")
+	require.NoError(t, err)
+	require.Equal(t, LatencyRoutingStrategy, strategy)
+
+	_, err = ParseRoutingStrategy("random")
+	require.Error(t, err)
+}
+
+func TestOrderBackendsRoundRobin(t *testing.T) {
+	group := &BackendGroup{Backends: newTestBackends(1, 1, 1), Strategy: RoundRobinRoutingStrategy}
+	ctx := context.Background()
+	require.Equal(t, "abc", backendNames(group.orderBackends(ctx, group.Backends)))
+	require.Equal(t, "bca", backendNames(group.orderBackends(ctx, group.Backends)))
+	require.Equal(t, "cab", backendNames(group.orderBackends(ctx, group.Backends)))
+	require.Equal(t, "abc", backendNames(group.orderBackends(ctx, group.Backends)))
+}
+
+func TestOrderBackendsWeighted(t *testing.T) {
+	backends := newTestBackends(1, 3, 0)
+
+	random := func(values ...float64) func() float64 {
+		return func() float64 {
+			v := values[0]
+			values = values[1:]
+			return v
+		}
+	}
+	require.Equal(t, "abc", backendNames(orderBackendsWeighted(random(0.2, 0.5, 0.5), backends)))
+	require.Equal(t, "bac", backendNames(orderBackendsWeighted(random(0.3, 0.5, 0.5), backends)))
+	require.Equal(t, "bac", backendNames(orderBackendsWeighted(random(0.99, 0.99, 0.5), backends)))
+	require.Equal(t, "abc", backendNames(backends))
+
+	counts := make(map[string]int)
+	group := &BackendGroup{Backends: backends, Strategy: WeightedRoutingStrategy}
+	for i := 0; i < 1000; i++ {
+		counts[group.orderBackends(context.Background(), backends)[0].Name]++
+	}
+	require.Zero(t, counts["c"])
+	require.Greater(t, counts["b"], counts["a"])
+}
+
+func TestOrderBackendsByLatency(t *testing.T) {
+	now := time.Now()
+	backends := newTestBackends(1, 1, 1)
+	backends[0].latency.observeAt(now, 300*time.Millisecond)
+	backends[1].latency.observeAt(now, 100*time.Millisecond)
+	require.Equal(t, "cba", backendNames(orderBackendsByLatency(now, backends)))
+
+	backends[2].latency.observeAt(now, 200*time.Millisecond)
+	require.Equal(t, "bca", backendNames(orderBackendsByLatency(now, backends)))
+
+	// b slows down
+	for i := 0; i < 10; i++ {
+		backends[1].latency.observeAt(now, time.Second)
+	}
+	require.Equal(t, "cab", backendNames(orderBackendsByLatency(now, backends)))
+}
+
+func TestOrderBackendsByLatencyRetriesSlowBackends(t *testing.T) {
+	start := time.Now()
+	backends := newTestBackends(1, 1)
+	backends[0].latency.observeAt(start, 300*time.Millisecond)
+	backends[1].latency.observeAt(start, 100*time.Millisecond)
+	require.Equal(t, "ba", backendNames(orderBackendsByLatency(start, backends)))
+
+	// b keeps serving the requests, while the score of a decays until it is tried again.
+	now := start
+	for i := 0; i < 4; i++ {
+		now = now.Add(10 * time.Second)
+		backends[1].latency.observeAt(now, 100*time.Millisecond)
+		require.Equal(t, "ba", backendNames(orderBackendsByLatency(now, backends)))
+	}
+	now = now.Add(10 * time.Second)
+	backends[1].latency.observeAt(now, 100*time.Millisecond)
+	require.Equal(t, "ab", backendNames(orderBackendsByLatency(now, backends)))
+
+	// a is measured again, and is still slow.
+	backends[0].latency.observeAt(now, 300*time.Millisecond)
+	require.Equal(t, "ba", backendNames(orderBackendsByLatency(now, backends)))
+	require.Equal(t, 300*time.Millisecond, backends[0].Latency().Round(time.Millisecond))
+}
+
+func TestOrderBackendsByLatencyPenalizesFailures(t *testing.T) {
+	now := time.Now()
+	backends := newTestBackends(1, 1, 1)
+	backends[1].latency.observeAt(now, 100*time.Millisecond)
+	require.Equal(t, "acb", backendNames(orderBackendsByLatency(now, backends)))
+
+	// a never succeeds, so it isn't tried first anymore, unlike c which wasn't measured yet.
+	backends[0].latency.ObserveFailure(time.Millisecond)
+	require.Equal(t, latencyFailurePenalty, backends[0].Latency())
+	require.Equal(t, "cba", backendNames(orderBackendsByLatency(time.Now(), backends)))
+
+	// A failure counts as at least the penalty, or as the time it took if longer.
+	backends[1].latency.ObserveFailure(10 * time.Second)
+	require.Equal(t, 2080*time.Millisecond, backends[1].Latency().Round(time.Millisecond))
+}
+
+func TestOrderBackendsSticky(t *testing.T) {
+	backends := newTestBackends(1, 1, 1, 1)
+	group := &BackendGroup{Backends: backends, Strategy: RoundRobinRoutingStrategy, StickyRouting: true}
+
+	ctx := context.WithValue(context.Background(), ContextKeyXForwardedFor, "1.2.3.4, 10.0.0.1") // nolint:staticcheck
+	first := group.orderBackends(ctx, backends)[0]
+	for i := 0; i < 10; i++ {
+		require.Equal(t, first, group.orderBackends(ctx, backends)[0])
+	}
+
+	// The client keeps its backend when another backend leaves the group
+	var remaining []*Backend
+	for _, be := range backends {
+		if be != first && len(remaining) < 2 {
+			remaining = append(remaining, be)
+		}
+	}
+	remaining = append(remaining, first)
+	require.Equal(t, first, group.orderBackends(ctx, remaining)[0])
+
+	// Clients are spread across the backends
+	seen := make(map[*Backend]bool)
+	for i := 0; i < 100; i++ {
+		ctx := context.WithValue(context.Background(), ContextKeyXForwardedFor, fmt.Sprintf("10.0.0.%d", i)) // nolint:staticcheck
+		seen[group.orderBackends(ctx, backends)[0]] = true
+	}
+	require.Len(t, seen, len(backends))
+}
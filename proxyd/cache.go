@@ -12,6 +12,8 @@ import (
 type Cache interface {
 	Get(ctx context.Context, key string) (string, error)
 	Put(ctx context.Context, key string, value string) error
+	PutWithTTL(ctx context.Context, key string, value string, ttl time.Duration) error
+	Delete(ctx context.Context, key string) error
 }
 
 const (
@@ -25,6 +27,11 @@ type cache struct {
 	lru *lru.Cache
 }
 
+type cacheEntry struct {
+	value     string
+	expiresAt time.Time
+}
+
 func newMemoryCache() *cache {
 	rep, _ := lru.New(memoryCacheLimit)
 	return &cache{rep}
@@ -32,13 +39,28 @@ func newMemoryCache() *cache {
 
 func (c *cache) Get(ctx context.Context, key string) (string, error) {
 	if val, ok := c.lru.Get(key); ok {
-		return val.(string), nil
+		entry := val.(cacheEntry)
+		if !entry.expiresAt.IsZero() && time.Now().After(entry.expiresAt) {
+			c.lru.Remove(key)
+			return "", nil
+		}
+		return entry.value, nil
 	}
 	return "", nil
 }
 
 func (c *cache) Put(ctx context.Context, key string, value string) error {
-	c.lru.Add(key, value)
+	c.lru.Add(key, cacheEntry{value: value})
+	return nil
+}
+
+func (c *cache) PutWithTTL(ctx context.Context, key string, value string, ttl time.Duration) error {
+	c.lru.Add(key, cacheEntry{value: value, expiresAt: time.Now().Add(ttl)})
+	return nil
+}
+
+func (c *cache) Delete(ctx context.Context, key string) error {
+	c.lru.Remove(key)
 	return nil
 }
 
@@ -65,8 +87,12 @@ func (c *redisCache) Get(ctx context.Context, key string) (string, error) {
 }
 
 func (c *redisCache) Put(ctx context.Context, key string, value string) error {
+	return c.PutWithTTL(ctx, key, value, redisTTL)
+}
+
+func (c *redisCache) PutWithTTL(ctx context.Context, key string, value string, ttl time.Duration) error {
 	start := time.Now()
-	err := c.rdb.SetEX(ctx, key, value, redisTTL).Err()
+	err := c.rdb.SetEX(ctx, key, value, ttl).Err()
 	redisCacheDurationSumm.WithLabelValues("SETEX").Observe(float64(time.Since(start).Milliseconds()))
 
 	if err != nil {
@@ -75,6 +101,17 @@ func (c *redisCache) Put(ctx context.Context, key string, value string) error {
 	return err
 }
 
+func (c *redisCache) Delete(ctx context.Context, key string) error {
+	start := time.Now()
+	err := c.rdb.Del(ctx, key).Err()
+	redisCacheDurationSumm.WithLabelValues("DEL").Observe(float64(time.Since(start).Milliseconds()))
+
+	if err != nil {
+		RecordRedisError("CacheDelete")
+	}
+	return err
+}
+
 type cacheWithCompression struct {
 	cache Cache
 }
@@ -103,6 +140,15 @@ func (c *cacheWithCompression) Put(ctx context.Context, key string, value string
 	return c.cache.Put(ctx, key, string(encodedVal))
 }
 
+func (c *cacheWithCompression) PutWithTTL(ctx context.Context, key string, value string, ttl time.Duration) error {
+	encodedVal := snappy.Encode(nil, []byte(value))
+	return c.cache.PutWithTTL(ctx, key, string(encodedVal), ttl)
+}
+
+func (c *cacheWithCompression) Delete(ctx context.Context, key string) error {
+	return c.cache.Delete(ctx, key)
+}
+
 type GetLatestBlockNumFn func(ctx context.Context) (uint64, error)
 type GetLatestGasPriceFn func(ctx context.Context) (uint64, error)
 
@@ -116,7 +162,44 @@ type rpcCache struct {
 	handlers map[string]RPCMethodHandler
 }
 
-func newRPCCache(cache Cache, getLatestBlockNumFn GetLatestBlockNumFn, getLatestGasPriceFn GetLatestGasPriceFn, numBlockConfirmations int) RPCCache {
+// cachePolicyMethods are the cached methods whose TTLs can be configured.
+var cachePolicyMethods = map[string]bool{
+	"eth_getBlockByHash":        true,
+	"eth_getTransactionByHash":  true,
+	"eth_getTransactionReceipt": true,
+	"eth_getCode":               true,
+	"eth_getStorageAt":          true,
+}
+
+type rpcCacheOptions struct {
+	policies map[string]cachePolicy
+	reorgs   *ReorgTracker
+}
+
+type RPCCacheOpt func(o *rpcCacheOptions)
+
+// WithCachePolicy sets the TTL of the cached responses of a method, and of its null results.
+// Null results aren't cached if nullResultTTL is 0.
+func WithCachePolicy(method string, ttl time.Duration, nullResultTTL time.Duration) RPCCacheOpt {
+	return func(o *rpcCacheOptions) {
+		o.policies[method] = cachePolicy{ttl: ttl, nullResultTTL: nullResultTTL}
+	}
+}
+
+// WithReorgTracker enables caching responses for blocks without enough confirmations,
+// which are invalidated by the tracker when their block is reorged out.
+func WithReorgTracker(reorgs *ReorgTracker) RPCCacheOpt {
+	return func(o *rpcCacheOptions) {
+		o.reorgs = reorgs
+	}
+}
+
+func newRPCCache(cache Cache, getLatestBlockNumFn GetLatestBlockNumFn, getLatestGasPriceFn GetLatestGasPriceFn, numBlockConfirmations int, opts ...RPCCacheOpt) RPCCache {
+	o := &rpcCacheOptions{policies: make(map[string]cachePolicy)}
+	for _, opt := range opts {
+		opt(o)
+	}
+
 	handlers := map[string]RPCMethodHandler{
 		"eth_chainId":          &StaticMethodHandler{},
 		"net_version":          &StaticMethodHandler{},
@@ -125,6 +208,17 @@ func newRPCCache(cache Cache, getLatestBlockNumFn GetLatestBlockNumFn, getLatest
 		"eth_blockNumber":      &EthBlockNumberMethodHandler{getLatestBlockNumFn},
 		"eth_gasPrice":         &EthGasPriceMethodHandler{getLatestGasPriceFn},
 		"eth_call":             &EthCallMethodHandler{cache, getLatestBlockNumFn, numBlockConfirmations},
+		"eth_getBlockByHash":   &EthGetBlockByHashMethodHandler{cache, o.policies["eth_getBlockByHash"]},
+		"eth_getTransactionByHash": &EthGetTransactionMethodHandler{
+			"eth_getTransactionByHash", cache, o.policies["eth_getTransactionByHash"],
+			getLatestBlockNumFn, numBlockConfirmations, o.reorgs,
+		},
+		"eth_getTransactionReceipt": &EthGetTransactionMethodHandler{
+			"eth_getTransactionReceipt", cache, o.policies["eth_getTransactionReceipt"],
+			getLatestBlockNumFn, numBlockConfirmations, o.reorgs,
+		},
+		"eth_getCode":      &EthBlockHashStateMethodHandler{"eth_getCode", 1, cache, o.policies["eth_getCode"]},
+		"eth_getStorageAt": &EthBlockHashStateMethodHandler{"eth_getStorageAt", 2, cache, o.policies["eth_getStorageAt"]},
 	}
 	return &rpcCache{
 		cache:    cache,
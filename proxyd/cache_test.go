@@ -5,6 +5,7 @@ import (
 	"math"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -620,3 +621,204 @@ func TestRPCCacheEthCall(t *testing.T) {
 		require.Nil(t, cachedRes)
 	})
 }
+
+func TestRPCCacheEthGetTransactionReceipt(t *testing.T) {
+	ctx := context.Background()
+
+	var blockHead uint64
+	fn := func(ctx context.Context) (uint64, error) {
+		return blockHead, nil
+	}
+	ID := []byte(strconv.Itoa(1))
+	txHash := "0x1111111111111111111111111111111111111111111111111111111111111111"
+	blockHash := "0x2222222222222222222222222222222222222222222222222222222222222222"
+
+	req := &RPCReq{
+		JSONRPC: "2.0",
+		Method:  "eth_getTransactionReceipt",
+		Params:  []byte(`["` + txHash + `"]`),
+		ID:      ID,
+	}
+	res := &RPCRes{
+		JSONRPC: "2.0",
+		Result: map[string]interface{}{
+			"transactionHash": txHash,
+			"blockHash":       blockHash,
+			"blockNumber":     "0xa",
+			"status":          "0x1",
+		},
+		ID: ID,
+	}
+	nullRes := &RPCRes{
+		JSONRPC: "2.0",
+		ID:      ID,
+	}
+
+	t.Run("confirmed transaction", func(t *testing.T) {
+		blockHead = 100
+		cache := newRPCCache(newMemoryCache(), fn, nil, numBlockConfirmations)
+		require.NoError(t, cache.PutRPC(ctx, req, res))
+		cachedRes, err := cache.GetRPC(ctx, req)
+		require.NoError(t, err)
+		require.Equal(t, res, cachedRes)
+	})
+
+	t.Run("unconfirmed transaction", func(t *testing.T) {
+		blockHead = 0xc
+		cache := newRPCCache(newMemoryCache(), fn, nil, numBlockConfirmations)
+		require.NoError(t, cache.PutRPC(ctx, req, res))
+		cachedRes, err := cache.GetRPC(ctx, req)
+		require.NoError(t, err)
+		require.Nil(t, cachedRes)
+	})
+
+	t.Run("unconfirmed transaction with reorg tracking", func(t *testing.T) {
+		blockHead = 0xc
+		reorgs := NewReorgTracker(newMemoryCache(), numBlockConfirmations, nil)
+		cache := newRPCCache(newMemoryCache(), fn, nil, numBlockConfirmations, WithReorgTracker(reorgs))
+		require.NoError(t, cache.PutRPC(ctx, req, res))
+		cachedRes, err := cache.GetRPC(ctx, req)
+		require.NoError(t, err)
+		require.Equal(t, res, cachedRes)
+	})
+
+	t.Run("pending transaction", func(t *testing.T) {
+		blockHead = 100
+		cache := newRPCCache(newMemoryCache(), fn, nil, numBlockConfirmations)
+		pendingReq := &RPCReq{
+			JSONRPC: "2.0",
+			Method:  "eth_getTransactionByHash",
+			Params:  []byte(`["` + txHash + `"]`),
+			ID:      ID,
+		}
+		pendingRes := &RPCRes{
+			JSONRPC: "2.0",
+			Result:  map[string]interface{}{"hash": txHash, "blockHash": nil, "blockNumber": nil},
+			ID:      ID,
+		}
+		require.NoError(t, cache.PutRPC(ctx, pendingReq, pendingRes))
+		cachedRes, err := cache.GetRPC(ctx, pendingReq)
+		require.NoError(t, err)
+		require.Nil(t, cachedRes)
+	})
+
+	t.Run("null result", func(t *testing.T) {
+		blockHead = 100
+		cache := newRPCCache(newMemoryCache(), fn, nil, numBlockConfirmations)
+		require.NoError(t, cache.PutRPC(ctx, req, nullRes))
+		cachedRes, err := cache.GetRPC(ctx, req)
+		require.NoError(t, err)
+		require.Nil(t, cachedRes)
+	})
+
+	t.Run("null result with a null result TTL", func(t *testing.T) {
+		blockHead = 100
+		cache := newRPCCache(newMemoryCache(), fn, nil, numBlockConfirmations,
+			WithCachePolicy("eth_getTransactionReceipt", 0, 50*time.Millisecond))
+		require.NoError(t, cache.PutRPC(ctx, req, nullRes))
+		cachedRes, err := cache.GetRPC(ctx, req)
+		require.NoError(t, err)
+		require.Equal(t, nullRes, cachedRes)
+
+		time.Sleep(100 * time.Millisecond)
+		cachedRes, err = cache.GetRPC(ctx, req)
+		require.NoError(t, err)
+		require.Nil(t, cachedRes)
+	})
+
+	t.Run("TTL", func(t *testing.T) {
+		blockHead = 100
+		cache := newRPCCache(newMemoryCache(), fn, nil, numBlockConfirmations,
+			WithCachePolicy("eth_getTransactionReceipt", 50*time.Millisecond, 0))
+		require.NoError(t, cache.PutRPC(ctx, req, res))
+		cachedRes, err := cache.GetRPC(ctx, req)
+		require.NoError(t, err)
+		require.Equal(t, res, cachedRes)
+
+		time.Sleep(100 * time.Millisecond)
+		cachedRes, err = cache.GetRPC(ctx, req)
+		require.NoError(t, err)
+		require.Nil(t, cachedRes)
+	})
+}
+
+func TestRPCCacheEthGetBlockByHash(t *testing.T) {
+	ctx := context.Background()
+
+	fn := func(ctx context.Context) (uint64, error) {
+		return 0, nil
+	}
+	cache := newRPCCache(newMemoryCache(), fn, nil, numBlockConfirmations)
+	ID := []byte(strconv.Itoa(1))
+	blockHash := "0x2222222222222222222222222222222222222222222222222222222222222222"
+
+	req := &RPCReq{
+		JSONRPC: "2.0",
+		Method:  "eth_getBlockByHash",
+		Params:  []byte(`["` + blockHash + `", false]`),
+		ID:      ID,
+	}
+	res := &RPCRes{
+		JSONRPC: "2.0",
+		Result:  `{"difficulty": "0x1", "number": "0x1"}`,
+		ID:      ID,
+	}
+	require.NoError(t, cache.PutRPC(ctx, req, res))
+	cachedRes, err := cache.GetRPC(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, res, cachedRes)
+
+	// The transactions are part of the cache key
+	req.Params = []byte(`["` + blockHash + `", true]`)
+	cachedRes, err = cache.GetRPC(ctx, req)
+	require.NoError(t, err)
+	require.Nil(t, cachedRes)
+}
+
+func TestRPCCacheStateAtBlockHash(t *testing.T) {
+	ctx := context.Background()
+
+	fn := func(ctx context.Context) (uint64, error) {
+		return 0, nil
+	}
+	cache := newRPCCache(newMemoryCache(), fn, nil, numBlockConfirmations)
+	ID := []byte(strconv.Itoa(1))
+	blockHash := "0x2222222222222222222222222222222222222222222222222222222222222222"
+
+	tests := []struct {
+		name      string
+		method    string
+		params    string
+		cacheable bool
+	}{
+		{"code at block hash", "eth_getCode", `["0x01", {"blockHash": "` + blockHash + `"}]`, true},
+		{"storage at block hash", "eth_getStorageAt", `["0x01", "0x0", {"blockHash": "` + blockHash + `"}]`, true},
+		{"canonical block hash", "eth_getCode", `["0x02", {"blockHash": "` + blockHash + `", "requireCanonical": true}]`, false},
+		{"block number", "eth_getCode", `["0x03", "0x1"]`, false},
+		{"block number object", "eth_getCode", `["0x04", {"blockNumber": "0x1"}]`, false},
+		{"latest", "eth_getStorageAt", `["0x01", "0x0", "latest"]`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &RPCReq{
+				JSONRPC: "2.0",
+				Method:  tt.method,
+				Params:  []byte(tt.params),
+				ID:      ID,
+			}
+			res := &RPCRes{
+				JSONRPC: "2.0",
+				Result:  "0x1234",
+				ID:      ID,
+			}
+			require.NoError(t, cache.PutRPC(ctx, req, res))
+			cachedRes, err := cache.GetRPC(ctx, req)
+			require.NoError(t, err)
+			if tt.cacheable {
+				require.Equal(t, res, cachedRes)
+			} else {
+				require.Nil(t, cachedRes)
+			}
+		})
+	}
+}
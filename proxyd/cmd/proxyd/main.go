@@ -32,8 +32,9 @@ func main() {
 		log.Crit("must specify a config file on the command line")
 	}
 
-	config := new(proxyd.Config)
-	if _, err := toml.DecodeFile(os.Args[1], config); err != nil {
+	configPath := os.Args[1]
+	config, err := readConfig(configPath)
+	if err != nil {
 		log.Crit("error reading config file", "err", err)
 	}
 
@@ -52,7 +53,10 @@ func main() {
 		),
 	)
 
-	shutdown, err := proxyd.Start(config)
+	// The config file is read again when proxyd receives SIGHUP.
+	shutdown, err := proxyd.Start(config, proxyd.WithConfigLoader(func() (*proxyd.Config, error) {
+		return readConfig(configPath)
+	}))
 	if err != nil {
 		log.Crit("error starting proxyd", "err", err)
 	}
@@ -63,3 +67,11 @@ func main() {
 	log.Info("caught signal, shutting down", "signal", recvSig)
 	shutdown()
 }
+
+func readConfig(path string) (*proxyd.Config, error) {
+	config := new(proxyd.Config)
+	if _, err := toml.DecodeFile(path, config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
@@ -26,9 +26,54 @@ type ServerConfig struct {
 }
 
 type CacheConfig struct {
-	Enabled               bool   `toml:"enabled"`
-	BlockSyncRPCURL       string `toml:"block_sync_rpc_url"`
-	NumBlockConfirmations int    `toml:"num_block_confirmations"`
+	Enabled               bool                          `toml:"enabled"`
+	BlockSyncRPCURL       string                        `toml:"block_sync_rpc_url"`
+	NumBlockConfirmations int                           `toml:"num_block_confirmations"`
+	InvalidateOnReorg     bool                          `toml:"invalidate_on_reorg"`
+	Methods               map[string]*CacheMethodConfig `toml:"methods"`
+}
+
+type CacheMethodConfig struct {
+	TTL           TOMLDuration `toml:"ttl"`
+	NullResultTTL TOMLDuration `toml:"null_result_ttl"`
+}
+
+type GetLogsConfig struct {
+	MaxBlockRange      uint64 `toml:"max_block_range"`
+	MaxAddresses       int    `toml:"max_addresses"`
+	MaxTopics          int    `toml:"max_topics"`
+	SplitRanges        bool   `toml:"split_ranges"`
+	MaxSplitBlockRange uint64 `toml:"max_split_block_range"`
+}
+
+type WSSubscriptionsConfig struct {
+	Multiplex  bool `toml:"multiplex"`
+	BufferSize int  `toml:"buffer_size"`
+}
+
+type TenantsConfig struct {
+	File           string       `toml:"file"`
+	ReloadInterval TOMLDuration `toml:"reload_interval"`
+}
+
+type AccessLogConfig struct {
+	File       string `toml:"file"`
+	MaxSizeMB  int    `toml:"max_size_mb"`
+	MaxBackups int    `toml:"max_backups"`
+}
+
+type TracingConfig struct {
+	OTLPEndpoint   string            `toml:"otlp_endpoint"`
+	Headers        map[string]string `toml:"headers"`
+	ServiceName    string            `toml:"service_name"`
+	SampleRatio    float64           `toml:"sample_ratio"`
+	ExportInterval TOMLDuration      `toml:"export_interval"`
+}
+
+type AdminConfig struct {
+	Host  string `toml:"host"`
+	Port  int    `toml:"port"`
+	Token string `toml:"token"`
 }
 
 type RedisConfig struct {
@@ -88,12 +133,29 @@ type BackendConfig struct {
 	ClientCertFile   string `toml:"client_cert_file"`
 	ClientKeyFile    string `toml:"client_key_file"`
 	StripTrailingXFF bool   `toml:"strip_trailing_xff"`
+	// Weight defaults to DefaultBackendWeight when unset. A backend with
+	// weight 0 only serves the requests the other backends fail.
+	Weight *int `toml:"weight"`
 }
 
 type BackendsConfig map[string]*BackendConfig
 
 type BackendGroupConfig struct {
 	Backends []string `toml:"backends"`
+
+	RoutingStrategy string `toml:"routing_strategy"`
+	StickyRouting   bool   `toml:"sticky_routing"`
+
+	ShadowBackends              []string `toml:"shadow_backends"`
+	ShadowIgnoredFields         []string `toml:"shadow_ignored_fields"`
+	ShadowMaxConcurrentRequests int64    `toml:"shadow_max_concurrent_requests"`
+	ShadowTimeoutSeconds        int      `toml:"shadow_timeout_seconds"`
+
+	ConsensusAware              bool         `toml:"consensus_aware"`
+	ConsensusBanPeriod          TOMLDuration `toml:"consensus_ban_period"`
+	ConsensusMaxUpdateThreshold TOMLDuration `toml:"consensus_max_update_threshold"`
+	ConsensusMaxBlockLag        uint64       `toml:"consensus_max_block_lag"`
+	ConsensusPollerInterval     TOMLDuration `toml:"consensus_poller_interval"`
 }
 
 type BackendGroupsConfig map[string]*BackendGroupConfig
@@ -113,6 +175,17 @@ type SenderRateLimitConfig struct {
 	Limit    int
 }
 
+// TxPolicyConfig configures the validation of the transactions sent with
+// eth_sendRawTransaction, and their broadcast to a backend group.
+type TxPolicyConfig struct {
+	ChainID     uint64 `toml:"chain_id"`
+	MaxGasLimit uint64 `toml:"max_gas_limit"`
+	// MinFeeCap is the minimum gas fee cap, or gas price of legacy transactions, in wei.
+	MinFeeCap             uint64 `toml:"min_fee_cap"`
+	MaxNonceGap           uint64 `toml:"max_nonce_gap"`
+	BroadcastBackendGroup string `toml:"broadcast_backend_group"`
+}
+
 type Config struct {
 	WSBackendGroup        string                `toml:"ws_backend_group"`
 	Server                ServerConfig          `toml:"server"`
@@ -127,8 +200,15 @@ type Config struct {
 	BackendGroups         BackendGroupsConfig   `toml:"backend_groups"`
 	RPCMethodMappings     map[string]string     `toml:"rpc_method_mappings"`
 	WSMethodWhitelist     []string              `toml:"ws_method_whitelist"`
+	WSSubscriptions       WSSubscriptionsConfig `toml:"ws_subscriptions"`
 	WhitelistErrorMessage string                `toml:"whitelist_error_message"`
 	SenderRateLimit       SenderRateLimitConfig `toml:"sender_rate_limit"`
+	TxPolicy              TxPolicyConfig        `toml:"tx_policy"`
+	GetLogs               GetLogsConfig         `toml:"get_logs"`
+	Tenants               TenantsConfig         `toml:"tenants"`
+	Admin                 AdminConfig           `toml:"admin"`
+	AccessLog             AccessLogConfig       `toml:"access_log"`
+	Tracing               TracingConfig         `toml:"tracing"`
 }
 
 func ReadFromEnvOrConfig(value string) (string, error) {
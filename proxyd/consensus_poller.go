@@ -0,0 +1,424 @@
+package proxyd
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+const (
+	DefaultConsensusPollerInterval     = 1 * time.Second
+	DefaultConsensusBanPeriod          = 5 * time.Minute
+	DefaultConsensusMaxUpdateThreshold = 30 * time.Second
+	DefaultConsensusMaxBlockLag        = 8
+	consensusPollerBlockRequestTimeout = 5 * time.Second
+)
+
+// ConsensusPoller checks the latest, safe and finalized blocks of every backend in a group
+// and agrees on a consensus head. Backends that are lagging behind, that stopped updating,
+// or that are on a different fork than the majority are removed from the consensus group.
+type ConsensusPoller struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	backendGroup *BackendGroup
+
+	interval           time.Duration
+	banPeriod          time.Duration
+	maxUpdateThreshold time.Duration
+	maxBlockLag        uint64
+
+	stateMu      sync.Mutex
+	backendState map[*Backend]*backendState
+
+	consensusMu    sync.RWMutex
+	consensus      ConsensusBlocks
+	consensusGroup []*Backend
+}
+
+// ConsensusBlocks are the blocks every backend of the consensus group agrees on.
+type ConsensusBlocks struct {
+	Latest     hexutil.Uint64
+	LatestHash string
+	Safe       hexutil.Uint64
+	Finalized  hexutil.Uint64
+}
+
+type backendState struct {
+	latestBlockNumber    hexutil.Uint64
+	latestBlockHash      string
+	safeBlockNumber      hexutil.Uint64
+	finalizedBlockNumber hexutil.Uint64
+	lastUpdate           time.Time
+	bannedUntil          time.Time
+}
+
+type ConsensusOpt func(cp *ConsensusPoller)
+
+func WithConsensusPollerInterval(interval time.Duration) ConsensusOpt {
+	return func(cp *ConsensusPoller) {
+		cp.interval = interval
+	}
+}
+
+func WithConsensusBanPeriod(banPeriod time.Duration) ConsensusOpt {
+	return func(cp *ConsensusPoller) {
+		cp.banPeriod = banPeriod
+	}
+}
+
+func WithConsensusMaxUpdateThreshold(maxUpdateThreshold time.Duration) ConsensusOpt {
+	return func(cp *ConsensusPoller) {
+		cp.maxUpdateThreshold = maxUpdateThreshold
+	}
+}
+
+func WithConsensusMaxBlockLag(maxBlockLag uint64) ConsensusOpt {
+	return func(cp *ConsensusPoller) {
+		cp.maxBlockLag = maxBlockLag
+	}
+}
+
+func NewConsensusPoller(bg *BackendGroup, opts ...ConsensusOpt) *ConsensusPoller {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	state := make(map[*Backend]*backendState, len(bg.Backends))
+	for _, be := range bg.Backends {
+		state[be] = &backendState{}
+	}
+
+	cp := &ConsensusPoller{
+		ctx:                ctx,
+		cancel:             cancel,
+		backendGroup:       bg,
+		interval:           DefaultConsensusPollerInterval,
+		banPeriod:          DefaultConsensusBanPeriod,
+		maxUpdateThreshold: DefaultConsensusMaxUpdateThreshold,
+		maxBlockLag:        DefaultConsensusMaxBlockLag,
+		backendState:       state,
+	}
+
+	for _, opt := range opts {
+		opt(cp)
+	}
+
+	return cp
+}
+
+func (cp *ConsensusPoller) Start() {
+	go func() {
+		cp.Poll(cp.ctx)
+
+		ticker := time.NewTicker(cp.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				cp.Poll(cp.ctx)
+			case <-cp.ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+func (cp *ConsensusPoller) Stop() {
+	cp.cancel()
+}
+
+// Poll updates the state of every backend concurrently, then updates the consensus.
+func (cp *ConsensusPoller) Poll(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, be := range cp.backendGroup.Backends {
+		wg.Add(1)
+		go func(be *Backend) {
+			defer wg.Done()
+			cp.UpdateBackend(ctx, be)
+		}(be)
+	}
+	wg.Wait()
+	cp.UpdateBackendGroupConsensus(ctx)
+}
+
+// GetConsensusGroup returns the backends that agree on the consensus blocks, in config order.
+func (cp *ConsensusPoller) GetConsensusGroup() []*Backend {
+	cp.consensusMu.RLock()
+	defer cp.consensusMu.RUnlock()
+
+	group := make([]*Backend, len(cp.consensusGroup))
+	copy(group, cp.consensusGroup)
+	return group
+}
+
+// GetConsensusBlocks returns the latest, safe and finalized consensus blocks.
+func (cp *ConsensusPoller) GetConsensusBlocks() ConsensusBlocks {
+	cp.consensusMu.RLock()
+	defer cp.consensusMu.RUnlock()
+	return cp.consensus
+}
+
+// UpdateBackend fetches the latest, safe and finalized blocks of a backend.
+// Banned and offline backends are not polled.
+func (cp *ConsensusPoller) UpdateBackend(ctx context.Context, be *Backend) {
+	if cp.IsBanned(be) {
+		log.Debug("skipping banned backend", "backend", be.Name)
+		return
+	}
+	if !be.Online() {
+		log.Debug("skipping offline backend", "backend", be.Name)
+		return
+	}
+
+	latest, latestHash, err := cp.fetchBlock(ctx, be, "latest")
+	if err != nil {
+		log.Warn("error updating backend latest block", "backend", be.Name, "err", err)
+		return
+	}
+	// Not every backend supports the safe and finalized tags, in which case they are left at 0.
+	safe, _, err := cp.fetchBlock(ctx, be, "safe")
+	if err != nil {
+		log.Debug("error updating backend safe block", "backend", be.Name, "err", err)
+	}
+	finalized, _, err := cp.fetchBlock(ctx, be, "finalized")
+	if err != nil {
+		log.Debug("error updating backend finalized block", "backend", be.Name, "err", err)
+	}
+
+	cp.stateMu.Lock()
+	state := cp.backendState[be]
+	if state.latestBlockNumber != latest || state.latestBlockHash != latestHash {
+		state.lastUpdate = time.Now()
+	}
+	state.latestBlockNumber = latest
+	state.latestBlockHash = latestHash
+	state.safeBlockNumber = safe
+	state.finalizedBlockNumber = finalized
+	cp.stateMu.Unlock()
+
+	RecordBackendLatestBlock(be, latest)
+	RecordBackendSafeBlock(be, safe)
+	RecordBackendFinalizedBlock(be, finalized)
+}
+
+// UpdateBackendGroupConsensus agrees on the consensus blocks of the group.
+//
+// Backends are candidates if they are online, not banned and their latest block changed within
+// maxUpdateThreshold. Candidates more than maxBlockLag blocks behind the highest candidate are lagging.
+// The proposed consensus block is the lowest latest block of the remaining candidates, and the
+// majority hash at that height wins. Candidates with a different hash are on a fork and are banned.
+func (cp *ConsensusPoller) UpdateBackendGroupConsensus(ctx context.Context) {
+	states := cp.getBackendStates()
+	prev := cp.GetConsensusBlocks()
+
+	var candidates []*Backend
+	var highest hexutil.Uint64
+	for _, be := range cp.backendGroup.Backends {
+		state := states[be]
+		if !state.bannedUntil.IsZero() && time.Now().Before(state.bannedUntil) {
+			continue
+		}
+		if state.latestBlockNumber == 0 || time.Since(state.lastUpdate) > cp.maxUpdateThreshold {
+			log.Debug("backend is not updating", "backend", be.Name, "last_update", state.lastUpdate)
+			RecordBackendInSync(be, false)
+			continue
+		}
+		if !be.Online() {
+			RecordBackendInSync(be, false)
+			continue
+		}
+		if state.latestBlockNumber > highest {
+			highest = state.latestBlockNumber
+		}
+		candidates = append(candidates, be)
+	}
+
+	var inSync []*Backend
+	for _, be := range candidates {
+		if uint64(highest-states[be].latestBlockNumber) > cp.maxBlockLag {
+			log.Debug("backend is lagging", "backend", be.Name, "latest", states[be].latestBlockNumber, "highest", highest)
+			RecordBackendInSync(be, false)
+			continue
+		}
+		inSync = append(inSync, be)
+	}
+
+	// Blocks must not go backwards, so backends that are behind the current consensus are
+	// excluded while others can serve it. If no backend can serve it, there was a reorg.
+	var notBehind []*Backend
+	for _, be := range inSync {
+		if states[be].latestBlockNumber >= prev.Latest {
+			notBehind = append(notBehind, be)
+		} else {
+			RecordBackendInSync(be, false)
+		}
+	}
+	if len(notBehind) > 0 {
+		inSync = notBehind
+	}
+
+	if len(inSync) == 0 {
+		log.Warn("no backend is in sync", "backend_group", cp.backendGroup.Name)
+		cp.setConsensus(ConsensusBlocks{}, nil)
+		return
+	}
+
+	proposed := states[inSync[0]].latestBlockNumber
+	for _, be := range inSync[1:] {
+		if states[be].latestBlockNumber < proposed {
+			proposed = states[be].latestBlockNumber
+		}
+	}
+
+	// Group the backends by their hash of the proposed block, in config order
+	hashes := make(map[*Backend]string, len(inSync))
+	var hashOrder []string
+	votes := make(map[string]int)
+	for _, be := range inSync {
+		hash := states[be].latestBlockHash
+		if states[be].latestBlockNumber != proposed {
+			var err error
+			_, hash, err = cp.fetchBlock(ctx, be, hexutil.EncodeUint64(uint64(proposed)))
+			if err != nil {
+				log.Warn("error fetching proposed consensus block", "backend", be.Name, "block", proposed, "err", err)
+				RecordBackendInSync(be, false)
+				continue
+			}
+		}
+		hashes[be] = hash
+		if votes[hash] == 0 {
+			hashOrder = append(hashOrder, hash)
+		}
+		votes[hash]++
+	}
+	if len(hashOrder) == 0 {
+		log.Warn("no backend served the proposed consensus block", "backend_group", cp.backendGroup.Name, "block", proposed)
+		cp.setConsensus(ConsensusBlocks{}, nil)
+		return
+	}
+	consensusHash := hashOrder[0]
+	for _, hash := range hashOrder[1:] {
+		if votes[hash] > votes[consensusHash] {
+			consensusHash = hash
+		}
+	}
+
+	var group []*Backend
+	var safe, finalized hexutil.Uint64
+	for _, be := range inSync {
+		hash, ok := hashes[be]
+		if !ok {
+			continue
+		}
+		if hash != consensusHash {
+			log.Warn("backend is on a different fork, banning it", "backend", be.Name, "block", proposed,
+				"hash", hash, "consensus_hash", consensusHash, "ban_period", cp.banPeriod)
+			cp.Ban(be)
+			RecordBackendInSync(be, false)
+			continue
+		}
+		state := states[be]
+		if len(group) == 0 || state.safeBlockNumber < safe {
+			safe = state.safeBlockNumber
+		}
+		if len(group) == 0 || state.finalizedBlockNumber < finalized {
+			finalized = state.finalizedBlockNumber
+		}
+		group = append(group, be)
+		RecordBackendInSync(be, true)
+	}
+
+	// The safe and finalized blocks never go backwards, and never ahead of the latest block
+	if safe < prev.Safe {
+		safe = prev.Safe
+	}
+	if finalized < prev.Finalized {
+		finalized = prev.Finalized
+	}
+	if safe > proposed {
+		safe = proposed
+	}
+	if finalized > safe {
+		finalized = safe
+	}
+
+	consensus := ConsensusBlocks{
+		Latest:     proposed,
+		LatestHash: consensusHash,
+		Safe:       safe,
+		Finalized:  finalized,
+	}
+	cp.setConsensus(consensus, group)
+
+	if consensus != prev {
+		log.Debug("updated consensus", "backend_group", cp.backendGroup.Name, "latest", consensus.Latest,
+			"latest_hash", consensus.LatestHash, "safe", consensus.Safe, "finalized", consensus.Finalized,
+			"group_size", len(group))
+	}
+}
+
+func (cp *ConsensusPoller) setConsensus(consensus ConsensusBlocks, group []*Backend) {
+	cp.consensusMu.Lock()
+	cp.consensus = consensus
+	cp.consensusGroup = group
+	cp.consensusMu.Unlock()
+
+	RecordGroupConsensusBlocks(cp.backendGroup, consensus)
+	RecordGroupConsensusCount(cp.backendGroup, len(group))
+}
+
+// IsBanned returns true if the backend was banned for being on a different fork.
+func (cp *ConsensusPoller) IsBanned(be *Backend) bool {
+	cp.stateMu.Lock()
+	defer cp.stateMu.Unlock()
+	return time.Now().Before(cp.backendState[be].bannedUntil)
+}
+
+// Ban removes the backend from the consensus group for the ban period.
+func (cp *ConsensusPoller) Ban(be *Backend) {
+	cp.stateMu.Lock()
+	defer cp.stateMu.Unlock()
+	cp.backendState[be] = &backendState{bannedUntil: time.Now().Add(cp.banPeriod)}
+}
+
+// Unban makes the backend a consensus candidate again.
+func (cp *ConsensusPoller) Unban(be *Backend) {
+	cp.stateMu.Lock()
+	defer cp.stateMu.Unlock()
+	cp.backendState[be].bannedUntil = time.Time{}
+}
+
+func (cp *ConsensusPoller) getBackendStates() map[*Backend]backendState {
+	cp.stateMu.Lock()
+	defer cp.stateMu.Unlock()
+
+	states := make(map[*Backend]backendState, len(cp.backendState))
+	for be, state := range cp.backendState {
+		states[be] = *state
+	}
+	return states
+}
+
+type consensusBlock struct {
+	Number hexutil.Uint64 `json:"number"`
+	Hash   string         `json:"hash"`
+}
+
+// fetchBlock returns the number and hash of a block by tag or hex encoded number.
+func (cp *ConsensusPoller) fetchBlock(ctx context.Context, be *Backend, block string) (hexutil.Uint64, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, consensusPollerBlockRequestTimeout)
+	defer cancel()
+
+	var res consensusBlock
+	if err := be.ForwardRPC(ctx, &res, "67", "eth_getBlockByNumber", block, false); err != nil {
+		return 0, "", err
+	}
+	if res.Hash == "" {
+		return 0, "", fmt.Errorf("block %s not found", block)
+	}
+	return res.Number, res.Hash, nil
+}
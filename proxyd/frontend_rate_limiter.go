@@ -18,6 +18,11 @@ type FrontendRateLimiter interface {
 	// No error will be returned if the limit could not be taken
 	// as a result of the requestor being over the limit.
 	Take(ctx context.Context, key string) (bool, error)
+
+	// TakeN is like Take, but consumes n units of the limit at
+	// once. It is used to limit weighted requests, e.g. by the
+	// compute units of RPC methods.
+	TakeN(ctx context.Context, key string, n int) (bool, error)
 }
 
 // limitedKeys is a wrapper around a map that stores a truncated
@@ -36,7 +41,7 @@ func newLimitedKeys(t int64) *limitedKeys {
 	}
 }
 
-func (l *limitedKeys) Take(key string, max int) bool {
+func (l *limitedKeys) Take(key string, n int, max int) bool {
 	l.mtx.Lock()
 	defer l.mtx.Unlock()
 	val, ok := l.keys[key]
@@ -44,8 +49,8 @@ func (l *limitedKeys) Take(key string, max int) bool {
 		l.keys[key] = 0
 		val = 0
 	}
-	l.keys[key] = val + 1
-	return val < max
+	l.keys[key] = val + n
+	return val+n <= max
 }
 
 // MemoryFrontendRateLimiter is a rate limiter that stores
@@ -70,6 +75,10 @@ func NewMemoryFrontendRateLimit(dur time.Duration, max int) FrontendRateLimiter
 }
 
 func (m *MemoryFrontendRateLimiter) Take(ctx context.Context, key string) (bool, error) {
+	return m.TakeN(ctx, key, 1)
+}
+
+func (m *MemoryFrontendRateLimiter) TakeN(ctx context.Context, key string, n int) (bool, error) {
 	m.mtx.Lock()
 	// Create truncated timestamp
 	truncTS := truncateNow(m.dur)
@@ -85,7 +94,7 @@ func (m *MemoryFrontendRateLimiter) Take(ctx context.Context, key string) (bool,
 
 	m.mtx.Unlock()
 
-	return limiter.Take(key, m.max), nil
+	return limiter.Take(key, n, m.max), nil
 }
 
 // RedisFrontendRateLimiter is a rate limiter that stores data in Redis.
@@ -108,11 +117,15 @@ func NewRedisFrontendRateLimiter(r *redis.Client, dur time.Duration, max int, pr
 }
 
 func (r *RedisFrontendRateLimiter) Take(ctx context.Context, key string) (bool, error) {
+	return r.TakeN(ctx, key, 1)
+}
+
+func (r *RedisFrontendRateLimiter) TakeN(ctx context.Context, key string, n int) (bool, error) {
 	var incr *redis.IntCmd
 	truncTS := truncateNow(r.dur)
 	fullKey := fmt.Sprintf("rate_limit:%s:%s:%d", r.prefix, key, truncTS)
 	_, err := r.r.Pipelined(ctx, func(pipe redis.Pipeliner) error {
-		incr = pipe.Incr(ctx, fullKey)
+		incr = pipe.IncrBy(ctx, fullKey, int64(n))
 		pipe.PExpire(ctx, fullKey, r.dur-time.Millisecond)
 		return nil
 	})
@@ -121,7 +134,7 @@ func (r *RedisFrontendRateLimiter) Take(ctx context.Context, key string) (bool,
 		return false, err
 	}
 
-	return incr.Val()-1 < int64(r.max), nil
+	return incr.Val() <= int64(r.max), nil
 }
 
 type noopFrontendRateLimiter struct{}
@@ -132,6 +145,10 @@ func (n *noopFrontendRateLimiter) Take(ctx context.Context, key string) (bool, e
 	return true, nil
 }
 
+func (n *noopFrontendRateLimiter) TakeN(ctx context.Context, key string, units int) (bool, error) {
+	return true, nil
+}
+
 // truncateNow truncates the current timestamp
 // to the specified duration.
 func truncateNow(dur time.Duration) int64 {
@@ -51,3 +51,42 @@ func TestFrontendRateLimiter(t *testing.T) {
 		})
 	}
 }
+
+func TestFrontendRateLimiterTakeN(t *testing.T) {
+	redisServer, err := miniredis.Run()
+	require.NoError(t, err)
+	defer redisServer.Close()
+
+	redisClient := redis.NewClient(&redis.Options{
+		Addr: fmt.Sprintf("127.0.0.1:%s", redisServer.Port()),
+	})
+
+	max := 10
+	lims := []struct {
+		name string
+		frl  FrontendRateLimiter
+	}{
+		{"memory", NewMemoryFrontendRateLimit(time.Minute, max)},
+		{"redis", NewRedisFrontendRateLimiter(redisClient, time.Minute, max, "")},
+	}
+
+	for _, cfg := range lims {
+		frl := cfg.frl
+		ctx := context.Background()
+		t.Run(cfg.name, func(t *testing.T) {
+			ok, err := frl.TakeN(ctx, "foo", 4)
+			require.NoError(t, err)
+			require.True(t, ok)
+			ok, err = frl.TakeN(ctx, "foo", 6)
+			require.NoError(t, err)
+			require.True(t, ok)
+			ok, err = frl.TakeN(ctx, "foo", 1)
+			require.NoError(t, err)
+			require.False(t, ok)
+
+			ok, err = frl.TakeN(ctx, "bar", 11)
+			require.NoError(t, err)
+			require.False(t, ok)
+		})
+	}
+}
@@ -0,0 +1,231 @@
+package proxyd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// DefaultGetLogsMaxConcurrentChunks is the maximum number of chunks of a split eth_getLogs
+// request that are forwarded at the same time.
+const DefaultGetLogsMaxConcurrentChunks = 4
+
+// GetLogsGuard rejects eth_getLogs requests that are too expensive for the backends to serve, and
+// optionally splits requests over too large block ranges into chunks that are forwarded separately.
+type GetLogsGuard struct {
+	maxBlockRange       uint64
+	maxAddresses        int
+	maxTopics           int
+	splitRanges         bool
+	maxSplitBlockRange  uint64
+	getLatestBlockNumFn GetLatestBlockNumFn
+}
+
+func NewGetLogsGuard(config GetLogsConfig, getLatestBlockNumFn GetLatestBlockNumFn) *GetLogsGuard {
+	return &GetLogsGuard{
+		maxBlockRange:       config.MaxBlockRange,
+		maxAddresses:        config.MaxAddresses,
+		maxTopics:           config.MaxTopics,
+		splitRanges:         config.SplitRanges,
+		maxSplitBlockRange:  config.MaxSplitBlockRange,
+		getLatestBlockNumFn: getLatestBlockNumFn,
+	}
+}
+
+type getLogsFilter struct {
+	BlockHash *string         `json:"blockHash"`
+	FromBlock *string         `json:"fromBlock"`
+	ToBlock   *string         `json:"toBlock"`
+	Address   json.RawMessage `json:"address"`
+	Topics    []interface{}   `json:"topics"`
+}
+
+// Check validates an eth_getLogs request. It returns true if the request must be split into
+// chunks with ForwardSplit, along with the resolved block range of the request.
+func (g *GetLogsGuard) Check(ctx context.Context, req *RPCReq) (bool, uint64, uint64, error) {
+	var params []json.RawMessage
+	if err := json.Unmarshal(req.Params, &params); err != nil || len(params) != 1 {
+		return false, 0, 0, ErrInvalidParams("eth_getLogs expects a single filter object")
+	}
+	var filter getLogsFilter
+	if err := json.Unmarshal(params[0], &filter); err != nil {
+		return false, 0, 0, ErrInvalidParams("invalid eth_getLogs filter")
+	}
+
+	if g.maxAddresses > 0 && countAddresses(filter.Address) > g.maxAddresses {
+		return false, 0, 0, ErrInvalidParams(fmt.Sprintf("too many addresses, max is %d", g.maxAddresses))
+	}
+	if g.maxTopics > 0 && countTopics(filter.Topics) > g.maxTopics {
+		return false, 0, 0, ErrInvalidParams(fmt.Sprintf("too many topics, max is %d", g.maxTopics))
+	}
+
+	if g.maxBlockRange == 0 || filter.BlockHash != nil {
+		return false, 0, 0, nil
+	}
+
+	from, err := g.resolveBlock(ctx, filter.FromBlock)
+	if err != nil {
+		return false, 0, 0, err
+	}
+	to, err := g.resolveBlock(ctx, filter.ToBlock)
+	if err != nil {
+		return false, 0, 0, err
+	}
+	// Let the backends respond to invalid ranges
+	if from > to {
+		return false, 0, 0, nil
+	}
+
+	blockRange := to - from + 1
+	if blockRange <= g.maxBlockRange {
+		return false, from, to, nil
+	}
+	if g.splitRanges && (g.maxSplitBlockRange == 0 || blockRange <= g.maxSplitBlockRange) {
+		return true, from, to, nil
+	}
+	maxBlockRange := g.maxBlockRange
+	if g.splitRanges {
+		maxBlockRange = g.maxSplitBlockRange
+	}
+	return false, 0, 0, ErrInvalidParams(fmt.Sprintf("block range is too large, max is %d", maxBlockRange))
+}
+
+// resolveBlock resolves a block tag to a block number. The safe and finalized tags are resolved
+// to the latest block, since they are always behind it, which undercounts their range.
+func (g *GetLogsGuard) resolveBlock(ctx context.Context, block *string) (uint64, error) {
+	tag := "latest"
+	if block != nil {
+		tag = *block
+	}
+
+	switch tag {
+	case "earliest":
+		return 0, nil
+	case "latest", "pending", "safe", "finalized":
+		latest, err := g.getLatestBlockNumFn(ctx)
+		if err != nil {
+			log.Error("error getting latest block number for eth_getLogs", "req_id", GetReqID(ctx), "err", err)
+			return 0, ErrInternal
+		}
+		return latest, nil
+	}
+
+	blockNum, err := hexutil.DecodeUint64(tag)
+	if err != nil {
+		return 0, ErrInvalidParams("invalid block number")
+	}
+	return blockNum, nil
+}
+
+// ForwardSplit forwards an eth_getLogs request over the block range [from, to] to the backend group,
+// in chunks of at most maxBlockRange blocks, and merges the logs of every chunk in order.
+func (g *GetLogsGuard) ForwardSplit(ctx context.Context, bg *BackendGroup, req *RPCReq, from, to uint64) *RPCRes {
+	var params []map[string]json.RawMessage
+	if err := json.Unmarshal(req.Params, &params); err != nil {
+		return NewRPCErrorRes(req.ID, ErrInvalidParams("invalid eth_getLogs filter"))
+	}
+
+	var chunks []*RPCReq
+	for start := from; start <= to; start += g.maxBlockRange {
+		end := start + g.maxBlockRange - 1
+		if end > to {
+			end = to
+		}
+		filter := make(map[string]json.RawMessage, len(params[0]))
+		for k, v := range params[0] {
+			filter[k] = v
+		}
+		filter["fromBlock"] = mustMarshalJSON(hexutil.EncodeUint64(start))
+		filter["toBlock"] = mustMarshalJSON(hexutil.EncodeUint64(end))
+		chunks = append(chunks, &RPCReq{
+			JSONRPC: req.JSONRPC,
+			Method:  req.Method,
+			Params:  mustMarshalJSON([]interface{}{filter}),
+			ID:      req.ID,
+		})
+		// Avoid overflowing when the range ends at the max uint64
+		if end == to {
+			break
+		}
+	}
+	RecordGetLogsSplit(len(chunks))
+
+	results := make([][]json.RawMessage, len(chunks))
+	errs := make([]error, len(chunks))
+	sem := make(chan struct{}, DefaultGetLogsMaxConcurrentChunks)
+	var wg sync.WaitGroup
+	for i, chunk := range chunks {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, chunk *RPCReq) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i], errs[i] = forwardGetLogsChunk(ctx, bg, chunk)
+		}(i, chunk)
+	}
+	wg.Wait()
+
+	var logs []json.RawMessage
+	for i := range chunks {
+		if errs[i] != nil {
+			log.Warn(
+				"error forwarding eth_getLogs chunk",
+				"req_id", GetReqID(ctx),
+				"chunk", string(chunks[i].Params),
+				"err", errs[i],
+			)
+			return NewRPCErrorRes(req.ID, errs[i])
+		}
+		logs = append(logs, results[i]...)
+	}
+	if logs == nil {
+		logs = make([]json.RawMessage, 0)
+	}
+	return NewRPCRes(req.ID, logs)
+}
+
+func forwardGetLogsChunk(ctx context.Context, bg *BackendGroup, chunk *RPCReq) ([]json.RawMessage, error) {
+	res, err := bg.Forward(ctx, []*RPCReq{chunk}, false)
+	if err != nil {
+		return nil, err
+	}
+	if res[0].IsError() {
+		return nil, res[0].Error
+	}
+	var logs []json.RawMessage
+	if err := json.Unmarshal(mustMarshalJSON(res[0].Result), &logs); err != nil {
+		return nil, ErrBackendBadResponse
+	}
+	return logs, nil
+}
+
+// countAddresses counts the addresses of a filter, which can be a single address or a list.
+func countAddresses(address json.RawMessage) int {
+	if len(address) == 0 || string(address) == "null" {
+		return 0
+	}
+	var addresses []string
+	if err := json.Unmarshal(address, &addresses); err != nil {
+		return 1
+	}
+	return len(addresses)
+}
+
+// countTopics counts the topics of a filter. Every position can be null, a single topic or a list of topics.
+func countTopics(topics []interface{}) int {
+	var count int
+	for _, topic := range topics {
+		switch topic := topic.(type) {
+		case []interface{}:
+			count += len(topic)
+		case nil:
+		default:
+			count++
+		}
+	}
+	return count
+}
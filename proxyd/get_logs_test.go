@@ -0,0 +1,63 @@
+package proxyd
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetLogsGuardCheck(t *testing.T) {
+	latest := func(ctx context.Context) (uint64, error) {
+		return 1000, nil
+	}
+
+	tests := []struct {
+		name   string
+		config GetLogsConfig
+		filter string
+		split  bool
+		from   uint64
+		to     uint64
+		err    string
+	}{
+		{"no limits", GetLogsConfig{}, `{"fromBlock":"earliest"}`, false, 0, 0, ""},
+		{"within range", GetLogsConfig{MaxBlockRange: 100}, `{"fromBlock":"0x384","toBlock":"0x3e7"}`, false, 900, 999, ""},
+		{"latest defaults", GetLogsConfig{MaxBlockRange: 100}, `{}`, false, 1000, 1000, ""},
+		{"latest resolved", GetLogsConfig{MaxBlockRange: 100}, `{"fromBlock":"0x384"}`, false, 0, 0, "block range is too large, max is 100"},
+		{"earliest", GetLogsConfig{MaxBlockRange: 100}, `{"fromBlock":"earliest","toBlock":"0x10"}`, false, 0, 16, ""},
+		{"block hash", GetLogsConfig{MaxBlockRange: 100}, `{"blockHash":"0x01"}`, false, 0, 0, ""},
+		{"inverted range", GetLogsConfig{MaxBlockRange: 100}, `{"fromBlock":"0x100","toBlock":"0x1"}`, false, 0, 0, ""},
+		{"invalid block", GetLogsConfig{MaxBlockRange: 100}, `{"fromBlock":"foo"}`, false, 0, 0, "invalid block number"},
+		{"split", GetLogsConfig{MaxBlockRange: 100, SplitRanges: true}, `{"fromBlock":"0x0"}`, true, 0, 1000, ""},
+		{"split within max", GetLogsConfig{MaxBlockRange: 100, SplitRanges: true, MaxSplitBlockRange: 1001}, `{"fromBlock":"0x0"}`, true, 0, 1000, ""},
+		{"split over max", GetLogsConfig{MaxBlockRange: 100, SplitRanges: true, MaxSplitBlockRange: 500}, `{"fromBlock":"0x0"}`, false, 0, 0, "block range is too large, max is 500"},
+		{"single address", GetLogsConfig{MaxAddresses: 1}, `{"address":"0x01"}`, false, 0, 0, ""},
+		{"too many addresses", GetLogsConfig{MaxAddresses: 1}, `{"address":["0x01","0x02"]}`, false, 0, 0, "too many addresses, max is 1"},
+		{"topics", GetLogsConfig{MaxTopics: 3}, `{"topics":[null,"0x01",["0x02","0x03"]]}`, false, 0, 0, ""},
+		{"too many topics", GetLogsConfig{MaxTopics: 2}, `{"topics":[null,"0x01",["0x02","0x03"]]}`, false, 0, 0, "too many topics, max is 2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			guard := NewGetLogsGuard(tt.config, latest)
+			req := &RPCReq{
+				JSONRPC: JSONRPCVersion,
+				Method:  "eth_getLogs",
+				Params:  json.RawMessage("[" + tt.filter + "]"),
+				ID:      []byte("1"),
+			}
+			split, from, to, err := guard.Check(context.Background(), req)
+			if tt.err != "" {
+				require.EqualError(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.split, split)
+			if tt.split || tt.from != 0 || tt.to != 0 {
+				require.Equal(t, tt.from, from)
+				require.Equal(t, tt.to, to)
+			}
+		})
+	}
+}
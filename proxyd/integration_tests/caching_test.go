@@ -23,6 +23,7 @@ func TestCaching(t *testing.T) {
 	hdlr.SetRoute("eth_blockNumber", "999", "0x64")
 	hdlr.SetRoute("eth_getBlockByNumber", "999", "dummy_block")
 	hdlr.SetRoute("eth_call", "999", "dummy_call")
+	hdlr.SetRoute("eth_getBlockByHash", "999", "dummy_block")
 
 	// mock LVC requests
 	hdlr.SetFallbackRoute("eth_blockNumber", "0x64")
@@ -88,6 +89,15 @@ func TestCaching(t *testing.T) {
 			"{\"id\":999,\"jsonrpc\":\"2.0\",\"result\":\"0x64\"}",
 			0,
 		},
+		{
+			"eth_getBlockByHash",
+			[]interface{}{
+				"0x2222222222222222222222222222222222222222222222222222222222222222",
+				false,
+			},
+			"{\"id\":999,\"jsonrpc\":\"2.0\",\"result\":\"dummy_block\"}",
+			1,
+		},
 		{
 			"eth_call",
 			[]interface{}{
@@ -147,6 +157,24 @@ func TestCaching(t *testing.T) {
 		RequireEqualJSON(t, resRaw, resCache)
 		require.Equal(t, 2, countRequests(backend, "eth_getBlockByNumber"))
 	})
+
+	t.Run("nil receipts are cached for the null result TTL", func(t *testing.T) {
+		backend.Reset()
+		hdlr.SetRoute("eth_getTransactionReceipt", "999", nil)
+		params := []interface{}{"0x1111111111111111111111111111111111111111111111111111111111111111"}
+		resRaw, _, err := client.SendRPC("eth_getTransactionReceipt", params)
+		require.NoError(t, err)
+		resCache, _, err := client.SendRPC("eth_getTransactionReceipt", params)
+		require.NoError(t, err)
+		RequireEqualJSON(t, []byte("{\"id\":999,\"jsonrpc\":\"2.0\",\"result\":null}"), resRaw)
+		RequireEqualJSON(t, resRaw, resCache)
+		require.Equal(t, 1, countRequests(backend, "eth_getTransactionReceipt"))
+
+		redis.FastForward(2 * time.Second)
+		_, _, err = client.SendRPC("eth_getTransactionReceipt", params)
+		require.NoError(t, err)
+		require.Equal(t, 2, countRequests(backend, "eth_getTransactionReceipt"))
+	})
 }
 
 func TestBatchCaching(t *testing.T) {
@@ -0,0 +1,175 @@
+package integration_tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum-optimism/optimism/proxyd"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/require"
+)
+
+// consensusNode serves the latest, safe and finalized blocks of a mocked chain. Requests for
+// other methods are answered with the name of the node and the params it received.
+type consensusNode struct {
+	name   string
+	mtx    sync.Mutex
+	latest uint64
+	safe   uint64
+	fork   string
+}
+
+func (n *consensusNode) SetHead(latest uint64, safe uint64, fork string) {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	n.latest = latest
+	n.safe = safe
+	n.fork = fork
+}
+
+func (n *consensusNode) block(number uint64) map[string]string {
+	return map[string]string{
+		"number": hexutil.EncodeUint64(number),
+		"hash":   fmt.Sprintf("0x%s%064x", n.fork, number)[:66],
+	}
+}
+
+func (n *consensusNode) result(req *proxyd.RPCReq) interface{} {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+
+	if req.Method != "eth_getBlockByNumber" {
+		return fmt.Sprintf("%s %s", n.name, string(req.Params))
+	}
+
+	var params []interface{}
+	if err := json.Unmarshal(req.Params, &params); err != nil {
+		panic(err)
+	}
+	switch tag := params[0].(string); tag {
+	case "latest":
+		return n.block(n.latest)
+	case "safe", "finalized":
+		return n.block(n.safe)
+	default:
+		number, err := hexutil.DecodeUint64(tag)
+		if err != nil {
+			panic(err)
+		}
+		if number > n.latest {
+			return nil
+		}
+		return n.block(number)
+	}
+}
+
+func (n *consensusNode) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		panic(err)
+	}
+	req, err := proxyd.ParseRPCReq(body)
+	if err != nil {
+		panic(err)
+	}
+	res := &proxyd.RPCRes{
+		JSONRPC: proxyd.JSONRPCVersion,
+		Result:  n.result(req),
+		ID:      req.ID,
+	}
+	if err := json.NewEncoder(w).Encode(res); err != nil {
+		panic(err)
+	}
+}
+
+func TestConsensus(t *testing.T) {
+	nodes := make([]*consensusNode, 3)
+	for i := range nodes {
+		nodes[i] = &consensusNode{name: fmt.Sprintf("node%d", i+1), latest: 0x10, safe: 0x8, fork: "a"}
+		backend := NewMockBackend(nodes[i])
+		defer backend.Close()
+		require.NoError(t, os.Setenv(fmt.Sprintf("NODE%d_URL", i+1), backend.URL()))
+	}
+
+	config := ReadConfig("consensus")
+	client := NewProxydClient("http://127.0.0.1:8545")
+	shutdown, err := proxyd.Start(config)
+	require.NoError(t, err)
+	defer shutdown()
+
+	sendRPC := func(method string, params []interface{}) *proxyd.RPCRes {
+		res, _, err := client.SendRPC(method, params)
+		require.NoError(t, err)
+		rpcRes := new(proxyd.RPCRes)
+		require.NoError(t, json.Unmarshal(res, rpcRes))
+		return rpcRes
+	}
+	requireBlockNumber := func(expected string) {
+		require.Eventually(t, func() bool {
+			return sendRPC("eth_blockNumber", nil).Result == expected
+		}, 5*time.Second, 50*time.Millisecond)
+	}
+	addr := "0x0000000000000000000000000000000000000001"
+
+	t.Run("rewrites block tags to the consensus blocks", func(t *testing.T) {
+		requireBlockNumber("0x10")
+
+		res := sendRPC("eth_getBalance", []interface{}{addr, "latest"})
+		require.Equal(t, fmt.Sprintf(`node1 ["%s","0x10"]`, addr), res.Result)
+		res = sendRPC("eth_getBalance", []interface{}{addr})
+		require.Equal(t, fmt.Sprintf(`node1 ["%s","0x10"]`, addr), res.Result)
+		res = sendRPC("eth_getBalance", []interface{}{addr, "safe"})
+		require.Equal(t, fmt.Sprintf(`node1 ["%s","0x8"]`, addr), res.Result)
+		res = sendRPC("eth_getBalance", []interface{}{addr, "pending"})
+		require.Equal(t, fmt.Sprintf(`node1 ["%s","pending"]`, addr), res.Result)
+	})
+
+	t.Run("consensus is the lowest block of the group", func(t *testing.T) {
+		nodes[0].SetHead(0x11, 0x8, "a")
+		nodes[1].SetHead(0x12, 0x9, "a")
+		nodes[2].SetHead(0x12, 0x9, "a")
+		requireBlockNumber("0x11")
+	})
+
+	t.Run("rejects blocks past the consensus", func(t *testing.T) {
+		res, code, err := client.SendRPC("eth_getBalance", []interface{}{addr, "0x100"})
+		require.NoError(t, err)
+		require.Equal(t, 400, code)
+		rpcRes := new(proxyd.RPCRes)
+		require.NoError(t, json.Unmarshal(res, rpcRes))
+		require.True(t, rpcRes.IsError())
+		require.Equal(t, proxyd.ErrRewriteBlockOutOfRange.Code, rpcRes.Error.Code)
+	})
+
+	t.Run("removes lagging backends", func(t *testing.T) {
+		nodes[1].SetHead(0x30, 0x20, "a")
+		nodes[2].SetHead(0x30, 0x20, "a")
+		requireBlockNumber("0x30")
+
+		res := sendRPC("eth_getBalance", []interface{}{addr, "latest"})
+		require.Equal(t, fmt.Sprintf(`node2 ["%s","0x30"]`, addr), res.Result)
+	})
+
+	t.Run("removes backends on a different fork", func(t *testing.T) {
+		nodes[0].SetHead(0x31, 0x20, "a")
+		nodes[1].SetHead(0x31, 0x20, "b")
+		nodes[2].SetHead(0x31, 0x20, "a")
+		requireBlockNumber("0x31")
+
+		res := sendRPC("eth_getBalance", []interface{}{addr, "latest"})
+		require.Equal(t, fmt.Sprintf(`node1 ["%s","0x31"]`, addr), res.Result)
+
+		// node2 is banned, so node3 serves the requests once node1 falls behind
+		nodes[0].SetHead(0x20, 0x20, "a")
+		require.Eventually(t, func() bool {
+			res := sendRPC("eth_getBalance", []interface{}{addr, "latest"})
+			return res.Result == fmt.Sprintf(`node3 ["%s","0x31"]`, addr)
+		}, 5*time.Second, 50*time.Millisecond)
+	})
+}
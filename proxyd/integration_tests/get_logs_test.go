@@ -0,0 +1,138 @@
+package integration_tests
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ethereum-optimism/optimism/proxyd"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/require"
+)
+
+// getLogsHandler serves a chain at block 0x100 with one log per block.
+func getLogsHandler(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		panic(err)
+	}
+	req, err := proxyd.ParseRPCReq(body)
+	if err != nil {
+		panic(err)
+	}
+
+	var result interface{}
+	switch req.Method {
+	case "eth_blockNumber":
+		result = "0x100"
+	case "eth_getLogs":
+		var params []map[string]string
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			panic(err)
+		}
+		block := func(tag string) uint64 {
+			if tag == "" || tag == "latest" {
+				return 0x100
+			}
+			return hexutil.MustDecodeUint64(tag)
+		}
+		from := block(params[0]["fromBlock"])
+		to := block(params[0]["toBlock"])
+		logs := make([]map[string]string, 0)
+		for i := from; i <= to; i++ {
+			logs = append(logs, map[string]string{"blockNumber": hexutil.EncodeUint64(i)})
+		}
+		result = logs
+	}
+
+	res := &proxyd.RPCRes{
+		JSONRPC: proxyd.JSONRPCVersion,
+		Result:  result,
+		ID:      req.ID,
+	}
+	if err := json.NewEncoder(w).Encode(res); err != nil {
+		panic(err)
+	}
+}
+
+func TestGetLogs(t *testing.T) {
+	node := NewMockBackend(http.HandlerFunc(getLogsHandler))
+	defer node.Close()
+
+	require.NoError(t, os.Setenv("NODE_URL", node.URL()))
+
+	config := ReadConfig("get_logs")
+	client := NewProxydClient("http://127.0.0.1:8545")
+	shutdown, err := proxyd.Start(config)
+	require.NoError(t, err)
+	defer shutdown()
+
+	getLogs := func(filter map[string]interface{}) *proxyd.RPCRes {
+		res, _, err := client.SendRPC("eth_getLogs", []interface{}{filter})
+		require.NoError(t, err)
+		rpcRes := new(proxyd.RPCRes)
+		require.NoError(t, json.Unmarshal(res, rpcRes))
+		return rpcRes
+	}
+	requireLogs := func(res *proxyd.RPCRes, from, to uint64) {
+		require.False(t, res.IsError(), "unexpected error: %v", res.Error)
+		logs := res.Result.([]interface{})
+		require.Len(t, logs, int(to-from+1))
+		for i, l := range logs {
+			require.Equal(t, hexutil.EncodeUint64(from+uint64(i)), l.(map[string]interface{})["blockNumber"])
+		}
+	}
+
+	t.Run("forwards ranges within the limit", func(t *testing.T) {
+		node.Reset()
+		res := getLogs(map[string]interface{}{"fromBlock": "0x1", "toBlock": "0xa"})
+		requireLogs(res, 1, 10)
+		require.Equal(t, 1, len(node.Requests()))
+	})
+
+	t.Run("splits large ranges and merges the logs", func(t *testing.T) {
+		node.Reset()
+		res := getLogs(map[string]interface{}{"fromBlock": "0x1", "toBlock": "0x19"})
+		requireLogs(res, 1, 25)
+		require.Equal(t, 3, len(node.Requests()))
+	})
+
+	t.Run("rejects ranges over the split limit", func(t *testing.T) {
+		res := getLogs(map[string]interface{}{"fromBlock": "0x1", "toBlock": "0x65"})
+		require.True(t, res.IsError())
+		require.Equal(t, -32602, res.Error.Code)
+	})
+
+	t.Run("resolves latest through the block number", func(t *testing.T) {
+		require.Eventually(t, func() bool {
+			return !getLogs(map[string]interface{}{"fromBlock": "0xf7"}).IsError()
+		}, 5*time.Second, 100*time.Millisecond)
+		requireLogs(getLogs(map[string]interface{}{"fromBlock": "0xf7"}), 0xf7, 0x100)
+		requireLogs(getLogs(map[string]interface{}{"fromBlock": "0xf0", "toBlock": "latest"}), 0xf0, 0x100)
+
+		res := getLogs(map[string]interface{}{"fromBlock": "0x1"})
+		require.True(t, res.IsError())
+		require.Equal(t, -32602, res.Error.Code)
+	})
+
+	t.Run("rejects too many addresses and topics", func(t *testing.T) {
+		res := getLogs(map[string]interface{}{
+			"fromBlock": "0x1",
+			"toBlock":   "0x1",
+			"address":   []string{"0x01", "0x02", "0x03"},
+		})
+		require.True(t, res.IsError())
+		require.Equal(t, -32602, res.Error.Code)
+
+		res = getLogs(map[string]interface{}{
+			"fromBlock": "0x1",
+			"toBlock":   "0x1",
+			"topics":    []interface{}{"0x01", []string{"0x02", "0x03", "0x04", "0x05"}},
+		})
+		require.True(t, res.IsError())
+		require.Equal(t, -32602, res.Error.Code)
+	})
+}
@@ -252,8 +252,10 @@ func (m *MockBackend) wrappedHandler(w http.ResponseWriter, r *http.Request) {
 		Headers: r.Header.Clone(),
 		Body:    body,
 	})
-	m.handler.ServeHTTP(w, clone)
+	handler := m.handler
 	m.mtx.Unlock()
+	// Serve outside of the lock so that concurrent requests are served concurrently.
+	handler.ServeHTTP(w, clone)
 }
 
 type MockWSBackend struct {
@@ -0,0 +1,174 @@
+package integration_tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/ethereum-optimism/optimism/proxyd"
+	"github.com/stretchr/testify/require"
+)
+
+const goodBackendConfig = `[backends.good]
+rpc_url = "$GOOD_BACKEND_RPC_URL"
+ws_url = "$GOOD_BACKEND_WS_URL"`
+
+const reloadedBackends = `[backends.new]
+rpc_url = "$NEW_BACKEND_RPC_URL"
+ws_url = "$NEW_BACKEND_RPC_URL"`
+
+func TestAdminRequiresToken(t *testing.T) {
+	goodBackend := NewMockBackend(BatchedResponseHandler(200, goodResponse))
+	defer goodBackend.Close()
+	require.NoError(t, os.Setenv("GOOD_BACKEND_RPC_URL", goodBackend.URL()))
+	require.NoError(t, os.Setenv("GOOD_BACKEND_WS_URL", goodBackend.URL()))
+
+	config := ReadConfig("reload")
+	config.Admin.Token = ""
+	_, err := proxyd.Start(config)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "must specify an admin token")
+}
+
+func TestConfigReload(t *testing.T) {
+	hdlr := NewBatchRPCResponseRouter()
+	hdlr.SetFallbackRoute("eth_chainId", "hello")
+	hdlr.SetFallbackRoute("eth_call", "hello")
+
+	goodBackend := NewMockBackend(hdlr)
+	defer goodBackend.Close()
+	newBackend := NewMockBackend(hdlr)
+	defer newBackend.Close()
+	node := newSubscriptionNode()
+	wsBackend := NewMockWSBackend(nil, node.OnMessage, nil)
+	defer wsBackend.Close()
+
+	require.NoError(t, os.Setenv("GOOD_BACKEND_RPC_URL", goodBackend.URL()))
+	require.NoError(t, os.Setenv("GOOD_BACKEND_WS_URL", wsBackend.URL()))
+	require.NoError(t, os.Setenv("NEW_BACKEND_RPC_URL", newBackend.URL()))
+
+	original, err := os.ReadFile("testdata/reload.toml")
+	require.NoError(t, err)
+	configPath := filepath.Join(t.TempDir(), "proxyd.toml")
+	writeConfig := func(replacer *strings.Replacer) {
+		contents := replacer.Replace(string(original))
+		require.NoError(t, os.WriteFile(configPath, []byte(contents), 0o600))
+	}
+	writeConfig(strings.NewReplacer())
+
+	config := ReadConfig("reload")
+	shutdown, err := proxyd.Start(config, proxyd.WithConfigLoader(func() (*proxyd.Config, error) {
+		config := new(proxyd.Config)
+		if _, err := toml.DecodeFile(configPath, config); err != nil {
+			return nil, err
+		}
+		return config, nil
+	}))
+	require.NoError(t, err)
+	defer shutdown()
+
+	reloadWithToken := func(token string) (int, []byte) {
+		req, err := http.NewRequest("POST", "http://127.0.0.1:8547/config/reload", nil)
+		require.NoError(t, err)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		var body json.RawMessage
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&body))
+		return res.StatusCode, body
+	}
+	reload := func() (int, []byte) {
+		return reloadWithToken("admin_token")
+	}
+
+	client := NewProxydClient("http://127.0.0.1:8545")
+	wsClient := newSubscriptionClient(t)
+	defer wsClient.HardClose()
+	require.Equal(t, "0x420", wsClient.Request(t, "eth_chainId", `[]`).Result)
+
+	t.Run("requires the admin token", func(t *testing.T) {
+		code, _ := reloadWithToken("")
+		require.Equal(t, 401, code)
+		code, _ = reloadWithToken("wrong_token")
+		require.Equal(t, 401, code)
+	})
+
+	t.Run("swaps backends and method mappings", func(t *testing.T) {
+		_, code, err := client.SendRPC("eth_call", nil)
+		require.NoError(t, err)
+		require.Equal(t, 403, code)
+
+		writeConfig(strings.NewReplacer(
+			goodBackendConfig, reloadedBackends,
+			`backends = ["good"]`, `backends = ["new"]`,
+			`eth_chainId = "main"`, "eth_chainId = \"main\"\neth_call = \"main\"",
+		))
+		code, body := reload()
+		require.Equal(t, 200, code)
+		var res proxyd.ReloadResult
+		require.NoError(t, json.Unmarshal(body, &res))
+		require.Equal(t, []string{"new"}, res.Backends.Added)
+		require.Equal(t, []string{"good"}, res.Backends.Removed)
+		require.Equal(t, []string{}, res.Backends.Updated)
+		require.Equal(t, []string{"main"}, res.BackendGroups.Updated)
+
+		goodBackend.Reset()
+		for _, method := range []string{"eth_chainId", "eth_call"} {
+			res, code, err := client.SendRPC(method, nil)
+			require.NoError(t, err)
+			require.Equal(t, 200, code)
+			RequireEqualJSON(t, []byte(goodResponse), res)
+		}
+		require.Equal(t, 0, len(goodBackend.Requests()))
+		require.Equal(t, 2, len(newBackend.Requests()))
+	})
+
+	t.Run("keeps websocket connections to removed backends", func(t *testing.T) {
+		require.Equal(t, "0x420", wsClient.Request(t, "eth_chainId", `[]`).Result)
+	})
+
+	t.Run("rejects invalid configs", func(t *testing.T) {
+		writeConfig(strings.NewReplacer(`eth_chainId = "main"`, `eth_chainId = "missing"`))
+		code, body := reload()
+		require.Equal(t, 400, code)
+		require.Contains(t, string(body), "undefined backend group missing")
+
+		writeConfig(strings.NewReplacer(`rpc_port = 8545`, `rpc_port = 8548`))
+		code, body = reload()
+		require.Equal(t, 400, code)
+		require.Contains(t, string(body), "changes to server require a restart")
+
+		// The previous config is still applied.
+		newBackend.Reset()
+		_, code, err := client.SendRPC("eth_call", nil)
+		require.NoError(t, err)
+		require.Equal(t, 200, code)
+		require.Equal(t, 1, len(newBackend.Requests()))
+	})
+
+	t.Run("reloads on SIGHUP", func(t *testing.T) {
+		writeConfig(strings.NewReplacer())
+		require.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGHUP))
+
+		require.Eventually(t, func() bool {
+			_, code, err := client.SendRPC("eth_call", nil)
+			require.NoError(t, err)
+			return code == 403
+		}, 5*time.Second, 10*time.Millisecond)
+
+		goodBackend.Reset()
+		_, code, err := client.SendRPC("eth_chainId", nil)
+		require.NoError(t, err)
+		require.Equal(t, 200, code)
+		require.Equal(t, 1, len(goodBackend.Requests()))
+	})
+}
@@ -0,0 +1,69 @@
+package integration_tests
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/ethereum-optimism/optimism/proxyd"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouting(t *testing.T) {
+	node1 := NewMockBackend(BatchedResponseHandler(200, goodResponse))
+	defer node1.Close()
+	node2 := NewMockBackend(BatchedResponseHandler(200, goodResponse))
+	defer node2.Close()
+
+	require.NoError(t, os.Setenv("NODE1_URL", node1.URL()))
+	require.NoError(t, os.Setenv("NODE2_URL", node2.URL()))
+
+	config := ReadConfig("routing")
+	shutdown, err := proxyd.Start(config)
+	require.NoError(t, err)
+	defer shutdown()
+
+	t.Run("round robin spreads requests across backends", func(t *testing.T) {
+		node1.Reset()
+		node2.Reset()
+		client := NewProxydClient("http://127.0.0.1:8545")
+		for i := 0; i < 10; i++ {
+			_, code, err := client.SendRPC("eth_chainId", nil)
+			require.NoError(t, err)
+			require.Equal(t, 200, code)
+		}
+		require.Equal(t, 5, len(node1.Requests()))
+		require.Equal(t, 5, len(node2.Requests()))
+	})
+
+	t.Run("sticky routing sends a client to the same backend", func(t *testing.T) {
+		for i := 0; i < 4; i++ {
+			node1.Reset()
+			node2.Reset()
+			h := make(http.Header)
+			h.Set("X-Forwarded-For", fmt.Sprintf("1.2.3.%d", i))
+			client := NewProxydClientWithHeaders("http://127.0.0.1:8545", h)
+			for j := 0; j < 5; j++ {
+				_, code, err := client.SendRPC("eth_blockNumber", nil)
+				require.NoError(t, err)
+				require.Equal(t, 200, code)
+			}
+			counts := []int{len(node1.Requests()), len(node2.Requests())}
+			require.Contains(t, [][]int{{5, 0}, {0, 5}}, counts)
+		}
+	})
+
+	t.Run("backends with weight 0 are only used as a fallback", func(t *testing.T) {
+		node1.Reset()
+		node2.Reset()
+		client := NewProxydClient("http://127.0.0.1:8545")
+		for i := 0; i < 10; i++ {
+			_, code, err := client.SendRPC("eth_gasPrice", nil)
+			require.NoError(t, err)
+			require.Equal(t, 200, code)
+		}
+		require.Equal(t, 10, len(node1.Requests()))
+		require.Equal(t, 0, len(node2.Requests()))
+	})
+}
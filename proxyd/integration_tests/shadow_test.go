@@ -0,0 +1,100 @@
+package integration_tests
+
+import (
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ethereum-optimism/optimism/proxyd"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShadowBackends(t *testing.T) {
+	primaryBackend := NewMockBackend(BatchedResponseHandler(200, goodResponse))
+	defer primaryBackend.Close()
+	shadowBackend := NewMockBackend(BatchedResponseHandler(200, `{"jsonrpc": "2.0", "result": "shadow", "id": 999}`))
+	defer shadowBackend.Close()
+
+	require.NoError(t, os.Setenv("PRIMARY_BACKEND_RPC_URL", primaryBackend.URL()))
+	require.NoError(t, os.Setenv("SHADOW_BACKEND_RPC_URL", shadowBackend.URL()))
+
+	config := ReadConfig("shadow")
+	client := NewProxydClient("http://127.0.0.1:8545")
+	shutdown, err := proxyd.Start(config)
+	require.NoError(t, err)
+	defer shutdown()
+
+	t.Run("client gets the primary response", func(t *testing.T) {
+		res, code, err := client.SendRPC("eth_chainId", nil)
+		require.NoError(t, err)
+		require.Equal(t, 200, code)
+		RequireEqualJSON(t, []byte(goodResponse), res)
+		require.Equal(t, 1, len(primaryBackend.Requests()))
+		require.Eventually(t, func() bool {
+			return len(shadowBackend.Requests()) == 1
+		}, time.Second, 10*time.Millisecond)
+		require.Equal(t, primaryBackend.Requests()[0].Body, shadowBackend.Requests()[0].Body)
+	})
+
+	t.Run("transactions are not shadowed", func(t *testing.T) {
+		hdlr := NewBatchRPCResponseRouter()
+		hdlr.SetFallbackRoute("eth_chainId", "0xa")
+		hdlr.SetFallbackRoute("eth_sendRawTransaction", "0x1")
+		primaryBackend.SetHandler(hdlr)
+		defer primaryBackend.SetHandler(BatchedResponseHandler(200, goodResponse))
+		shadowBackend.SetHandler(hdlr)
+		primaryBackend.Reset()
+		shadowBackend.Reset()
+		res, code, err := client.SendBatchRPC(
+			NewRPCReq("1", "eth_sendRawTransaction", []interface{}{"0x00"}),
+			NewRPCReq("2", "eth_chainId", nil),
+		)
+		require.NoError(t, err)
+		require.Equal(t, 200, code)
+		require.Equal(t, 1, len(primaryBackend.Requests()))
+		require.Contains(t, string(primaryBackend.Requests()[0].Body), "eth_sendRawTransaction")
+		require.NotEmpty(t, res)
+		require.Eventually(t, func() bool {
+			return len(shadowBackend.Requests()) == 1
+		}, time.Second, 10*time.Millisecond)
+		require.NotContains(t, string(shadowBackend.Requests()[0].Body), "eth_sendRawTransaction")
+
+		primaryBackend.Reset()
+		shadowBackend.Reset()
+		_, code, err = client.SendRPC("eth_sendRawTransaction", []interface{}{"0x00"})
+		require.NoError(t, err)
+		require.Equal(t, 200, code)
+		require.Equal(t, 1, len(primaryBackend.Requests()))
+		time.Sleep(100 * time.Millisecond)
+		require.Empty(t, shadowBackend.Requests())
+	})
+
+	t.Run("shadow backend failures don't affect the client", func(t *testing.T) {
+		primaryBackend.Reset()
+		shadowBackend.Reset()
+		shadowBackend.SetHandler(SingleResponseHandler(503, "unavailable"))
+
+		res, code, err := client.SendRPC("eth_chainId", nil)
+		require.NoError(t, err)
+		require.Equal(t, 200, code)
+		RequireEqualJSON(t, []byte(goodResponse), res)
+		require.Eventually(t, func() bool {
+			return len(shadowBackend.Requests()) == 1
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("slow shadow backends don't delay the client", func(t *testing.T) {
+		shadowBackend.SetHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(500 * time.Millisecond)
+			SingleResponseHandler(200, goodResponse)(w, r)
+		}))
+
+		start := time.Now()
+		res, code, err := client.SendRPC("eth_chainId", nil)
+		require.NoError(t, err)
+		require.Equal(t, 200, code)
+		RequireEqualJSON(t, []byte(goodResponse), res)
+		require.Less(t, time.Since(start), 400*time.Millisecond)
+	})
+}
@@ -0,0 +1,231 @@
+package integration_tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis"
+	"github.com/ethereum-optimism/optimism/proxyd"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+const tenantsFile = `
+[compute_units]
+eth_call = 10
+
+[tenants.acme]
+key = "acme_key"
+requests_per_second = 2
+allowed_methods = ["eth_chainId"]
+
+[tenants.globex]
+key = "globex_key"
+backend_group = "premium"
+monthly_compute_unit_quota = 21
+`
+
+const overQuotaResponse = `{"error":{"code":-32019,"message":"monthly compute unit quota exceeded"},"id":999,"jsonrpc":"2.0"}`
+
+func TestTenants(t *testing.T) {
+	redis, err := miniredis.Run()
+	require.NoError(t, err)
+	defer redis.Close()
+
+	hdlr := NewBatchRPCResponseRouter()
+	hdlr.SetFallbackRoute("eth_chainId", "hello")
+	hdlr.SetFallbackRoute("eth_call", "hello")
+
+	goodBackend := NewMockBackend(hdlr)
+	defer goodBackend.Close()
+	premiumBackend := NewMockBackend(hdlr)
+	defer premiumBackend.Close()
+
+	wsForwarded := make(chan []byte, 10)
+	wsBackend := NewMockWSBackend(nil, func(conn *websocket.Conn, msgType int, data []byte) {
+		wsForwarded <- data
+	}, nil)
+	defer wsBackend.Close()
+
+	tenantsPath := filepath.Join(t.TempDir(), "tenants.toml")
+	require.NoError(t, os.WriteFile(tenantsPath, []byte(tenantsFile), 0o600))
+
+	require.NoError(t, os.Setenv("GOOD_BACKEND_RPC_URL", goodBackend.URL()))
+	require.NoError(t, os.Setenv("PREMIUM_BACKEND_RPC_URL", premiumBackend.URL()))
+	require.NoError(t, os.Setenv("REDIS_URL", fmt.Sprintf("redis://127.0.0.1:%s", redis.Port())))
+	require.NoError(t, os.Setenv("WS_BACKEND_URL", wsBackend.URL()))
+	require.NoError(t, os.Setenv("TENANTS_FILE", tenantsPath))
+
+	config := ReadConfig("tenants")
+	shutdown, err := proxyd.Start(config)
+	require.NoError(t, err)
+	defer shutdown()
+
+	adminGet := func(path string) map[string]interface{} {
+		req, err := http.NewRequest("GET", "http://127.0.0.1:8547"+path, nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer admin_token")
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		require.Equal(t, 200, res.StatusCode)
+		body := make(map[string]interface{})
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&body))
+		return body
+	}
+
+	t.Run("unknown keys are rejected", func(t *testing.T) {
+		for _, url := range []string{"http://127.0.0.1:8545", "http://127.0.0.1:8545/unknown_key"} {
+			_, code, err := NewProxydClient(url).SendRPC("eth_chainId", nil)
+			require.NoError(t, err)
+			require.Equal(t, 401, code)
+		}
+	})
+
+	t.Run("static keys are not metered", func(t *testing.T) {
+		client := NewProxydClient("http://127.0.0.1:8545/static_key")
+		res, code, err := client.SendRPC("eth_call", nil)
+		require.NoError(t, err)
+		require.Equal(t, 200, code)
+		RequireEqualJSON(t, []byte(goodResponse), res)
+	})
+
+	t.Run("allowlist and rate limit", func(t *testing.T) {
+		goodBackend.Reset()
+		client := NewProxydClient("http://127.0.0.1:8545/acme_key")
+		res, code, err := client.SendRPC("eth_call", nil)
+		require.NoError(t, err)
+		require.Equal(t, 403, code)
+		var rpcRes proxyd.RPCRes
+		require.NoError(t, json.Unmarshal(res, &rpcRes))
+		require.Equal(t, proxyd.ErrMethodNotWhitelisted.Code, rpcRes.Error.Code)
+
+		res, code, err = client.SendBatchRPC(
+			NewRPCReq("1", "eth_chainId", nil),
+			NewRPCReq("2", "eth_chainId", nil),
+			NewRPCReq("3", "eth_chainId", nil),
+		)
+		require.NoError(t, err)
+		require.Equal(t, 200, code)
+		var batchRes []*proxyd.RPCRes
+		require.NoError(t, json.Unmarshal(res, &batchRes))
+		require.Len(t, batchRes, 3)
+		var limited int
+		for _, r := range batchRes {
+			if r.IsError() {
+				require.Equal(t, proxyd.ErrOverRateLimit.Code, r.Error.Code)
+				limited++
+			}
+		}
+		require.Equal(t, 1, limited)
+		require.Equal(t, 1, len(goodBackend.Requests()))
+	})
+
+	t.Run("backend group and quota", func(t *testing.T) {
+		goodBackend.Reset()
+		premiumBackend.Reset()
+		client := NewProxydClient("http://127.0.0.1:8545/globex_key")
+		for i := 0; i < 2; i++ {
+			res, code, err := client.SendRPC("eth_call", nil)
+			require.NoError(t, err)
+			require.Equal(t, 200, code)
+			RequireEqualJSON(t, []byte(goodResponse), res)
+		}
+		res, code, err := client.SendRPC("eth_call", nil)
+		require.NoError(t, err)
+		require.Equal(t, 429, code)
+		RequireEqualJSON(t, []byte(overQuotaResponse), res)
+
+		_, code, err = client.SendRPC("eth_chainId", nil)
+		require.NoError(t, err)
+		require.Equal(t, 200, code)
+		require.Equal(t, 0, len(goodBackend.Requests()))
+		require.Equal(t, 3, len(premiumBackend.Requests()))
+	})
+
+	t.Run("usage", func(t *testing.T) {
+		tenants := adminGet("/tenants/usage")["tenants"].(map[string]interface{})
+		require.Len(t, tenants, 2)
+
+		acme := tenants["acme"].(map[string]interface{})
+		require.EqualValues(t, 2, acme["requests"])
+		require.EqualValues(t, 2, acme["compute_units"])
+
+		globex := adminGet("/tenants/globex/usage")["usage"].(map[string]interface{})
+		require.EqualValues(t, 3, globex["requests"])
+		require.EqualValues(t, 21, globex["compute_units"])
+		methods := globex["methods"].(map[string]interface{})
+		require.EqualValues(t, 20, methods["eth_call"].(map[string]interface{})["compute_units"])
+		require.EqualValues(t, 1, methods["eth_chainId"].(map[string]interface{})["compute_units"])
+	})
+
+	t.Run("websocket", func(t *testing.T) {
+		wsCall := func(key string, method string) *proxyd.RPCRes {
+			resC := make(chan []byte, 1)
+			client, err := NewProxydWSClient("ws://127.0.0.1:8546/"+key, func(msgType int, data []byte) {
+				resC <- data
+			}, nil)
+			require.NoError(t, err)
+			defer client.HardClose()
+			require.NoError(t, client.WriteMessage(
+				websocket.TextMessage,
+				[]byte(fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"%s","params":[]}`, method)),
+			))
+			select {
+			case data := <-resC:
+				var res proxyd.RPCRes
+				require.NoError(t, json.Unmarshal(data, &res))
+				return &res
+			case <-time.After(5 * time.Second):
+				t.Fatalf("timed out waiting for the response to %s", method)
+				return nil
+			}
+		}
+
+		res := wsCall("acme_key", "eth_call")
+		require.True(t, res.IsError())
+		require.Equal(t, proxyd.ErrMethodNotWhitelisted.Code, res.Error.Code)
+
+		res = wsCall("globex_key", "eth_call")
+		require.True(t, res.IsError())
+		require.Equal(t, proxyd.ErrOverQuota.Code, res.Error.Code)
+
+		select {
+		case data := <-wsForwarded:
+			t.Fatalf("rejected request was forwarded: %s", data)
+		default:
+		}
+
+		globex := adminGet("/tenants/globex/usage")["usage"].(map[string]interface{})
+		require.EqualValues(t, 21, globex["compute_units"])
+	})
+
+	t.Run("reload", func(t *testing.T) {
+		client := NewProxydClient("http://127.0.0.1:8545/initech_key")
+		_, code, err := client.SendRPC("eth_chainId", nil)
+		require.NoError(t, err)
+		require.Equal(t, 401, code)
+
+		require.NoError(t, os.WriteFile(tenantsPath, []byte("[tenants.initech]\nkey = \"initech_key\""), 0o600))
+		req, err := http.NewRequest("POST", "http://127.0.0.1:8547/tenants/reload", nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer admin_token")
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		res.Body.Close()
+		require.Equal(t, 200, res.StatusCode)
+
+		_, code, err = client.SendRPC("eth_chainId", nil)
+		require.NoError(t, err)
+		require.Equal(t, 200, code)
+
+		_, code, err = NewProxydClient("http://127.0.0.1:8545/acme_key").SendRPC("eth_chainId", nil)
+		require.NoError(t, err)
+		require.Equal(t, 401, code)
+	})
+}
@@ -0,0 +1,250 @@
+package integration_tests
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum-optimism/optimism/proxyd"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+type collectedSpan struct {
+	TraceID      string `json:"traceId"`
+	SpanID       string `json:"spanId"`
+	ParentSpanID string `json:"parentSpanId"`
+	Name         string `json:"name"`
+}
+
+type mockCollector struct {
+	mtx     sync.Mutex
+	spans   []*collectedSpan
+	headers []http.Header
+}
+
+func (c *mockCollector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/v1/traces" {
+		w.WriteHeader(404)
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(500)
+		return
+	}
+	var req struct {
+		ResourceSpans []struct {
+			ScopeSpans []struct {
+				Spans []*collectedSpan `json:"spans"`
+			} `json:"scopeSpans"`
+		} `json:"resourceSpans"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		w.WriteHeader(400)
+		return
+	}
+	c.mtx.Lock()
+	c.headers = append(c.headers, r.Header.Clone())
+	for _, rs := range req.ResourceSpans {
+		for _, ss := range rs.ScopeSpans {
+			c.spans = append(c.spans, ss.Spans...)
+		}
+	}
+	c.mtx.Unlock()
+	_, _ = w.Write([]byte("{}"))
+}
+
+func (c *mockCollector) Spans() []*collectedSpan {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	out := make([]*collectedSpan, len(c.spans))
+	copy(out, c.spans)
+	return out
+}
+
+func (c *mockCollector) Header(i int) http.Header {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.headers[i]
+}
+
+func readAccessLog(t *testing.T, path string) []*proxyd.AccessLogRecord {
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	var records []*proxyd.AccessLogRecord
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var record proxyd.AccessLogRecord
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+		records = append(records, &record)
+	}
+	require.NoError(t, scanner.Err())
+	return records
+}
+
+func TestTracing(t *testing.T) {
+	hdlr := NewBatchRPCResponseRouter()
+	hdlr.SetFallbackRoute("eth_chainId", "0xa")
+	hdlr.SetFallbackRoute("eth_foobar", "0x1")
+	goodBackend := NewMockBackend(hdlr)
+	defer goodBackend.Close()
+	badBackend := NewMockBackend(SingleResponseHandler(503, "unavailable"))
+	defer badBackend.Close()
+
+	collector := &mockCollector{}
+	collectorServer := httptest.NewServer(collector)
+	defer collectorServer.Close()
+
+	wsBackend := NewMockWSBackend(nil, func(conn *websocket.Conn, msgType int, data []byte) {
+		_ = conn.WriteMessage(websocket.TextMessage, []byte(`{"jsonrpc":"2.0","id":1,"result":"0xa"}`))
+	}, nil)
+	defer wsBackend.Close()
+
+	accessLogFile := filepath.Join(t.TempDir(), "access.log")
+	require.NoError(t, os.Setenv("GOOD_BACKEND_RPC_URL", goodBackend.URL()))
+	require.NoError(t, os.Setenv("BAD_BACKEND_RPC_URL", badBackend.URL()))
+	require.NoError(t, os.Setenv("WS_BACKEND_URL", wsBackend.URL()))
+	require.NoError(t, os.Setenv("ACCESS_LOG_FILE", accessLogFile))
+	require.NoError(t, os.Setenv("OTLP_ENDPOINT", collectorServer.URL))
+	require.NoError(t, os.Setenv("OTLP_API_KEY", "secret"))
+
+	config := ReadConfig("tracing")
+	traceID := "4bf92f3577b34da6a3ce929d0e0e4736"
+	parentID := "00f067aa0ba902b7"
+	client := NewProxydClientWithHeaders("http://127.0.0.1:8545", http.Header{
+		"traceparent": []string{"00-" + traceID + "-" + parentID + "-01"},
+	})
+	shutdown, err := proxyd.Start(config)
+	require.NoError(t, err)
+	defer shutdown()
+
+	_, code, err := client.SendBatchRPC(
+		NewRPCReq("1", "eth_chainId", nil),
+		NewRPCReq("2", "eth_foobar", nil),
+		NewRPCReq("3", "eth_foobar", nil),
+		NewRPCReq("4", "eth_unknown", nil),
+	)
+	require.NoError(t, err)
+	require.Equal(t, 200, code)
+
+	// The access log record is written once the response has been sent.
+	var records []*proxyd.AccessLogRecord
+	require.Eventually(t, func() bool {
+		records = readAccessLog(t, accessLogFile)
+		return len(records) == 1
+	}, time.Second, 10*time.Millisecond)
+	record := records[0]
+	require.Equal(t, traceID, record.TraceID)
+	require.NotEmpty(t, record.ReqID)
+	require.Equal(t, "127.0.0.1", record.RemoteIP)
+	require.True(t, record.Batch)
+	require.Equal(t, 200, record.Status)
+	require.False(t, record.RateLimited)
+	require.Contains(t, record.Steps, proxyd.SpanReadBody)
+	require.Contains(t, record.Steps, proxyd.SpanForward)
+	require.Contains(t, record.Steps, proxyd.SpanWriteResponse)
+
+	require.Len(t, record.Calls, 4)
+	for i, call := range record.Calls[:2] {
+		require.Equal(t, i, call.Index)
+		require.Equal(t, "main", call.BackendGroup)
+		require.Equal(t, "good", call.Backend)
+		require.Equal(t, 1, call.Retries)
+		require.False(t, call.CacheHit)
+		require.Zero(t, call.ErrorCode)
+	}
+	require.Equal(t, "eth_foobar", record.Calls[2].Method)
+	require.True(t, record.Calls[2].RateLimited)
+	require.Equal(t, proxyd.ErrOverRateLimit.Code, record.Calls[2].ErrorCode)
+	require.Empty(t, record.Calls[2].Backend)
+	require.Equal(t, "eth_unknown", record.Calls[3].Method)
+	require.Equal(t, proxyd.ErrMethodNotWhitelisted.Code, record.Calls[3].ErrorCode)
+
+	// The backends are sent the trace context of their request.
+	for _, backend := range []*MockBackend{badBackend, goodBackend} {
+		reqs := backend.Requests()
+		require.Len(t, reqs, 1)
+		require.True(t, strings.HasPrefix(reqs[0].Headers.Get("traceparent"), "00-"+traceID+"-"))
+	}
+
+	var spans []*collectedSpan
+	require.Eventually(t, func() bool {
+		spans = collector.Spans()
+		return len(spans) > 0
+	}, 2*time.Second, 10*time.Millisecond)
+	spansByName := make(map[string][]*collectedSpan)
+	for _, span := range spans {
+		require.Equal(t, traceID, span.TraceID)
+		spansByName[span.Name] = append(spansByName[span.Name], span)
+	}
+	require.Len(t, spansByName[proxyd.SpanRequest], 1)
+	root := spansByName[proxyd.SpanRequest][0]
+	require.Equal(t, parentID, root.ParentSpanID)
+	require.Len(t, spansByName[proxyd.SpanCall], 4)
+	for _, span := range spansByName[proxyd.SpanCall] {
+		require.Equal(t, root.SpanID, span.ParentSpanID)
+	}
+	require.Len(t, spansByName[proxyd.SpanForward], 1)
+	require.Len(t, spansByName[proxyd.SpanBackendRequest], 2)
+	for _, span := range spansByName[proxyd.SpanBackendRequest] {
+		require.Equal(t, spansByName[proxyd.SpanForward][0].SpanID, span.ParentSpanID)
+	}
+	require.Equal(t, "secret", collector.Header(0).Get("x-api-key"))
+
+	// Each websocket message is traced as a request with a single call.
+	resC := make(chan []byte, 2)
+	wsClient, err := NewProxydWSClient("ws://127.0.0.1:8546", func(msgType int, data []byte) {
+		resC <- data
+	}, nil)
+	require.NoError(t, err)
+	defer wsClient.HardClose()
+	for _, method := range []string{"eth_chainId", "eth_unknown"} {
+		require.NoError(t, wsClient.WriteMessage(
+			websocket.TextMessage,
+			[]byte(`{"jsonrpc":"2.0","id":1,"method":"`+method+`","params":[]}`),
+		))
+		select {
+		case <-resC:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for the response to %s", method)
+		}
+	}
+	require.Eventually(t, func() bool {
+		records = readAccessLog(t, accessLogFile)
+		return len(records) == 3
+	}, time.Second, 10*time.Millisecond)
+	require.False(t, records[0].Websocket)
+	for _, record := range records[1:] {
+		require.True(t, record.Websocket)
+		require.NotEqual(t, traceID, record.TraceID)
+		require.NotEmpty(t, record.ReqID)
+		require.Equal(t, "127.0.0.1", record.RemoteIP)
+		require.Len(t, record.Calls, 1)
+	}
+	require.Equal(t, records[1].ReqID, records[2].ReqID)
+	require.Equal(t, "eth_chainId", records[1].Calls[0].Method)
+	require.Equal(t, "ws", records[1].Calls[0].Backend)
+	require.Zero(t, records[1].Calls[0].ErrorCode)
+	require.Equal(t, "eth_unknown", records[2].Calls[0].Method)
+	require.Equal(t, proxyd.ErrMethodNotWhitelisted.Code, records[2].Calls[0].ErrorCode)
+
+	require.Eventually(t, func() bool {
+		var wsSpans int
+		for _, span := range collector.Spans() {
+			if span.Name == proxyd.SpanWSRequest {
+				wsSpans++
+			}
+		}
+		return wsSpans == 2
+	}, 2*time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,206 @@
+package integration_tests
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ethereum-optimism/optimism/proxyd"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+const txHashResult = "0x2e2ba3f3e5b3b1fd0ba1ad6cd5d7a0f29b4e6e2b54ab1c5d2a6b0b8ac9b0d6b5"
+
+func signTestTx(t *testing.T, key *ecdsa.PrivateKey, chainID int64, nonce uint64) string {
+	tx, err := types.SignNewTx(key, types.LatestSignerForChainID(big.NewInt(chainID)), &types.DynamicFeeTx{
+		ChainID:   big.NewInt(chainID),
+		Nonce:     nonce,
+		Gas:       21000,
+		GasFeeCap: big.NewInt(100),
+		GasTipCap: big.NewInt(1),
+	})
+	require.NoError(t, err)
+	data, err := tx.MarshalBinary()
+	require.NoError(t, err)
+	return hexutil.Encode(data)
+}
+
+func countSentTxs(backend *MockBackend) int {
+	var count int
+	for _, req := range backend.Requests() {
+		if bytes.Contains(req.Body, []byte("eth_sendRawTransaction")) {
+			count++
+		}
+	}
+	return count
+}
+
+func TestTxPolicy(t *testing.T) {
+	hdlr := NewBatchRPCResponseRouter()
+	hdlr.SetFallbackRoute("eth_getTransactionCount", "0x2")
+	hdlr.SetFallbackRoute("eth_sendRawTransaction", txHashResult)
+
+	sequencer := NewMockBackend(hdlr)
+	defer sequencer.Close()
+	replica := NewMockBackend(hdlr)
+	defer replica.Close()
+
+	require.NoError(t, os.Setenv("SEQUENCER_RPC_URL", sequencer.URL()))
+	require.NoError(t, os.Setenv("REPLICA_RPC_URL", replica.URL()))
+
+	wsForwarded := make(chan []byte, 10)
+	wsBackend := NewMockWSBackend(nil, func(conn *websocket.Conn, msgType int, data []byte) {
+		wsForwarded <- data
+	}, nil)
+	defer wsBackend.Close()
+	require.NoError(t, os.Setenv("WS_BACKEND_URL", wsBackend.URL()))
+
+	config := ReadConfig("tx_policy")
+	client := NewProxydClient("http://127.0.0.1:8545")
+	shutdown, err := proxyd.Start(config)
+	require.NoError(t, err)
+	defer shutdown()
+
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	sendTx := func(rawTx string) (*proxyd.RPCRes, int) {
+		res, code, err := client.SendRequest(makeSendRawTransaction(rawTx))
+		require.NoError(t, err)
+		var rpcRes proxyd.RPCRes
+		require.NoError(t, json.Unmarshal(res, &rpcRes))
+		return &rpcRes, code
+	}
+
+	t.Run("valid transactions are broadcast", func(t *testing.T) {
+		sequencer.Reset()
+		replica.Reset()
+		res, code := sendTx(signTestTx(t, key, 10, 7))
+		require.Equal(t, 200, code)
+		require.Nil(t, res.Error)
+		require.Equal(t, txHashResult, res.Result)
+
+		require.Eventually(t, func() bool {
+			return countSentTxs(sequencer) == 1 && countSentTxs(replica) == 1
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("invalid transactions are rejected", func(t *testing.T) {
+		sequencer.Reset()
+		replica.Reset()
+		res, code := sendTx(signTestTx(t, key, 1, 2))
+		require.Equal(t, 400, code)
+		require.Equal(t, proxyd.ErrTxInvalidChainID.Code, res.Error.Code)
+
+		// The pending nonce is 2, so the nonce gap is 6.
+		res, code = sendTx(signTestTx(t, key, 10, 8))
+		require.Equal(t, 400, code)
+		require.Equal(t, proxyd.ErrTxNonceGapTooLarge.Code, res.Error.Code)
+
+		require.Equal(t, 0, countSentTxs(sequencer))
+		require.Equal(t, 0, countSentTxs(replica))
+	})
+
+	t.Run("first success is returned", func(t *testing.T) {
+		sequencer.SetHandler(SingleResponseHandler(200, `{"jsonrpc":"2.0","error":{"code":-32000,"message":"nonce too low"},"id":1}`))
+		defer sequencer.SetHandler(hdlr)
+
+		res, code := sendTx(signTestTx(t, key, 10, 2))
+		require.Equal(t, 200, code)
+		require.Nil(t, res.Error)
+		require.Equal(t, txHashResult, res.Result)
+
+		replica.SetHandler(SingleResponseHandler(200, `{"jsonrpc":"2.0","error":{"code":-32000,"message":"already known"},"id":1}`))
+		defer replica.SetHandler(hdlr)
+		res, _ = sendTx(signTestTx(t, key, 10, 2))
+		require.Equal(t, "nonce too low", res.Error.Message)
+	})
+
+	t.Run("batched transactions are broadcast concurrently", func(t *testing.T) {
+		slow := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, err := io.ReadAll(r.Body)
+			require.NoError(t, err)
+			if bytes.Contains(body, []byte("eth_sendRawTransaction")) {
+				time.Sleep(400 * time.Millisecond)
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			hdlr.ServeHTTP(w, r)
+		})
+		sequencer.SetHandler(slow)
+		defer sequencer.SetHandler(hdlr)
+		replica.SetHandler(slow)
+		defer replica.SetHandler(hdlr)
+
+		start := time.Now()
+		res, code, err := client.SendBatchRPC(
+			NewRPCReq("1", "eth_sendRawTransaction", []interface{}{signTestTx(t, key, 10, 2)}),
+			NewRPCReq("2", "eth_sendRawTransaction", []interface{}{signTestTx(t, key, 10, 3)}),
+			NewRPCReq("3", "eth_sendRawTransaction", []interface{}{signTestTx(t, key, 10, 4)}),
+		)
+		require.NoError(t, err)
+		require.Equal(t, 200, code)
+		require.Less(t, time.Since(start), time.Second)
+
+		var batchRes []*proxyd.RPCRes
+		require.NoError(t, json.Unmarshal(res, &batchRes))
+		require.Len(t, batchRes, 3)
+		for i, r := range batchRes {
+			require.Nil(t, r.Error)
+			require.Equal(t, fmt.Sprintf("%d", i+1), string(r.ID))
+			require.Equal(t, txHashResult, r.Result)
+		}
+	})
+
+	t.Run("websocket transactions are checked", func(t *testing.T) {
+		sequencer.Reset()
+		replica.Reset()
+		resC := make(chan []byte, 1)
+		wsClient, err := NewProxydWSClient("ws://127.0.0.1:8546", func(msgType int, data []byte) {
+			resC <- data
+		}, nil)
+		require.NoError(t, err)
+		defer wsClient.HardClose()
+
+		wsSendTx := func(rawTx string) *proxyd.RPCRes {
+			require.NoError(t, wsClient.WriteMessage(websocket.TextMessage, makeSendRawTransaction(rawTx)))
+			select {
+			case data := <-resC:
+				var res proxyd.RPCRes
+				require.NoError(t, json.Unmarshal(data, &res))
+				return &res
+			case <-time.After(5 * time.Second):
+				t.Fatalf("timed out waiting for the response")
+				return nil
+			}
+		}
+
+		res := wsSendTx(signTestTx(t, key, 1, 2))
+		require.Equal(t, proxyd.ErrTxInvalidChainID.Code, res.Error.Code)
+		res = wsSendTx(signTestTx(t, key, 10, 8))
+		require.Equal(t, proxyd.ErrTxNonceGapTooLarge.Code, res.Error.Code)
+
+		res = wsSendTx(signTestTx(t, key, 10, 2))
+		require.Nil(t, res.Error)
+		require.Equal(t, txHashResult, res.Result)
+		require.Eventually(t, func() bool {
+			return countSentTxs(sequencer) == 1 && countSentTxs(replica) == 1
+		}, time.Second, 10*time.Millisecond)
+
+		select {
+		case data := <-wsForwarded:
+			t.Fatalf("transaction was forwarded to the websocket backend: %s", data)
+		default:
+		}
+	})
+}
@@ -0,0 +1,332 @@
+package integration_tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum-optimism/optimism/proxyd"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+// subscriptionNode is a mock websocket node serving subscriptions.
+type subscriptionNode struct {
+	mtx          sync.Mutex
+	subConn      *websocket.Conn
+	subs         map[string]string
+	nextID       int
+	subscribes   int
+	unsubscribes int
+	// The eth_subscribe requests with heldParams are answered once
+	// release is closed.
+	heldParams string
+	release    chan struct{}
+	held       int
+}
+
+func newSubscriptionNode() *subscriptionNode {
+	return &subscriptionNode{
+		subs: make(map[string]string),
+	}
+}
+
+func (n *subscriptionNode) OnMessage(conn *websocket.Conn, msgType int, data []byte) {
+	req, err := proxyd.ParseRPCReq(data)
+	if err != nil {
+		return
+	}
+
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	var res *proxyd.RPCRes
+	switch req.Method {
+	case "eth_subscribe":
+		if n.release != nil && string(req.Params) == n.heldParams {
+			release := n.release
+			n.held++
+			go func() {
+				<-release
+				n.mtx.Lock()
+				defer n.mtx.Unlock()
+				n.subscribe(conn, req)
+			}()
+			return
+		}
+		n.subscribe(conn, req)
+		return
+	case "eth_unsubscribe":
+		var ids []string
+		if err := json.Unmarshal(req.Params, &ids); err != nil {
+			panic(err)
+		}
+		delete(n.subs, ids[0])
+		n.unsubscribes++
+		res = proxyd.NewRPCRes(req.ID, true)
+	default:
+		res = proxyd.NewRPCRes(req.ID, "0x420")
+	}
+	out, err := json.Marshal(res)
+	if err != nil {
+		panic(err)
+	}
+	_ = conn.WriteMessage(websocket.TextMessage, out)
+}
+
+// subscribe answers an eth_subscribe request. The caller must hold n.mtx.
+func (n *subscriptionNode) subscribe(conn *websocket.Conn, req *proxyd.RPCReq) {
+	n.nextID++
+	id := fmt.Sprintf("0x%x", n.nextID)
+	n.subs[id] = string(req.Params)
+	n.subConn = conn
+	n.subscribes++
+	out, err := json.Marshal(proxyd.NewRPCRes(req.ID, id))
+	if err != nil {
+		panic(err)
+	}
+	_ = conn.WriteMessage(websocket.TextMessage, out)
+}
+
+// Hold delays the responses to the eth_subscribe requests with the params
+// until the returned function is called.
+func (n *subscriptionNode) Hold(params string) func() {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	release := make(chan struct{})
+	n.heldParams = params
+	n.release = release
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			n.mtx.Lock()
+			n.release = nil
+			n.mtx.Unlock()
+			close(release)
+		})
+	}
+}
+
+func (n *subscriptionNode) Held() int {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	return n.held
+}
+
+// Notify sends a notification to the subscriptions with the params.
+func (n *subscriptionNode) Notify(params string, result string) int {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	var notified int
+	for id, subParams := range n.subs {
+		if subParams != params {
+			continue
+		}
+		msg := fmt.Sprintf(`{"jsonrpc":"2.0","method":"eth_subscription","params":{"subscription":"%s","result":%s}}`, id, result)
+		_ = n.subConn.WriteMessage(websocket.TextMessage, []byte(msg))
+		notified++
+	}
+	return notified
+}
+
+func (n *subscriptionNode) Disconnect() {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	n.subConn.Close()
+	n.subs = make(map[string]string)
+}
+
+func (n *subscriptionNode) Counts() (int, int, int) {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	return len(n.subs), n.subscribes, n.unsubscribes
+}
+
+type subscriptionClient struct {
+	*ProxydWSClient
+	msgs chan []byte
+}
+
+func newSubscriptionClient(t *testing.T) *subscriptionClient {
+	msgs := make(chan []byte, 16)
+	client, err := NewProxydWSClient("ws://127.0.0.1:8546", func(msgType int, data []byte) {
+		msgs <- data
+	}, nil)
+	require.NoError(t, err)
+	return &subscriptionClient{
+		ProxydWSClient: client,
+		msgs:           msgs,
+	}
+}
+
+func (c *subscriptionClient) Request(t *testing.T, method string, params string) *proxyd.RPCRes {
+	req := fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"%s","params":%s}`, method, params)
+	require.NoError(t, c.WriteMessage(websocket.TextMessage, []byte(req)))
+	var res proxyd.RPCRes
+	require.NoError(t, json.Unmarshal(c.Receive(t), &res))
+	return &res
+}
+
+func (c *subscriptionClient) Subscribe(t *testing.T, params string) string {
+	res := c.Request(t, "eth_subscribe", params)
+	require.Nil(t, res.Error)
+	return res.Result.(string)
+}
+
+func (c *subscriptionClient) Receive(t *testing.T) []byte {
+	select {
+	case msg := <-c.msgs:
+		return msg
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for message")
+		return nil
+	}
+}
+
+func (c *subscriptionClient) RequireNotification(t *testing.T, id string, result string) {
+	var notification struct {
+		Method string `json:"method"`
+		Params struct {
+			Subscription string          `json:"subscription"`
+			Result       json.RawMessage `json:"result"`
+		} `json:"params"`
+	}
+	require.NoError(t, json.Unmarshal(c.Receive(t), &notification))
+	require.Equal(t, "eth_subscription", notification.Method)
+	require.Equal(t, id, notification.Params.Subscription)
+	require.JSONEq(t, result, string(notification.Params.Result))
+}
+
+func TestWSSubscriptionMultiplexing(t *testing.T) {
+	node := newSubscriptionNode()
+	backend := NewMockWSBackend(nil, node.OnMessage, nil)
+	defer backend.Close()
+
+	require.NoError(t, os.Setenv("GOOD_BACKEND_RPC_URL", backend.URL()))
+
+	config := ReadConfig("ws_subscriptions")
+	shutdown, err := proxyd.Start(config)
+	require.NoError(t, err)
+	defer shutdown()
+
+	// More clients than the backend accepts connections.
+	clients := make([]*subscriptionClient, 3)
+	ids := make([]string, 3)
+	for i := range clients {
+		clients[i] = newSubscriptionClient(t)
+		defer clients[i].HardClose()
+		ids[i] = clients[i].Subscribe(t, `["newHeads"]`)
+	}
+	require.NotEqual(t, ids[0], ids[1])
+	active, subscribes, _ := node.Counts()
+	require.Equal(t, 1, active)
+	require.Equal(t, 1, subscribes)
+
+	t.Run("notifications fan out", func(t *testing.T) {
+		require.Equal(t, 1, node.Notify(`["newHeads"]`, `{"number":"0x1"}`))
+		for i, client := range clients {
+			client.RequireNotification(t, ids[i], `{"number":"0x1"}`)
+		}
+	})
+
+	t.Run("equivalent logs filters share a subscription", func(t *testing.T) {
+		id1 := clients[0].Subscribe(t, `["logs",{"address":"0xABC","topics":["0x01"]}]`)
+		id2 := clients[1].Subscribe(t, `["logs",{"topics":["0x01"],"address":"0xabc"}]`)
+		active, subscribes, _ := node.Counts()
+		require.Equal(t, 2, active)
+		require.Equal(t, 2, subscribes)
+
+		require.Equal(t, 1, node.Notify(`["logs",{"address":"0xABC","topics":["0x01"]}]`, `{"logIndex":"0x0"}`))
+		clients[0].RequireNotification(t, id1, `{"logIndex":"0x0"}`)
+		clients[1].RequireNotification(t, id2, `{"logIndex":"0x0"}`)
+
+		res := clients[0].Request(t, "eth_unsubscribe", fmt.Sprintf(`["%s"]`, id1))
+		require.Equal(t, true, res.Result)
+		_, _, unsubscribes := node.Counts()
+		require.Equal(t, 0, unsubscribes)
+
+		res = clients[1].Request(t, "eth_unsubscribe", fmt.Sprintf(`["%s"]`, id2))
+		require.Equal(t, true, res.Result)
+		active, _, unsubscribes = node.Counts()
+		require.Equal(t, 1, active)
+		require.Equal(t, 1, unsubscribes)
+	})
+
+	t.Run("other requests are forwarded", func(t *testing.T) {
+		res := clients[2].Request(t, "eth_chainId", `[]`)
+		require.Nil(t, res.Error)
+		require.Equal(t, "0x420", res.Result)
+	})
+
+	t.Run("backend reconnects and resubscribes", func(t *testing.T) {
+		node.Disconnect()
+		require.Eventually(t, func() bool {
+			active, _, _ := node.Counts()
+			return active == 1
+		}, 10*time.Second, 10*time.Millisecond)
+		_, subscribes, _ := node.Counts()
+		require.Equal(t, 3, subscribes)
+
+		require.Equal(t, 1, node.Notify(`["newHeads"]`, `{"number":"0x2"}`))
+		for i, client := range clients {
+			client.RequireNotification(t, ids[i], `{"number":"0x2"}`)
+		}
+	})
+
+	t.Run("disconnected clients are unsubscribed", func(t *testing.T) {
+		for _, client := range clients {
+			client.HardClose()
+		}
+		require.Eventually(t, func() bool {
+			active, _, _ := node.Counts()
+			return active == 0
+		}, 5*time.Second, 10*time.Millisecond)
+	})
+}
+
+func TestWSSubscriptionSlowUpstream(t *testing.T) {
+	node := newSubscriptionNode()
+	backend := NewMockWSBackend(nil, node.OnMessage, nil)
+	defer backend.Close()
+
+	require.NoError(t, os.Setenv("GOOD_BACKEND_RPC_URL", backend.URL()))
+
+	config := ReadConfig("ws_subscriptions")
+	shutdown, err := proxyd.Start(config)
+	require.NoError(t, err)
+	defer shutdown()
+
+	slowClient := newSubscriptionClient(t)
+	defer slowClient.HardClose()
+	client := newSubscriptionClient(t)
+	defer client.HardClose()
+
+	release := node.Hold(`["logs"]`)
+	defer release()
+	require.NoError(t, slowClient.WriteMessage(
+		websocket.TextMessage,
+		[]byte(`{"jsonrpc":"2.0","id":1,"method":"eth_subscribe","params":["logs"]}`),
+	))
+	require.Eventually(t, func() bool {
+		return node.Held() == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// Other subscriptions aren't blocked by the pending one.
+	id := client.Subscribe(t, `["newHeads"]`)
+	require.Equal(t, 1, node.Notify(`["newHeads"]`, `{"number":"0x1"}`))
+	client.RequireNotification(t, id, `{"number":"0x1"}`)
+
+	// Neither is a client that disconnects with a pending subscription.
+	slowClient.HardClose()
+	res := client.Request(t, "eth_unsubscribe", fmt.Sprintf(`["%s"]`, id))
+	require.Equal(t, true, res.Result)
+
+	// The upstream subscription answered after the client left is cancelled.
+	release()
+	require.Eventually(t, func() bool {
+		active, subscribes, unsubscribes := node.Counts()
+		return active == 0 && subscribes == 2 && unsubscribes == 2
+	}, 5*time.Second, 10*time.Millisecond)
+}
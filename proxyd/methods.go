@@ -5,8 +5,11 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 	"sync"
+	"time"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 )
 
@@ -270,6 +273,179 @@ func (e *EthGasPriceMethodHandler) PutRPCMethod(context.Context, *RPCReq, *RPCRe
 	return nil
 }
 
+// cachePolicy configures how long the responses of a method are cached. Null results, e.g. for
+// transactions or blocks the backend doesn't know about yet, are only cached if nullResultTTL is set.
+type cachePolicy struct {
+	ttl           time.Duration
+	nullResultTTL time.Duration
+}
+
+func (p cachePolicy) put(ctx context.Context, cache Cache, key string, res *RPCRes) error {
+	if key == "" {
+		return nil
+	}
+	if res.Result == nil {
+		if p.nullResultTTL == 0 {
+			return nil
+		}
+		return cache.PutWithTTL(ctx, key, "null", p.nullResultTTL)
+	}
+	val := string(mustMarshalJSON(res.Result))
+	if p.ttl == 0 {
+		return cache.Put(ctx, key, val)
+	}
+	return cache.PutWithTTL(ctx, key, val, p.ttl)
+}
+
+type EthGetBlockByHashMethodHandler struct {
+	cache  Cache
+	policy cachePolicy
+}
+
+func (e *EthGetBlockByHashMethodHandler) cacheKey(req *RPCReq) string {
+	hash, includeTx, err := decodeGetBlockByHashParams(req.Params)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("method:eth_getBlockByHash:%s:%t", hash, includeTx)
+}
+
+func (e *EthGetBlockByHashMethodHandler) GetRPCMethod(ctx context.Context, req *RPCReq) (*RPCRes, error) {
+	key := e.cacheKey(req)
+	if key == "" {
+		return nil, nil
+	}
+	return getImmutableRPCResponse(ctx, e.cache, key, req)
+}
+
+func (e *EthGetBlockByHashMethodHandler) PutRPCMethod(ctx context.Context, req *RPCReq, res *RPCRes) error {
+	// The block of a hash never changes, even when it is reorged out
+	return e.policy.put(ctx, e.cache, e.cacheKey(req), res)
+}
+
+// EthGetTransactionMethodHandler caches the responses of eth_getTransactionByHash and
+// eth_getTransactionReceipt. Transactions in blocks without numBlockConfirmations confirmations
+// are only cached if reorgs are tracked, which invalidates them if their block is reorged out.
+type EthGetTransactionMethodHandler struct {
+	method                string
+	cache                 Cache
+	policy                cachePolicy
+	getLatestBlockNumFn   GetLatestBlockNumFn
+	numBlockConfirmations int
+	reorgs                *ReorgTracker
+}
+
+type transactionBlock struct {
+	BlockNumber *hexutil.Uint64 `json:"blockNumber"`
+	BlockHash   *common.Hash    `json:"blockHash"`
+}
+
+func (e *EthGetTransactionMethodHandler) cacheKey(req *RPCReq) string {
+	hash, err := decodeHashParams(req.Params)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("method:%s:%s", e.method, hash)
+}
+
+func (e *EthGetTransactionMethodHandler) GetRPCMethod(ctx context.Context, req *RPCReq) (*RPCRes, error) {
+	key := e.cacheKey(req)
+	if key == "" {
+		return nil, nil
+	}
+	return getImmutableRPCResponse(ctx, e.cache, key, req)
+}
+
+func (e *EthGetTransactionMethodHandler) PutRPCMethod(ctx context.Context, req *RPCReq, res *RPCRes) error {
+	key := e.cacheKey(req)
+	if key == "" {
+		return nil
+	}
+	if res.Result == nil {
+		return e.policy.put(ctx, e.cache, key, res)
+	}
+
+	var block transactionBlock
+	if err := json.Unmarshal(mustMarshalJSON(res.Result), &block); err != nil {
+		return err
+	}
+	// Pending transactions have no block yet
+	if block.BlockNumber == nil || block.BlockHash == nil {
+		return nil
+	}
+
+	curBlock, err := e.getLatestBlockNumFn(ctx)
+	if err != nil {
+		return err
+	}
+	blockNum := uint64(*block.BlockNumber)
+	if curBlock > blockNum+uint64(e.numBlockConfirmations) {
+		return e.policy.put(ctx, e.cache, key, res)
+	}
+	if e.reorgs == nil {
+		return nil
+	}
+
+	if err := e.policy.put(ctx, e.cache, key, res); err != nil {
+		return err
+	}
+	if !e.reorgs.Track(blockNum, *block.BlockHash, key) {
+		return e.cache.Delete(ctx, key)
+	}
+	return nil
+}
+
+// EthBlockHashStateMethodHandler caches the responses of state methods like eth_getCode and
+// eth_getStorageAt that are made at a block hash (EIP-1898), since they never change.
+type EthBlockHashStateMethodHandler struct {
+	method   string
+	blockPos int
+	cache    Cache
+	policy   cachePolicy
+}
+
+type blockHashParam struct {
+	BlockHash        *common.Hash `json:"blockHash"`
+	RequireCanonical bool         `json:"requireCanonical"`
+}
+
+func (e *EthBlockHashStateMethodHandler) cacheKey(req *RPCReq) string {
+	var params []json.RawMessage
+	if err := json.Unmarshal(req.Params, &params); err != nil || len(params) != e.blockPos+1 {
+		return ""
+	}
+	var block blockHashParam
+	if err := json.Unmarshal(params[e.blockPos], &block); err != nil || block.BlockHash == nil {
+		return ""
+	}
+	// Whether the block is still canonical can change
+	if block.RequireCanonical {
+		return ""
+	}
+
+	keyParams := make([]string, 0, e.blockPos)
+	for _, param := range params[:e.blockPos] {
+		var value string
+		if err := json.Unmarshal(param, &value); err != nil {
+			return ""
+		}
+		keyParams = append(keyParams, strings.ToLower(value))
+	}
+	return fmt.Sprintf("method:%s:%s:%s", e.method, strings.Join(keyParams, ":"), block.BlockHash.Hex())
+}
+
+func (e *EthBlockHashStateMethodHandler) GetRPCMethod(ctx context.Context, req *RPCReq) (*RPCRes, error) {
+	key := e.cacheKey(req)
+	if key == "" {
+		return nil, nil
+	}
+	return getImmutableRPCResponse(ctx, e.cache, key, req)
+}
+
+func (e *EthBlockHashStateMethodHandler) PutRPCMethod(ctx context.Context, req *RPCReq, res *RPCRes) error {
+	return e.policy.put(ctx, e.cache, e.cacheKey(req), res)
+}
+
 func isBlockDependentParam(s string) bool {
 	return s == "latest" || s == "pending"
 }
@@ -322,6 +498,45 @@ func decodeGetBlockRangeParams(params json.RawMessage) (string, string, bool, er
 	return startBlockNum, endBlockNum, includeTx, nil
 }
 
+func decodeGetBlockByHashParams(params json.RawMessage) (string, bool, error) {
+	var list []interface{}
+	if err := json.Unmarshal(params, &list); err != nil {
+		return "", false, err
+	}
+	if len(list) != 2 {
+		return "", false, errInvalidRPCParams
+	}
+	hash, ok := list[0].(string)
+	if !ok {
+		return "", false, errInvalidRPCParams
+	}
+	includeTx, ok := list[1].(bool)
+	if !ok {
+		return "", false, errInvalidRPCParams
+	}
+	decoded, err := decodeHash(hash)
+	return decoded, includeTx, err
+}
+
+func decodeHashParams(params json.RawMessage) (string, error) {
+	var list []string
+	if err := json.Unmarshal(params, &list); err != nil {
+		return "", err
+	}
+	if len(list) != 1 {
+		return "", errInvalidRPCParams
+	}
+	return decodeHash(list[0])
+}
+
+func decodeHash(input string) (string, error) {
+	b, err := hexutil.Decode(input)
+	if err != nil || len(b) != common.HashLength {
+		return "", errInvalidRPCParams
+	}
+	return common.BytesToHash(b).Hex(), nil
+}
+
 func decodeBlockInput(input string) (uint64, error) {
 	return hexutil.DecodeUint64(input)
 }
@@ -391,7 +606,7 @@ func getImmutableRPCResponse(ctx context.Context, cache Cache, key string, req *
 }
 
 func putImmutableRPCResponse(ctx context.Context, cache Cache, key string, req *RPCReq, res *RPCRes) error {
-	if key == "" {
+	if key == "" || res.Result == nil {
 		return nil
 	}
 	val := mustMarshalJSON(res.Result)
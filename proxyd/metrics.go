@@ -5,6 +5,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 )
@@ -242,6 +243,178 @@ var (
 		Name:      "rate_limit_take_errors",
 		Help:      "Count of errors taking frontend rate limits",
 	})
+
+	consensusLatestBlock = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: MetricsNamespace,
+		Name:      "group_consensus_latest_block",
+		Help:      "Consensus latest block",
+	}, []string{
+		"backend_group_name",
+	})
+
+	consensusSafeBlock = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: MetricsNamespace,
+		Name:      "group_consensus_safe_block",
+		Help:      "Consensus safe block",
+	}, []string{
+		"backend_group_name",
+	})
+
+	consensusFinalizedBlock = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: MetricsNamespace,
+		Name:      "group_consensus_finalized_block",
+		Help:      "Consensus finalized block",
+	}, []string{
+		"backend_group_name",
+	})
+
+	consensusGroupCount = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: MetricsNamespace,
+		Name:      "group_consensus_count",
+		Help:      "Number of backends in the consensus group",
+	}, []string{
+		"backend_group_name",
+	})
+
+	backendLatestBlockBackend = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: MetricsNamespace,
+		Name:      "backend_latest_block",
+		Help:      "Current latest block observed per backend",
+	}, []string{
+		"backend_name",
+	})
+
+	backendSafeBlockBackend = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: MetricsNamespace,
+		Name:      "backend_safe_block",
+		Help:      "Current safe block observed per backend",
+	}, []string{
+		"backend_name",
+	})
+
+	backendFinalizedBlockBackend = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: MetricsNamespace,
+		Name:      "backend_finalized_block",
+		Help:      "Current finalized block observed per backend",
+	}, []string{
+		"backend_name",
+	})
+
+	backendInSync = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: MetricsNamespace,
+		Name:      "backend_in_sync",
+		Help:      "1 if the backend is part of the consensus group of its backend group",
+	}, []string{
+		"backend_name",
+	})
+
+	shadowResultsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: MetricsNamespace,
+		Name:      "shadow_results_total",
+		Help:      "Count of the comparisons of shadow backend responses to the primary responses.",
+	}, []string{
+		"backend_group_name",
+		"backend_name",
+		"method_name",
+		"result",
+	})
+
+	getLogsSplitChunks = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: MetricsNamespace,
+		Name:      "get_logs_split_chunks",
+		Help:      "Histogram of the number of chunks eth_getLogs requests over large block ranges are split into.",
+		Buckets:   []float64{2, 4, 8, 16, 32, 64, 128},
+	})
+
+	cacheReorgsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: MetricsNamespace,
+		Name:      "cache_reorgs_total",
+		Help:      "Count of the reorgs detected by the block sync poller.",
+	})
+
+	cacheReorgInvalidationsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: MetricsNamespace,
+		Name:      "cache_reorg_invalidations_total",
+		Help:      "Count of the cache entries invalidated because of reorgs.",
+	})
+
+	backendLatencyEWMAGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: MetricsNamespace,
+		Name:      "backend_latency_ewma_seconds",
+		Help:      "Exponentially weighted moving average of the backend response times",
+	}, []string{
+		"backend_name",
+	})
+
+	wsSubscriptionsGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: MetricsNamespace,
+		Name:      "ws_subscriptions",
+		Help:      "Gauge of the multiplexed subscriptions of clients.",
+	}, []string{
+		"backend_group_name",
+	})
+
+	wsUpstreamSubscriptionsGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: MetricsNamespace,
+		Name:      "ws_upstream_subscriptions",
+		Help:      "Gauge of the backend subscriptions multiplexed subscriptions are served by.",
+	}, []string{
+		"backend_group_name",
+	})
+
+	wsSubscriptionReconnectsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: MetricsNamespace,
+		Name:      "ws_subscription_reconnects_total",
+		Help:      "Count of the reconnections of the backend connection of multiplexed subscriptions.",
+	}, []string{
+		"backend_group_name",
+	})
+
+	wsSubscribersDroppedTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: MetricsNamespace,
+		Name:      "ws_subscribers_dropped_total",
+		Help:      "Count of the clients disconnected for not keeping up with their subscription notifications.",
+	})
+
+	txPolicyRejectionsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: MetricsNamespace,
+		Name:      "tx_policy_rejections_total",
+		Help:      "Count of transactions rejected by the transaction policy.",
+	}, []string{
+		"reason",
+	})
+
+	txBroadcastsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: MetricsNamespace,
+		Name:      "tx_broadcasts_total",
+		Help:      "Count of transactions broadcast to each backend.",
+	}, []string{
+		"backend_group_name",
+		"backend_name",
+		"success",
+	})
+
+	configReloadsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: MetricsNamespace,
+		Name:      "config_reloads_total",
+		Help:      "Count of config reloads.",
+	}, []string{
+		"success",
+	})
+
+	tracingExportedSpansTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: MetricsNamespace,
+		Name:      "tracing_exported_spans_total",
+		Help:      "Count of spans exported to the OTLP collector.",
+	}, []string{
+		"success",
+	})
+
+	tracingDroppedSpansTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: MetricsNamespace,
+		Name:      "tracing_dropped_spans_total",
+		Help:      "Count of spans dropped because the export queue was full.",
+	})
 )
 
 func RecordRedisError(source string) {
@@ -302,3 +475,82 @@ func RecordCacheMiss(method string) {
 func RecordBatchSize(size int) {
 	batchSizeHistogram.Observe(float64(size))
 }
+
+func RecordGroupConsensusBlocks(group *BackendGroup, blocks ConsensusBlocks) {
+	consensusLatestBlock.WithLabelValues(group.Name).Set(float64(blocks.Latest))
+	consensusSafeBlock.WithLabelValues(group.Name).Set(float64(blocks.Safe))
+	consensusFinalizedBlock.WithLabelValues(group.Name).Set(float64(blocks.Finalized))
+}
+
+func RecordGroupConsensusCount(group *BackendGroup, count int) {
+	consensusGroupCount.WithLabelValues(group.Name).Set(float64(count))
+}
+
+func RecordBackendLatestBlock(b *Backend, blockNumber hexutil.Uint64) {
+	backendLatestBlockBackend.WithLabelValues(b.Name).Set(float64(blockNumber))
+}
+
+func RecordBackendSafeBlock(b *Backend, blockNumber hexutil.Uint64) {
+	backendSafeBlockBackend.WithLabelValues(b.Name).Set(float64(blockNumber))
+}
+
+func RecordBackendFinalizedBlock(b *Backend, blockNumber hexutil.Uint64) {
+	backendFinalizedBlockBackend.WithLabelValues(b.Name).Set(float64(blockNumber))
+}
+
+func RecordBackendInSync(b *Backend, inSync bool) {
+	backendInSync.WithLabelValues(b.Name).Set(boolToFloat64(inSync))
+}
+
+func boolToFloat64(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func RecordBackendLatencyEWMA(b *Backend, seconds float64) {
+	backendLatencyEWMAGauge.WithLabelValues(b.Name).Set(seconds)
+}
+
+func RecordShadowResult(groupName, backendName, method, result string) {
+	shadowResultsTotal.WithLabelValues(groupName, backendName, method, result).Inc()
+}
+
+func RecordGetLogsSplit(chunks int) {
+	getLogsSplitChunks.Observe(float64(chunks))
+}
+
+func RecordCacheReorg(invalidated int) {
+	cacheReorgsTotal.Inc()
+	cacheReorgInvalidationsTotal.Add(float64(invalidated))
+}
+
+func RecordWSSubscriptions(groupName string, subscriptionsDelta, upstreamDelta int) {
+	wsSubscriptionsGauge.WithLabelValues(groupName).Add(float64(subscriptionsDelta))
+	wsUpstreamSubscriptionsGauge.WithLabelValues(groupName).Add(float64(upstreamDelta))
+}
+
+func RecordWSSubscriptionReconnect(groupName string) {
+	wsSubscriptionReconnectsTotal.WithLabelValues(groupName).Inc()
+}
+
+func RecordConfigReload(success bool) {
+	configReloadsTotal.WithLabelValues(strconv.FormatBool(success)).Inc()
+}
+
+func RecordTxPolicyRejection(reason string) {
+	txPolicyRejectionsTotal.WithLabelValues(reason).Inc()
+}
+
+func RecordTxBroadcast(groupName string, backendName string, success bool) {
+	txBroadcastsTotal.WithLabelValues(groupName, backendName, strconv.FormatBool(success)).Inc()
+}
+
+func RecordExportedSpans(count int, success bool) {
+	tracingExportedSpansTotal.WithLabelValues(strconv.FormatBool(success)).Add(float64(count))
+}
+
+func RecordDroppedSpans(count int) {
+	tracingDroppedSpansTotal.Add(float64(count))
+}
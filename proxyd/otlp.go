@@ -0,0 +1,286 @@
+package proxyd
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+)
+
+const (
+	defaultOTLPExportInterval = 5 * time.Second
+	defaultOTLPServiceName    = "proxyd"
+	otlpExportTimeout         = 10 * time.Second
+	otlpMaxExportBatchSize    = 512
+	otlpMaxQueueSize          = 8192
+	otlpTracesPath            = "/v1/traces"
+)
+
+// OTLPExporter batches spans and exports them to an OpenTelemetry collector with the JSON
+// encoding of OTLP over HTTP. The spans that don't fit in its queue are dropped rather than
+// slowing down the requests.
+type OTLPExporter struct {
+	url         string
+	headers     map[string]string
+	serviceName string
+	interval    time.Duration
+	client      *http.Client
+
+	mtx   sync.Mutex
+	queue []*otlpSpan
+
+	flushC   chan struct{}
+	stopC    chan struct{}
+	doneC    chan struct{}
+	stopOnce sync.Once
+}
+
+// NewOTLPExporter creates an exporter to the collector at endpoint, to which the OTLP traces
+// path is appended.
+func NewOTLPExporter(endpoint string, headers map[string]string, serviceName string, interval time.Duration) *OTLPExporter {
+	if serviceName == "" {
+		serviceName = defaultOTLPServiceName
+	}
+	if interval == 0 {
+		interval = defaultOTLPExportInterval
+	}
+	return &OTLPExporter{
+		url:         strings.TrimSuffix(endpoint, "/") + otlpTracesPath,
+		headers:     headers,
+		serviceName: serviceName,
+		interval:    interval,
+		client:      &http.Client{Timeout: otlpExportTimeout},
+		flushC:      make(chan struct{}, 1),
+		stopC:       make(chan struct{}),
+		doneC:       make(chan struct{}),
+	}
+}
+
+func (e *OTLPExporter) Start() {
+	go e.loop()
+}
+
+// Stop exports the queued spans and stops the exporter.
+func (e *OTLPExporter) Stop() {
+	e.stopOnce.Do(func() {
+		close(e.stopC)
+		<-e.doneC
+	})
+}
+
+// Export queues the spans of a trace.
+func (e *OTLPExporter) Export(id traceID, spans []*spanSnapshot) {
+	e.mtx.Lock()
+	dropped := len(e.queue) + len(spans) - otlpMaxQueueSize
+	if dropped > len(spans) {
+		dropped = len(spans)
+	}
+	if dropped > 0 {
+		spans = spans[:len(spans)-dropped]
+	}
+	for _, span := range spans {
+		e.queue = append(e.queue, newOTLPSpan(id, span))
+	}
+	full := len(e.queue) >= otlpMaxExportBatchSize
+	e.mtx.Unlock()
+
+	if dropped > 0 {
+		RecordDroppedSpans(dropped)
+	}
+	if full {
+		select {
+		case e.flushC <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (e *OTLPExporter) loop() {
+	defer close(e.doneC)
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			e.flush()
+		case <-e.flushC:
+			e.flush()
+		case <-e.stopC:
+			e.flush()
+			return
+		}
+	}
+}
+
+func (e *OTLPExporter) flush() {
+	for {
+		e.mtx.Lock()
+		n := len(e.queue)
+		if n > otlpMaxExportBatchSize {
+			n = otlpMaxExportBatchSize
+		}
+		batch := e.queue[:n]
+		e.queue = e.queue[n:]
+		e.mtx.Unlock()
+		if len(batch) == 0 {
+			return
+		}
+
+		err := e.export(batch)
+		if err != nil {
+			log.Warn("error exporting spans", "url", e.url, "spans", len(batch), "err", err)
+		}
+		RecordExportedSpans(len(batch), err == nil)
+	}
+}
+
+func (e *OTLPExporter) export(spans []*otlpSpan) error {
+	body := mustMarshalJSON(&otlpTracesRequest{
+		ResourceSpans: []*otlpResourceSpans{
+			{
+				Resource: otlpResource{
+					Attributes: []otlpKeyValue{newOTLPKeyValue("service.name", e.serviceName)},
+				},
+				ScopeSpans: []*otlpScopeSpans{
+					{
+						Scope: otlpScope{Name: "proxyd"},
+						Spans: spans,
+					},
+				},
+			},
+		},
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), otlpExportTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "POST", e.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("content-type", "application/json")
+	for k, v := range e.headers {
+		req.Header.Set(k, v)
+	}
+	res, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(io.Discard, res.Body)
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("collector responded with status %d", res.StatusCode)
+	}
+	return nil
+}
+
+// The JSON encoding of the OTLP trace export request. Trace and span IDs are hex encoded and
+// 64 bit integers are strings.
+type otlpTracesRequest struct {
+	ResourceSpans []*otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource      `json:"resource"`
+	ScopeSpans []*otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope   `json:"scope"`
+	Spans []*otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+const otlpStatusCodeError = 2
+
+type otlpStatus struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
+
+func newOTLPKeyValue(key string, value interface{}) otlpKeyValue {
+	var v otlpAnyValue
+	switch value := value.(type) {
+	case string:
+		v.StringValue = &value
+	case bool:
+		v.BoolValue = &value
+	case int:
+		s := strconv.Itoa(value)
+		v.IntValue = &s
+	case int64:
+		s := strconv.FormatInt(value, 10)
+		v.IntValue = &s
+	case float64:
+		v.DoubleValue = &value
+	default:
+		s := fmt.Sprint(value)
+		v.StringValue = &s
+	}
+	return otlpKeyValue{Key: key, Value: v}
+}
+
+func newOTLPSpan(id traceID, span *spanSnapshot) *otlpSpan {
+	s := &otlpSpan{
+		TraceID:           hex.EncodeToString(id[:]),
+		SpanID:            hex.EncodeToString(span.id[:]),
+		Name:              span.name,
+		Kind:              span.kind,
+		StartTimeUnixNano: strconv.FormatInt(span.start.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(span.end.UnixNano(), 10),
+	}
+	if span.parentID != (spanID{}) {
+		s.ParentSpanID = hex.EncodeToString(span.parentID[:])
+	}
+	keys := make([]string, 0, len(span.attributes))
+	for k := range span.attributes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		s.Attributes = append(s.Attributes, newOTLPKeyValue(k, span.attributes[k]))
+	}
+	if span.errMsg != "" {
+		s.Status = otlpStatus{Code: otlpStatusCodeError, Message: span.errMsg}
+	}
+	return s
+}
@@ -5,11 +5,16 @@ import (
 	"crypto/tls"
 	"errors"
 	"fmt"
+	"math/big"
 	"net/http"
 	"os"
+	"os/signal"
+	"reflect"
 	"strconv"
+	"syscall"
 	"time"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/math"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/ethereum/go-ethereum/log"
@@ -18,21 +23,10 @@ import (
 	"golang.org/x/sync/semaphore"
 )
 
-func Start(config *Config) (func(), error) {
-	if len(config.Backends) == 0 {
-		return nil, errors.New("must define at least one backend")
-	}
-	if len(config.BackendGroups) == 0 {
-		return nil, errors.New("must define at least one backend group")
-	}
-	if len(config.RPCMethodMappings) == 0 {
-		return nil, errors.New("must define at least one RPC method mapping")
-	}
-
-	for authKey := range config.Authentication {
-		if authKey == "none" {
-			return nil, errors.New("cannot use none as an auth key")
-		}
+func Start(config *Config, opts ...StartOpt) (func(), error) {
+	startOpts := new(startOptions)
+	for _, opt := range opts {
+		opt(startOpts)
 	}
 
 	var redisClient *redis.Client
@@ -51,6 +45,18 @@ func Start(config *Config) (func(), error) {
 		return nil, errors.New("must specify a Redis URL if UseRedis is true in rate limit config")
 	}
 
+	var adminToken string
+	if config.Admin.Port != 0 {
+		token, err := ReadFromEnvOrConfig(config.Admin.Token)
+		if err != nil {
+			return nil, err
+		}
+		if token == "" {
+			return nil, errors.New("must specify an admin token if the admin server is enabled")
+		}
+		adminToken = token
+	}
+
 	var lim BackendRateLimiter
 	var err error
 	if config.RateLimit.EnableBackendRateLimiter {
@@ -78,141 +84,39 @@ func Start(config *Config) (func(), error) {
 		ErrTooManyBatchRequests.Message = config.BatchConfig.ErrorMessage
 	}
 
-	if config.SenderRateLimit.Enabled {
-		if config.SenderRateLimit.Limit <= 0 {
-			return nil, errors.New("limit in sender_rate_limit must be > 0")
-		}
-		if time.Duration(config.SenderRateLimit.Interval) < time.Second {
-			return nil, errors.New("interval in sender_rate_limit must be >= 1s")
-		}
-	}
-
 	maxConcurrentRPCs := config.Server.MaxConcurrentRPCs
 	if maxConcurrentRPCs == 0 {
 		maxConcurrentRPCs = math.MaxInt64
 	}
 	rpcRequestSemaphore := semaphore.NewWeighted(maxConcurrentRPCs)
 
-	backendNames := make([]string, 0)
-	backendsByName := make(map[string]*Backend)
-	for name, cfg := range config.Backends {
-		opts := make([]BackendOpt, 0)
-
-		rpcURL, err := ReadFromEnvOrConfig(cfg.RPCURL)
-		if err != nil {
-			return nil, err
-		}
-		wsURL, err := ReadFromEnvOrConfig(cfg.WSURL)
-		if err != nil {
-			return nil, err
-		}
-		if rpcURL == "" {
-			return nil, fmt.Errorf("must define an RPC URL for backend %s", name)
-		}
-		if wsURL == "" {
-			return nil, fmt.Errorf("must define a WS URL for backend %s", name)
-		}
-
-		if config.BackendOptions.ResponseTimeoutSeconds != 0 {
-			timeout := secondsToDuration(config.BackendOptions.ResponseTimeoutSeconds)
-			opts = append(opts, WithTimeout(timeout))
-		}
-		if config.BackendOptions.MaxRetries != 0 {
-			opts = append(opts, WithMaxRetries(config.BackendOptions.MaxRetries))
-		}
-		if config.BackendOptions.MaxResponseSizeBytes != 0 {
-			opts = append(opts, WithMaxResponseSize(config.BackendOptions.MaxResponseSizeBytes))
-		}
-		if config.BackendOptions.OutOfServiceSeconds != 0 {
-			opts = append(opts, WithOutOfServiceDuration(secondsToDuration(config.BackendOptions.OutOfServiceSeconds)))
-		}
-		if cfg.MaxRPS != 0 {
-			opts = append(opts, WithMaxRPS(cfg.MaxRPS))
-		}
-		if cfg.MaxWSConns != 0 {
-			opts = append(opts, WithMaxWSConns(cfg.MaxWSConns))
-		}
-		if cfg.Password != "" {
-			passwordVal, err := ReadFromEnvOrConfig(cfg.Password)
-			if err != nil {
-				return nil, err
-			}
-			opts = append(opts, WithBasicAuth(cfg.Username, passwordVal))
-		}
-		tlsConfig, err := configureBackendTLS(cfg)
-		if err != nil {
-			return nil, err
-		}
-		if tlsConfig != nil {
-			log.Info("using custom TLS config for backend", "name", name)
-			opts = append(opts, WithTLSConfig(tlsConfig))
-		}
-		if cfg.StripTrailingXFF {
-			opts = append(opts, WithStrippedTrailingXFF())
-		}
-		opts = append(opts, WithProxydIP(os.Getenv("PROXYD_IP")))
-		back := NewBackend(name, rpcURL, wsURL, lim, rpcRequestSemaphore, opts...)
-		backendNames = append(backendNames, name)
-		backendsByName[name] = back
-		log.Info("configured backend", "name", name, "rpc_url", rpcURL, "ws_url", wsURL)
-	}
-
-	backendGroups := make(map[string]*BackendGroup)
-	for bgName, bg := range config.BackendGroups {
-		backends := make([]*Backend, 0)
-		for _, bName := range bg.Backends {
-			if backendsByName[bName] == nil {
-				return nil, fmt.Errorf("backend %s is not defined", bName)
-			}
-			backends = append(backends, backendsByName[bName])
-		}
-		group := &BackendGroup{
-			Name:     bgName,
-			Backends: backends,
-		}
-		backendGroups[bgName] = group
+	initialRouting, err := buildRouting(config, lim, rpcRequestSemaphore, nil)
+	if err != nil {
+		return nil, err
 	}
+	backendGroups := initialRouting.backendGroups
+	wsBackendGroup := initialRouting.wsBackendGroup
 
-	var wsBackendGroup *BackendGroup
-	if config.WSBackendGroup != "" {
-		wsBackendGroup = backendGroups[config.WSBackendGroup]
+	if config.WSSubscriptions.Multiplex {
 		if wsBackendGroup == nil {
-			return nil, fmt.Errorf("ws backend group %s does not exist", config.WSBackendGroup)
+			return nil, fmt.Errorf("ws subscriptions can only be multiplexed if a ws group was defined")
 		}
-	}
-
-	if wsBackendGroup == nil && config.Server.WSPort != 0 {
-		return nil, fmt.Errorf("a ws port was defined, but no ws group was defined")
-	}
-
-	for _, bg := range config.RPCMethodMappings {
-		if backendGroups[bg] == nil {
-			return nil, fmt.Errorf("undefined backend group %s", bg)
-		}
-	}
-
-	var resolvedAuth map[string]string
-
-	if config.Authentication != nil {
-		resolvedAuth = make(map[string]string)
-		for secret, alias := range config.Authentication {
-			resolvedSecret, err := ReadFromEnvOrConfig(secret)
-			if err != nil {
-				return nil, err
-			}
-			resolvedAuth[resolvedSecret] = alias
+		subscriptionOpts := make([]SubscriptionMultiplexerOpt, 0)
+		if config.WSSubscriptions.BufferSize != 0 {
+			subscriptionOpts = append(subscriptionOpts, WithWSSubscriptionBufferSize(config.WSSubscriptions.BufferSize))
 		}
+		wsBackendGroup.Subscriptions = NewSubscriptionMultiplexer(wsBackendGroup, subscriptionOpts...)
 	}
 
 	var (
 		rpcCache    RPCCache
 		blockNumLVC *EthLastValueCache
 		gasPriceLVC *EthLastValueCache
+		blockNumFn  GetLatestBlockNumFn
 	)
 	if config.Cache.Enabled {
 		var (
 			cache      Cache
-			blockNumFn GetLatestBlockNumFn
 			gasPriceFn GetLatestGasPriceFn
 		)
 
@@ -237,9 +141,96 @@ func Start(config *Config) (func(), error) {
 		}
 		defer ethClient.Close()
 
-		blockNumLVC, blockNumFn = makeGetLatestBlockNumFn(ethClient, cache)
+		rpcCacheOpts := make([]RPCCacheOpt, 0)
+		for method, methodConfig := range config.Cache.Methods {
+			if !cachePolicyMethods[method] {
+				return nil, fmt.Errorf("cache TTLs are not supported for method %s", method)
+			}
+			rpcCacheOpts = append(rpcCacheOpts, WithCachePolicy(
+				method,
+				time.Duration(methodConfig.TTL),
+				time.Duration(methodConfig.NullResultTTL),
+			))
+		}
+		var reorgs *ReorgTracker
+		if config.Cache.InvalidateOnReorg {
+			reorgs = NewReorgTracker(
+				newCacheWithCompression(cache),
+				config.Cache.NumBlockConfirmations,
+				makeGetBlockHashFn(ethClient),
+			)
+			rpcCacheOpts = append(rpcCacheOpts, WithReorgTracker(reorgs))
+		}
+
+		blockNumLVC, blockNumFn = makeGetLatestBlockNumFn(ethClient, cache, reorgs)
 		gasPriceLVC, gasPriceFn = makeGetLatestGasPriceFn(ethClient, cache)
-		rpcCache = newRPCCache(newCacheWithCompression(cache), blockNumFn, gasPriceFn, config.Cache.NumBlockConfirmations)
+		rpcCache = newRPCCache(newCacheWithCompression(cache), blockNumFn, gasPriceFn, config.Cache.NumBlockConfirmations, rpcCacheOpts...)
+	}
+
+	var getLogsGuard *GetLogsGuard
+	if config.GetLogs != (GetLogsConfig{}) {
+		// Resolving the latest block of eth_getLogs block ranges requires the block number LVC
+		if config.GetLogs.MaxBlockRange > 0 && blockNumFn == nil {
+			if config.Cache.BlockSyncRPCURL == "" {
+				return nil, fmt.Errorf("block sync node required for eth_getLogs block range limits")
+			}
+			blockSyncRPCURL, err := ReadFromEnvOrConfig(config.Cache.BlockSyncRPCURL)
+			if err != nil {
+				return nil, err
+			}
+			ethClient, err := ethclient.Dial(blockSyncRPCURL)
+			if err != nil {
+				return nil, err
+			}
+			defer ethClient.Close()
+
+			var cache Cache
+			if redisClient == nil {
+				cache = newMemoryCache()
+			} else {
+				cache = newRedisCache(redisClient)
+			}
+			blockNumLVC, blockNumFn = makeGetLatestBlockNumFn(ethClient, cache, nil)
+		}
+		if config.GetLogs.MaxSplitBlockRange > 0 && config.GetLogs.MaxSplitBlockRange < config.GetLogs.MaxBlockRange {
+			return nil, fmt.Errorf("get_logs.max_split_block_range must be greater than get_logs.max_block_range")
+		}
+		getLogsGuard = NewGetLogsGuard(config.GetLogs, blockNumFn)
+	}
+
+	var tenants *TenantRegistry
+	if config.Tenants.File != "" {
+		tenantsFile, err := ReadFromEnvOrConfig(config.Tenants.File)
+		if err != nil {
+			return nil, err
+		}
+		limiterFactory := func(dur time.Duration, max int, prefix string) FrontendRateLimiter {
+			return NewMemoryFrontendRateLimit(dur, max)
+		}
+		usage := NewMemoryUsageCounter()
+		if redisClient == nil {
+			log.Warn("redis is not configured, tenant rate limits and usage are local to this instance")
+		} else {
+			limiterFactory = func(dur time.Duration, max int, prefix string) FrontendRateLimiter {
+				return NewRedisFrontendRateLimiter(redisClient, dur, max, prefix)
+			}
+			usage = NewRedisUsageCounter(redisClient)
+		}
+		tenants, err = NewTenantRegistry(
+			tenantsFile,
+			limiterFactory,
+			backendGroups,
+			config.RPCMethodMappings,
+			usage,
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	tracer, err := buildTracer(config.AccessLog, config.Tracing)
+	if err != nil {
+		return nil, err
 	}
 
 	srv, err := NewServer(
@@ -248,7 +239,7 @@ func Start(config *Config) (func(), error) {
 		NewStringSetFromStrings(config.WSMethodWhitelist),
 		config.RPCMethodMappings,
 		config.Server.MaxBodySizeBytes,
-		resolvedAuth,
+		initialRouting.authentication,
 		secondsToDuration(config.Server.TimeoutSeconds),
 		config.Server.MaxUpstreamBatchSize,
 		rpcCache,
@@ -258,11 +249,17 @@ func Start(config *Config) (func(), error) {
 		config.Server.MaxRequestBodyLogLen,
 		config.BatchConfig.MaxSize,
 		redisClient,
+		getLogsGuard,
+		tenants,
+		initialRouting.txPolicy,
+		tracer,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("error creating server: %w", err)
 	}
 
+	reloader := newConfigReloader(startOpts.configLoader, srv, tenants, lim, rpcRequestSemaphore, initialRouting)
+
 	if config.Metrics.Enabled {
 		addr := fmt.Sprintf("%s:%d", config.Metrics.Host, config.Metrics.Port)
 		log.Info("starting metrics server", "addr", addr)
@@ -302,6 +299,45 @@ func Start(config *Config) (func(), error) {
 		}()
 	}
 
+	for _, bg := range backendGroups {
+		if bg.Consensus != nil {
+			bg.Consensus.Start()
+		}
+		if bg.Subscriptions != nil {
+			bg.Subscriptions.Start()
+		}
+	}
+
+	var adminServer *AdminServer
+	if config.Admin.Port != 0 {
+		adminServer = NewAdminServer(adminToken, tenants, reloader)
+		go func() {
+			if err := adminServer.ListenAndServe(config.Admin.Host, config.Admin.Port); err != nil {
+				if errors.Is(err, http.ErrServerClosed) {
+					log.Info("admin server shut down")
+					return
+				}
+				log.Crit("error starting admin server", "err", err)
+			}
+		}()
+	}
+
+	if tenants != nil {
+		tenants.Start(time.Duration(config.Tenants.ReloadInterval))
+	}
+
+	var hup chan os.Signal
+	if startOpts.configLoader != nil {
+		hup = make(chan os.Signal, 1)
+		signal.Notify(hup, syscall.SIGHUP)
+		go func() {
+			for range hup {
+				log.Info("caught SIGHUP, reloading config")
+				_, _ = reloader.Reload()
+			}
+		}()
+	}
+
 	<-errTimer.C
 	log.Info("started proxyd")
 
@@ -313,14 +349,268 @@ func Start(config *Config) (func(), error) {
 		if gasPriceLVC != nil {
 			gasPriceLVC.Stop()
 		}
+		if hup != nil {
+			signal.Stop(hup)
+			close(hup)
+		}
+		reloader.Stop()
+		if tenants != nil {
+			tenants.Stop()
+		}
 		srv.Shutdown()
-		if err := lim.FlushBackendWSConns(backendNames); err != nil {
+		tracer.Close()
+		if adminServer != nil {
+			adminServer.Shutdown()
+		}
+		if err := lim.FlushBackendWSConns(reloader.BackendNames()); err != nil {
 			log.Error("error flushing backend ws conns", "err", err)
 		}
 		log.Info("goodbye")
 	}, nil
 }
 
+// routing holds the backends and backend groups built from a config.
+type routing struct {
+	config         *Config
+	backends       map[string]*Backend
+	backendGroups  map[string]*BackendGroup
+	wsBackendGroup *BackendGroup
+	authentication map[string]string
+	txPolicy       *TxPolicy
+}
+
+// buildRouting validates the routing part of the config and builds its
+// backends and backend groups. If prev is set, the backends and backend
+// groups whose config didn't change are reused rather than rebuilt.
+func buildRouting(config *Config, lim BackendRateLimiter, rpcRequestSemaphore *semaphore.Weighted, prev *routing) (*routing, error) {
+	if len(config.Backends) == 0 {
+		return nil, errors.New("must define at least one backend")
+	}
+	if len(config.BackendGroups) == 0 {
+		return nil, errors.New("must define at least one backend group")
+	}
+	if len(config.RPCMethodMappings) == 0 {
+		return nil, errors.New("must define at least one RPC method mapping")
+	}
+
+	for authKey := range config.Authentication {
+		if authKey == "none" {
+			return nil, errors.New("cannot use none as an auth key")
+		}
+	}
+
+	if config.SenderRateLimit.Enabled {
+		if config.SenderRateLimit.Limit <= 0 {
+			return nil, errors.New("limit in sender_rate_limit must be > 0")
+		}
+		if time.Duration(config.SenderRateLimit.Interval) < time.Second {
+			return nil, errors.New("interval in sender_rate_limit must be >= 1s")
+		}
+	}
+
+	backendsByName := make(map[string]*Backend)
+	for name, cfg := range config.Backends {
+		if prev != nil && prev.backends[name] != nil &&
+			reflect.DeepEqual(prev.config.Backends[name], cfg) &&
+			prev.config.BackendOptions == config.BackendOptions {
+			backendsByName[name] = prev.backends[name]
+			continue
+		}
+
+		opts := make([]BackendOpt, 0)
+
+		rpcURL, err := ReadFromEnvOrConfig(cfg.RPCURL)
+		if err != nil {
+			return nil, err
+		}
+		wsURL, err := ReadFromEnvOrConfig(cfg.WSURL)
+		if err != nil {
+			return nil, err
+		}
+		if rpcURL == "" {
+			return nil, fmt.Errorf("must define an RPC URL for backend %s", name)
+		}
+		if wsURL == "" {
+			return nil, fmt.Errorf("must define a WS URL for backend %s", name)
+		}
+
+		if config.BackendOptions.ResponseTimeoutSeconds != 0 {
+			timeout := secondsToDuration(config.BackendOptions.ResponseTimeoutSeconds)
+			opts = append(opts, WithTimeout(timeout))
+		}
+		if config.BackendOptions.MaxRetries != 0 {
+			opts = append(opts, WithMaxRetries(config.BackendOptions.MaxRetries))
+		}
+		if config.BackendOptions.MaxResponseSizeBytes != 0 {
+			opts = append(opts, WithMaxResponseSize(config.BackendOptions.MaxResponseSizeBytes))
+		}
+		if config.BackendOptions.OutOfServiceSeconds != 0 {
+			opts = append(opts, WithOutOfServiceDuration(secondsToDuration(config.BackendOptions.OutOfServiceSeconds)))
+		}
+		if cfg.MaxRPS != 0 {
+			opts = append(opts, WithMaxRPS(cfg.MaxRPS))
+		}
+		if cfg.MaxWSConns != 0 {
+			opts = append(opts, WithMaxWSConns(cfg.MaxWSConns))
+		}
+		if cfg.Password != "" {
+			passwordVal, err := ReadFromEnvOrConfig(cfg.Password)
+			if err != nil {
+				return nil, err
+			}
+			opts = append(opts, WithBasicAuth(cfg.Username, passwordVal))
+		}
+		tlsConfig, err := configureBackendTLS(cfg)
+		if err != nil {
+			return nil, err
+		}
+		if tlsConfig != nil {
+			log.Info("using custom TLS config for backend", "name", name)
+			opts = append(opts, WithTLSConfig(tlsConfig))
+		}
+		if cfg.StripTrailingXFF {
+			opts = append(opts, WithStrippedTrailingXFF())
+		}
+		if cfg.Weight != nil {
+			if *cfg.Weight < 0 {
+				return nil, fmt.Errorf("weight of backend %s must not be negative", name)
+			}
+			opts = append(opts, WithWeight(*cfg.Weight))
+		}
+		opts = append(opts, WithProxydIP(os.Getenv("PROXYD_IP")))
+		back := NewBackend(name, rpcURL, wsURL, lim, rpcRequestSemaphore, opts...)
+		backendsByName[name] = back
+		log.Info("configured backend", "name", name, "rpc_url", rpcURL, "ws_url", wsURL)
+	}
+
+	backendGroups := make(map[string]*BackendGroup)
+	for bgName, bg := range config.BackendGroups {
+		backends := make([]*Backend, 0)
+		for _, bName := range bg.Backends {
+			if backendsByName[bName] == nil {
+				return nil, fmt.Errorf("backend %s is not defined", bName)
+			}
+			backends = append(backends, backendsByName[bName])
+		}
+		for _, bName := range bg.ShadowBackends {
+			if backendsByName[bName] == nil {
+				return nil, fmt.Errorf("shadow backend %s is not defined", bName)
+			}
+		}
+		if prev != nil && prev.backendGroups[bgName] != nil &&
+			reflect.DeepEqual(prev.config.BackendGroups[bgName], bg) &&
+			reusesBackends(prev, backendsByName, bg) {
+			backendGroups[bgName] = prev.backendGroups[bgName]
+			continue
+		}
+		strategy, err := ParseRoutingStrategy(bg.RoutingStrategy)
+		if err != nil {
+			return nil, fmt.Errorf("backend group %s: %w", bgName, err)
+		}
+		group := &BackendGroup{
+			Name:          bgName,
+			Backends:      backends,
+			Strategy:      strategy,
+			StickyRouting: bg.StickyRouting,
+		}
+		if len(bg.ShadowBackends) > 0 {
+			shadows := make([]*Backend, 0)
+			for _, bName := range bg.ShadowBackends {
+				shadows = append(shadows, backendsByName[bName])
+			}
+			sopts := []ShadowOpt{WithShadowIgnoredFields(bg.ShadowIgnoredFields)}
+			if bg.ShadowMaxConcurrentRequests > 0 {
+				sopts = append(sopts, WithShadowMaxConcurrentRequests(bg.ShadowMaxConcurrentRequests))
+			}
+			if bg.ShadowTimeoutSeconds > 0 {
+				sopts = append(sopts, WithShadowTimeout(secondsToDuration(bg.ShadowTimeoutSeconds)))
+			}
+			group.Shadow = NewShadowComparer(bgName, shadows, sopts...)
+			log.Info("configured shadow backends", "name", bgName, "shadow_backends", bg.ShadowBackends)
+		}
+		if bg.ConsensusAware {
+			copts := make([]ConsensusOpt, 0)
+			if bg.ConsensusBanPeriod > 0 {
+				copts = append(copts, WithConsensusBanPeriod(time.Duration(bg.ConsensusBanPeriod)))
+			}
+			if bg.ConsensusMaxUpdateThreshold > 0 {
+				copts = append(copts, WithConsensusMaxUpdateThreshold(time.Duration(bg.ConsensusMaxUpdateThreshold)))
+			}
+			if bg.ConsensusMaxBlockLag > 0 {
+				copts = append(copts, WithConsensusMaxBlockLag(bg.ConsensusMaxBlockLag))
+			}
+			if bg.ConsensusPollerInterval > 0 {
+				copts = append(copts, WithConsensusPollerInterval(time.Duration(bg.ConsensusPollerInterval)))
+			}
+			group.Consensus = NewConsensusPoller(group, copts...)
+			log.Info("configured consensus-aware backend group", "name", bgName)
+		}
+		backendGroups[bgName] = group
+	}
+
+	var wsBackendGroup *BackendGroup
+	if config.WSBackendGroup != "" {
+		wsBackendGroup = backendGroups[config.WSBackendGroup]
+		if wsBackendGroup == nil {
+			return nil, fmt.Errorf("ws backend group %s does not exist", config.WSBackendGroup)
+		}
+	}
+
+	if wsBackendGroup == nil && config.Server.WSPort != 0 {
+		return nil, fmt.Errorf("a ws port was defined, but no ws group was defined")
+	}
+
+	for _, bg := range config.RPCMethodMappings {
+		if backendGroups[bg] == nil {
+			return nil, fmt.Errorf("undefined backend group %s", bg)
+		}
+	}
+
+	var txPolicy *TxPolicy
+	if config.TxPolicy != (TxPolicyConfig{}) {
+		var broadcastGroup *BackendGroup
+		if config.TxPolicy.BroadcastBackendGroup != "" {
+			broadcastGroup = backendGroups[config.TxPolicy.BroadcastBackendGroup]
+			if broadcastGroup == nil {
+				return nil, fmt.Errorf("tx broadcast backend group %s does not exist", config.TxPolicy.BroadcastBackendGroup)
+			}
+		}
+		txPolicy = NewTxPolicy(config.TxPolicy, broadcastGroup)
+	}
+
+	var resolvedAuth map[string]string
+	if config.Authentication != nil {
+		resolvedAuth = make(map[string]string)
+		for secret, alias := range config.Authentication {
+			resolvedSecret, err := ReadFromEnvOrConfig(secret)
+			if err != nil {
+				return nil, err
+			}
+			resolvedAuth[resolvedSecret] = alias
+		}
+	}
+
+	return &routing{
+		config:         config,
+		backends:       backendsByName,
+		backendGroups:  backendGroups,
+		wsBackendGroup: wsBackendGroup,
+		authentication: resolvedAuth,
+		txPolicy:       txPolicy,
+	}, nil
+}
+
+// reusesBackends returns whether the backends and shadow backends of the
+// group are the ones of the previous routing.
+func reusesBackends(prev *routing, backendsByName map[string]*Backend, bg *BackendGroupConfig) bool {
+	for _, bName := range append(append([]string{}, bg.Backends...), bg.ShadowBackends...) {
+		if prev.backends[bName] != backendsByName[bName] {
+			return false
+		}
+	}
+	return true
+}
+
 func secondsToDuration(seconds int) time.Duration {
 	return time.Duration(seconds) * time.Second
 }
@@ -365,13 +655,34 @@ func makeUint64LastValueFn(client *ethclient.Client, cache Cache, key string, up
 	}
 }
 
-func makeGetLatestBlockNumFn(client *ethclient.Client, cache Cache) (*EthLastValueCache, GetLatestBlockNumFn) {
+// makeGetLatestBlockNumFn polls the latest block number. If reorgs is set, it's also fed the new
+// chain heads to detect reorgs.
+func makeGetLatestBlockNumFn(client *ethclient.Client, cache Cache, reorgs *ReorgTracker) (*EthLastValueCache, GetLatestBlockNumFn) {
 	return makeUint64LastValueFn(client, cache, "lvc:block_number", func(ctx context.Context, c *ethclient.Client) (string, error) {
-		blockNum, err := c.BlockNumber(ctx)
-		return strconv.FormatUint(blockNum, 10), err
+		if reorgs == nil {
+			blockNum, err := c.BlockNumber(ctx)
+			return strconv.FormatUint(blockNum, 10), err
+		}
+
+		header, err := c.HeaderByNumber(ctx, nil)
+		if err != nil {
+			return "", err
+		}
+		reorgs.Observe(ctx, header)
+		return header.Number.String(), nil
 	})
 }
 
+func makeGetBlockHashFn(client *ethclient.Client) GetBlockHashFn {
+	return func(ctx context.Context, blockNum uint64) (common.Hash, error) {
+		header, err := client.HeaderByNumber(ctx, new(big.Int).SetUint64(blockNum))
+		if err != nil {
+			return common.Hash{}, err
+		}
+		return header.Hash(), nil
+	}
+}
+
 func makeGetLatestGasPriceFn(client *ethclient.Client, cache Cache) (*EthLastValueCache, GetLatestGasPriceFn) {
 	return makeUint64LastValueFn(client, cache, "lvc:gas_price", func(ctx context.Context, c *ethclient.Client) (string, error) {
 		gasPrice, err := c.SuggestGasPrice(ctx)
@@ -0,0 +1,305 @@
+package proxyd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"golang.org/x/sync/semaphore"
+)
+
+const (
+	defaultBackendDrainTimeout = 30 * time.Second
+	backendDrainPollInterval   = 50 * time.Millisecond
+)
+
+var ErrConfigReloadDisabled = errors.New("config reload is not enabled")
+
+// ConfigLoader reads the config that is applied when proxyd is reloaded.
+type ConfigLoader func() (*Config, error)
+
+type startOptions struct {
+	configLoader ConfigLoader
+}
+
+type StartOpt func(o *startOptions)
+
+// WithConfigLoader enables config reloads on SIGHUP and through the admin
+// API. Each reload applies the config returned by loader.
+func WithConfigLoader(loader ConfigLoader) StartOpt {
+	return func(o *startOptions) {
+		o.configLoader = loader
+	}
+}
+
+// ConfigChanges lists the entries of a config section that were added,
+// removed or updated by a reload.
+type ConfigChanges struct {
+	Added   []string `json:"added"`
+	Removed []string `json:"removed"`
+	Updated []string `json:"updated"`
+}
+
+func newConfigChanges() *ConfigChanges {
+	return &ConfigChanges{
+		Added:   make([]string, 0),
+		Removed: make([]string, 0),
+		Updated: make([]string, 0),
+	}
+}
+
+func (c *ConfigChanges) record(name string, inPrev bool, inNext bool, reused bool) {
+	switch {
+	case !inPrev:
+		c.Added = append(c.Added, name)
+	case !inNext:
+		c.Removed = append(c.Removed, name)
+	case !reused:
+		c.Updated = append(c.Updated, name)
+	}
+}
+
+func (c *ConfigChanges) sort() {
+	sort.Strings(c.Added)
+	sort.Strings(c.Removed)
+	sort.Strings(c.Updated)
+}
+
+type ReloadResult struct {
+	Backends      *ConfigChanges `json:"backends"`
+	BackendGroups *ConfigChanges `json:"backend_groups"`
+}
+
+// ConfigReloader applies new configs to a running proxyd. A new config is
+// validated as a whole before anything is applied: the backends and backend
+// groups that changed are rebuilt, then the method mappings, backend groups
+// and rate limits of the server are swapped at once. The backends that were
+// removed are drained in the background.
+type ConfigReloader struct {
+	loader       ConfigLoader
+	srv          *Server
+	tenants      *TenantRegistry
+	lim          BackendRateLimiter
+	sem          *semaphore.Weighted
+	drainTimeout time.Duration
+
+	mtx          sync.Mutex
+	routing      *routing
+	backendNames map[string]bool
+}
+
+func newConfigReloader(
+	loader ConfigLoader,
+	srv *Server,
+	tenants *TenantRegistry,
+	lim BackendRateLimiter,
+	sem *semaphore.Weighted,
+	routing *routing,
+) *ConfigReloader {
+	r := &ConfigReloader{
+		loader:       loader,
+		srv:          srv,
+		tenants:      tenants,
+		lim:          lim,
+		sem:          sem,
+		drainTimeout: defaultBackendDrainTimeout,
+		routing:      routing,
+		backendNames: make(map[string]bool),
+	}
+	for name := range routing.backends {
+		r.backendNames[name] = true
+	}
+	return r
+}
+
+// Reload loads the config and applies it. The running config is kept if
+// the new one is invalid.
+func (r *ConfigReloader) Reload() (*ReloadResult, error) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	if r.loader == nil {
+		return nil, ErrConfigReloadDisabled
+	}
+
+	config, err := r.loader()
+	if err != nil {
+		RecordConfigReload(false)
+		log.Error("error loading config", "err", err)
+		return nil, wrapErr(err, "error loading config")
+	}
+	res, err := r.apply(config)
+	if err != nil {
+		RecordConfigReload(false)
+		log.Error("error reloading config", "err", err)
+		return nil, err
+	}
+	RecordConfigReload(true)
+	log.Info(
+		"reloaded config",
+		"added_backends", res.Backends.Added,
+		"removed_backends", res.Backends.Removed,
+		"updated_backends", res.Backends.Updated,
+		"added_backend_groups", res.BackendGroups.Added,
+		"removed_backend_groups", res.BackendGroups.Removed,
+		"updated_backend_groups", res.BackendGroups.Updated,
+	)
+	return res, nil
+}
+
+func (r *ConfigReloader) apply(config *Config) (*ReloadResult, error) {
+	prev := r.routing
+	if changed := restartRequiredChanges(prev.config, config); len(changed) > 0 {
+		return nil, fmt.Errorf("changes to %s require a restart", strings.Join(changed, ", "))
+	}
+
+	next, err := buildRouting(config, r.lim, r.sem, prev)
+	if err != nil {
+		return nil, err
+	}
+	state, err := r.srv.newState(
+		r.srv.getState(),
+		next.backendGroups,
+		next.wsBackendGroup,
+		NewStringSetFromStrings(config.WSMethodWhitelist),
+		config.RPCMethodMappings,
+		next.authentication,
+		config.RateLimit,
+		config.SenderRateLimit,
+		next.txPolicy,
+	)
+	if err != nil {
+		return nil, err
+	}
+	// This is the last check, as it applies the routing to the tenants if
+	// they are valid.
+	if r.tenants != nil {
+		if err := r.tenants.UpdateRouting(next.backendGroups, config.RPCMethodMappings); err != nil {
+			return nil, err
+		}
+	}
+
+	for name, bg := range next.backendGroups {
+		if prev.backendGroups[name] != bg && bg.Consensus != nil {
+			bg.Consensus.Start()
+		}
+	}
+	// The subscriptions of the clients outlive the ws backend group.
+	if prev.wsBackendGroup != nil && prev.wsBackendGroup.Subscriptions != nil && next.wsBackendGroup != prev.wsBackendGroup {
+		next.wsBackendGroup.Subscriptions = prev.wsBackendGroup.Subscriptions
+		next.wsBackendGroup.Subscriptions.SetGroup(next.wsBackendGroup)
+	}
+
+	r.srv.setState(state)
+	r.routing = next
+
+	for name, bg := range prev.backendGroups {
+		if next.backendGroups[name] != bg && bg.Consensus != nil {
+			bg.Consensus.Stop()
+		}
+	}
+	for name, backend := range prev.backends {
+		if next.backends[name] != backend {
+			go func(backend *Backend) {
+				ctx, cancel := context.WithTimeout(context.Background(), r.drainTimeout)
+				defer cancel()
+				backend.Drain(ctx)
+			}(backend)
+		}
+	}
+	for name := range next.backends {
+		r.backendNames[name] = true
+	}
+
+	return diffRouting(prev, next), nil
+}
+
+// Stop stops the background work of the backend groups. No reload can
+// happen while or after it runs.
+func (r *ConfigReloader) Stop() {
+	r.mtx.Lock()
+	r.loader = nil
+	for _, bg := range r.routing.backendGroups {
+		if bg.Consensus != nil {
+			bg.Consensus.Stop()
+		}
+		if bg.Subscriptions != nil {
+			bg.Subscriptions.Stop()
+		}
+	}
+	r.mtx.Unlock()
+}
+
+// BackendNames returns the names of the backends of every config applied so far.
+func (r *ConfigReloader) BackendNames() []string {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	names := make([]string, 0, len(r.backendNames))
+	for name := range r.backendNames {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func diffRouting(prev *routing, next *routing) *ReloadResult {
+	res := &ReloadResult{
+		Backends:      newConfigChanges(),
+		BackendGroups: newConfigChanges(),
+	}
+	for name, backend := range next.backends {
+		res.Backends.record(name, prev.backends[name] != nil, true, prev.backends[name] == backend)
+	}
+	for name := range prev.backends {
+		if next.backends[name] == nil {
+			res.Backends.record(name, true, false, false)
+		}
+	}
+	for name, bg := range next.backendGroups {
+		res.BackendGroups.record(name, prev.backendGroups[name] != nil, true, prev.backendGroups[name] == bg)
+	}
+	for name := range prev.backendGroups {
+		if next.backendGroups[name] == nil {
+			res.BackendGroups.record(name, true, false, false)
+		}
+	}
+	res.Backends.sort()
+	res.BackendGroups.sort()
+	return res
+}
+
+// restartRequiredChanges returns the config sections that differ between
+// the configs but can only be applied by restarting proxyd.
+func restartRequiredChanges(prev *Config, next *Config) []string {
+	changed := make([]string, 0)
+	check := func(name string, prevValue interface{}, nextValue interface{}) {
+		if !reflect.DeepEqual(prevValue, nextValue) {
+			changed = append(changed, name)
+		}
+	}
+	check("server", prev.Server, next.Server)
+	check("cache", prev.Cache, next.Cache)
+	check("redis", prev.Redis, next.Redis)
+	check("metrics", prev.Metrics, next.Metrics)
+	check("batch", prev.BatchConfig, next.BatchConfig)
+	check("get_logs", prev.GetLogs, next.GetLogs)
+	check("tenants", prev.Tenants, next.Tenants)
+	check("admin", prev.Admin, next.Admin)
+	check("access_log", prev.AccessLog, next.AccessLog)
+	check("tracing", prev.Tracing, next.Tracing)
+	check("ws_subscriptions", prev.WSSubscriptions, next.WSSubscriptions)
+	check("whitelist_error_message", prev.WhitelistErrorMessage, next.WhitelistErrorMessage)
+	check("rate_limit.use_redis", prev.RateLimit.UseRedis, next.RateLimit.UseRedis)
+	check("rate_limit.enable_backend_rate_limiter", prev.RateLimit.EnableBackendRateLimiter, next.RateLimit.EnableBackendRateLimiter)
+	check("rate_limit.error_message", prev.RateLimit.ErrorMessage, next.RateLimit.ErrorMessage)
+	// The multiplexed subscriptions are bound to the ws backend group.
+	if prev.WSSubscriptions.Multiplex {
+		check("ws_backend_group", prev.WSBackendGroup, next.WSBackendGroup)
+	}
+	return changed
+}
@@ -0,0 +1,155 @@
+package proxyd
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sync/semaphore"
+)
+
+func newRoutingTestConfig() *Config {
+	return &Config{
+		Backends: BackendsConfig{
+			"a": {RPCURL: "http://a", WSURL: "ws://a"},
+			"b": {RPCURL: "http://b", WSURL: "ws://b"},
+		},
+		BackendGroups: BackendGroupsConfig{
+			"main":  {Backends: []string{"a"}},
+			"other": {Backends: []string{"b"}},
+		},
+		RPCMethodMappings: map[string]string{
+			"eth_chainId": "main",
+			"eth_call":    "other",
+		},
+	}
+}
+
+func TestBuildRoutingReuse(t *testing.T) {
+	sem := semaphore.NewWeighted(1)
+	prev, err := buildRouting(newRoutingTestConfig(), noopBackendRateLimiter, sem, nil)
+	require.NoError(t, err)
+
+	newConfig := func() *Config {
+		config := newRoutingTestConfig()
+		config.Backends["b"] = &BackendConfig{RPCURL: "http://b2", WSURL: "ws://b2"}
+		config.Backends["c"] = &BackendConfig{RPCURL: "http://c", WSURL: "ws://c"}
+		delete(config.Backends, "a")
+		config.BackendGroups["main"] = &BackendGroupConfig{Backends: []string{"c"}}
+		config.BackendGroups["third"] = &BackendGroupConfig{Backends: []string{"c"}}
+		return config
+	}
+	next, err := buildRouting(newConfig(), noopBackendRateLimiter, sem, prev)
+	require.NoError(t, err)
+	require.NotSame(t, prev.backends["b"], next.backends["b"])
+	require.NotSame(t, prev.backendGroups["other"], next.backendGroups["other"])
+
+	res := diffRouting(prev, next)
+	require.Equal(t, []string{"c"}, res.Backends.Added)
+	require.Equal(t, []string{"a"}, res.Backends.Removed)
+	require.Equal(t, []string{"b"}, res.Backends.Updated)
+	require.Equal(t, []string{"third"}, res.BackendGroups.Added)
+	require.Equal(t, []string{}, res.BackendGroups.Removed)
+	require.Equal(t, []string{"main", "other"}, res.BackendGroups.Updated)
+
+	// Nothing is rebuilt if the config didn't change.
+	again, err := buildRouting(newConfig(), noopBackendRateLimiter, sem, next)
+	require.NoError(t, err)
+	for name, backend := range next.backends {
+		require.Same(t, backend, again.backends[name])
+	}
+	for name, group := range next.backendGroups {
+		require.Same(t, group, again.backendGroups[name])
+	}
+	res = diffRouting(next, again)
+	require.Empty(t, res.Backends.Added)
+	require.Empty(t, res.Backends.Updated)
+	require.Empty(t, res.BackendGroups.Updated)
+
+	// Changing the shared backend options rebuilds every backend.
+	config := newConfig()
+	config.BackendOptions.MaxRetries = 5
+	again, err = buildRouting(config, noopBackendRateLimiter, sem, next)
+	require.NoError(t, err)
+	require.Equal(t, []string{"b", "c"}, diffRouting(next, again).Backends.Updated)
+
+	config = newConfig()
+	config.RPCMethodMappings["eth_call"] = "missing"
+	_, err = buildRouting(config, noopBackendRateLimiter, sem, next)
+	require.Error(t, err)
+}
+
+func TestRestartRequiredChanges(t *testing.T) {
+	prev := newRoutingTestConfig()
+	next := newRoutingTestConfig()
+	next.Backends["c"] = &BackendConfig{RPCURL: "http://c", WSURL: "ws://c"}
+	next.RateLimit.BaseRate = 10
+	next.WSBackendGroup = "main"
+	require.Empty(t, restartRequiredChanges(prev, next))
+
+	next.Server.RPCPort = 8545
+	next.RateLimit.ErrorMessage = "slow down"
+	require.Equal(t, []string{"server", "rate_limit.error_message"}, restartRequiredChanges(prev, next))
+
+	prev = newRoutingTestConfig()
+	prev.WSSubscriptions.Multiplex = true
+	next = newRoutingTestConfig()
+	next.WSSubscriptions.Multiplex = true
+	next.WSBackendGroup = "other"
+	require.Equal(t, []string{"ws_backend_group"}, restartRequiredChanges(prev, next))
+}
+
+func TestNewStateReusesLimiters(t *testing.T) {
+	srv := &Server{}
+	rateLimit := func() RateLimitConfig {
+		return RateLimitConfig{
+			BaseRate:     1,
+			BaseInterval: TOMLDuration(time.Minute),
+			MethodOverrides: map[string]*RateLimitMethodOverride{
+				"eth_call":    {Limit: 1, Interval: TOMLDuration(time.Minute)},
+				"eth_getLogs": {Limit: 1, Interval: TOMLDuration(time.Minute)},
+			},
+		}
+	}
+	senderRateLimit := SenderRateLimitConfig{Enabled: true, Interval: TOMLDuration(time.Minute), Limit: 1}
+	newState := func(prev *serverState, rateLimit RateLimitConfig, senderRateLimit SenderRateLimitConfig) *serverState {
+		state, err := srv.newState(prev, nil, nil, nil, nil, nil, rateLimit, senderRateLimit, nil)
+		require.NoError(t, err)
+		return state
+	}
+	prev := newState(nil, rateLimit(), senderRateLimit)
+
+	ctx := context.Background()
+	take := func(lim FrontendRateLimiter) bool {
+		ok, err := lim.Take(ctx, "1.2.3.4")
+		require.NoError(t, err)
+		return ok
+	}
+	require.True(t, take(prev.mainLim))
+	require.True(t, take(prev.overrideLims["eth_call"]))
+	require.True(t, take(prev.overrideLims["eth_getLogs"]))
+	require.True(t, take(prev.senderLim))
+
+	// The counters of the clients survive a reload that doesn't change the limits.
+	next := newState(prev, rateLimit(), senderRateLimit)
+	require.Same(t, prev.mainLim, next.mainLim)
+	require.False(t, take(next.mainLim))
+	require.False(t, take(next.overrideLims["eth_call"]))
+	require.False(t, take(next.senderLim))
+
+	// Only the limiters whose config changed are reset.
+	config := rateLimit()
+	config.MethodOverrides["eth_getLogs"].Limit = 2
+	config.ExemptOrigins = []string{"example.com"}
+	again := newState(next, config, SenderRateLimitConfig{Enabled: true, Interval: TOMLDuration(time.Minute), Limit: 2})
+	require.Same(t, next.mainLim, again.mainLim)
+	require.Same(t, next.overrideLims["eth_call"], again.overrideLims["eth_call"])
+	require.NotSame(t, next.overrideLims["eth_getLogs"], again.overrideLims["eth_getLogs"])
+	require.True(t, take(again.overrideLims["eth_getLogs"]))
+	require.True(t, take(again.senderLim))
+	require.Len(t, again.limExemptOrigins, 1)
+
+	config.BaseInterval = TOMLDuration(time.Hour)
+	require.NotSame(t, again.mainLim, newState(again, config, senderRateLimit).mainLim)
+}
@@ -0,0 +1,196 @@
+package proxyd
+
+import (
+	"context"
+	"math"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// GetBlockHashFn returns the hash of the canonical block at a height.
+type GetBlockHashFn func(ctx context.Context, blockNum uint64) (common.Hash, error)
+
+// ReorgTracker follows the chain head seen by the block sync poller. It remembers the cache keys of the
+// responses for blocks that don't have numBlockConfirmations confirmations yet, and deletes them from the
+// cache when a reorg removes their block from the canonical chain.
+type ReorgTracker struct {
+	cache                 Cache
+	numBlockConfirmations uint64
+	getBlockHashFn        GetBlockHashFn
+
+	// observeMtx serializes the calls to Observe, mtx guards the tracked blocks.
+	observeMtx sync.Mutex
+	mtx        sync.Mutex
+	head       uint64
+	hashes     map[uint64]common.Hash
+	keys       map[uint64][]string
+}
+
+func NewReorgTracker(cache Cache, numBlockConfirmations int, getBlockHashFn GetBlockHashFn) *ReorgTracker {
+	return &ReorgTracker{
+		cache:                 cache,
+		numBlockConfirmations: uint64(numBlockConfirmations),
+		getBlockHashFn:        getBlockHashFn,
+		hashes:                make(map[uint64]common.Hash),
+		keys:                  make(map[uint64][]string),
+	}
+}
+
+// Track remembers that the cached response at key depends on the block blockNum with the given hash.
+// It returns false if the block is already known to be reorged out, in which case the response must
+// not be cached.
+func (r *ReorgTracker) Track(blockNum uint64, blockHash common.Hash, key string) bool {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if known, ok := r.hashes[blockNum]; ok && known != blockHash {
+		return false
+	}
+	if blockNum+r.numBlockConfirmations < r.head {
+		// The block is confirmed already
+		return true
+	}
+	r.keys[blockNum] = append(r.keys[blockNum], key)
+	return true
+}
+
+// Observe records a new chain head, and invalidates the cached responses for the blocks that were
+// reorged out since the previous one. The canonical chain is fetched and the cache entries are deleted
+// without holding the lock taken by Track, so that slow backends don't stall the cached requests.
+func (r *ReorgTracker) Observe(ctx context.Context, header *types.Header) {
+	r.observeMtx.Lock()
+	defer r.observeMtx.Unlock()
+
+	number := header.Number.Uint64()
+	hash := header.Hash()
+
+	r.mtx.Lock()
+	head := r.head
+	hashes := make(map[uint64]common.Hash, len(r.hashes))
+	for blockNum, blockHash := range r.hashes {
+		hashes[blockNum] = blockHash
+	}
+	r.mtx.Unlock()
+
+	reorgFrom := uint64(math.MaxUint64)
+	if known, ok := hashes[number]; ok && known != hash {
+		reorgFrom = number
+	}
+	// The blocks above the new head are gone
+	if number < head && number+1 < reorgFrom {
+		reorgFrom = number + 1
+	}
+	// Check that the new head builds on the highest block seen below it
+	if prev, ok := highestBelow(hashes, number); ok {
+		var prevHash common.Hash
+		if prev == number-1 {
+			prevHash = header.ParentHash
+		} else {
+			var err error
+			prevHash, err = r.getBlockHashFn(ctx, prev)
+			if err != nil {
+				log.Warn("error getting block hash to detect reorgs", "block", prev, "err", err)
+				prevHash = hashes[prev]
+			}
+		}
+		if prevHash != hashes[prev] {
+			if forkPoint := r.findForkPoint(ctx, hashes, prev); forkPoint < reorgFrom {
+				reorgFrom = forkPoint
+			}
+		}
+	}
+
+	r.mtx.Lock()
+	var invalidated []string
+	if reorgFrom != math.MaxUint64 {
+		invalidated = r.forget(reorgFrom)
+	}
+	r.head = number
+	r.hashes[number] = hash
+	if number > 0 {
+		r.hashes[number-1] = header.ParentHash
+	}
+	for blockNum := range r.hashes {
+		if blockNum+r.numBlockConfirmations < number {
+			delete(r.hashes, blockNum)
+		}
+	}
+	for blockNum := range r.keys {
+		if blockNum+r.numBlockConfirmations < number {
+			delete(r.keys, blockNum)
+		}
+	}
+	r.mtx.Unlock()
+
+	if reorgFrom != math.MaxUint64 {
+		r.invalidate(ctx, invalidated, reorgFrom, number)
+	}
+}
+
+// findForkPoint returns the lowest block that was reorged out, walking back from block until the
+// recorded hash matches the canonical chain again. If the canonical chain can't be fetched, it
+// conservatively returns the lowest recorded block.
+func (r *ReorgTracker) findForkPoint(ctx context.Context, hashes map[uint64]common.Hash, block uint64) uint64 {
+	forkPoint := block
+	for {
+		prev, ok := highestBelow(hashes, forkPoint)
+		if !ok {
+			return forkPoint
+		}
+		canonical, err := r.getBlockHashFn(ctx, prev)
+		if err != nil {
+			log.Warn("error getting block hash to find reorg depth", "block", prev, "err", err)
+			forkPoint = prev
+			continue
+		}
+		if canonical == hashes[prev] {
+			return forkPoint
+		}
+		forkPoint = prev
+	}
+}
+
+func highestBelow(hashes map[uint64]common.Hash, number uint64) (uint64, bool) {
+	var highest uint64
+	var found bool
+	for blockNum := range hashes {
+		if blockNum < number && (!found || blockNum > highest) {
+			highest = blockNum
+			found = true
+		}
+	}
+	return highest, found
+}
+
+// forget stops tracking the blocks from reorgFrom on, and returns the cache keys of their responses.
+// It must be called with the lock held.
+func (r *ReorgTracker) forget(reorgFrom uint64) []string {
+	var keys []string
+	for blockNum, blockKeys := range r.keys {
+		if blockNum >= reorgFrom {
+			keys = append(keys, blockKeys...)
+			delete(r.keys, blockNum)
+		}
+	}
+	for blockNum := range r.hashes {
+		if blockNum >= reorgFrom {
+			delete(r.hashes, blockNum)
+		}
+	}
+	return keys
+}
+
+// invalidate deletes the cached responses of the blocks that were reorged out.
+func (r *ReorgTracker) invalidate(ctx context.Context, keys []string, reorgFrom uint64, head uint64) {
+	for _, key := range keys {
+		if err := r.cache.Delete(ctx, key); err != nil {
+			log.Error("error invalidating reorged cache entry", "key", key, "err", err)
+		}
+	}
+
+	log.Warn("reorg detected, invalidated cache entries", "reorg_from", reorgFrom, "head", head, "invalidated", len(keys))
+	RecordCacheReorg(len(keys))
+}
@@ -0,0 +1,131 @@
+package proxyd
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/require"
+)
+
+// testChain builds headers whose hashes depend on the fork they are on.
+type testChain struct {
+	canonical map[uint64]*types.Header
+}
+
+func newTestChain() *testChain {
+	return &testChain{canonical: make(map[uint64]*types.Header)}
+}
+
+// extend builds the canonical chain from block from to block to on the given fork.
+func (c *testChain) extend(from, to uint64, fork byte) *types.Header {
+	var head *types.Header
+	for n := from; n <= to; n++ {
+		header := &types.Header{
+			Number: new(big.Int).SetUint64(n),
+			Extra:  []byte{fork},
+		}
+		if parent, ok := c.canonical[n-1]; ok {
+			header.ParentHash = parent.Hash()
+		}
+		c.canonical[n] = header
+		head = header
+	}
+	return head
+}
+
+func (c *testChain) getBlockHash(ctx context.Context, blockNum uint64) (common.Hash, error) {
+	return c.canonical[blockNum].Hash(), nil
+}
+
+func TestReorgTracker(t *testing.T) {
+	ctx := context.Background()
+	cache := newMemoryCache()
+	chain := newTestChain()
+	reorgs := NewReorgTracker(cache, 10, chain.getBlockHash)
+
+	put := func(blockNum uint64, key string) {
+		require.NoError(t, cache.Put(ctx, key, "value"))
+		require.True(t, reorgs.Track(blockNum, chain.canonical[blockNum].Hash(), key))
+	}
+	requireCached := func(key string, cached bool) {
+		val, err := cache.Get(ctx, key)
+		require.NoError(t, err)
+		if cached {
+			require.Equal(t, "value", val)
+		} else {
+			require.Empty(t, val)
+		}
+	}
+
+	for n := uint64(1); n <= 100; n++ {
+		reorgs.Observe(ctx, chain.extend(n, n, 'a'))
+	}
+	put(95, "a95")
+	put(98, "a98")
+	put(100, "a100")
+
+	// Blocks skipped by the poller are still checked
+	reorgs.Observe(ctx, chain.extend(101, 103, 'a'))
+	requireCached("a95", true)
+	requireCached("a98", true)
+	requireCached("a100", true)
+
+	// Reorg of the blocks from 98, with the new head building on the new fork
+	staleHash := chain.canonical[103].Hash()
+	chain.extend(98, 104, 'b')
+	reorgs.Observe(ctx, chain.canonical[104])
+	requireCached("a95", true)
+	requireCached("a98", false)
+	requireCached("a100", false)
+
+	// Responses from the stale fork are not tracked
+	require.False(t, reorgs.Track(103, staleHash, "a103"))
+
+	// The head goes back
+	put(104, "b104")
+	chain.extend(103, 103, 'c')
+	delete(chain.canonical, 104)
+	reorgs.Observe(ctx, chain.canonical[103])
+	requireCached("a95", true)
+	requireCached("b104", false)
+}
+
+func TestReorgTrackerTrackDuringObserve(t *testing.T) {
+	ctx := context.Background()
+	chain := newTestChain()
+	called := make(chan struct{})
+	release := make(chan struct{})
+	reorgs := NewReorgTracker(newMemoryCache(), 10, func(ctx context.Context, blockNum uint64) (common.Hash, error) {
+		close(called)
+		<-release
+		return chain.getBlockHash(ctx, blockNum)
+	})
+	reorgs.Observe(ctx, chain.extend(0, 1, 0))
+
+	// The new head doesn't build on the last one, so its ancestor is fetched.
+	head := chain.extend(2, 5, 0)
+	done := make(chan struct{})
+	go func() {
+		reorgs.Observe(ctx, head)
+		close(done)
+	}()
+	<-called
+
+	// Responses are tracked while the backend is slow.
+	tracked := make(chan bool)
+	go func() {
+		tracked <- reorgs.Track(1, chain.canonical[1].Hash(), "key")
+	}()
+	select {
+	case ok := <-tracked:
+		require.True(t, ok)
+	case <-time.After(time.Second):
+		t.Fatal("Track blocked on the block hash lookup")
+	}
+	close(release)
+	<-done
+}
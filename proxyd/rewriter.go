@@ -0,0 +1,177 @@
+package proxyd
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+var (
+	ErrRewriteBlockOutOfRange = &RPCErr{
+		Code:          JSONRPCErrorInternal - 18,
+		Message:       "block is out of range",
+		HTTPErrorCode: 400,
+	}
+
+	errRewriteInvalidParams = errors.New("invalid params")
+)
+
+// blockParamPositions maps the methods that take a block tag or number to the position of that parameter.
+var blockParamPositions = map[string]int{
+	"eth_getBalance":                          1,
+	"eth_getCode":                             1,
+	"eth_getTransactionCount":                 1,
+	"eth_call":                                1,
+	"eth_getStorageAt":                        2,
+	"eth_getProof":                            2,
+	"eth_getBlockByNumber":                    0,
+	"eth_getBlockTransactionCountByNumber":    0,
+	"eth_getUncleCountByBlockNumber":          0,
+	"eth_getTransactionByBlockNumberAndIndex": 0,
+	"eth_getUncleByBlockNumberAndIndex":       0,
+}
+
+// RewriteRequest returns a copy of the request with the latest, safe and finalized block tags replaced
+// by the consensus block numbers, so every backend of the consensus group answers the same way.
+// Block numbers past the consensus latest block are rejected with ErrRewriteBlockOutOfRange.
+// Requests that don't need to be rewritten are returned as is.
+func RewriteRequest(consensus ConsensusBlocks, req *RPCReq) (*RPCReq, error) {
+	if req.Method == "eth_getLogs" {
+		return rewriteGetLogs(consensus, req)
+	}
+
+	pos, ok := blockParamPositions[req.Method]
+	if !ok {
+		return req, nil
+	}
+
+	var params []json.RawMessage
+	if len(req.Params) > 0 {
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return req, nil
+		}
+	}
+	// The block parameter is optional and defaults to latest
+	if len(params) == pos {
+		params = append(params, json.RawMessage(`"latest"`))
+	} else if len(params) < pos {
+		return req, nil
+	}
+
+	rewritten, changed, err := rewriteBlockParam(consensus, params[pos])
+	if err != nil {
+		return nil, err
+	}
+	if !changed {
+		return req, nil
+	}
+	params[pos] = rewritten
+	return withParams(req, params)
+}
+
+// RewriteResponse replaces the result of eth_blockNumber with the consensus latest block.
+func RewriteResponse(consensus ConsensusBlocks, req *RPCReq, res *RPCRes) {
+	if req.Method != "eth_blockNumber" || res.IsError() {
+		return
+	}
+	res.Result = consensus.Latest.String()
+}
+
+// rewriteBlockParam rewrites a block tag or number, or the blockNumber field of an EIP-1898 block parameter.
+func rewriteBlockParam(consensus ConsensusBlocks, param json.RawMessage) (json.RawMessage, bool, error) {
+	var tag string
+	if err := json.Unmarshal(param, &tag); err == nil {
+		rewritten, changed, err := rewriteTag(consensus, tag)
+		if err != nil || !changed {
+			return param, false, err
+		}
+		return mustMarshalJSON(rewritten), true, nil
+	}
+
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(param, &obj); err != nil {
+		return param, false, nil
+	}
+	blockNumber, ok := obj["blockNumber"]
+	if !ok {
+		// blockHash parameters are the same on every backend
+		return param, false, nil
+	}
+	rewritten, changed, err := rewriteBlockParam(consensus, blockNumber)
+	if err != nil || !changed {
+		return param, false, err
+	}
+	obj["blockNumber"] = rewritten
+	return mustMarshalJSON(obj), true, nil
+}
+
+func rewriteTag(consensus ConsensusBlocks, tag string) (string, bool, error) {
+	switch tag {
+	case "latest":
+		return consensus.Latest.String(), true, nil
+	case "safe":
+		// Leave the tag as is if the backends don't support it
+		if consensus.Safe == 0 {
+			return tag, false, nil
+		}
+		return consensus.Safe.String(), true, nil
+	case "finalized":
+		if consensus.Finalized == 0 {
+			return tag, false, nil
+		}
+		return consensus.Finalized.String(), true, nil
+	case "pending", "earliest":
+		return tag, false, nil
+	}
+
+	blockNum, err := hexutil.DecodeUint64(tag)
+	if err != nil {
+		// Let the backend respond with the appropriate error
+		return tag, false, nil
+	}
+	if blockNum > uint64(consensus.Latest) {
+		return tag, false, ErrRewriteBlockOutOfRange
+	}
+	return tag, false, nil
+}
+
+func rewriteGetLogs(consensus ConsensusBlocks, req *RPCReq) (*RPCReq, error) {
+	var params []map[string]json.RawMessage
+	if err := json.Unmarshal(req.Params, &params); err != nil || len(params) != 1 {
+		return req, nil
+	}
+	filter := params[0]
+	if _, ok := filter["blockHash"]; ok {
+		return req, nil
+	}
+
+	for _, field := range []string{"fromBlock", "toBlock"} {
+		param, ok := filter[field]
+		if !ok {
+			// Both fields default to latest
+			param = json.RawMessage(`"latest"`)
+		}
+		rewritten, changed, err := rewriteBlockParam(consensus, param)
+		if err != nil {
+			return nil, err
+		}
+		if changed {
+			filter[field] = rewritten
+		}
+	}
+	return withParams(req, []interface{}{filter})
+}
+
+func withParams(req *RPCReq, params interface{}) (*RPCReq, error) {
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return nil, errRewriteInvalidParams
+	}
+	return &RPCReq{
+		JSONRPC: req.JSONRPC,
+		Method:  req.Method,
+		Params:  raw,
+		ID:      req.ID,
+	}, nil
+}
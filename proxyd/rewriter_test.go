@@ -0,0 +1,81 @@
+package proxyd
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRewriteRequest(t *testing.T) {
+	consensus := ConsensusBlocks{
+		Latest:    0x100,
+		Safe:      0xf0,
+		Finalized: 0xe0,
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		params string
+		out    string
+		err    error
+	}{
+		{"latest tag", "eth_getBalance", `["0x01","latest"]`, `["0x01","0x100"]`, nil},
+		{"missing block param", "eth_getBalance", `["0x01"]`, `["0x01","0x100"]`, nil},
+		{"safe tag", "eth_getStorageAt", `["0x01","0x0","safe"]`, `["0x01","0x0","0xf0"]`, nil},
+		{"finalized tag", "eth_getBlockByNumber", `["finalized",false]`, `["0xe0",false]`, nil},
+		{"pending tag", "eth_call", `[{},"pending"]`, `[{},"pending"]`, nil},
+		{"earliest tag", "eth_getCode", `["0x01","earliest"]`, `["0x01","earliest"]`, nil},
+		{"block number", "eth_getCode", `["0x01","0x50"]`, `["0x01","0x50"]`, nil},
+		{"block number out of range", "eth_getCode", `["0x01","0x101"]`, "", ErrRewriteBlockOutOfRange},
+		{"eip-1898 block number", "eth_call", `[{},{"blockNumber":"latest"}]`, `[{},{"blockNumber":"0x100"}]`, nil},
+		{"eip-1898 block hash", "eth_call", `[{},{"blockHash":"0x01"}]`, `[{},{"blockHash":"0x01"}]`, nil},
+		{"get logs", "eth_getLogs", `[{"fromBlock":"0x50"}]`, `[{"fromBlock":"0x50","toBlock":"0x100"}]`, nil},
+		{"get logs out of range", "eth_getLogs", `[{"fromBlock":"0x50","toBlock":"0x200"}]`, "", ErrRewriteBlockOutOfRange},
+		{"get logs block hash", "eth_getLogs", `[{"blockHash":"0x01"}]`, `[{"blockHash":"0x01"}]`, nil},
+		{"other method", "eth_chainId", `[]`, `[]`, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &RPCReq{
+				JSONRPC: JSONRPCVersion,
+				Method:  tt.method,
+				Params:  json.RawMessage(tt.params),
+				ID:      []byte("1"),
+			}
+			out, err := RewriteRequest(consensus, req)
+			if tt.err != nil {
+				require.Equal(t, tt.err, err)
+				return
+			}
+			require.NoError(t, err)
+			require.JSONEq(t, tt.out, string(out.Params))
+			require.Equal(t, tt.params, string(req.Params))
+		})
+	}
+}
+
+func TestRewriteRequestNoSafeBlock(t *testing.T) {
+	req := &RPCReq{
+		JSONRPC: JSONRPCVersion,
+		Method:  "eth_getBlockByNumber",
+		Params:  json.RawMessage(`["safe",false]`),
+		ID:      []byte("1"),
+	}
+	out, err := RewriteRequest(ConsensusBlocks{Latest: 0x100}, req)
+	require.NoError(t, err)
+	require.JSONEq(t, `["safe",false]`, string(out.Params))
+}
+
+func TestRewriteResponse(t *testing.T) {
+	consensus := ConsensusBlocks{Latest: 0x100}
+	req := &RPCReq{Method: "eth_blockNumber"}
+	res := &RPCRes{Result: "0x105"}
+	RewriteResponse(consensus, req, res)
+	require.Equal(t, "0x100", res.Result)
+
+	res = &RPCRes{Error: ErrNoBackends}
+	RewriteResponse(consensus, req, res)
+	require.Nil(t, res.Result)
+}
@@ -14,7 +14,7 @@ import (
 	"sync"
 	"time"
 
-	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 
 	"github.com/ethereum/go-ethereum/log"
@@ -39,28 +39,43 @@ const (
 var emptyArrayResponse = json.RawMessage("[]")
 
 type Server struct {
+	state                *serverState
+	stateMu              sync.RWMutex
+	maxBodySize          int64
+	enableRequestLog     bool
+	maxRequestBodyLogLen int
+	timeout              time.Duration
+	maxUpstreamBatchSize int
+	maxBatchSize         int
+	upgrader             *websocket.Upgrader
+	redisClient          *redis.Client
+	rpcServer            *http.Server
+	wsServer             *http.Server
+	cache                RPCCache
+	getLogsGuard         *GetLogsGuard
+	tenants              *TenantRegistry
+	tracer               *Tracer
+	srvMu                sync.Mutex
+}
+
+// serverState holds the routing and rate limits of the server. It is
+// replaced as a whole when the config is reloaded, so each request sees
+// either the old or the new config.
+type serverState struct {
 	backendGroups          map[string]*BackendGroup
 	wsBackendGroup         *BackendGroup
 	wsMethodWhitelist      *StringSet
 	rpcMethodMappings      map[string]string
-	maxBodySize            int64
-	enableRequestLog       bool
-	maxRequestBodyLogLen   int
 	authenticatedPaths     map[string]string
-	timeout                time.Duration
-	maxUpstreamBatchSize   int
-	maxBatchSize           int
-	upgrader               *websocket.Upgrader
+	rateLimitConfig        RateLimitConfig
+	senderRateLimitConfig  SenderRateLimitConfig
 	mainLim                FrontendRateLimiter
 	overrideLims           map[string]FrontendRateLimiter
 	senderLim              FrontendRateLimiter
 	limExemptOrigins       []*regexp.Regexp
 	limExemptUserAgents    []*regexp.Regexp
 	globallyLimitedMethods map[string]bool
-	rpcServer              *http.Server
-	wsServer               *http.Server
-	cache                  RPCCache
-	srvMu                  sync.Mutex
+	txPolicy               *TxPolicy
 }
 
 type limiterFunc func(method string) bool
@@ -81,6 +96,10 @@ func NewServer(
 	maxRequestBodyLogLen int,
 	maxBatchSize int,
 	redisClient *redis.Client,
+	getLogsGuard *GetLogsGuard,
+	tenants *TenantRegistry,
+	txPolicy *TxPolicy,
+	tracer *Tracer,
 ) (*Server, error) {
 	if cache == nil {
 		cache = &NoopRPCCache{}
@@ -102,19 +121,78 @@ func NewServer(
 		maxBatchSize = MaxBatchRPCCallsHardLimit
 	}
 
+	srv := &Server{
+		maxBodySize:          maxBodySize,
+		timeout:              timeout,
+		maxUpstreamBatchSize: maxUpstreamBatchSize,
+		cache:                cache,
+		getLogsGuard:         getLogsGuard,
+		tenants:              tenants,
+		tracer:               tracer,
+		enableRequestLog:     enableRequestLog,
+		maxRequestBodyLogLen: maxRequestBodyLogLen,
+		maxBatchSize:         maxBatchSize,
+		upgrader: &websocket.Upgrader{
+			HandshakeTimeout: 5 * time.Second,
+		},
+		redisClient: redisClient,
+	}
+	state, err := srv.newState(
+		nil,
+		backendGroups,
+		wsBackendGroup,
+		wsMethodWhitelist,
+		rpcMethodMappings,
+		authenticatedPaths,
+		rateLimitConfig,
+		senderRateLimitConfig,
+		txPolicy,
+	)
+	if err != nil {
+		return nil, err
+	}
+	srv.setState(state)
+	return srv, nil
+}
+
+// newState builds the routing and rate limits of the server without
+// applying them. The limiters of prev whose config is unchanged are reused,
+// so that reloading the config doesn't reset the counters of the clients.
+// prev is nil when the server is created.
+func (s *Server) newState(
+	prev *serverState,
+	backendGroups map[string]*BackendGroup,
+	wsBackendGroup *BackendGroup,
+	wsMethodWhitelist *StringSet,
+	rpcMethodMappings map[string]string,
+	authenticatedPaths map[string]string,
+	rateLimitConfig RateLimitConfig,
+	senderRateLimitConfig SenderRateLimitConfig,
+	txPolicy *TxPolicy,
+) (*serverState, error) {
 	limiterFactory := func(dur time.Duration, max int, prefix string) FrontendRateLimiter {
 		if rateLimitConfig.UseRedis {
-			return NewRedisFrontendRateLimiter(redisClient, dur, max, prefix)
+			return NewRedisFrontendRateLimiter(s.redisClient, dur, max, prefix)
 		}
 
 		return NewMemoryFrontendRateLimit(dur, max)
 	}
+	// Limiters can only be reused if they are stored in the same place.
+	if prev != nil && prev.rateLimitConfig.UseRedis != rateLimitConfig.UseRedis {
+		prev = nil
+	}
 
 	var mainLim FrontendRateLimiter
 	limExemptOrigins := make([]*regexp.Regexp, 0)
 	limExemptUserAgents := make([]*regexp.Regexp, 0)
 	if rateLimitConfig.BaseRate > 0 {
-		mainLim = limiterFactory(time.Duration(rateLimitConfig.BaseInterval), rateLimitConfig.BaseRate, "main")
+		if prev != nil &&
+			prev.rateLimitConfig.BaseRate == rateLimitConfig.BaseRate &&
+			prev.rateLimitConfig.BaseInterval == rateLimitConfig.BaseInterval {
+			mainLim = prev.mainLim
+		} else {
+			mainLim = limiterFactory(time.Duration(rateLimitConfig.BaseInterval), rateLimitConfig.BaseRate, "main")
+		}
 		for _, origin := range rateLimitConfig.ExemptOrigins {
 			pattern, err := regexp.Compile(origin)
 			if err != nil {
@@ -136,10 +214,10 @@ func NewServer(
 	overrideLims := make(map[string]FrontendRateLimiter)
 	globalMethodLims := make(map[string]bool)
 	for method, override := range rateLimitConfig.MethodOverrides {
-		var err error
-		overrideLims[method] = limiterFactory(time.Duration(override.Interval), override.Limit, method)
-		if err != nil {
-			return nil, err
+		if prev != nil && prev.overrideLims[method] != nil && sameMethodOverride(prev.rateLimitConfig.MethodOverrides[method], override) {
+			overrideLims[method] = prev.overrideLims[method]
+		} else {
+			overrideLims[method] = limiterFactory(time.Duration(override.Interval), override.Limit, method)
 		}
 
 		if override.Global {
@@ -148,34 +226,50 @@ func NewServer(
 	}
 	var senderLim FrontendRateLimiter
 	if senderRateLimitConfig.Enabled {
-		senderLim = limiterFactory(time.Duration(senderRateLimitConfig.Interval), senderRateLimitConfig.Limit, "senders")
+		if prev != nil && prev.senderLim != nil &&
+			prev.senderRateLimitConfig.Interval == senderRateLimitConfig.Interval &&
+			prev.senderRateLimitConfig.Limit == senderRateLimitConfig.Limit {
+			senderLim = prev.senderLim
+		} else {
+			senderLim = limiterFactory(time.Duration(senderRateLimitConfig.Interval), senderRateLimitConfig.Limit, "senders")
+		}
 	}
 
-	return &Server{
-		backendGroups:        backendGroups,
-		wsBackendGroup:       wsBackendGroup,
-		wsMethodWhitelist:    wsMethodWhitelist,
-		rpcMethodMappings:    rpcMethodMappings,
-		maxBodySize:          maxBodySize,
-		authenticatedPaths:   authenticatedPaths,
-		timeout:              timeout,
-		maxUpstreamBatchSize: maxUpstreamBatchSize,
-		cache:                cache,
-		enableRequestLog:     enableRequestLog,
-		maxRequestBodyLogLen: maxRequestBodyLogLen,
-		maxBatchSize:         maxBatchSize,
-		upgrader: &websocket.Upgrader{
-			HandshakeTimeout: 5 * time.Second,
-		},
+	return &serverState{
+		backendGroups:          backendGroups,
+		wsBackendGroup:         wsBackendGroup,
+		wsMethodWhitelist:      wsMethodWhitelist,
+		rpcMethodMappings:      rpcMethodMappings,
+		authenticatedPaths:     authenticatedPaths,
+		rateLimitConfig:        rateLimitConfig,
+		senderRateLimitConfig:  senderRateLimitConfig,
 		mainLim:                mainLim,
 		overrideLims:           overrideLims,
 		globallyLimitedMethods: globalMethodLims,
 		senderLim:              senderLim,
 		limExemptOrigins:       limExemptOrigins,
 		limExemptUserAgents:    limExemptUserAgents,
+		txPolicy:               txPolicy,
 	}, nil
 }
 
+// sameMethodOverride returns whether the limiters of two method overrides count the same way.
+func sameMethodOverride(a, b *RateLimitMethodOverride) bool {
+	return a != nil && b != nil && a.Limit == b.Limit && a.Interval == b.Interval
+}
+
+func (s *Server) getState() *serverState {
+	s.stateMu.RLock()
+	defer s.stateMu.RUnlock()
+	return s.state
+}
+
+func (s *Server) setState(state *serverState) {
+	s.stateMu.Lock()
+	s.state = state
+	s.stateMu.Unlock()
+}
+
 func (s *Server) RPCListenAndServe(host string, port int) error {
 	s.srvMu.Lock()
 	hdlr := mux.NewRouter()
@@ -229,10 +323,23 @@ func (s *Server) HandleHealthz(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) HandleRPC(w http.ResponseWriter, r *http.Request) {
-	ctx := s.populateContext(w, r)
+	r, root := s.tracer.StartRequest(r)
+	if root != nil {
+		rec := &statusRecorder{ResponseWriter: w}
+		w = rec
+		defer func() {
+			root.SetAttribute(AttrStatus, rec.status)
+			s.tracer.FinishRequest(root)
+		}()
+	}
+
+	st := s.getState()
+	ctx := s.populateContext(w, r, st)
 	if ctx == nil {
 		return
 	}
+	root.SetAttribute(AttrReqID, GetReqID(ctx))
+	root.SetAttribute(AttrAuth, GetAuthCtx(ctx))
 	var cancel context.CancelFunc
 	ctx, cancel = context.WithTimeout(ctx, s.timeout)
 	defer cancel()
@@ -241,8 +348,11 @@ func (s *Server) HandleRPC(w http.ResponseWriter, r *http.Request) {
 	userAgent := r.Header.Get("User-Agent")
 	// Use XFF in context since it will automatically be replaced by the remote IP
 	xff := stripXFF(GetXForwardedFor(ctx))
-	isUnlimitedOrigin := s.isUnlimitedOrigin(origin)
-	isUnlimitedUserAgent := s.isUnlimitedUserAgent(userAgent)
+	isUnlimitedOrigin := st.isUnlimitedOrigin(origin)
+	isUnlimitedUserAgent := st.isUnlimitedUserAgent(userAgent)
+	root.SetAttribute(AttrRemoteIP, xff)
+	root.SetAttribute(AttrUserAgent, userAgent)
+	root.SetAttribute(AttrOrigin, origin)
 
 	if xff == "" {
 		writeRPCError(ctx, w, nil, ErrInvalidRequest("request does not include a remote IP"))
@@ -250,16 +360,16 @@ func (s *Server) HandleRPC(w http.ResponseWriter, r *http.Request) {
 	}
 
 	isLimited := func(method string) bool {
-		isGloballyLimitedMethod := s.isGlobalLimit(method)
+		isGloballyLimitedMethod := st.isGlobalLimit(method)
 		if !isGloballyLimitedMethod && (isUnlimitedOrigin || isUnlimitedUserAgent) {
 			return false
 		}
 
 		var lim FrontendRateLimiter
 		if method == "" {
-			lim = s.mainLim
+			lim = st.mainLim
 		} else {
-			lim = s.overrideLims[method]
+			lim = st.overrideLims[method]
 		}
 
 		if lim == nil {
@@ -274,7 +384,11 @@ func (s *Server) HandleRPC(w http.ResponseWriter, r *http.Request) {
 		return !ok
 	}
 
-	if isLimited("") {
+	_, span := StartSpan(ctx, SpanRateLimit)
+	limited := isLimited("")
+	span.End()
+	if limited {
+		root.SetAttribute(AttrRateLimited, true)
 		RecordRPCError(ctx, BackendProxyd, "unknown", ErrOverRateLimit)
 		log.Warn(
 			"rate limited request",
@@ -297,7 +411,9 @@ func (s *Server) HandleRPC(w http.ResponseWriter, r *http.Request) {
 		"remote_ip", xff,
 	)
 
+	_, span = StartSpan(ctx, SpanReadBody)
 	body, err := io.ReadAll(io.LimitReader(r.Body, s.maxBodySize))
+	span.End()
 	if err != nil {
 		log.Error("error reading request body", "err", err)
 		writeRPCError(ctx, w, nil, ErrInternal)
@@ -314,7 +430,10 @@ func (s *Server) HandleRPC(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if IsBatch(body) {
+		root.SetAttribute(AttrBatch, true)
+		_, span = StartSpan(ctx, SpanParse)
 		reqs, err := ParseBatchRPCReq(body)
+		span.End()
 		if err != nil {
 			log.Error("error parsing batch RPC request", "err", err)
 			RecordRPCError(ctx, BackendProxyd, MethodUnknown, err)
@@ -335,7 +454,7 @@ func (s *Server) HandleRPC(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		batchRes, batchContainsCached, err := s.handleBatchRPC(ctx, reqs, isLimited, true)
+		batchRes, batchContainsCached, err := s.handleBatchRPC(ctx, st, reqs, isLimited, true)
 		if err == context.DeadlineExceeded {
 			writeRPCError(ctx, w, nil, ErrGatewayTimeout)
 			return
@@ -346,21 +465,25 @@ func (s *Server) HandleRPC(w http.ResponseWriter, r *http.Request) {
 		}
 
 		setCacheHeader(w, batchContainsCached)
+		_, span = StartSpan(ctx, SpanWriteResponse)
 		writeBatchRPCRes(ctx, w, batchRes)
+		span.End()
 		return
 	}
 
 	rawBody := json.RawMessage(body)
-	backendRes, cached, err := s.handleBatchRPC(ctx, []json.RawMessage{rawBody}, isLimited, false)
+	backendRes, cached, err := s.handleBatchRPC(ctx, st, []json.RawMessage{rawBody}, isLimited, false)
 	if err != nil {
 		writeRPCError(ctx, w, nil, ErrInternal)
 		return
 	}
 	setCacheHeader(w, cached)
+	_, span = StartSpan(ctx, SpanWriteResponse)
 	writeRPCRes(ctx, w, backendRes[0])
+	span.End()
 }
 
-func (s *Server) handleBatchRPC(ctx context.Context, reqs []json.RawMessage, isLimited limiterFunc, isBatch bool) ([]*RPCRes, bool, error) {
+func (s *Server) handleBatchRPC(ctx context.Context, st *serverState, reqs []json.RawMessage, isLimited limiterFunc, isBatch bool) ([]*RPCRes, bool, error) {
 	// A request set is transformed into groups of batches.
 	// Each batch group maps to a forwarded JSON-RPC batch request (subject to maxUpstreamBatchSize constraints)
 	// A groupID is used to decouple Requests that have duplicate ID so they're not part of the same batch that's
@@ -376,27 +499,44 @@ func (s *Server) handleBatchRPC(ctx context.Context, reqs []json.RawMessage, isL
 	batches := make(map[batchGroup][]batchElem)
 	ids := make(map[string]int, len(reqs))
 
+	// Each call is traced from its parsing until its response is known.
+	calls := make([]*Span, len(reqs))
+	setResponse := func(i int, res *RPCRes) {
+		responses[i] = res
+		calls[i].SetResponse(res)
+		calls[i].End()
+	}
+
+	// Every broadcast transaction has its response once handleBatchRPC returns.
+	var broadcasts sync.WaitGroup
+	defer broadcasts.Wait()
+
 	for i := range reqs {
+		callCtx, call := StartSpan(ctx, SpanCall)
+		call.SetAttribute(AttrIndex, i)
+		calls[i] = call
+
 		parsedReq, err := ParseRPCReq(reqs[i])
 		if err != nil {
 			log.Info("error parsing RPC call", "source", "rpc", "err", err)
-			responses[i] = NewRPCErrorRes(nil, err)
+			setResponse(i, NewRPCErrorRes(nil, err))
 			continue
 		}
+		call.SetAttribute(AttrMethod, parsedReq.Method)
 
 		if err := ValidateRPCReq(parsedReq); err != nil {
 			RecordRPCError(ctx, BackendProxyd, MethodUnknown, err)
-			responses[i] = NewRPCErrorRes(nil, err)
+			setResponse(i, NewRPCErrorRes(nil, err))
 			continue
 		}
 
 		if parsedReq.Method == "eth_accounts" {
 			RecordRPCForward(ctx, BackendProxyd, "eth_accounts", RPCRequestSourceHTTP)
-			responses[i] = NewRPCRes(parsedReq.ID, emptyArrayResponse)
+			setResponse(i, NewRPCRes(parsedReq.ID, emptyArrayResponse))
 			continue
 		}
 
-		group := s.rpcMethodMappings[parsedReq.Method]
+		group := st.rpcMethodMappings[parsedReq.Method]
 		if group == "" {
 			// use unknown below to prevent DOS vector that fills up memory
 			// with arbitrary method names.
@@ -407,33 +547,98 @@ func (s *Server) handleBatchRPC(ctx context.Context, reqs []json.RawMessage, isL
 				"method", parsedReq.Method,
 			)
 			RecordRPCError(ctx, BackendProxyd, MethodUnknown, ErrMethodNotWhitelisted)
-			responses[i] = NewRPCErrorRes(parsedReq.ID, ErrMethodNotWhitelisted)
+			setResponse(i, NewRPCErrorRes(parsedReq.ID, ErrMethodNotWhitelisted))
 			continue
 		}
 
+		// Enforce the allowlist, limits and quota of the tenant of the API key,
+		// and route its requests to its backend group.
+		if tenant := GetTenant(ctx); tenant != nil {
+			if err := s.tenants.Take(ctx, tenant, parsedReq.Method); err != nil {
+				log.Info(
+					"blocked tenant request",
+					"source", "rpc",
+					"req_id", GetReqID(ctx),
+					"tenant", tenant.Name,
+					"method", parsedReq.Method,
+					"err", err,
+				)
+				if err == ErrOverRateLimit || err == ErrOverQuota {
+					call.SetAttribute(AttrRateLimited, true)
+				}
+				RecordRPCError(ctx, BackendProxyd, parsedReq.Method, err)
+				setResponse(i, NewRPCErrorRes(parsedReq.ID, err))
+				continue
+			}
+			if tenant.BackendGroup != "" {
+				group = tenant.BackendGroup
+			}
+		}
+		call.SetAttribute(AttrBackendGroup, group)
+
 		// Take rate limit for specific methods.
 		// NOTE: eventually, this should apply to all batch requests. However,
 		// since we don't have data right now on the size of each batch, we
 		// only apply this to the methods that have an additional rate limit.
-		if _, ok := s.overrideLims[parsedReq.Method]; ok && isLimited(parsedReq.Method) {
+		if _, ok := st.overrideLims[parsedReq.Method]; ok && isLimited(parsedReq.Method) {
 			log.Info(
 				"rate limited specific RPC",
 				"source", "rpc",
 				"req_id", GetReqID(ctx),
 				"method", parsedReq.Method,
 			)
+			call.SetAttribute(AttrRateLimited, true)
 			RecordRPCError(ctx, BackendProxyd, parsedReq.Method, ErrOverRateLimit)
-			responses[i] = NewRPCErrorRes(parsedReq.ID, ErrOverRateLimit)
+			setResponse(i, NewRPCErrorRes(parsedReq.ID, ErrOverRateLimit))
 			continue
 		}
 
-		// Apply a sender-based rate limit if it is enabled. Note that sender-based rate
-		// limits apply regardless of origin or user-agent. As such, they don't use the
-		// isLimited method.
-		if parsedReq.Method == "eth_sendRawTransaction" && s.senderLim != nil {
-			if err := s.rateLimitSender(ctx, parsedReq); err != nil {
+		if parsedReq.Method == "eth_sendRawTransaction" && (st.senderLim != nil || st.txPolicy != nil) {
+			tx, sender, err := parseRawTransaction(ctx, parsedReq)
+			// Apply a sender-based rate limit if it is enabled. Note that sender-based rate
+			// limits apply regardless of origin or user-agent. As such, they don't use the
+			// isLimited method.
+			if err == nil && st.senderLim != nil {
+				err = s.rateLimitSender(ctx, st.senderLim, tx, sender)
+				if err == ErrOverSenderRateLimit {
+					call.SetAttribute(AttrRateLimited, true)
+				}
+			}
+			if err == nil && st.txPolicy != nil {
+				err = st.txPolicy.Check(callCtx, tx, sender, st.backendGroups[group])
+			}
+			if err != nil {
+				RecordRPCError(ctx, BackendProxyd, parsedReq.Method, err)
+				setResponse(i, NewRPCErrorRes(parsedReq.ID, err))
+				continue
+			}
+			if st.txPolicy != nil && st.txPolicy.Broadcasts() {
+				// Broadcast the transactions of a batch concurrently, like the
+				// other calls of the batch are forwarded.
+				broadcasts.Add(1)
+				go func(i int, req *RPCReq) {
+					defer broadcasts.Done()
+					setResponse(i, st.txPolicy.Broadcast(callCtx, req))
+				}(i, parsedReq)
+				continue
+			}
+		}
+
+		if parsedReq.Method == "eth_getLogs" && s.getLogsGuard != nil {
+			split, from, to, err := s.getLogsGuard.Check(callCtx, parsedReq)
+			if err != nil {
 				RecordRPCError(ctx, BackendProxyd, parsedReq.Method, err)
-				responses[i] = NewRPCErrorRes(parsedReq.ID, err)
+				setResponse(i, NewRPCErrorRes(parsedReq.ID, err))
+				continue
+			}
+			if split {
+				res := s.getLogsGuard.ForwardSplit(callCtx, st.backendGroups[group], parsedReq, from, to)
+				backend, retries := call.ServedBy()
+				if backend != "" {
+					call.SetAttribute(AttrBackend, backend)
+				}
+				call.SetAttribute(AttrRetries, retries)
+				setResponse(i, res)
 				continue
 			}
 		}
@@ -450,15 +655,18 @@ func (s *Server) handleBatchRPC(ctx context.Context, reqs []json.RawMessage, isL
 	for group, batch := range batches {
 		var cacheMisses []batchElem
 
+		_, cacheSpan := StartSpan(ctx, SpanCache)
 		for _, req := range batch {
 			backendRes, _ := s.cache.GetRPC(ctx, req.Req)
+			calls[req.Index].SetAttribute(AttrCacheHit, backendRes != nil)
 			if backendRes != nil {
-				responses[req.Index] = backendRes
+				setResponse(req.Index, backendRes)
 				cached = true
 			} else {
 				cacheMisses = append(cacheMisses, req)
 			}
 		}
+		cacheSpan.End()
 
 		// Create minibatches - each minibatch must be no larger than the maxUpstreamBatchSize
 		numBatches := int(math.Ceil(float64(len(cacheMisses)) / float64(s.maxUpstreamBatchSize)))
@@ -476,7 +684,13 @@ func (s *Server) handleBatchRPC(ctx context.Context, reqs []json.RawMessage, isL
 			start := i * s.maxUpstreamBatchSize
 			end := int(math.Min(float64(start+s.maxUpstreamBatchSize), float64(len(cacheMisses))))
 			elems := cacheMisses[start:end]
-			res, err := s.backendGroups[group.backendGroup].Forward(ctx, createBatchRequest(elems), isBatch)
+			forwardCtx, forward := StartSpan(ctx, SpanForward)
+			forward.SetAttribute(AttrBackendGroup, group.backendGroup)
+			forward.SetAttribute(AttrBatchSize, len(elems))
+			res, err := st.backendGroups[group.backendGroup].Forward(forwardCtx, createBatchRequest(elems), isBatch)
+			forward.SetError(err)
+			forward.End()
+			backend, retries := forward.ServedBy()
 			if err != nil {
 				log.Error(
 					"error forwarding RPC batch",
@@ -492,10 +706,14 @@ func (s *Server) handleBatchRPC(ctx context.Context, reqs []json.RawMessage, isL
 			}
 
 			for i := range elems {
-				responses[elems[i].Index] = res[i]
+				if backend != "" {
+					calls[elems[i].Index].SetAttribute(AttrBackend, backend)
+				}
+				calls[elems[i].Index].SetAttribute(AttrRetries, retries)
+				setResponse(elems[i].Index, res[i])
 
 				// TODO(inphi): batch put these
-				if res[i].Error == nil && res[i].Result != nil {
+				if res[i].Error == nil {
 					if err := s.cache.PutRPC(ctx, elems[i].Req, res[i]); err != nil {
 						log.Warn(
 							"cache put error",
@@ -512,7 +730,8 @@ func (s *Server) handleBatchRPC(ctx context.Context, reqs []json.RawMessage, isL
 }
 
 func (s *Server) HandleWS(w http.ResponseWriter, r *http.Request) {
-	ctx := s.populateContext(w, r)
+	st := s.getState()
+	ctx := s.populateContext(w, r, st)
 	if ctx == nil {
 		return
 	}
@@ -524,8 +743,11 @@ func (s *Server) HandleWS(w http.ResponseWriter, r *http.Request) {
 		log.Error("error upgrading client conn", "auth", GetAuthCtx(ctx), "req_id", GetReqID(ctx), "err", err)
 		return
 	}
+	// The connection outlives the HTTP request, whose context is canceled
+	// when this handler returns.
+	ctx = detachContext(ctx)
 
-	proxier, err := s.wsBackendGroup.ProxyWS(ctx, clientConn, s.wsMethodWhitelist)
+	proxier, err := st.wsBackendGroup.ProxyWS(ctx, clientConn, st.wsMethodWhitelist, s.wsRequestFilter(st))
 	if err != nil {
 		if errors.Is(err, ErrNoBackends) {
 			RecordUnserviceableRequest(ctx, RPCRequestSourceWS)
@@ -535,6 +757,8 @@ func (s *Server) HandleWS(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	proxier.tracer = s.tracer
+
 	activeClientWsConnsGauge.WithLabelValues(GetAuthCtx(ctx)).Inc()
 	go func() {
 		// Below call blocks so run it in a goroutine.
@@ -547,7 +771,52 @@ func (s *Server) HandleWS(w http.ResponseWriter, r *http.Request) {
 	log.Info("accepted WS connection", "auth", GetAuthCtx(ctx), "req_id", GetReqID(ctx))
 }
 
-func (s *Server) populateContext(w http.ResponseWriter, r *http.Request) context.Context {
+// wsRequestFilter applies to the requests of a websocket client the checks
+// that handleBatchRPC applies to HTTP requests: the allowlist, limits and
+// quota of the tenant of the API key, and the sender rate limit and
+// transaction policy of eth_sendRawTransaction.
+func (s *Server) wsRequestFilter(st *serverState) WSRequestFilter {
+	return func(ctx context.Context, req *RPCReq) *RPCRes {
+		reqCtx, cancel := context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+
+		if tenant := GetTenant(ctx); tenant != nil {
+			if err := s.tenants.Take(reqCtx, tenant, req.Method); err != nil {
+				log.Info(
+					"blocked tenant request",
+					"source", "ws",
+					"req_id", GetReqID(ctx),
+					"tenant", tenant.Name,
+					"method", req.Method,
+					"err", err,
+				)
+				RecordRPCError(ctx, BackendProxyd, req.Method, err)
+				return NewRPCErrorRes(req.ID, err)
+			}
+		}
+
+		if req.Method != "eth_sendRawTransaction" || (st.senderLim == nil && st.txPolicy == nil) {
+			return nil
+		}
+		tx, sender, err := parseRawTransaction(ctx, req)
+		if err == nil && st.senderLim != nil {
+			err = s.rateLimitSender(reqCtx, st.senderLim, tx, sender)
+		}
+		if err == nil && st.txPolicy != nil {
+			err = st.txPolicy.Check(reqCtx, tx, sender, st.wsBackendGroup)
+		}
+		if err != nil {
+			RecordRPCError(ctx, BackendProxyd, req.Method, err)
+			return NewRPCErrorRes(req.ID, err)
+		}
+		if st.txPolicy != nil && st.txPolicy.Broadcasts() {
+			return st.txPolicy.Broadcast(reqCtx, req)
+		}
+		return nil
+	}
+}
+
+func (s *Server) populateContext(w http.ResponseWriter, r *http.Request, st *serverState) context.Context {
 	vars := mux.Vars(r)
 	authorization := vars["authorization"]
 	xff := r.Header.Get("X-Forwarded-For")
@@ -559,7 +828,7 @@ func (s *Server) populateContext(w http.ResponseWriter, r *http.Request) context
 	}
 	ctx := context.WithValue(r.Context(), ContextKeyXForwardedFor, xff) // nolint:staticcheck
 
-	if s.authenticatedPaths == nil {
+	if st.authenticatedPaths == nil && s.tenants == nil {
 		// handle the edge case where auth is disabled
 		// but someone sends in an auth key anyway
 		if authorization != "" {
@@ -569,14 +838,26 @@ func (s *Server) populateContext(w http.ResponseWriter, r *http.Request) context
 			return nil
 		}
 	} else {
-		if authorization == "" || s.authenticatedPaths[authorization] == "" {
+		alias := st.authenticatedPaths[authorization]
+		var tenant *Tenant
+		if alias == "" && authorization != "" && s.tenants != nil {
+			tenant = s.tenants.Get(authorization)
+			if tenant != nil {
+				alias = tenant.Name
+			}
+		}
+
+		if authorization == "" || alias == "" {
 			log.Info("blocked unauthorized request", "authorization", authorization)
 			httpResponseCodesTotal.WithLabelValues("401").Inc()
 			w.WriteHeader(401)
 			return nil
 		}
 
-		ctx = context.WithValue(r.Context(), ContextKeyAuth, s.authenticatedPaths[authorization]) // nolint:staticcheck
+		ctx = context.WithValue(ctx, ContextKeyAuth, alias) // nolint:staticcheck
+		if tenant != nil {
+			ctx = context.WithValue(ctx, ContextKeyTenant, tenant) // nolint:staticcheck
+		}
 	}
 
 	return context.WithValue(
@@ -586,8 +867,8 @@ func (s *Server) populateContext(w http.ResponseWriter, r *http.Request) context
 	)
 }
 
-func (s *Server) isUnlimitedOrigin(origin string) bool {
-	for _, pat := range s.limExemptOrigins {
+func (st *serverState) isUnlimitedOrigin(origin string) bool {
+	for _, pat := range st.limExemptOrigins {
 		if pat.MatchString(origin) {
 			return true
 		}
@@ -596,8 +877,8 @@ func (s *Server) isUnlimitedOrigin(origin string) bool {
 	return false
 }
 
-func (s *Server) isUnlimitedUserAgent(origin string) bool {
-	for _, pat := range s.limExemptUserAgents {
+func (st *serverState) isUnlimitedUserAgent(origin string) bool {
+	for _, pat := range st.limExemptUserAgents {
 		if pat.MatchString(origin) {
 			return true
 		}
@@ -605,52 +886,18 @@ func (s *Server) isUnlimitedUserAgent(origin string) bool {
 	return false
 }
 
-func (s *Server) isGlobalLimit(method string) bool {
-	return s.globallyLimitedMethods[method]
+func (st *serverState) isGlobalLimit(method string) bool {
+	return st.globallyLimitedMethods[method]
 }
 
-func (s *Server) rateLimitSender(ctx context.Context, req *RPCReq) error {
-	var params []string
-	if err := json.Unmarshal(req.Params, &params); err != nil {
-		log.Debug("error unmarshaling raw transaction params", "err", err, "req_Id", GetReqID(ctx))
-		return ErrParseErr
-	}
-
-	if len(params) != 1 {
-		log.Debug("raw transaction request has invalid number of params", "req_id", GetReqID(ctx))
-		// The error below is identical to the one Geth responds with.
-		return ErrInvalidParams("missing value for required argument 0")
-	}
-
-	var data hexutil.Bytes
-	if err := data.UnmarshalText([]byte(params[0])); err != nil {
-		log.Debug("error decoding raw tx data", "err", err, "req_id", GetReqID(ctx))
-		// Geth returns the raw error from UnmarshalText.
-		return ErrInvalidParams(err.Error())
-	}
-
-	// Inflates a types.Transaction object from the transaction's raw bytes.
-	tx := new(types.Transaction)
-	if err := tx.UnmarshalBinary(data); err != nil {
-		log.Debug("could not unmarshal transaction", "err", err, "req_id", GetReqID(ctx))
-		return ErrInvalidParams(err.Error())
-	}
-
-	// Convert the transaction into a Message object so that we can get the
-	// sender. This method performs an ecrecover, which can be expensive.
-	msg, err := tx.AsMessage(types.LatestSignerForChainID(tx.ChainId()), nil)
-	if err != nil {
-		log.Debug("could not get message from transaction", "err", err, "req_id", GetReqID(ctx))
-		return ErrInvalidParams(err.Error())
-	}
-
-	ok, err := s.senderLim.Take(ctx, fmt.Sprintf("%s:%d", msg.From().Hex(), tx.Nonce()))
+func (s *Server) rateLimitSender(ctx context.Context, lim FrontendRateLimiter, tx *types.Transaction, sender common.Address) error {
+	ok, err := lim.Take(ctx, fmt.Sprintf("%s:%d", sender.Hex(), tx.Nonce()))
 	if err != nil {
 		log.Error("error taking from sender limiter", "err", err, "req_id", GetReqID(ctx))
 		return ErrInternal
 	}
 	if !ok {
-		log.Debug("sender rate limit exceeded", "sender", msg.From(), "req_id", GetReqID(ctx))
+		log.Debug("sender rate limit exceeded", "sender", sender, "req_id", GetReqID(ctx))
 		return ErrOverSenderRateLimit
 	}
 
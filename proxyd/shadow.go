@@ -0,0 +1,233 @@
+package proxyd
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"golang.org/x/sync/semaphore"
+)
+
+const (
+	DefaultShadowMaxConcurrentRequests = 100
+	DefaultShadowTimeout               = 10 * time.Second
+
+	ShadowResultMatch    = "match"
+	ShadowResultMismatch = "mismatch"
+	ShadowResultError    = "error"
+	ShadowResultDropped  = "dropped"
+
+	// shadowMaxLoggedResultSize truncates the results of mismatches in the logs.
+	shadowMaxLoggedResultSize = 512
+)
+
+// shadowExcludedMethods are never sent to shadow backends, since they change
+// the state of the chain or of the node: shadow backends would broadcast the
+// transactions of the clients.
+var shadowExcludedMethods = map[string]bool{
+	"eth_sendRawTransaction":            true,
+	"eth_sendRawTransactionConditional": true,
+	"eth_sendTransaction":               true,
+	"eth_sign":                          true,
+	"eth_signTransaction":               true,
+	"eth_signTypedData":                 true,
+	"eth_submitWork":                    true,
+	"eth_submitHashrate":                true,
+	"debug_setHead":                     true,
+}
+
+// shadowExcludedNamespaces are the method namespaces that are never sent to shadow backends.
+var shadowExcludedNamespaces = []string{"admin_", "engine_", "miner_", "personal_"}
+
+// IsShadowed returns whether requests for method are sent to shadow backends.
+func IsShadowed(method string) bool {
+	if shadowExcludedMethods[method] {
+		return false
+	}
+	for _, namespace := range shadowExcludedNamespaces {
+		if strings.HasPrefix(method, namespace) {
+			return false
+		}
+	}
+	return true
+}
+
+// ShadowComparer sends the requests served by a backend group to shadow backends, and compares their
+// responses to the responses the clients got. It never changes the responses the clients get.
+type ShadowComparer struct {
+	groupName     string
+	backends      []*Backend
+	ignoredFields map[string]bool
+	timeout       time.Duration
+	sem           *semaphore.Weighted
+}
+
+type ShadowOpt func(s *ShadowComparer)
+
+// WithShadowIgnoredFields ignores the object fields with these names, at any depth, when comparing results.
+func WithShadowIgnoredFields(fields []string) ShadowOpt {
+	return func(s *ShadowComparer) {
+		for _, field := range fields {
+			s.ignoredFields[field] = true
+		}
+	}
+}
+
+func WithShadowMaxConcurrentRequests(max int64) ShadowOpt {
+	return func(s *ShadowComparer) {
+		s.sem = semaphore.NewWeighted(max)
+	}
+}
+
+func WithShadowTimeout(timeout time.Duration) ShadowOpt {
+	return func(s *ShadowComparer) {
+		s.timeout = timeout
+	}
+}
+
+func NewShadowComparer(groupName string, backends []*Backend, opts ...ShadowOpt) *ShadowComparer {
+	s := &ShadowComparer{
+		groupName:     groupName,
+		backends:      backends,
+		ignoredFields: make(map[string]bool),
+		timeout:       DefaultShadowTimeout,
+		sem:           semaphore.NewWeighted(DefaultShadowMaxConcurrentRequests),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Shadow sends the requests to every shadow backend in the background, and compares their
+// responses to the primary responses. Requests are dropped if too many are in flight. Requests
+// for methods that change state, see IsShadowed, are never sent.
+func (s *ShadowComparer) Shadow(ctx context.Context, reqs []*RPCReq, primary []*RPCRes, isBatch bool) {
+	// The primary responses may still be modified before they're sent to the client
+	shadowed := make([]*RPCReq, 0, len(reqs))
+	snapshot := make([]*RPCRes, 0, len(primary))
+	for i, req := range reqs {
+		if !IsShadowed(req.Method) {
+			continue
+		}
+		resCopy := *primary[i]
+		shadowed = append(shadowed, req)
+		snapshot = append(snapshot, &resCopy)
+	}
+	if len(shadowed) == 0 {
+		return
+	}
+	reqs = shadowed
+
+	for _, be := range s.backends {
+		if !s.sem.TryAcquire(1) {
+			for _, req := range reqs {
+				RecordShadowResult(s.groupName, be.Name, req.Method, ShadowResultDropped)
+			}
+			continue
+		}
+
+		go func(be *Backend) {
+			defer s.sem.Release(1)
+
+			shadowCtx, cancel := context.WithTimeout(detachContext(ctx), s.timeout)
+			defer cancel()
+			s.compare(shadowCtx, be, reqs, snapshot, isBatch)
+		}(be)
+	}
+}
+
+func (s *ShadowComparer) compare(ctx context.Context, be *Backend, reqs []*RPCReq, primary []*RPCRes, isBatch bool) {
+	shadow, err := be.doForward(ctx, reqs, isBatch)
+	if err != nil {
+		log.Warn(
+			"error forwarding request to shadow backend",
+			"backend_group", s.groupName,
+			"name", be.Name,
+			"req_id", GetReqID(ctx),
+			"err", err,
+		)
+		for _, req := range reqs {
+			RecordShadowResult(s.groupName, be.Name, req.Method, ShadowResultError)
+		}
+		return
+	}
+
+	shadowByID := make(map[string]*RPCRes, len(shadow))
+	for _, res := range shadow {
+		shadowByID[string(res.ID)] = res
+	}
+	for i, req := range reqs {
+		shadowRes := shadowByID[string(primary[i].ID)]
+		if shadowRes == nil {
+			RecordShadowResult(s.groupName, be.Name, req.Method, ShadowResultError)
+			continue
+		}
+		if s.Equal(primary[i], shadowRes) {
+			RecordShadowResult(s.groupName, be.Name, req.Method, ShadowResultMatch)
+			continue
+		}
+
+		RecordShadowResult(s.groupName, be.Name, req.Method, ShadowResultMismatch)
+		log.Warn(
+			"shadow backend response mismatch",
+			"backend_group", s.groupName,
+			"name", be.Name,
+			"req_id", GetReqID(ctx),
+			"method", req.Method,
+			"params", truncate(string(req.Params), shadowMaxLoggedResultSize),
+			"primary", truncate(string(mustMarshalJSON(primary[i])), shadowMaxLoggedResultSize),
+			"shadow", truncate(string(mustMarshalJSON(shadowRes)), shadowMaxLoggedResultSize),
+		)
+	}
+}
+
+// Equal compares the results and error codes of two responses, without the ignored fields.
+func (s *ShadowComparer) Equal(a, b *RPCRes) bool {
+	if a.IsError() || b.IsError() {
+		return a.IsError() && b.IsError() && a.Error.Code == b.Error.Code
+	}
+	return reflect.DeepEqual(s.normalize(a.Result), s.normalize(b.Result))
+}
+
+// normalize converts a result to its generic JSON representation, without the ignored fields.
+func (s *ShadowComparer) normalize(result interface{}) interface{} {
+	var generic interface{}
+	if err := json.Unmarshal(mustMarshalJSON(result), &generic); err != nil {
+		return result
+	}
+	return s.removeIgnoredFields(generic)
+}
+
+func (s *ShadowComparer) removeIgnoredFields(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, field := range v {
+			if s.ignoredFields[k] {
+				delete(v, k)
+				continue
+			}
+			v[k] = s.removeIgnoredFields(field)
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = s.removeIgnoredFields(v[i])
+		}
+	}
+	return v
+}
+
+// detachContext returns a context with the request values of ctx, that isn't
+// canceled when the client request completes.
+func detachContext(ctx context.Context) context.Context {
+	detached := context.Background()
+	for _, key := range []string{ContextKeyAuth, ContextKeyReqID, ContextKeyXForwardedFor, ContextKeyTenant} {
+		if v := ctx.Value(key); v != nil {
+			detached = context.WithValue(detached, key, v) // nolint:staticcheck
+		}
+	}
+	return detached
+}
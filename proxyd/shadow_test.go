@@ -0,0 +1,50 @@
+package proxyd
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestShadowComparerEqual(t *testing.T) {
+	s := NewShadowComparer("main", nil, WithShadowIgnoredFields([]string{"id", "totalDifficulty"}))
+
+	res := func(result string) *RPCRes {
+		return &RPCRes{JSONRPC: JSONRPCVersion, Result: json.RawMessage(result), ID: []byte("1")}
+	}
+
+	tests := []struct {
+		name  string
+		a     *RPCRes
+		b     *RPCRes
+		equal bool
+	}{
+		{"same string", res(`"0x1"`), res(`"0x1"`), true},
+		{"different string", res(`"0x1"`), res(`"0x2"`), false},
+		{"different key order", res(`{"a":1,"b":2}`), res(`{"b":2,"a":1}`), true},
+		{"ignored field", res(`{"a":1,"id":2}`), res(`{"a":1,"id":3}`), true},
+		{"missing ignored field", res(`{"a":1,"totalDifficulty":"0x1"}`), res(`{"a":1}`), true},
+		{"nested ignored field", res(`[{"a":{"id":1}}]`), res(`[{"a":{"id":2}}]`), true},
+		{"different field", res(`{"a":1,"id":2}`), res(`{"a":2,"id":2}`), false},
+		{"null result", res(`null`), &RPCRes{JSONRPC: JSONRPCVersion, ID: []byte("1")}, true},
+		{"same error code", &RPCRes{Error: &RPCErr{Code: -32000, Message: "a"}}, &RPCRes{Error: &RPCErr{Code: -32000, Message: "b"}}, true},
+		{"different error code", &RPCRes{Error: &RPCErr{Code: -32000}}, &RPCRes{Error: &RPCErr{Code: -32601}}, false},
+		{"error and result", &RPCRes{Error: &RPCErr{Code: -32000}}, res(`"0x1"`), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.equal, s.Equal(tt.a, tt.b))
+			require.Equal(t, tt.equal, s.Equal(tt.b, tt.a))
+		})
+	}
+}
+
+func TestIsShadowed(t *testing.T) {
+	for _, method := range []string{"eth_chainId", "eth_call", "eth_getBlockByNumber", "debug_traceTransaction"} {
+		require.True(t, IsShadowed(method), method)
+	}
+	for _, method := range []string{"eth_sendRawTransaction", "eth_sendTransaction", "debug_setHead", "personal_unlockAccount", "admin_addPeer", "engine_forkchoiceUpdatedV1"} {
+		require.False(t, IsShadowed(method), method)
+	}
+}
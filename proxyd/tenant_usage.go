@@ -0,0 +1,165 @@
+package proxyd
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	usageMonthLayout = "2006-01"
+	// Usage counters are kept for a bit more than a year in Redis.
+	usageCounterTTL = 400 * 24 * time.Hour
+
+	usageRequestsField     = "requests"
+	usageComputeUnitsField = "compute_units"
+)
+
+// UsageMonth formats the month of t, in UTC, as used by usage counters.
+func UsageMonth(t time.Time) string {
+	return t.UTC().Format(usageMonthLayout)
+}
+
+func ValidateUsageMonth(month string) error {
+	if _, err := time.Parse(usageMonthLayout, month); err != nil {
+		return fmt.Errorf("invalid month %s, must be formatted like YYYY-MM", month)
+	}
+	return nil
+}
+
+type TenantUsage struct {
+	Requests     int64                   `json:"requests"`
+	ComputeUnits int64                   `json:"compute_units"`
+	Methods      map[string]*MethodUsage `json:"methods"`
+}
+
+type MethodUsage struct {
+	Requests     int64 `json:"requests"`
+	ComputeUnits int64 `json:"compute_units"`
+}
+
+func newTenantUsage() *TenantUsage {
+	return &TenantUsage{
+		Methods: make(map[string]*MethodUsage),
+	}
+}
+
+func (u *TenantUsage) add(method string, requests int64, computeUnits int64) {
+	u.Requests += requests
+	u.ComputeUnits += computeUnits
+	methodUsage := u.Methods[method]
+	if methodUsage == nil {
+		methodUsage = new(MethodUsage)
+		u.Methods[method] = methodUsage
+	}
+	methodUsage.Requests += requests
+	methodUsage.ComputeUnits += computeUnits
+}
+
+// UsageCounter counts the requests and compute units of tenants per month.
+type UsageCounter interface {
+	Incr(ctx context.Context, tenant string, month string, method string, computeUnits int) error
+	Get(ctx context.Context, tenant string, month string) (*TenantUsage, error)
+}
+
+type memoryUsageCounter struct {
+	usage map[string]*TenantUsage
+	mtx   sync.Mutex
+}
+
+func NewMemoryUsageCounter() UsageCounter {
+	return &memoryUsageCounter{
+		usage: make(map[string]*TenantUsage),
+	}
+}
+
+func (m *memoryUsageCounter) Incr(ctx context.Context, tenant string, month string, method string, computeUnits int) error {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	key := usageKey(tenant, month)
+	usage := m.usage[key]
+	if usage == nil {
+		usage = newTenantUsage()
+		m.usage[key] = usage
+	}
+	usage.add(method, 1, int64(computeUnits))
+	return nil
+}
+
+func (m *memoryUsageCounter) Get(ctx context.Context, tenant string, month string) (*TenantUsage, error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	res := newTenantUsage()
+	if usage := m.usage[usageKey(tenant, month)]; usage != nil {
+		for method, methodUsage := range usage.Methods {
+			res.add(method, methodUsage.Requests, methodUsage.ComputeUnits)
+		}
+	}
+	return res, nil
+}
+
+// redisUsageCounter stores the usage of a tenant during a month in a
+// hash, with a field per method and counter.
+type redisUsageCounter struct {
+	rdb *redis.Client
+}
+
+func NewRedisUsageCounter(rdb *redis.Client) UsageCounter {
+	return &redisUsageCounter{rdb: rdb}
+}
+
+func (r *redisUsageCounter) Incr(ctx context.Context, tenant string, month string, method string, computeUnits int) error {
+	key := usageKey(tenant, month)
+	_, err := r.rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HIncrBy(ctx, key, usageField(method, usageRequestsField), 1)
+		pipe.HIncrBy(ctx, key, usageField(method, usageComputeUnitsField), int64(computeUnits))
+		pipe.Expire(ctx, key, usageCounterTTL)
+		return nil
+	})
+	if err != nil {
+		RecordRedisError("UsageIncr")
+		return err
+	}
+	return nil
+}
+
+func (r *redisUsageCounter) Get(ctx context.Context, tenant string, month string) (*TenantUsage, error) {
+	fields, err := r.rdb.HGetAll(ctx, usageKey(tenant, month)).Result()
+	if err != nil {
+		RecordRedisError("UsageGet")
+		return nil, err
+	}
+
+	usage := newTenantUsage()
+	for field, val := range fields {
+		i := strings.LastIndex(field, ":")
+		if i == -1 {
+			continue
+		}
+		count, err := strconv.ParseInt(val, 10, 64)
+		if err != nil {
+			return nil, wrapErr(err, "invalid usage counter")
+		}
+		method := field[:i]
+		switch field[i+1:] {
+		case usageRequestsField:
+			usage.add(method, count, 0)
+		case usageComputeUnitsField:
+			usage.add(method, 0, count)
+		}
+	}
+	return usage, nil
+}
+
+func usageKey(tenant string, month string) string {
+	return fmt.Sprintf("tenant_usage:%s:%s", tenant, month)
+}
+
+func usageField(method string, counter string) string {
+	return fmt.Sprintf("%s:%s", method, counter)
+}
@@ -0,0 +1,373 @@
+package proxyd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+const (
+	ContextKeyTenant           = "tenant"
+	DefaultTenantComputeUnits  = 1
+	defaultTenantsReloadPeriod = 30 * time.Second
+)
+
+var (
+	ErrOverQuota = &RPCErr{
+		Code:          JSONRPCErrorInternal - 19,
+		Message:       "monthly compute unit quota exceeded",
+		HTTPErrorCode: 429,
+	}
+
+	ErrUnknownTenant = errors.New("unknown tenant")
+)
+
+// TenantsFileConfig is the content of the tenants file, which is kept
+// separate from the main config so that keys can be reloaded without
+// restarting proxyd.
+type TenantsFileConfig struct {
+	// DefaultComputeUnits is the cost of the methods missing from ComputeUnits.
+	DefaultComputeUnits int                      `toml:"default_compute_units"`
+	ComputeUnits        map[string]int           `toml:"compute_units"`
+	Tenants             map[string]*TenantConfig `toml:"tenants"`
+}
+
+type TenantConfig struct {
+	Key                     string         `toml:"key"`
+	RequestsPerSecond       int            `toml:"requests_per_second"`
+	ComputeUnitsPerSecond   int            `toml:"compute_units_per_second"`
+	ComputeUnits            map[string]int `toml:"compute_units"`
+	AllowedMethods          []string       `toml:"allowed_methods"`
+	BackendGroup            string         `toml:"backend_group"`
+	MonthlyComputeUnitQuota int64          `toml:"monthly_compute_unit_quota"`
+}
+
+// Tenant is a customer authenticated by an API key. The limits of a
+// tenant apply on top of the global whitelist and rate limits.
+type Tenant struct {
+	Name string
+	// BackendGroup overrides the backend group of rpc_method_mappings
+	// for the requests of the tenant if it is set.
+	BackendGroup string
+
+	allowedMethods          *StringSet
+	computeUnits            map[string]int
+	defaultComputeUnits     int
+	monthlyComputeUnitQuota int64
+	requestLim              FrontendRateLimiter
+	computeUnitLim          FrontendRateLimiter
+}
+
+// ComputeUnits returns the cost of a request for the method.
+func (t *Tenant) ComputeUnits(method string) int {
+	if units, ok := t.computeUnits[method]; ok {
+		return units
+	}
+	return t.defaultComputeUnits
+}
+
+func (t *Tenant) IsAllowed(method string) bool {
+	return t.allowedMethods == nil || t.allowedMethods.Has(method)
+}
+
+type TenantLimiterFactory func(dur time.Duration, max int, prefix string) FrontendRateLimiter
+
+// TenantRegistry resolves API keys to tenants, enforces their limits
+// and counts their usage. The tenants are read from a file, which is
+// reloaded periodically or through Reload.
+type TenantRegistry struct {
+	path              string
+	limiterFactory    TenantLimiterFactory
+	backendGroups     map[string]*BackendGroup
+	rpcMethodMappings map[string]string
+	usage             UsageCounter
+
+	mtx      sync.RWMutex
+	byKey    map[string]*Tenant
+	byName   map[string]*Tenant
+	contents []byte
+
+	reloadMtx sync.Mutex
+	done      chan struct{}
+	wg        sync.WaitGroup
+}
+
+func NewTenantRegistry(
+	path string,
+	limiterFactory TenantLimiterFactory,
+	backendGroups map[string]*BackendGroup,
+	rpcMethodMappings map[string]string,
+	usage UsageCounter,
+) (*TenantRegistry, error) {
+	r := &TenantRegistry{
+		path:              path,
+		limiterFactory:    limiterFactory,
+		backendGroups:     backendGroups,
+		rpcMethodMappings: rpcMethodMappings,
+		usage:             usage,
+		done:              make(chan struct{}),
+	}
+	if _, err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload reads the tenants file and replaces the tenants if it changed.
+// The current tenants are kept if the file is invalid.
+func (r *TenantRegistry) Reload() (bool, error) {
+	r.reloadMtx.Lock()
+	defer r.reloadMtx.Unlock()
+
+	contents, err := os.ReadFile(r.path)
+	if err != nil {
+		return false, wrapErr(err, "error reading tenants file")
+	}
+
+	r.mtx.RLock()
+	unchanged := r.byKey != nil && bytes.Equal(contents, r.contents)
+	r.mtx.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	cfg := new(TenantsFileConfig)
+	if _, err := toml.Decode(string(contents), cfg); err != nil {
+		return false, wrapErr(err, "error decoding tenants file")
+	}
+	byKey, byName, err := r.buildTenants(cfg)
+	if err != nil {
+		return false, err
+	}
+
+	r.mtx.Lock()
+	r.byKey = byKey
+	r.byName = byName
+	r.contents = contents
+	r.mtx.Unlock()
+
+	log.Info("loaded tenants", "path", r.path, "count", len(byName))
+	return true, nil
+}
+
+func (r *TenantRegistry) buildTenants(cfg *TenantsFileConfig) (map[string]*Tenant, map[string]*Tenant, error) {
+	defaultComputeUnits := cfg.DefaultComputeUnits
+	if defaultComputeUnits == 0 {
+		defaultComputeUnits = DefaultTenantComputeUnits
+	}
+
+	byKey := make(map[string]*Tenant)
+	byName := make(map[string]*Tenant)
+	for name, tenantConfig := range cfg.Tenants {
+		if tenantConfig.Key == "" {
+			return nil, nil, fmt.Errorf("tenant %s has no key", name)
+		}
+		key, err := ReadFromEnvOrConfig(tenantConfig.Key)
+		if err != nil {
+			return nil, nil, err
+		}
+		if key == "none" {
+			return nil, nil, fmt.Errorf("tenant %s cannot use none as a key", name)
+		}
+		if byKey[key] != nil {
+			return nil, nil, fmt.Errorf("tenants %s and %s have the same key", byKey[key].Name, name)
+		}
+		if err := checkTenantRouting(name, tenantConfig.BackendGroup, tenantConfig.AllowedMethods, r.backendGroups, r.rpcMethodMappings); err != nil {
+			return nil, nil, err
+		}
+
+		tenant := &Tenant{
+			Name:                    name,
+			BackendGroup:            tenantConfig.BackendGroup,
+			computeUnits:            make(map[string]int),
+			defaultComputeUnits:     defaultComputeUnits,
+			monthlyComputeUnitQuota: tenantConfig.MonthlyComputeUnitQuota,
+			requestLim:              NoopFrontendRateLimiter,
+			computeUnitLim:          NoopFrontendRateLimiter,
+		}
+		if tenantConfig.AllowedMethods != nil {
+			tenant.allowedMethods = NewStringSetFromStrings(tenantConfig.AllowedMethods)
+		}
+		for method, units := range cfg.ComputeUnits {
+			tenant.computeUnits[method] = units
+		}
+		for method, units := range tenantConfig.ComputeUnits {
+			tenant.computeUnits[method] = units
+		}
+		if tenantConfig.RequestsPerSecond > 0 {
+			tenant.requestLim = r.limiterFactory(time.Second, tenantConfig.RequestsPerSecond, "tenant_requests")
+		}
+		if tenantConfig.ComputeUnitsPerSecond > 0 {
+			tenant.computeUnitLim = r.limiterFactory(time.Second, tenantConfig.ComputeUnitsPerSecond, "tenant_compute_units")
+		}
+
+		byKey[key] = tenant
+		byName[name] = tenant
+	}
+	return byKey, byName, nil
+}
+
+// UpdateRouting checks that the tenants only use the given backend groups
+// and methods, and validates later reloads of the tenants file against
+// them. Nothing is changed if a tenant uses a removed group or method.
+func (r *TenantRegistry) UpdateRouting(backendGroups map[string]*BackendGroup, rpcMethodMappings map[string]string) error {
+	r.reloadMtx.Lock()
+	defer r.reloadMtx.Unlock()
+
+	r.mtx.RLock()
+	tenants := r.byName
+	r.mtx.RUnlock()
+	for name, tenant := range tenants {
+		var allowedMethods []string
+		if tenant.allowedMethods != nil {
+			allowedMethods = tenant.allowedMethods.Entries()
+		}
+		if err := checkTenantRouting(name, tenant.BackendGroup, allowedMethods, backendGroups, rpcMethodMappings); err != nil {
+			return err
+		}
+	}
+
+	r.backendGroups = backendGroups
+	r.rpcMethodMappings = rpcMethodMappings
+	return nil
+}
+
+func checkTenantRouting(
+	name string,
+	backendGroup string,
+	allowedMethods []string,
+	backendGroups map[string]*BackendGroup,
+	rpcMethodMappings map[string]string,
+) error {
+	if backendGroup != "" && backendGroups[backendGroup] == nil {
+		return fmt.Errorf("tenant %s uses undefined backend group %s", name, backendGroup)
+	}
+	for _, method := range allowedMethods {
+		if rpcMethodMappings[method] == "" {
+			return fmt.Errorf("tenant %s allows method %s, which is not in rpc_method_mappings", name, method)
+		}
+	}
+	return nil
+}
+
+// Start reloads the tenants file every interval until Stop is called.
+func (r *TenantRegistry) Start(interval time.Duration) {
+	if interval == 0 {
+		interval = defaultTenantsReloadPeriod
+	}
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := r.Reload(); err != nil {
+					log.Error("error reloading tenants", "path", r.path, "err", err)
+				}
+			case <-r.done:
+				return
+			}
+		}
+	}()
+}
+
+func (r *TenantRegistry) Stop() {
+	close(r.done)
+	r.wg.Wait()
+}
+
+func (r *TenantRegistry) Get(key string) *Tenant {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+	return r.byKey[key]
+}
+
+// Names returns the names of the tenants in alphabetical order.
+func (r *TenantRegistry) Names() []string {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+	names := make([]string, 0, len(r.byName))
+	for name := range r.byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (r *TenantRegistry) HasTenant(name string) bool {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+	return r.byName[name] != nil
+}
+
+// Take checks a request of the tenant against its method allowlist,
+// rate limits and monthly quota, and counts it in its usage if it is
+// allowed.
+func (r *TenantRegistry) Take(ctx context.Context, tenant *Tenant, method string) error {
+	if !tenant.IsAllowed(method) {
+		return ErrMethodNotWhitelisted
+	}
+
+	ok, err := tenant.requestLim.Take(ctx, tenant.Name)
+	if err != nil {
+		log.Warn("error taking tenant rate limit", "tenant", tenant.Name, "err", err)
+		return ErrOverRateLimit
+	}
+	if !ok {
+		return ErrOverRateLimit
+	}
+
+	units := tenant.ComputeUnits(method)
+	ok, err = tenant.computeUnitLim.TakeN(ctx, tenant.Name, units)
+	if err != nil {
+		log.Warn("error taking tenant compute unit limit", "tenant", tenant.Name, "err", err)
+		return ErrOverRateLimit
+	}
+	if !ok {
+		return ErrOverRateLimit
+	}
+
+	month := UsageMonth(time.Now())
+	if tenant.monthlyComputeUnitQuota > 0 {
+		usage, err := r.usage.Get(ctx, tenant.Name, month)
+		if err != nil {
+			log.Error("error getting tenant usage", "tenant", tenant.Name, "err", err)
+			return ErrInternal
+		}
+		if usage.ComputeUnits+int64(units) > tenant.monthlyComputeUnitQuota {
+			return ErrOverQuota
+		}
+	}
+
+	if err := r.usage.Incr(ctx, tenant.Name, month, method, units); err != nil {
+		// Usage is best-effort, so don't fail requests when it can't be counted.
+		log.Error("error counting tenant usage", "tenant", tenant.Name, "err", err)
+	}
+	return nil
+}
+
+// Usage returns the usage of a tenant during a month, formatted like UsageMonth.
+func (r *TenantRegistry) Usage(ctx context.Context, name string, month string) (*TenantUsage, error) {
+	if !r.HasTenant(name) {
+		return nil, ErrUnknownTenant
+	}
+	return r.usage.Get(ctx, name, month)
+}
+
+func GetTenant(ctx context.Context) *Tenant {
+	tenant, ok := ctx.Value(ContextKeyTenant).(*Tenant)
+	if !ok {
+		return nil
+	}
+	return tenant
+}
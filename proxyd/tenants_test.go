@@ -0,0 +1,272 @@
+package proxyd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/require"
+)
+
+const testTenantsFile = `
+[compute_units]
+eth_call = 10
+eth_getLogs = 50
+
+[tenants.acme]
+key = "acme_key"
+requests_per_second = 3
+compute_units_per_second = 25
+allowed_methods = ["eth_call", "eth_chainId"]
+
+[tenants.globex]
+key = "globex_key"
+backend_group = "premium"
+monthly_compute_unit_quota = 110
+[tenants.globex.compute_units]
+eth_call = 20
+`
+
+func newTestTenantRegistry(t *testing.T, contents string) (*TenantRegistry, string) {
+	path := filepath.Join(t.TempDir(), "tenants.toml")
+	require.NoError(t, os.WriteFile(path, []byte(contents), 0o600))
+	registry, err := NewTenantRegistry(
+		path,
+		func(dur time.Duration, max int, prefix string) FrontendRateLimiter {
+			return NewMemoryFrontendRateLimit(time.Hour, max)
+		},
+		map[string]*BackendGroup{
+			"main":    {Name: "main"},
+			"premium": {Name: "premium"},
+		},
+		map[string]string{
+			"eth_call":     "main",
+			"eth_chainId":  "main",
+			"eth_getLogs":  "main",
+			"net_version":  "main",
+			"eth_getBlock": "main",
+		},
+		NewMemoryUsageCounter(),
+	)
+	require.NoError(t, err)
+	return registry, path
+}
+
+func TestTenantRegistry(t *testing.T) {
+	registry, path := newTestTenantRegistry(t, testTenantsFile)
+	require.Equal(t, []string{"acme", "globex"}, registry.Names())
+
+	acme := registry.Get("acme_key")
+	require.NotNil(t, acme)
+	require.Equal(t, "acme", acme.Name)
+	require.Equal(t, "", acme.BackendGroup)
+	require.Equal(t, 10, acme.ComputeUnits("eth_call"))
+	require.Equal(t, 1, acme.ComputeUnits("eth_chainId"))
+	require.True(t, acme.IsAllowed("eth_call"))
+	require.False(t, acme.IsAllowed("eth_getLogs"))
+
+	globex := registry.Get("globex_key")
+	require.NotNil(t, globex)
+	require.Equal(t, "premium", globex.BackendGroup)
+	require.Equal(t, 20, globex.ComputeUnits("eth_call"))
+	require.Equal(t, 50, globex.ComputeUnits("eth_getLogs"))
+	require.True(t, globex.IsAllowed("eth_getLogs"))
+
+	require.Nil(t, registry.Get("acme"))
+	require.Nil(t, registry.Get(""))
+
+	t.Run("unchanged file", func(t *testing.T) {
+		reloaded, err := registry.Reload()
+		require.NoError(t, err)
+		require.False(t, reloaded)
+		require.Same(t, acme, registry.Get("acme_key"))
+	})
+
+	t.Run("invalid file keeps tenants", func(t *testing.T) {
+		invalid := []string{
+			"[tenants.foo]\nrequests_per_second = 1",
+			"[tenants.foo]\nkey = \"none\"",
+			"[tenants.foo]\nkey = \"a\"\n[tenants.bar]\nkey = \"a\"",
+			"[tenants.foo]\nkey = \"a\"\nbackend_group = \"nope\"",
+			"[tenants.foo]\nkey = \"a\"\nallowed_methods = [\"eth_sendTransaction\"]",
+			"[tenants.foo]\nkey = \"$TENANTS_TEST_UNSET_KEY\"",
+			"[tenants.foo",
+		}
+		for _, contents := range invalid {
+			require.NoError(t, os.WriteFile(path, []byte(contents), 0o600))
+			_, err := registry.Reload()
+			require.Error(t, err, contents)
+			require.Same(t, acme, registry.Get("acme_key"))
+		}
+	})
+
+	t.Run("changed file", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(path, []byte("[tenants.initech]\nkey = \"initech_key\""), 0o600))
+		reloaded, err := registry.Reload()
+		require.NoError(t, err)
+		require.True(t, reloaded)
+		require.Equal(t, []string{"initech"}, registry.Names())
+		require.Nil(t, registry.Get("acme_key"))
+		require.NotNil(t, registry.Get("initech_key"))
+	})
+}
+
+func TestTenantRegistryTake(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("allowlist", func(t *testing.T) {
+		registry, _ := newTestTenantRegistry(t, testTenantsFile)
+		acme := registry.Get("acme_key")
+		require.Equal(t, ErrMethodNotWhitelisted, registry.Take(ctx, acme, "eth_getLogs"))
+		require.NoError(t, registry.Take(ctx, acme, "eth_chainId"))
+	})
+
+	t.Run("requests per second", func(t *testing.T) {
+		registry, _ := newTestTenantRegistry(t, testTenantsFile)
+		acme := registry.Get("acme_key")
+		for i := 0; i < 3; i++ {
+			require.NoError(t, registry.Take(ctx, acme, "eth_chainId"))
+		}
+		require.Equal(t, ErrOverRateLimit, registry.Take(ctx, acme, "eth_chainId"))
+	})
+
+	t.Run("compute units per second", func(t *testing.T) {
+		registry, _ := newTestTenantRegistry(t, testTenantsFile)
+		acme := registry.Get("acme_key")
+		require.NoError(t, registry.Take(ctx, acme, "eth_call"))
+		require.NoError(t, registry.Take(ctx, acme, "eth_call"))
+		require.Equal(t, ErrOverRateLimit, registry.Take(ctx, acme, "eth_call"))
+	})
+
+	t.Run("monthly quota", func(t *testing.T) {
+		registry, _ := newTestTenantRegistry(t, testTenantsFile)
+		globex := registry.Get("globex_key")
+		require.NoError(t, registry.Take(ctx, globex, "eth_getLogs"))
+		require.NoError(t, registry.Take(ctx, globex, "eth_getLogs"))
+		require.Equal(t, ErrOverQuota, registry.Take(ctx, globex, "eth_call"))
+		require.NoError(t, registry.Take(ctx, globex, "eth_chainId"))
+
+		usage, err := registry.Usage(ctx, "globex", UsageMonth(time.Now()))
+		require.NoError(t, err)
+		require.EqualValues(t, 3, usage.Requests)
+		require.EqualValues(t, 101, usage.ComputeUnits)
+	})
+
+	t.Run("unknown tenant usage", func(t *testing.T) {
+		registry, _ := newTestTenantRegistry(t, testTenantsFile)
+		_, err := registry.Usage(ctx, "initech", UsageMonth(time.Now()))
+		require.ErrorIs(t, err, ErrUnknownTenant)
+	})
+}
+
+func TestUsageCounter(t *testing.T) {
+	redisServer, err := miniredis.Run()
+	require.NoError(t, err)
+	defer redisServer.Close()
+
+	redisClient := redis.NewClient(&redis.Options{
+		Addr: fmt.Sprintf("127.0.0.1:%s", redisServer.Port()),
+	})
+
+	counters := []struct {
+		name    string
+		counter UsageCounter
+	}{
+		{"memory", NewMemoryUsageCounter()},
+		{"redis", NewRedisUsageCounter(redisClient)},
+	}
+
+	ctx := context.Background()
+	for _, cfg := range counters {
+		counter := cfg.counter
+		t.Run(cfg.name, func(t *testing.T) {
+			require.NoError(t, counter.Incr(ctx, "acme", "2022-10", "eth_call", 10))
+			require.NoError(t, counter.Incr(ctx, "acme", "2022-10", "eth_call", 10))
+			require.NoError(t, counter.Incr(ctx, "acme", "2022-10", "eth_chainId", 1))
+			require.NoError(t, counter.Incr(ctx, "acme", "2022-11", "eth_call", 10))
+			require.NoError(t, counter.Incr(ctx, "globex", "2022-10", "eth_call", 10))
+
+			usage, err := counter.Get(ctx, "acme", "2022-10")
+			require.NoError(t, err)
+			require.Equal(t, &TenantUsage{
+				Requests:     3,
+				ComputeUnits: 21,
+				Methods: map[string]*MethodUsage{
+					"eth_call":    {Requests: 2, ComputeUnits: 20},
+					"eth_chainId": {Requests: 1, ComputeUnits: 1},
+				},
+			}, usage)
+
+			usage, err = counter.Get(ctx, "acme", "2022-12")
+			require.NoError(t, err)
+			require.Equal(t, newTenantUsage(), usage)
+		})
+	}
+}
+
+func TestAdminServer(t *testing.T) {
+	registry, path := newTestTenantRegistry(t, testTenantsFile)
+	ctx := context.Background()
+	require.NoError(t, registry.Take(ctx, registry.Get("acme_key"), "eth_call"))
+	require.NoError(t, registry.Take(ctx, registry.Get("globex_key"), "eth_getLogs"))
+
+	srv := httptest.NewServer(NewAdminServer("secret", registry, nil).Handler())
+	defer srv.Close()
+
+	do := func(method string, path string, token string) (int, map[string]interface{}) {
+		req, err := http.NewRequest(method, srv.URL+path, nil)
+		require.NoError(t, err)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		body := make(map[string]interface{})
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&body))
+		return res.StatusCode, body
+	}
+
+	code, _ := do("GET", "/tenants/usage", "")
+	require.Equal(t, http.StatusUnauthorized, code)
+	code, _ = do("GET", "/tenants/usage", "wrong")
+	require.Equal(t, http.StatusUnauthorized, code)
+
+	month := UsageMonth(time.Now())
+	code, body := do("GET", "/tenants/usage", "secret")
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, month, body["month"])
+	tenants := body["tenants"].(map[string]interface{})
+	require.Len(t, tenants, 2)
+	require.EqualValues(t, 10, tenants["acme"].(map[string]interface{})["compute_units"])
+	require.EqualValues(t, 50, tenants["globex"].(map[string]interface{})["compute_units"])
+
+	code, body = do("GET", "/tenants/globex/usage?month=2000-01", "secret")
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, "globex", body["tenant"])
+	require.EqualValues(t, 0, body["usage"].(map[string]interface{})["requests"])
+
+	code, _ = do("GET", "/tenants/initech/usage", "secret")
+	require.Equal(t, http.StatusNotFound, code)
+	code, _ = do("GET", "/tenants/usage?month=10-2022", "secret")
+	require.Equal(t, http.StatusBadRequest, code)
+
+	require.NoError(t, os.WriteFile(path, []byte("[tenants.initech]\nkey = \"initech_key\""), 0o600))
+	code, body = do("POST", "/tenants/reload", "secret")
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, true, body["reloaded"])
+	require.Equal(t, []interface{}{"initech"}, body["tenants"])
+
+	require.NoError(t, os.WriteFile(path, []byte("[tenants.initech"), 0o600))
+	code, _ = do("POST", "/tenants/reload", "secret")
+	require.Equal(t, http.StatusBadRequest, code)
+	require.NotNil(t, registry.Get("initech_key"))
+}
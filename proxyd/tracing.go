@@ -0,0 +1,479 @@
+package proxyd
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+)
+
+const ContextKeySpan = "span"
+
+// The spans recorded for every HTTP request. The steps of a request are the direct children
+// of its root span, except for the spans of its calls. Each message of a websocket client is
+// traced as a request with a single call.
+const (
+	SpanRequest        = "proxyd.request"
+	SpanWSRequest      = "proxyd.ws_request"
+	SpanRateLimit      = "rate_limit"
+	SpanReadBody       = "read_body"
+	SpanParse          = "parse"
+	SpanCall           = "rpc.call"
+	SpanCache          = "cache"
+	SpanForward        = "forward"
+	SpanBackendRequest = "backend.request"
+	SpanWriteResponse  = "write_response"
+)
+
+const (
+	AttrReqID        = "proxyd.req_id"
+	AttrAuth         = "proxyd.auth"
+	AttrRemoteIP     = "client.address"
+	AttrUserAgent    = "user_agent.original"
+	AttrOrigin       = "proxyd.origin"
+	AttrBatch        = "proxyd.batch"
+	AttrWebsocket    = "proxyd.websocket"
+	AttrStatus       = "http.status_code"
+	AttrRateLimited  = "proxyd.rate_limited"
+	AttrIndex        = "proxyd.batch_index"
+	AttrMethod       = "rpc.method"
+	AttrBackendGroup = "proxyd.backend_group"
+	AttrBackend      = "proxyd.backend"
+	AttrRetries      = "proxyd.retries"
+	AttrAttempt      = "proxyd.attempt"
+	AttrCacheHit     = "proxyd.cache_hit"
+	AttrBatchSize    = "proxyd.batch_size"
+	AttrErrorCode    = "rpc.jsonrpc.error_code"
+)
+
+const (
+	spanKindInternal = 1
+	spanKindServer   = 2
+	spanKindClient   = 3
+)
+
+type traceID [16]byte
+
+type spanID [8]byte
+
+type trace struct {
+	id      traceID
+	sampled bool
+
+	mtx   sync.Mutex
+	spans []*Span
+}
+
+// Span is a timed step of a request. The methods of a nil span do nothing, so that requests
+// can be instrumented unconditionally.
+type Span struct {
+	trace    *trace
+	name     string
+	kind     int
+	id       spanID
+	parentID spanID
+	start    time.Time
+
+	mtx        sync.Mutex
+	end        time.Time
+	attributes map[string]interface{}
+	errMsg     string
+}
+
+func (t *trace) newSpan(name string, kind int, parentID spanID) *Span {
+	s := &Span{
+		trace:      t,
+		name:       name,
+		kind:       kind,
+		id:         newSpanID(),
+		parentID:   parentID,
+		start:      time.Now(),
+		attributes: make(map[string]interface{}),
+	}
+	t.mtx.Lock()
+	t.spans = append(t.spans, s)
+	t.mtx.Unlock()
+	return s
+}
+
+// StartSpan starts a child span of the span of ctx. It returns a nil span if ctx isn't traced.
+func StartSpan(ctx context.Context, name string) (context.Context, *Span) {
+	parent := SpanFromContext(ctx)
+	if parent == nil {
+		return ctx, nil
+	}
+	kind := spanKindInternal
+	if name == SpanBackendRequest {
+		kind = spanKindClient
+	}
+	span := parent.trace.newSpan(name, kind, parent.id)
+	return context.WithValue(ctx, ContextKeySpan, span), span // nolint:staticcheck
+}
+
+func SpanFromContext(ctx context.Context) *Span {
+	span, ok := ctx.Value(ContextKeySpan).(*Span)
+	if !ok {
+		return nil
+	}
+	return span
+}
+
+func (s *Span) SetAttribute(key string, value interface{}) {
+	if s == nil {
+		return
+	}
+	s.mtx.Lock()
+	s.attributes[key] = value
+	s.mtx.Unlock()
+}
+
+// SetError marks the span as failed if err is not nil.
+func (s *Span) SetError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mtx.Lock()
+	s.errMsg = err.Error()
+	s.mtx.Unlock()
+}
+
+// SetResponse records the error code of res, if any.
+func (s *Span) SetResponse(res *RPCRes) {
+	if s == nil || res == nil || !res.IsError() {
+		return
+	}
+	s.SetAttribute(AttrErrorCode, res.Error.Code)
+	s.SetError(res.Error)
+}
+
+// End ends the span. Only the first call has an effect.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mtx.Lock()
+	if s.end.IsZero() {
+		s.end = time.Now()
+	}
+	s.mtx.Unlock()
+}
+
+// Traceparent returns the W3C trace context header that makes the span the parent of the
+// spans of the backend.
+func (s *Span) Traceparent() string {
+	flags := "00"
+	if s.trace.sampled {
+		flags = "01"
+	}
+	return "00-" + hex.EncodeToString(s.trace.id[:]) + "-" + hex.EncodeToString(s.id[:]) + "-" + flags
+}
+
+// ServedBy returns the backend that answered the backend requests started under the span, and
+// the number of requests that failed before.
+func (s *Span) ServedBy() (string, int) {
+	if s == nil {
+		return "", 0
+	}
+	var backend string
+	var attempts int
+	for _, child := range s.trace.children(s.id) {
+		if child.name != SpanBackendRequest {
+			continue
+		}
+		attempts++
+		child.mtx.Lock()
+		if child.errMsg == "" {
+			backend, _ = child.attributes[AttrBackend].(string)
+		}
+		child.mtx.Unlock()
+	}
+	if backend == "" || attempts == 0 {
+		return backend, attempts
+	}
+	return backend, attempts - 1
+}
+
+func (t *trace) children(id spanID) []*Span {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	var children []*Span
+	for _, s := range t.spans {
+		if s.parentID == id {
+			children = append(children, s)
+		}
+	}
+	return children
+}
+
+// spanSnapshot is a copy of a span that is no longer updated.
+type spanSnapshot struct {
+	name       string
+	kind       int
+	id         spanID
+	parentID   spanID
+	start      time.Time
+	end        time.Time
+	attributes map[string]interface{}
+	errMsg     string
+}
+
+// snapshot copies the spans of the trace. The spans that haven't ended end with the trace.
+func (t *trace) snapshot(end time.Time) []*spanSnapshot {
+	t.mtx.Lock()
+	spans := make([]*Span, len(t.spans))
+	copy(spans, t.spans)
+	t.mtx.Unlock()
+
+	snapshots := make([]*spanSnapshot, len(spans))
+	for i, s := range spans {
+		s.mtx.Lock()
+		snap := &spanSnapshot{
+			name:       s.name,
+			kind:       s.kind,
+			id:         s.id,
+			parentID:   s.parentID,
+			start:      s.start,
+			end:        s.end,
+			attributes: make(map[string]interface{}, len(s.attributes)),
+			errMsg:     s.errMsg,
+		}
+		for k, v := range s.attributes {
+			snap.attributes[k] = v
+		}
+		s.mtx.Unlock()
+		if snap.end.IsZero() {
+			snap.end = end
+		}
+		snapshots[i] = snap
+	}
+	return snapshots
+}
+
+// Tracer records the spans of the HTTP requests and websocket messages, and writes them as
+// access log records and as OTLP spans.
+type Tracer struct {
+	accessLog   io.WriteCloser
+	logMtx      sync.Mutex
+	exporter    *OTLPExporter
+	sampleRatio float64
+}
+
+// NewTracer creates a tracer. accessLog and exporter may be nil. Only the share sampleRatio of
+// the traces that don't have a sampled parent are exported.
+func NewTracer(accessLog io.WriteCloser, exporter *OTLPExporter, sampleRatio float64) *Tracer {
+	return &Tracer{
+		accessLog:   accessLog,
+		exporter:    exporter,
+		sampleRatio: sampleRatio,
+	}
+}
+
+// buildTracer creates the tracer of the config, or returns nil if neither the access log nor the
+// exporter is enabled.
+func buildTracer(accessLogConfig AccessLogConfig, tracingConfig TracingConfig) (*Tracer, error) {
+	sampleRatio := tracingConfig.SampleRatio
+	if sampleRatio == 0 {
+		sampleRatio = 1
+	}
+	if sampleRatio < 0 || sampleRatio > 1 {
+		return nil, errors.New("tracing.sample_ratio must be between 0 and 1")
+	}
+
+	var exporter *OTLPExporter
+	if tracingConfig.OTLPEndpoint != "" {
+		endpoint, err := ReadFromEnvOrConfig(tracingConfig.OTLPEndpoint)
+		if err != nil {
+			return nil, err
+		}
+		headers := make(map[string]string, len(tracingConfig.Headers))
+		for name, value := range tracingConfig.Headers {
+			headers[name], err = ReadFromEnvOrConfig(value)
+			if err != nil {
+				return nil, err
+			}
+		}
+		exporter = NewOTLPExporter(endpoint, headers, tracingConfig.ServiceName, time.Duration(tracingConfig.ExportInterval))
+	}
+
+	var accessLog io.WriteCloser
+	if accessLogConfig.File != "" {
+		path, err := ReadFromEnvOrConfig(accessLogConfig.File)
+		if err != nil {
+			return nil, err
+		}
+		file, err := NewRotatingFile(path, accessLogConfig.MaxSizeMB, accessLogConfig.MaxBackups)
+		if err != nil {
+			return nil, wrapErr(err, "error opening access log")
+		}
+		accessLog = file
+	}
+
+	if accessLog == nil && exporter == nil {
+		return nil, nil
+	}
+	if exporter != nil {
+		exporter.Start()
+	}
+	return NewTracer(accessLog, exporter, sampleRatio), nil
+}
+
+// StartRequest starts the root span of r. The trace continues the trace of the client if r has
+// a traceparent header.
+func (t *Tracer) StartRequest(r *http.Request) (*http.Request, *Span) {
+	if t == nil {
+		return r, nil
+	}
+	tr := &trace{}
+	var parentID spanID
+	if id, parent, sampled, ok := parseTraceparent(r.Header.Get("traceparent")); ok {
+		tr.id = id
+		tr.sampled = sampled
+		parentID = parent
+	} else {
+		tr.id = newTraceID()
+		tr.sampled = sample(t.sampleRatio)
+	}
+	root := tr.newSpan(SpanRequest, spanKindServer, parentID)
+	ctx := context.WithValue(r.Context(), ContextKeySpan, root) // nolint:staticcheck
+	return r.WithContext(ctx), root
+}
+
+// StartWSRequest starts the root span of a request sent by a websocket client. ctx is the
+// context of the connection.
+func (t *Tracer) StartWSRequest(ctx context.Context) (context.Context, *Span) {
+	if t == nil {
+		return ctx, nil
+	}
+	tr := &trace{
+		id:      newTraceID(),
+		sampled: sample(t.sampleRatio),
+	}
+	root := tr.newSpan(SpanWSRequest, spanKindServer, spanID{})
+	root.SetAttribute(AttrWebsocket, true)
+	root.SetAttribute(AttrReqID, GetReqID(ctx))
+	root.SetAttribute(AttrAuth, GetAuthCtx(ctx))
+	root.SetAttribute(AttrRemoteIP, stripXFF(GetXForwardedFor(ctx)))
+	return context.WithValue(ctx, ContextKeySpan, root), root // nolint:staticcheck
+}
+
+// FinishRequest ends the root span, and writes the trace.
+func (t *Tracer) FinishRequest(root *Span) {
+	if t == nil || root == nil {
+		return
+	}
+	root.End()
+	root.mtx.Lock()
+	end := root.end
+	root.mtx.Unlock()
+	spans := root.trace.snapshot(end)
+
+	if t.accessLog != nil {
+		record := newAccessLogRecord(root.trace.id, spans)
+		line, err := json.Marshal(record)
+		if err != nil {
+			log.Error("error encoding access log record", "err", err)
+		} else {
+			t.logMtx.Lock()
+			_, err = t.accessLog.Write(append(line, '\n'))
+			t.logMtx.Unlock()
+			if err != nil {
+				log.Error("error writing access log", "err", err)
+			}
+		}
+	}
+	if t.exporter != nil && root.trace.sampled {
+		t.exporter.Export(root.trace.id, spans)
+	}
+}
+
+// Close flushes the spans that weren't exported yet, and closes the access log.
+func (t *Tracer) Close() {
+	if t == nil {
+		return
+	}
+	if t.exporter != nil {
+		t.exporter.Stop()
+	}
+	if t.accessLog != nil {
+		if err := t.accessLog.Close(); err != nil {
+			log.Error("error closing access log", "err", err)
+		}
+	}
+}
+
+// parseTraceparent parses a W3C trace context header.
+func parseTraceparent(header string) (traceID, spanID, bool, bool) {
+	var id traceID
+	var parent spanID
+	parts := strings.Split(header, "-")
+	if len(parts) != 4 || len(parts[0]) != 2 || parts[0] == "ff" {
+		return id, parent, false, false
+	}
+	if n, err := hex.Decode(id[:], []byte(parts[1])); err != nil || n != len(id) || id == (traceID{}) {
+		return id, parent, false, false
+	}
+	if n, err := hex.Decode(parent[:], []byte(parts[2])); err != nil || n != len(parent) || parent == (spanID{}) {
+		return id, parent, false, false
+	}
+	flags, err := hex.DecodeString(parts[3])
+	if err != nil || len(flags) != 1 {
+		return id, parent, false, false
+	}
+	return id, parent, flags[0]&1 == 1, true
+}
+
+func newTraceID() traceID {
+	var id traceID
+	_, _ = rand.Read(id[:])
+	return id
+}
+
+func newSpanID() spanID {
+	var id spanID
+	_, _ = rand.Read(id[:])
+	return id
+}
+
+func sample(ratio float64) bool {
+	if ratio >= 1 {
+		return true
+	}
+	if ratio <= 0 {
+		return false
+	}
+	const precision = 1 << 20
+	n, err := rand.Int(rand.Reader, big.NewInt(precision))
+	if err != nil {
+		return false
+	}
+	return float64(n.Int64()) < ratio*precision
+}
+
+// statusRecorder records the status code written by a handler.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusRecorder) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusRecorder) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(p)
+}
@@ -0,0 +1,151 @@
+package proxyd
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	id, parent, sampled, ok := parseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	require.True(t, ok)
+	require.True(t, sampled)
+	require.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", hex.EncodeToString(id[:]))
+	require.Equal(t, "00f067aa0ba902b7", hex.EncodeToString(parent[:]))
+
+	_, _, sampled, ok = parseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+	require.True(t, ok)
+	require.False(t, sampled)
+
+	for _, header := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e47-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-zz",
+	} {
+		_, _, _, ok := parseTraceparent(header)
+		require.False(t, ok, header)
+	}
+}
+
+func TestTraceRecords(t *testing.T) {
+	tracer := NewTracer(nil, nil, 1)
+	r, root := tracer.StartRequest(httptest.NewRequest("POST", "/", nil))
+	root.SetAttribute(AttrReqID, "abc")
+	root.SetAttribute(AttrBatch, true)
+	root.SetAttribute(AttrStatus, 200)
+	ctx := r.Context()
+
+	_, span := StartSpan(ctx, SpanReadBody)
+	span.End()
+	_, call := StartSpan(ctx, SpanCall)
+	call.SetAttribute(AttrIndex, 1)
+	call.SetAttribute(AttrMethod, "eth_call")
+	_, rejected := StartSpan(ctx, SpanCall)
+	rejected.SetAttribute(AttrIndex, 0)
+	rejected.SetAttribute(AttrRateLimited, true)
+	rejected.SetResponse(NewRPCErrorRes(nil, ErrOverRateLimit))
+	rejected.End()
+
+	forwardCtx, forward := StartSpan(ctx, SpanForward)
+	for i, backend := range []string{"a", "a", "b"} {
+		_, attempt := StartSpan(forwardCtx, SpanBackendRequest)
+		attempt.SetAttribute(AttrBackend, backend)
+		if i < 2 {
+			attempt.SetError(errors.New("failed"))
+		}
+		attempt.End()
+	}
+	forward.End()
+	backend, retries := forward.ServedBy()
+	require.Equal(t, "b", backend)
+	require.Equal(t, 2, retries)
+	call.SetAttribute(AttrBackend, backend)
+	call.SetAttribute(AttrRetries, retries)
+	call.End()
+	root.End()
+
+	record := newAccessLogRecord(root.trace.id, root.trace.snapshot(root.end))
+	require.Equal(t, hex.EncodeToString(root.trace.id[:]), record.TraceID)
+	require.Equal(t, "abc", record.ReqID)
+	require.True(t, record.Batch)
+	require.Equal(t, 200, record.Status)
+	require.Len(t, record.Steps, 2)
+	require.Contains(t, record.Steps, SpanReadBody)
+	require.Contains(t, record.Steps, SpanForward)
+	require.Equal(t, []*AccessLogCall{
+		{Index: 0, RateLimited: true, ErrorCode: ErrOverRateLimit.Code, DurationMS: record.Calls[0].DurationMS},
+		{Index: 1, Method: "eth_call", Backend: "b", Retries: 2, DurationMS: record.Calls[1].DurationMS},
+	}, record.Calls)
+
+	// Requests aren't traced without a tracer.
+	r, root = (*Tracer)(nil).StartRequest(httptest.NewRequest("POST", "/", nil))
+	require.Nil(t, root)
+	_, span = StartSpan(r.Context(), SpanCall)
+	require.Nil(t, span)
+	span.SetAttribute(AttrMethod, "eth_call")
+	span.End()
+	_, span = StartSpan(context.Background(), SpanCall)
+	require.Nil(t, span)
+}
+
+func TestWSRequestRecord(t *testing.T) {
+	tracer := NewTracer(nil, nil, 1)
+	ctx := context.WithValue(context.Background(), ContextKeyReqID, "abc")    // nolint:staticcheck
+	ctx = context.WithValue(ctx, ContextKeyXForwardedFor, "1.2.3.4, 5.6.7.8") // nolint:staticcheck
+	ctx, root := tracer.StartWSRequest(ctx)
+	_, call := StartSpan(ctx, SpanCall)
+	call.SetAttribute(AttrMethod, "eth_subscribe")
+	call.SetResponse(NewRPCErrorRes(nil, ErrMethodNotWhitelisted))
+	call.End()
+	root.End()
+
+	record := newAccessLogRecord(root.trace.id, root.trace.snapshot(root.end))
+	require.True(t, record.Websocket)
+	require.False(t, record.Batch)
+	require.Equal(t, "abc", record.ReqID)
+	require.Equal(t, "none", record.Auth)
+	require.Equal(t, "1.2.3.4", record.RemoteIP)
+	require.Equal(t, []*AccessLogCall{
+		{Method: "eth_subscribe", ErrorCode: ErrMethodNotWhitelisted.Code, DurationMS: record.Calls[0].DurationMS},
+	}, record.Calls)
+
+	ctx, root = (*Tracer)(nil).StartWSRequest(context.Background())
+	require.Nil(t, root)
+	require.Nil(t, SpanFromContext(ctx))
+}
+
+func TestOTLPSpan(t *testing.T) {
+	tracer := NewTracer(nil, nil, 1)
+	r, root := tracer.StartRequest(httptest.NewRequest("POST", "/", nil))
+	_, span := StartSpan(r.Context(), SpanBackendRequest)
+	span.SetAttribute(AttrBackend, "a")
+	span.SetAttribute(AttrAttempt, 2)
+	span.SetAttribute(AttrCacheHit, false)
+	span.SetError(errors.New("failed"))
+	span.End()
+	root.End()
+
+	spans := root.trace.snapshot(root.end)
+	encoded := newOTLPSpan(root.trace.id, spans[1])
+	require.Equal(t, hex.EncodeToString(root.trace.id[:]), encoded.TraceID)
+	require.Equal(t, hex.EncodeToString(root.id[:]), encoded.ParentSpanID)
+	require.Equal(t, spanKindClient, encoded.Kind)
+	require.Equal(t, otlpStatus{Code: otlpStatusCodeError, Message: "failed"}, encoded.Status)
+	require.JSONEq(t, `[
+		{"key": "proxyd.attempt", "value": {"intValue": "2"}},
+		{"key": "proxyd.backend", "value": {"stringValue": "a"}},
+		{"key": "proxyd.cache_hit", "value": {"boolValue": false}}
+	]`, string(mustMarshalJSON(encoded.Attributes)))
+
+	// The root span has no parent unless the client sent a trace context.
+	require.Empty(t, newOTLPSpan(root.trace.id, spans[0]).ParentSpanID)
+	require.Equal(t, spanKindServer, spans[0].kind)
+}
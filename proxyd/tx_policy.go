@@ -0,0 +1,242 @@
+package proxyd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// DefaultTxBroadcastTimeout is the time given to every backend of the broadcast group to accept
+// a transaction, regardless of when the client got its response.
+const DefaultTxBroadcastTimeout = 10 * time.Second
+
+const (
+	TxPolicyRejectionChainID  = "chain_id"
+	TxPolicyRejectionGasLimit = "gas_limit"
+	TxPolicyRejectionFeeCap   = "fee_cap"
+	TxPolicyRejectionNonceGap = "nonce_gap"
+)
+
+var (
+	ErrTxInvalidChainID = &RPCErr{
+		Code:          JSONRPCErrorInternal - 20,
+		Message:       "invalid transaction chain ID",
+		HTTPErrorCode: 400,
+	}
+	ErrTxGasLimitTooHigh = &RPCErr{
+		Code:          JSONRPCErrorInternal - 21,
+		Message:       "transaction gas limit is too high",
+		HTTPErrorCode: 400,
+	}
+	ErrTxFeeCapTooLow = &RPCErr{
+		Code:          JSONRPCErrorInternal - 22,
+		Message:       "transaction fee cap is too low",
+		HTTPErrorCode: 400,
+	}
+	ErrTxNonceGapTooLarge = &RPCErr{
+		Code:          JSONRPCErrorInternal - 23,
+		Message:       "transaction nonce is too far ahead of the pending nonce of the sender",
+		HTTPErrorCode: 400,
+	}
+)
+
+// TxPolicy validates the transactions sent with eth_sendRawTransaction before they are forwarded,
+// and optionally broadcasts them to every backend of a group so that they are known to the sequencer
+// and to the replicas the clients read from.
+type TxPolicy struct {
+	chainID        *big.Int
+	maxGasLimit    uint64
+	minFeeCap      *big.Int
+	maxNonceGap    uint64
+	broadcastGroup *BackendGroup
+	timeout        time.Duration
+}
+
+// NewTxPolicy builds the policy of the config. broadcastGroup may be nil if transactions
+// aren't broadcast.
+func NewTxPolicy(config TxPolicyConfig, broadcastGroup *BackendGroup) *TxPolicy {
+	p := &TxPolicy{
+		maxGasLimit:    config.MaxGasLimit,
+		maxNonceGap:    config.MaxNonceGap,
+		broadcastGroup: broadcastGroup,
+		timeout:        DefaultTxBroadcastTimeout,
+	}
+	if config.ChainID != 0 {
+		p.chainID = new(big.Int).SetUint64(config.ChainID)
+	}
+	if config.MinFeeCap != 0 {
+		p.minFeeCap = new(big.Int).SetUint64(config.MinFeeCap)
+	}
+	return p
+}
+
+// Check validates a transaction of sender. The pending nonce of the sender is read from group,
+// or from the broadcast group if there is one. The nonce gap isn't checked if the pending nonce
+// can't be read, since the backends validate nonces themselves.
+func (p *TxPolicy) Check(ctx context.Context, tx *types.Transaction, sender common.Address, group *BackendGroup) error {
+	if p.chainID != nil && tx.ChainId().Cmp(p.chainID) != 0 {
+		log.Debug("rejected transaction with invalid chain ID", "chain_id", tx.ChainId(), "req_id", GetReqID(ctx))
+		RecordTxPolicyRejection(TxPolicyRejectionChainID)
+		return ErrTxInvalidChainID
+	}
+	if p.maxGasLimit > 0 && tx.Gas() > p.maxGasLimit {
+		log.Debug("rejected transaction with too high gas limit", "gas", tx.Gas(), "req_id", GetReqID(ctx))
+		RecordTxPolicyRejection(TxPolicyRejectionGasLimit)
+		return ErrTxGasLimitTooHigh
+	}
+	if p.minFeeCap != nil && tx.GasFeeCap().Cmp(p.minFeeCap) < 0 {
+		log.Debug("rejected transaction with too low fee cap", "fee_cap", tx.GasFeeCap(), "req_id", GetReqID(ctx))
+		RecordTxPolicyRejection(TxPolicyRejectionFeeCap)
+		return ErrTxFeeCapTooLow
+	}
+	if p.maxNonceGap == 0 {
+		return nil
+	}
+
+	if p.broadcastGroup != nil {
+		group = p.broadcastGroup
+	}
+	pendingNonce, err := pendingNonce(ctx, group, sender)
+	if err != nil {
+		log.Warn("error getting pending nonce, skipping nonce gap check", "sender", sender, "req_id", GetReqID(ctx), "err", err)
+		return nil
+	}
+	if tx.Nonce() > pendingNonce && tx.Nonce()-pendingNonce > p.maxNonceGap {
+		log.Debug("rejected transaction with too large nonce gap", "sender", sender, "nonce", tx.Nonce(), "pending_nonce", pendingNonce, "req_id", GetReqID(ctx))
+		RecordTxPolicyRejection(TxPolicyRejectionNonceGap)
+		return ErrTxNonceGapTooLarge
+	}
+	return nil
+}
+
+func pendingNonce(ctx context.Context, group *BackendGroup, sender common.Address) (uint64, error) {
+	req := &RPCReq{
+		JSONRPC: JSONRPCVersion,
+		Method:  "eth_getTransactionCount",
+		Params:  mustMarshalJSON([]string{sender.Hex(), "pending"}),
+		ID:      json.RawMessage("1"),
+	}
+	res, err := group.Forward(ctx, []*RPCReq{req}, false)
+	if err != nil {
+		return 0, err
+	}
+	if res[0].IsError() {
+		return 0, res[0].Error
+	}
+	var nonce hexutil.Uint64
+	if err := json.Unmarshal(mustMarshalJSON(res[0].Result), &nonce); err != nil {
+		return 0, fmt.Errorf("invalid pending nonce: %w", err)
+	}
+	return uint64(nonce), nil
+}
+
+// Broadcasts returns whether transactions are broadcast rather than forwarded.
+func (p *TxPolicy) Broadcasts() bool {
+	return p.broadcastGroup != nil
+}
+
+// Broadcast sends the transaction to every backend of the broadcast group at once, and returns
+// the first successful response. The other backends still get the transaction after the client
+// got its response. If every backend fails, the response of the first backend of the group is
+// returned.
+func (p *TxPolicy) Broadcast(ctx context.Context, req *RPCReq) *RPCRes {
+	backends := p.broadcastGroup.Backends
+	if len(backends) == 0 {
+		return NewRPCErrorRes(req.ID, ErrNoBackends)
+	}
+
+	type broadcastResult struct {
+		index int
+		res   *RPCRes
+	}
+	resC := make(chan broadcastResult, len(backends))
+	broadcastCtx, cancel := context.WithTimeout(detachContext(ctx), p.timeout)
+	var wg sync.WaitGroup
+	for i, be := range backends {
+		wg.Add(1)
+		go func(i int, be *Backend) {
+			defer wg.Done()
+			var res *RPCRes
+			backendRes, err := be.Forward(broadcastCtx, []*RPCReq{req}, false)
+			if err != nil {
+				log.Warn(
+					"error broadcasting transaction",
+					"backend_group", p.broadcastGroup.Name,
+					"name", be.Name,
+					"req_id", GetReqID(ctx),
+					"err", err,
+				)
+				res = NewRPCErrorRes(req.ID, err)
+			} else {
+				res = backendRes[0]
+			}
+			RecordTxBroadcast(p.broadcastGroup.Name, be.Name, !res.IsError())
+			resC <- broadcastResult{i, res}
+		}(i, be)
+	}
+	go func() {
+		wg.Wait()
+		cancel()
+	}()
+
+	failures := make([]*RPCRes, len(backends))
+	for range backends {
+		select {
+		case result := <-resC:
+			if !result.res.IsError() {
+				return result.res
+			}
+			failures[result.index] = result.res
+		case <-ctx.Done():
+			return NewRPCErrorRes(req.ID, ErrGatewayTimeout)
+		}
+	}
+	return failures[0]
+}
+
+// parseRawTransaction decodes the transaction of an eth_sendRawTransaction request, and
+// recovers its sender.
+func parseRawTransaction(ctx context.Context, req *RPCReq) (*types.Transaction, common.Address, error) {
+	var params []string
+	if err := json.Unmarshal(req.Params, &params); err != nil {
+		log.Debug("error unmarshaling raw transaction params", "err", err, "req_Id", GetReqID(ctx))
+		return nil, common.Address{}, ErrParseErr
+	}
+
+	if len(params) != 1 {
+		log.Debug("raw transaction request has invalid number of params", "req_id", GetReqID(ctx))
+		// The error below is identical to the one Geth responds with.
+		return nil, common.Address{}, ErrInvalidParams("missing value for required argument 0")
+	}
+
+	var data hexutil.Bytes
+	if err := data.UnmarshalText([]byte(params[0])); err != nil {
+		log.Debug("error decoding raw tx data", "err", err, "req_id", GetReqID(ctx))
+		// Geth returns the raw error from UnmarshalText.
+		return nil, common.Address{}, ErrInvalidParams(err.Error())
+	}
+
+	// Inflates a types.Transaction object from the transaction's raw bytes.
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(data); err != nil {
+		log.Debug("could not unmarshal transaction", "err", err, "req_id", GetReqID(ctx))
+		return nil, common.Address{}, ErrInvalidParams(err.Error())
+	}
+
+	// Convert the transaction into a Message object so that we can get the
+	// sender. This method performs an ecrecover, which can be expensive.
+	msg, err := tx.AsMessage(types.LatestSignerForChainID(tx.ChainId()), nil)
+	if err != nil {
+		log.Debug("could not get message from transaction", "err", err, "req_id", GetReqID(ctx))
+		return nil, common.Address{}, ErrInvalidParams(err.Error())
+	}
+	return tx, msg.From(), nil
+}
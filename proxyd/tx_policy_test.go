@@ -0,0 +1,87 @@
+package proxyd
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTxPolicyCheck(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	sender := crypto.PubkeyToAddress(key.PublicKey)
+
+	signTx := func(chainID int64, gas uint64, feeCap int64) *types.Transaction {
+		tx, err := types.SignNewTx(key, types.LatestSignerForChainID(big.NewInt(chainID)), &types.DynamicFeeTx{
+			ChainID:   big.NewInt(chainID),
+			Gas:       gas,
+			GasFeeCap: big.NewInt(feeCap),
+			GasTipCap: big.NewInt(1),
+		})
+		require.NoError(t, err)
+		return tx
+	}
+
+	policy := NewTxPolicy(TxPolicyConfig{
+		ChainID:     10,
+		MaxGasLimit: 1000000,
+		MinFeeCap:   100,
+	}, nil)
+	tests := []struct {
+		name string
+		tx   *types.Transaction
+		err  error
+	}{
+		{"valid", signTx(10, 21000, 100), nil},
+		{"wrong chain ID", signTx(1, 21000, 100), ErrTxInvalidChainID},
+		{"gas limit too high", signTx(10, 1000001, 100), ErrTxGasLimitTooHigh},
+		{"fee cap too low", signTx(10, 21000, 99), ErrTxFeeCapTooLow},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.err, policy.Check(context.Background(), tt.tx, sender, nil))
+		})
+	}
+
+	// Only the configured checks apply.
+	require.NoError(t, NewTxPolicy(TxPolicyConfig{}, nil).Check(context.Background(), signTx(1, 30000000, 0), sender, nil))
+}
+
+func TestParseRawTransaction(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	tx, err := types.SignNewTx(key, types.LatestSignerForChainID(big.NewInt(10)), &types.LegacyTx{
+		Nonce:    3,
+		Gas:      21000,
+		GasPrice: big.NewInt(1),
+	})
+	require.NoError(t, err)
+	data, err := tx.MarshalBinary()
+	require.NoError(t, err)
+
+	newReq := func(params interface{}) *RPCReq {
+		raw, err := json.Marshal(params)
+		require.NoError(t, err)
+		return &RPCReq{Method: "eth_sendRawTransaction", Params: raw}
+	}
+
+	parsed, sender, err := parseRawTransaction(context.Background(), newReq([]string{hexutil.Encode(data)}))
+	require.NoError(t, err)
+	require.Equal(t, tx.Hash(), parsed.Hash())
+	require.Equal(t, crypto.PubkeyToAddress(key.PublicKey), sender)
+
+	_, _, err = parseRawTransaction(context.Background(), newReq([]string{}))
+	require.Error(t, err)
+	_, _, err = parseRawTransaction(context.Background(), newReq([]string{"0x1234"}))
+	require.Error(t, err)
+	_, sender, err = parseRawTransaction(context.Background(), newReq(map[string]string{}))
+	require.Equal(t, ErrParseErr, err)
+	require.Equal(t, common.Address{}, sender)
+}
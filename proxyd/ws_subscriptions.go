@@ -0,0 +1,642 @@
+package proxyd
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	DefaultWSSubscriptionBufferSize = 256
+	wsSubscriptionRequestTimeout    = 10 * time.Second
+)
+
+var ErrWSSubscriberTooSlow = errors.New("client is too slow to receive subscription notifications")
+
+// wsSubscriber is a client connection receiving the notifications of
+// multiplexed subscriptions.
+type wsSubscriber struct {
+	notifications chan []byte
+	overflow      chan struct{}
+	overflowOnce  sync.Once
+	quit          chan struct{}
+	// subs maps the subscription IDs of the client to their upstream subscriptions.
+	subs map[string]*upstreamSubscription
+}
+
+// notify queues a notification without blocking. If the client can't keep
+// up with its notifications, the overflow channel is closed.
+func (s *wsSubscriber) notify(msg []byte) {
+	select {
+	case s.notifications <- msg:
+	default:
+		s.overflowOnce.Do(func() {
+			wsSubscribersDroppedTotal.Inc()
+			close(s.overflow)
+		})
+	}
+}
+
+// upstreamSubscription is a subscription to the backend, shared by the
+// client subscriptions with the same parameters.
+type upstreamSubscription struct {
+	key    string
+	params json.RawMessage
+	// id is the upstream subscription ID, or empty while disconnected.
+	id          string
+	subscribers map[string]*wsSubscriber
+	// subscribing is closed once the eth_subscribe in flight completes,
+	// and is nil if there is none. err is the error of the last one.
+	subscribing chan struct{}
+	err         error
+}
+
+type pendingWSRequest struct {
+	resC chan *wsUpstreamMsg
+	// onResult is called by the read loop before it reads the next message,
+	// with m.mtx held. It is called even if the request timed out.
+	onResult func(result json.RawMessage)
+}
+
+type wsUpstreamMsg struct {
+	ID     json.RawMessage `json:"id"`
+	Method string          `json:"method"`
+	Params struct {
+		Subscription string          `json:"subscription"`
+		Result       json.RawMessage `json:"result"`
+	} `json:"params"`
+	Result json.RawMessage `json:"result"`
+	Error  *RPCErr         `json:"error"`
+}
+
+// SubscriptionMultiplexer serves the newHeads and logs subscriptions of the
+// clients of a backend group over a single backend connection, with one
+// upstream subscription per unique set of parameters. If the connection
+// fails, it reconnects to the group and resubscribes, keeping the
+// subscription IDs of the clients.
+type SubscriptionMultiplexer struct {
+	groupName  string
+	bufferSize int
+
+	mtx   sync.Mutex
+	group *BackendGroup
+	// backend is the backend of conn.
+	backend   *Backend
+	conn      *websocket.Conn
+	subs      map[string]*upstreamSubscription
+	byID      map[string]*upstreamSubscription
+	pending   map[uint64]*pendingWSRequest
+	nextReqID uint64
+	// connectedC is closed when the backend is connected.
+	connectedC chan struct{}
+
+	writeMtx sync.Mutex
+	done     chan struct{}
+	wg       sync.WaitGroup
+}
+
+type SubscriptionMultiplexerOpt func(m *SubscriptionMultiplexer)
+
+// WithWSSubscriptionBufferSize sets the number of notifications buffered for
+// each client, past which slow clients are disconnected.
+func WithWSSubscriptionBufferSize(size int) SubscriptionMultiplexerOpt {
+	return func(m *SubscriptionMultiplexer) {
+		m.bufferSize = size
+	}
+}
+
+func NewSubscriptionMultiplexer(group *BackendGroup, opts ...SubscriptionMultiplexerOpt) *SubscriptionMultiplexer {
+	m := &SubscriptionMultiplexer{
+		groupName:  group.Name,
+		group:      group,
+		bufferSize: DefaultWSSubscriptionBufferSize,
+		subs:       make(map[string]*upstreamSubscription),
+		byID:       make(map[string]*upstreamSubscription),
+		pending:    make(map[uint64]*pendingWSRequest),
+		connectedC: make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+func (m *SubscriptionMultiplexer) Start() {
+	m.wg.Add(1)
+	go m.run()
+}
+
+// SetGroup replaces the backend group of the multiplexer, after the group
+// was rebuilt by a config reload. If the backend of the connection is no
+// longer part of the group, the connection is closed so that the
+// subscriptions move to the new backends.
+func (m *SubscriptionMultiplexer) SetGroup(group *BackendGroup) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.group = group
+	if m.conn == nil {
+		return
+	}
+	for _, backend := range group.Backends {
+		if backend == m.backend {
+			return
+		}
+	}
+	log.Info("reconnecting multiplexed subscriptions", "group", m.groupName, "backend", m.backend.Name)
+	m.conn.Close()
+}
+
+func (m *SubscriptionMultiplexer) Stop() {
+	close(m.done)
+	m.mtx.Lock()
+	if m.conn != nil {
+		m.conn.Close()
+	}
+	m.mtx.Unlock()
+	m.wg.Wait()
+}
+
+// run keeps a connection to the backend group open until Stop is called.
+func (m *SubscriptionMultiplexer) run() {
+	defer m.wg.Done()
+	for attempt := 0; ; attempt++ {
+		select {
+		case <-m.done:
+			return
+		default:
+		}
+
+		m.mtx.Lock()
+		group := m.group
+		m.mtx.Unlock()
+		backend, conn, err := group.dialWS(context.Background())
+		if err != nil {
+			log.Warn("error dialing multiplexed subscriptions backend", "group", m.groupName, "err", err)
+			select {
+			case <-m.done:
+				return
+			case <-time.After(calcBackoff(attempt)):
+			}
+			continue
+		}
+		attempt = -1
+
+		m.mtx.Lock()
+		m.conn = conn
+		m.backend = backend
+		close(m.connectedC)
+		m.mtx.Unlock()
+		log.Info("connected multiplexed subscriptions backend", "group", m.groupName, "backend", backend.Name)
+
+		readErrC := make(chan error, 1)
+		go func() {
+			readErrC <- m.readLoop(conn)
+		}()
+		m.resubscribe()
+
+		select {
+		case err := <-readErrC:
+			log.Warn("multiplexed subscriptions backend disconnected", "group", m.groupName, "backend", backend.Name, "err", err)
+			RecordWSSubscriptionReconnect(m.groupName)
+		case <-m.done:
+			conn.Close()
+			<-readErrC
+		}
+		m.disconnect(conn)
+		backend.releaseWSConn()
+	}
+}
+
+// disconnect fails the pending requests, and marks the upstream subscriptions
+// as disconnected until they're resubscribed.
+func (m *SubscriptionMultiplexer) disconnect(conn *websocket.Conn) {
+	conn.Close()
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.conn = nil
+	m.backend = nil
+	m.connectedC = make(chan struct{})
+	for id, p := range m.pending {
+		close(p.resC)
+		delete(m.pending, id)
+	}
+	for id, sub := range m.byID {
+		sub.id = ""
+		delete(m.byID, id)
+	}
+}
+
+func (m *SubscriptionMultiplexer) resubscribe() {
+	// Subscriptions of clients that subscribed after the reconnection are
+	// already resubscribed, and those in flight are retried by their caller.
+	m.mtx.Lock()
+	subs := make([]*upstreamSubscription, 0, len(m.subs))
+	for _, sub := range m.subs {
+		if sub.id == "" && sub.subscribing == nil {
+			subs = append(subs, sub)
+		}
+	}
+	m.mtx.Unlock()
+
+	for _, sub := range subs {
+		if err := m.ensureSubscribed(sub); err != nil {
+			log.Error("error resubscribing", "group", m.groupName, "params", string(sub.params), "err", err)
+			if errors.Is(err, ErrBackendOffline) {
+				return
+			}
+		}
+	}
+}
+
+func (m *SubscriptionMultiplexer) readLoop(conn *websocket.Conn) error {
+	for {
+		_, msg, err := conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+		m.handleMessage(msg)
+	}
+}
+
+func (m *SubscriptionMultiplexer) handleMessage(msg []byte) {
+	var upstreamMsg wsUpstreamMsg
+	if err := json.Unmarshal(msg, &upstreamMsg); err != nil {
+		log.Warn("error parsing multiplexed subscriptions backend message", "group", m.groupName, "err", err)
+		return
+	}
+
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	if upstreamMsg.Method == "eth_subscription" {
+		sub := m.byID[upstreamMsg.Params.Subscription]
+		if sub == nil {
+			return
+		}
+		for id, subscriber := range sub.subscribers {
+			subscriber.notify(subscriptionNotification(id, upstreamMsg.Params.Result))
+		}
+		return
+	}
+
+	reqID, err := strconv.ParseUint(string(upstreamMsg.ID), 10, 64)
+	if err != nil {
+		return
+	}
+	p := m.pending[reqID]
+	if p == nil {
+		return
+	}
+	delete(m.pending, reqID)
+	if upstreamMsg.Error == nil && p.onResult != nil {
+		p.onResult(upstreamMsg.Result)
+	}
+	p.resC <- &upstreamMsg
+}
+
+// request sends a request to the backend, and waits for its response. If
+// the backend is disconnected, it waits for it to reconnect first.
+func (m *SubscriptionMultiplexer) request(method string, params json.RawMessage, onResult func(json.RawMessage)) (*wsUpstreamMsg, error) {
+	timeout := time.NewTimer(wsSubscriptionRequestTimeout)
+	defer timeout.Stop()
+
+	m.mtx.Lock()
+	for m.conn == nil {
+		connectedC := m.connectedC
+		m.mtx.Unlock()
+		select {
+		case <-connectedC:
+		case <-timeout.C:
+			return nil, ErrBackendOffline
+		case <-m.done:
+			return nil, ErrBackendOffline
+		}
+		m.mtx.Lock()
+	}
+	conn := m.conn
+	m.nextReqID++
+	reqID := m.nextReqID
+	p := &pendingWSRequest{
+		resC:     make(chan *wsUpstreamMsg, 1),
+		onResult: onResult,
+	}
+	m.pending[reqID] = p
+	m.mtx.Unlock()
+
+	req := &RPCReq{
+		JSONRPC: JSONRPCVersion,
+		Method:  method,
+		Params:  params,
+		ID:      json.RawMessage(strconv.FormatUint(reqID, 10)),
+	}
+	m.writeMtx.Lock()
+	err := conn.WriteMessage(websocket.TextMessage, mustMarshalJSON(req))
+	m.writeMtx.Unlock()
+	if err != nil {
+		// The read loop fails too, and disconnects.
+		m.removePending(reqID)
+		return nil, ErrBackendOffline
+	}
+
+	select {
+	case res, ok := <-p.resC:
+		if !ok {
+			return nil, ErrBackendOffline
+		}
+		if res.Error != nil {
+			return nil, res.Error
+		}
+		return res, nil
+	case <-timeout.C:
+		// The result of a late response is still handled, so that it can
+		// be cleaned up. The request is forgotten once disconnected.
+		if onResult == nil {
+			m.removePending(reqID)
+		}
+		return nil, ErrGatewayTimeout
+	}
+}
+
+func (m *SubscriptionMultiplexer) removePending(reqID uint64) {
+	m.mtx.Lock()
+	delete(m.pending, reqID)
+	m.mtx.Unlock()
+}
+
+// ensureSubscribed subscribes sub to the backend, unless it already is. If
+// an eth_subscribe is already in flight for sub, it waits for it instead of
+// sending another one. The caller must not hold m.mtx.
+func (m *SubscriptionMultiplexer) ensureSubscribed(sub *upstreamSubscription) error {
+	for {
+		m.mtx.Lock()
+		// There is nothing to subscribe to once every client unsubscribed.
+		if sub.id != "" || len(sub.subscribers) == 0 {
+			m.mtx.Unlock()
+			return nil
+		}
+		if waitC := sub.subscribing; waitC != nil {
+			m.mtx.Unlock()
+			<-waitC
+			m.mtx.Lock()
+			err := sub.err
+			m.mtx.Unlock()
+			if err != nil {
+				return err
+			}
+			// The backend may have disconnected since, in which case
+			// the subscription is sent again.
+			continue
+		}
+		doneC := make(chan struct{})
+		sub.subscribing = doneC
+		m.mtx.Unlock()
+
+		err := m.subscribeUpstream(sub)
+
+		m.mtx.Lock()
+		sub.subscribing = nil
+		sub.err = err
+		close(doneC)
+		m.mtx.Unlock()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// subscribeUpstream subscribes to the backend. The upstream ID is registered
+// by the read loop, so that no notification is missed.
+func (m *SubscriptionMultiplexer) subscribeUpstream(sub *upstreamSubscription) error {
+	var subscribed bool
+	_, err := m.request("eth_subscribe", sub.params, func(result json.RawMessage) {
+		var id string
+		if err := json.Unmarshal(result, &id); err != nil || id == "" {
+			log.Warn("invalid upstream subscription ID", "group", m.groupName, "result", string(result))
+			return
+		}
+		// The response may come after the request timed out, after every
+		// client unsubscribed, or after another eth_subscribe succeeded.
+		// The upstream subscription isn't needed then.
+		if sub.id != "" || len(sub.subscribers) == 0 {
+			subscribed = sub.id != ""
+			go m.unsubscribeUpstream(id)
+			return
+		}
+		sub.id = id
+		m.byID[id] = sub
+		subscribed = true
+	})
+	if err != nil {
+		return err
+	}
+	if !subscribed {
+		return ErrBackendBadResponse
+	}
+	return nil
+}
+
+func (m *SubscriptionMultiplexer) NewSubscriber() *wsSubscriber {
+	return &wsSubscriber{
+		notifications: make(chan []byte, m.bufferSize),
+		overflow:      make(chan struct{}),
+		quit:          make(chan struct{}),
+		subs:          make(map[string]*upstreamSubscription),
+	}
+}
+
+// Subscribe subscribes the client to the eth_subscribe params, and returns
+// the ID of its subscription.
+func (m *SubscriptionMultiplexer) Subscribe(subscriber *wsSubscriber, params json.RawMessage) (string, error) {
+	key, ok := subscriptionKey(params)
+	if !ok {
+		return "", ErrInvalidParams("subscription can't be multiplexed")
+	}
+	id, err := newSubscriptionID()
+	if err != nil {
+		return "", err
+	}
+
+	// The subscription is registered before subscribing upstream, so that
+	// concurrent subscriptions with the same params share the eth_subscribe
+	// in flight, and the client gets the first notification.
+	m.mtx.Lock()
+	sub := m.subs[key]
+	newSub := sub == nil
+	if newSub {
+		sub = &upstreamSubscription{
+			key:         key,
+			params:      params,
+			subscribers: make(map[string]*wsSubscriber),
+		}
+		m.subs[key] = sub
+	}
+	sub.subscribers[id] = subscriber
+	subscriber.subs[id] = sub
+	m.mtx.Unlock()
+	if newSub {
+		RecordWSSubscriptions(m.groupName, 1, 1)
+	} else {
+		RecordWSSubscriptions(m.groupName, 1, 0)
+	}
+
+	if err := m.ensureSubscribed(sub); err != nil {
+		var upstreamID string
+		m.mtx.Lock()
+		// The client may have disconnected in the meantime.
+		if subscriber.subs[id] == sub {
+			upstreamID = m.removeSubscription(subscriber, id, sub)
+		}
+		m.mtx.Unlock()
+		m.unsubscribeUpstream(upstreamID)
+		return "", err
+	}
+	return id, nil
+}
+
+// Unsubscribe cancels a subscription of the client, and returns false if
+// the client has no subscription with the ID.
+func (m *SubscriptionMultiplexer) Unsubscribe(subscriber *wsSubscriber, id string) bool {
+	m.mtx.Lock()
+	sub := subscriber.subs[id]
+	if sub == nil {
+		m.mtx.Unlock()
+		return false
+	}
+	upstreamID := m.removeSubscription(subscriber, id, sub)
+	m.mtx.Unlock()
+
+	m.unsubscribeUpstream(upstreamID)
+	return true
+}
+
+// RemoveSubscriber cancels all the subscriptions of a client that disconnected.
+func (m *SubscriptionMultiplexer) RemoveSubscriber(subscriber *wsSubscriber) {
+	close(subscriber.quit)
+
+	m.mtx.Lock()
+	upstreamIDs := make([]string, 0)
+	for id, sub := range subscriber.subs {
+		if upstreamID := m.removeSubscription(subscriber, id, sub); upstreamID != "" {
+			upstreamIDs = append(upstreamIDs, upstreamID)
+		}
+	}
+	m.mtx.Unlock()
+
+	for _, upstreamID := range upstreamIDs {
+		m.unsubscribeUpstream(upstreamID)
+	}
+}
+
+// removeSubscription removes a client subscription, and the upstream
+// subscription if it was the last one. It returns the ID of the upstream
+// subscription to cancel, if any. The caller must hold m.mtx.
+func (m *SubscriptionMultiplexer) removeSubscription(subscriber *wsSubscriber, id string, sub *upstreamSubscription) string {
+	delete(subscriber.subs, id)
+	delete(sub.subscribers, id)
+	if len(sub.subscribers) > 0 {
+		RecordWSSubscriptions(m.groupName, -1, 0)
+		return ""
+	}
+
+	RecordWSSubscriptions(m.groupName, -1, -1)
+	// A new subscription with the same params may have replaced it.
+	if m.subs[sub.key] == sub {
+		delete(m.subs, sub.key)
+	}
+	upstreamID := sub.id
+	if upstreamID != "" {
+		delete(m.byID, upstreamID)
+	}
+	return upstreamID
+}
+
+func (m *SubscriptionMultiplexer) unsubscribeUpstream(upstreamID string) {
+	if upstreamID == "" {
+		return
+	}
+	params := mustMarshalJSON([]string{upstreamID})
+	if _, err := m.request("eth_unsubscribe", params, nil); err != nil {
+		log.Warn("error unsubscribing", "group", m.groupName, "id", upstreamID, "err", err)
+	}
+}
+
+// subscriptionKey returns the key identifying equivalent eth_subscribe
+// params, or false if they can't be multiplexed. Only newHeads and logs
+// subscriptions are multiplexed.
+func subscriptionKey(params json.RawMessage) (string, bool) {
+	var args []json.RawMessage
+	if err := json.Unmarshal(params, &args); err != nil || len(args) == 0 {
+		return "", false
+	}
+	var kind string
+	if err := json.Unmarshal(args[0], &kind); err != nil {
+		return "", false
+	}
+
+	switch kind {
+	case "newHeads":
+		if len(args) != 1 {
+			return "", false
+		}
+		return kind, true
+	case "logs":
+		if len(args) == 1 {
+			return kind + ":{}", true
+		}
+		if len(args) != 2 {
+			return "", false
+		}
+		var filter map[string]interface{}
+		if err := json.Unmarshal(args[1], &filter); err != nil || filter == nil {
+			return "", false
+		}
+		// Addresses and topics are hex, so they're compared case-insensitively.
+		// Map keys are sorted when marshaled.
+		return kind + ":" + string(mustMarshalJSON(lowercaseStrings(filter))), true
+	default:
+		return "", false
+	}
+}
+
+func lowercaseStrings(in interface{}) interface{} {
+	switch v := in.(type) {
+	case string:
+		return strings.ToLower(v)
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, elem := range v {
+			out[i] = lowercaseStrings(elem)
+		}
+		return out
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, elem := range v {
+			out[key] = lowercaseStrings(elem)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+func newSubscriptionID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hexutil.Encode(b), nil
+}
+
+func subscriptionNotification(id string, result json.RawMessage) []byte {
+	return []byte(`{"jsonrpc":"2.0","method":"eth_subscription","params":{"subscription":"` + id + `","result":` + string(result) + `}}`)
+}
@@ -0,0 +1,61 @@
+package proxyd
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubscriptionKey(t *testing.T) {
+	tests := []struct {
+		name   string
+		params string
+		key    string
+		ok     bool
+	}{
+		{"newHeads", `["newHeads"]`, "newHeads", true},
+		{"newHeads with args", `["newHeads", {}]`, "", false},
+		{"logs without filter", `["logs"]`, "logs:{}", true},
+		{
+			"logs",
+			`["logs", {"topics": ["0xABCD"], "address": "0xAbC"}]`,
+			`logs:{"address":"0xabc","topics":["0xabcd"]}`,
+			true,
+		},
+		{"logs with invalid filter", `["logs", "0x1"]`, "", false},
+		{"logs with null filter", `["logs", null]`, "", false},
+		{"logs with extra args", `["logs", {}, {}]`, "", false},
+		{"newPendingTransactions", `["newPendingTransactions"]`, "", false},
+		{"no params", `[]`, "", false},
+		{"invalid params", `{}`, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, ok := subscriptionKey(json.RawMessage(tt.params))
+			require.Equal(t, tt.ok, ok)
+			require.Equal(t, tt.key, key)
+		})
+	}
+
+	key1, _ := subscriptionKey(json.RawMessage(`["logs", {"address": ["0xA", "0xB"], "topics": [null, "0xC"]}]`))
+	key2, _ := subscriptionKey(json.RawMessage(`["logs",{"topics":[null,"0xc"],"address":["0xa","0xb"]}]`))
+	require.Equal(t, key1, key2)
+}
+
+func TestSubscriptionNotification(t *testing.T) {
+	msg := subscriptionNotification("0x1234", json.RawMessage(`{"number":"0x1"}`))
+	var notification struct {
+		JSONRPC string `json:"jsonrpc"`
+		Method  string `json:"method"`
+		Params  struct {
+			Subscription string          `json:"subscription"`
+			Result       json.RawMessage `json:"result"`
+		} `json:"params"`
+	}
+	require.NoError(t, json.Unmarshal(msg, &notification))
+	require.Equal(t, "2.0", notification.JSONRPC)
+	require.Equal(t, "eth_subscription", notification.Method)
+	require.Equal(t, "0x1234", notification.Params.Subscription)
+	require.JSONEq(t, `{"number":"0x1"}`, string(notification.Params.Result))
+}